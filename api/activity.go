@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// ActiveSession describes one in-flight deploy/task session for the admin
+// activity endpoint.
+type ActiveSession struct {
+	SessionID  string    `json:"session_id"`
+	ConfigPath string    `json:"config_path"`
+	Host       string    `json:"host"`
+	UserID     int       `json:"user_id,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// activeDetails returns the in-flight sessions, oldest first. A userID > 0
+// restricts the list to that user's sessions.
+func (r *sessionRegistry) activeDetails(userID int) []ActiveSession {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	sessions := make([]ActiveSession, 0, len(r.active))
+	for id, info := range r.active {
+		if userID > 0 && info.UserID != userID {
+			continue
+		}
+		sessions = append(sessions, ActiveSession{
+			SessionID:  id,
+			ConfigPath: info.ConfigPath,
+			Host:       info.Host,
+			UserID:     info.UserID,
+			StartedAt:  info.StartedAt,
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+	return sessions
+}
+
+// handleActivity returns the currently running deploy/task sessions together
+// with the server's concurrency limit. Admin only.
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	response := map[string]any{
+		"sessions":               s.sessions.activeDetails(0),
+		"running":                s.limiter.running(),
+		"max_concurrent_deploys": s.limiter.capacity(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleSessions returns the requesting user's own in-flight deploy/task
+// sessions, so a client that lost its WebSocket can discover which session
+// IDs are still running (or recently finished and replayable) and reconnect.
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"sessions": s.sessions.activeDetails(claims.UserID),
+	})
+}
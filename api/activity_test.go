@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDeployReturns429AtConcurrencyLimit(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	// Fill the single slot so the next deploy is over the limit
+	s.limiter = newDeployLimiter(1)
+	s.limiter.tryAcquire()
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, deployRequest(t, "/api/deploy", configPath, "server1", 1))
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("deploy over the limit status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "1 of 1") {
+		t.Errorf("429 body should report occupancy, got %q", body)
+	}
+}
+
+func TestActivityEndpointListsActiveSessions(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.sessions = newSessionRegistry()
+	s.limiter = newDeployLimiter(5)
+
+	s.sessions.begin("session-1", "/tmp/nyati.yaml", "server1", 1)
+	defer s.sessions.end("session-1")
+	s.limiter.tryAcquire()
+
+	rec := httptest.NewRecorder()
+	s.handleActivity(rec, auditRequest(t, "GET", "/api/admin/activity", nil, 1, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("activity status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Sessions []ActiveSession `json:"sessions"`
+		Running  int             `json:"running"`
+		Max      int             `json:"max_concurrent_deploys"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("activity response is not JSON: %v", err)
+	}
+
+	if len(resp.Sessions) != 1 {
+		t.Fatalf("sessions = %v, want one entry", resp.Sessions)
+	}
+	session := resp.Sessions[0]
+	if session.SessionID != "session-1" || session.ConfigPath != "/tmp/nyati.yaml" || session.Host != "server1" {
+		t.Errorf("session = %+v, want session-1 deploying /tmp/nyati.yaml to server1", session)
+	}
+	if session.StartedAt.IsZero() {
+		t.Error("session start time should be set")
+	}
+	if resp.Running != 1 || resp.Max != 5 {
+		t.Errorf("occupancy = %d/%d, want 1/5", resp.Running, resp.Max)
+	}
+}
+
+func TestActivityEndpointRequiresAdmin(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.sessions = newSessionRegistry()
+	s.limiter = newDeployLimiter(5)
+
+	rec := httptest.NewRecorder()
+	s.handleActivity(rec, auditRequest(t, "GET", "/api/admin/activity", nil, 2, nil))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("activity for non-admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestSessionsEndpointListsOnlyOwnSessions(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.sessions = newSessionRegistry()
+
+	s.sessions.begin("mine", "/tmp/nyati.yaml", "server1", 1)
+	defer s.sessions.end("mine")
+	s.sessions.begin("theirs", "/tmp/other.yaml", "server1", 2)
+	defer s.sessions.end("theirs")
+
+	rec := httptest.NewRecorder()
+	s.handleSessions(rec, auditRequest(t, "GET", "/api/sessions", nil, 1, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("sessions status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Sessions []ActiveSession `json:"sessions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("sessions response is not JSON: %v", err)
+	}
+	if len(resp.Sessions) != 1 || resp.Sessions[0].SessionID != "mine" {
+		t.Errorf("sessions = %+v, want only the caller's session", resp.Sessions)
+	}
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/zechtz/nyatictl/cache"
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// adminStatsCacheTTL is how long a computed admin stats snapshot is served
+// before the aggregation queries run again.
+const adminStatsCacheTTL = 60 * time.Second
+
+// adminStatsCache holds one snapshot per requested range so the dashboard can
+// poll without hammering the database.
+var adminStatsCache = cache.NewCache(adminStatsCacheTTL)
+
+// adminTopLimit caps the per-config and per-host rankings in the admin stats.
+const adminTopLimit = 5
+
+// StatusCounts breaks a set of deployments down by outcome.
+type StatusCounts struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Pending   int `json:"pending"`
+}
+
+// ConfigFailureRate is one entry in the per-config failure ranking.
+type ConfigFailureRate struct {
+	ConfigPath  string  `json:"config_path"`
+	Deploys     int     `json:"deploys"`
+	FailureRate float64 `json:"failure_rate"` // Failed / finished, 0 when nothing finished
+}
+
+// HostDeployCount is one entry in the busiest-hosts ranking.
+type HostDeployCount struct {
+	Host    string `json:"host"`
+	Deploys int    `json:"deploys"`
+}
+
+// DurationTrendPoint is the average deploy duration for one calendar day.
+type DurationTrendPoint struct {
+	Day           string `json:"day"` // YYYY-MM-DD, UTC
+	AvgDurationMs int64  `json:"avg_duration_ms"`
+}
+
+// AdminStats aggregates server-wide deployment and webhook activity for the
+// admin dashboard.
+type AdminStats struct {
+	Range              string               `json:"range"`                // The effective range, echoed back
+	Last24h            StatusCounts         `json:"last_24h"`             // Deployments started in the last 24 hours
+	InRange            StatusCounts         `json:"in_range"`             // Deployments started within the requested range
+	FailureRates       []ConfigFailureRate  `json:"failure_rates"`        // Worst-failing configs in range, highest rate first
+	BusiestHosts       []HostDeployCount    `json:"busiest_hosts"`        // Most-deployed-to hosts in range
+	DurationTrend      []DurationTrendPoint `json:"duration_trend"`       // Per-day average duration of finished deploys
+	ActiveUsers        int                  `json:"active_users"`         // Distinct users who deployed in range
+	WebhookDeliveries  int                  `json:"webhook_deliveries"`   // Delivery attempts in range, muted ones excluded
+	WebhookSuccessRate float64              `json:"webhook_success_rate"` // Successful / attempted, 0 when none attempted
+	WebhooksSuppressed int                  `json:"webhooks_suppressed"`  // Deliveries swallowed by the global mute in range
+	WebhookMute        WebhookMuteStatus    `json:"webhook_mute"`         // Current global mute state, stamped outside the cache
+}
+
+// statsCutoff formats a window start the way SQLite stores CURRENT_TIMESTAMP
+// (UTC text), so created_at comparisons work.
+func statsCutoff(window time.Duration) string {
+	return time.Now().UTC().Add(-window).Format("2006-01-02 15:04:05")
+}
+
+// statusCounts aggregates all deployments started since the cutoff by outcome.
+func (s *Server) statusCounts(cutoff string) (StatusCounts, error) {
+	var counts StatusCounts
+	err := s.db.QueryRow(
+		`SELECT COUNT(*),
+			COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END), 0)
+		FROM deployments WHERE created_at >= ?`,
+		cutoff,
+	).Scan(&counts.Total, &counts.Succeeded, &counts.Failed, &counts.Pending)
+	if err != nil {
+		return StatusCounts{}, fmt.Errorf("failed to count deployments: %v", err)
+	}
+	return counts, nil
+}
+
+// adminStats computes the server-wide dashboard aggregates over the given
+// window. All heavy lifting stays in SQL; only the already-grouped rankings
+// are materialized.
+//
+// Parameters:
+//   - window: how far back from now to look
+//
+// Returns:
+//   - AdminStats: the aggregated metrics
+//   - error: if a database query fails
+func (s *Server) adminStats(window time.Duration) (AdminStats, error) {
+	stats := AdminStats{
+		Range:         window.String(),
+		FailureRates:  []ConfigFailureRate{},
+		BusiestHosts:  []HostDeployCount{},
+		DurationTrend: []DurationTrendPoint{},
+	}
+	cutoff := statsCutoff(window)
+
+	var err error
+	if stats.Last24h, err = s.statusCounts(statsCutoff(24 * time.Hour)); err != nil {
+		return AdminStats{}, err
+	}
+	if stats.InRange, err = s.statusCounts(cutoff); err != nil {
+		return AdminStats{}, err
+	}
+
+	// Failure rate per config, worst first; configs with nothing finished yet
+	// are excluded since they have no rate to report
+	rows, err := s.db.Query(
+		`SELECT config_path, COUNT(*) AS deploys,
+			CAST(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END) AS REAL) /
+				SUM(CASE WHEN status != 'pending' THEN 1 ELSE 0 END) AS failure_rate
+		FROM deployments WHERE created_at >= ?
+		GROUP BY config_path
+		HAVING SUM(CASE WHEN status != 'pending' THEN 1 ELSE 0 END) > 0
+		ORDER BY failure_rate DESC, deploys DESC, config_path LIMIT ?`,
+		cutoff, adminTopLimit,
+	)
+	if err != nil {
+		return AdminStats{}, fmt.Errorf("failed to rank config failure rates: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry ConfigFailureRate
+		if err := rows.Scan(&entry.ConfigPath, &entry.Deploys, &entry.FailureRate); err != nil {
+			return AdminStats{}, fmt.Errorf("failed to scan config failure rate: %v", err)
+		}
+		stats.FailureRates = append(stats.FailureRates, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return AdminStats{}, fmt.Errorf("failed to rank config failure rates: %v", err)
+	}
+
+	// Busiest hosts in range
+	hostRows, err := s.db.Query(
+		`SELECT host, COUNT(*) AS deploys
+		FROM deployments WHERE created_at >= ?
+		GROUP BY host ORDER BY deploys DESC, host LIMIT ?`,
+		cutoff, adminTopLimit,
+	)
+	if err != nil {
+		return AdminStats{}, fmt.Errorf("failed to rank hosts: %v", err)
+	}
+	defer hostRows.Close()
+	for hostRows.Next() {
+		var entry HostDeployCount
+		if err := hostRows.Scan(&entry.Host, &entry.Deploys); err != nil {
+			return AdminStats{}, fmt.Errorf("failed to scan host ranking: %v", err)
+		}
+		stats.BusiestHosts = append(stats.BusiestHosts, entry)
+	}
+	if err := hostRows.Err(); err != nil {
+		return AdminStats{}, fmt.Errorf("failed to rank hosts: %v", err)
+	}
+
+	// Average duration of finished deploys per calendar day, oldest first
+	trendRows, err := s.db.Query(
+		`SELECT date(created_at), CAST(AVG(duration_ms) AS INTEGER)
+		FROM deployments WHERE created_at >= ? AND status != 'pending'
+		GROUP BY date(created_at) ORDER BY date(created_at)`,
+		cutoff,
+	)
+	if err != nil {
+		return AdminStats{}, fmt.Errorf("failed to compute duration trend: %v", err)
+	}
+	defer trendRows.Close()
+	for trendRows.Next() {
+		var point DurationTrendPoint
+		if err := trendRows.Scan(&point.Day, &point.AvgDurationMs); err != nil {
+			return AdminStats{}, fmt.Errorf("failed to scan duration trend: %v", err)
+		}
+		stats.DurationTrend = append(stats.DurationTrend, point)
+	}
+	if err := trendRows.Err(); err != nil {
+		return AdminStats{}, fmt.Errorf("failed to compute duration trend: %v", err)
+	}
+
+	if err := s.db.QueryRow(
+		"SELECT COUNT(DISTINCT user_id) FROM deployments WHERE created_at >= ?",
+		cutoff,
+	).Scan(&stats.ActiveUsers); err != nil {
+		return AdminStats{}, fmt.Errorf("failed to count active users: %v", err)
+	}
+
+	// Muted rows are suppressions, not attempts, so they count separately and
+	// never drag the success rate down
+	var delivered int
+	if err := s.db.QueryRow(
+		`SELECT COALESCE(SUM(CASE WHEN status != 'muted' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN success THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN status = 'muted' THEN 1 ELSE 0 END), 0)
+		FROM webhook_deliveries WHERE created_at >= ?`,
+		cutoff,
+	).Scan(&stats.WebhookDeliveries, &delivered, &stats.WebhooksSuppressed); err != nil {
+		return AdminStats{}, fmt.Errorf("failed to aggregate webhook deliveries: %v", err)
+	}
+	if stats.WebhookDeliveries > 0 {
+		stats.WebhookSuccessRate = float64(delivered) / float64(stats.WebhookDeliveries)
+	}
+
+	return stats, nil
+}
+
+// handleAdminStats returns server-wide dashboard aggregates, selected by
+// GET /api/admin/stats?range=7d. Admin only; non-admin users get the reduced,
+// per-user view from GET /api/stats instead.
+//
+// Results are cached per range for adminStatsCacheTTL, so consecutive
+// dashboard polls don't re-run the aggregation queries.
+func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	window, err := parseStatsWindow(r.URL.Query().Get("range"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cacheKey := "admin_stats:" + window.String()
+	var stats AdminStats
+	if cached, ok := adminStatsCache.Get(cacheKey); ok {
+		stats = cached.(AdminStats)
+	} else {
+		computed, err := s.adminStats(window)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to compute admin stats: %v", err))
+			http.Error(w, "Failed to compute stats", http.StatusInternalServerError)
+			return
+		}
+		adminStatsCache.Set(cacheKey, computed)
+		stats = computed
+	}
+
+	// The mute state is stamped after the cache lookup so an expired or lifted
+	// mute is visible immediately, not a cache TTL later
+	stats.WebhookMute = webhookMuteStatus(s.db.DB)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
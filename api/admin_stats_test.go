@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newAdminStatsTestServer extends the deployment test schema with the users
+// and webhook_deliveries tables the admin stats queries touch, and seeds an
+// admin (ID 1) and a regular user (ID 2).
+func newAdminStatsTestServer(t *testing.T) *Server {
+	t.Helper()
+	adminStatsCache.Clear()
+
+	s := newDeploymentTestServer(t)
+	schema := []string{
+		`CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL,
+			is_admin INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE TABLE webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook_id INTEGER NOT NULL,
+			event TEXT NOT NULL,
+			status_code INTEGER NOT NULL DEFAULT 0,
+			success INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`INSERT INTO users (id, email, is_admin) VALUES (1, 'admin@example.com', 1), (2, 'user@example.com', 0);`,
+	}
+	for _, stmt := range schema {
+		if _, err := s.db.Exec(stmt); err != nil {
+			t.Fatalf("failed to create admin stats schema: %v", err)
+		}
+	}
+	return s
+}
+
+func TestAdminStatsAggregates(t *testing.T) {
+	s := newAdminStatsTestServer(t)
+
+	// Two users deploying two configs to two hosts; /tmp/b.yaml fails half
+	// the time, /tmp/a.yaml never fails
+	seedDeployment(t, s, 1, "/tmp/a.yaml", "success", 100, time.Hour)
+	seedDeployment(t, s, 1, "/tmp/a.yaml", "success", 300, 2*time.Hour)
+	seedDeployment(t, s, 2, "/tmp/b.yaml", "error", 200, 25*time.Hour)
+	seedDeployment(t, s, 2, "/tmp/b.yaml", "success", 400, 26*time.Hour)
+	seedDeployment(t, s, 2, "/tmp/b.yaml", "pending", 0, time.Minute)
+
+	// Outside the range
+	seedDeployment(t, s, 1, "/tmp/a.yaml", "error", 999, 8*24*time.Hour)
+
+	if _, err := s.db.Exec(
+		"INSERT INTO webhook_deliveries (webhook_id, event, status_code, success) VALUES (1, 'deployment', 200, 1), (1, 'deployment', 200, 1), (1, 'deployment', 500, 0), (2, 'deployment', 0, 0)",
+	); err != nil {
+		t.Fatalf("failed to seed webhook deliveries: %v", err)
+	}
+	// A delivery suppressed by the global mute: counted separately, never
+	// part of the attempt count or the success rate
+	if _, err := s.db.Exec(
+		"INSERT INTO webhook_deliveries (webhook_id, event, status) VALUES (1, 'deployment', 'muted')",
+	); err != nil {
+		t.Fatalf("failed to seed muted webhook delivery: %v", err)
+	}
+
+	stats, err := s.adminStats(7 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("adminStats failed: %v", err)
+	}
+
+	if stats.InRange.Total != 5 || stats.InRange.Succeeded != 3 || stats.InRange.Failed != 1 || stats.InRange.Pending != 1 {
+		t.Errorf("in-range counts = %+v, want total 5, 3/1/1", stats.InRange)
+	}
+	if stats.Last24h.Total != 3 {
+		t.Errorf("last-24h total = %d, want 3", stats.Last24h.Total)
+	}
+	if stats.ActiveUsers != 2 {
+		t.Errorf("active users = %d, want 2", stats.ActiveUsers)
+	}
+
+	if len(stats.FailureRates) != 2 {
+		t.Fatalf("failure rates = %d entries, want 2", len(stats.FailureRates))
+	}
+	if stats.FailureRates[0].ConfigPath != "/tmp/b.yaml" || stats.FailureRates[0].FailureRate != 0.5 {
+		t.Errorf("worst config = %+v, want /tmp/b.yaml at 0.5", stats.FailureRates[0])
+	}
+	if stats.FailureRates[1].ConfigPath != "/tmp/a.yaml" || stats.FailureRates[1].FailureRate != 0 {
+		t.Errorf("second config = %+v, want /tmp/a.yaml at 0", stats.FailureRates[1])
+	}
+
+	if len(stats.BusiestHosts) != 1 || stats.BusiestHosts[0].Host != "server1" || stats.BusiestHosts[0].Deploys != 5 {
+		t.Errorf("busiest hosts = %+v, want server1 with 5 deploys", stats.BusiestHosts)
+	}
+
+	if len(stats.DurationTrend) == 0 {
+		t.Error("duration trend is empty, want at least one per-day point")
+	}
+
+	if stats.WebhookDeliveries != 4 || stats.WebhookSuccessRate != 0.5 {
+		t.Errorf("webhook deliveries = %d at rate %v, want 4 at 0.5",
+			stats.WebhookDeliveries, stats.WebhookSuccessRate)
+	}
+	if stats.WebhooksSuppressed != 1 {
+		t.Errorf("suppressed deliveries = %d, want 1", stats.WebhooksSuppressed)
+	}
+}
+
+func TestHandleAdminStatsRequiresAdmin(t *testing.T) {
+	s := newAdminStatsTestServer(t)
+
+	req := httptest.NewRequest("GET", "/api/admin/stats", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 2}))
+
+	rec := httptest.NewRecorder()
+	s.handleAdminStats(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("non-admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleAdminStatsCachesSnapshots(t *testing.T) {
+	s := newAdminStatsTestServer(t)
+	seedDeployment(t, s, 1, "/tmp/a.yaml", "success", 100, time.Hour)
+
+	fetch := func() AdminStats {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/admin/stats?range=30d", nil)
+		req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+		rec := httptest.NewRecorder()
+		s.handleAdminStats(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("admin stats status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		var stats AdminStats
+		if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+			t.Fatalf("failed to decode admin stats: %v", err)
+		}
+		return stats
+	}
+
+	first := fetch()
+	if first.InRange.Total != 1 {
+		t.Fatalf("first snapshot total = %d, want 1", first.InRange.Total)
+	}
+
+	// A row added within the cache TTL must not show up yet
+	seedDeployment(t, s, 1, "/tmp/a.yaml", "success", 100, time.Hour)
+	second := fetch()
+	if second.InRange.Total != 1 {
+		t.Errorf("cached snapshot total = %d, want the cached 1", second.InRange.Total)
+	}
+}
+
+func TestSendWebhookRecordsDeliveryOutcome(t *testing.T) {
+	s := newAdminStatsTestServer(t)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer backend.Close()
+
+	sendWebhook(s.db.DB, Webhook{ID: 7, URL: backend.URL}, WebhookPayload{Event: "deployment"})
+
+	var statusCode int
+	var success bool
+	if err := s.db.QueryRow(
+		"SELECT status_code, success FROM webhook_deliveries WHERE webhook_id = 7",
+	).Scan(&statusCode, &success); err != nil {
+		t.Fatalf("failed to read recorded delivery: %v", err)
+	}
+	if statusCode != http.StatusInternalServerError || success {
+		t.Errorf("recorded delivery = (%d, %v), want (500, false)", statusCode, success)
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// auditEnabledSetting is the settings-table key that toggles the admin audit
+// log. The log is on by default; operators can disable it by setting the
+// value to "false".
+const auditEnabledSetting = "audit_log_enabled"
+
+// AuditEntry is a single recorded admin action, as returned by the audit
+// query endpoint.
+type AuditEntry struct {
+	ID         int    `json:"id"`
+	UserID     int    `json:"user_id"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// auditEnabled reports whether audit logging is turned on. The toggle lives
+// in the settings table so it can be changed without a restart.
+func (s *Server) auditEnabled() bool {
+	return GetSetting(s.db.DB, auditEnabledSetting, "true") != "false"
+}
+
+// recordAudit writes one audit entry for an admin action.
+//
+// The write is synchronous (not routed through the write queue) so the entry
+// is durable before the mutating handler responds; audit volume is low enough
+// that contention is not a concern. A failed write is logged but never fails
+// the request that triggered it.
+//
+// Parameters:
+//   - userID: the acting user (from JWT claims)
+//   - action: what was done, e.g. "create", "update", "delete"
+//   - targetType: what kind of object was affected, e.g. "webhook"
+//   - targetID: identifier of the affected object
+func (s *Server) recordAudit(userID int, action, targetType, targetID string) {
+	if !s.auditEnabled() {
+		return
+	}
+
+	_, err := s.db.Exec(
+		"INSERT INTO admin_audit (user_id, action, target_type, target_id, created_at) VALUES (?, ?, ?, ?, ?)",
+		userID, action, targetType, targetID, time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to record audit entry (%s %s %s): %v", action, targetType, targetID, err))
+	}
+}
+
+// isAdmin reports whether the given user has the admin flag set.
+func (s *Server) isAdmin(userID int) bool {
+	var admin bool
+	if err := s.db.QueryRow("SELECT is_admin FROM users WHERE id = ?", userID).Scan(&admin); err != nil {
+		return false
+	}
+	return admin
+}
+
+// handleAuditLog returns recent audit entries, newest first. Admin only.
+//
+// Query parameters:
+//   - limit: maximum number of entries to return (default 100, capped at 500)
+func (s *Server) handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		if n > 500 {
+			n = 500
+		}
+		limit = n
+	}
+
+	rows, err := s.db.Query(
+		"SELECT id, user_id, action, target_type, target_id, created_at FROM admin_audit ORDER BY id DESC LIMIT ?",
+		limit,
+	)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to query audit log: %v", err))
+		http.Error(w, "Failed to query audit log", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Action, &e.TargetType, &e.TargetID, &e.CreatedAt); err != nil {
+			http.Error(w, "Failed to read audit log", http.StatusInternalServerError)
+			return
+		}
+		entries = append(entries, e)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/zechtz/nyatictl/db"
+)
+
+// newAuditTestServer creates a Server backed by an in-memory database with
+// user 1 as admin and user 2 as a regular user.
+func newAuditTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	rawDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	rawDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { rawDB.Close() })
+
+	schema := []string{
+		`CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL UNIQUE,
+			password TEXT NOT NULL,
+			is_admin INTEGER NOT NULL DEFAULT 0,
+			created_at TEXT
+		);`,
+		`CREATE TABLE settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		);`,
+		`CREATE TABLE admin_audit (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			action TEXT NOT NULL,
+			target_type TEXT NOT NULL,
+			target_id TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE webhooks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			description TEXT,
+			url TEXT NOT NULL,
+			secret TEXT,
+			event TEXT NOT NULL,
+			tag_filter TEXT NOT NULL DEFAULT '',
+			algorithm TEXT NOT NULL DEFAULT 'sha256',
+			tls_server_name TEXT NOT NULL DEFAULT '',
+			host_header TEXT NOT NULL DEFAULT '',
+			user_id INTEGER NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT 1,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE environments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT,
+			is_current BOOLEAN DEFAULT 0,
+			user_id INTEGER NOT NULL,
+			wrapped_key TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE environment_variables (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			environment_id INTEGER NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT,
+			is_secret BOOLEAN DEFAULT 0,
+			encrypted_value TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (environment_id, key)
+		);`,
+		`INSERT INTO users (email, password, is_admin) VALUES ('admin@example.com', 'x', 1);`,
+		`INSERT INTO users (email, password, is_admin) VALUES ('user@example.com', 'x', 0);`,
+	}
+	for _, stmt := range schema {
+		if _, err := rawDB.Exec(stmt); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+
+	return &Server{db: db.NewMetricsDB(rawDB)}
+}
+
+// auditRequest builds an authenticated request with an optional JSON body and
+// mux URL variables.
+func auditRequest(t *testing.T, method, url string, body any, userID int, urlVars map[string]string) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("failed to marshal request body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, url, &buf)
+	ctx := context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: userID})
+	req = req.WithContext(ctx)
+	if urlVars != nil {
+		req = mux.SetURLVars(req, urlVars)
+	}
+	return req
+}
+
+// auditEntries reads all audit rows, oldest first.
+func auditEntries(t *testing.T, s *Server) []AuditEntry {
+	t.Helper()
+
+	rows, err := s.db.Query("SELECT user_id, action, target_type, target_id FROM admin_audit ORDER BY id")
+	if err != nil {
+		t.Fatalf("failed to query admin_audit: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.UserID, &e.Action, &e.TargetType, &e.TargetID); err != nil {
+			t.Fatalf("failed to scan audit entry: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestWebhookMutationsWriteAuditEntries(t *testing.T) {
+	s := newAuditTestServer(t)
+	webhook := map[string]any{"name": "deploys", "url": "https://example.com/hook", "event": "deployment.succeeded"}
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleCreateWebhook)(rec, auditRequest(t, "POST", "/api/webhooks", webhook, 1, nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create webhook status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	rec = httptest.NewRecorder()
+	s.withUser(s.HandleUpdateWebhook)(rec, auditRequest(t, "PUT", "/api/webhooks/1", webhook, 1, map[string]string{"id": "1"}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update webhook status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	s.withUser(s.HandleDeleteWebhook)(rec, auditRequest(t, "DELETE", "/api/webhooks/1", nil, 1, map[string]string{"id": "1"}))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete webhook status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	entries := auditEntries(t, s)
+	if len(entries) != 3 {
+		t.Fatalf("audit log has %d entries, want 3: %v", len(entries), entries)
+	}
+	for i, action := range []string{"create", "update", "delete"} {
+		e := entries[i]
+		if e.UserID != 1 || e.Action != action || e.TargetType != "webhook" || e.TargetID != "1" {
+			t.Errorf("entry %d = %+v, want user 1 %s webhook 1", i, e, action)
+		}
+	}
+}
+
+func TestEnvironmentMutationsWriteAuditEntries(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.handleCreateEnvironment(rec, auditRequest(t, "POST", "/api/env/create",
+		map[string]string{"name": "staging"}, 1, nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create environment status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleDeleteEnvironment(rec, auditRequest(t, "DELETE", "/api/env/delete/1", nil, 1, map[string]string{"id": "1"}))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete environment status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	entries := auditEntries(t, s)
+	if len(entries) != 2 {
+		t.Fatalf("audit log has %d entries, want 2: %v", len(entries), entries)
+	}
+	for i, action := range []string{"create", "delete"} {
+		e := entries[i]
+		if e.UserID != 1 || e.Action != action || e.TargetType != "environment" || e.TargetID != "1" {
+			t.Errorf("entry %d = %+v, want user 1 %s environment 1", i, e, action)
+		}
+	}
+}
+
+func TestAuditLogDisabledViaSetting(t *testing.T) {
+	s := newAuditTestServer(t)
+	if err := SetSetting(s.db.DB, auditEnabledSetting, "false"); err != nil {
+		t.Fatalf("failed to disable audit log: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleCreateWebhook)(rec, auditRequest(t, "POST", "/api/webhooks",
+		map[string]any{"name": "deploys", "url": "https://example.com/hook", "event": "deployment.succeeded"}, 1, nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create webhook status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	if entries := auditEntries(t, s); len(entries) != 0 {
+		t.Errorf("audit log should be empty when disabled, got %v", entries)
+	}
+}
+
+func TestAuditLogEndpointIsAdminOnly(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.recordAudit(1, "create", "webhook", "1")
+
+	// Regular users are rejected
+	rec := httptest.NewRecorder()
+	s.handleAuditLog(rec, auditRequest(t, "GET", "/api/audit", nil, 2, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("audit log for non-admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	// Admins see the entries
+	rec = httptest.NewRecorder()
+	s.handleAuditLog(rec, auditRequest(t, "GET", "/api/audit", nil, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("audit log for admin status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var entries []AuditEntry
+	if err := json.NewDecoder(rec.Body).Decode(&entries); err != nil {
+		t.Fatalf("audit log did not return JSON: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != "create" || entries[0].TargetType != "webhook" {
+		t.Errorf("audit log entries = %v, want one create webhook entry", entries)
+	}
+}
@@ -158,6 +158,21 @@ func GetUserFromContext(r *http.Request) (*Claims, bool) {
 	return claims, ok
 }
 
+// withUser wraps a handler that requires an authenticated user. It extracts
+// the JWT claims from the request context once, answers 401 when they are
+// missing, and passes them to the handler, so individual handlers don't
+// repeat the claims-extraction boilerplate.
+func (s *Server) withUser(handler func(http.ResponseWriter, *http.Request, *Claims)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := GetUserFromContext(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r, claims)
+	}
+}
+
 // HandleLogout doesn't actually invalidate the token (since JWTs are stateless)
 // but it's a placeholder for future token invalidation logic
 func (s *Server) HandleLogout(w http.ResponseWriter, r *http.Request) {
@@ -40,6 +40,12 @@ func GetBlueprintTypes() []string {
 
 // SaveBlueprint saves a blueprint to the database
 func SaveBlueprint(db *sql.DB, blueprint Blueprint) error {
+	// A blueprint imported from elsewhere can carry colliding task IDs;
+	// reject them here so they never reach a generated config
+	if err := validateTaskIDs(blueprint.Tasks); err != nil {
+		return err
+	}
+
 	// Serialize tasks and parameters to JSON
 	tasksJSON, err := json.Marshal(blueprint.Tasks)
 	if err != nil {
@@ -115,22 +121,45 @@ func SaveBlueprint(db *sql.DB, blueprint Blueprint) error {
 
 // GetBlueprints retrieves all blueprints visible to a user
 func GetBlueprints(db *sql.DB, userID int) ([]Blueprint, error) {
+	var blueprints []Blueprint
+	err := ForEachBlueprint(db, userID, func(blueprint Blueprint) error {
+		blueprints = append(blueprints, blueprint)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blueprints, nil
+}
+
+// ForEachBlueprint invokes fn for every blueprint visible to the user, one
+// row at a time, so callers can stream blueprints without holding the whole
+// list in memory. GetBlueprints is built on top of it for callers that do
+// want the slice.
+//
+// Parameters:
+//   - db: SQLite database connection
+//   - userID: user whose own and public blueprints are visible
+//   - fn: called once per blueprint; a returned error stops iteration
+//
+// Returns:
+//   - error: if the query, a scan, or fn fails
+func ForEachBlueprint(db *sql.DB, userID int, fn func(Blueprint) error) error {
 	// Get public blueprints and those created by the user
 	rows, err := db.Query(
-		`SELECT 
-			id, name, description, type, version, 
-			tasks, parameters, created_by, is_public, created_at 
-		FROM blueprints 
+		`SELECT
+			id, name, description, type, version,
+			tasks, parameters, created_by, is_public, created_at
+		FROM blueprints
 		WHERE is_public = 1 OR created_by = ?
 		ORDER BY created_at DESC`,
 		userID,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query blueprints: %v", err)
+		return fmt.Errorf("failed to query blueprints: %v", err)
 	}
 	defer rows.Close()
 
-	var blueprints []Blueprint
 	for rows.Next() {
 		var blueprint Blueprint
 		var tasksJSON, paramsJSON []byte
@@ -148,27 +177,29 @@ func GetBlueprints(db *sql.DB, userID int) ([]Blueprint, error) {
 			&blueprint.CreatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan blueprint: %v", err)
+			return fmt.Errorf("failed to scan blueprint: %v", err)
 		}
 
 		// Deserialize tasks and parameters from JSON
 		if err := json.Unmarshal(tasksJSON, &blueprint.Tasks); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal tasks: %v", err)
+			return fmt.Errorf("failed to unmarshal tasks: %v", err)
 		}
 
 		if err := json.Unmarshal(paramsJSON, &blueprint.Parameters); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal parameters: %v", err)
+			return fmt.Errorf("failed to unmarshal parameters: %v", err)
 		}
 
-		blueprints = append(blueprints, blueprint)
+		if err := fn(blueprint); err != nil {
+			return err
+		}
 	}
 
 	// Check for errors during iteration
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error during blueprint row iteration: %v", err)
+		return fmt.Errorf("error during blueprint row iteration: %v", err)
 	}
 
-	return blueprints, nil
+	return nil
 }
 
 // GetBlueprintByID retrieves a specific blueprint by ID
@@ -237,8 +268,11 @@ func DeleteBlueprint(db *sql.DB, id string, userID int) error {
 	return nil
 }
 
-// GenerateConfigFromBlueprint creates a config file from a blueprint
-func GenerateConfigFromBlueprint(blueprint *Blueprint, name string, params map[string]string) (*config.Config, error) {
+// GenerateConfigFromBlueprint creates a config file from a blueprint. Task
+// IDs that are missing or duplicated in the blueprint are regenerated so the
+// config never carries colliding IDs; the returned map reports any remapping
+// as old ID -> new ID.
+func GenerateConfigFromBlueprint(blueprint *Blueprint, name string, params map[string]string) (*config.Config, map[string]string, error) {
 	// Start with the parameters from the blueprint
 	mergedParams := make(map[string]string)
 	maps.Copy(mergedParams, blueprint.Parameters)
@@ -246,15 +280,17 @@ func GenerateConfigFromBlueprint(blueprint *Blueprint, name string, params map[s
 	// Override with the provided parameters
 	maps.Copy(mergedParams, params)
 
+	tasks, remapped := dedupeTaskIDs(blueprint.Tasks)
+
 	// Create a new config
 	cfg := &config.Config{
 		Version:        "0.1.2", // Use the current version
 		AppName:        name,
-		Tasks:          blueprint.Tasks,
+		Tasks:          tasks,
 		Params:         mergedParams,
 		Hosts:          make(map[string]config.Host),
 		ReleaseVersion: time.Now().UnixMilli(),
 	}
 
-	return cfg, nil
+	return cfg, remapped, nil
 }
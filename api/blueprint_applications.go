@@ -0,0 +1,123 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/zechtz/nyatictl/api/response"
+)
+
+// BlueprintApplication records that a config was generated from a blueprint:
+// which blueprint and version produced it, and with what parameters. This is
+// the provenance a user needs to re-apply an updated blueprint later.
+type BlueprintApplication struct {
+	ID               int               `json:"id"`
+	BlueprintID      string            `json:"blueprint_id"`
+	BlueprintName    string            `json:"blueprint_name"`
+	BlueprintVersion string            `json:"blueprint_version"`
+	ConfigName       string            `json:"config_name"`
+	Parameters       map[string]string `json:"parameters"`
+	UserID           int               `json:"user_id"`
+	CreatedAt        string            `json:"created_at"`
+}
+
+// RecordBlueprintApplication stores one blueprint-to-config application.
+//
+// Parameters:
+//   - db: database connection
+//   - blueprint: the blueprint that was applied
+//   - configName: name of the generated config
+//   - params: the effective parameters the config was generated with
+//   - userID: the applying user
+//
+// Returns:
+//   - error: if serialization or the insert fails
+func RecordBlueprintApplication(db *sql.DB, blueprint *Blueprint, configName string, params map[string]string, userID int) error {
+	if params == nil {
+		params = map[string]string{}
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal parameters: %v", err)
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO blueprint_applications (blueprint_id, blueprint_name, blueprint_version, config_name, parameters, user_id)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		blueprint.ID, blueprint.Name, blueprint.Version, configName, string(paramsJSON), userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record blueprint application: %v", err)
+	}
+	return nil
+}
+
+// GetBlueprintApplications returns a config's blueprint provenance for one
+// user, newest application first.
+//
+// Parameters:
+//   - db: database connection
+//   - configName: name of the config whose provenance is requested
+//   - userID: the requesting user; other users' applications are not visible
+//
+// Returns:
+//   - []BlueprintApplication: the recorded applications, newest first
+//   - error: if the query fails
+func GetBlueprintApplications(db *sql.DB, configName string, userID int) ([]BlueprintApplication, error) {
+	rows, err := db.Query(
+		`SELECT id, blueprint_id, blueprint_name, blueprint_version, config_name, parameters, user_id, created_at
+		FROM blueprint_applications WHERE config_name = ? AND user_id = ?
+		ORDER BY id DESC`,
+		configName, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blueprint applications: %v", err)
+	}
+	defer rows.Close()
+
+	applications := []BlueprintApplication{}
+	for rows.Next() {
+		var app BlueprintApplication
+		var paramsJSON string
+		if err := rows.Scan(&app.ID, &app.BlueprintID, &app.BlueprintName, &app.BlueprintVersion,
+			&app.ConfigName, &paramsJSON, &app.UserID, &app.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blueprint application: %v", err)
+		}
+		if err := json.Unmarshal([]byte(paramsJSON), &app.Parameters); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal parameters: %v", err)
+		}
+		applications = append(applications, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query blueprint applications: %v", err)
+	}
+	return applications, nil
+}
+
+// handleBlueprintApplications lists a config's blueprint provenance, selected
+// by GET /api/blueprints/applications?config=<name>.
+func (s *Server) handleBlueprintApplications(w http.ResponseWriter, r *http.Request) {
+	rw := response.NewWriter(w)
+
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		rw.Unauthorized("Unauthorized")
+		return
+	}
+
+	configName := r.URL.Query().Get("config")
+	if configName == "" {
+		rw.BadRequest("Query parameter 'config' is required")
+		return
+	}
+
+	applications, err := GetBlueprintApplications(s.db.DB, configName, claims.UserID)
+	if err != nil {
+		rw.InternalServerError(err.Error())
+		return
+	}
+
+	rw.Success(applications)
+}
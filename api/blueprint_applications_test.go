@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/zechtz/nyatictl/db"
+)
+
+// newBlueprintApplicationTestServer creates a Server backed by an in-memory
+// database with the blueprint provenance table.
+func newBlueprintApplicationTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	rawDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	rawDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { rawDB.Close() })
+
+	if _, err := rawDB.Exec(`CREATE TABLE blueprint_applications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		blueprint_id TEXT NOT NULL,
+		blueprint_name TEXT NOT NULL,
+		blueprint_version TEXT NOT NULL,
+		config_name TEXT NOT NULL,
+		parameters TEXT NOT NULL DEFAULT '{}',
+		user_id INTEGER NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+
+	return &Server{db: db.NewMetricsDB(rawDB)}
+}
+
+func TestRecordAndRetrieveBlueprintApplication(t *testing.T) {
+	s := newBlueprintApplicationTestServer(t)
+	blueprint := &Blueprint{ID: "bp-1", Name: "Node.js", Version: "1.0.0"}
+
+	err := RecordBlueprintApplication(s.db.DB, blueprint, "demo-app",
+		map[string]string{"branch": "main"}, 1)
+	if err != nil {
+		t.Fatalf("RecordBlueprintApplication failed: %v", err)
+	}
+
+	apps, err := GetBlueprintApplications(s.db.DB, "demo-app", 1)
+	if err != nil {
+		t.Fatalf("GetBlueprintApplications failed: %v", err)
+	}
+	if len(apps) != 1 {
+		t.Fatalf("applications = %d, want 1", len(apps))
+	}
+
+	app := apps[0]
+	if app.BlueprintID != "bp-1" || app.BlueprintName != "Node.js" || app.BlueprintVersion != "1.0.0" {
+		t.Errorf("provenance = %+v, want blueprint bp-1 Node.js v1.0.0", app)
+	}
+	if app.Parameters["branch"] != "main" {
+		t.Errorf("parameters = %v, want the applied branch=main", app.Parameters)
+	}
+}
+
+func TestBlueprintApplicationsScopedToUser(t *testing.T) {
+	s := newBlueprintApplicationTestServer(t)
+	blueprint := &Blueprint{ID: "bp-1", Name: "Node.js", Version: "1.0.0"}
+
+	if err := RecordBlueprintApplication(s.db.DB, blueprint, "demo-app", nil, 1); err != nil {
+		t.Fatalf("RecordBlueprintApplication failed: %v", err)
+	}
+
+	apps, err := GetBlueprintApplications(s.db.DB, "demo-app", 2)
+	if err != nil {
+		t.Fatalf("GetBlueprintApplications failed: %v", err)
+	}
+	if len(apps) != 0 {
+		t.Errorf("another user sees %d applications, want 0", len(apps))
+	}
+}
+
+func TestHandleBlueprintApplicationsReturnsProvenance(t *testing.T) {
+	s := newBlueprintApplicationTestServer(t)
+	blueprint := &Blueprint{ID: "bp-1", Name: "Node.js", Version: "1.0.0"}
+	if err := RecordBlueprintApplication(s.db.DB, blueprint, "demo-app",
+		map[string]string{"branch": "main"}, 1); err != nil {
+		t.Fatalf("RecordBlueprintApplication failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/blueprints/applications?config=demo-app", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleBlueprintApplications(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("applications status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var envelope struct {
+		Success bool                   `json:"success"`
+		Data    []BlueprintApplication `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&envelope); err != nil {
+		t.Fatalf("applications did not return JSON: %v", err)
+	}
+	if !envelope.Success || len(envelope.Data) != 1 {
+		t.Fatalf("response = %s, want one application", rec.Body.String())
+	}
+	if envelope.Data[0].BlueprintVersion != "1.0.0" {
+		t.Errorf("version = %q, want 1.0.0", envelope.Data[0].BlueprintVersion)
+	}
+}
+
+func TestHandleBlueprintApplicationsRequiresConfig(t *testing.T) {
+	s := newBlueprintApplicationTestServer(t)
+
+	req := httptest.NewRequest("GET", "/api/blueprints/applications", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleBlueprintApplications(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("missing config status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -2,10 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/zechtz/nyatictl/api/response"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
 )
 
 // handleGetBlueprints returns all blueprints visible to the user
@@ -19,6 +22,17 @@ func (s *Server) handleGetBlueprints(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Full export: stream blueprints row by row as a bare JSON array (no
+	// response envelope) so memory stays flat no matter how many exist
+	if r.URL.Query().Get("stream") == "true" {
+		streamList(w, func(write func(any) error) error {
+			return ForEachBlueprint(s.db.DB, claims.UserID, func(blueprint Blueprint) error {
+				return write(blueprint)
+			})
+		})
+		return
+	}
+
 	// Get blueprints from the database
 	blueprints, err := GetBlueprints(s.db.DB, claims.UserID)
 	if err != nil {
@@ -27,7 +41,7 @@ func (s *Server) handleGetBlueprints(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return blueprints as JSON
-	rw.Success(blueprints)
+	rw.Success(paginate(blueprints, queryInt(r, "limit"), queryInt(r, "offset")))
 }
 
 // handleGetBlueprintByID returns a specific blueprint
@@ -93,6 +107,14 @@ func (s *Server) handleSaveBlueprint(w http.ResponseWriter, r *http.Request) {
 
 	// log.Printf("Unmarshaled Blueprint: %+v\n", blueprint)
 
+	// Fill missing task IDs, then fail fast on duplicates: two tasks
+	// sharing an ID would mix their timeline and stats data downstream
+	blueprint.Tasks = assignTaskIDs(blueprint.Tasks)
+	if err := validateTaskIDs(blueprint.Tasks); err != nil {
+		rw.BadRequest(err.Error())
+		return
+	}
+
 	// Save blueprint to the database
 	if err := SaveBlueprint(s.db.DB, blueprint); err != nil {
 		rw.InternalServerError(err.Error())
@@ -168,14 +190,86 @@ func (s *Server) handleGenerateConfigFromBlueprint(w http.ResponseWriter, r *htt
 	}
 
 	// Generate config from blueprint
-	cfg, err := GenerateConfigFromBlueprint(blueprint, req.ConfigName, req.Parameters)
+	cfg, remapped, err := GenerateConfigFromBlueprint(blueprint, req.ConfigName, req.Parameters)
+	if err != nil {
+		rw.InternalServerError(err.Error())
+		return
+	}
+
+	// Record provenance with the effective parameters, so the config's
+	// blueprint origin can be shown and re-applied later. Recording is
+	// best-effort: the config has already been generated.
+	if err := RecordBlueprintApplication(s.db.DB, blueprint, req.ConfigName, cfg.Params, claims.UserID); err != nil {
+		logger.Error(fmt.Sprintf("Failed to record blueprint application for %s: %v", req.ConfigName, err))
+	}
+
+	// Return config as JSON, with any regenerated task IDs reported
+	// alongside it so the caller can reconcile references to the old ones
+	rw.Created(struct {
+		*config.Config
+		RemappedTaskIDs map[string]string `json:"remapped_task_ids,omitempty"`
+	}{cfg, remapped})
+}
+
+// BlueprintPreviewRequest carries a full (possibly unsaved) blueprint plus
+// sample parameters to render into a config preview.
+type BlueprintPreviewRequest struct {
+	Blueprint  Blueprint         `json:"blueprint"`
+	ConfigName string            `json:"config_name"`
+	Parameters map[string]string `json:"parameters"`
+}
+
+// BlueprintPreviewResult contains the rendered YAML and the outcome of
+// validating it, so the editor can show both the preview and its problems.
+type BlueprintPreviewResult struct {
+	YAML   string   `json:"yaml"`
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors"`
+}
+
+// handleBlueprintPreview renders a blueprint to nyati.yaml-style YAML without
+// persisting anything. The blueprint comes from the request body rather than
+// the database, so the editor can preview unsaved changes. The YAML is
+// validated the same way a saved config would be, and validation errors are
+// returned alongside the preview instead of failing the request.
+func (s *Server) handleBlueprintPreview(w http.ResponseWriter, r *http.Request) {
+	rw := response.NewWriter(w)
+
+	var req BlueprintPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.BadRequest("Invalid request body")
+		return
+	}
+
+	// Fall back to the blueprint name so a bare blueprint still previews
+	name := req.ConfigName
+	if name == "" {
+		name = req.Blueprint.Name
+	}
+
+	cfg, _, err := GenerateConfigFromBlueprint(&req.Blueprint, name, req.Parameters)
 	if err != nil {
 		rw.InternalServerError(err.Error())
 		return
 	}
 
-	// Return config as JSON
-	rw.Created(cfg)
+	yamlBytes, err := config.Marshal(cfg)
+	if err != nil {
+		rw.InternalServerError(err.Error())
+		return
+	}
+
+	result := BlueprintPreviewResult{
+		YAML:   string(yamlBytes),
+		Valid:  true,
+		Errors: []string{},
+	}
+	if _, err := config.LoadBytes(yamlBytes, "0.1.2"); err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	rw.Success(result)
 }
 
 // handleGetBlueprintTypes returns the list of available blueprint types
@@ -210,9 +304,12 @@ func (s *Server) RegisterBlueprintRoutes(router *mux.Router) {
 	// Blueprint endpoints
 	router.HandleFunc("/blueprints", s.handleGetBlueprints).Methods("GET")
 	router.HandleFunc("/blueprints", s.handleSaveBlueprint).Methods("POST")
+	// Registered before /blueprints/{id} so "applications" is not taken as an ID
+	router.HandleFunc("/blueprints/applications", s.handleBlueprintApplications).Methods("GET")
 	router.HandleFunc("/blueprints/{id}", s.handleGetBlueprintByID).Methods("GET")
 	router.HandleFunc("/blueprints/{id}", s.handleDeleteBlueprint).Methods("DELETE")
 	router.HandleFunc("/blueprints/generate", s.handleGenerateConfigFromBlueprint).Methods("POST")
+	router.HandleFunc("/blueprints/preview", s.handleBlueprintPreview).Methods("POST")
 	router.HandleFunc("/blueprint-types", s.handleGetBlueprintTypes).Methods("GET")
 	router.HandleFunc("/blueprints/preset/{type}", s.handleGetBlueprintPreset).Methods("GET")
 }
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"fmt"
+
 	"github.com/google/uuid"
 	"github.com/zechtz/nyatictl/config"
 )
@@ -373,3 +375,57 @@ func assignTaskIDs(tasks []config.Task) []config.Task {
 	}
 	return tasks
 }
+
+// validateTaskIDs rejects tasks that share an ID. assignTaskIDs only fills
+// empty IDs, so a blueprint imported from another server can carry duplicates
+// that would later mix timeline and stats data; saving such a blueprint fails
+// here instead.
+//
+// Parameters:
+//   - tasks: the blueprint's tasks, after assignTaskIDs
+//
+// Returns:
+//   - error: naming the duplicated ID and both tasks that carry it
+func validateTaskIDs(tasks []config.Task) error {
+	seen := make(map[string]string)
+	for _, task := range tasks {
+		if task.ID == "" {
+			continue
+		}
+		if first, ok := seen[task.ID]; ok {
+			return fmt.Errorf("duplicate task id '%s' on tasks '%s' and '%s'", task.ID, first, task.Name)
+		}
+		seen[task.ID] = task.Name
+	}
+	return nil
+}
+
+// dedupeTaskIDs copies tasks, fills empty IDs and regenerates any ID that is
+// already taken, so a config generated from a blueprint never carries
+// colliding IDs. The returned map records old ID -> new ID for every task
+// that was remapped, so callers can report the remapping.
+//
+// Parameters:
+//   - tasks: the blueprint's tasks; the slice is not modified
+//
+// Returns:
+//   - []config.Task: a copy with unique, non-empty IDs
+//   - map[string]string: IDs that were regenerated, keyed by the old ID
+func dedupeTaskIDs(tasks []config.Task) ([]config.Task, map[string]string) {
+	deduped := make([]config.Task, len(tasks))
+	copy(deduped, tasks)
+
+	seen := make(map[string]bool)
+	remapped := make(map[string]string)
+	for i := range deduped {
+		id := deduped[i].ID
+		if id != "" && seen[id] {
+			deduped[i].ID = uuid.NewString()
+			remapped[id] = deduped[i].ID
+		} else if id == "" {
+			deduped[i].ID = uuid.NewString()
+		}
+		seen[deduped[i].ID] = true
+	}
+	return deduped, remapped
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+func previewRequest(t *testing.T, body BlueprintPreviewRequest) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		t.Fatalf("failed to marshal preview request: %v", err)
+	}
+	return httptest.NewRequest("POST", "/api/blueprints/preview", &buf)
+}
+
+func decodePreview(t *testing.T, rec *httptest.ResponseRecorder) BlueprintPreviewResult {
+	t.Helper()
+
+	var envelope struct {
+		Success bool                   `json:"success"`
+		Data    BlueprintPreviewResult `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&envelope); err != nil {
+		t.Fatalf("preview did not return JSON: %v", err)
+	}
+	if !envelope.Success {
+		t.Fatalf("preview response not successful: %s", rec.Body.String())
+	}
+	return envelope.Data
+}
+
+func TestBlueprintPreviewRendersYAML(t *testing.T) {
+	s := &Server{}
+	req := previewRequest(t, BlueprintPreviewRequest{
+		Blueprint: Blueprint{
+			Name:       "nodejs-app",
+			Tasks:      []config.Task{{Name: "install", Cmd: "npm install ${branch}"}},
+			Parameters: map[string]string{"branch": "main"},
+		},
+		ConfigName: "demo",
+	})
+
+	rec := httptest.NewRecorder()
+	s.handleBlueprintPreview(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preview status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	result := decodePreview(t, rec)
+	for _, want := range []string{"appname: demo", "name: install", "branch: main"} {
+		if !strings.Contains(result.YAML, want) {
+			t.Errorf("preview YAML missing %q:\n%s", want, result.YAML)
+		}
+	}
+
+	// Blueprints carry no hosts, so the preview is expected to flag that
+	// rather than fail the request
+	if result.Valid {
+		t.Error("preview of a host-less blueprint should not validate")
+	}
+	if len(result.Errors) == 0 || !strings.Contains(result.Errors[0], "host") {
+		t.Errorf("preview errors = %v, want a missing-host error", result.Errors)
+	}
+}
+
+func TestBlueprintPreviewDefaultsToBlueprintName(t *testing.T) {
+	s := &Server{}
+	req := previewRequest(t, BlueprintPreviewRequest{
+		Blueprint: Blueprint{
+			Name:  "nodejs-app",
+			Tasks: []config.Task{{Name: "install", Cmd: "npm install"}},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	s.handleBlueprintPreview(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preview status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	result := decodePreview(t, rec)
+	if !strings.Contains(result.YAML, "appname: nodejs-app") {
+		t.Errorf("preview YAML should fall back to the blueprint name:\n%s", result.YAML)
+	}
+}
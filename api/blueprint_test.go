@@ -0,0 +1,130 @@
+package api
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/zechtz/nyatictl/config"
+)
+
+// newBlueprintTestDB creates an in-memory database with the blueprints table.
+func newBlueprintTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	rawDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	rawDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { rawDB.Close() })
+
+	if _, err := rawDB.Exec(`CREATE TABLE blueprints (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		type TEXT NOT NULL,
+		version TEXT NOT NULL,
+		tasks TEXT NOT NULL,
+		parameters TEXT NOT NULL,
+		created_by INTEGER NOT NULL,
+		is_public BOOLEAN NOT NULL DEFAULT 0,
+		created_at TEXT NOT NULL
+	);`); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+
+	return rawDB
+}
+
+func TestValidateTaskIDs(t *testing.T) {
+	unique := []config.Task{
+		{ID: "a", Name: "build", Cmd: "make build"},
+		{ID: "b", Name: "deploy", Cmd: "make deploy"},
+		{Name: "cleanup", Cmd: "make clean"}, // empty IDs are not duplicates
+		{Name: "notify", Cmd: "make notify"},
+	}
+	if err := validateTaskIDs(unique); err != nil {
+		t.Errorf("validateTaskIDs rejected unique IDs: %v", err)
+	}
+
+	duplicated := []config.Task{
+		{ID: "a", Name: "build", Cmd: "make build"},
+		{ID: "a", Name: "deploy", Cmd: "make deploy"},
+	}
+	err := validateTaskIDs(duplicated)
+	if err == nil {
+		t.Fatal("validateTaskIDs accepted a duplicate ID")
+	}
+	for _, want := range []string{"'a'", "build", "deploy"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should name %s", err, want)
+		}
+	}
+}
+
+func TestSaveBlueprintRejectsDuplicateTaskIDs(t *testing.T) {
+	db := newBlueprintTestDB(t)
+	blueprint := Blueprint{
+		Name: "imported", Type: "custom", Version: "1.0.0",
+		Tasks: []config.Task{
+			{ID: "a", Name: "build", Cmd: "make build"},
+			{ID: "a", Name: "deploy", Cmd: "make deploy"},
+		},
+	}
+
+	if err := SaveBlueprint(db, blueprint); err == nil || !strings.Contains(err.Error(), "duplicate task id") {
+		t.Errorf("SaveBlueprint error = %v, want the duplicate task id rejected", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM blueprints").Scan(&count); err != nil {
+		t.Fatalf("failed to count blueprints: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("rejected blueprint was saved anyway (%d rows)", count)
+	}
+}
+
+func TestGenerateConfigFromBlueprintRemapsDuplicateIDs(t *testing.T) {
+	blueprint := &Blueprint{
+		Name: "imported",
+		Tasks: []config.Task{
+			{ID: "a", Name: "build", Cmd: "make build"},
+			{ID: "a", Name: "deploy", Cmd: "make deploy"},
+			{Name: "cleanup", Cmd: "make clean"},
+		},
+	}
+
+	cfg, remapped, err := GenerateConfigFromBlueprint(blueprint, "demo", nil)
+	if err != nil {
+		t.Fatalf("GenerateConfigFromBlueprint failed: %v", err)
+	}
+
+	// Every generated task carries a unique, non-empty ID
+	seen := make(map[string]bool)
+	for _, task := range cfg.Tasks {
+		if task.ID == "" {
+			t.Errorf("task '%s' has no ID", task.Name)
+		}
+		if seen[task.ID] {
+			t.Errorf("task '%s' shares ID %q with another task", task.Name, task.ID)
+		}
+		seen[task.ID] = true
+	}
+
+	// The collision was resolved by regenerating the second ID, and the
+	// remapping reports the old ID so callers can reconcile references
+	if cfg.Tasks[0].ID != "a" {
+		t.Errorf("first task ID = %q, want the original kept", cfg.Tasks[0].ID)
+	}
+	if newID, ok := remapped["a"]; !ok || newID != cfg.Tasks[1].ID {
+		t.Errorf("remapped = %v, want 'a' mapped to the regenerated ID %q", remapped, cfg.Tasks[1].ID)
+	}
+
+	// The blueprint's own tasks are left untouched
+	if blueprint.Tasks[1].ID != "a" || blueprint.Tasks[2].ID != "" {
+		t.Errorf("blueprint tasks were modified: %+v", blueprint.Tasks)
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// compressMinSize is the response size below which compression is skipped:
+// tiny payloads gain nothing from gzip and the header overhead can even grow
+// them. Responses are buffered up to this size before the decision is made.
+const compressMinSize = 1 << 10 // 1 KiB
+
+// compressionMiddleware gzips responses for clients that advertise support
+// via Accept-Encoding, once a response grows past compressMinSize. WebSocket
+// routes and connection upgrades are passed through untouched: compression
+// would break the hijacked connection. Brotli is deliberately not offered;
+// there is no stdlib encoder and the embedded UI and JSON lists compress
+// well enough with gzip to not be worth a dependency.
+//
+// Parameters:
+//   - next: the handler whose responses are candidates for compression
+//
+// Returns:
+//   - http.Handler: the wrapping handler
+func compressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") ||
+			strings.HasPrefix(r.URL.Path, "/ws/") ||
+			strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// gzipResponseWriter buffers a response until it either exceeds
+// compressMinSize (at which point the buffered bytes and everything after
+// them are gzipped) or completes small enough to send uncompressed. Handlers
+// that flush explicitly commit the response in whatever mode it is in at
+// that point.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+
+	status      int          // Status from WriteHeader, held until the mode is decided
+	buf         []byte       // Response bytes buffered before the decision
+	gz          *gzip.Writer // Non-nil once compressing
+	plain       bool         // True once the response is committed uncompressed
+	wroteHeader bool         // True once status and headers reached the client
+}
+
+// WriteHeader records the status; it is forwarded once the compression
+// decision is made so Content-Encoding can still be set.
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if !g.wroteHeader && g.gz == nil && !g.plain {
+		g.status = status
+		return
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+// Write buffers until the threshold is reached, then switches to gzip.
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if g.gz != nil {
+		return g.gz.Write(p)
+	}
+	if g.plain {
+		return g.ResponseWriter.Write(p)
+	}
+
+	g.buf = append(g.buf, p...)
+	if len(g.buf) >= compressMinSize {
+		if err := g.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush commits the response in its current mode and forwards the flush, so
+// streaming handlers keep working behind the middleware.
+func (g *gzipResponseWriter) Flush() {
+	switch {
+	case g.gz != nil:
+		g.gz.Flush()
+	case !g.plain:
+		// The handler wants bytes on the wire now; stop buffering and
+		// send what we have uncompressed
+		g.commitPlain()
+	}
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// startCompression commits the response as gzip-encoded and drains the
+// buffer through the compressor. Responses that already carry a
+// Content-Encoding (e.g. pre-compressed assets) are committed as-is instead.
+func (g *gzipResponseWriter) startCompression() error {
+	if g.ResponseWriter.Header().Get("Content-Encoding") != "" {
+		g.commitPlain()
+		return nil
+	}
+
+	header := g.ResponseWriter.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	// The compressed length is unknown; the original one no longer applies
+	header.Del("Content-Length")
+	g.ResponseWriter.WriteHeader(g.status)
+	g.wroteHeader = true
+
+	g.gz = gzip.NewWriter(g.ResponseWriter)
+	_, err := g.gz.Write(g.buf)
+	g.buf = nil
+	return err
+}
+
+// commitPlain sends the held status and buffered bytes uncompressed; later
+// writes pass straight through.
+func (g *gzipResponseWriter) commitPlain() {
+	g.plain = true
+	if !g.wroteHeader {
+		g.ResponseWriter.WriteHeader(g.status)
+		g.wroteHeader = true
+	}
+	if len(g.buf) > 0 {
+		g.ResponseWriter.Write(g.buf)
+		g.buf = nil
+	}
+}
+
+// close finishes the response: small buffered responses go out uncompressed,
+// compressed ones get their gzip trailer.
+func (g *gzipResponseWriter) close() {
+	if g.gz != nil {
+		g.gz.Close()
+		return
+	}
+	if !g.plain {
+		g.commitPlain()
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// largeJSONHandler writes a response comfortably above compressMinSize.
+func largeJSONHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`["` + strings.Repeat("x", 2*compressMinSize) + `"]`))
+}
+
+func TestCompressionMiddlewareGzipsLargeResponses(t *testing.T) {
+	handler := compressionMiddleware(http.HandlerFunc(largeJSONHandler))
+
+	req := httptest.NewRequest("GET", "/api/configs", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+	if rec.Body.Len() >= 2*compressMinSize {
+		t.Errorf("compressed body is %d bytes, not smaller than the original", rec.Body.Len())
+	}
+
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("body is not valid gzip: %v", err)
+	}
+	decompressed, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("failed to decompress body: %v", err)
+	}
+	if !bytes.Contains(decompressed, []byte("xxx")) || len(decompressed) <= 2*compressMinSize {
+		t.Errorf("decompressed body does not match the original (%d bytes)", len(decompressed))
+	}
+}
+
+func TestCompressionMiddlewareSkipsSmallResponses(t *testing.T) {
+	handler := compressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+
+	req := httptest.NewRequest("GET", "/api/stats", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want the small response uncompressed", got)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want the handler's %d preserved", rec.Code, http.StatusCreated)
+	}
+	if rec.Body.String() != `{"ok":true}` {
+		t.Errorf("body = %q, want it passed through verbatim", rec.Body.String())
+	}
+}
+
+func TestCompressionMiddlewareRespectsClientAndWebSockets(t *testing.T) {
+	handler := compressionMiddleware(http.HandlerFunc(largeJSONHandler))
+
+	// No Accept-Encoding: the response stays uncompressed
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/configs", nil))
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q without Accept-Encoding, want none", got)
+	}
+
+	// WebSocket routes are never wrapped, even for gzip-capable clients
+	req := httptest.NewRequest("GET", "/ws/logs/session-1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q on a websocket route, want none", got)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+// defaultMaxConcurrentDeploys is used when no limit is configured.
+const defaultMaxConcurrentDeploys = 5
+
+// deployLimiter is a server-wide semaphore bounding how many deploy, task and
+// sandbox goroutines run at once, so a misbehaving script queueing dozens of
+// deploys cannot peg the box with SSH connections. There is no queueing:
+// requests beyond the limit are rejected with 429 and the caller retries.
+type deployLimiter struct {
+	slots chan struct{}
+}
+
+// newDeployLimiter creates a limiter allowing up to max concurrent deploys.
+// Non-positive limits fall back to the default.
+func newDeployLimiter(max int) *deployLimiter {
+	if max < 1 {
+		max = defaultMaxConcurrentDeploys
+	}
+	return &deployLimiter{slots: make(chan struct{}, max)}
+}
+
+// tryAcquire claims a deploy slot without blocking. Every successful acquire
+// must be paired with a release when the deploy goroutine finishes.
+func (l *deployLimiter) tryAcquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release returns a previously acquired slot.
+func (l *deployLimiter) release() {
+	<-l.slots
+}
+
+// running returns how many slots are currently in use.
+func (l *deployLimiter) running() int {
+	return len(l.slots)
+}
+
+// capacity returns the configured concurrency limit.
+func (l *deployLimiter) capacity() int {
+	return cap(l.slots)
+}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 
 	"github.com/gorilla/mux"
@@ -25,6 +27,29 @@ type ConfigEntry struct {
 	Path        string `json:"path"`              // File path or resource reference
 	Status      string `json:"status"`            // Status of the configuration - Note the corrected JSON tag
 	UserID      int    `json:"user_id,omitempty"` // ID of the user who created this config
+
+	// Default notification targets for deploys of this config, as JSON:
+	// {"webhook_ids": [1, 2], "emails": ["ops@example.com"]}. Empty disables
+	// per-config notifications.
+	NotificationDefaults string `json:"notification_defaults,omitempty"`
+
+	// SHA-256 of the config file when the entry was last saved or
+	// revalidated; deploys compare it against the live file to catch edits
+	// made after the UI last showed the config.
+	ContentHash string `json:"content_hash,omitempty"`
+
+	// Labels group configs in the UI and can target bulk operations
+	// ("run clear_cache on everything labeled laravel"). Normalized to
+	// lowercase on save; stored as a JSON array.
+	Labels []string `json:"labels,omitempty"`
+
+	// Per-host reachability from the background health checker, attached to
+	// list responses when checks are enabled; never persisted with the entry.
+	HostStatus []HostStatus `json:"host_status,omitempty"`
+
+	// EnvironmentID binds an environment whose variables are injected into
+	// web deploys of this config. Zero means no environment is bound.
+	EnvironmentID int `json:"environment_id,omitempty"`
 }
 
 // EnsureConfigsFile checks if the file defined by ConfigFilePath exists on disk.
@@ -54,41 +79,73 @@ func EnsureConfigsFile() error {
 //   - []ConfigEntry: list of loaded configs
 //   - error: if the database query fails
 func LoadConfigs(db *sql.DB, userID ...int) ([]ConfigEntry, error) {
+	var configs []ConfigEntry
+	err := ForEachConfig(db, func(cfg ConfigEntry) error {
+		configs = append(configs, cfg)
+		return nil
+	}, userID...)
+	if err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// ForEachConfig invokes fn for every config entry, one row at a time, so
+// callers can stream entries without holding the whole list in memory.
+// LoadConfigs is built on top of it for callers that do want the slice.
+//
+// Parameters:
+//   - db: SQLite database connection
+//   - fn: called once per entry; a returned error stops iteration
+//   - userID: optional user ID to filter by
+//
+// Returns:
+//   - error: if the query, a scan, or fn fails
+func ForEachConfig(db *sql.DB, fn func(ConfigEntry) error, userID ...int) error {
 	var query string
 	var args []any
 
 	if len(userID) > 0 && userID[0] > 0 {
 		// Load configs for specific user
-		query = `SELECT id, name, description, path, status, user_id 
+		query = `SELECT id, name, description, path, status, user_id, notification_defaults, content_hash, labels, environment_id
 				FROM configs WHERE user_id = ?`
 		args = []any{userID[0]}
 	} else {
 		// Load all configs (for server initialization)
-		query = `SELECT id, name, description, path, status, user_id 
+		query = `SELECT id, name, description, path, status, user_id, notification_defaults, content_hash, labels, environment_id
 				FROM configs`
 	}
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query configs: %v", err)
+		return fmt.Errorf("failed to query configs: %v", err)
 	}
 	defer rows.Close()
 
-	var configs []ConfigEntry
 	for rows.Next() {
 		var cfg ConfigEntry
-		if err := rows.Scan(&cfg.ID, &cfg.Name, &cfg.Description, &cfg.Path, &cfg.Status, &cfg.UserID); err != nil {
-			return nil, fmt.Errorf("failed to scan config: %v", err)
+		var labelsJSON string
+		var environmentID sql.NullInt64
+		if err := rows.Scan(&cfg.ID, &cfg.Name, &cfg.Description, &cfg.Path, &cfg.Status, &cfg.UserID, &cfg.NotificationDefaults, &cfg.ContentHash, &labelsJSON, &environmentID); err != nil {
+			return fmt.Errorf("failed to scan config: %v", err)
+		}
+		cfg.EnvironmentID = int(environmentID.Int64)
+		if labelsJSON != "" {
+			if err := json.Unmarshal([]byte(labelsJSON), &cfg.Labels); err != nil {
+				return fmt.Errorf("failed to unmarshal labels for %s: %v", cfg.Path, err)
+			}
+		}
+		if err := fn(cfg); err != nil {
+			return err
 		}
-		configs = append(configs, cfg)
 	}
 
 	// Check for errors during iteration
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error during config row iteration: %v", err)
+		return fmt.Errorf("error during config row iteration: %v", err)
 	}
 
-	return configs, nil
+	return nil
 }
 
 // SaveConfigs saves the provided list of configuration entries to the SQLite database.
@@ -101,18 +158,32 @@ func LoadConfigs(db *sql.DB, userID ...int) ([]ConfigEntry, error) {
 // Returns:
 //   - error: if the database operation fails
 func SaveConfig(db *sql.DB, config ConfigEntry) error {
+	labelsJSON, err := json.Marshal(config.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to marshal labels: %v", err)
+	}
+	if config.Labels == nil {
+		labelsJSON = []byte("[]")
+	}
+
+	// Unbound configs store NULL rather than a dangling zero ID
+	var environmentID any
+	if config.EnvironmentID != 0 {
+		environmentID = config.EnvironmentID
+	}
+
 	// Check if the config exists
 	var exists bool
 	var existingUserID int
-	err := db.QueryRow("SELECT 1, user_id FROM configs WHERE path = ?", config.Path).Scan(&exists, &existingUserID)
+	err = db.QueryRow("SELECT 1, user_id FROM configs WHERE path = ?", config.Path).Scan(&exists, &existingUserID)
 
 	// If config exists, update it, otherwise insert it
 	switch err {
 	case nil:
 		// Update existing config, preserving user_id
 		_, err = db.Exec(
-			"UPDATE configs SET name = ?, description = ?, status = ? WHERE path = ?",
-			config.Name, config.Description, config.Status, config.Path,
+			"UPDATE configs SET name = ?, description = ?, status = ?, notification_defaults = ?, content_hash = ?, labels = ?, environment_id = ? WHERE path = ?",
+			config.Name, config.Description, config.Status, config.NotificationDefaults, config.ContentHash, string(labelsJSON), environmentID, config.Path,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to update config: %v", err)
@@ -120,8 +191,8 @@ func SaveConfig(db *sql.DB, config ConfigEntry) error {
 	case sql.ErrNoRows:
 		// Insert new config
 		_, err = db.Exec(
-			"INSERT INTO configs (name, description, path, status, user_id) VALUES (?, ?, ?, ?, ?)",
-			config.Name, config.Description, config.Path, config.Status, config.UserID,
+			"INSERT INTO configs (name, description, path, status, user_id, notification_defaults, content_hash, labels, environment_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			config.Name, config.Description, config.Path, config.Status, config.UserID, config.NotificationDefaults, config.ContentHash, string(labelsJSON), environmentID,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert config: %v", err)
@@ -133,10 +204,49 @@ func SaveConfig(db *sql.DB, config ConfigEntry) error {
 	return nil
 }
 
+// authorizeConfigAccess verifies that a config path is registered in the
+// configs table and owned by the requesting user. Every handler that acts on
+// a config path must go through this check so the rules cannot drift apart.
+//
+// Parameters:
+//   - r: the request carrying the user's JWT claims
+//   - configPath: the config path being accessed
+//   - action: verb used in the permission error (e.g. "deploy", "access")
+//
+// Returns:
+//   - int: HTTP status to write when err is non-nil
+//   - error: nil when access is allowed, otherwise the rejection reason
+func (s *Server) authorizeConfigAccess(r *http.Request, configPath, action string) (int, error) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	var userID int
+	err := s.db.DB.QueryRow("SELECT user_id FROM configs WHERE path = ?", configPath).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, fmt.Errorf("Config not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("Database error: %v", err)
+	}
+
+	if userID != claims.UserID {
+		return http.StatusForbidden, fmt.Errorf("You don't have permission to %s this config", action)
+	}
+
+	return http.StatusOK, nil
+}
+
 func (s *Server) RegisterConfigRoutes(r *mux.Router) {
 	r.HandleFunc("/configs", s.handleGetConfigs).Methods("GET")
 	r.HandleFunc("/configs", s.handleSaveConfigs).Methods("POST")
+	r.HandleFunc("/configs/labels", s.handleAssignLabels).Methods("POST")
+	r.HandleFunc("/configs/import-git", s.handleImportGitConfig).Methods("POST")
+	r.HandleFunc("/configs/{id:[0-9]+}/sync", s.handleSyncGitConfig).Methods("POST")
+	r.HandleFunc("/git-credentials", s.handleSaveGitCredential).Methods("POST")
 	r.HandleFunc("/config-details", s.handleConfigDetails).Methods("GET")
+	r.HandleFunc("/config/validate", s.handleValidateConfig).Methods("POST")
 }
 
 // SaveConfigs saves multiple configuration entries to the database
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// configDetailsRequest builds an authenticated config-details request.
+func configDetailsRequest(configPath string, userID int) *http.Request {
+	req := httptest.NewRequest("GET", "/api/config-details?path="+configPath, nil)
+	ctx := context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: userID})
+	return req.WithContext(ctx)
+}
+
+func TestConfigDetailsAccessControl(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	content := `version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 10.0.0.1
+    username: deploy
+    password: secret
+tasks:
+  - name: build
+    cmd: make build
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		userID     int
+		wantStatus int
+	}{
+		{
+			name:       "owner can read a registered config",
+			path:       configPath,
+			userID:     1,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unregistered path returns 404 even if the file exists",
+			path:       filepath.Join(filepath.Dir(configPath), "..", "other.yaml"),
+			userID:     1,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "another user's config is forbidden",
+			path:       configPath,
+			userID:     2,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleConfigDetails(rec, configDetailsRequest(tt.path, tt.userID))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("handleConfigDetails() status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestExecuteTaskUnregisteredConfig(t *testing.T) {
+	s := newDeployTestServer(t, "/registered/nyati.yaml", 1)
+	defer s.writeQueue.Close()
+
+	rec := httptest.NewRecorder()
+	req := deployRequest(t, "/api/execute", "/not/registered.yaml", "server1", 1)
+	s.handleExecuteTask(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("handleExecuteTask() status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestConfigDetailsIncludesTaskAnnotations(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	content := `version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 10.0.0.1
+    username: deploy
+    password: secret
+tasks:
+  - name: build
+    cmd: make build
+    estimate: 2m
+    doc: https://wiki.example.com/build
+    owner: platform-team
+  - name: deploy
+    cmd: make deploy
+    depends_on: [build]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	rec := httptest.NewRecorder()
+	s.handleConfigDetails(rec, configDetailsRequest(configPath, 1))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("config details status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp struct {
+		TaskDetails []struct {
+			Name      string   `json:"name"`
+			DependsOn []string `json:"depends_on"`
+			Estimate  string   `json:"estimate"`
+			Doc       string   `json:"doc"`
+			Owner     string   `json:"owner"`
+		} `json:"task_details"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if len(resp.TaskDetails) != 2 {
+		t.Fatalf("got %d task details, want 2", len(resp.TaskDetails))
+	}
+
+	build := resp.TaskDetails[0]
+	if build.Estimate != "2m" || build.Doc != "https://wiki.example.com/build" || build.Owner != "platform-team" {
+		t.Errorf("build annotations = %+v, want estimate/doc/owner carried through", build)
+	}
+	if deploy := resp.TaskDetails[1]; len(deploy.DependsOn) != 1 || deploy.DependsOn[0] != "build" {
+		t.Errorf("deploy dependencies = %v, want the graph edge to build", deploy.DependsOn)
+	}
+}
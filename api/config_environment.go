@@ -0,0 +1,103 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/zechtz/nyatictl/env"
+)
+
+// boundEnvironment resolves the environment bound to a config into its
+// variables, for injection into a web deploy. Configs without a bound
+// environment yield nil variables and no error.
+//
+// Secrets are decrypted with the request's X-Encryption-Key header or, when
+// the server holds a master key, with the environment's own wrapped data key;
+// one of the two is required whenever the environment holds any secret. A
+// bound environment that
+// has since been deleted is a preflight failure, not a silent deploy without
+// variables.
+//
+// Parameters:
+//   - configPath: path of the config being deployed
+//   - r: the deploy request, consulted for the encryption key header
+//
+// Returns:
+//   - map[string]string: the environment's variables (nil when none is bound)
+//   - string: the environment's name, for the deployment history note
+//   - int: HTTP status to write when err is non-nil
+//   - error: nil when resolution succeeded or no environment is bound
+func (s *Server) boundEnvironment(configPath string, r *http.Request) (map[string]string, string, int, error) {
+	var environmentID sql.NullInt64
+	err := s.db.QueryRow("SELECT environment_id FROM configs WHERE path = ?", configPath).Scan(&environmentID)
+	if err == sql.ErrNoRows || (err == nil && !environmentID.Valid) {
+		return nil, "", 0, nil
+	}
+	if err != nil {
+		return nil, "", http.StatusInternalServerError, fmt.Errorf("Failed to look up bound environment: %v", err)
+	}
+
+	environment, err := env.GetEnvironment(s.db.DB, int(environmentID.Int64))
+	if err == sql.ErrNoRows {
+		return nil, "", http.StatusUnprocessableEntity,
+			fmt.Errorf("The environment bound to this config no longer exists; rebind or clear it before deploying")
+	}
+	if err != nil {
+		return nil, "", http.StatusInternalServerError, fmt.Errorf("Failed to load bound environment: %v", err)
+	}
+
+	// Secrets never deploy undecrypted: a key — from the request header or
+	// unwrapped from the environment's stored data key — is mandatory when
+	// any exist
+	if len(environment.Secrets) > 0 {
+		key, keyErr := s.resolveEnvironmentKey(environment, r)
+		if keyErr != nil {
+			return nil, "", http.StatusInternalServerError, keyErr
+		}
+		if key == "" {
+			return nil, "", http.StatusBadRequest,
+				fmt.Errorf("Encryption key required: environment '%s' holds secrets", environment.Name)
+		}
+		environment.SetEncryptionKey(key)
+	}
+
+	vars, err := environment.AsMap()
+	if err != nil {
+		// A wrong key surfaces here as a decryption failure; the error
+		// carries key names only, never values
+		return nil, "", http.StatusBadRequest,
+			fmt.Errorf("Failed to decrypt environment '%s': %v", environment.Name, err)
+	}
+
+	return vars, environment.Name, 0, nil
+}
+
+// validateConfigEnvironment checks that a config entry's bound environment
+// exists and belongs to the saving user. An unbound entry passes.
+//
+// Parameters:
+//   - entry: the config entry being saved
+//   - userID: the saving user
+//
+// Returns:
+//   - int: HTTP status to write when err is non-nil
+//   - error: nil when the binding is acceptable
+func (s *Server) validateConfigEnvironment(entry ConfigEntry, userID int) (int, error) {
+	if entry.EnvironmentID == 0 {
+		return http.StatusOK, nil
+	}
+
+	environment, err := env.GetEnvironment(s.db.DB, entry.EnvironmentID)
+	if err == sql.ErrNoRows {
+		return http.StatusUnprocessableEntity, fmt.Errorf("Environment %d not found", entry.EnvironmentID)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Failed to check environment: %v", err)
+	}
+	if environment.UserID != userID {
+		return http.StatusForbidden, fmt.Errorf("You don't have permission to bind this environment")
+	}
+
+	return http.StatusOK, nil
+}
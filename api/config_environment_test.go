@@ -0,0 +1,213 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zechtz/nyatictl/db"
+	"github.com/zechtz/nyatictl/env"
+)
+
+// newEnvConfigTestServer builds a server with the configs and environments
+// tables, for exercising config-environment bindings.
+func newEnvConfigTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	rawDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	rawDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { rawDB.Close() })
+
+	schema := []string{
+		`CREATE TABLE configs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			description TEXT,
+			path TEXT NOT NULL UNIQUE,
+			status TEXT DEFAULT '',
+			user_id INTEGER,
+			notification_defaults TEXT NOT NULL DEFAULT '',
+			content_hash TEXT NOT NULL DEFAULT '',
+			labels TEXT NOT NULL DEFAULT '[]',
+			environment_id INTEGER
+		);`,
+		`CREATE TABLE environments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT,
+			is_current BOOLEAN DEFAULT 0,
+			user_id INTEGER NOT NULL,
+			wrapped_key TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE environment_variables (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			environment_id INTEGER NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT,
+			is_secret BOOLEAN DEFAULT 0,
+			encrypted_value TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (environment_id, key)
+		);`,
+	}
+	for _, stmt := range schema {
+		if _, err := rawDB.Exec(stmt); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+
+	return &Server{db: db.NewMetricsDB(rawDB)}
+}
+
+// saveTestEnvironment stores an environment for userID with the given plain
+// variables and, when encryptionKey is set, one secret named SECRET_TOKEN.
+func saveTestEnvironment(t *testing.T, s *Server, name string, userID int, vars map[string]string, encryptionKey string) int {
+	t.Helper()
+
+	environment := env.NewEnvironment(name, "")
+	environment.UserID = userID
+	for k, v := range vars {
+		if err := environment.Set(k, v, false); err != nil {
+			t.Fatalf("failed to set variable: %v", err)
+		}
+	}
+	if encryptionKey != "" {
+		environment.SetEncryptionKey(encryptionKey)
+		if err := environment.Set("SECRET_TOKEN", "s3cret", true); err != nil {
+			t.Fatalf("failed to set secret: %v", err)
+		}
+	}
+	if err := env.SaveEnvironment(s.db.DB, environment); err != nil {
+		t.Fatalf("failed to save environment: %v", err)
+	}
+	return environment.ID
+}
+
+func TestSaveConfigRoundTripsEnvironmentID(t *testing.T) {
+	s := newEnvConfigTestServer(t)
+	envID := saveTestEnvironment(t, s, "staging", 1, map[string]string{"APP_ENV": "staging"}, "")
+
+	entry := ConfigEntry{Name: "demo", Path: "/tmp/nyati.yaml", UserID: 1, EnvironmentID: envID}
+	if err := SaveConfig(s.db.DB, entry); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	configs, err := LoadConfigs(s.db.DB, 1)
+	if err != nil {
+		t.Fatalf("LoadConfigs failed: %v", err)
+	}
+	if len(configs) != 1 || configs[0].EnvironmentID != envID {
+		t.Errorf("configs = %+v, want one entry bound to environment %d", configs, envID)
+	}
+
+	// Clearing the binding stores NULL, not a dangling zero
+	entry.EnvironmentID = 0
+	if err := SaveConfig(s.db.DB, entry); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	var stored sql.NullInt64
+	s.db.QueryRow("SELECT environment_id FROM configs WHERE path = ?", entry.Path).Scan(&stored)
+	if stored.Valid {
+		t.Errorf("environment_id = %v after clearing, want NULL", stored.Int64)
+	}
+}
+
+func TestValidateConfigEnvironment(t *testing.T) {
+	s := newEnvConfigTestServer(t)
+	envID := saveTestEnvironment(t, s, "staging", 1, nil, "")
+
+	cases := []struct {
+		name       string
+		entry      ConfigEntry
+		userID     int
+		wantStatus int
+	}{
+		{"unbound entry", ConfigEntry{}, 1, http.StatusOK},
+		{"own environment", ConfigEntry{EnvironmentID: envID}, 1, http.StatusOK},
+		{"unknown environment", ConfigEntry{EnvironmentID: 999}, 1, http.StatusUnprocessableEntity},
+		{"another user's environment", ConfigEntry{EnvironmentID: envID}, 2, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status, err := s.validateConfigEnvironment(c.entry, c.userID)
+			if status != c.wantStatus {
+				t.Errorf("status = %d (err %v), want %d", status, err, c.wantStatus)
+			}
+			if (c.wantStatus != http.StatusOK) != (err != nil) {
+				t.Errorf("err = %v, inconsistent with status %d", err, c.wantStatus)
+			}
+		})
+	}
+}
+
+func TestBoundEnvironmentResolvesVariables(t *testing.T) {
+	s := newEnvConfigTestServer(t)
+	envID := saveTestEnvironment(t, s, "staging", 1, map[string]string{"APP_ENV": "staging"}, "")
+
+	if err := SaveConfig(s.db.DB, ConfigEntry{Name: "demo", Path: "/tmp/nyati.yaml", UserID: 1, EnvironmentID: envID}); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	vars, name, _, err := s.boundEnvironment("/tmp/nyati.yaml", httptest.NewRequest("POST", "/api/deploy", nil))
+	if err != nil {
+		t.Fatalf("boundEnvironment failed: %v", err)
+	}
+	if name != "staging" || vars["APP_ENV"] != "staging" {
+		t.Errorf("boundEnvironment = %v, %q; want the staging variables", vars, name)
+	}
+
+	// A config without a binding injects nothing and is not an error
+	if err := SaveConfig(s.db.DB, ConfigEntry{Name: "plain", Path: "/tmp/plain.yaml", UserID: 1}); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	vars, name, _, err = s.boundEnvironment("/tmp/plain.yaml", httptest.NewRequest("POST", "/api/deploy", nil))
+	if err != nil || vars != nil || name != "" {
+		t.Errorf("unbound config = %v, %q, %v; want nothing", vars, name, err)
+	}
+}
+
+func TestBoundEnvironmentSecretsRequireKey(t *testing.T) {
+	s := newEnvConfigTestServer(t)
+	envID := saveTestEnvironment(t, s, "production", 1, nil, "k3y")
+
+	if err := SaveConfig(s.db.DB, ConfigEntry{Name: "demo", Path: "/tmp/nyati.yaml", UserID: 1, EnvironmentID: envID}); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	// Without the header the deploy must fail preflight
+	_, _, status, err := s.boundEnvironment("/tmp/nyati.yaml", httptest.NewRequest("POST", "/api/deploy", nil))
+	if err == nil || status != http.StatusBadRequest {
+		t.Errorf("status = %d, err = %v; want a missing-key failure", status, err)
+	}
+
+	// With the key the secret decrypts
+	req := httptest.NewRequest("POST", "/api/deploy", nil)
+	req.Header.Set("X-Encryption-Key", "k3y")
+	vars, _, _, err := s.boundEnvironment("/tmp/nyati.yaml", req)
+	if err != nil || vars["SECRET_TOKEN"] != "s3cret" {
+		t.Errorf("vars = %v, err = %v; want the decrypted secret", vars, err)
+	}
+}
+
+func TestBoundEnvironmentDeletedEnvironmentFailsPreflight(t *testing.T) {
+	s := newEnvConfigTestServer(t)
+	envID := saveTestEnvironment(t, s, "staging", 1, nil, "")
+
+	if err := SaveConfig(s.db.DB, ConfigEntry{Name: "demo", Path: "/tmp/nyati.yaml", UserID: 1, EnvironmentID: envID}); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if _, err := s.db.Exec("DELETE FROM environments WHERE id = ?", envID); err != nil {
+		t.Fatalf("failed to delete environment: %v", err)
+	}
+
+	_, _, status, err := s.boundEnvironment("/tmp/nyati.yaml", httptest.NewRequest("POST", "/api/deploy", nil))
+	if err == nil || status != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, err = %v; want a clear preflight failure", status, err)
+	}
+}
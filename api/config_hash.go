@@ -0,0 +1,52 @@
+package api
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// configFileHash returns the hex SHA-256 of a config file's current content,
+// the fingerprint stored on the config entry at save/validation time and
+// compared again at deploy time.
+//
+// Parameters:
+//   - path: path of the config file
+//
+// Returns:
+//   - string: the hex digest
+//   - error: if the file cannot be read
+func configFileHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config for hashing: %v", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// storedConfigHash returns the content hash recorded for a config entry,
+// empty when the entry is unknown or was saved before hashes existed.
+func (s *Server) storedConfigHash(path string) string {
+	var hash string
+	if err := s.db.QueryRow("SELECT content_hash FROM configs WHERE path = ?", path).Scan(&hash); err != nil {
+		return ""
+	}
+	return hash
+}
+
+// refreshConfigHash re-records the current file hash on a config entry, so a
+// routine edit followed by revalidation stops triggering the deploy-time
+// staleness check. Best-effort: a failure is logged and never blocks the
+// caller.
+func (s *Server) refreshConfigHash(path string) {
+	hash, err := configFileHash(path)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to refresh content hash for %s: %v", path, err))
+		return
+	}
+	if _, err := s.db.Exec("UPDATE configs SET content_hash = ? WHERE path = ?", hash, path); err != nil {
+		logger.Error(fmt.Sprintf("Failed to store content hash for %s: %v", path, err))
+	}
+}
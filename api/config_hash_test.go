@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfigFileHash(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+
+	first, err := configFileHash(configPath)
+	if err != nil {
+		t.Fatalf("configFileHash failed: %v", err)
+	}
+	second, err := configFileHash(configPath)
+	if err != nil {
+		t.Fatalf("configFileHash failed: %v", err)
+	}
+	if first != second {
+		t.Errorf("hash not deterministic: %s vs %s", first, second)
+	}
+
+	if _, err := configFileHash("/nonexistent/nyati.yaml"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+// forceDeployRequest is deployRequest with the force flag set, for overriding
+// the staleness check.
+func forceDeployRequest(t *testing.T, url, configPath, host string, userID int) *http.Request {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{
+		"configPath": configPath,
+		"host":       host,
+		"sessionID":  "test-session",
+		"force":      true,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", url, bytes.NewBuffer(body))
+	ctx := context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: userID})
+	return req.WithContext(ctx)
+}
+
+func TestDeployRejectsStaleConfigHash(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	// Simulate the file changing after the entry was saved by storing a hash
+	// that no longer matches the file on disk
+	if _, err := s.db.Exec("UPDATE configs SET content_hash = 'stale' WHERE path = ?", configPath); err != nil {
+		t.Fatalf("failed to set stale hash: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, deployRequest(t, "/api/deploy", configPath, "server1", 1))
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("stale deploy status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode conflict body: %v", err)
+	}
+	if body["saved_hash"] != "stale" {
+		t.Errorf("saved_hash = %q, want the stored hash", body["saved_hash"])
+	}
+	if body["current_hash"] == "" || body["current_hash"] == "stale" {
+		t.Errorf("current_hash = %q, want the live file's hash", body["current_hash"])
+	}
+}
+
+func TestDeployForceOverridesStaleConfigHash(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	if _, err := s.db.Exec("UPDATE configs SET content_hash = 'stale' WHERE path = ?", configPath); err != nil {
+		t.Fatalf("failed to set stale hash: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, forceDeployRequest(t, "/api/deploy", configPath, "server1", 1))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("forced deploy status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestDeployAcceptsMatchingConfigHash(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	hash, err := configFileHash(configPath)
+	if err != nil {
+		t.Fatalf("configFileHash failed: %v", err)
+	}
+	if _, err := s.db.Exec("UPDATE configs SET content_hash = ? WHERE path = ?", hash, configPath); err != nil {
+		t.Fatalf("failed to store hash: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, deployRequest(t, "/api/deploy", configPath, "server1", 1))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("deploy with matching hash status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestValidateConfigRefreshesStoredHash(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	if _, err := s.db.Exec("UPDATE configs SET content_hash = 'stale' WHERE path = ?", configPath); err != nil {
+		t.Fatalf("failed to set stale hash: %v", err)
+	}
+
+	body, _ := json.Marshal(ValidateConfigRequest{Path: configPath})
+	rec := httptest.NewRecorder()
+	s.handleValidateConfig(rec, httptest.NewRequest("POST", "/api/config/validate", bytes.NewBuffer(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("validate status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	want, err := configFileHash(configPath)
+	if err != nil {
+		t.Fatalf("configFileHash failed: %v", err)
+	}
+	if got := s.storedConfigHash(configPath); got != want {
+		t.Errorf("stored hash after revalidation = %q, want %q", got, want)
+	}
+}
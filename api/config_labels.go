@@ -0,0 +1,239 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zechtz/nyatictl/cli"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// Bounds on config labels: a config carries at most maxConfigLabels labels of
+// at most maxConfigLabelLength characters each.
+const (
+	maxConfigLabels      = 10
+	maxConfigLabelLength = 30
+)
+
+// normalizeConfigLabels validates and normalizes a label list: labels are
+// trimmed, lowercased and deduplicated, empty entries are dropped, and the
+// count and length limits are enforced.
+//
+// Parameters:
+//   - labels: the raw labels from the request
+//
+// Returns:
+//   - []string: the normalized labels, in first-seen order
+//   - error: if a label is too long or there are too many
+func normalizeConfigLabels(labels []string) ([]string, error) {
+	normalized := []string{}
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label == "" {
+			continue
+		}
+		if len(label) > maxConfigLabelLength {
+			return nil, fmt.Errorf("label %q exceeds %d characters", label, maxConfigLabelLength)
+		}
+		if slices.Contains(normalized, label) {
+			continue
+		}
+		normalized = append(normalized, label)
+	}
+	if len(normalized) > maxConfigLabels {
+		return nil, fmt.Errorf("too many labels: %d, maximum is %d", len(normalized), maxConfigLabels)
+	}
+	return normalized, nil
+}
+
+// configsByLabel returns the user's config entries carrying the given
+// (already normalized) label.
+func (s *Server) configsByLabel(userID int, label string) ([]ConfigEntry, error) {
+	configs, err := LoadConfigs(s.db.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	matched := []ConfigEntry{}
+	for _, cfg := range configs {
+		if slices.Contains(cfg.Labels, label) {
+			matched = append(matched, cfg)
+		}
+	}
+	return matched, nil
+}
+
+// handleAssignLabels merges labels into several configs at once, selected by
+// POST /api/configs/labels with {"config_ids": [...], "labels": [...]}.
+// Only configs owned by the requesting user are touched.
+func (s *Server) handleAssignLabels(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		ConfigIDs []int    `json:"config_ids"`
+		Labels    []string `json:"labels"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.ConfigIDs) == 0 {
+		http.Error(w, "config_ids must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	labels, err := normalizeConfigLabels(req.Labels)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(labels) == 0 {
+		http.Error(w, "labels must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	configs, err := LoadConfigs(s.db.DB, claims.UserID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load configs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	updated := 0
+	for _, cfg := range configs {
+		if !slices.Contains(req.ConfigIDs, cfg.ID) {
+			continue
+		}
+		merged, err := normalizeConfigLabels(append(cfg.Labels, labels...))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("config %s: %v", cfg.Path, err), http.StatusBadRequest)
+			return
+		}
+		cfg.Labels = merged
+		if err := SaveConfig(s.db.DB, cfg); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save config: %v", err), http.StatusInternalServerError)
+			return
+		}
+		updated++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"updated": updated})
+}
+
+// handleBulkTaskByLabel runs one task across every config carrying a label,
+// selected by POST /api/tasks/bulk with
+// {"label": "laravel", "taskName": "clear_cache", "host": "all", "sessionID": "..."}.
+//
+// Configs that don't define the task are skipped rather than failing the
+// whole run. The matched configs execute sequentially in one session, holding
+// a single concurrency slot.
+func (s *Server) handleBulkTaskByLabel(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Label     string `json:"label"`
+		Host      string `json:"host"`
+		TaskName  string `json:"taskName"`
+		SessionID string `json:"sessionID"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Label == "" || req.TaskName == "" {
+		http.Error(w, "label and taskName are required", http.StatusBadRequest)
+		return
+	}
+	if req.Host == "" {
+		req.Host = "all"
+	}
+
+	label := strings.ToLower(strings.TrimSpace(req.Label))
+	entries, err := s.configsByLabel(claims.UserID, label)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load configs: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(entries) == 0 {
+		http.Error(w, fmt.Sprintf("No configs labeled %q", label), http.StatusNotFound)
+		return
+	}
+
+	// Load every matched config up front; configs without the task are
+	// skipped, broken files fail the request before anything runs
+	type bulkTarget struct {
+		path string
+		cfg  *config.Config
+	}
+	targets := []bulkTarget{}
+	skipped := []string{}
+	for _, entry := range entries {
+		cfg, err := config.Load(entry.Path, "0.1.2")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to load config %s: %v", entry.Path, err), statusForError(err))
+			return
+		}
+		if err := cli.ValidateTaskNames(cfg, []string{req.TaskName}); err != nil {
+			skipped = append(skipped, entry.Path)
+			continue
+		}
+		targets = append(targets, bulkTarget{path: entry.Path, cfg: cfg})
+	}
+	if len(targets) == 0 {
+		http.Error(w, fmt.Sprintf("No config labeled %q defines task %q", label, req.TaskName), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if !s.limiter.tryAcquire() {
+		http.Error(w, fmt.Sprintf("Too many concurrent deployments: %d of %d slots in use", s.limiter.running(), s.limiter.capacity()), http.StatusTooManyRequests)
+		return
+	}
+
+	broadcaster := newLogBroadcaster(s.logBufferSize)
+	s.logLock.Lock()
+	s.logChannels[req.SessionID] = broadcaster
+	s.logLock.Unlock()
+
+	s.sessions.begin(req.SessionID, fmt.Sprintf("label:%s", label), req.Host, claims.UserID)
+	go func() {
+		defer s.limiter.release()
+		defer s.sessions.end(req.SessionID)
+		defer s.retireLogChannel(req.SessionID, broadcaster)
+
+		args := []string{"deploy", req.Host}
+		for _, target := range targets {
+			logger.Log(fmt.Sprintf("▶ %s: running %s", target.path, req.TaskName))
+			if _, err := cli.RunWithOptions(target.cfg, args, cli.RunOptions{TaskName: req.TaskName, Debug: true, AutoConfirm: true}); err != nil {
+				logger.Log(fmt.Sprintf("Error: %s: %v", target.path, err))
+			}
+		}
+		logger.Log(fmt.Sprintf("Bulk task %s finished on %d config(s) at %s",
+			req.TaskName, len(targets), time.Now().Format(time.RFC3339)))
+	}()
+
+	paths := make([]string, 0, len(targets))
+	for _, target := range targets {
+		paths = append(paths, target.path)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{
+		"label":   label,
+		"task":    req.TaskName,
+		"configs": paths,
+		"skipped": skipped,
+	})
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeConfigLabels(t *testing.T) {
+	labels, err := normalizeConfigLabels([]string{" Laravel ", "PROD", "laravel", ""})
+	if err != nil {
+		t.Fatalf("normalizeConfigLabels failed: %v", err)
+	}
+	if !slices.Equal(labels, []string{"laravel", "prod"}) {
+		t.Errorf("labels = %v, want [laravel prod] (trimmed, lowercased, deduplicated)", labels)
+	}
+
+	if _, err := normalizeConfigLabels([]string{strings.Repeat("x", maxConfigLabelLength+1)}); err == nil {
+		t.Error("overlong label should be rejected")
+	}
+
+	tooMany := make([]string, maxConfigLabels+1)
+	for i := range tooMany {
+		tooMany[i] = string(rune('a' + i))
+	}
+	if _, err := normalizeConfigLabels(tooMany); err == nil {
+		t.Errorf("more than %d labels should be rejected", maxConfigLabels)
+	}
+}
+
+func TestLabelsRoundTripThroughSaveConfig(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/labeled.yaml", 1)
+
+	entry := ConfigEntry{
+		Name:   "labeled",
+		Path:   "/tmp/labeled.yaml",
+		Status: "DRAFT",
+		UserID: 1,
+		Labels: []string{"laravel", "prod"},
+	}
+	if err := SaveConfig(s.db.DB, entry); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	configs, err := LoadConfigs(s.db.DB, 1)
+	if err != nil {
+		t.Fatalf("LoadConfigs failed: %v", err)
+	}
+	if len(configs) != 1 {
+		t.Fatalf("configs = %d, want 1", len(configs))
+	}
+	if !slices.Equal(configs[0].Labels, []string{"laravel", "prod"}) {
+		t.Errorf("labels = %v, want [laravel prod]", configs[0].Labels)
+	}
+}
+
+func TestAssignLabelsBulk(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/one.yaml", 1)
+	if _, err := s.db.Exec(
+		"INSERT INTO configs (name, description, path, status, user_id) VALUES ('two', '', '/tmp/two.yaml', '', 1), ('theirs', '', '/tmp/theirs.yaml', '', 2)",
+	); err != nil {
+		t.Fatalf("failed to insert configs: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"config_ids": []int{1, 2, 3},
+		"labels":     []string{"Laravel"},
+	})
+	req := httptest.NewRequest("POST", "/api/configs/labels", bytes.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleAssignLabels(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("assign labels status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var result map[string]int
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("assign labels did not return JSON: %v", err)
+	}
+	// Config 3 belongs to user 2 and must not be updated
+	if result["updated"] != 2 {
+		t.Errorf("updated = %d, want 2 (only the caller's configs)", result["updated"])
+	}
+
+	matched, err := s.configsByLabel(1, "laravel")
+	if err != nil {
+		t.Fatalf("configsByLabel failed: %v", err)
+	}
+	if len(matched) != 2 {
+		t.Errorf("labeled configs = %d, want 2", len(matched))
+	}
+	if other, _ := s.configsByLabel(2, "laravel"); len(other) != 0 {
+		t.Errorf("other user's configs gained labels: %v", other)
+	}
+}
+
+func TestGetConfigsFiltersByLabel(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/one.yaml", 1)
+	if err := SaveConfig(s.db.DB, ConfigEntry{
+		Name: "two", Path: "/tmp/two.yaml", Status: "DRAFT", UserID: 1, Labels: []string{"laravel"},
+	}); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/configs?label=Laravel", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleGetConfigs(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get configs status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var configs PagedResponse[ConfigEntry]
+	if err := json.NewDecoder(rec.Body).Decode(&configs); err != nil {
+		t.Fatalf("get configs did not return JSON: %v", err)
+	}
+	if len(configs.Items) != 1 || configs.Items[0].Path != "/tmp/two.yaml" {
+		t.Errorf("filtered configs = %+v, want only /tmp/two.yaml", configs.Items)
+	}
+}
+
+func TestBulkTaskByLabelRequiresMatchingConfigs(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/one.yaml", 1)
+
+	body, _ := json.Marshal(map[string]string{
+		"label":    "laravel",
+		"taskName": "clear_cache",
+	})
+	req := httptest.NewRequest("POST", "/api/tasks/bulk", bytes.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleBulkTaskByLabel(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("bulk task with no labeled configs status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestBulkTaskByLabelSkipsConfigsWithoutTask(t *testing.T) {
+	withTask := writeTestConfig(t, validConfigYAML)
+	s := newDeployTestServer(t, withTask, 1)
+
+	// A second labeled config whose file lacks the requested task
+	without := writeTestConfig(t, strings.Replace(validConfigYAML, "name: build", "name: other", 1))
+	for _, path := range []string{withTask, without} {
+		if err := SaveConfig(s.db.DB, ConfigEntry{
+			Name: "cfg", Path: path, Status: "DRAFT", UserID: 1, Labels: []string{"laravel"},
+		}); err != nil {
+			t.Fatalf("SaveConfig failed: %v", err)
+		}
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"label":     "laravel",
+		"taskName":  "build",
+		"host":      "server1",
+		"sessionID": "bulk-session",
+	})
+	req := httptest.NewRequest("POST", "/api/tasks/bulk", bytes.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleBulkTaskByLabel(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("bulk task status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+
+	var result struct {
+		Configs []string `json:"configs"`
+		Skipped []string `json:"skipped"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("bulk task did not return JSON: %v", err)
+	}
+	if len(result.Configs) != 1 || result.Configs[0] != withTask {
+		t.Errorf("configs = %v, want only %s", result.Configs, withTask)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != without {
+		t.Errorf("skipped = %v, want %s", result.Skipped, without)
+	}
+}
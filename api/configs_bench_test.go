@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/zechtz/nyatictl/db"
+)
+
+// newBenchConfigServer builds a file-backed server so concurrent reads are
+// not serialized by the single-connection in-memory database the unit
+// tests use.
+func newBenchConfigServer(b *testing.B) *Server {
+	b.Helper()
+
+	rawDB, err := sql.Open("sqlite3", filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("failed to open database: %v", err)
+	}
+	b.Cleanup(func() { rawDB.Close() })
+
+	schema := `CREATE TABLE configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		path TEXT NOT NULL UNIQUE,
+		status TEXT DEFAULT '',
+		user_id INTEGER,
+		notification_defaults TEXT NOT NULL DEFAULT '',
+		content_hash TEXT NOT NULL DEFAULT '',
+		labels TEXT NOT NULL DEFAULT '[]',
+		environment_id INTEGER
+	);`
+	if _, err := rawDB.Exec(schema); err != nil {
+		b.Fatalf("failed to create schema: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := rawDB.Exec(
+			"INSERT INTO configs (name, description, path, status, user_id) VALUES (?, ?, ?, ?, ?)",
+			fmt.Sprintf("app-%d", i), "", fmt.Sprintf("/tmp/app-%d.yaml", i), "", 1,
+		); err != nil {
+			b.Fatalf("failed to insert config: %v", err)
+		}
+	}
+
+	metricsDB := db.NewMetricsDB(rawDB)
+	return &Server{
+		db:          metricsDB,
+		writeQueue:  NewWriteQueue(metricsDB.DB, 16),
+		sessions:    newSessionRegistry(),
+		limiter:     newDeployLimiter(0),
+		logChannels: make(map[string]*logBroadcaster),
+	}
+}
+
+// BenchmarkGetConfigsDuringDeploy measures config listing throughput while
+// a deploy keeps writing status updates, the scenario that used to contend
+// on the global configs lock. Run with -cpu to vary reader parallelism;
+// SetParallelism keeps roughly 50 in-flight requests per core.
+func BenchmarkGetConfigsDuringDeploy(b *testing.B) {
+	s := newBenchConfigServer(b)
+	defer s.writeQueue.Close()
+
+	// An "active deploy": status writes flowing through the write queue
+	// for as long as the readers run
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				s.markConfigDeployed("/tmp/app-0.yaml")
+			}
+		}
+	}()
+
+	b.SetParallelism(50)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := httptest.NewRequest("GET", "/api/configs", nil)
+			ctx := context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1})
+			rec := httptest.NewRecorder()
+			s.handleGetConfigs(rec, req.WithContext(ctx))
+			if rec.Code != http.StatusOK {
+				b.Fatalf("configs status = %d, want %d", rec.Code, http.StatusOK)
+			}
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/zechtz/nyatictl/cli"
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// MigrationStatusResponse splits the migration files into applied and pending
+// sections, the shape both db admin endpoints return.
+type MigrationStatusResponse struct {
+	Applied []cli.MigrationState `json:"applied"`
+	Pending []cli.MigrationState `json:"pending"`
+}
+
+// migrationStatusResponse builds the response from the current standing of
+// every migration file.
+func migrationStatusResponse() (MigrationStatusResponse, error) {
+	states, err := cli.MigrationStatusAPI()
+	if err != nil {
+		return MigrationStatusResponse{}, err
+	}
+
+	resp := MigrationStatusResponse{Applied: []cli.MigrationState{}, Pending: []cli.MigrationState{}}
+	for _, state := range states {
+		if state.Applied {
+			resp.Applied = append(resp.Applied, state)
+		} else {
+			resp.Pending = append(resp.Pending, state)
+		}
+	}
+	return resp, nil
+}
+
+// HandleDBStatus reports which migrations are applied and which are pending.
+// Admin only; the underlying read shares the migration advisory lock, so a
+// status request during a run sees the state after it finishes.
+func (s *Server) HandleDBStatus(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	resp, err := migrationStatusResponse()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to read migration status: %v", err))
+		http.Error(w, fmt.Sprintf("Failed to read migration status: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// HandleDBMigrate applies all pending migrations to the configured database,
+// for operators without shell access to the server. Admin only. The run holds
+// the same advisory lock as the startup migration, so concurrent triggers
+// serialize instead of colliding; the response is the post-run status.
+func (s *Server) HandleDBMigrate(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	if err := cli.RunMigrationsAPI(); err != nil {
+		logger.Error(fmt.Sprintf("Remote migration run by user %d failed: %v", claims.UserID, err))
+		http.Error(w, fmt.Sprintf("Migration failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.recordAudit(claims.UserID, "migrate", "database", "global")
+	logger.Log(fmt.Sprintf("Database migrations applied remotely by user %d", claims.UserID))
+
+	resp, err := migrationStatusResponse()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to read migration status after run: %v", err))
+		http.Error(w, fmt.Sprintf("Migrations applied, but reading status failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupMigrationWorkdir points the process at a temp directory holding one
+// migration file and a temp database path, so the remote migration endpoints
+// never touch the real working tree. The original working directory is
+// restored when the test ends.
+func setupMigrationWorkdir(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "db", "migrations")
+	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+		t.Fatalf("failed to create migrations dir: %v", err)
+	}
+	migration := "-- UP\nCREATE TABLE widgets (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE widgets;\n"
+	if err := os.WriteFile(filepath.Join(migrationsDir, "20240101000000_create_widgets.sql"), []byte(migration), 0644); err != nil {
+		t.Fatalf("failed to write migration file: %v", err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to read working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to enter temp workdir: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(cwd); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	})
+
+	t.Setenv("NYATI_DB_PATH", filepath.Join(dir, "nyatictl.db"))
+}
+
+// dbStatus fetches the migration status as the given user.
+func dbStatus(t *testing.T, s *Server, userID int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleDBStatus)(rec, auditRequest(t, "GET", "/api/db/status", nil, userID, nil))
+	return rec
+}
+
+func decodeMigrationStatus(t *testing.T, rec *httptest.ResponseRecorder) MigrationStatusResponse {
+	t.Helper()
+
+	var resp MigrationStatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("status response is not JSON: %v", err)
+	}
+	return resp
+}
+
+func TestDBEndpointsRequireAdmin(t *testing.T) {
+	s := newAuditTestServer(t)
+	setupMigrationWorkdir(t)
+
+	if rec := dbStatus(t, s, 2); rec.Code != http.StatusForbidden {
+		t.Errorf("status as non-admin = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleDBMigrate)(rec, auditRequest(t, "POST", "/api/db/migrate", nil, 2, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("migrate as non-admin = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestDBMigrateAppliesPendingMigrations(t *testing.T) {
+	s := newAuditTestServer(t)
+	setupMigrationWorkdir(t)
+
+	// The migration has never run, so migrate must report it applied
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleDBMigrate)(rec, auditRequest(t, "POST", "/api/db/migrate", nil, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("migrate status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	resp := decodeMigrationStatus(t, rec)
+	if len(resp.Applied) != 1 || resp.Applied[0].Name != "20240101000000_create_widgets.sql" {
+		t.Errorf("applied = %v, want the test migration applied", resp.Applied)
+	}
+	if !resp.Applied[0].Applied || resp.Applied[0].AppliedAt == "" {
+		t.Errorf("applied entry = %+v, want applied with a timestamp", resp.Applied[0])
+	}
+	if len(resp.Pending) != 0 {
+		t.Errorf("pending = %v, want none after the run", resp.Pending)
+	}
+
+	// A follow-up status as admin sees the same standing
+	statusRec := dbStatus(t, s, 1)
+	if statusRec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", statusRec.Code, http.StatusOK, statusRec.Body)
+	}
+	status := decodeMigrationStatus(t, statusRec)
+	if len(status.Applied) != 1 || len(status.Pending) != 0 {
+		t.Errorf("status = %+v, want the applied migration reported", status)
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zechtz/nyatictl/cli"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+	"github.com/zechtz/nyatictl/notify"
+	"github.com/zechtz/nyatictl/ssh"
+)
+
+// deployRetryQueueSize bounds the backlog of deployments waiting for an
+// automatic retry; further failures are dropped with a log line rather than
+// blocking the deploy goroutine.
+const deployRetryQueueSize = 16
+
+// deployRetryJob describes one failed deployment awaiting automatic retry.
+type deployRetryJob struct {
+	userID         int
+	configPath     string
+	host           string
+	note           string
+	params         map[string]string // Request param overrides, re-applied on every attempt
+	releaseVersion int64
+	opts           cli.RunOptions
+	attempt        int // Attempt number to run next; the initial deploy is 1
+}
+
+// deployRetrier queues deployments that failed with a connection error for
+// automatic re-execution after a delay. A nil retrier (retries disabled) is
+// valid and queues nothing, mirroring the nil-safe Notifier.
+type deployRetrier struct {
+	maxRetries int                 // How many automatic retries one deployment gets
+	delay      time.Duration       // Wait before each retry runs
+	jobs       chan deployRetryJob // Pending retries, consumed by runDeployRetries
+}
+
+// newDeployRetrier builds a retrier for the given settings. A non-positive
+// retry count disables automatic retries and yields nil.
+func newDeployRetrier(maxRetries int, delay time.Duration) *deployRetrier {
+	if maxRetries <= 0 {
+		return nil
+	}
+	return &deployRetrier{
+		maxRetries: maxRetries,
+		delay:      delay,
+		jobs:       make(chan deployRetryJob, deployRetryQueueSize),
+	}
+}
+
+// retryableDeployError reports whether a deploy failure is worth retrying
+// automatically. Only connection failures qualify: a task that actually ran
+// and failed would fail the same way again, so task errors are never retried.
+// DNS resolution failures are excluded too — an NXDOMAIN will not fix itself
+// by waiting, unlike a timeout or a refused port.
+func retryableDeployError(err error) bool {
+	var dnsFailed ssh.ErrDNSLookup
+	if errors.As(err, &dnsFailed) {
+		return false
+	}
+	var connectFailed ssh.ErrConnectFailed
+	return errors.As(err, &connectFailed)
+}
+
+// maybeRetryDeploy queues an automatic retry for a failed deployment when
+// retries are enabled, the failure was a connection error, and the deployment
+// has attempts left. job.attempt is the attempt that just failed.
+//
+// Parameters:
+//   - job: the failed deployment's parameters
+//   - err: the failure returned by the run
+//
+// Returns:
+//   - bool: whether a retry was queued
+func (s *Server) maybeRetryDeploy(job deployRetryJob, err error) bool {
+	if s.retrier == nil || !retryableDeployError(err) {
+		return false
+	}
+	if job.attempt > s.retrier.maxRetries {
+		logger.Log(fmt.Sprintf("Deploy %s (%s): giving up after %d attempt(s)",
+			job.configPath, job.host, job.attempt))
+		return false
+	}
+
+	job.attempt++
+	select {
+	case s.retrier.jobs <- job:
+		logger.Log(fmt.Sprintf("Deploy %s (%s): connection failed, retry %d of %d queued in %s",
+			job.configPath, job.host, job.attempt-1, s.retrier.maxRetries, s.retrier.delay))
+		return true
+	default:
+		logger.Error(fmt.Sprintf("Deploy %s (%s): retry queue full, dropping retry",
+			job.configPath, job.host))
+		return false
+	}
+}
+
+// runDeployRetries is the background worker consuming queued retries. It is
+// registered with the server's lifecycle at startup and exits when the
+// context is cancelled or the jobs channel closes.
+func (s *Server) runDeployRetries(ctx context.Context) error {
+	if s.retrier == nil {
+		return nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case job, ok := <-s.retrier.jobs:
+			if !ok {
+				return nil
+			}
+			// Honor the retry delay, but abandon the wait on shutdown
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(s.retrier.delay):
+			}
+			s.runDeployRetry(job)
+		}
+	}
+}
+
+// runDeployRetry re-executes one failed deployment. The retry is recorded as
+// its own deployments row carrying the attempt number, goes through the same
+// notification path as a first run, and may queue a further retry if it fails
+// with a connection error again.
+func (s *Server) runDeployRetry(job deployRetryJob) {
+	cfg, err := config.LoadWithParams(job.configPath, "0.1.2", job.params)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Deploy retry for %s failed to load config: %v", job.configPath, err))
+		return
+	}
+	if job.releaseVersion > 0 {
+		cfg.ReleaseVersion = job.releaseVersion
+	}
+
+	// Retries compete for deploy slots like any other run; when everything
+	// is busy the job goes back on the queue for another delay round
+	if !s.limiter.tryAcquire() {
+		select {
+		case s.retrier.jobs <- job:
+		default:
+			logger.Error(fmt.Sprintf("Deploy %s (%s): no deploy slot and retry queue full, dropping retry",
+				job.configPath, job.host))
+		}
+		return
+	}
+	defer s.limiter.release()
+
+	// A retry has no request to answer with a rejection, so regardless of
+	// the lock mode it waits for any in-flight deploy to its target
+	s.targetLocks.acquire(job.configPath, job.host)
+	defer s.targetLocks.release(job.configPath, job.host)
+
+	deployID := s.recordDeploymentAttempt(job.userID, job.configPath, job.host, job.note, cfg.ReleaseVersion, job.attempt)
+	s.recordDeploymentParams(deployID, job.params)
+
+	start := time.Now()
+	results, err := cli.RunWithOptions(cfg, []string{"deploy", job.host}, job.opts)
+	summary := notify.Summarize(job.configPath, job.host, results, time.Since(start))
+	summary.Note = job.note
+	summary.Params = job.params
+	s.finishDeployment(deployID, err == nil, summary.Duration)
+	s.recordDeploymentResults(deployID, results)
+	s.notifier.DeployCompleted(summary)
+	s.notifyDeployTargets(job.configPath, summary)
+	if err != nil {
+		logger.Log(fmt.Sprintf("Deploy %s (%s): attempt %d failed: %v", job.configPath, job.host, job.attempt, err))
+		s.maybeRetryDeploy(job, err)
+		return
+	}
+
+	logger.Log(fmt.Sprintf("Deploy %s (%s): succeeded on attempt %d", job.configPath, job.host, job.attempt))
+	s.markConfigDeployed(job.configPath)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zechtz/nyatictl/ssh"
+)
+
+func connectFailedErr() error {
+	return ssh.ErrConnectFailed{Host: "server1", Cause: errors.New("dial tcp: connection refused")}
+}
+
+func TestRetryableDeployError(t *testing.T) {
+	if !retryableDeployError(connectFailedErr()) {
+		t.Error("a connection failure should be retryable")
+	}
+	if !retryableDeployError(fmt.Errorf("deploy: %w", connectFailedErr())) {
+		t.Error("a wrapped connection failure should be retryable")
+	}
+	if retryableDeployError(errors.New("task build failed on server1")) {
+		t.Error("a task failure must not be retryable")
+	}
+}
+
+func TestRetryableDeployErrorSkipsDNSFailures(t *testing.T) {
+	dnsErr := ssh.ErrDNSLookup{Host: "server1", Hostname: "db.example.invalid", Cause: errors.New("no such host")}
+
+	if retryableDeployError(dnsErr) {
+		t.Error("a DNS failure must not be retryable; NXDOMAIN will not fix itself")
+	}
+	// Even wrapped inside the usual connect failure, DNS stays non-retryable
+	wrapped := ssh.ErrConnectFailed{Host: "server1", Cause: dnsErr}
+	if retryableDeployError(wrapped) {
+		t.Error("a connect failure caused by DNS must not be retryable")
+	}
+}
+
+func TestMaybeRetryDeployQueuesConnectionFailure(t *testing.T) {
+	s := &Server{retrier: newDeployRetrier(2, time.Millisecond)}
+	job := deployRetryJob{configPath: "/tmp/nyati.yaml", host: "server1", attempt: 1}
+
+	if !s.maybeRetryDeploy(job, connectFailedErr()) {
+		t.Fatal("connection-failed deploy should be re-enqueued")
+	}
+	select {
+	case queued := <-s.retrier.jobs:
+		if queued.attempt != 2 {
+			t.Errorf("queued attempt = %d, want 2", queued.attempt)
+		}
+	default:
+		t.Fatal("no retry job was queued")
+	}
+}
+
+func TestMaybeRetryDeployIgnoresTaskFailures(t *testing.T) {
+	s := &Server{retrier: newDeployRetrier(2, time.Millisecond)}
+	job := deployRetryJob{configPath: "/tmp/nyati.yaml", host: "server1", attempt: 1}
+
+	if s.maybeRetryDeploy(job, errors.New("task build failed on server1")) {
+		t.Error("task-failed deploy must not be re-enqueued")
+	}
+	if len(s.retrier.jobs) != 0 {
+		t.Errorf("retry queue has %d job(s), want 0", len(s.retrier.jobs))
+	}
+}
+
+func TestMaybeRetryDeployStopsAfterConfiguredAttempts(t *testing.T) {
+	s := &Server{retrier: newDeployRetrier(2, time.Millisecond)}
+
+	// Attempt 3 means both allowed retries already ran
+	job := deployRetryJob{configPath: "/tmp/nyati.yaml", host: "server1", attempt: 3}
+	if s.maybeRetryDeploy(job, connectFailedErr()) {
+		t.Error("deploy past its retry budget must not be re-enqueued")
+	}
+}
+
+func TestMaybeRetryDeployDisabledByDefault(t *testing.T) {
+	if newDeployRetrier(0, time.Second) != nil {
+		t.Error("0 attempts should disable the retrier")
+	}
+
+	s := &Server{}
+	job := deployRetryJob{configPath: "/tmp/nyati.yaml", host: "server1", attempt: 1}
+	if s.maybeRetryDeploy(job, connectFailedErr()) {
+		t.Error("retries are opt-in; a server without a retrier must not queue any")
+	}
+}
+
+func TestRecordDeploymentAttemptStoresAttemptNumber(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+
+	id := s.recordDeploymentAttempt(1, path, "server1", "", 0, 2)
+	if id == 0 {
+		t.Fatal("recordDeploymentAttempt failed")
+	}
+
+	var attempt int
+	if err := s.db.QueryRow("SELECT attempt FROM deployments WHERE id = ?", id).Scan(&attempt); err != nil {
+		t.Fatalf("failed to query attempt: %v", err)
+	}
+	if attempt != 2 {
+		t.Errorf("attempt = %d, want 2", attempt)
+	}
+
+	// The plain recordDeployment path stays attempt 1
+	id = s.recordDeployment(1, path, "server1", "", 0)
+	if err := s.db.QueryRow("SELECT attempt FROM deployments WHERE id = ?", id).Scan(&attempt); err != nil {
+		t.Fatalf("failed to query attempt: %v", err)
+	}
+	if attempt != 1 {
+		t.Errorf("initial deploy attempt = %d, want 1", attempt)
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/zechtz/nyatictl/cli"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/notify"
+	"github.com/zechtz/nyatictl/tasks"
+)
+
+// deployWaitTimeout bounds how long a synchronous deploy (?wait=true) may run
+// before the request is answered with a timeout.
+const deployWaitTimeout = 15 * time.Minute
+
+// DeployResult is the machine-readable summary returned by synchronous
+// deploys, so CI can check pass/fail from the HTTP response without managing
+// a websocket.
+type DeployResult struct {
+	Success    bool           `json:"success"`         // Whether every task succeeded
+	ConfigPath string         `json:"configPath"`      // Config that was deployed
+	Host       string         `json:"host"`            // Target host (or "all")
+	Results    []tasks.Result `json:"results"`         // Per-task, per-host outcomes
+	Duration   string         `json:"duration"`        // Total wall-clock time
+	Error      string         `json:"error,omitempty"` // Failure reason, when any
+}
+
+// handleDeploySync runs a deployment inline and writes the full result
+// summary to the response. It is selected by POST /api/deploy?wait=true.
+// The caller has already loaded and validated the config and claimed a
+// deploy slot and the target lock, which the worker goroutine releases when
+// it finishes.
+func (s *Server) handleDeploySync(w http.ResponseWriter, cfg *config.Config, configPath, host, sessionID, note string, params map[string]string, deployID int64, userID int, opts cli.RunOptions) {
+	type deployOutcome struct {
+		results []tasks.Result
+		err     error
+	}
+	outcomeChan := make(chan deployOutcome, 1)
+
+	start := time.Now()
+	s.sessions.begin(sessionID, configPath, host, userID)
+	go func() {
+		defer s.limiter.release()
+		defer s.targetLocks.release(configPath, host)
+		defer s.sessions.end(sessionID)
+		results, err := cli.RunWithOptions(cfg, []string{"deploy", host}, opts)
+		outcomeChan <- deployOutcome{results: results, err: err}
+	}()
+
+	// finalize records the outcome in deployment history and fires the
+	// completion notifications. It is shared between the in-time path and the
+	// timed-out path, where the worker keeps running after the 504.
+	finalize := func(outcome deployOutcome) notify.DeploySummary {
+		duration := time.Since(start)
+		summary := notify.Summarize(configPath, host, outcome.results, duration)
+		summary.Note = note
+		summary.Params = params
+		s.finishDeployment(deployID, outcome.err == nil, duration)
+		s.recordDeploymentResults(deployID, outcome.results)
+		s.notifier.DeployCompleted(summary)
+		s.notifyDeployTargets(configPath, summary)
+		if outcome.err == nil {
+			s.markConfigDeployed(configPath)
+		}
+		return summary
+	}
+
+	var outcome deployOutcome
+	select {
+	case outcome = <-outcomeChan:
+	case <-time.After(deployWaitTimeout):
+		// Answer the caller now, but keep waiting for the worker so history
+		// gets the real outcome (and per-task results, which a later resume
+		// needs to skip what did succeed) instead of a forever-pending row
+		go func() { finalize(<-outcomeChan) }()
+		http.Error(w, fmt.Sprintf("Deploy did not finish within %s", deployWaitTimeout), http.StatusGatewayTimeout)
+		return
+	}
+
+	summary := finalize(outcome)
+
+	result := DeployResult{
+		Success:    outcome.err == nil,
+		ConfigPath: configPath,
+		Host:       host,
+		Results:    outcome.results,
+		Duration:   summary.Duration.String(),
+	}
+
+	status := http.StatusOK
+	if outcome.err != nil {
+		result.Error = outcome.err.Error()
+		status = statusForError(outcome.err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/zechtz/nyatictl/db"
+)
+
+// newDeployTestServer creates a Server backed by an in-memory database with a
+// single config owned by the given user.
+func newDeployTestServer(t *testing.T, configPath string, userID int) *Server {
+	t.Helper()
+
+	rawDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	rawDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { rawDB.Close() })
+
+	schema := `CREATE TABLE configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		path TEXT NOT NULL UNIQUE,
+		status TEXT DEFAULT '',
+		user_id INTEGER,
+		notification_defaults TEXT NOT NULL DEFAULT '',
+		content_hash TEXT NOT NULL DEFAULT '',
+		labels TEXT NOT NULL DEFAULT '[]',
+		environment_id INTEGER
+	);`
+	if _, err := rawDB.Exec(schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	if _, err := rawDB.Exec(
+		"INSERT INTO configs (name, description, path, status, user_id) VALUES (?, ?, ?, ?, ?)",
+		"test", "", configPath, "", userID,
+	); err != nil {
+		t.Fatalf("failed to insert config: %v", err)
+	}
+
+	metricsDB := db.NewMetricsDB(rawDB)
+	return &Server{
+		db:          metricsDB,
+		writeQueue:  NewWriteQueue(metricsDB.DB, 16),
+		sessions:    newSessionRegistry(),
+		limiter:     newDeployLimiter(0),
+		logChannels: make(map[string]*logBroadcaster),
+	}
+}
+
+// deployRequest builds an authenticated deploy request for the given user.
+func deployRequest(t *testing.T, url, configPath, host string, userID int) *http.Request {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]string{
+		"configPath": configPath,
+		"host":       host,
+		"sessionID":  "test-session",
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", url, bytes.NewBuffer(body))
+	ctx := context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: userID})
+	return req.WithContext(ctx)
+}
+
+func TestDeployAsyncReturnsImmediately(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, deployRequest(t, "/api/deploy", configPath, "server1", 1))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("async deploy status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestDeployAsyncMissingConfigFile(t *testing.T) {
+	// Config problems now fail the request up front instead of surfacing
+	// only as a goroutine log line
+	s := newDeployTestServer(t, "/nonexistent/nyati.yaml", 1)
+	defer s.writeQueue.Close()
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, deployRequest(t, "/api/deploy", "/nonexistent/nyati.yaml", "server1", 1))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("async deploy with missing config status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestDeploySyncMissingConfigFile(t *testing.T) {
+	s := newDeployTestServer(t, "/nonexistent/nyati.yaml", 1)
+	defer s.writeQueue.Close()
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, deployRequest(t, "/api/deploy?wait=true", "/nonexistent/nyati.yaml", "server1", 1))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("sync deploy with missing config status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+// writeUnreachableHostConfig writes a valid config whose host cannot be
+// reached, so deploys fail at connect time rather than at load time.
+func writeUnreachableHostConfig(t *testing.T) string {
+	t.Helper()
+
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	content := `version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 127.0.0.1
+    username: deploy
+    password: secret
+    host_key_policy: insecure-ignore
+tasks:
+  - name: build
+    cmd: "true"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return configPath
+}
+
+func TestDeploySyncReturnsResultShape(t *testing.T) {
+	// Config pointing at an unreachable host: the deploy fails at connect
+	// time, which still exercises the synchronous result path end to end
+	configPath := writeUnreachableHostConfig(t)
+
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, deployRequest(t, "/api/deploy?wait=true", configPath, "server1", 1))
+
+	var result DeployResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("sync deploy did not return JSON: %v (status %d)", err, rec.Code)
+	}
+
+	if result.Success {
+		t.Error("deploy against an unreachable host should not succeed")
+	}
+	if result.Error == "" {
+		t.Error("failed sync deploy should include an error message")
+	}
+	if result.ConfigPath != configPath || result.Host != "server1" {
+		t.Errorf("result identifies %s/%s, want %s/server1", result.ConfigPath, result.Host, configPath)
+	}
+	if result.Duration == "" {
+		t.Error("result should include the deploy duration")
+	}
+	if rec.Code == http.StatusOK {
+		t.Errorf("failed sync deploy should not return %d", http.StatusOK)
+	}
+}
+
+func TestDeployRejectsUnknownTaskNames(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	body, err := json.Marshal(map[string]any{
+		"configPath": configPath,
+		"host":       "server1",
+		"sessionID":  "test-session",
+		"tasks":      []string{"no-such-task"},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+	req := httptest.NewRequest("POST", "/api/deploy", bytes.NewBuffer(body))
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("deploy with unknown task status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestDeploySyncOwnershipStillEnforced(t *testing.T) {
+	s := newDeployTestServer(t, "/some/nyati.yaml", 1)
+	defer s.writeQueue.Close()
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, deployRequest(t, "/api/deploy?wait=true", "/some/nyati.yaml", "server1", 2))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("sync deploy for another user's config status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestDeployRejectsSecretParamOverride(t *testing.T) {
+	configPath := writeTestConfig(t, `version: "0.1.2"
+appname: demo
+param_schema:
+  db_password:
+    secret: true
+hosts:
+  server1:
+    host: 127.0.0.1
+    username: deploy
+    password: secret
+    host_key_policy: insecure-ignore
+tasks:
+  - name: build
+    cmd: "true"
+`)
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+
+	body, err := json.Marshal(map[string]any{
+		"configPath": configPath,
+		"host":       "server1",
+		"sessionID":  "test-session",
+		"params":     map[string]string{"db_password": "hunter2"},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+	req := httptest.NewRequest("POST", "/api/deploy", bytes.NewBuffer(body))
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("deploy with secret param status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if got := rec.Body.String(); !strings.Contains(got, "environment store") {
+		t.Errorf("rejection %q should point at the environment store", got)
+	}
+}
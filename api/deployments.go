@@ -0,0 +1,350 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/zechtz/nyatictl/logger"
+	"github.com/zechtz/nyatictl/tasks"
+)
+
+// recordDeployment stores a deployment history entry together with a snapshot
+// of the config file content as it was at deploy start.
+//
+// The snapshot is keyed by the SHA-256 of the raw content and stored gzipped;
+// repeated deploys of an unchanged file reuse the existing snapshot row.
+// Recording is best-effort: a failure is logged but never blocks the deploy.
+//
+// maxDeployNoteLength caps the free-text note attached to a deployment.
+const maxDeployNoteLength = 500
+
+// sanitizeDeployNote normalizes a user-supplied deploy note: control
+// characters are dropped, the text is trimmed and length-limited, and HTML
+// metacharacters are escaped so the note is safe to embed in rendered
+// reports and notification bodies as-is.
+//
+// Parameters:
+//   - note: the raw note from the deploy request
+//
+// Returns:
+//   - string: the sanitized note, possibly empty
+func sanitizeDeployNote(note string) string {
+	var b strings.Builder
+	for _, r := range note {
+		if unicode.IsControl(r) && r != '\n' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	cleaned := strings.TrimSpace(b.String())
+	if len(cleaned) > maxDeployNoteLength {
+		// Back up to a rune boundary so a multibyte character straddling the
+		// limit is dropped whole instead of leaving invalid UTF-8 behind
+		cut := maxDeployNoteLength
+		for cut > 0 && !utf8.RuneStart(cleaned[cut]) {
+			cut--
+		}
+		cleaned = cleaned[:cut]
+	}
+	return html.EscapeString(cleaned)
+}
+
+// Parameters:
+//   - userID: the deploying user (from JWT claims)
+//   - configPath: path of the config file being deployed
+//   - host: target host (or "all")
+//   - note: sanitized free-text note attached to this deployment
+//   - releaseVersion: the ${release_version} value this deployment runs with
+//
+// Returns:
+//   - int64: ID of the recorded deployment, 0 when recording failed
+func (s *Server) recordDeployment(userID int, configPath, host, note string, releaseVersion int64) int64 {
+	return s.recordDeploymentAttempt(userID, configPath, host, note, releaseVersion, 1)
+}
+
+// recordDeploymentAttempt is recordDeployment with an explicit attempt
+// number. The initial deploy is attempt 1; automatic retries of connection
+// failures record their own row counting up from 2.
+func (s *Server) recordDeploymentAttempt(userID int, configPath, host, note string, releaseVersion int64, attempt int) int64 {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to snapshot config for deployment history: %v", err))
+		return 0
+	}
+
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(content); err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to compress config snapshot: %v", err))
+		return 0
+	}
+
+	// INSERT OR IGNORE deduplicates: the hash is the primary key
+	if _, err := s.db.Exec(
+		"INSERT OR IGNORE INTO config_snapshots (hash, content) VALUES (?, ?)",
+		hash, compressed.Bytes(),
+	); err != nil {
+		logger.Error(fmt.Sprintf("Failed to store config snapshot: %v", err))
+		return 0
+	}
+
+	result, err := s.db.Exec(
+		"INSERT INTO deployments (config_path, host, user_id, config_hash, note, release_version, attempt) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		configPath, host, userID, hash, note, releaseVersion, attempt,
+	)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to record deployment: %v", err))
+		return 0
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to get deployment ID: %v", err))
+		return 0
+	}
+	return id
+}
+
+// recordDeploymentParams attaches the request parameter overrides a
+// deployment ran with to its history row, stored as JSON. Like the rest of
+// history recording it is best-effort and never blocks the deploy.
+//
+// Parameters:
+//   - id: deployment ID from recordDeployment, 0 is ignored
+//   - params: the overrides from the deploy request, possibly empty
+func (s *Server) recordDeploymentParams(id int64, params map[string]string) {
+	if id == 0 || len(params) == 0 {
+		return
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to encode deployment params: %v", err))
+		return
+	}
+	if _, err := s.db.Exec(
+		"UPDATE deployments SET params = ? WHERE id = ?", string(encoded), id,
+	); err != nil {
+		logger.Error(fmt.Sprintf("Failed to record deployment params: %v", err))
+	}
+}
+
+// recordDeploymentResults attaches the compact per-task, per-host outcomes of
+// a finished run to its history row, stored as JSON. Resume reads them back
+// to skip tasks that already succeeded. Best-effort like the rest of history
+// recording.
+//
+// Parameters:
+//   - id: deployment ID from recordDeployment, 0 is ignored
+//   - results: per-task, per-host results from the run, possibly empty
+func (s *Server) recordDeploymentResults(id int64, results []tasks.Result) {
+	if id == 0 || len(results) == 0 {
+		return
+	}
+	encoded, err := json.Marshal(tasks.Outcomes(results))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to encode deployment results: %v", err))
+		return
+	}
+	if _, err := s.db.Exec(
+		"UPDATE deployments SET results = ? WHERE id = ?", string(encoded), id,
+	); err != nil {
+		logger.Error(fmt.Sprintf("Failed to record deployment results: %v", err))
+	}
+}
+
+// finishDeployment fills in the outcome of a recorded deployment once the run
+// completes. Like recording, it is best-effort and never blocks the deploy.
+//
+// Parameters:
+//   - id: deployment ID from recordDeployment, 0 is ignored
+//   - success: whether every task succeeded
+//   - duration: total wall-clock run time
+func (s *Server) finishDeployment(id int64, success bool, duration time.Duration) {
+	if id == 0 {
+		return
+	}
+	status := "success"
+	if !success {
+		status = "error"
+	}
+	if _, err := s.db.Exec(
+		"UPDATE deployments SET status = ?, duration_ms = ? WHERE id = ?",
+		status, duration.Milliseconds(), id,
+	); err != nil {
+		logger.Error(fmt.Sprintf("Failed to record deployment outcome: %v", err))
+	}
+}
+
+// loadDeploymentSnapshot returns the decompressed config content deployed by
+// the given deployment, enforcing that it belongs to the requesting user.
+//
+// Returns:
+//   - string: the raw YAML content as it was deployed
+//   - int: an HTTP status code describing the failure, 0 on success
+//   - error: why the snapshot could not be loaded
+func (s *Server) loadDeploymentSnapshot(id, userID int) (string, int, error) {
+	var ownerID int
+	var compressed []byte
+	err := s.db.QueryRow(
+		`SELECT d.user_id, cs.content FROM deployments d
+		 JOIN config_snapshots cs ON cs.hash = d.config_hash
+		 WHERE d.id = ?`, id,
+	).Scan(&ownerID, &compressed)
+	if err != nil {
+		return "", http.StatusNotFound, fmt.Errorf("deployment %d not found", id)
+	}
+
+	if ownerID != userID {
+		return "", http.StatusForbidden, fmt.Errorf("unauthorized access to deployment %d", id)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+	content, err := io.ReadAll(gz)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to decompress snapshot: %v", err)
+	}
+	return string(content), 0, nil
+}
+
+// handleDeploymentConfig returns the exact config content a deployment used,
+// selected by GET /api/deployments/{id}/config.
+func (s *Server) handleDeploymentConfig(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid deployment ID", http.StatusBadRequest)
+		return
+	}
+
+	content, status, err := s.loadDeploymentSnapshot(id, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, content)
+}
+
+// handleCompareDeployments returns a unified diff between the config
+// snapshots of two deployments, selected by
+// GET /api/deployments/compare?a=<id>&b=<id>.
+func (s *Server) handleCompareDeployments(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	a, errA := strconv.Atoi(r.URL.Query().Get("a"))
+	b, errB := strconv.Atoi(r.URL.Query().Get("b"))
+	if errA != nil || errB != nil {
+		http.Error(w, "Query parameters 'a' and 'b' must be deployment IDs", http.StatusBadRequest)
+		return
+	}
+
+	contentA, status, err := s.loadDeploymentSnapshot(a, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	contentB, status, err := s.loadDeploymentSnapshot(b, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	diff := unifiedDiff(contentA, contentB,
+		fmt.Sprintf("deployment %d", a), fmt.Sprintf("deployment %d", b))
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, diff)
+}
+
+// RegisterDeploymentRoutes attaches the deployment history endpoints.
+func (s *Server) RegisterDeploymentRoutes(r *mux.Router) {
+	r.HandleFunc("/deployments/compare", s.handleCompareDeployments).Methods("GET")
+	r.HandleFunc("/deployments/export", s.handleExportDeployments).Methods("GET")
+	r.HandleFunc("/deployments/{id:[0-9]+}/config", s.handleDeploymentConfig).Methods("GET")
+	r.HandleFunc("/deployments/{id:[0-9]+}/resume", s.handleResumeDeployment).Methods("POST")
+}
+
+// unifiedDiff computes a line-based unified diff between two texts. Identical
+// inputs produce an empty string. The whole comparison is emitted as a single
+// hunk, which keeps the implementation small; config files are short enough
+// that hunk splitting isn't worth a diff dependency.
+func unifiedDiff(a, b, aLabel, bLabel string) string {
+	if a == b {
+		return ""
+	}
+
+	aLines := strings.Split(a, "\n")
+	bLines := strings.Split(b, "\n")
+
+	// Longest common subsequence table over lines
+	lcs := make([][]int, len(aLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bLines)+1)
+	}
+	for i := len(aLines) - 1; i >= 0; i-- {
+		for j := len(bLines) - 1; j >= 0; j-- {
+			if aLines[i] == bLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var body strings.Builder
+	i, j := 0, 0
+	for i < len(aLines) || j < len(bLines) {
+		switch {
+		case i < len(aLines) && j < len(bLines) && aLines[i] == bLines[j]:
+			body.WriteString(" " + aLines[i] + "\n")
+			i++
+			j++
+		case j < len(bLines) && (i == len(aLines) || lcs[i][j+1] >= lcs[i+1][j]):
+			body.WriteString("+" + bLines[j] + "\n")
+			j++
+		default:
+			body.WriteString("-" + aLines[i] + "\n")
+			i++
+		}
+	}
+
+	return fmt.Sprintf("--- %s\n+++ %s\n@@ -1,%d +1,%d @@\n%s",
+		aLabel, bLabel, len(aLines), len(bLines), body.String())
+}
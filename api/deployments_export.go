@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/zechtz/nyatictl/history"
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// handleExportDeployments streams deployment history in CSV or JSON for
+// spreadsheet-style reporting. Rows are written as they are read, so large
+// date ranges never buffer the full result set.
+//
+// Query parameters:
+//   - from: inclusive start day, YYYY-MM-DD (optional)
+//   - to: inclusive end day, YYYY-MM-DD (optional)
+//   - format: csv (default) or json
+//
+// Visibility matches the rest of the history endpoints: regular users
+// export only their own deployments; admins export everyone's.
+func (s *Server) handleExportDeployments(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	opts := history.Options{Format: history.FormatCSV}
+	if format := r.URL.Query().Get("format"); format != "" {
+		opts.Format = format
+	}
+	if opts.Format != history.FormatCSV && opts.Format != history.FormatJSON {
+		http.Error(w, fmt.Sprintf("Invalid format '%s'; expected csv or json", opts.Format), http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if from := r.URL.Query().Get("from"); from != "" {
+		if opts.From, err = history.ParseDay(from); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if to := r.URL.Query().Get("to"); to != "" {
+		if opts.To, err = history.ParseDay(to); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Regular users see their own history; admins see the whole install's
+	opts.UserID = claims.UserID
+	if s.isAdmin(claims.UserID) {
+		opts.UserID = 0
+	}
+
+	if opts.Format == history.FormatCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="deployments.csv"`)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	// Headers are sent with the first row, so a mid-stream failure can only
+	// be logged; clients see a truncated body
+	if err := history.Export(s.db.DB, opts, w); err != nil {
+		logger.Error(fmt.Sprintf("Failed to export deployments: %v", err))
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zechtz/nyatictl/history"
+)
+
+// exportDeployments runs the export handler for the given user and query.
+func exportDeployments(t *testing.T, s *Server, userID int, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.handleExportDeployments(rec, auditRequest(t, "GET", "/api/deployments/export"+query, nil, userID, nil))
+	return rec
+}
+
+func TestExportDeploymentsScopesToRequestingUser(t *testing.T) {
+	s := newAdminStatsTestServer(t)
+	seedDeployment(t, s, 1, "/tmp/admin.yaml", "success", 100, time.Hour)
+	seedDeployment(t, s, 2, "/tmp/dev.yaml", "success", 100, time.Hour)
+
+	rec := exportDeployments(t, s, 2, "?format=json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var rows []history.Row
+	if err := json.NewDecoder(rec.Body).Decode(&rows); err != nil {
+		t.Fatalf("export is not valid JSON: %v", err)
+	}
+	if len(rows) != 1 || rows[0].ConfigPath != "/tmp/dev.yaml" {
+		t.Errorf("regular user export = %+v, want only their own deployment", rows)
+	}
+
+	// The admin's export covers everyone
+	rec = exportDeployments(t, s, 1, "?format=json")
+	rows = nil
+	if err := json.NewDecoder(rec.Body).Decode(&rows); err != nil {
+		t.Fatalf("admin export is not valid JSON: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("admin export has %d rows, want both users' deployments", len(rows))
+	}
+}
+
+func TestExportDeploymentsCSVDefault(t *testing.T) {
+	s := newAdminStatsTestServer(t)
+	seedDeployment(t, s, 2, "/tmp/dev.yaml", "success", 100, time.Hour)
+
+	rec := exportDeployments(t, s, 2, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, "deployments.csv") {
+		t.Errorf("Content-Disposition = %q, want an attachment filename", cd)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("got %d CSV records, want header plus one row", len(records))
+	}
+}
+
+func TestExportDeploymentsRejectsBadParams(t *testing.T) {
+	s := newAdminStatsTestServer(t)
+
+	if rec := exportDeployments(t, s, 2, "?format=xml"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown format status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := exportDeployments(t, s, 2, "?from=March"); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad from date status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
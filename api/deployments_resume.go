@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/zechtz/nyatictl/cli"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+	"github.com/zechtz/nyatictl/notify"
+	"github.com/zechtz/nyatictl/tasks"
+)
+
+// ResumeDeploymentRequest is the optional body of a resume request.
+type ResumeDeploymentRequest struct {
+	SessionID string `json:"sessionID,omitempty"` // Log streaming session; generated when empty
+	Force     bool   `json:"force,omitempty"`     // Resume even if the config file changed since the original run
+}
+
+// handleResumeDeployment re-runs a failed deployment from where it stopped,
+// selected by POST /api/deployments/{id}/resume.
+//
+// The plan is rebuilt from the original run's recorded outcomes: task/host
+// pairs that succeeded are skipped, so execution continues from the first
+// failed or unrun task on each host. The original release_version is reused
+// so release paths line up, and the resumed run gets its own history entry
+// linked to the original through resumed_from. If the config file changed
+// since the original run the resume is refused with a 409 unless the request
+// sets force, and a forced resume is noted in the history entry.
+func (s *Server) handleResumeDeployment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid deployment ID", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional; an absent one resumes with defaults
+	var req ResumeDeploymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.SessionID == "" {
+		req.SessionID = uuid.NewString()
+	}
+
+	var (
+		configPath     string
+		host           string
+		ownerID        int
+		originalHash   string
+		releaseVersion int64
+		paramsJSON     string
+		resultsJSON    string
+	)
+	err = s.db.QueryRow(
+		`SELECT config_path, host, user_id, config_hash, release_version, params, results
+		 FROM deployments WHERE id = ?`, id,
+	).Scan(&configPath, &host, &ownerID, &originalHash, &releaseVersion, &paramsJSON, &resultsJSON)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Deployment %d not found", id), http.StatusNotFound)
+		return
+	}
+	if ownerID != claims.UserID {
+		http.Error(w, "You don't have permission to resume this deployment", http.StatusForbidden)
+		return
+	}
+
+	// Without recorded outcomes there is nothing to skip, so a "resume" would
+	// silently re-run everything; make the caller use a plain deploy instead
+	if resultsJSON == "" {
+		http.Error(w, fmt.Sprintf("Deployment %d has no recorded task results to resume from", id), http.StatusUnprocessableEntity)
+		return
+	}
+	var outcomes []tasks.TaskOutcome
+	if err := json.Unmarshal([]byte(resultsJSON), &outcomes); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode recorded task results: %v", err), http.StatusInternalServerError)
+		return
+	}
+	completed := tasks.CompletedFromOutcomes(outcomes)
+
+	var params map[string]string
+	if paramsJSON != "" {
+		if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to decode recorded params: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Resuming assumes the plan matches what partially ran; a changed file
+	// invalidates the recorded outcomes, so refuse unless explicitly forced
+	forced := false
+	if current, err := configFileHash(configPath); err == nil && current != originalHash {
+		if !req.Force {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error":         "Config file changed since the original run; re-deploy from scratch or retry with force=true",
+				"original_hash": originalHash,
+				"current_hash":  current,
+			})
+			return
+		}
+		forced = true
+		logger.Log(fmt.Sprintf("Warning: resuming deployment %d with force=true; file changed since the original run", id))
+	}
+
+	cfg, err := config.LoadWithParams(configPath, "0.1.2", params)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load config: %v", err), statusForError(err))
+		return
+	}
+	// Reuse the original release directory so partially created paths line up
+	if releaseVersion > 0 {
+		cfg.ReleaseVersion = releaseVersion
+	}
+
+	if !s.limiter.tryAcquire() {
+		http.Error(w, fmt.Sprintf("Too many concurrent deployments: %d of %d slots in use", s.limiter.running(), s.limiter.capacity()), http.StatusTooManyRequests)
+		return
+	}
+
+	// A resume writes the same release directory as the run it resumes, so
+	// it takes the target lock like any other deploy
+	if !s.targetLocks.tryAcquire(configPath, host) {
+		if !s.targetLocks.queues() {
+			s.limiter.release()
+			http.Error(w, fmt.Sprintf("A deployment of %s to %s is already running", configPath, host), http.StatusConflict)
+			return
+		}
+		s.targetLocks.acquire(configPath, host)
+	}
+
+	note := fmt.Sprintf("resumed from deployment %d", id)
+	if forced {
+		note += "; forced: config file changed since the original run"
+	}
+	deployID := s.recordDeployment(claims.UserID, configPath, host, note, cfg.ReleaseVersion)
+	s.recordDeploymentParams(deployID, params)
+	s.linkResumedDeployment(deployID, int64(id))
+
+	opts := cli.RunOptions{Debug: true, Completed: completed, AutoConfirm: true}
+
+	broadcaster := newLogBroadcaster(s.logBufferSize)
+	s.logLock.Lock()
+	s.logChannels[req.SessionID] = broadcaster
+	s.logLock.Unlock()
+
+	s.sessions.begin(req.SessionID, configPath, host, claims.UserID)
+	go func() {
+		defer s.limiter.release()
+		defer s.targetLocks.release(configPath, host)
+		defer s.sessions.end(req.SessionID)
+		defer s.retireLogChannel(req.SessionID, broadcaster)
+
+		start := time.Now()
+		results, err := cli.RunWithOptions(cfg, []string{"deploy", host}, opts)
+		summary := notify.Summarize(configPath, host, results, time.Since(start))
+		summary.Note = note
+		summary.Params = params
+		s.finishDeployment(deployID, err == nil, summary.Duration)
+		s.recordDeploymentResults(deployID, results)
+		s.notifier.DeployCompleted(summary)
+		s.notifyDeployTargets(configPath, summary)
+		if err != nil {
+			logger.Log(fmt.Sprintf("Error: %v", err))
+			return
+		}
+
+		s.markConfigDeployed(configPath)
+	}()
+
+	skipped := 0
+	for _, hosts := range completed {
+		skipped += len(hosts)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"sessionID":     req.SessionID,
+		"deployment_id": deployID,
+		"resumed_from":  id,
+		"skipped":       skipped,
+	})
+}
+
+// linkResumedDeployment points a resumed run's history entry at the original
+// deployment it continues. Best-effort like the rest of history recording.
+func (s *Server) linkResumedDeployment(id, originalID int64) {
+	if id == 0 {
+		return
+	}
+	if _, err := s.db.Exec(
+		"UPDATE deployments SET resumed_from = ? WHERE id = ?", originalID, id,
+	); err != nil {
+		logger.Error(fmt.Sprintf("Failed to link resumed deployment: %v", err))
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// resumeRequest builds an authenticated resume request for the given
+// deployment and user.
+func resumeRequest(t *testing.T, deploymentID string, userID int, body any) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("failed to marshal request: %v", err)
+		}
+	}
+	req := httptest.NewRequest("POST", "/api/deployments/"+deploymentID+"/resume", &buf)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: userID}))
+	return mux.SetURLVars(req, map[string]string{"id": deploymentID})
+}
+
+// recordResumableDeployment records a deployment with stored outcomes for the
+// resume tests and returns its ID.
+func recordResumableDeployment(t *testing.T, s *Server, path string, outcomes string) int64 {
+	t.Helper()
+
+	id := s.recordDeployment(1, path, "server1", "", 42)
+	if id == 0 {
+		t.Fatal("failed to record deployment")
+	}
+	if _, err := s.db.Exec("UPDATE deployments SET results = ? WHERE id = ?", outcomes, id); err != nil {
+		t.Fatalf("failed to store results: %v", err)
+	}
+	return id
+}
+
+func TestResumeDeploymentRequiresRecordedResults(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+	s.recordDeployment(1, path, "server1", "", 0)
+
+	rec := httptest.NewRecorder()
+	s.handleResumeDeployment(rec, resumeRequest(t, "1", 1, nil))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("resume without results status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestResumeDeploymentOwnershipEnforced(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+	recordResumableDeployment(t, s, path, `[{"task":"build","host":"server1","success":true}]`)
+
+	rec := httptest.NewRecorder()
+	s.handleResumeDeployment(rec, resumeRequest(t, "1", 2, nil))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("resume of another user's deployment status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestResumeDeploymentRejectsChangedConfig(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+	recordResumableDeployment(t, s, path, `[{"task":"build","host":"server1","success":true}]`)
+
+	// The file changes after the original run
+	if err := os.WriteFile(path, []byte("appname: demo\nversion: \"0.1.2\"\n"), 0644); err != nil {
+		t.Fatalf("failed to rewrite config: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleResumeDeployment(rec, resumeRequest(t, "1", 1, nil))
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("resume of a changed config status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode conflict body: %v", err)
+	}
+	if body["original_hash"] == "" || body["current_hash"] == "" || body["original_hash"] == body["current_hash"] {
+		t.Errorf("conflict body = %v, want both differing hashes", body)
+	}
+}
+
+func TestResumeDeploymentRunsAndLinksHistory(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+	s := newDeploymentTestServer(t)
+	s.writeQueue = NewWriteQueue(s.db.DB, 16)
+	defer s.writeQueue.Close()
+	s.sessions = newSessionRegistry()
+	s.limiter = newDeployLimiter(0)
+	s.logChannels = make(map[string]*logBroadcaster)
+
+	id := recordResumableDeployment(t, s, configPath,
+		`[{"task":"deploy","host":"server1","success":true}]`)
+
+	rec := httptest.NewRecorder()
+	s.handleResumeDeployment(rec, resumeRequest(t, "1", 1, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("resume status = %d, body %q", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		SessionID    string `json:"sessionID"`
+		DeploymentID int64  `json:"deployment_id"`
+		ResumedFrom  int64  `json:"resumed_from"`
+		Skipped      int    `json:"skipped"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("resume response is not JSON: %v", err)
+	}
+	if resp.SessionID == "" {
+		t.Error("resume should return a session ID for log streaming")
+	}
+	if resp.ResumedFrom != id || resp.Skipped != 1 {
+		t.Errorf("resumed_from = %d, skipped = %d; want %d and 1", resp.ResumedFrom, resp.Skipped, id)
+	}
+
+	// The resumed run has its own history entry linked to the original and
+	// reusing its release version
+	var resumedFrom, releaseVersion int64
+	if err := s.db.QueryRow(
+		"SELECT resumed_from, release_version FROM deployments WHERE id = ?", resp.DeploymentID,
+	).Scan(&resumedFrom, &releaseVersion); err != nil {
+		t.Fatalf("failed to load resumed entry: %v", err)
+	}
+	if resumedFrom != id {
+		t.Errorf("resumed_from = %d, want the original deployment %d", resumedFrom, id)
+	}
+	if releaseVersion != 42 {
+		t.Errorf("release_version = %d, want the original run's 42", releaseVersion)
+	}
+}
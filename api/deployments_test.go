@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/zechtz/nyatictl/db"
+)
+
+// newDeploymentTestServer creates a Server backed by an in-memory database
+// with the deployment history tables.
+func newDeploymentTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	rawDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	rawDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { rawDB.Close() })
+
+	schema := []string{
+		`CREATE TABLE config_snapshots (
+			hash TEXT PRIMARY KEY,
+			content BLOB NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE deployments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			config_path TEXT NOT NULL,
+			host TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			config_hash TEXT NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'pending',
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			release_version INTEGER NOT NULL DEFAULT 0,
+			attempt INTEGER NOT NULL DEFAULT 1,
+			params TEXT NOT NULL DEFAULT '',
+			results TEXT NOT NULL DEFAULT '',
+			resumed_from INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+	}
+	for _, stmt := range schema {
+		if _, err := rawDB.Exec(stmt); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+
+	return &Server{db: db.NewMetricsDB(rawDB)}
+}
+
+// writeTestConfig writes content to a temp nyati.yaml and returns its path.
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "nyati.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestRecordDeploymentDeduplicatesSnapshots(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+
+	// Two deploys of the same content, one of different content
+	s.recordDeployment(1, path, "server1", "", 0)
+	s.recordDeployment(1, path, "server2", "", 0)
+	if err := os.WriteFile(path, []byte("appname: demo\nversion: \"0.1.2\"\n"), 0644); err != nil {
+		t.Fatalf("failed to rewrite config: %v", err)
+	}
+	s.recordDeployment(1, path, "server1", "", 0)
+
+	var deployments, snapshots int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM deployments").Scan(&deployments); err != nil {
+		t.Fatalf("failed to count deployments: %v", err)
+	}
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM config_snapshots").Scan(&snapshots); err != nil {
+		t.Fatalf("failed to count snapshots: %v", err)
+	}
+
+	if deployments != 3 {
+		t.Errorf("deployments = %d, want 3", deployments)
+	}
+	if snapshots != 2 {
+		t.Errorf("snapshots = %d, want 2 (identical content must share one row)", snapshots)
+	}
+}
+
+func TestDeploymentConfigReturnsExactContent(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	content := "appname: demo\ntasks:\n  - name: build\n    cmd: make\n"
+	path := writeTestConfig(t, content)
+	s.recordDeployment(1, path, "server1", "", 0)
+
+	req := httptest.NewRequest("GET", "/api/deployments/1/config", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+
+	rec := httptest.NewRecorder()
+	s.handleDeploymentConfig(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("deployment config status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec.Body.String() != content {
+		t.Errorf("deployment config = %q, want the deployed content %q", rec.Body.String(), content)
+	}
+}
+
+func TestDeploymentConfigOwnershipEnforced(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+	s.recordDeployment(1, path, "server1", "", 0)
+
+	req := httptest.NewRequest("GET", "/api/deployments/1/config", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 2}))
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+
+	rec := httptest.NewRecorder()
+	s.handleDeploymentConfig(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("deployment config for another user's deployment status = %d, want %d",
+			rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestCompareDeploymentsReturnsUnifiedDiff(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\nversion: \"0.1.1\"\n")
+	s.recordDeployment(1, path, "server1", "", 0)
+	if err := os.WriteFile(path, []byte("appname: demo\nversion: \"0.1.2\"\n"), 0644); err != nil {
+		t.Fatalf("failed to rewrite config: %v", err)
+	}
+	s.recordDeployment(1, path, "server1", "", 0)
+
+	req := httptest.NewRequest("GET", "/api/deployments/compare?a=1&b=2", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleCompareDeployments(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("compare status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	diff := rec.Body.String()
+	for _, want := range []string{
+		"--- deployment 1",
+		"+++ deployment 2",
+		`-version: "0.1.1"`,
+		`+version: "0.1.2"`,
+		" appname: demo",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+}
+
+func TestCompareIdenticalDeploymentsIsEmpty(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+	s.recordDeployment(1, path, "server1", "", 0)
+	s.recordDeployment(1, path, "server2", "", 0)
+
+	req := httptest.NewRequest("GET", "/api/deployments/compare?a=1&b=2", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleCompareDeployments(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("compare status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("diff of identical snapshots should be empty, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestRecordDeploymentParams(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+
+	id := s.recordDeployment(1, path, "server1", "", 0)
+	s.recordDeploymentParams(id, map[string]string{"branch": "feature/login"})
+
+	var params string
+	if err := s.db.QueryRow("SELECT params FROM deployments WHERE id = ?", id).Scan(&params); err != nil {
+		t.Fatalf("failed to read deployment params: %v", err)
+	}
+	if !strings.Contains(params, `"branch":"feature/login"`) {
+		t.Errorf("params = %q, want the override recorded as JSON", params)
+	}
+
+	// Empty overrides leave the row untouched
+	id = s.recordDeployment(1, path, "server1", "", 0)
+	s.recordDeploymentParams(id, nil)
+	if err := s.db.QueryRow("SELECT params FROM deployments WHERE id = ?", id).Scan(&params); err != nil {
+		t.Fatalf("failed to read deployment params: %v", err)
+	}
+	if params != "" {
+		t.Errorf("params = %q, want empty without overrides", params)
+	}
+}
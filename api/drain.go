@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// sessionRegistry tracks in-flight deploy/task sessions so the server can
+// drain them during graceful shutdown instead of cutting a deployment
+// mid-task on SIGTERM.
+type sessionRegistry struct {
+	lock   sync.Mutex
+	active map[string]sessionInfo // Session ID -> what the session is working on
+	done   sync.WaitGroup
+}
+
+// sessionInfo describes what an in-flight session is doing, for the admin
+// activity endpoint.
+type sessionInfo struct {
+	ConfigPath string    // Config being deployed
+	Host       string    // Target host (or "all")
+	UserID     int       // User who started the session
+	StartedAt  time.Time // When the session began
+}
+
+// newSessionRegistry creates an empty registry.
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{
+		active: make(map[string]sessionInfo),
+	}
+}
+
+// begin registers a session as in-flight. Every begin must be paired with a
+// deferred end in the goroutine that runs the session.
+func (r *sessionRegistry) begin(sessionID, configPath, host string, userID int) {
+	r.lock.Lock()
+	r.active[sessionID] = sessionInfo{ConfigPath: configPath, Host: host, UserID: userID, StartedAt: time.Now()}
+	r.lock.Unlock()
+	r.done.Add(1)
+}
+
+// end marks a session as finished.
+func (r *sessionRegistry) end(sessionID string) {
+	r.lock.Lock()
+	delete(r.active, sessionID)
+	r.lock.Unlock()
+	r.done.Done()
+}
+
+// activeSessions returns the IDs of sessions still in flight.
+func (r *sessionRegistry) activeSessions() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	ids := make([]string, 0, len(r.active))
+	for id := range r.active {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// drain blocks until all in-flight sessions finish or the timeout elapses.
+//
+// Parameters:
+//   - timeout: how long to wait before giving up on running sessions
+//
+// Returns:
+//   - []string: session IDs that were still running when the timeout fired;
+//     empty when everything finished in time
+func (r *sessionRegistry) drain(timeout time.Duration) []string {
+	finished := make(chan struct{})
+	go func() {
+		r.done.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-time.After(timeout):
+		return r.activeSessions()
+	}
+}
+
+// drainDeploySessions waits for in-flight deploy/task sessions to finish,
+// logging any that had to be interrupted because the shutdown timeout fired.
+func (s *Server) drainDeploySessions() {
+	active := len(s.sessions.activeSessions())
+	if active == 0 {
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Waiting up to %s for %d active deploy session(s) to finish", s.shutdownTimeout, active))
+	interrupted := s.sessions.drain(s.shutdownTimeout)
+	for _, id := range interrupted {
+		logger.Warn(fmt.Sprintf("Deploy session %s interrupted by shutdown", id))
+	}
+}
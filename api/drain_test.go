@@ -0,0 +1,57 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrainWaitsForActiveSessions(t *testing.T) {
+	reg := newSessionRegistry()
+	reg.begin("session-1", "/tmp/nyati.yaml", "server1", 1)
+
+	finished := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		reg.end("session-1")
+		close(finished)
+	}()
+
+	interrupted := reg.drain(2 * time.Second)
+	if len(interrupted) != 0 {
+		t.Errorf("drain() interrupted = %v, want none", interrupted)
+	}
+
+	select {
+	case <-finished:
+	default:
+		t.Error("drain() returned before the in-flight session finished")
+	}
+}
+
+func TestDrainTimesOutAndReportsSessions(t *testing.T) {
+	reg := newSessionRegistry()
+	reg.begin("stuck-session", "/tmp/nyati.yaml", "server1", 1)
+	defer reg.end("stuck-session")
+
+	start := time.Now()
+	interrupted := reg.drain(50 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("drain() returned after %s, want at least the 50ms timeout", elapsed)
+	}
+
+	if len(interrupted) != 1 || interrupted[0] != "stuck-session" {
+		t.Errorf("drain() interrupted = %v, want [stuck-session]", interrupted)
+	}
+}
+
+func TestDrainWithNoActiveSessions(t *testing.T) {
+	reg := newSessionRegistry()
+
+	start := time.Now()
+	if interrupted := reg.drain(time.Second); len(interrupted) != 0 {
+		t.Errorf("drain() interrupted = %v, want none", interrupted)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("drain() with no sessions took %s, want immediate return", elapsed)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Sudo-mode for sensitive reads: a short-lived elevation token issued after
+// the user re-enters their password, required on top of the JWT for
+// operations like full secret reveals.
+const (
+	// elevationTTL is how long one password confirmation stays valid.
+	elevationTTL = 5 * time.Minute
+	// elevationTokenHeader carries the elevation token on requests that need it.
+	elevationTokenHeader = "X-Elevation-Token"
+)
+
+// elevation is one issued token's owner and expiry.
+type elevation struct {
+	userID  int
+	expires time.Time
+}
+
+// elevationRegistry tracks issued elevation tokens in memory. Tokens are
+// deliberately not persisted: a server restart simply requires the user to
+// confirm their password again.
+type elevationRegistry struct {
+	mu     sync.Mutex
+	tokens map[string]elevation
+}
+
+// newElevationRegistry creates an empty registry.
+func newElevationRegistry() *elevationRegistry {
+	return &elevationRegistry{tokens: make(map[string]elevation)}
+}
+
+// issue creates a token for the user, valid for elevationTTL, and prunes
+// expired entries while it holds the lock.
+func (e *elevationRegistry) issue(userID int) (string, time.Time) {
+	token := uuid.New().String()
+	expires := time.Now().Add(elevationTTL)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for t, el := range e.tokens {
+		if time.Now().After(el.expires) {
+			delete(e.tokens, t)
+		}
+	}
+	e.tokens[token] = elevation{userID: userID, expires: expires}
+	return token, expires
+}
+
+// valid reports whether token was issued to userID and has not expired.
+// A nil registry (a Server constructed without one in tests) accepts nothing.
+func (e *elevationRegistry) valid(token string, userID int) bool {
+	if e == nil || token == "" {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	el, ok := e.tokens[token]
+	return ok && el.userID == userID && time.Now().Before(el.expires)
+}
+
+// handleElevate confirms the requesting user's password and issues a
+// short-lived elevation token, selected by POST /api/auth/elevate with
+// {"password": "..."}. The caller presents the token in the
+// X-Elevation-Token header on requests that require sudo-mode.
+func (s *Server) handleElevate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var storedHash string
+	if err := s.db.QueryRow("SELECT password FROM users WHERE id = ?", claims.UserID).Scan(&storedHash); err != nil {
+		http.Error(w, "Invalid password", http.StatusUnauthorized)
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(req.Password)) != nil {
+		http.Error(w, "Invalid password", http.StatusUnauthorized)
+		return
+	}
+
+	token, expires := s.elevations.issue(claims.UserID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"token":      token,
+		"expires_at": expires.Format(time.RFC3339),
+	})
+}
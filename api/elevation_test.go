@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zechtz/nyatictl/env"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setElevationPassword stores a bcrypt hash of password for the given user.
+func setElevationPassword(t *testing.T, s *Server, userID int, password string) {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if _, err := s.db.Exec("UPDATE users SET password = ? WHERE id = ?", string(hash), userID); err != nil {
+		t.Fatalf("failed to set password: %v", err)
+	}
+}
+
+func TestHandleElevateIssuesShortLivedToken(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.elevations = newElevationRegistry()
+	setElevationPassword(t, s, 2, "hunter2secret")
+
+	req := auditRequest(t, "POST", "/api/auth/elevate", map[string]string{"password": "hunter2secret"}, 2, nil)
+	rec := httptest.NewRecorder()
+	s.handleElevate(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("elevate status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("elevate did not return JSON: %v", err)
+	}
+	if resp["token"] == "" {
+		t.Fatal("elevate response is missing the token")
+	}
+	if !s.elevations.valid(resp["token"], 2) {
+		t.Error("freshly issued token should be valid for its user")
+	}
+	if s.elevations.valid(resp["token"], 1) {
+		t.Error("token must not be valid for a different user")
+	}
+}
+
+func TestHandleElevateRejectsWrongPassword(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.elevations = newElevationRegistry()
+	setElevationPassword(t, s, 2, "hunter2secret")
+
+	req := auditRequest(t, "POST", "/api/auth/elevate", map[string]string{"password": "wrong"}, 2, nil)
+	rec := httptest.NewRecorder()
+	s.handleElevate(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("elevate with wrong password status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestElevationTokensExpire(t *testing.T) {
+	reg := newElevationRegistry()
+	token, _ := reg.issue(1)
+
+	reg.tokens[token] = elevation{userID: 1, expires: time.Now().Add(-time.Second)}
+	if reg.valid(token, 1) {
+		t.Error("an expired token must not validate")
+	}
+
+	var nilReg *elevationRegistry
+	if nilReg.valid("anything", 1) {
+		t.Error("a nil registry must accept nothing")
+	}
+}
+
+// seedSecretEnvironment inserts an environment for user 2 with one plain
+// variable and one secret encrypted under "testkey".
+func seedSecretEnvironment(t *testing.T, s *Server) {
+	t.Helper()
+
+	if _, err := s.db.Exec("INSERT INTO environments (name, description, user_id) VALUES ('prod', '', 2)"); err != nil {
+		t.Fatalf("failed to insert environment: %v", err)
+	}
+	cipher, err := env.EncryptValue("supersecretvalue", "testkey")
+	if err != nil {
+		t.Fatalf("failed to encrypt secret: %v", err)
+	}
+	for _, stmt := range []struct {
+		key, value string
+		secret     bool
+		enc        string
+	}{
+		{"REGION", "eu-west-1", false, ""},
+		{"API_TOKEN", "", true, cipher},
+	} {
+		if _, err := s.db.Exec(
+			"INSERT INTO environment_variables (environment_id, key, value, is_secret, encrypted_value) VALUES (1, ?, ?, ?, ?)",
+			stmt.key, stmt.value, stmt.secret, stmt.enc,
+		); err != nil {
+			t.Fatalf("failed to insert variable: %v", err)
+		}
+	}
+}
+
+// listVariables runs handleListVariables for user 2 against environment 1 and
+// returns the decoded variables keyed by name.
+func listVariables(t *testing.T, s *Server, query string, headers map[string]string) (int, map[string]string) {
+	t.Helper()
+
+	req := auditRequest(t, "GET", "/api/env/vars/1"+query, nil, 2, map[string]string{"env_id": "1"})
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	s.handleListVariables(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+
+	var variables []struct {
+		Key      string `json:"key"`
+		Value    string `json:"value"`
+		IsSecret bool   `json:"is_secret"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&variables); err != nil {
+		t.Fatalf("list variables did not return JSON: %v", err)
+	}
+	values := map[string]string{}
+	for _, v := range variables {
+		values[v.Key] = v.Value
+	}
+	return rec.Code, values
+}
+
+func TestListVariablesPartialRevealMasksSecrets(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.elevations = newElevationRegistry()
+	seedSecretEnvironment(t, s)
+
+	code, values := listVariables(t, s, "?reveal=partial", map[string]string{"X-Encryption-Key": "testkey"})
+	if code != http.StatusOK {
+		t.Fatalf("partial reveal status = %d, want %d", code, http.StatusOK)
+	}
+	if values["API_TOKEN"] != "su****ue" {
+		t.Errorf("partial reveal = %q, want first and last two characters only", values["API_TOKEN"])
+	}
+	if values["REGION"] != "eu-west-1" {
+		t.Errorf("plain variable = %q, want it unmasked", values["REGION"])
+	}
+
+	// The legacy show_secrets parameter now maps to the copy-safe form too
+	code, values = listVariables(t, s, "?show_secrets=true", map[string]string{"X-Encryption-Key": "testkey"})
+	if code != http.StatusOK || values["API_TOKEN"] != "su****ue" {
+		t.Errorf("show_secrets=true = %q (status %d), want the partial mask", values["API_TOKEN"], code)
+	}
+}
+
+func TestListVariablesFullRevealNeedsElevation(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.elevations = newElevationRegistry()
+	seedSecretEnvironment(t, s)
+
+	code, _ := listVariables(t, s, "?reveal=full", map[string]string{"X-Encryption-Key": "testkey"})
+	if code != http.StatusForbidden {
+		t.Fatalf("full reveal without elevation status = %d, want %d", code, http.StatusForbidden)
+	}
+
+	token, _ := s.elevations.issue(2)
+	code, values := listVariables(t, s, "?reveal=full", map[string]string{
+		"X-Encryption-Key":   "testkey",
+		elevationTokenHeader: token,
+	})
+	if code != http.StatusOK {
+		t.Fatalf("full reveal with elevation status = %d, want %d", code, http.StatusOK)
+	}
+	if values["API_TOKEN"] != "supersecretvalue" {
+		t.Errorf("full reveal = %q, want the decrypted value", values["API_TOKEN"])
+	}
+
+	// Every full reveal lands in the audit log with the key name
+	entries := auditEntries(t, s)
+	if len(entries) != 1 || entries[0].Action != "reveal" || entries[0].TargetType != "env_secret" || entries[0].TargetID != "1:API_TOKEN" {
+		t.Errorf("audit entries = %+v, want one reveal of env_secret 1:API_TOKEN", entries)
+	}
+}
+
+func TestListVariablesRejectsUnknownRevealMode(t *testing.T) {
+	s := newAuditTestServer(t)
+	seedSecretEnvironment(t, s)
+
+	code, _ := listVariables(t, s, "?reveal=everything", nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("unknown reveal mode status = %d, want %d", code, http.StatusBadRequest)
+	}
+}
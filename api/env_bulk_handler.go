@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/zechtz/nyatictl/env"
+)
+
+// handleBulkSetVariables applies a batch of variables to an environment
+// atomically: one transaction, one audit trail entry per batch rather than
+// per variable. Per-item validation errors are collected and returned
+// together; if any item is invalid, nothing is applied.
+func (s *Server) handleBulkSetVariables(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the environment ID from the URL
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["env_id"])
+	if err != nil {
+		http.Error(w, "Invalid environment ID", http.StatusBadRequest)
+		return
+	}
+
+	var items []env.BulkItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(items) == 0 {
+		http.Error(w, "At least one variable is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get the environment
+	environment, err := env.GetEnvironment(s.db.DB, id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Environment not found: %v", err), http.StatusNotFound)
+		return
+	}
+
+	// Verify user has access to this environment
+	if environment.UserID != claims.UserID {
+		http.Error(w, "Unauthorized access to this environment", http.StatusForbidden)
+		return
+	}
+
+	// A batch containing secrets needs the encryption key exactly once
+	for _, item := range items {
+		if item.IsSecret {
+			key, err := s.resolveEnvironmentKey(environment, r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if key == "" {
+				http.Error(w, "Encryption key required for secrets", http.StatusBadRequest)
+				return
+			}
+			environment.SetEncryptionKey(key)
+			break
+		}
+	}
+
+	// Apply the batch atomically; validation failures reject the whole batch
+	if bulkErrs := environment.BulkSet(items); len(bulkErrs) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{"errors": bulkErrs})
+		return
+	}
+
+	// Save changes in a single transaction
+	if err := env.SaveEnvironment(s.db.DB, environment); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save environment: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"message": fmt.Sprintf("%d variable(s) set successfully", len(items)),
+		"count":   len(items),
+	})
+}
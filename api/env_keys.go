@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/zechtz/nyatictl/env"
+)
+
+// masterKey returns the configured server master key, empty when none is set
+// (or in tests without application settings).
+func (s *Server) masterKey() string {
+	if s.appCfg == nil {
+		return ""
+	}
+	return s.appCfg.MasterKey
+}
+
+// resolveEnvironmentKey returns the encryption key a request should use for
+// an environment's secrets. An explicit X-Encryption-Key header always wins
+// (passphrase mode); otherwise, when the server has a master key and the
+// environment carries a wrapped data key, the data key is unwrapped so the
+// authenticated user needs no passphrase. An empty key with a nil error
+// means neither source is available and the caller should reject as before.
+//
+// Parameters:
+//   - environment: the loaded environment
+//   - r: the request, consulted for the X-Encryption-Key header
+//
+// Returns:
+//   - string: the key to pass to SetEncryptionKey, empty when unavailable
+//   - error: if the stored data key cannot be unwrapped (wrong master key)
+func (s *Server) resolveEnvironmentKey(environment *env.Environment, r *http.Request) (string, error) {
+	if key := r.Header.Get("X-Encryption-Key"); key != "" {
+		return key, nil
+	}
+
+	master := s.masterKey()
+	if master == "" || environment.WrappedKey == "" {
+		return "", nil
+	}
+	dataKey, err := env.UnwrapDataKey(environment.WrappedKey, master)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key for environment '%s': %v", environment.Name, err)
+	}
+	return dataKey, nil
+}
+
+// provisionDataKey gives a new environment its own wrapped data key when a
+// master key is configured. Without one the environment stays in passphrase
+// mode; existing environments are never converted implicitly.
+//
+// Parameters:
+//   - environment: the environment being created
+//
+// Returns:
+//   - error: if key generation or wrapping fails
+func (s *Server) provisionDataKey(environment *env.Environment) error {
+	master := s.masterKey()
+	if master == "" {
+		return nil
+	}
+	dataKey, err := env.GenerateDataKey()
+	if err != nil {
+		return err
+	}
+	environment.WrappedKey, err = env.WrapDataKey(dataKey, master)
+	return err
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zechtz/nyatictl/appconfig"
+	"github.com/zechtz/nyatictl/env"
+)
+
+func TestResolveEnvironmentKey(t *testing.T) {
+	s := &Server{appCfg: &appconfig.Config{MasterKey: "master-key"}}
+
+	environment := env.NewEnvironment("production", "")
+	if err := s.provisionDataKey(environment); err != nil {
+		t.Fatalf("provisionDataKey failed: %v", err)
+	}
+	if environment.WrappedKey == "" {
+		t.Fatal("provisionDataKey left no wrapped key")
+	}
+
+	// An explicit header always wins over the wrapped key
+	req := httptest.NewRequest("GET", "/api/env/vars/1", nil)
+	req.Header.Set("X-Encryption-Key", "passphrase")
+	key, err := s.resolveEnvironmentKey(environment, req)
+	if err != nil || key != "passphrase" {
+		t.Errorf("key = %q, %v; want the header passphrase", key, err)
+	}
+
+	// Without a header the wrapped data key is unwrapped and usable
+	key, err = s.resolveEnvironmentKey(environment, httptest.NewRequest("GET", "/api/env/vars/1", nil))
+	if err != nil || key == "" {
+		t.Fatalf("key = %q, %v; want the unwrapped data key", key, err)
+	}
+	environment.SetEncryptionKey(key)
+	if err := environment.Set("TOKEN", "s3cret", true); err != nil {
+		t.Fatalf("Set with the unwrapped key failed: %v", err)
+	}
+
+	// A wrong master key fails loudly instead of decrypting into garbage
+	wrong := &Server{appCfg: &appconfig.Config{MasterKey: "other"}}
+	if _, err := wrong.resolveEnvironmentKey(environment, httptest.NewRequest("GET", "/", nil)); err == nil {
+		t.Error("resolveEnvironmentKey succeeded with the wrong master key")
+	}
+
+	// Passphrase-mode environments and servers without a master key yield
+	// no key, so existing behavior applies
+	plain := env.NewEnvironment("legacy", "")
+	key, err = s.resolveEnvironmentKey(plain, httptest.NewRequest("GET", "/", nil))
+	if err != nil || key != "" {
+		t.Errorf("key = %q, %v for a passphrase-mode environment; want none", key, err)
+	}
+	bare := &Server{}
+	key, err = bare.resolveEnvironmentKey(environment, httptest.NewRequest("GET", "/", nil))
+	if err != nil || key != "" {
+		t.Errorf("key = %q, %v without a master key; want none", key, err)
+	}
+}
+
+func TestSecretsWorkWithoutPassphraseUnderMasterKey(t *testing.T) {
+	s := newEnvConfigTestServer(t)
+	s.appCfg = &appconfig.Config{MasterKey: "master-key"}
+
+	// Create the environment; the server provisions its wrapped data key
+	rec := httptest.NewRecorder()
+	s.handleCreateEnvironment(rec, auditRequest(t, "POST", "/api/env/create",
+		map[string]string{"name": "production"}, 1, nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("create response is not JSON: %v", err)
+	}
+	vars := map[string]string{"env_id": "1"}
+
+	// Set a secret with no X-Encryption-Key header
+	rec = httptest.NewRecorder()
+	s.handleSetVariable(rec, auditRequest(t, "POST", "/api/env/vars/1",
+		map[string]any{"key": "TOKEN", "value": "s3cret", "is_secret": true}, 1, vars))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("set secret status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Read it back, also without a header
+	rec = httptest.NewRecorder()
+	s.handleGetVariable(rec, auditRequest(t, "GET", "/api/env/vars/1/TOKEN", nil, 1,
+		map[string]string{"env_id": "1", "key": "TOKEN"}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get secret status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var got struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("get response is not JSON: %v", err)
+	}
+	if got.Value != "s3cret" {
+		t.Errorf("value = %q, want the decrypted secret", got.Value)
+	}
+
+	// The stored row stays encrypted at rest
+	var encrypted string
+	s.db.QueryRow("SELECT encrypted_value FROM environment_variables WHERE key = 'TOKEN'").Scan(&encrypted)
+	if encrypted == "" || encrypted == "s3cret" {
+		t.Errorf("encrypted_value = %q, want ciphertext", encrypted)
+	}
+
+	// Passphrase mode still works as the alternative: a header-supplied key
+	// wins, so a caller using a wrong passphrase cannot read the secret
+	rec = httptest.NewRecorder()
+	req := auditRequest(t, "GET", "/api/env/vars/1/TOKEN", nil, 1,
+		map[string]string{"env_id": "1", "key": "TOKEN"})
+	req.Header.Set("X-Encryption-Key", "wrong-passphrase")
+	s.handleGetVariable(rec, req)
+	if rec.Code == http.StatusOK {
+		t.Error("secret decrypted despite a wrong passphrase header")
+	}
+}
@@ -23,10 +23,12 @@ func (s *Server) InitEnvRoutes(r *mux.Router) {
 	api.HandleFunc("/switch/{id}", s.handleSwitchEnvironment).Methods("POST")
 	api.HandleFunc("/create", s.handleCreateEnvironment).Methods("POST")
 	api.HandleFunc("/delete/{id}", s.handleDeleteEnvironment).Methods("DELETE")
+	api.HandleFunc("/{id:[0-9]+}/export", s.handleExportEnvironment).Methods("GET")
 
 	// Variable management endpoints
 	api.HandleFunc("/vars/{env_id}", s.handleListVariables).Methods("GET")
 	api.HandleFunc("/vars/{env_id}", s.handleSetVariable).Methods("POST")
+	api.HandleFunc("/vars/{env_id}/bulk", s.handleBulkSetVariables).Methods("PUT")
 	api.HandleFunc("/vars/{env_id}/{key}", s.handleGetVariable).Methods("GET")
 	api.HandleFunc("/vars/{env_id}/{key}", s.handleDeleteVariable).Methods("DELETE")
 }
@@ -44,6 +46,12 @@ type VariableRequest struct {
 	IsSecret bool   `json:"is_secret"`
 }
 
+// Reveal modes accepted by the list-variables reveal query parameter.
+const (
+	revealPartialMode = "partial"
+	revealFullMode    = "full"
+)
+
 // handleListEnvironments returns a list of all environments for the current user
 func (s *Server) handleListEnvironments(w http.ResponseWriter, r *http.Request) {
 	rw := response.NewWriter(w)
@@ -102,22 +110,9 @@ func (s *Server) handleGetCurrentEnvironment(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	data := map[string]any{
-		"id":           environment.ID,
-		"name":         environment.Name,
-		"description":  environment.Description,
-		"is_current":   environment.IsCurrent,
-		"var_count":    len(environment.Variables),
-		"secret_count": len(environment.Secrets),
-	}
-
-	env, err := mapToEnvironment(data)
-	if err != nil {
-		rw.InternalServerError(err.Error())
-	}
-
-	rw.Success(env)
+	// The environment serializes directly; secret values stay in their
+	// encrypted form
+	rw.Success(environment)
 }
 
 // handleSwitchEnvironment changes the current active environment
@@ -147,6 +142,8 @@ func (s *Server) handleSwitchEnvironment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	s.recordAudit(claims.UserID, "switch", "environment", strconv.Itoa(id))
+
 	rw.Success(fmt.Sprintf("Switched to environment '%s'", environment.Name))
 }
 
@@ -176,12 +173,21 @@ func (s *Server) handleCreateEnvironment(w http.ResponseWriter, r *http.Request)
 	newEnv := env.NewEnvironment(req.Name, req.Description)
 	newEnv.UserID = claims.UserID
 
+	// With a master key configured, the environment gets its own wrapped
+	// data key so secrets work without a per-request passphrase
+	if err := s.provisionDataKey(newEnv); err != nil {
+		rw.InternalServerError(fmt.Sprintf("Failed to provision environment data key: %v", err))
+		return
+	}
+
 	// Save to database
 	if err := env.SaveEnvironment(s.db.DB, newEnv); err != nil {
 		rw.InternalServerError(fmt.Sprintf("Failed to create environment: %v", err))
 		return
 	}
 
+	s.recordAudit(claims.UserID, "create", "environment", strconv.Itoa(newEnv.ID))
+
 	rw.Created(newEnv)
 }
 
@@ -236,6 +242,8 @@ func (s *Server) handleDeleteEnvironment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	s.recordAudit(claims.UserID, "delete", "environment", strconv.Itoa(id))
+
 	rw.NoContent()
 }
 
@@ -257,7 +265,20 @@ func (s *Server) handleListVariables(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	showSecrets := r.URL.Query().Get("show_secrets") == "true"
+	// Secret visibility: reveal=partial shows the first and last two
+	// characters, reveal=full shows everything. The historical
+	// show_secrets=true parameter behaves like a full reveal.
+	reveal := r.URL.Query().Get("reveal")
+	if reveal == "" && r.URL.Query().Get("show_secrets") == "true" {
+		reveal = revealPartialMode // legacy callers get the copy-safe form
+	}
+	switch reveal {
+	case "", revealPartialMode, revealFullMode:
+	default:
+		http.Error(w, "reveal must be 'partial' or 'full'", http.StatusBadRequest)
+		return
+	}
+	showSecrets := reveal != ""
 
 	// Get the environment
 	environment, err := env.GetEnvironment(s.db.DB, id)
@@ -272,9 +293,20 @@ func (s *Server) handleListVariables(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Full reveals hand out copy-pasteable secrets, so they additionally
+	// require a recent password confirmation (sudo-mode)
+	if reveal == revealFullMode && !s.elevations.valid(r.Header.Get(elevationTokenHeader), claims.UserID) {
+		http.Error(w, "Full secret reveal requires recent password confirmation via POST /api/auth/elevate", http.StatusForbidden)
+		return
+	}
+
 	// Get encryption key if needed
 	if showSecrets && len(environment.Secrets) > 0 {
-		key := r.Header.Get("X-Encryption-Key")
+		key, err := s.resolveEnvironmentKey(environment, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		if key == "" {
 			http.Error(w, "Encryption key required to view secrets", http.StatusBadRequest)
 			return
@@ -310,10 +342,15 @@ func (s *Server) handleListVariables(w http.ResponseWriter, r *http.Request) {
 
 		if showSecrets {
 			value, _, err := environment.Get(k)
-			if err != nil {
+			switch {
+			case err != nil:
 				v.Value = fmt.Sprintf("<error: %v>", err)
-			} else {
+			case reveal == revealPartialMode:
+				v.Value = env.MaskValue(value)
+			default:
 				v.Value = value
+				// Every full reveal is traceable to a user and a key name
+				s.recordAudit(claims.UserID, "reveal", "env_secret", fmt.Sprintf("%d:%s", id, k))
 			}
 		} else {
 			v.Value = "<encrypted>"
@@ -370,7 +407,11 @@ func (s *Server) handleSetVariable(w http.ResponseWriter, r *http.Request) {
 
 	// If it's a secret, we need an encryption key
 	if req.IsSecret {
-		key := r.Header.Get("X-Encryption-Key")
+		key, err := s.resolveEnvironmentKey(environment, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		if key == "" {
 			http.Error(w, "Encryption key required for secrets", http.StatusBadRequest)
 			return
@@ -435,7 +476,11 @@ func (s *Server) handleGetVariable(w http.ResponseWriter, r *http.Request) {
 
 	// If it's a secret and we need a key
 	if isSecret && err == env.ErrNoEncryptionKey {
-		encKey := r.Header.Get("X-Encryption-Key")
+		encKey, keyErr := s.resolveEnvironmentKey(environment, r)
+		if keyErr != nil {
+			http.Error(w, keyErr.Error(), http.StatusInternalServerError)
+			return
+		}
 		if encKey == "" {
 			http.Error(w, "Encryption key required for secrets", http.StatusBadRequest)
 			return
@@ -513,18 +558,70 @@ func (s *Server) handleDeleteVariable(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func mapToEnvironment(data map[string]any) (*env.Environment, error) {
-	// Step 1: Marshal the map to JSON
-	jsonBytes, err := json.Marshal(data)
+// handleExportEnvironment streams an environment as a downloadable .env file:
+// plain variables plus decrypted secrets, scoped to the owner. When the
+// environment holds secrets the X-Encryption-Key header is required; the
+// decrypted values go only into the response body, never into logs.
+func (s *Server) handleExportEnvironment(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the environment ID from the URL
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid environment ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get the environment
+	environment, err := env.GetEnvironment(s.db.DB, id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Environment not found: %v", err), http.StatusNotFound)
+		return
+	}
+
+	// Verify user has access to this environment
+	if environment.UserID != claims.UserID {
+		http.Error(w, "Unauthorized access to this environment", http.StatusForbidden)
+		return
+	}
+
+	// Exports include decrypted secrets, so the key is mandatory whenever
+	// any secret exists
+	if len(environment.Secrets) > 0 {
+		key, err := s.resolveEnvironmentKey(environment, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if key == "" {
+			http.Error(w, "Encryption key required to export secrets", http.StatusBadRequest)
+			return
+		}
+
+		environment.SetEncryptionKey(key)
+	}
+
+	content, err := env.DotenvContent(environment)
 	if err != nil {
-		return nil, err
+		// A wrong key surfaces here as a decryption failure; the error
+		// carries key names only, never values
+		http.Error(w, fmt.Sprintf("Failed to export environment: %v", err), http.StatusBadRequest)
+		return
 	}
 
-	// Step 2: Unmarshal JSON into the struct
-	var env env.Environment
-	if err := json.Unmarshal(jsonBytes, &env); err != nil {
-		return nil, err
+	// Every export of decrypted secrets is traceable to a user
+	if len(environment.Secrets) > 0 {
+		s.recordAudit(claims.UserID, "export", "environment", idStr)
 	}
 
-	return &env, nil
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", environment.Name+".env"))
+	w.Write([]byte(content))
 }
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleGetCurrentEnvironmentReturnsRealObject(t *testing.T) {
+	s := newAuditTestServer(t)
+	seedSecretEnvironment(t, s)
+	if _, err := s.db.Exec("UPDATE environments SET is_current = 1 WHERE id = 1"); err != nil {
+		t.Fatalf("failed to mark environment current: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleGetCurrentEnvironment(rec, auditRequest(t, "GET", "/api/env/current", nil, 2, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("current environment status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	// The wire shape of an environment, without the env package's internal
+	// locking so the decoded value is freely copyable in assertions
+	var resp struct {
+		Success bool `json:"success"`
+		Data    struct {
+			ID          int               `json:"id"`
+			Name        string            `json:"name"`
+			Description string            `json:"description"`
+			Variables   map[string]string `json:"variables"`
+			Secrets     map[string]string `json:"secrets"`
+			UserID      int               `json:"user_id"`
+			IsCurrent   bool              `json:"is_current"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if !resp.Success {
+		t.Fatal("response envelope reports failure")
+	}
+
+	got := resp.Data
+	if got.ID != 1 || got.Name != "prod" || !got.IsCurrent || got.UserID != 2 {
+		t.Errorf("environment = %+v, want id 1 'prod' current for user 2", got)
+	}
+
+	// The full environment comes through: plain variables as-is, secret
+	// values still in their encrypted form
+	if got.Variables["REGION"] != "eu-west-1" {
+		t.Errorf("variables = %v, want REGION carried through", got.Variables)
+	}
+	cipher, ok := got.Secrets["API_TOKEN"]
+	if !ok || cipher == "" {
+		t.Fatalf("secrets = %v, want the API_TOKEN ciphertext present", got.Secrets)
+	}
+	if cipher == "supersecretvalue" {
+		t.Error("secret was serialized in plaintext")
+	}
+}
+
+func TestHandleGetCurrentEnvironmentWithoutActive(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.handleGetCurrentEnvironment(rec, auditRequest(t, "GET", "/api/env/current", nil, 2, nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("no active environment status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+// exportEnvironment runs the export handler for the given user and headers.
+func exportEnvironment(t *testing.T, s *Server, userID int, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := auditRequest(t, "GET", "/api/env/1/export", nil, userID, map[string]string{"id": "1"})
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	s.handleExportEnvironment(rec, req)
+	return rec
+}
+
+func TestExportEnvironmentWithKey(t *testing.T) {
+	s := newAuditTestServer(t)
+	seedSecretEnvironment(t, s)
+
+	rec := exportEnvironment(t, s, 2, map[string]string{"X-Encryption-Key": "testkey"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, "prod.env") {
+		t.Errorf("Content-Disposition = %q, want an attachment named after the environment", cd)
+	}
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "REGION=") || !strings.Contains(body, "eu-west-1") {
+		t.Errorf("export missing the plain variable:\n%s", body)
+	}
+	if !strings.Contains(body, "supersecretvalue") {
+		t.Errorf("export missing the decrypted secret:\n%s", body)
+	}
+}
+
+func TestExportEnvironmentRequiresKeyForSecrets(t *testing.T) {
+	s := newAuditTestServer(t)
+	seedSecretEnvironment(t, s)
+
+	rec := exportEnvironment(t, s, 2, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("export without key status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	// A wrong key fails the decryption rather than exporting garbage
+	rec = exportEnvironment(t, s, 2, map[string]string{"X-Encryption-Key": "not-the-key"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("export with wrong key status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if strings.Contains(rec.Body.String(), "supersecretvalue") {
+		t.Error("secret leaked despite the wrong key")
+	}
+}
+
+func TestExportEnvironmentScopedToOwner(t *testing.T) {
+	s := newAuditTestServer(t)
+	seedSecretEnvironment(t, s)
+
+	rec := exportEnvironment(t, s, 1, map[string]string{"X-Encryption-Key": "testkey"})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("other user's export status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
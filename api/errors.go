@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/ssh"
+)
+
+// statusForError maps the typed errors surfaced by config loading and SSH
+// execution to HTTP status codes, so handlers don't fall back to 500 for
+// every failure:
+//
+//   - missing configs, hosts or tasks -> 404
+//   - invalid config content (cycles, auth misconfiguration) -> 422
+//   - unreachable hosts -> 502
+//   - anything else -> 500
+func statusForError(err error) int {
+	var hostNotFound ssh.ErrHostNotFound
+	var taskNotFound config.ErrTaskNotFound
+	var connectFailed ssh.ErrConnectFailed
+	var circular config.ErrCircularDependency
+	var invalidParam config.ErrInvalidParam
+
+	switch {
+	case errors.As(err, &hostNotFound), errors.As(err, &taskNotFound), errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.As(err, &circular), errors.As(err, &invalidParam), errors.Is(err, ssh.ErrAuthConfig):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &connectFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
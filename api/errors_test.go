@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/ssh"
+)
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "host not found",
+			err:  ssh.ErrHostNotFound{Host: "server1"},
+			want: http.StatusNotFound,
+		},
+		{
+			name: "task not found",
+			err:  config.ErrTaskNotFound{Name: "clean"},
+			want: http.StatusNotFound,
+		},
+		{
+			name: "missing config file",
+			err:  fmt.Errorf("failed to read config: %w", fs.ErrNotExist),
+			want: http.StatusNotFound,
+		},
+		{
+			name: "circular dependency",
+			err:  config.ErrCircularDependency{Cycle: []string{"a", "b", "a"}},
+			want: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "auth misconfiguration",
+			err:  fmt.Errorf("host server1: %w", ssh.ErrAuthConfig),
+			want: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "connection failure",
+			err:  ssh.ErrConnectFailed{Host: "server1", Cause: errors.New("connection refused")},
+			want: http.StatusBadGateway,
+		},
+		{
+			name: "unknown error",
+			err:  errors.New("something else"),
+			want: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusForError(tt.err); got != tt.want {
+				t.Errorf("statusForError(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
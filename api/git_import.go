@@ -0,0 +1,387 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/env"
+	"github.com/zechtz/nyatictl/gitsource"
+)
+
+// gitConfigSource is one config's Git provenance: which repository, ref and
+// in-repo path the managed copy was imported from, and the commit it
+// currently matches.
+type gitConfigSource struct {
+	Repo       string `json:"repo"`
+	Ref        string `json:"ref,omitempty"`
+	Path       string `json:"path"`
+	LastCommit string `json:"last_commit,omitempty"`
+}
+
+// managedConfigsDir is where imported config files are stored, next to the
+// database so backups naturally cover both.
+func (s *Server) managedConfigsDir() string {
+	if s.appCfg != nil && s.appCfg.DatabasePath != "" {
+		return filepath.Join(filepath.Dir(s.appCfg.DatabasePath), "imported-configs")
+	}
+	return "imported-configs"
+}
+
+// loadGitCredential returns the user's stored credential for a remote host,
+// decrypted with the caller-supplied key. No stored credential yields the
+// zero (anonymous) credential; a stored credential without a key to decrypt
+// it is an error, because silently cloning anonymously would just produce a
+// confusing auth failure from the remote.
+func (s *Server) loadGitCredential(userID int, host, encryptionKey string) (gitsource.Credential, error) {
+	var token, sshKey string
+	err := s.db.QueryRow(
+		"SELECT token, ssh_key FROM git_credentials WHERE user_id = ? AND host = ?",
+		userID, host,
+	).Scan(&token, &sshKey)
+	if err == sql.ErrNoRows {
+		return gitsource.Credential{}, nil
+	}
+	if err != nil {
+		return gitsource.Credential{}, fmt.Errorf("failed to load git credential: %v", err)
+	}
+
+	if encryptionKey == "" {
+		return gitsource.Credential{}, fmt.Errorf("a credential for %s is stored; supply encryption_key to use it", host)
+	}
+
+	var cred gitsource.Credential
+	if token != "" {
+		if cred.Token, err = env.DecryptValue(token, encryptionKey); err != nil {
+			return gitsource.Credential{}, fmt.Errorf("failed to decrypt token for %s: %v", host, err)
+		}
+	}
+	if sshKey != "" {
+		if cred.SSHKey, err = env.DecryptValue(sshKey, encryptionKey); err != nil {
+			return gitsource.Credential{}, fmt.Errorf("failed to decrypt SSH key for %s: %v", host, err)
+		}
+	}
+	return cred, nil
+}
+
+// handleSaveGitCredential stores one user's credential for a Git host, with
+// the secret parts encrypted under a user-supplied key that is never
+// persisted, mirroring how env secrets are kept.
+func (s *Server) handleSaveGitCredential(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Host          string `json:"host"`
+		Token         string `json:"token"`
+		SSHKey        string `json:"ssh_key"`
+		EncryptionKey string `json:"encryption_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Host == "" {
+		http.Error(w, "host is required", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" && req.SSHKey == "" {
+		http.Error(w, "one of token or ssh_key is required", http.StatusBadRequest)
+		return
+	}
+	if req.EncryptionKey == "" {
+		http.Error(w, "encryption_key is required to store credentials", http.StatusBadRequest)
+		return
+	}
+
+	var token, sshKey string
+	var err error
+	if req.Token != "" {
+		if token, err = env.EncryptValue(req.Token, req.EncryptionKey); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to encrypt token: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.SSHKey != "" {
+		if sshKey, err = env.EncryptValue(req.SSHKey, req.EncryptionKey); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to encrypt SSH key: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO git_credentials (user_id, host, token, ssh_key)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, host) DO UPDATE SET
+			token = excluded.token,
+			ssh_key = excluded.ssh_key
+	`, claims.UserID, req.Host, token, sshKey)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save credential: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "saved", "host": req.Host})
+}
+
+// handleImportGitConfig fetches a config file from a Git repository,
+// validates it, stores it under the managed configs directory and registers
+// it with full provenance so it can later be re-synced from the same source.
+func (s *Server) handleImportGitConfig(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		URL           string `json:"url"`
+		Ref           string `json:"ref"`
+		Path          string `json:"path"`
+		Name          string `json:"name"`
+		Description   string `json:"description"`
+		EncryptionKey string `json:"encryption_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	// Only plain remotes may reach git: a transport-helper or option-like
+	// value would execute in the server process
+	if err := gitsource.ValidateRemote(req.URL, req.Ref); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Path == "" {
+		req.Path = "nyati.yaml"
+	}
+
+	cred, err := s.loadGitCredential(claims.UserID, gitsource.RepoHost(req.URL), req.EncryptionKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	file, err := gitsource.Fetch(req.URL, req.Ref, req.Path, cred)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch from repository: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// The managed copy is named after the repository so two imports of
+	// nyati.yaml from different repos do not collide
+	dir := s.managedConfigsDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create configs directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+	managedPath := filepath.Join(dir, managedConfigName(req.URL, req.Path))
+	if err := os.WriteFile(managedPath, file.Content, 0644); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to store config: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Validate the stored file exactly the way a deploy would load it; an
+	// invalid config is removed again so no broken entry lingers
+	if _, err := config.Load(managedPath, "0.1.2"); err != nil {
+		os.Remove(managedPath)
+		http.Error(w, fmt.Sprintf("Fetched config is invalid: %v", err), statusForError(err))
+		return
+	}
+
+	entry := ConfigEntry{
+		Name:        req.Name,
+		Description: req.Description,
+		Path:        managedPath,
+		Status:      "imported",
+		UserID:      claims.UserID,
+	}
+	if entry.Name == "" {
+		entry.Name = strings.TrimSuffix(filepath.Base(req.URL), ".git")
+	}
+	if hash, err := configFileHash(managedPath); err == nil {
+		entry.ContentHash = hash
+	}
+	if err := SaveConfig(s.db.DB, entry); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to register config: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := s.db.QueryRow("SELECT id FROM configs WHERE path = ?", managedPath).Scan(&entry.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to look up imported config: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO config_git_sources (config_id, repo, ref, path, last_commit, synced_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (config_id) DO UPDATE SET
+			repo = excluded.repo,
+			ref = excluded.ref,
+			path = excluded.path,
+			last_commit = excluded.last_commit,
+			synced_at = excluded.synced_at
+	`, entry.ID, req.URL, req.Ref, req.Path, file.Commit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to record provenance: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"config": entry,
+		"source": gitConfigSource{Repo: req.URL, Ref: req.Ref, Path: req.Path, LastCommit: file.Commit},
+	})
+}
+
+// handleSyncGitConfig re-fetches an imported config from its recorded Git
+// source and reports the diff against the managed copy. With "apply": true
+// the fetched version replaces the managed copy and the provenance is
+// advanced to the fetched commit.
+func (s *Server) handleSyncGitConfig(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid config ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Apply         bool   `json:"apply"`
+		EncryptionKey string `json:"encryption_key"`
+	}
+	if r.Body != nil {
+		// An empty body means "just show me the diff"
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	var configPath string
+	var ownerID int
+	err = s.db.QueryRow("SELECT path, user_id FROM configs WHERE id = ?", id).Scan(&configPath, &ownerID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Config not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Database error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if ownerID != claims.UserID {
+		http.Error(w, "You don't have permission to sync this config", http.StatusForbidden)
+		return
+	}
+
+	var source gitConfigSource
+	err = s.db.QueryRow(
+		"SELECT repo, ref, path, last_commit FROM config_git_sources WHERE config_id = ?", id,
+	).Scan(&source.Repo, &source.Ref, &source.Path, &source.LastCommit)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Config was not imported from a Git repository", http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Database error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// The stored remote is replayed on every sync, so it is re-validated the
+	// same way the import path validated it
+	if err := gitsource.ValidateRemote(source.Repo, source.Ref); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	cred, err := s.loadGitCredential(claims.UserID, gitsource.RepoHost(source.Repo), req.EncryptionKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	file, err := gitsource.Fetch(source.Repo, source.Ref, source.Path, cred)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch from repository: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	current, err := os.ReadFile(configPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read managed config: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	diff := unifiedDiff(string(current), string(file.Content), "managed", source.Ref+"@"+shortCommit(file.Commit))
+	applied := false
+	if req.Apply && diff != "" {
+		if err := os.WriteFile(configPath, file.Content, 0644); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to update managed config: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.refreshConfigHash(configPath)
+		applied = true
+	}
+	if req.Apply || diff == "" {
+		// Record that the managed copy now matches this commit
+		if _, err := s.db.Exec(
+			"UPDATE config_git_sources SET last_commit = ?, synced_at = CURRENT_TIMESTAMP WHERE config_id = ?",
+			file.Commit, id,
+		); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to record sync: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	source.LastCommit = file.Commit
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"source":  source,
+		"changed": diff != "",
+		"applied": applied,
+		"diff":    diff,
+	})
+}
+
+// managedConfigName builds a stable filename for one repo+path import, so
+// re-importing the same source overwrites its own copy instead of piling up.
+func managedConfigName(repoURL, path string) string {
+	repo := strings.TrimSuffix(filepath.Base(repoURL), ".git")
+	name := repo + "-" + strings.ReplaceAll(filepath.ToSlash(filepath.Clean(path)), "/", "-")
+	// Keep only filesystem-friendly characters
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-' || r == '_' || r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, name)
+	return name
+}
+
+// shortCommit abbreviates a commit hash for labels and messages.
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/appconfig"
+	"github.com/zechtz/nyatictl/db"
+	"github.com/zechtz/nyatictl/env"
+)
+
+// validGitConfigYAML is a loadable config committed into test repositories.
+const validGitConfigYAML = `version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 127.0.0.1
+    username: deploy
+    password: secret
+    host_key_policy: insecure-ignore
+tasks:
+  - name: build
+    cmd: "true"
+`
+
+// newGitTestServer builds a server with the configs, git provenance and git
+// credentials tables, storing managed configs under a per-test directory.
+func newGitTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	rawDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	rawDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { rawDB.Close() })
+
+	schema := []string{
+		`CREATE TABLE configs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			description TEXT,
+			path TEXT NOT NULL UNIQUE,
+			status TEXT DEFAULT '',
+			user_id INTEGER,
+			notification_defaults TEXT NOT NULL DEFAULT '',
+			content_hash TEXT NOT NULL DEFAULT '',
+			labels TEXT NOT NULL DEFAULT '[]',
+			environment_id INTEGER
+		);`,
+		`CREATE TABLE config_git_sources (
+			config_id INTEGER PRIMARY KEY,
+			repo TEXT NOT NULL,
+			ref TEXT NOT NULL DEFAULT '',
+			path TEXT NOT NULL,
+			last_commit TEXT NOT NULL DEFAULT '',
+			synced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE git_credentials (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			host TEXT NOT NULL,
+			token TEXT NOT NULL DEFAULT '',
+			ssh_key TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (user_id, host)
+		);`,
+	}
+	for _, stmt := range schema {
+		if _, err := rawDB.Exec(stmt); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+
+	return &Server{
+		db:     db.NewMetricsDB(rawDB),
+		appCfg: &appconfig.Config{DatabasePath: filepath.Join(t.TempDir(), "nyatictl.db")},
+	}
+}
+
+// newGitConfigRepo commits content as nyati.yaml in a fresh local repository,
+// serves it over git's smart HTTP protocol (the handlers refuse file://
+// remotes) and returns the http:// clone URL and working directory.
+func newGitConfigRepo(t *testing.T, content string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "main", ".")
+	if err := os.WriteFile(filepath.Join(dir, "nyati.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write nyati.yaml: %v", err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	execPath, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		t.Fatalf("git --exec-path failed: %v", err)
+	}
+	ts := httptest.NewServer(&cgi.Handler{
+		Path: filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend"),
+		Env:  []string{"GIT_PROJECT_ROOT=" + dir, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	t.Cleanup(ts.Close)
+	return ts.URL + "/.git", dir
+}
+
+func TestImportGitConfigRejectsUnsafeRemotes(t *testing.T) {
+	s := newGitTestServer(t)
+
+	for _, repoURL := range []string{
+		"ext::sh -c id",
+		"file:///srv/repos/x.git",
+		"--upload-pack=id",
+		"/srv/repos/x.git",
+	} {
+		rec := httptest.NewRecorder()
+		s.handleImportGitConfig(rec, auditRequest(t, "POST", "/api/configs/import-git",
+			map[string]string{"url": repoURL}, 1, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("import of %q status = %d, want %d", repoURL, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	// An option-like ref is rejected even with a clean URL
+	rec := httptest.NewRecorder()
+	s.handleImportGitConfig(rec, auditRequest(t, "POST", "/api/configs/import-git",
+		map[string]string{"url": "https://github.com/org/repo.git", "ref": "-b"}, 1, nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("import with option-like ref status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestImportGitConfigCreatesManagedEntry(t *testing.T) {
+	s := newGitTestServer(t)
+	repoURL, _ := newGitConfigRepo(t, validGitConfigYAML)
+
+	rec := httptest.NewRecorder()
+	s.handleImportGitConfig(rec, auditRequest(t, "POST", "/api/configs/import-git",
+		map[string]string{"url": repoURL, "name": "demo"}, 1, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp struct {
+		Config ConfigEntry     `json:"config"`
+		Source gitConfigSource `json:"source"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("import response is not JSON: %v", err)
+	}
+	if resp.Config.ID == 0 || resp.Config.Name != "demo" {
+		t.Errorf("config = %+v, want a registered entry named demo", resp.Config)
+	}
+	if resp.Source.Repo != repoURL || len(resp.Source.LastCommit) != 40 {
+		t.Errorf("source = %+v, want provenance with a full commit", resp.Source)
+	}
+
+	// The managed copy must exist and match the repository content
+	stored, err := os.ReadFile(resp.Config.Path)
+	if err != nil {
+		t.Fatalf("managed config missing: %v", err)
+	}
+	if string(stored) != validGitConfigYAML {
+		t.Error("managed copy differs from the repository content")
+	}
+}
+
+func TestImportGitConfigRejectsInvalidConfig(t *testing.T) {
+	s := newGitTestServer(t)
+	repoURL, _ := newGitConfigRepo(t, "not: a: valid: config\n")
+
+	rec := httptest.NewRecorder()
+	s.handleImportGitConfig(rec, auditRequest(t, "POST", "/api/configs/import-git",
+		map[string]string{"url": repoURL}, 1, nil))
+
+	if rec.Code == http.StatusOK {
+		t.Fatal("import accepted an invalid config")
+	}
+
+	// Nothing may linger: no managed file, no registered entry
+	var count int
+	s.db.QueryRow("SELECT COUNT(*) FROM configs").Scan(&count)
+	if count != 0 {
+		t.Errorf("configs table has %d entries after a failed import, want 0", count)
+	}
+	entries, _ := os.ReadDir(s.managedConfigsDir())
+	if len(entries) != 0 {
+		t.Errorf("managed dir has %d files after a failed import, want 0", len(entries))
+	}
+}
+
+func TestSyncGitConfigReportsDiffAndApplies(t *testing.T) {
+	s := newGitTestServer(t)
+	repoURL, repoDir := newGitConfigRepo(t, validGitConfigYAML)
+
+	rec := httptest.NewRecorder()
+	s.handleImportGitConfig(rec, auditRequest(t, "POST", "/api/configs/import-git",
+		map[string]string{"url": repoURL}, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import failed: %s", rec.Body.String())
+	}
+	var imported struct {
+		Config ConfigEntry `json:"config"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&imported); err != nil {
+		t.Fatalf("import response is not JSON: %v", err)
+	}
+
+	// Change the file upstream
+	updated := strings.Replace(validGitConfigYAML, "appname: demo", "appname: demo-v2", 1)
+	if err := os.WriteFile(filepath.Join(repoDir, "nyati.yaml"), []byte(updated), 0644); err != nil {
+		t.Fatalf("failed to update repo file: %v", err)
+	}
+	commit := exec.Command("git", "commit", "-q", "-am", "rename app")
+	commit.Dir = repoDir
+	commit.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if out, err := commit.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+
+	vars := map[string]string{"id": "1"}
+
+	// Without apply: the diff is reported and the managed copy is untouched
+	rec = httptest.NewRecorder()
+	s.handleSyncGitConfig(rec, auditRequest(t, "POST", "/api/configs/1/sync", map[string]any{}, 1, vars))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("sync status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var preview struct {
+		Changed bool   `json:"changed"`
+		Applied bool   `json:"applied"`
+		Diff    string `json:"diff"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&preview); err != nil {
+		t.Fatalf("sync response is not JSON: %v", err)
+	}
+	if !preview.Changed || preview.Applied {
+		t.Errorf("preview = %+v, want changed and not applied", preview)
+	}
+	if !strings.Contains(preview.Diff, "demo-v2") {
+		t.Errorf("diff %q should show the upstream change", preview.Diff)
+	}
+	managed, _ := os.ReadFile(imported.Config.Path)
+	if string(managed) != validGitConfigYAML {
+		t.Error("preview sync modified the managed copy")
+	}
+
+	// With apply: the managed copy is replaced
+	rec = httptest.NewRecorder()
+	s.handleSyncGitConfig(rec, auditRequest(t, "POST", "/api/configs/1/sync",
+		map[string]any{"apply": true}, 1, vars))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("apply sync status = %d: %s", rec.Code, rec.Body.String())
+	}
+	managed, _ = os.ReadFile(imported.Config.Path)
+	if string(managed) != updated {
+		t.Error("apply sync did not update the managed copy")
+	}
+}
+
+func TestSyncGitConfigOwnershipEnforced(t *testing.T) {
+	s := newGitTestServer(t)
+	repoURL, _ := newGitConfigRepo(t, validGitConfigYAML)
+
+	rec := httptest.NewRecorder()
+	s.handleImportGitConfig(rec, auditRequest(t, "POST", "/api/configs/import-git",
+		map[string]string{"url": repoURL}, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import failed: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleSyncGitConfig(rec, auditRequest(t, "POST", "/api/configs/1/sync", nil, 2,
+		map[string]string{"id": "1"}))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("sync by another user status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestGitCredentialStoredEncrypted(t *testing.T) {
+	s := newGitTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.handleSaveGitCredential(rec, auditRequest(t, "POST", "/api/git-credentials",
+		map[string]string{"host": "github.com", "token": "tok-secret", "encryption_key": "k3y"}, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("save credential status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var stored string
+	if err := s.db.QueryRow("SELECT token FROM git_credentials WHERE user_id = 1 AND host = 'github.com'").Scan(&stored); err != nil {
+		t.Fatalf("credential row missing: %v", err)
+	}
+	if strings.Contains(stored, "tok-secret") {
+		t.Error("token stored in plaintext")
+	}
+	if plain, err := env.DecryptValue(stored, "k3y"); err != nil || plain != "tok-secret" {
+		t.Errorf("decrypt = %q, %v; want the original token", plain, err)
+	}
+
+	// A stored credential without the key to decrypt it is an error, not a
+	// silent anonymous clone
+	if _, err := s.loadGitCredential(1, "github.com", ""); err == nil {
+		t.Error("loadGitCredential without a key should fail when a credential exists")
+	}
+	cred, err := s.loadGitCredential(1, "github.com", "k3y")
+	if err != nil || cred.Token != "tok-secret" {
+		t.Errorf("loadGitCredential = %+v, %v; want the decrypted token", cred, err)
+	}
+
+	// No credential stored means anonymous, regardless of key
+	cred, err = s.loadGitCredential(1, "gitlab.com", "")
+	if err != nil || cred.Token != "" {
+		t.Errorf("loadGitCredential for an unknown host = %+v, %v; want anonymous", cred, err)
+	}
+}
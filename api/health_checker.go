@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+	"github.com/zechtz/nyatictl/ssh"
+)
+
+const (
+	// hostStatusReachable means the last SSH connect to the host succeeded.
+	hostStatusReachable = "reachable"
+
+	// hostStatusUnreachable means the last SSH connect failed; the error is
+	// stored alongside the status.
+	hostStatusUnreachable = "unreachable"
+
+	// hostStatusUnknown means the host was skipped because checking it
+	// would require interaction (no stored password or key).
+	hostStatusUnknown = "unknown"
+
+	// healthCheckConcurrency bounds how many hosts are probed at once.
+	healthCheckConcurrency = 4
+
+	// healthCheckTimeout is the per-host connect deadline.
+	healthCheckTimeout = 10 * time.Second
+)
+
+// HostStatus is one host's reachability as shown in the configs list.
+type HostStatus struct {
+	Host      string    `json:"host"`                 // Host alias from the config file
+	Status    string    `json:"status"`               // reachable, unreachable or unknown
+	LastError string    `json:"last_error,omitempty"` // Why the last check failed, if it did
+	CheckedAt time.Time `json:"checked_at"`           // When the host was last probed
+}
+
+// healthChecker probes every saved config's hosts with a short SSH connect
+// on a fixed interval and records the outcome in config_host_status. It
+// never runs commands and never prompts: hosts whose auth would require
+// interaction are recorded as unknown. A nil checker (health checks
+// disabled) is safe to use; all its methods are no-ops.
+type healthChecker struct {
+	db       *sql.DB
+	pool     *ssh.ConnectionPool
+	interval time.Duration
+}
+
+// newHealthChecker builds a checker, or nil when checks are disabled.
+//
+// Parameters:
+//   - enabled: whether background health checks are turned on
+//   - interval: how often every config is re-checked
+//   - database: handle used to list configs and store results
+//
+// Returns:
+//   - *healthChecker: the checker, or nil when disabled
+func newHealthChecker(enabled bool, interval time.Duration, database *sql.DB) *healthChecker {
+	if !enabled || interval <= 0 {
+		return nil
+	}
+	return &healthChecker{
+		db:       database,
+		pool:     ssh.NewConnectionPool(nil),
+		interval: interval,
+	}
+}
+
+// run is the background loop, registered with the server's lifecycle. It
+// checks once at startup and then on every interval tick until the context
+// is cancelled.
+func (c *healthChecker) run(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	c.checkAll()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			c.checkAll()
+		}
+	}
+}
+
+// close releases the checker's pooled connections.
+func (c *healthChecker) close() {
+	if c == nil {
+		return
+	}
+	c.pool.Close()
+}
+
+// checkAll probes the hosts of every saved config with bounded concurrency.
+func (c *healthChecker) checkAll() {
+	entries, err := LoadConfigs(c.db)
+	if err != nil {
+		logger.Log(fmt.Sprintf("Health check: failed to load configs: %v", err))
+		return
+	}
+
+	// Semaphore bounding concurrent SSH connects across all configs
+	slots := make(chan struct{}, healthCheckConcurrency)
+	for _, entry := range entries {
+		cfg, err := config.Load(entry.Path, "0.1.2")
+		if err != nil {
+			// A config that does not load has no hosts to report on;
+			// validation surfaces the error elsewhere
+			continue
+		}
+		for name, host := range cfg.Hosts {
+			slots <- struct{}{}
+			go func(configID int, name string, host config.Host) {
+				defer func() { <-slots }()
+				c.checkHost(configID, name, host)
+			}(entry.ID, name, host)
+		}
+	}
+
+	// Wait for the in-flight checks before returning
+	for i := 0; i < healthCheckConcurrency; i++ {
+		slots <- struct{}{}
+	}
+}
+
+// checkHost connects to one host through the pool and records the outcome.
+// Hosts without stored credentials are marked unknown rather than prompting.
+func (c *healthChecker) checkHost(configID int, name string, host config.Host) {
+	if host.Password == "" && host.PrivateKey == "" {
+		c.recordHostStatus(configID, name, hostStatusUnknown, "authentication would require interaction")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	conn, err := c.pool.GetConnection(ctx, name, host, false)
+	if err != nil {
+		c.recordHostStatus(configID, name, hostStatusUnreachable, err.Error())
+		return
+	}
+	c.pool.ReleaseConnection(conn)
+	c.recordHostStatus(configID, name, hostStatusReachable, "")
+}
+
+// recordHostStatus upserts one host's check result.
+func (c *healthChecker) recordHostStatus(configID int, host, status, lastError string) {
+	_, err := c.db.Exec(`
+		INSERT INTO config_host_status (config_id, host, status, last_error, checked_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (config_id, host) DO UPDATE SET
+			status = excluded.status,
+			last_error = excluded.last_error,
+			checked_at = excluded.checked_at
+	`, configID, host, status, lastError)
+	if err != nil {
+		logger.Log(fmt.Sprintf("Health check: failed to record status for %s: %v", host, err))
+	}
+}
+
+// loadHostStatuses returns the stored reachability of one config's hosts.
+//
+// Parameters:
+//   - database: handle to read from
+//   - configID: the config whose hosts are requested
+//
+// Returns:
+//   - []HostStatus: one entry per checked host, ordered by host name
+//   - error: if the query fails
+func loadHostStatuses(database *sql.DB, configID int) ([]HostStatus, error) {
+	rows, err := database.Query(
+		"SELECT host, status, last_error, checked_at FROM config_host_status WHERE config_id = ? ORDER BY host",
+		configID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load host statuses: %v", err)
+	}
+	defer rows.Close()
+
+	var statuses []HostStatus
+	for rows.Next() {
+		var s HostStatus
+		if err := rows.Scan(&s.Host, &s.Status, &s.LastError, &s.CheckedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan host status: %v", err)
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, rows.Err()
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/ssh"
+)
+
+// newHealthTestChecker wraps a deploy test server's database with the
+// config_host_status table and an enabled checker.
+func newHealthTestChecker(t *testing.T, s *Server) *healthChecker {
+	t.Helper()
+
+	schema := `CREATE TABLE config_host_status (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		config_id INTEGER NOT NULL,
+		host TEXT NOT NULL,
+		status TEXT NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (config_id, host)
+	);`
+	if _, err := s.db.Exec(schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+
+	checker := newHealthChecker(true, time.Minute, s.db.DB)
+	t.Cleanup(checker.close)
+	return checker
+}
+
+func TestNewHealthCheckerDisabled(t *testing.T) {
+	if c := newHealthChecker(false, time.Minute, nil); c != nil {
+		t.Error("a disabled checker should be nil")
+	}
+	if c := newHealthChecker(true, 0, nil); c != nil {
+		t.Error("a zero interval should disable the checker")
+	}
+
+	// The nil checker must be safe to run and close
+	var nilChecker *healthChecker
+	if err := nilChecker.run(context.Background()); err != nil {
+		t.Errorf("nil checker run returned %v, want nil", err)
+	}
+	nilChecker.close()
+}
+
+func TestRecordHostStatusUpserts(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/nyati.yaml", 1)
+	checker := newHealthTestChecker(t, s)
+
+	checker.recordHostStatus(1, "server1", hostStatusUnreachable, "dial tcp: refused")
+	checker.recordHostStatus(1, "server1", hostStatusReachable, "")
+	checker.recordHostStatus(1, "server2", hostStatusUnknown, "authentication would require interaction")
+
+	statuses, err := loadHostStatuses(s.db.DB, 1)
+	if err != nil {
+		t.Fatalf("loadHostStatuses failed: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("got %d statuses, want 2 (the re-check must overwrite, not append)", len(statuses))
+	}
+	if statuses[0].Host != "server1" || statuses[0].Status != hostStatusReachable || statuses[0].LastError != "" {
+		t.Errorf("server1 = %+v, want the latest reachable result", statuses[0])
+	}
+	if statuses[1].Host != "server2" || statuses[1].Status != hostStatusUnknown {
+		t.Errorf("server2 = %+v, want unknown", statuses[1])
+	}
+}
+
+func TestCheckHostSkipsInteractiveAuth(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/nyati.yaml", 1)
+	checker := newHealthTestChecker(t, s)
+
+	// No password and no key: probing would prompt, so the host must be
+	// recorded as unknown without any connection attempt
+	checker.checkHost(1, "server1", config.Host{Host: "example.com", Username: "deploy"})
+
+	statuses, err := loadHostStatuses(s.db.DB, 1)
+	if err != nil {
+		t.Fatalf("loadHostStatuses failed: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Status != hostStatusUnknown {
+		t.Fatalf("statuses = %+v, want one unknown entry", statuses)
+	}
+}
+
+func TestCheckHostRecordsUnreachable(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/nyati.yaml", 1)
+	checker := newHealthTestChecker(t, s)
+
+	// A closed local port fails the connect immediately
+	checker.checkHost(1, "server1", config.Host{
+		Host:          "127.0.0.1:1",
+		Username:      "deploy",
+		Password:      "secret",
+		HostKeyPolicy: ssh.HostKeyPolicyInsecureIgnore,
+	})
+
+	statuses, err := loadHostStatuses(s.db.DB, 1)
+	if err != nil {
+		t.Fatalf("loadHostStatuses failed: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Status != hostStatusUnreachable {
+		t.Fatalf("statuses = %+v, want one unreachable entry", statuses)
+	}
+	if statuses[0].LastError == "" {
+		t.Error("an unreachable host must record why")
+	}
+}
+
+func TestGetConfigsIncludesHostStatus(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/nyati.yaml", 1)
+	checker := newHealthTestChecker(t, s)
+	s.health = checker
+
+	checker.recordHostStatus(1, "server1", hostStatusReachable, "")
+
+	rec := httptest.NewRecorder()
+	s.handleGetConfigs(rec, auditRequest(t, "GET", "/api/configs", nil, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get configs status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var page PagedResponse[ConfigEntry]
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("get configs did not return JSON: %v", err)
+	}
+	if len(page.Items) != 1 {
+		t.Fatalf("got %d configs, want 1", len(page.Items))
+	}
+	badges := page.Items[0].HostStatus
+	if len(badges) != 1 || badges[0].Host != "server1" || badges[0].Status != hostStatusReachable {
+		t.Errorf("host_status = %+v, want server1 reachable", badges)
+	}
+}
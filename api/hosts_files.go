@@ -0,0 +1,125 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// hostsFilesSetting is the settings-table key holding the JSON array of
+// registered hosts file paths. Deploy requests may only name a registered
+// file, so the API never reads arbitrary filesystem paths on a caller's say.
+const hostsFilesSetting = "hosts_files"
+
+// RegisterHostsFileRequest is the body of POST and DELETE /api/hosts-files.
+type RegisterHostsFileRequest struct {
+	Path string `json:"path"` // Path to the hosts file on the server
+}
+
+// registeredHostsFiles returns the registered hosts file paths, in
+// registration order. A missing or corrupt setting is an empty list.
+func registeredHostsFiles(db *sql.DB) []string {
+	raw := GetSetting(db, hostsFilesSetting, "")
+	if raw == "" {
+		return nil
+	}
+	var paths []string
+	if err := json.Unmarshal([]byte(raw), &paths); err != nil {
+		logger.Log(fmt.Sprintf("Ignoring corrupt %s setting: %v", hostsFilesSetting, err))
+		return nil
+	}
+	return paths
+}
+
+// hostsFileRegistered reports whether path is in the registered list.
+func hostsFileRegistered(db *sql.DB, path string) bool {
+	return slices.Contains(registeredHostsFiles(db), path)
+}
+
+// storeHostsFiles persists the registered list back to the settings table.
+func storeHostsFiles(db *sql.DB, paths []string) error {
+	encoded, err := json.Marshal(paths)
+	if err != nil {
+		return err
+	}
+	return SetSetting(db, hostsFilesSetting, string(encoded))
+}
+
+// HandleListHostsFiles returns the registered hosts files, so the deploy UI
+// can offer them as targets. Any authenticated user may list; only admins
+// register or remove entries.
+func (s *Server) HandleListHostsFiles(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	paths := registeredHostsFiles(s.db.DB)
+	if paths == nil {
+		paths = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"hosts_files": paths})
+}
+
+// HandleRegisterHostsFile adds a hosts file to the registered list. Admin
+// only. The file must load as a valid hosts document at registration time,
+// so a typoed path or malformed file fails here rather than mid-deploy.
+func (s *Server) HandleRegisterHostsFile(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	var req RegisterHostsFileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+		http.Error(w, "Invalid request body; expected a hosts file path", http.StatusBadRequest)
+		return
+	}
+
+	if err := config.ValidateHostsFile(req.Path); err != nil {
+		http.Error(w, fmt.Sprintf("Hosts file validation failed: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	paths := registeredHostsFiles(s.db.DB)
+	if !slices.Contains(paths, req.Path) {
+		if err := storeHostsFiles(s.db.DB, append(paths, req.Path)); err != nil {
+			logger.Error(fmt.Sprintf("Failed to register hosts file %s: %v", req.Path, err))
+			http.Error(w, "Failed to register hosts file", http.StatusInternalServerError)
+			return
+		}
+		s.recordAudit(claims.UserID, "register", "hosts_file", req.Path)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// HandleUnregisterHostsFile removes a hosts file from the registered list.
+// Admin only; removing an unregistered path is a harmless no-op.
+func (s *Server) HandleUnregisterHostsFile(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	var req RegisterHostsFileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+		http.Error(w, "Invalid request body; expected a hosts file path", http.StatusBadRequest)
+		return
+	}
+
+	paths := registeredHostsFiles(s.db.DB)
+	remaining := slices.DeleteFunc(slices.Clone(paths), func(p string) bool { return p == req.Path })
+	if len(remaining) != len(paths) {
+		if err := storeHostsFiles(s.db.DB, remaining); err != nil {
+			logger.Error(fmt.Sprintf("Failed to unregister hosts file %s: %v", req.Path, err))
+			http.Error(w, "Failed to unregister hosts file", http.StatusInternalServerError)
+			return
+		}
+		s.recordAudit(claims.UserID, "unregister", "hosts_file", req.Path)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestHostsFile writes a valid hosts document and returns its path.
+func writeTestHostsFile(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "hosts.prod.yaml")
+	content := `
+hosts:
+  web1:
+    host: "web1.prod.example.com"
+    username: "ops"
+    password: "pass"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write hosts file: %v", err)
+	}
+	return path
+}
+
+// listHostsFiles fetches the registered hosts files as the given user.
+func listHostsFiles(t *testing.T, s *Server, userID int) []string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleListHostsFiles)(rec, auditRequest(t, "GET", "/api/hosts-files", nil, userID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list hosts files status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var resp map[string][]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("list response is not JSON: %v", err)
+	}
+	return resp["hosts_files"]
+}
+
+func TestRegisterAndListHostsFiles(t *testing.T) {
+	s := newAuditTestServer(t)
+	path := writeTestHostsFile(t)
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleRegisterHostsFile)(rec, auditRequest(t, "POST", "/api/hosts-files", RegisterHostsFileRequest{Path: path}, 1, nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+
+	// Any user may list; the new entry is visible and marked registered
+	if paths := listHostsFiles(t, s, 2); len(paths) != 1 || paths[0] != path {
+		t.Errorf("registered hosts files = %v, want [%s]", paths, path)
+	}
+	if !hostsFileRegistered(s.db.DB, path) {
+		t.Error("hostsFileRegistered = false for a registered path")
+	}
+
+	// Re-registering the same path stays a single entry
+	rec = httptest.NewRecorder()
+	s.withUser(s.HandleRegisterHostsFile)(rec, auditRequest(t, "POST", "/api/hosts-files", RegisterHostsFileRequest{Path: path}, 1, nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("re-register status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if paths := listHostsFiles(t, s, 1); len(paths) != 1 {
+		t.Errorf("hosts files after re-register = %v, want a single entry", paths)
+	}
+}
+
+func TestRegisterHostsFileRequiresAdmin(t *testing.T) {
+	s := newAuditTestServer(t)
+	path := writeTestHostsFile(t)
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleRegisterHostsFile)(rec, auditRequest(t, "POST", "/api/hosts-files", RegisterHostsFileRequest{Path: path}, 2, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("register as non-admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec = httptest.NewRecorder()
+	s.withUser(s.HandleUnregisterHostsFile)(rec, auditRequest(t, "DELETE", "/api/hosts-files", RegisterHostsFileRequest{Path: path}, 2, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("unregister as non-admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestRegisterHostsFileValidatesTheFile(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleRegisterHostsFile)(rec, auditRequest(t, "POST", "/api/hosts-files", RegisterHostsFileRequest{Path: "/nonexistent/hosts.yaml"}, 1, nil))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("register missing file status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if paths := listHostsFiles(t, s, 1); len(paths) != 0 {
+		t.Errorf("hosts files after failed register = %v, want none", paths)
+	}
+}
+
+func TestUnregisterHostsFile(t *testing.T) {
+	s := newAuditTestServer(t)
+	path := writeTestHostsFile(t)
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleRegisterHostsFile)(rec, auditRequest(t, "POST", "/api/hosts-files", RegisterHostsFileRequest{Path: path}, 1, nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	rec = httptest.NewRecorder()
+	s.withUser(s.HandleUnregisterHostsFile)(rec, auditRequest(t, "DELETE", "/api/hosts-files", RegisterHostsFileRequest{Path: path}, 1, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("unregister status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	if paths := listHostsFiles(t, s, 1); len(paths) != 0 {
+		t.Errorf("hosts files after unregister = %v, want none", paths)
+	}
+	if hostsFileRegistered(s.db.DB, path) {
+		t.Error("hostsFileRegistered = true after unregister")
+	}
+}
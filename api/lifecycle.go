@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// workerStateRunning means the worker's loop is still executing.
+	workerStateRunning = "running"
+
+	// workerStateStopped means the worker exited cleanly, normally because
+	// the lifecycle context was cancelled during shutdown.
+	workerStateStopped = "stopped"
+
+	// workerStateFailed means the worker returned an error while the server
+	// was still supposed to be running.
+	workerStateFailed = "failed"
+)
+
+// WorkerStatus describes one registered background worker for the admin
+// goroutines endpoint.
+type WorkerStatus struct {
+	Name      string     `json:"name"`                 // Worker name given at registration
+	State     string     `json:"state"`                // running, stopped or failed
+	StartedAt time.Time  `json:"started_at"`           // When the worker goroutine was launched
+	StoppedAt *time.Time `json:"stopped_at,omitempty"` // When it exited, once it has
+	Error     string     `json:"error,omitempty"`      // Why it failed, for failed workers
+}
+
+// lifecycle owns the server's long-lived background goroutines: the log
+// dispatcher, the deploy retry worker and the health checker. Every worker
+// registers through Go, runs under a shared context that Close cancels, and
+// reports a fatal error through the fatal channel so the server can shut
+// down gracefully instead of limping along without one of its loops.
+type lifecycle struct {
+	ctx    context.Context    // Shared context cancelled on shutdown
+	cancel context.CancelFunc // Cancels ctx; called by stop
+	wg     sync.WaitGroup     // Tracks running workers so stop can wait for them
+
+	// fatal carries the first fatal worker error. It is buffered so a failing
+	// worker never blocks on reporting; later errors are dropped because one
+	// fatal error already means the server is going down.
+	fatal chan error
+
+	lock    sync.Mutex
+	workers map[string]*workerRecord // Worker name -> latest state
+}
+
+// workerRecord is the mutable state behind one WorkerStatus.
+type workerRecord struct {
+	state     string
+	startedAt time.Time
+	stoppedAt time.Time
+	err       error
+}
+
+// newLifecycle builds an empty lifecycle manager ready to register workers.
+func newLifecycle() *lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycle{
+		ctx:     ctx,
+		cancel:  cancel,
+		fatal:   make(chan error, 1),
+		workers: make(map[string]*workerRecord),
+	}
+}
+
+// Go launches fn as a tracked background worker. fn must return promptly
+// once the passed context is cancelled; returning a non-nil error before
+// then marks the worker failed and reports the error as fatal.
+//
+// Parameters:
+//   - name: identifies the worker in the goroutines endpoint, unique per worker
+//   - fn: the worker loop, handed the shared lifecycle context
+func (l *lifecycle) Go(name string, fn func(ctx context.Context) error) {
+	l.lock.Lock()
+	l.workers[name] = &workerRecord{state: workerStateRunning, startedAt: time.Now()}
+	l.lock.Unlock()
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		err := fn(l.ctx)
+
+		// An error returned during shutdown is just the loop winding down,
+		// not a failure worth reporting
+		if err != nil && l.ctx.Err() != nil {
+			err = nil
+		}
+
+		l.lock.Lock()
+		record := l.workers[name]
+		record.stoppedAt = time.Now()
+		if err != nil {
+			record.state = workerStateFailed
+			record.err = err
+		} else {
+			record.state = workerStateStopped
+		}
+		l.lock.Unlock()
+
+		if err != nil {
+			select {
+			case l.fatal <- err:
+			default:
+			}
+		}
+	}()
+}
+
+// fatalErrors returns the channel carrying the first fatal worker error,
+// watched by Start to trigger a graceful HTTP shutdown.
+func (l *lifecycle) fatalErrors() <-chan error {
+	return l.fatal
+}
+
+// stop cancels the shared context and waits for the workers to exit.
+//
+// Parameters:
+//   - timeout: how long to wait; non-positive waits indefinitely
+//
+// Returns:
+//   - bool: false if workers were still running when the timeout expired
+func (l *lifecycle) stop(timeout time.Duration) bool {
+	l.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+
+	if timeout <= 0 {
+		<-done
+		return true
+	}
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// statuses returns a snapshot of the registered workers, sorted by name.
+func (l *lifecycle) statuses() []WorkerStatus {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	statuses := make([]WorkerStatus, 0, len(l.workers))
+	for name, record := range l.workers {
+		status := WorkerStatus{
+			Name:      name,
+			State:     record.state,
+			StartedAt: record.startedAt,
+		}
+		if !record.stoppedAt.IsZero() {
+			stoppedAt := record.stoppedAt
+			status.StoppedAt = &stoppedAt
+		}
+		if record.err != nil {
+			status.Error = record.err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// handleGoroutines lists the registered background workers and their states,
+// for verifying the lifecycle wiring on a running server. Admin only.
+func (s *Server) handleGoroutines(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	workers := []WorkerStatus{}
+	if s.lifecycle != nil {
+		workers = s.lifecycle.statuses()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"workers": workers})
+}
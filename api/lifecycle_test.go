@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLifecycleStopsWorkersOnCancel(t *testing.T) {
+	l := newLifecycle()
+
+	l.Go("blocker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	if !l.stop(time.Second) {
+		t.Fatal("stop timed out waiting for a context-aware worker")
+	}
+
+	statuses := l.statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("statuses = %v, want one worker", statuses)
+	}
+	if statuses[0].Name != "blocker" || statuses[0].State != workerStateStopped {
+		t.Errorf("worker = %+v, want blocker in state %q", statuses[0], workerStateStopped)
+	}
+	if statuses[0].StoppedAt == nil {
+		t.Error("stopped worker should carry a stop time")
+	}
+}
+
+func TestLifecycleReportsFatalError(t *testing.T) {
+	l := newLifecycle()
+
+	l.Go("doomed", func(ctx context.Context) error {
+		return fmt.Errorf("channel closed")
+	})
+
+	select {
+	case err := <-l.fatalErrors():
+		if err == nil || err.Error() != "channel closed" {
+			t.Errorf("fatal error = %v, want the worker's error", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no fatal error reported within a second")
+	}
+
+	l.stop(time.Second)
+	statuses := l.statuses()
+	if len(statuses) != 1 || statuses[0].State != workerStateFailed {
+		t.Fatalf("statuses = %v, want one failed worker", statuses)
+	}
+	if statuses[0].Error != "channel closed" {
+		t.Errorf("worker error = %q, want the failure message", statuses[0].Error)
+	}
+}
+
+func TestLifecycleErrorDuringShutdownIsNotFatal(t *testing.T) {
+	l := newLifecycle()
+
+	l.Go("loop", func(ctx context.Context) error {
+		<-ctx.Done()
+		// Loops often surface ctx.Err() when unwinding; that is a clean stop
+		return ctx.Err()
+	})
+
+	if !l.stop(time.Second) {
+		t.Fatal("stop timed out")
+	}
+
+	select {
+	case err := <-l.fatalErrors():
+		t.Errorf("shutdown-time error %v reported as fatal", err)
+	default:
+	}
+	if statuses := l.statuses(); statuses[0].State != workerStateStopped {
+		t.Errorf("worker state = %q, want %q", statuses[0].State, workerStateStopped)
+	}
+}
+
+func TestLifecycleStopTimesOutOnStuckWorker(t *testing.T) {
+	l := newLifecycle()
+
+	release := make(chan struct{})
+	l.Go("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	if l.stop(20 * time.Millisecond) {
+		t.Error("stop reported success while a worker was still running")
+	}
+	if statuses := l.statuses(); statuses[0].State != workerStateRunning {
+		t.Errorf("stuck worker state = %q, want %q", statuses[0].State, workerStateRunning)
+	}
+	close(release)
+}
+
+func TestGoroutinesEndpointListsWorkers(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.lifecycle = newLifecycle()
+	defer s.lifecycle.stop(time.Second)
+
+	s.lifecycle.Go("log-dispatcher", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	s.handleGoroutines(rec, auditRequest(t, "GET", "/api/admin/goroutines", nil, 1, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("goroutines status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Workers []WorkerStatus `json:"workers"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("goroutines response is not JSON: %v", err)
+	}
+	if len(resp.Workers) != 1 {
+		t.Fatalf("workers = %v, want one entry", resp.Workers)
+	}
+	worker := resp.Workers[0]
+	if worker.Name != "log-dispatcher" || worker.State != workerStateRunning {
+		t.Errorf("worker = %+v, want a running log-dispatcher", worker)
+	}
+	if worker.StartedAt.IsZero() {
+		t.Error("worker start time should be set")
+	}
+}
+
+func TestGoroutinesEndpointRequiresAdmin(t *testing.T) {
+	s := newAuditTestServer(t)
+	s.lifecycle = newLifecycle()
+
+	rec := httptest.NewRecorder()
+	s.handleGoroutines(rec, auditRequest(t, "GET", "/api/admin/goroutines", nil, 2, nil))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("goroutines for non-admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// logChannelRetention is how long a finished session's broadcaster stays
+// registered after the deploy ends, so a client that reconnects just after
+// completion can still replay the tail of the stream instead of finding the
+// channel gone.
+const logChannelRetention = 5 * time.Minute
+
+// logBroadcaster fans one session's log stream out to any number of
+// subscribers, each with its own coalescing logBuffer. With a single buffer
+// per session, two browser tabs on the same WebSocket endpoint would race on
+// Next() and each see interleaved halves of the stream; here every subscriber
+// receives every message.
+type logBroadcaster struct {
+	lock        sync.Mutex
+	subscribers map[*logBuffer]bool
+	capacity    int      // Per-subscriber buffer capacity
+	history     []string // Ring of the most recent messages, replayed to late subscribers
+	closed      bool
+	dropped     int // Drops accumulated by subscribers that already left
+}
+
+// newLogBroadcaster creates a broadcaster whose subscribers buffer up to
+// capacity frames each. Non-positive capacities fall back to the default.
+func newLogBroadcaster(capacity int) *logBroadcaster {
+	if capacity < 1 {
+		capacity = defaultLogBufferSize
+	}
+	return &logBroadcaster{
+		subscribers: make(map[*logBuffer]bool),
+		capacity:    capacity,
+	}
+}
+
+// Publish delivers a log message to every current subscriber. Publishing to a
+// closed broadcaster is a no-op.
+func (b *logBroadcaster) Publish(msg string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return
+	}
+	// Remember the tail of the stream so a client that reconnects mid-deploy
+	// can be backfilled with the lines it missed
+	b.history = append(b.history, msg)
+	if len(b.history) > b.capacity {
+		b.history = b.history[1:]
+	}
+	for buf := range b.subscribers {
+		buf.Push(msg)
+	}
+}
+
+// Subscribe registers a new consumer and returns its private buffer, primed
+// with the broadcaster's history so a reconnecting client first replays the
+// lines it missed and then continues with the live stream. A subscriber added
+// after the broadcaster closed still drains the history, after which its read
+// loop terminates instead of blocking forever.
+func (b *logBroadcaster) Subscribe() *logBuffer {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	buf := newLogBuffer(b.capacity)
+	for _, msg := range b.history {
+		buf.Push(msg)
+	}
+	if b.closed {
+		buf.Close()
+		return buf
+	}
+	b.subscribers[buf] = true
+	return buf
+}
+
+// Unsubscribe removes a subscriber and closes its buffer. The subscriber's
+// drop count is folded into the broadcaster total so session metrics survive
+// a client disconnecting. Safe to call after Close.
+func (b *logBroadcaster) Unsubscribe(buf *logBuffer) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.subscribers[buf] {
+		delete(b.subscribers, buf)
+		b.dropped += buf.Dropped()
+	}
+	buf.Close()
+}
+
+// Close marks the end of the session's stream and closes every subscriber
+// buffer; already-buffered frames can still be drained. Safe to call more
+// than once.
+func (b *logBroadcaster) Close() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for buf := range b.subscribers {
+		buf.Close()
+	}
+}
+
+// SubscriberCount returns how many consumers are currently attached.
+func (b *logBroadcaster) SubscriberCount() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.subscribers)
+}
+
+// Dropped returns the total messages dropped across all subscribers of this
+// session, including ones that have already unsubscribed.
+func (b *logBroadcaster) Dropped() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	total := b.dropped
+	for buf := range b.subscribers {
+		total += buf.Dropped()
+	}
+	return total
+}
+
+// retireLogChannel closes a finished session's broadcaster but keeps it
+// registered for logChannelRetention, so late reconnects can still drain the
+// history before the session disappears. The identity check on removal makes
+// sure a reused session ID's fresh broadcaster is never torn down by an old
+// timer.
+func (s *Server) retireLogChannel(sessionID string, b *logBroadcaster) {
+	b.Close()
+	time.AfterFunc(logChannelRetention, func() {
+		s.logLock.Lock()
+		if s.logChannels[sessionID] == b {
+			delete(s.logChannels, sessionID)
+		}
+		s.logLock.Unlock()
+	})
+}
+
+// logSubscriberCounts reports how many WebSocket clients are attached to each
+// active session, for the database metrics endpoint.
+func (s *Server) logSubscriberCounts() map[string]int {
+	s.logLock.Lock()
+	defer s.logLock.Unlock()
+
+	counts := make(map[string]int, len(s.logChannels))
+	for sessionID, broadcaster := range s.logChannels {
+		counts[sessionID] = broadcaster.SubscriberCount()
+	}
+	return counts
+}
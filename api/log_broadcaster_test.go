@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBroadcasterDeliversFullStreamToEverySubscriber(t *testing.T) {
+	b := newLogBroadcaster(10)
+	first := b.Subscribe()
+	second := b.Subscribe()
+
+	var want []string
+	for i := 0; i < 5; i++ {
+		msg := fmt.Sprintf("line %d", i)
+		want = append(want, msg)
+		b.Publish(msg)
+	}
+	b.Close()
+
+	for i, sub := range []*logBuffer{first, second} {
+		if got := drainLogBuffer(sub); !reflect.DeepEqual(got, want) {
+			t.Errorf("subscriber %d got %v, want the full stream %v", i, got, want)
+		}
+	}
+}
+
+func TestBroadcasterUnsubscribeStopsDelivery(t *testing.T) {
+	b := newLogBroadcaster(10)
+	leaving := b.Subscribe()
+	staying := b.Subscribe()
+
+	b.Publish("before")
+	b.Unsubscribe(leaving)
+	b.Publish("after")
+	b.Close()
+
+	if got := drainLogBuffer(leaving); len(got) != 1 || got[0] != "before" {
+		t.Errorf("unsubscribed client got %v, want only the first message", got)
+	}
+	if got := drainLogBuffer(staying); len(got) != 2 {
+		t.Errorf("remaining client got %v, want both messages", got)
+	}
+	if b.SubscriberCount() != 1 {
+		t.Errorf("SubscriberCount() = %d, want 1", b.SubscriberCount())
+	}
+}
+
+func TestBroadcasterSubscribeAfterCloseTerminatesImmediately(t *testing.T) {
+	b := newLogBroadcaster(10)
+	b.Close()
+
+	// A late subscriber must not block forever waiting for frames
+	sub := b.Subscribe()
+	if _, ok := sub.Next(); ok {
+		t.Error("subscriber on a closed broadcaster should see an ended stream")
+	}
+}
+
+func TestBroadcasterDroppedSurvivesUnsubscribe(t *testing.T) {
+	b := newLogBroadcaster(4)
+	sub := b.Subscribe()
+
+	for i := 0; i < 10; i++ {
+		b.Publish(fmt.Sprintf("line %d", i))
+	}
+	dropped := b.Dropped()
+	if dropped == 0 {
+		t.Fatal("overflowing a subscriber should record drops")
+	}
+
+	b.Unsubscribe(sub)
+	if got := b.Dropped(); got != dropped {
+		t.Errorf("Dropped() after unsubscribe = %d, want %d", got, dropped)
+	}
+}
+
+func TestBroadcasterReplaysHistoryToReconnectingSubscriber(t *testing.T) {
+	b := newLogBroadcaster(10)
+
+	// The client is gone while the deploy keeps logging
+	b.Publish("missed 1")
+	b.Publish("missed 2")
+
+	// Reconnecting must backfill the missed lines, then continue live
+	sub := b.Subscribe()
+	b.Publish("live")
+	b.Close()
+
+	want := []string{"missed 1", "missed 2", "live"}
+	if got := drainLogBuffer(sub); !reflect.DeepEqual(got, want) {
+		t.Errorf("reconnected client got %v, want backfill plus live stream %v", got, want)
+	}
+}
+
+func TestBroadcasterHistoryBoundedByCapacity(t *testing.T) {
+	b := newLogBroadcaster(2)
+	for i := 0; i < 5; i++ {
+		b.Publish(fmt.Sprintf("line %d", i))
+	}
+	b.Close()
+
+	// Only the newest capacity lines survive in history
+	want := []string{"line 3", "line 4"}
+	if got := drainLogBuffer(b.Subscribe()); !reflect.DeepEqual(got, want) {
+		t.Errorf("late subscriber got %v, want the last two lines %v", got, want)
+	}
+}
+
+func TestBroadcasterHistorySurvivesClose(t *testing.T) {
+	b := newLogBroadcaster(10)
+	b.Publish("tail line")
+	b.Close()
+
+	// A client reconnecting just after the deploy finished still drains the
+	// tail, then sees the stream end
+	sub := b.Subscribe()
+	if msg, ok := sub.Next(); !ok || msg != "tail line" {
+		t.Errorf("Next() = %q, %v, want the buffered tail line", msg, ok)
+	}
+	if _, ok := sub.Next(); ok {
+		t.Error("stream should end after the history is drained")
+	}
+}
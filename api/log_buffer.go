@@ -0,0 +1,163 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// defaultLogBufferSize is used when no buffer size is configured.
+const defaultLogBufferSize = 100
+
+// structuralFramePrefixes marks log frames the UI needs to render deploy
+// structure (task boundaries, deploy completion). These frames are never
+// dropped, no matter how far a client falls behind.
+var structuralFramePrefixes = []string{"task_start", "task_end", "deploy_end"}
+
+// isStructuralFrame reports whether a log message is a structural frame.
+func isStructuralFrame(msg string) bool {
+	for _, prefix := range structuralFramePrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// logFrame is one buffered log message awaiting delivery to a client.
+type logFrame struct {
+	text       string
+	structural bool // Never evicted
+	marker     bool // Synthetic "N messages dropped" frame
+	dropped    int  // Number of messages this marker stands in for
+}
+
+// logBuffer buffers log messages for one WebSocket session.
+//
+// Unlike the previous fixed channel, a full buffer does not silently drop
+// messages: overflowing frames are coalesced into a single marker frame
+// telling the client how many messages were skipped, and structural frames
+// (task_start/task_end/deploy_end) always survive so the UI never loses the
+// shape of a deploy.
+type logBuffer struct {
+	lock     sync.Mutex
+	cond     *sync.Cond
+	frames   []logFrame
+	capacity int
+	closed   bool
+	dropped  int // Total messages dropped for this session
+}
+
+// newLogBuffer creates a buffer holding up to capacity frames. Non-positive
+// capacities fall back to the default.
+func newLogBuffer(capacity int) *logBuffer {
+	if capacity < 1 {
+		capacity = defaultLogBufferSize
+	}
+	b := &logBuffer{capacity: capacity}
+	b.cond = sync.NewCond(&b.lock)
+	return b
+}
+
+// Push appends a log message, coalescing older non-structural frames into a
+// drop marker when the buffer is full. Pushing to a closed buffer is a no-op.
+func (b *logBuffer) Push(msg string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.frames = append(b.frames, logFrame{text: msg, structural: isStructuralFrame(msg)})
+	for len(b.frames) > b.capacity && b.evictOldest() {
+	}
+	b.cond.Signal()
+}
+
+// evictOldest drops the oldest evictable frame, folding it into the session's
+// drop marker. Returns false when every frame is structural or a marker, in
+// which case the buffer is allowed to exceed its capacity rather than lose
+// structure.
+func (b *logBuffer) evictOldest() bool {
+	victim := -1
+	marker := -1
+	for i, f := range b.frames {
+		if f.marker {
+			marker = i
+			continue
+		}
+		if !f.structural && victim == -1 {
+			victim = i
+		}
+	}
+	if victim == -1 {
+		return false
+	}
+
+	b.frames = append(b.frames[:victim], b.frames[victim+1:]...)
+	b.dropped++
+
+	if marker >= 0 {
+		if marker > victim {
+			marker--
+		}
+		b.frames[marker].dropped++
+		return true
+	}
+
+	// First drop: insert the marker where the evicted frame was
+	markerFrame := logFrame{marker: true, dropped: 1}
+	b.frames = append(b.frames[:victim], append([]logFrame{markerFrame}, b.frames[victim:]...)...)
+	return true
+}
+
+// Next blocks until a frame is available or the buffer is closed. It returns
+// the rendered frame text and false once the buffer is closed and drained.
+func (b *logBuffer) Next() (string, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for len(b.frames) == 0 && !b.closed {
+		b.cond.Wait()
+	}
+	if len(b.frames) == 0 {
+		return "", false
+	}
+
+	f := b.frames[0]
+	b.frames = b.frames[1:]
+	if f.marker {
+		return fmt.Sprintf("%d messages dropped (client too slow)", f.dropped), true
+	}
+	return f.text, true
+}
+
+// Close marks the buffer closed and wakes any blocked reader. Frames already
+// buffered can still be drained. Safe to call more than once.
+func (b *logBuffer) Close() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.closed = true
+	b.cond.Broadcast()
+}
+
+// Dropped returns how many messages have been dropped for this session.
+func (b *logBuffer) Dropped() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.dropped
+}
+
+// logDropCounts reports the per-session drop counts across all subscribers,
+// for the database metrics endpoint.
+func (s *Server) logDropCounts() map[string]int {
+	s.logLock.Lock()
+	defer s.logLock.Unlock()
+
+	counts := make(map[string]int, len(s.logChannels))
+	for sessionID, broadcaster := range s.logChannels {
+		counts[sessionID] = broadcaster.Dropped()
+	}
+	return counts
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// drainLogBuffer closes the buffer and collects every remaining frame.
+func drainLogBuffer(b *logBuffer) []string {
+	b.Close()
+	var frames []string
+	for {
+		msg, ok := b.Next()
+		if !ok {
+			return frames
+		}
+		frames = append(frames, msg)
+	}
+}
+
+func TestLogBufferCoalescesOverflowIntoMarker(t *testing.T) {
+	b := newLogBuffer(4)
+	for i := 0; i < 10; i++ {
+		b.Push(fmt.Sprintf("line %d", i))
+	}
+
+	frames := drainLogBuffer(b)
+	if len(frames) > 4 {
+		t.Errorf("buffer delivered %d frames, capacity is 4: %v", len(frames), frames)
+	}
+
+	markers := 0
+	for _, f := range frames {
+		if strings.Contains(f, "messages dropped (client too slow)") {
+			markers++
+			// The marker itself takes a slot, so 3 real lines survive
+			if !strings.HasPrefix(f, "7 ") {
+				t.Errorf("marker = %q, want 7 dropped messages", f)
+			}
+		}
+	}
+	if markers != 1 {
+		t.Errorf("found %d drop markers, want exactly 1: %v", markers, frames)
+	}
+
+	if got := b.Dropped(); got != 7 {
+		t.Errorf("Dropped() = %d, want 7", got)
+	}
+
+	// The newest lines must survive the coalescing
+	if frames[len(frames)-1] != "line 9" {
+		t.Errorf("last frame = %q, want the newest message", frames[len(frames)-1])
+	}
+}
+
+func TestLogBufferKeepsStructuralFrames(t *testing.T) {
+	b := newLogBuffer(3)
+	b.Push("task_start: build")
+	for i := 0; i < 20; i++ {
+		b.Push(fmt.Sprintf("output line %d", i))
+	}
+	b.Push("task_end: build")
+	b.Push("deploy_end: demo")
+
+	frames := drainLogBuffer(b)
+	joined := strings.Join(frames, "\n")
+	for _, structural := range []string{"task_start: build", "task_end: build", "deploy_end: demo"} {
+		if !strings.Contains(joined, structural) {
+			t.Errorf("structural frame %q was dropped:\n%s", structural, joined)
+		}
+	}
+}
+
+func TestLogBufferSlowReader(t *testing.T) {
+	b := newLogBuffer(8)
+
+	// Writer floods the buffer while the reader is asleep
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Push("task_start: migrate")
+		for i := 0; i < 200; i++ {
+			b.Push(fmt.Sprintf("migration step %d", i))
+		}
+		b.Push("task_end: migrate")
+	}()
+	<-done
+
+	// Reader wakes up late and drains whatever survived
+	time.Sleep(10 * time.Millisecond)
+	frames := drainLogBuffer(b)
+
+	joined := strings.Join(frames, "\n")
+	if !strings.Contains(joined, "task_start: migrate") || !strings.Contains(joined, "task_end: migrate") {
+		t.Errorf("structural frames missing after slow read:\n%s", joined)
+	}
+	if !strings.Contains(joined, "messages dropped (client too slow)") {
+		t.Errorf("no drop marker for a slow reader:\n%s", joined)
+	}
+	if b.Dropped() == 0 {
+		t.Error("Dropped() = 0 after flooding a slow reader")
+	}
+}
+
+func TestLogBufferNextReturnsFalseWhenClosed(t *testing.T) {
+	b := newLogBuffer(2)
+	b.Push("only line")
+	b.Close()
+
+	if msg, ok := b.Next(); !ok || msg != "only line" {
+		t.Errorf("Next() = (%q, %v), want buffered frame before close takes effect", msg, ok)
+	}
+	if _, ok := b.Next(); ok {
+		t.Error("Next() on a drained, closed buffer should report ok=false")
+	}
+
+	// Pushing after close is a no-op
+	b.Push("late line")
+	if _, ok := b.Next(); ok {
+		t.Error("Push() after Close() should not deliver frames")
+	}
+}
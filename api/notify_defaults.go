@@ -0,0 +1,157 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zechtz/nyatictl/logger"
+	"github.com/zechtz/nyatictl/notify"
+)
+
+// NotificationDefaults are the per-config default notification targets,
+// stored as JSON in the config entry's notification_defaults column and
+// applied to every deploy of that config in addition to the global notifier.
+type NotificationDefaults struct {
+	WebhookIDs []int    `json:"webhook_ids"` // Registered webhook IDs to deliver the summary to
+	Emails     []string `json:"emails"`      // Email addresses to send the summary to
+}
+
+// notificationDefaultsFor looks up the notification defaults configured for a
+// config path.
+//
+// Parameters:
+//   - configPath: path of the deployed config
+//
+// Returns:
+//   - NotificationDefaults: the parsed defaults
+//   - bool: false when the config has none or the stored JSON is invalid
+func (s *Server) notificationDefaultsFor(configPath string) (NotificationDefaults, bool) {
+	var raw string
+	if err := s.db.QueryRow(
+		"SELECT notification_defaults FROM configs WHERE path = ?", configPath,
+	).Scan(&raw); err != nil || raw == "" {
+		return NotificationDefaults{}, false
+	}
+
+	var defaults NotificationDefaults
+	if err := json.Unmarshal([]byte(raw), &defaults); err != nil {
+		logger.Error(fmt.Sprintf("Invalid notification_defaults for %s: %v", configPath, err))
+		return NotificationDefaults{}, false
+	}
+	return defaults, true
+}
+
+// webhookByID retrieves a single active webhook regardless of owner. Defaults
+// reference webhooks by ID; ownership was already checked when the default
+// was saved, so inactive is the only state that suppresses delivery here.
+func webhookByID(db *sql.DB, id int) (Webhook, error) {
+	query := `
+		SELECT id, name, description, url, secret, event, tag_filter, algorithm, user_id, active, created_at, updated_at
+		FROM webhooks
+		WHERE id = ? AND active = 1
+	`
+	var webhook Webhook
+	var createdAt, updatedAt string
+	err := db.QueryRow(query, id).Scan(
+		&webhook.ID,
+		&webhook.Name,
+		&webhook.Description,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.Event,
+		&webhook.TagFilter,
+		&webhook.Algorithm,
+		&webhook.UserID,
+		&webhook.Active,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to get webhook: %v", err)
+	}
+
+	webhook.CreatedAt = parseTimeWithLogging(createdAt, "created_at")
+	webhook.UpdatedAt = parseTimeWithLogging(updatedAt, "updated_at")
+	return webhook, nil
+}
+
+// notifyDeployTargets delivers a deploy summary to the config's default
+// notification targets: each listed webhook gets a deployment-event payload
+// and each listed email address gets the rendered summary. Delivery is
+// best-effort and never affects the deploy outcome.
+//
+// Parameters:
+//   - configPath: path of the deployed config
+//   - summary: the aggregated deploy outcome, including any deploy note
+func (s *Server) notifyDeployTargets(configPath string, summary notify.DeploySummary) {
+	defaults, ok := s.notificationDefaultsFor(configPath)
+	if !ok {
+		return
+	}
+
+	status := "success"
+	event := EventDeploymentSucceeded
+	switch {
+	case !summary.Success():
+		status = "error"
+		event = EventDeploymentFailed
+	case summary.AllowedFailures > 0:
+		status = "warning"
+	}
+	payload := WebhookPayload{
+		Event:      event,
+		Action:     "deploy",
+		Status:     status,
+		Timestamp:  time.Now(),
+		ConfigPath: configPath,
+		Host:       summary.Host,
+		Data: map[string]any{
+			"succeeded": summary.Succeeded,
+			"failed":    summary.Failed,
+			"duration":  summary.Duration.String(),
+		},
+	}
+	if summary.AllowedFailures > 0 {
+		payload.Data["allowed_failures"] = summary.AllowedFailures
+	}
+	if summary.Note != "" {
+		payload.Data["note"] = summary.Note
+	}
+	if len(summary.Params) > 0 {
+		payload.Data["params"] = summary.Params
+	}
+
+	for _, id := range defaults.WebhookIDs {
+		webhook, err := webhookByID(s.db.DB, id)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Notification default webhook %d for %s unavailable: %v", id, configPath, err))
+			continue
+		}
+		go sendWebhook(s.db.DB, webhook, payload)
+	}
+
+	if len(defaults.Emails) == 0 {
+		return
+	}
+	if s.appCfg == nil || s.appCfg.SMTPHost == "" {
+		logger.Error(fmt.Sprintf("Notification defaults for %s list emails but SMTP is not configured", configPath))
+		return
+	}
+	for _, to := range defaults.Emails {
+		sender := &notify.SMTPSender{
+			Host:     s.appCfg.SMTPHost,
+			Port:     s.appCfg.SMTPPort,
+			Username: s.appCfg.SMTPUsername,
+			Password: s.appCfg.SMTPPassword,
+			From:     s.appCfg.SMTPFrom,
+			To:       to,
+		}
+		go func(sender *notify.SMTPSender) {
+			if err := sender.Send(summary.Subject(), summary.Body()); err != nil {
+				logger.Error(fmt.Sprintf("Failed to send deploy notification email: %v", err))
+			}
+		}(sender)
+	}
+}
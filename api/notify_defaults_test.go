@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zechtz/nyatictl/notify"
+)
+
+func TestSanitizeDeployNote(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"  rollout for v2  ", "rollout for v2"},
+		{"line one\nline two", "line one\nline two"},
+		{"bell\x07 and tab\t", "bell and tab"},
+		{"<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+	}
+	for _, c := range cases {
+		if got := sanitizeDeployNote(c.in); got != c.want {
+			t.Errorf("sanitizeDeployNote(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+
+	long := make([]byte, maxDeployNoteLength+100)
+	for i := range long {
+		long[i] = 'a'
+	}
+	if got := sanitizeDeployNote(string(long)); len(got) != maxDeployNoteLength {
+		t.Errorf("long note sanitized to %d chars, want %d", len(got), maxDeployNoteLength)
+	}
+
+	// A multibyte rune straddling the limit must be dropped whole, never split
+	multibyte := strings.Repeat("a", maxDeployNoteLength-1) + "émoji"
+	got := sanitizeDeployNote(multibyte)
+	if !utf8.ValidString(got) {
+		t.Errorf("truncated multibyte note is not valid UTF-8: %q", got)
+	}
+	if len(got) != maxDeployNoteLength-1 {
+		t.Errorf("multibyte note truncated to %d bytes, want %d with the split rune dropped", len(got), maxDeployNoteLength-1)
+	}
+}
+
+func TestRecordDeploymentStoresNote(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+
+	s.recordDeployment(1, path, "server1", "rollout for v2", 0)
+
+	var note string
+	if err := s.db.QueryRow("SELECT note FROM deployments WHERE id = 1").Scan(&note); err != nil {
+		t.Fatalf("failed to read deployment note: %v", err)
+	}
+	if note != "rollout for v2" {
+		t.Errorf("stored note = %q, want %q", note, "rollout for v2")
+	}
+}
+
+// seedConfigDefaults stores a config row with the given notification
+// defaults so lookups hit the database like production reads do.
+func seedConfigDefaults(t *testing.T, s *Server, path, defaults string) {
+	t.Helper()
+
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT,
+		description TEXT,
+		path TEXT UNIQUE,
+		status TEXT,
+		user_id INTEGER DEFAULT 1,
+		notification_defaults TEXT NOT NULL DEFAULT '',
+		labels TEXT NOT NULL DEFAULT '[]'
+	)`); err != nil {
+		t.Fatalf("failed to create configs table: %v", err)
+	}
+	if _, err := s.db.Exec(
+		"INSERT INTO configs (name, path, notification_defaults) VALUES (?, ?, ?)",
+		"demo", path, defaults,
+	); err != nil {
+		t.Fatalf("failed to seed config: %v", err)
+	}
+}
+
+func TestNotificationDefaultsForParsesConfigEntry(t *testing.T) {
+	s := newAuditTestServer(t)
+	seedConfigDefaults(t, s, "/tmp/plain.yaml", "")
+	seedConfigDefaults(t, s, "/tmp/nyati.yaml", `{"webhook_ids": [3], "emails": ["ops@example.com"]}`)
+	seedConfigDefaults(t, s, "/tmp/broken.yaml", `{not json`)
+
+	if _, ok := s.notificationDefaultsFor("/tmp/plain.yaml"); ok {
+		t.Error("config without defaults should report none")
+	}
+	if _, ok := s.notificationDefaultsFor("/tmp/broken.yaml"); ok {
+		t.Error("invalid defaults JSON should report none")
+	}
+
+	defaults, ok := s.notificationDefaultsFor("/tmp/nyati.yaml")
+	if !ok {
+		t.Fatal("configured defaults were not found")
+	}
+	if len(defaults.WebhookIDs) != 1 || defaults.WebhookIDs[0] != 3 {
+		t.Errorf("webhook IDs = %v, want [3]", defaults.WebhookIDs)
+	}
+	if len(defaults.Emails) != 1 || defaults.Emails[0] != "ops@example.com" {
+		t.Errorf("emails = %v, want [ops@example.com]", defaults.Emails)
+	}
+}
+
+func TestNotifyDeployTargetsDeliversToDefaultWebhook(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	delivered := make(chan WebhookPayload, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("webhook payload is not valid JSON: %v", err)
+		}
+		delivered <- payload
+	}))
+	defer ts.Close()
+
+	id, err := CreateWebhook(s.db.DB, Webhook{Name: "deploys", URL: ts.URL, Event: "deployment", UserID: 1, Active: true})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	seedConfigDefaults(t, s, "/tmp/nyati.yaml", `{"webhook_ids": [`+strconv.Itoa(id)+`]}`)
+
+	summary := notify.DeploySummary{
+		ConfigPath: "/tmp/nyati.yaml",
+		Host:       "server1",
+		Succeeded:  2,
+		Note:       "rollout for v2",
+	}
+	s.notifyDeployTargets("/tmp/nyati.yaml", summary)
+
+	select {
+	case payload := <-delivered:
+		if payload.Event != EventDeploymentSucceeded || payload.Status != "success" {
+			t.Errorf("payload = %s/%s, want deployment.succeeded/success", payload.Event, payload.Status)
+		}
+		if payload.Data["note"] != "rollout for v2" {
+			t.Errorf("payload note = %v, want the deploy note", payload.Data["note"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("default webhook was never delivered")
+	}
+}
+
+func TestNotifyDeployTargetsSkipsInactiveWebhook(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	delivered := make(chan struct{}, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer ts.Close()
+
+	id, err := CreateWebhook(s.db.DB, Webhook{Name: "disabled", URL: ts.URL, Event: "deployment", UserID: 1, Active: false})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	seedConfigDefaults(t, s, "/tmp/nyati.yaml", `{"webhook_ids": [`+strconv.Itoa(id)+`]}`)
+
+	s.notifyDeployTargets("/tmp/nyati.yaml", notify.DeploySummary{ConfigPath: "/tmp/nyati.yaml", Host: "server1"})
+
+	select {
+	case <-delivered:
+		t.Error("inactive webhook must not be delivered")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
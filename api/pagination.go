@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const (
+	// defaultPageLimit is the page size used when the client does not ask
+	// for one.
+	defaultPageLimit = 50
+
+	// maxPageLimit caps the page size a client can request, keeping a
+	// single response bounded regardless of how many rows exist.
+	maxPageLimit = 200
+)
+
+// PagedResponse is the shared envelope for paginated list endpoints. Items
+// holds one page of results; the remaining fields let clients render pagers
+// without a second count query.
+type PagedResponse[T any] struct {
+	Items   []T  `json:"items"`    // The requested page of results
+	Total   int  `json:"total"`    // Total number of results across all pages
+	Page    int  `json:"page"`     // The 1-based page that was returned
+	Limit   int  `json:"limit"`    // Page size actually applied after clamping
+	HasNext bool `json:"has_next"` // Whether another page follows this one
+}
+
+// pageParams reads the page and limit query parameters, applying defaults
+// and bounds: page defaults to 1 and is never lower, limit defaults to
+// defaultPageLimit and is clamped to 1..maxPageLimit. Values that do not
+// parse fall back to the defaults rather than erroring, matching how the
+// list endpoints already treat malformed query input.
+//
+// Parameters:
+//   - r: the request whose query string is inspected
+//
+// Returns:
+//   - int: the 1-based page to return
+//   - int: the clamped page size
+func pageParams(r *http.Request) (int, int) {
+	page := 1
+	if v, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && v > 1 {
+		page = v
+	}
+
+	limit := defaultPageLimit
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return page, limit
+}
+
+// Paginate slices items down to the page requested by r's page/limit query
+// parameters and wraps it in the shared envelope. A page past the end of the
+// results yields an empty (never nil) Items slice so the JSON stays an array.
+//
+// Parameters:
+//   - r: the request carrying the page/limit parameters
+//   - items: the full result set to page over
+//
+// Returns:
+//   - PagedResponse[T]: one page of items plus paging metadata
+func Paginate[T any](r *http.Request, items []T) PagedResponse[T] {
+	page, limit := pageParams(r)
+
+	start := (page - 1) * limit
+	end := start + limit
+	if start > len(items) {
+		start = len(items)
+	}
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return PagedResponse[T]{
+		Items:   items[start:end:end],
+		Total:   len(items),
+		Page:    page,
+		Limit:   limit,
+		HasNext: end < len(items),
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPageParamsDefaultsAndClamping(t *testing.T) {
+	cases := []struct {
+		query     string
+		wantPage  int
+		wantLimit int
+	}{
+		{"", 1, defaultPageLimit},
+		{"?page=3&limit=10", 3, 10},
+		{"?page=0&limit=0", 1, defaultPageLimit},
+		{"?page=-2&limit=-5", 1, defaultPageLimit},
+		{"?page=abc&limit=xyz", 1, defaultPageLimit},
+		{fmt.Sprintf("?limit=%d", maxPageLimit+1), 1, maxPageLimit},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("GET", "/api/configs"+tc.query, nil)
+		page, limit := pageParams(r)
+		if page != tc.wantPage || limit != tc.wantLimit {
+			t.Errorf("pageParams(%q) = (%d, %d), want (%d, %d)", tc.query, page, limit, tc.wantPage, tc.wantLimit)
+		}
+	}
+}
+
+func TestPaginateEnvelope(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	r := httptest.NewRequest("GET", "/?page=2&limit=2", nil)
+	page := Paginate(r, items)
+	if !reflect.DeepEqual(page.Items, []int{3, 4}) {
+		t.Errorf("page 2 items = %v, want [3 4]", page.Items)
+	}
+	if page.Total != 5 || page.Page != 2 || page.Limit != 2 || !page.HasNext {
+		t.Errorf("envelope = %+v, want total 5, page 2, limit 2, has_next", page)
+	}
+
+	r = httptest.NewRequest("GET", "/?page=3&limit=2", nil)
+	if page := Paginate(r, items); page.HasNext {
+		t.Errorf("last page reported has_next: %+v", page)
+	}
+}
+
+func TestPaginatePastEndReturnsEmptyArray(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?page=9&limit=10", nil)
+	page := Paginate(r, []int{1, 2})
+	if page.Items == nil || len(page.Items) != 0 {
+		t.Errorf("past-end items = %#v, want an empty non-nil slice", page.Items)
+	}
+	if page.Total != 2 || page.HasNext {
+		t.Errorf("past-end envelope = %+v, want total 2 and no next page", page)
+	}
+}
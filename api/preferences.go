@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// maxPreferencesSize bounds one user's preferences blob. The server does not
+// interpret the contents, so the limit is what keeps a buggy client from
+// turning the table into arbitrary storage.
+const maxPreferencesSize = 16 * 1024
+
+// PreferencesResponse is the body of both preference endpoints: the stored
+// blob plus when it was last written, so a client seeing an updated_at newer
+// than its own last write knows another browser changed something.
+type PreferencesResponse struct {
+	Preferences json.RawMessage `json:"preferences"`
+	UpdatedAt   string          `json:"updated_at,omitempty"`
+}
+
+// handleGetPreferences returns the caller's stored UI preferences. A user
+// who never saved any gets an empty object rather than a 404, so clients
+// need no special first-run handling.
+func (s *Server) handleGetPreferences(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	resp := PreferencesResponse{Preferences: json.RawMessage("{}")}
+
+	var prefs, updatedAt string
+	err := s.db.QueryRow(
+		"SELECT preferences, updated_at FROM user_preferences WHERE user_id = ?",
+		claims.UserID,
+	).Scan(&prefs, &updatedAt)
+	if err == nil {
+		resp.Preferences = json.RawMessage(prefs)
+		resp.UpdatedAt = updatedAt
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handlePutPreferences stores the caller's UI preferences. The body must be
+// a JSON object under maxPreferencesSize; the server keeps it opaque beyond
+// that. Writes are last-write-wins — the returned updated_at is the client's
+// handle for detecting that another browser wrote in between.
+func (s *Server) handlePutPreferences(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPreferencesSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Preferences exceed the %d byte limit", maxPreferencesSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	// Schema-light validation: any JSON object is fine, anything else is not
+	var prefs map[string]json.RawMessage
+	if err := json.Unmarshal(body, &prefs); err != nil {
+		http.Error(w, "Preferences must be a JSON object", http.StatusBadRequest)
+		return
+	}
+
+	updatedAt := time.Now().UTC().Format(time.RFC3339)
+	if _, err := s.db.Exec(
+		`INSERT INTO user_preferences (user_id, preferences, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET preferences = excluded.preferences, updated_at = excluded.updated_at`,
+		claims.UserID, string(body), updatedAt,
+	); err != nil {
+		logger.Error(fmt.Sprintf("Failed to store preferences for user %d: %v", claims.UserID, err))
+		http.Error(w, "Failed to store preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PreferencesResponse{Preferences: body, UpdatedAt: updatedAt})
+}
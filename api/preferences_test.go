@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newPreferencesTestServer extends the audit test server with the
+// user_preferences table.
+func newPreferencesTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	s := newAuditTestServer(t)
+	if _, err := s.db.Exec(`CREATE TABLE user_preferences (
+		user_id INTEGER PRIMARY KEY,
+		preferences TEXT NOT NULL DEFAULT '{}',
+		updated_at TEXT NOT NULL
+	);`); err != nil {
+		t.Fatalf("failed to create user_preferences table: %v", err)
+	}
+	return s
+}
+
+func getPreferences(t *testing.T, s *Server, userID int) PreferencesResponse {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.handleGetPreferences)(rec, auditRequest(t, "GET", "/api/account/preferences", nil, userID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get preferences status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var resp PreferencesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("preferences response is not JSON: %v", err)
+	}
+	return resp
+}
+
+func putPreferences(t *testing.T, s *Server, userID int, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest("PUT", "/api/account/preferences", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: userID}))
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.handlePutPreferences)(rec, req)
+	return rec
+}
+
+func TestPreferencesRoundTrip(t *testing.T) {
+	s := newPreferencesTestServer(t)
+
+	rec := putPreferences(t, s, 1, `{"dark_mode": true, "page_size": 50}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("put preferences status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	resp := getPreferences(t, s, 1)
+	if resp.UpdatedAt == "" {
+		t.Error("updated_at is empty after a write")
+	}
+
+	var prefs map[string]any
+	if err := json.Unmarshal(resp.Preferences, &prefs); err != nil {
+		t.Fatalf("stored preferences are not JSON: %v", err)
+	}
+	if prefs["dark_mode"] != true || prefs["page_size"] != float64(50) {
+		t.Errorf("preferences = %v, want the stored values back", prefs)
+	}
+}
+
+func TestPreferencesDefaultToEmptyObject(t *testing.T) {
+	s := newPreferencesTestServer(t)
+
+	resp := getPreferences(t, s, 1)
+	if string(resp.Preferences) != "{}" || resp.UpdatedAt != "" {
+		t.Errorf("first-run preferences = (%s, %q), want an empty object with no timestamp", resp.Preferences, resp.UpdatedAt)
+	}
+}
+
+func TestPreferencesLastWriteWins(t *testing.T) {
+	s := newPreferencesTestServer(t)
+
+	if rec := putPreferences(t, s, 1, `{"dark_mode": true}`); rec.Code != http.StatusOK {
+		t.Fatalf("first write status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := putPreferences(t, s, 1, `{"dark_mode": false}`); rec.Code != http.StatusOK {
+		t.Fatalf("second write status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	resp := getPreferences(t, s, 1)
+	var prefs map[string]any
+	if err := json.Unmarshal(resp.Preferences, &prefs); err != nil {
+		t.Fatalf("stored preferences are not JSON: %v", err)
+	}
+	if prefs["dark_mode"] != false {
+		t.Errorf("preferences = %v, want the later write", prefs)
+	}
+}
+
+func TestPreferencesAreScopedPerUser(t *testing.T) {
+	s := newPreferencesTestServer(t)
+
+	if rec := putPreferences(t, s, 1, `{"dark_mode": true}`); rec.Code != http.StatusOK {
+		t.Fatalf("write status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if resp := getPreferences(t, s, 2); string(resp.Preferences) != "{}" {
+		t.Errorf("user 2 preferences = %s, want user 1's write invisible", resp.Preferences)
+	}
+}
+
+func TestPreferencesRejectInvalidBodies(t *testing.T) {
+	s := newPreferencesTestServer(t)
+
+	for _, body := range []string{`not json`, `[1, 2, 3]`, `"just a string"`, `42`} {
+		rec := putPreferences(t, s, 1, body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("put %q status = %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestPreferencesRejectOversizedBodies(t *testing.T) {
+	s := newPreferencesTestServer(t)
+
+	big := `{"blob": "` + strings.Repeat("x", maxPreferencesSize) + `"}`
+	rec := putPreferences(t, s, 1, big)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized put status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -67,6 +67,18 @@ func sanitizeInput(input string) string {
 
 // HandleRegister processes user registration requests
 func (s *Server) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	// Registration is disabled until first-run setup has created the admin
+	if s.setupIsRequired() {
+		http.Error(w, "Setup required: create the admin user via POST /api/setup", http.StatusServiceUnavailable)
+		return
+	}
+
+	// After setup, registration is controlled by the allow_registration setting
+	if GetSetting(s.db.DB, "allow_registration", "true") != "true" {
+		http.Error(w, "Registration is disabled", http.StatusForbidden)
+		return
+	}
+
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
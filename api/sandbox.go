@@ -75,24 +75,29 @@ func (s *Server) handleSandboxSimulation(w http.ResponseWriter, r *http.Request)
 	// Load the configuration file
 	cfg, err := config.Load(req.ConfigPath, "0.1.2")
 	if err != nil {
-		rw.InternalServerError(err.Error())
+		rw.Error(statusForError(err), err.Error())
 		return
 	}
 
-	// Create a log channel scoped to this session
-	logChan := make(chan string, 100)
+	// Simulations share the deploy slots: they are cheap individually, but an
+	// unbounded number of them still pegs the box
+	if !s.limiter.tryAcquire() {
+		rw.Error(http.StatusTooManyRequests, fmt.Sprintf("Too many concurrent deployments: %d of %d slots in use", s.limiter.running(), s.limiter.capacity()))
+		return
+	}
+
+	// Create a log buffer scoped to this session
+	broadcaster := newLogBroadcaster(s.logBufferSize)
 	s.logLock.Lock()
-	s.logChannels[req.SessionID] = logChan
+	s.logChannels[req.SessionID] = broadcaster
 	s.logLock.Unlock()
 
 	// Simulate the deployment in a goroutine to allow for streaming logs
+	s.sessions.begin(req.SessionID, req.ConfigPath, req.Host, claims.UserID)
 	go func() {
-		defer func() {
-			s.logLock.Lock()
-			delete(s.logChannels, req.SessionID)
-			close(logChan)
-			s.logLock.Unlock()
-		}()
+		defer s.limiter.release()
+		defer s.sessions.end(req.SessionID)
+		defer s.retireLogChannel(req.SessionID, broadcaster)
 
 		// Initialize random number generator with a seed for consistent results
 		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
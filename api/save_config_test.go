@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// validConfigYAML is a minimal config that passes config.Load, for save tests
+// that need the referenced file to be valid.
+const validConfigYAML = `version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 192.168.1.1
+    username: admin
+tasks:
+  - name: build
+    cmd: make
+`
+
+// saveConfigRequest builds an authenticated save request with an optional
+// X-Content-SHA256 header.
+func saveConfigRequest(t *testing.T, body []byte, checksum string) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", "/api/configs", bytes.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+	if checksum != "" {
+		req.Header.Set("X-Content-SHA256", checksum)
+	}
+	return req
+}
+
+func TestSaveConfigChecksumMatch(t *testing.T) {
+	s := newDeployTestServer(t, "/existing/nyati.yaml", 1)
+	defer s.writeQueue.Close()
+
+	path := writeTestConfig(t, validConfigYAML)
+	body := []byte(fmt.Sprintf(`{"name": "demo", "description": "test", "path": %q}`, path))
+	sum := sha256.Sum256(body)
+
+	rec := httptest.NewRecorder()
+	s.handleSaveConfigs(rec, saveConfigRequest(t, body, hex.EncodeToString(sum[:])))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("save with matching checksum status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
+
+func TestSaveConfigChecksumMismatch(t *testing.T) {
+	s := newDeployTestServer(t, "/existing/nyati.yaml", 1)
+	defer s.writeQueue.Close()
+
+	body := []byte(`{"name": "demo", "description": "test", "path": "/new/nyati.yaml"}`)
+	wrong := sha256.Sum256([]byte("different content"))
+
+	rec := httptest.NewRecorder()
+	s.handleSaveConfigs(rec, saveConfigRequest(t, body, hex.EncodeToString(wrong[:])))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("save with mismatching checksum status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+
+	// Nothing may be persisted on mismatch
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM configs WHERE path = ?", "/new/nyati.yaml").Scan(&count); err != nil {
+		t.Fatalf("failed to count configs: %v", err)
+	}
+	if count != 0 {
+		t.Error("config was persisted despite a checksum mismatch")
+	}
+}
+
+func TestSaveConfigWithoutChecksumStillWorks(t *testing.T) {
+	s := newDeployTestServer(t, "/existing/nyati.yaml", 1)
+	defer s.writeQueue.Close()
+
+	path := writeTestConfig(t, validConfigYAML)
+	body := []byte(fmt.Sprintf(`{"name": "demo", "description": "test", "path": %q}`, path))
+
+	rec := httptest.NewRecorder()
+	s.handleSaveConfigs(rec, saveConfigRequest(t, body, ""))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("save without checksum status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
+
+func TestSaveConfigRejectsInvalidReferencedConfig(t *testing.T) {
+	s := newDeployTestServer(t, "/existing/nyati.yaml", 1)
+	defer s.writeQueue.Close()
+
+	// A file that parses but fails validation (no hosts, no tasks)
+	path := writeTestConfig(t, "version: \"0.1.2\"\nappname: demo\n")
+	body := []byte(fmt.Sprintf(`{"name": "broken", "description": "", "path": %q}`, path))
+
+	rec := httptest.NewRecorder()
+	s.handleSaveConfigs(rec, saveConfigRequest(t, body, ""))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("save of invalid config status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+
+	// The rejection carries structured validation errors
+	var result ValidateConfigResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("rejection body is not structured: %v", err)
+	}
+	if result.Valid || len(result.Errors) == 0 {
+		t.Errorf("rejection result = %+v, want valid=false with errors", result)
+	}
+
+	// Nothing may be persisted on rejection
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM configs WHERE path = ?", path).Scan(&count); err != nil {
+		t.Fatalf("failed to count configs: %v", err)
+	}
+	if count != 0 {
+		t.Error("invalid config was persisted")
+	}
+}
+
+func TestSaveConfigNormalizesUnknownStatus(t *testing.T) {
+	s := newDeployTestServer(t, "/existing/nyati.yaml", 1)
+	defer s.writeQueue.Close()
+
+	path := writeTestConfig(t, validConfigYAML)
+	body := []byte(fmt.Sprintf(`{"name": "demo", "description": "", "path": %q, "status": "BOGUS"}`, path))
+
+	rec := httptest.NewRecorder()
+	s.handleSaveConfigs(rec, saveConfigRequest(t, body, ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("save status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var status string
+	if err := s.db.QueryRow("SELECT status FROM configs WHERE path = ?", path).Scan(&status); err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	if status != "DRAFT" {
+		t.Errorf("status = %q, want unknown states normalized to DRAFT", status)
+	}
+}
@@ -1,13 +1,17 @@
 package api
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io/fs"
+	"hash"
+	"io"
 	"log"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -21,7 +25,7 @@ import (
 	"github.com/zechtz/nyatictl/config"
 	"github.com/zechtz/nyatictl/db"
 	"github.com/zechtz/nyatictl/logger"
-	"github.com/zechtz/nyatictl/web"
+	"github.com/zechtz/nyatictl/notify"
 )
 
 // Embed the web/build directory
@@ -35,12 +39,27 @@ import (
 //   - REST API endpoints for config management and task execution
 //   - Serving the embedded React frontend
 type Server struct {
-	configs     []ConfigEntry          // In-memory list of available config entries
-	configsLock sync.Mutex             // Mutex to protect access to configs
-	logChannels map[string]chan string // Session ID -> log channel mapping for WebSocket streaming
-	logLock     sync.Mutex             // Mutex to protect logChannels map
-	upgrader    websocket.Upgrader     // WebSocket upgrader with origin check disabled
-	db          *db.MetricsDB          // SQLite database connection with metrics
+	logChannels map[string]*logBroadcaster // Session ID -> broadcaster fanning logs out to WebSocket subscribers
+	logLock     sync.Mutex                 // Mutex to protect logChannels map
+	upgrader    websocket.Upgrader         // WebSocket upgrader with origin check disabled
+	db          *db.MetricsDB              // SQLite database connection with metrics
+	writeQueue  *WriteQueue                // Serialized writer for status/history updates under parallel deploys
+	sessions    *sessionRegistry           // In-flight deploy/task sessions drained on shutdown
+	limiter     *deployLimiter             // Server-wide cap on concurrent deploy/task/sandbox runs
+	targetLocks *targetLockRegistry        // Per config+host advisory locks serializing deploys to one target
+	notifier    *notify.Notifier           // Deploy-completion notifications; nil when not configured
+	retrier     *deployRetrier             // Queued automatic retries for connection-failed deploys; nil when disabled
+	elevations  *elevationRegistry         // Short-lived sudo-mode tokens for full secret reveals
+	health      *healthChecker             // Background per-host reachability checks; nil when disabled
+	lifecycle   *lifecycle                 // Tracked background workers, cancelled and awaited on shutdown
+	appCfg      *appconfig.Config          // Application settings, used for per-config notification emails; nil in tests
+
+	shutdownTimeout time.Duration // How long Close waits for active deploy sessions
+	logBufferSize   int           // Per-session log buffer capacity; 0 means default
+
+	setupLock     sync.Mutex // Serializes first-run setup attempts
+	setupRequired bool       // True until the initial admin user exists
+	setupToken    string     // One-time token printed at startup; empty once setup completes
 }
 
 // NewServer creates and initializes a new Server instance.
@@ -80,17 +99,19 @@ func NewServerWithConfig(cfg *appconfig.Config) (*Server, error) {
 		return nil, fmt.Errorf("migration check failed: %v", err)
 	}
 
-	// Initialize SQLite database connection with optimizations
-	rawDB, err := sql.Open("sqlite3", cfg.GetDatabaseURL())
+	// Initialize SQLite database connection with optimizations. The server
+	// owns the database, so a missing file is created here; the CLI shares
+	// the same helper but refuses to create one implicitly.
+	rawDB, err := cli.OpenDatabase(cfg.DatabasePath, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %v", err)
+		return nil, err
 	}
 
 	// Configure connection pool using configuration values
-	rawDB.SetMaxOpenConns(cfg.DatabaseMaxConns)        // Limit concurrent connections
-	rawDB.SetMaxIdleConns(cfg.DatabaseIdleConns)       // Keep idle connections for reuse
-	rawDB.SetConnMaxLifetime(cfg.DatabaseConnLife)     // Recycle connections based on config
-	rawDB.SetConnMaxIdleTime(cfg.DatabaseIdleTime)     // Close idle connections based on config
+	rawDB.SetMaxOpenConns(cfg.DatabaseMaxConns)    // Limit concurrent connections
+	rawDB.SetMaxIdleConns(cfg.DatabaseIdleConns)   // Keep idle connections for reuse
+	rawDB.SetConnMaxLifetime(cfg.DatabaseConnLife) // Recycle connections based on config
+	rawDB.SetConnMaxIdleTime(cfg.DatabaseIdleTime) // Close idle connections based on config
 
 	// Test the connection
 	if err := rawDB.Ping(); err != nil {
@@ -117,31 +138,37 @@ func NewServerWithConfig(cfg *appconfig.Config) (*Server, error) {
 	}
 
 	// Only create initial setup if no users exist
-	if userCount == 0 {
+	setupRequired := userCount == 0
+	setupToken := ""
+	if setupRequired {
+		setupToken = generateSetupToken()
 		log.Println("No users found. Initial setup required.")
-		log.Println("Please create an admin user by registering through the web interface.")
-		log.Println("The first user to register will have admin privileges.")
-	}
-
-	// Load all configs from the database initially (for server startup)
-	// We don't specify a user_id here because we want all configs
-	configs, err := LoadConfigs(metricsDB.DB)
-	if err != nil {
-		if closeErr := metricsDB.Close(); closeErr != nil {
-			log.Printf("Failed to close database after config load error: %v", closeErr)
-		}
-		return nil, fmt.Errorf("failed to load configs: %v", err)
+		log.Printf("Setup token: %s", setupToken)
+		log.Println("Create the admin user via POST /api/setup using this token.")
 	}
 
 	return &Server{
-		configs:     configs,
-		logChannels: make(map[string]chan string),
+		logChannels:   make(map[string]*logBroadcaster),
+		logBufferSize: cfg.LogBufferSize,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins for WebSocket connections
 			},
 		},
-		db: metricsDB,
+		db:              metricsDB,
+		writeQueue:      NewWriteQueue(metricsDB.DB, 256),
+		sessions:        newSessionRegistry(),
+		limiter:         newDeployLimiter(cfg.MaxConcurrentDeploys),
+		targetLocks:     newTargetLockRegistry(cfg.DeployLockMode),
+		notifier:        notify.FromConfig(cfg),
+		retrier:         newDeployRetrier(cfg.DeployRetryAttempts, cfg.DeployRetryDelay),
+		elevations:      newElevationRegistry(),
+		health:          newHealthChecker(cfg.HealthCheckEnabled, cfg.HealthCheckInterval, metricsDB.DB),
+		lifecycle:       newLifecycle(),
+		appCfg:          cfg,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		setupRequired:   setupRequired,
+		setupToken:      setupToken,
 	}, nil
 }
 
@@ -159,31 +186,36 @@ func NewServerWithConfig(cfg *appconfig.Config) (*Server, error) {
 //   - error: from ListenAndServe if the server fails to start
 func (s *Server) Start(port string) error {
 	// Note: Database connection is intentionally NOT closed here since the server
-	// needs it throughout its lifetime. The connection will be closed when the 
+	// needs it throughout its lifetime. The connection will be closed when the
 	// server instance is garbage collected or explicitly closed by calling Close().
 
+	// Servers built by hand (tests, embedders) may not have gone through
+	// NewServerWithConfig; give them a lifecycle too
+	if s.lifecycle == nil {
+		s.lifecycle = newLifecycle()
+	}
+
 	// Background goroutine to dispatch log messages to each session's WebSocket
-	go func() {
-		for msg := range logger.LogChan {
-			s.logLock.Lock()
-			for _, ch := range s.logChannels {
-				select {
-				case ch <- msg:
-				default:
-					// Drop log message if client's channel is full
-				}
-			}
-			s.logLock.Unlock()
-		}
-	}()
+	s.lifecycle.Go("log-dispatcher", s.dispatchLogs)
+
+	// Background worker re-running deploys that failed with a connection
+	// error; not started when automatic retries are disabled
+	if s.retrier != nil {
+		s.lifecycle.Go("deploy-retrier", s.runDeployRetries)
+	}
+
+	// Background reachability checks feeding the config health badges; not
+	// started when health checks are disabled
+	if s.health != nil {
+		s.lifecycle.Go("health-checker", s.health.run)
+	}
 
 	r := mux.NewRouter()
 
 	// --- Serve embedded frontend ---
-	uiFS, err := fs.Sub(web.EmbeddedUI, "dist")
-	if err != nil {
-		return fmt.Errorf("failed to access embedded UI: %v", err)
-	}
+	// A missing frontend build must not take the API down; resolveUIFS logs
+	// a warning and we fall back to a status page at /
+	uiFS := resolveUIFS()
 
 	// Add CORS middleware
 	corsHandler := handlers.CORS(
@@ -194,6 +226,17 @@ func (s *Server) Start(port string) error {
 		handlers.AllowCredentials(),
 	)(r)
 
+	// Block the API with 503 until first-run setup completes
+	r.Use(s.SetupMiddleware)
+
+	// Gzip large responses (UI bundle, list endpoints) for clients that
+	// accept it; websocket routes are passed through untouched
+	r.Use(compressionMiddleware)
+
+	// --- SETUP ROUTES (not protected, available before setup completes) ---
+	r.HandleFunc("/api/setup/status", s.handleSetupStatus).Methods("GET")
+	r.HandleFunc("/api/setup", s.handleSetup).Methods("POST")
+
 	// --- AUTH ROUTES (not protected) ---
 	r.HandleFunc("/api/login", s.HandleLogin).Methods("POST")
 	r.HandleFunc("/api/logout", s.HandleLogout).Methods("POST")
@@ -210,7 +253,9 @@ func (s *Server) Start(port string) error {
 
 	api.HandleFunc("/deploy", s.handleDeploy).Methods("POST")
 	api.HandleFunc("/task", s.handleExecuteTask).Methods("POST")
+	api.HandleFunc("/tasks/bulk", s.handleBulkTaskByLabel).Methods("POST")
 	api.HandleFunc("/refresh-token", s.HandleRefreshToken).Methods("POST")
+	api.HandleFunc("/auth/elevate", s.handleElevate).Methods("POST")
 
 	// Register the ConfigRoutes routes to the protected API subrouter
 	s.RegisterConfigRoutes(api)
@@ -224,41 +269,129 @@ func (s *Server) Start(port string) error {
 	// Register the sandbox routes to the protected API subrouter
 	s.RegisterSandboxRoutes(api)
 
+	// Register the deployment history routes to the protected API subrouter
+	s.RegisterDeploymentRoutes(api)
+
 	// Register the env routes to the protected API subrouter
 	s.InitEnvRoutes(api)
 
 	// Add metrics endpoint for administrators
 	api.HandleFunc("/metrics/database", s.handleDatabaseMetrics).Methods("GET")
-	
+
+	// Add the admin-only audit log endpoint
+	api.HandleFunc("/audit", s.handleAuditLog).Methods("GET")
+
+	// Add the per-user deployment statistics endpoint for the dashboard
+	api.HandleFunc("/stats", s.handleStats).Methods("GET")
+
+	// Add the per-user UI preference storage endpoints
+	api.HandleFunc("/account/preferences", s.withUser(s.handleGetPreferences)).Methods("GET")
+	api.HandleFunc("/account/preferences", s.withUser(s.handlePutPreferences)).Methods("PUT")
+
+	// Registered hosts files the deploy flow may pick among; listing is open
+	// to all users, registration and removal are admin-only
+	api.HandleFunc("/hosts-files", s.withUser(s.HandleListHostsFiles)).Methods("GET")
+	api.HandleFunc("/hosts-files", s.withUser(s.HandleRegisterHostsFile)).Methods("POST")
+	api.HandleFunc("/hosts-files", s.withUser(s.HandleUnregisterHostsFile)).Methods("DELETE")
+
+	// Add the per-user endpoint listing the caller's running deploy sessions
+	api.HandleFunc("/sessions", s.handleSessions).Methods("GET")
+
+	// Add the admin-only activity endpoint listing running deploy sessions
+	api.HandleFunc("/admin/activity", s.handleActivity).Methods("GET")
+
+	// Add the admin-only dashboard aggregates endpoint
+	api.HandleFunc("/admin/stats", s.handleAdminStats).Methods("GET")
+
+	// Add the admin-only debug endpoint listing the background workers
+	api.HandleFunc("/admin/goroutines", s.handleGoroutines).Methods("GET")
+
+	// Admin-only remote migration endpoints, for operators without shell
+	// access to the server
+	api.HandleFunc("/db/status", s.withUser(s.HandleDBStatus)).Methods("GET")
+	api.HandleFunc("/db/migrate", s.withUser(s.HandleDBMigrate)).Methods("POST")
+
 	// Add health check endpoint (no auth required)
 	r.HandleFunc("/health", s.handleHealthCheck).Methods("GET")
 
 	// WebSocket endpoint for real-time logs
 	r.HandleFunc("/ws/logs/{sessionID}", s.handleLogsWebSocket)
 
-	// --- EMBEDDED STATIC UI ---
+	// --- STATIC UI ---
 
-	// Create a file server handler
-	fileServer := http.FileServer(http.FS(uiFS))
+	// Handle all other requests with the UI file server, or the built-in
+	// status page when no frontend build is available
+	if uiFS != nil {
+		r.PathPrefix("/").HandlerFunc(uiHandler(uiFS))
+	} else {
+		r.PathPrefix("/").HandlerFunc(handleUIMissing)
+	}
+
+	log.Printf("Starting web server on :%s", port)
+
+	httpServer := &http.Server{Addr: ":" + port, Handler: corsHandler}
 
-	// Handle all other requests with the file server
-	r.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check if the path exists in our file system
-		_, err := uiFS.Open(strings.TrimPrefix(r.URL.Path, "/"))
-		if err != nil && os.IsNotExist(err) {
-			// If the file doesn't exist, serve the index.html file
-			// This enables client-side routing with React Router
-			r.URL.Path = "/"
+	// A fatal background worker error takes the HTTP server down gracefully
+	// instead of leaving the process serving requests without its workers
+	go func() {
+		err := <-s.lifecycle.fatalErrors()
+		logger.Error(fmt.Sprintf("Background worker failed: %v; shutting down", err))
+
+		ctx := context.Background()
+		if s.shutdownTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.shutdownTimeout)
+			defer cancel()
 		}
-		fileServer.ServeHTTP(w, r)
-	})
+		if err := httpServer.Shutdown(ctx); err != nil {
+			log.Printf("HTTP shutdown after worker failure: %v", err)
+		}
+	}()
 
-	log.Printf("Starting web server on :%s", port)
-	return http.ListenAndServe(":"+port, corsHandler)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
-// Close gracefully shuts down the server and closes database connections
+// dispatchLogs fans every message from the global logger channel out to the
+// per-session broadcasters. It runs as a lifecycle worker; a closed LogChan
+// is fatal because log streaming cannot recover from it.
+func (s *Server) dispatchLogs(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-logger.LogChan:
+			if !ok {
+				return fmt.Errorf("logger channel closed, log streaming cannot continue")
+			}
+			s.logLock.Lock()
+			for _, broadcaster := range s.logChannels {
+				// Each subscriber's buffer coalesces overflow into a drop
+				// marker instead of silently losing messages
+				broadcaster.Publish(msg)
+			}
+			s.logLock.Unlock()
+		}
+	}
+}
+
+// Close gracefully shuts down the server and closes database connections.
+// In-flight deploy sessions are drained and background workers are cancelled
+// and awaited (each up to the configured shutdown timeout), then pending
+// write-queue jobs are flushed before the database is closed.
 func (s *Server) Close() error {
+	if s.sessions != nil {
+		s.drainDeploySessions()
+	}
+	if s.lifecycle != nil && !s.lifecycle.stop(s.shutdownTimeout) {
+		logger.Error("Background workers did not stop within the shutdown timeout")
+	}
+	if s.writeQueue != nil {
+		s.writeQueue.Close()
+	}
+	s.health.close()
 	if s.db != nil {
 		return s.db.Close()
 	}
@@ -275,28 +408,62 @@ func (s *Server) handleGetConfigs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.configsLock.Lock()
-	defer s.configsLock.Unlock()
+	// Full export: stream entries row by row so memory stays flat no matter
+	// how many configs exist
+	if r.URL.Query().Get("stream") == "true" {
+		streamList(w, func(write func(any) error) error {
+			return ForEachConfig(s.db.DB, func(cfg ConfigEntry) error {
+				return write(cfg)
+			}, claims.UserID)
+		})
+		return
+	}
 
-	// Reload configs from the database to ensure freshness
+	// The database is the source of truth; no lock is needed for reads
 	configs, err := LoadConfigs(s.db.DB, claims.UserID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to load configs: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Log the config entries
-	// for _, cfg := range configs {
-	// 	log.Printf("Config Entry: %s, Path: %s, Status: %s", cfg.Name, cfg.Path, cfg.Status)
-	// }
+	// Optional label filter for grouped views
+	filtered := configs
+	if label := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("label"))); label != "" {
+		filtered = []ConfigEntry{}
+		for _, cfg := range configs {
+			if slices.Contains(cfg.Labels, label) {
+				filtered = append(filtered, cfg)
+			}
+		}
+	}
 
-	s.configs = configs
+	// Attach the background checker's reachability badges when it is on
+	if s.health != nil {
+		for i := range filtered {
+			statuses, err := loadHostStatuses(s.db.DB, filtered[i].ID)
+			if err != nil {
+				logger.Log(fmt.Sprintf("Failed to load host statuses: %v", err))
+				continue
+			}
+			filtered[i].HostStatus = statuses
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(s.configs)
+	json.NewEncoder(w).Encode(Paginate(r, filtered))
+}
+
+// validConfigStatuses are the config entry states the UI understands.
+var validConfigStatuses = map[string]bool{
+	"DEPLOYED": true,
+	"DRAFT":    true,
+	"TEMPLATE": true,
 }
 
-// handleSaveConfigs accepts a new or updated config entry and persists it to disk.
+// handleSaveConfigs accepts a new or updated config entry, validates the
+// config file it points at, and persists the entry only when the file loads
+// cleanly. Validation failures come back as structured errors so the editor
+// can show them inline.
 func (s *Server) handleSaveConfigs(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from the JWT claims in context
 	claims, ok := GetUserFromContext(r)
@@ -305,36 +472,95 @@ func (s *Server) handleSaveConfigs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Optional integrity check: hash the body as it streams through the
+	// decoder and compare against the client-provided checksum before
+	// anything is persisted. This catches truncated or corrupted saves.
+	wantSum := r.Header.Get("X-Content-SHA256")
+	body := io.Reader(r.Body)
+	var hasher hash.Hash
+	if wantSum != "" {
+		hasher = sha256.New()
+		body = io.TeeReader(r.Body, hasher)
+	}
+
 	var entry ConfigEntry
-	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+	if err := json.NewDecoder(body).Decode(&entry); err != nil {
 		log.Printf("JSON decode error: %v", err)
 		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
 
+	if hasher != nil {
+		// Hash any trailing bytes the decoder left unread, so the checksum
+		// covers the complete body
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		gotSum := hex.EncodeToString(hasher.Sum(nil))
+		if !strings.EqualFold(gotSum, wantSum) {
+			http.Error(w,
+				fmt.Sprintf("Checksum mismatch: body hashes to %s, X-Content-SHA256 is %s", gotSum, wantSum),
+				http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
 	// Set the user ID for the config
 	entry.UserID = claims.UserID
 
-	s.configsLock.Lock()
-	defer s.configsLock.Unlock()
+	// Validate the referenced config file before committing, so a broken
+	// file is caught at save time instead of at deploy time
+	if _, err := config.Load(entry.Path, "0.1.2"); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ValidateConfigResult{
+			Valid:    false,
+			Errors:   []string{err.Error()},
+			Warnings: []string{},
+		})
+		return
+	}
+
+	// Record the file's fingerprint alongside the entry, so a deploy can
+	// detect edits made after this save. Hash errors leave it empty, which
+	// disables the staleness check for this entry.
+	if hash, err := configFileHash(entry.Path); err == nil {
+		entry.ContentHash = hash
+	}
 
-	// Update existing config or append new one
-	updated := false
-	for i, cfg := range s.configs {
-		if cfg.Path == entry.Path {
-			// Only allow updates if the user owns the config
-			if cfg.UserID != claims.UserID {
-				http.Error(w, "You don't have permission to modify this config", http.StatusForbidden)
-				return
-			}
-			s.configs[i] = entry
-			updated = true
-			break
-		}
+	// Only persist a status the UI understands; anything else becomes a draft
+	if !validConfigStatuses[entry.Status] {
+		entry.Status = "DRAFT"
 	}
 
-	if !updated {
-		s.configs = append(s.configs, entry)
+	// Normalize labels (lowercase, deduplicated, bounded) before persisting
+	labels, err := normalizeConfigLabels(entry.Labels)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entry.Labels = labels
+
+	// A bound environment must exist and belong to the saver
+	if status, err := s.validateConfigEnvironment(entry, claims.UserID); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Only allow updates if the user owns the existing config
+	var ownerID int
+	switch err := s.db.QueryRow("SELECT user_id FROM configs WHERE path = ?", entry.Path).Scan(&ownerID); err {
+	case nil:
+		if ownerID != claims.UserID {
+			http.Error(w, "You don't have permission to modify this config", http.StatusForbidden)
+			return
+		}
+	case sql.ErrNoRows:
+		// New config; nothing to check
+	default:
+		http.Error(w, fmt.Sprintf("Failed to check config ownership: %v", err), http.StatusInternalServerError)
+		return
 	}
 
 	// Save the config to the database
@@ -348,7 +574,9 @@ func (s *Server) handleSaveConfigs(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Config saved successfully"})
 }
 
-// handleConfigDetails loads a specified config file and returns its task and host names.
+// handleConfigDetails loads a registered config file and returns its task and
+// host names. The path must exist in the configs table and belong to the
+// requesting user; arbitrary filesystem paths are rejected with 404.
 func (s *Server) handleConfigDetails(w http.ResponseWriter, r *http.Request) {
 	configPath := r.URL.Query().Get("path")
 	if configPath == "" {
@@ -356,16 +584,39 @@ func (s *Server) handleConfigDetails(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if status, err := s.authorizeConfigAccess(r, configPath, "access"); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
 	cfg, err := config.Load(configPath, "0.1.2")
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to load config: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to load config: %v", err), statusForError(err))
 		return
 	}
 
+	// Task annotations and dependency edges let the UI render the graph and
+	// runbook links without loading the raw YAML itself
+	type taskDetail struct {
+		Name      string   `json:"name"`
+		DependsOn []string `json:"depends_on,omitempty"`
+		Estimate  string   `json:"estimate,omitempty"`
+		Doc       string   `json:"doc,omitempty"`
+		Owner     string   `json:"owner,omitempty"`
+	}
+
 	// Extract task and host names
 	tasks := make([]string, 0, len(cfg.Tasks))
+	details := make([]taskDetail, 0, len(cfg.Tasks))
 	for _, task := range cfg.Tasks {
 		tasks = append(tasks, task.Name)
+		details = append(details, taskDetail{
+			Name:      task.Name,
+			DependsOn: task.DependsOn,
+			Estimate:  task.Estimate,
+			Doc:       task.Doc,
+			Owner:     task.Owner,
+		})
 	}
 
 	hosts := make([]string, 0, len(cfg.Hosts)+1)
@@ -374,10 +625,16 @@ func (s *Server) handleConfigDetails(w http.ResponseWriter, r *http.Request) {
 		hosts = append(hosts, hostName)
 	}
 
+	// The bound environment preselects the environment dropdown in the UI
+	var environmentID sql.NullInt64
+	s.db.QueryRow("SELECT environment_id FROM configs WHERE path = ?", configPath).Scan(&environmentID)
+
 	response := struct {
-		Tasks []string `json:"tasks"`
-		Hosts []string `json:"hosts"`
-	}{Tasks: tasks, Hosts: hosts}
+		Tasks         []string     `json:"tasks"`
+		TaskDetails   []taskDetail `json:"task_details"`
+		Hosts         []string     `json:"hosts"`
+		EnvironmentID int          `json:"environment_id,omitempty"`
+	}{Tasks: tasks, TaskDetails: details, Hosts: hosts, EnvironmentID: int(environmentID.Int64)}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -385,86 +642,213 @@ func (s *Server) handleConfigDetails(w http.ResponseWriter, r *http.Request) {
 
 // handleDeploy triggers a deployment using the provided config and host.
 func (s *Server) handleDeploy(w http.ResponseWriter, r *http.Request) {
-	// Get user ID from the JWT claims in context
-	claims, ok := GetUserFromContext(r)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
 	var req struct {
-		ConfigPath string `json:"configPath"`
-		Host       string `json:"host"`
-		SessionID  string `json:"sessionID"`
+		ConfigPath     string   `json:"configPath"`
+		Host           string   `json:"host"`
+		SessionID      string   `json:"sessionID"`
+		IncludeLib     bool     `json:"includeLib"`
+		Tasks          []string `json:"tasks"`
+		ExcludeTasks   []string `json:"excludeTasks"`
+		Only           bool     `json:"only"`  // Run the listed tasks without their dependencies
+		Force          bool     `json:"force"` // Deploy even if the file changed since the entry was saved
+		Note           string   `json:"note"`
+		ReleaseVersion int64    `json:"releaseVersion"`
+
+		// Params override config params for this run only; precedence is
+		// request > config > global defaults. Validated against param_schema.
+		Params map[string]string `json:"params"`
+
+		// HostsFile retargets this deploy at the hosts defined by a
+		// registered hosts file; unregistered paths are rejected.
+		HostsFile string `json:"hostsFile"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Check if the user owns this config
-	var userID int
-	err := s.db.DB.QueryRow("SELECT user_id FROM configs WHERE path = ?", req.ConfigPath).Scan(&userID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "Config not found", http.StatusNotFound)
-		} else {
-			http.Error(w, fmt.Sprintf("Database error: %v", err), http.StatusInternalServerError)
+	// Check registration and ownership of the target config
+	if status, err := s.authorizeConfigAccess(r, req.ConfigPath, "deploy"); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Refuse to deploy a file that changed since the entry was saved or last
+	// revalidated, unless the caller explicitly forces it. Entries without a
+	// stored hash (saved before hashes existed) skip the check.
+	forcedStale := false
+	if stored := s.storedConfigHash(req.ConfigPath); stored != "" {
+		if current, err := configFileHash(req.ConfigPath); err == nil && current != stored {
+			if !req.Force {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error":        "Config file changed since it was last saved or validated; re-validate it or retry with force=true",
+					"saved_hash":   stored,
+					"current_hash": current,
+				})
+				return
+			}
+			forcedStale = true
+			logger.Log(fmt.Sprintf("Warning: deploying %s with force=true; file changed since last validation", req.ConfigPath))
 		}
+	}
+
+	// A hosts file must come from the registered list; like unregistered
+	// configs, arbitrary filesystem paths get a 404
+	if req.HostsFile != "" && !hostsFileRegistered(s.db.DB, req.HostsFile) {
+		http.Error(w, fmt.Sprintf("Hosts file %s is not registered", req.HostsFile), http.StatusNotFound)
 		return
 	}
 
-	// Verify ownership
-	if userID != claims.UserID {
-		http.Error(w, "You don't have permission to deploy this config", http.StatusForbidden)
+	// Load the config up front so a bad file or a bad task selection fails
+	// the request instead of a log line inside the goroutine
+	cfg, err := config.LoadWithOptions(req.ConfigPath, "0.1.2", config.LoadOptions{Params: req.Params, HostsFile: req.HostsFile})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load config: %v", err), statusForError(err))
 		return
 	}
+	if err := cli.ValidateTaskNames(cfg, append(append([]string{}, req.Tasks...), req.ExcludeTasks...)); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if req.Only && len(req.Tasks) == 0 {
+		http.Error(w, "only mode requires at least one task in 'tasks'", http.StatusUnprocessableEntity)
+		return
+	}
+	opts := cli.RunOptions{
+		Tasks:        req.Tasks,
+		ExcludeTasks: req.ExcludeTasks,
+		Only:         req.Only,
+		IncludeLib:   req.IncludeLib,
+		Debug:        true,
+		AutoConfirm:  true, // Web deploys have no terminal to prompt on
+	}
 
-	// Create a log channel scoped to this session
-	logChan := make(chan string, 100)
-	s.logLock.Lock()
-	s.logChannels[req.SessionID] = logChan
-	s.logLock.Unlock()
+	// Resolve the config's bound environment before claiming any slot, so a
+	// deleted environment or a missing encryption key fails the request
+	// instead of a half-started deploy
+	envVars, envName, status, err := s.boundEnvironment(req.ConfigPath, r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	opts.Env = envVars
 
-	go func() {
-		defer func() {
-			s.logLock.Lock()
-			delete(s.logChannels, req.SessionID)
-			close(logChan)
-			s.logLock.Unlock()
-		}()
+	// Claim a deploy slot up front so a flood of requests cannot spawn
+	// unbounded SSH-opening goroutines; the running goroutine releases it
+	if !s.limiter.tryAcquire() {
+		http.Error(w, fmt.Sprintf("Too many concurrent deployments: %d of %d slots in use", s.limiter.running(), s.limiter.capacity()), http.StatusTooManyRequests)
+		return
+	}
 
-		cfg, err := config.Load(req.ConfigPath, "0.1.2")
-		if err != nil {
-			logger.Log(fmt.Sprintf("Error: %v", err))
+	// Serialize deploys per config+host: two runs writing the same release
+	// directory at once would corrupt it. In queue mode the request waits
+	// its turn; in reject mode the caller gets a conflict right away.
+	if !s.targetLocks.tryAcquire(req.ConfigPath, req.Host) {
+		if !s.targetLocks.queues() {
+			s.limiter.release()
+			http.Error(w, fmt.Sprintf("A deployment of %s to %s is already running", req.ConfigPath, req.Host), http.StatusConflict)
 			return
 		}
+		logger.Log(fmt.Sprintf("Deploy %s (%s): waiting for the in-flight deploy to the same target", req.ConfigPath, req.Host))
+		s.targetLocks.acquire(req.ConfigPath, req.Host)
+	}
+
+	// Record the deployment with a snapshot of the config as it is right now,
+	// so later debugging can tell whether the YAML changed between deploys
+	// Reuse an explicit release version (e.g. from an earlier phase or a
+	// rollback) instead of the freshly generated one
+	if req.ReleaseVersion > 0 {
+		cfg.ReleaseVersion = req.ReleaseVersion
+	}
+
+	note := sanitizeDeployNote(req.Note)
+	// Make the override visible in deployment history, so a later "why did
+	// this deploy run a stale file" question has its answer on the record
+	if forcedStale {
+		if note != "" {
+			note += "; "
+		}
+		note += "forced: config file changed after last validation"
+	}
+	// Record which environment's variables this deploy ran with
+	if envName != "" {
+		if note != "" {
+			note += "; "
+		}
+		note += "environment: " + envName
+	}
+	var deployID int64
+	var userID int
+	if claims, ok := GetUserFromContext(r); ok {
+		userID = claims.UserID
+		deployID = s.recordDeployment(claims.UserID, req.ConfigPath, req.Host, note, cfg.ReleaseVersion)
+		s.recordDeploymentParams(deployID, req.Params)
+	}
+
+	// Synchronous mode for CI: run the deploy inline and return the full
+	// result summary instead of streaming over the websocket
+	if r.URL.Query().Get("wait") == "true" {
+		s.handleDeploySync(w, cfg, req.ConfigPath, req.Host, req.SessionID, note, req.Params, deployID, userID, opts)
+		return
+	}
+
+	// Create a log broadcaster scoped to this session
+	broadcaster := newLogBroadcaster(s.logBufferSize)
+	s.logLock.Lock()
+	s.logChannels[req.SessionID] = broadcaster
+	s.logLock.Unlock()
+
+	s.sessions.begin(req.SessionID, req.ConfigPath, req.Host, userID)
+	go func() {
+		defer s.limiter.release()
+		defer s.targetLocks.release(req.ConfigPath, req.Host)
+		defer s.sessions.end(req.SessionID)
+		defer s.retireLogChannel(req.SessionID, broadcaster)
 
+		start := time.Now()
 		args := []string{"deploy", req.Host}
-		if err := cli.Run(cfg, args, "", false, true); err != nil {
+		results, err := cli.RunWithOptions(cfg, args, opts)
+		summary := notify.Summarize(req.ConfigPath, req.Host, results, time.Since(start))
+		summary.Note = note
+		summary.Params = req.Params
+		s.finishDeployment(deployID, err == nil, summary.Duration)
+		s.recordDeploymentResults(deployID, results)
+		s.notifier.DeployCompleted(summary)
+		s.notifyDeployTargets(req.ConfigPath, summary)
+		if err != nil {
 			logger.Log(fmt.Sprintf("Error: %v", err))
+			s.maybeRetryDeploy(deployRetryJob{
+				userID:         userID,
+				configPath:     req.ConfigPath,
+				host:           req.Host,
+				note:           note,
+				params:         req.Params,
+				releaseVersion: cfg.ReleaseVersion,
+				opts:           opts,
+				attempt:        1,
+			}, err)
 			return
 		}
 
-		// Update the config status to "DEPLOYED" after successful deployment
-		s.configsLock.Lock()
-		for i, cfg := range s.configs {
-			if cfg.Path == req.ConfigPath {
-				s.configs[i].Status = "DEPLOYED"
-
-				// Save the updated status to the database
-				if err := SaveConfig(s.db.DB, s.configs[i]); err != nil {
-					logger.Log(fmt.Sprintf("Failed to update config status: %v", err))
-				}
-				break
-			}
-		}
-		s.configsLock.Unlock()
+		s.markConfigDeployed(req.ConfigPath)
 	}()
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// markConfigDeployed sets a config's status to "DEPLOYED" through the
+// serialized write queue so parallel deploys don't contend on SQLite writes.
+// It takes no locks: the database is the source of truth and readers query
+// it directly.
+func (s *Server) markConfigDeployed(configPath string) {
+	s.writeQueue.Enqueue("config-status", func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE configs SET status = ? WHERE path = ?", "DEPLOYED", configPath)
+		return err
+	})
+}
+
 // handleExecuteTask runs a single task for a host using CLI execution.
 func (s *Server) handleExecuteTask(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from the JWT claims in context
@@ -485,92 +869,86 @@ func (s *Server) handleExecuteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if the user owns this config
-	var userID int
-	err := s.db.DB.QueryRow("SELECT user_id FROM configs WHERE path = ?", req.ConfigPath).Scan(&userID)
+	// Check registration and ownership of the target config
+	if status, err := s.authorizeConfigAccess(r, req.ConfigPath, "execute tasks on"); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	userID := claims.UserID
+
+	// Load the config up front so a bad file or an unknown task name fails
+	// the request instead of a log line inside the goroutine
+	cfg, err := config.Load(req.ConfigPath, "0.1.2")
 	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "Config not found", http.StatusNotFound)
-		} else {
-			http.Error(w, fmt.Sprintf("Database error: %v", err), http.StatusInternalServerError)
-		}
+		http.Error(w, fmt.Sprintf("Failed to load config: %v", err), statusForError(err))
 		return
 	}
+	if err := cli.ValidateTaskNames(cfg, []string{req.TaskName}); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Tags of the executed task, for webhooks scoped by tag filter
+	var taskTags []string
+	for _, task := range cfg.Tasks {
+		if task.Name == req.TaskName {
+			taskTags = task.Tags
+			break
+		}
+	}
 
-	// Verify ownership
-	if userID != claims.UserID {
-		http.Error(w, "You don't have permission to execute tasks on this config", http.StatusForbidden)
+	if !s.limiter.tryAcquire() {
+		http.Error(w, fmt.Sprintf("Too many concurrent deployments: %d of %d slots in use", s.limiter.running(), s.limiter.capacity()), http.StatusTooManyRequests)
 		return
 	}
 
-	logChan := make(chan string, 100)
+	broadcaster := newLogBroadcaster(s.logBufferSize)
 	s.logLock.Lock()
-	s.logChannels[req.SessionID] = logChan
+	s.logChannels[req.SessionID] = broadcaster
 	s.logLock.Unlock()
 
+	s.sessions.begin(req.SessionID, req.ConfigPath, req.Host, userID)
 	go func() {
-		defer func() {
-			s.logLock.Lock()
-			delete(s.logChannels, req.SessionID)
-			close(logChan)
-			s.logLock.Unlock()
-		}()
-		cfg, err := config.Load(req.ConfigPath, "0.1.2")
-		if err != nil {
-			logger.Log(fmt.Sprintf("Error: %v", err))
-
-			// Trigger webhooks for task failure
-			payload := WebhookPayload{
-				Event:      "task",
-				Action:     "execute",
-				Status:     "error",
-				Timestamp:  time.Now(),
-				ConfigPath: req.ConfigPath,
-				TaskName:   req.TaskName,
-				Host:       req.Host,
-				UserID:     userID,
-				Data: map[string]any{
-					"error": err.Error(),
-				},
-			}
-			TriggerWebhooks(s.db.DB, "task", payload)
-			return
-		}
+		defer s.limiter.release()
+		defer s.sessions.end(req.SessionID)
+		defer s.retireLogChannel(req.SessionID, broadcaster)
 		args := []string{"deploy", req.Host}
-		if err := cli.Run(cfg, args, req.TaskName, false, true); err != nil {
+		if _, err := cli.RunWithOptions(cfg, args, cli.RunOptions{TaskName: req.TaskName, Debug: true, AutoConfirm: true}); err != nil {
 			logger.Log(fmt.Sprintf("Error: %v", err))
 
 			// Trigger webhooks for task failure
 			payload := WebhookPayload{
-				Event:      "task",
+				Event:      EventTaskFailed,
 				Action:     "execute",
 				Status:     "error",
 				Timestamp:  time.Now(),
 				ConfigPath: req.ConfigPath,
 				TaskName:   req.TaskName,
+				Tags:       taskTags,
 				Host:       req.Host,
 				UserID:     userID,
 				Data: map[string]any{
 					"error": err.Error(),
 				},
 			}
-			TriggerWebhooks(s.db.DB, "task", payload)
+			TriggerWebhooks(s.db.DB, EventTaskFailed, payload)
 		} else {
 			// Trigger webhooks for task success
 			payload := WebhookPayload{
-				Event:      "task",
+				Event:      EventTaskSucceeded,
 				Action:     "execute",
 				Status:     "success",
 				Timestamp:  time.Now(),
 				ConfigPath: req.ConfigPath,
 				TaskName:   req.TaskName,
+				Tags:       taskTags,
 				Host:       req.Host,
 				UserID:     userID,
 				Data: map[string]any{
-					"config_name": getConfigName(s.configs, req.ConfigPath),
+					"config_name": s.configNameFor(req.ConfigPath),
 				},
 			}
-			TriggerWebhooks(s.db.DB, "task", payload)
+			TriggerWebhooks(s.db.DB, EventTaskSucceeded, payload)
 		}
 	}()
 
@@ -590,20 +968,29 @@ func (s *Server) handleLogsWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
-	var logChan chan string
-	// Wait until the log channel becomes available
+	var broadcaster *logBroadcaster
+	// Wait until the session's broadcaster becomes available
 	for {
 		s.logLock.Lock()
-		if ch, exists := s.logChannels[sessionID]; exists {
-			logChan = ch
+		if b, exists := s.logChannels[sessionID]; exists {
+			broadcaster = b
 			s.logLock.Unlock()
 			break
 		}
 		s.logLock.Unlock()
 	}
 
+	// Each client gets its own subscription, so parallel tabs on the same
+	// session all receive the full stream instead of splitting it
+	logBuf := broadcaster.Subscribe()
+	defer broadcaster.Unsubscribe(logBuf)
+
 	// Stream logs to WebSocket client
-	for logMsg := range logChan {
+	for {
+		logMsg, ok := logBuf.Next()
+		if !ok {
+			return
+		}
 		if err := conn.WriteMessage(websocket.TextMessage, []byte(logMsg)); err != nil {
 			log.Printf("WebSocket write failed: %v", err)
 			return
@@ -626,7 +1013,7 @@ func (s *Server) handleDatabaseMetrics(w http.ResponseWriter, r *http.Request) {
 
 	// Get current database metrics
 	metrics := s.db.GetMetrics()
-	
+
 	// Calculate average query duration in milliseconds
 	avgDuration := float64(0)
 	if metrics.QueryCount > 0 {
@@ -636,12 +1023,17 @@ func (s *Server) handleDatabaseMetrics(w http.ResponseWriter, r *http.Request) {
 	// Create response with additional context
 	response := map[string]interface{}{
 		"database_metrics": map[string]interface{}{
-			"total_queries":           metrics.QueryCount,
-			"total_errors":            metrics.ErrorCount,
-			"average_duration_ms":     avgDuration,
-			"open_connections":        metrics.OpenConns,
-			"idle_connections":        metrics.IdleConns,
-			"error_rate_percent":      float64(0),
+			"total_queries":          metrics.QueryCount,
+			"total_errors":           metrics.ErrorCount,
+			"average_duration_ms":    avgDuration,
+			"open_connections":       metrics.OpenConns,
+			"idle_connections":       metrics.IdleConns,
+			"write_queue_depth":      s.writeQueue.Depth(),
+			"active_deploys":         s.limiter.running(),
+			"max_concurrent_deploys": s.limiter.capacity(),
+			"websocket_log_drops":    s.logDropCounts(),
+			"websocket_subscribers":  s.logSubscriberCounts(),
+			"error_rate_percent":     float64(0),
 		},
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	}
@@ -666,20 +1058,20 @@ func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 
 	// Get basic database metrics
 	metrics := s.db.GetMetrics()
-	
+
 	// Calculate uptime (approximate based on when server started)
 	// For a more accurate uptime, you'd want to store start time as a field
 	startTime := time.Now().Add(-time.Hour) // Placeholder - replace with actual start time
 	uptime := time.Since(startTime)
 
 	response := map[string]interface{}{
-		"status": "ok",
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"status":         "ok",
+		"timestamp":      time.Now().UTC().Format(time.RFC3339),
 		"uptime_seconds": int(uptime.Seconds()),
 		"database": map[string]interface{}{
-			"status": dbStatus,
-			"total_queries": metrics.QueryCount,
-			"total_errors": metrics.ErrorCount,
+			"status":           dbStatus,
+			"total_queries":    metrics.QueryCount,
+			"total_errors":     metrics.ErrorCount,
 			"open_connections": metrics.OpenConns,
 			"idle_connections": metrics.IdleConns,
 		},
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SetupRequest represents the initial admin setup form data. The users table
+// stores no display name, so the request carries none.
+type SetupRequest struct {
+	Token    string `json:"token"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// SetupStatusResponse reports whether the server still needs first-run setup
+type SetupStatusResponse struct {
+	SetupRequired bool `json:"setup_required"`
+}
+
+// generateSetupToken creates the one-time setup token used to authorize the
+// first-run admin creation. The token is printed to the server log/console so
+// only someone with access to the host can complete setup.
+func generateSetupToken() string {
+	return uuid.New().String()
+}
+
+// setupIsRequired reports whether first-run setup is still pending.
+// It is safe for concurrent use.
+func (s *Server) setupIsRequired() bool {
+	s.setupLock.Lock()
+	defer s.setupLock.Unlock()
+	return s.setupRequired
+}
+
+// SetupMiddleware blocks all API routes (except the setup endpoints and the
+// health check) with 503 Service Unavailable until first-run setup completes.
+func (s *Server) SetupMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.setupIsRequired() &&
+			strings.HasPrefix(r.URL.Path, "/api/") &&
+			!strings.HasPrefix(r.URL.Path, "/api/setup") &&
+			r.Method != http.MethodOptions {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "setup required: create the admin user via POST /api/setup",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleSetupStatus reports whether the server still requires first-run setup.
+func (s *Server) handleSetupStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SetupStatusResponse{SetupRequired: s.setupIsRequired()})
+}
+
+// handleSetup creates the initial admin user using the one-time setup token.
+//
+// The token is generated at server startup (when the users table is empty) and
+// printed to the server log. Concurrent setup attempts are serialized; only the
+// first successful attempt creates the admin, later ones receive 409 Conflict.
+func (s *Server) handleSetup(w http.ResponseWriter, r *http.Request) {
+	var req SetupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Sanitize inputs the same way as registration
+	req.Email = sanitizeInput(req.Email)
+
+	if req.Email == "" || req.Password == "" {
+		http.Error(w, "Email and password are required", http.StatusBadRequest)
+		return
+	}
+
+	if !validateEmail(req.Email) {
+		http.Error(w, "Invalid email format", http.StatusBadRequest)
+		return
+	}
+
+	if passwordErrors := validatePassword(req.Password); len(passwordErrors) > 0 {
+		errorMsg := "Password validation failed: " + strings.Join(passwordErrors, ", ")
+		http.Error(w, errorMsg, http.StatusBadRequest)
+		return
+	}
+
+	// Serialize concurrent setup attempts: only the first one wins
+	s.setupLock.Lock()
+	defer s.setupLock.Unlock()
+
+	if !s.setupRequired {
+		http.Error(w, "Setup has already been completed", http.StatusConflict)
+		return
+	}
+
+	if req.Token == "" || req.Token != s.setupToken {
+		http.Error(w, "Invalid setup token", http.StatusUnauthorized)
+		return
+	}
+
+	// Double-check the users table is still empty before creating the admin
+	var userCount int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if userCount > 0 {
+		s.setupRequired = false
+		http.Error(w, "Setup has already been completed", http.StatusConflict)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = s.db.Exec(
+		"INSERT INTO users (email, password, is_admin, created_at) VALUES (?, ?, 1, ?)",
+		req.Email,
+		string(hashedPassword),
+		time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		http.Error(w, "Failed to create admin user", http.StatusInternalServerError)
+		return
+	}
+
+	// Setup complete: invalidate the token and unblock the API
+	s.setupRequired = false
+	s.setupToken = ""
+	log.Println("First-run setup completed: admin user created")
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Admin user created successfully"})
+}
+
+// GetSetting reads a value from the settings table, returning defaultValue
+// when the key is not present.
+func GetSetting(db *sql.DB, key, defaultValue string) string {
+	var value string
+	err := db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// SetSetting inserts or updates a value in the settings table.
+func SetSetting(db *sql.DB, key, value string) error {
+	_, err := db.Exec(
+		"INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value",
+		key, value,
+	)
+	return err
+}
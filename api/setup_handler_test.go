@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/zechtz/nyatictl/db"
+)
+
+// newSetupTestServer creates a Server backed by an in-memory SQLite database
+// with just the tables the setup flow needs.
+func newSetupTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	rawDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	t.Cleanup(func() { rawDB.Close() })
+
+	// The race in handleSetup is serialized by setupLock, not the database,
+	// so a single connection is enough for these tests.
+	rawDB.SetMaxOpenConns(1)
+
+	schema := []string{
+		`CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT UNIQUE NOT NULL,
+			password TEXT NOT NULL,
+			is_admin INTEGER NOT NULL DEFAULT 0,
+			created_at TEXT NOT NULL DEFAULT (datetime('now'))
+		)`,
+		`CREATE TABLE settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)`,
+	}
+	for _, stmt := range schema {
+		if _, err := rawDB.Exec(stmt); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+
+	return &Server{
+		db:            db.NewMetricsDB(rawDB),
+		setupRequired: true,
+		setupToken:    "test-setup-token",
+	}
+}
+
+func setupRequestBody(t *testing.T, token string) *bytes.Buffer {
+	t.Helper()
+
+	body, err := json.Marshal(SetupRequest{
+		Token:    token,
+		Email:    "admin@example.com",
+		Password: "StrongPass123!",
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal setup request: %v", err)
+	}
+	return bytes.NewBuffer(body)
+}
+
+func TestSetupStatus(t *testing.T) {
+	s := newSetupTestServer(t)
+
+	req := httptest.NewRequest("GET", "/api/setup/status", nil)
+	rec := httptest.NewRecorder()
+	s.handleSetupStatus(rec, req)
+
+	var status SetupStatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode status response: %v", err)
+	}
+	if !status.SetupRequired {
+		t.Error("expected setup_required to be true before setup")
+	}
+}
+
+func TestSetupWithInvalidToken(t *testing.T) {
+	s := newSetupTestServer(t)
+
+	req := httptest.NewRequest("POST", "/api/setup", setupRequestBody(t, "wrong-token"))
+	rec := httptest.NewRecorder()
+	s.handleSetup(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("handleSetup() status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if !s.setupIsRequired() {
+		t.Error("setup should still be required after a failed attempt")
+	}
+}
+
+func TestSetupConcurrentAttempts(t *testing.T) {
+	s := newSetupTestServer(t)
+
+	const attempts = 2
+	codes := make([]int, attempts)
+
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest("POST", "/api/setup", setupRequestBody(t, "test-setup-token"))
+			rec := httptest.NewRecorder()
+			s.handleSetup(rec, req)
+			codes[i] = rec.Code
+		}(i)
+	}
+	wg.Wait()
+
+	created, conflicted := 0, 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+			conflicted++
+		default:
+			t.Errorf("unexpected status code %d from concurrent setup", code)
+		}
+	}
+	if created != 1 {
+		t.Errorf("expected exactly one successful setup, got %d", created)
+	}
+	if conflicted != attempts-1 {
+		t.Errorf("expected %d conflicts, got %d", attempts-1, conflicted)
+	}
+
+	// Exactly one admin user should exist
+	var userCount int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE is_admin = 1").Scan(&userCount); err != nil {
+		t.Fatalf("failed to count users: %v", err)
+	}
+	if userCount != 1 {
+		t.Errorf("expected 1 admin user, got %d", userCount)
+	}
+	if s.setupIsRequired() {
+		t.Error("setup should be complete after a successful attempt")
+	}
+}
+
+func TestSetupMiddlewareBlocksAPIRoutes(t *testing.T) {
+	s := newSetupTestServer(t)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := s.SetupMiddleware(next)
+
+	// API routes are blocked while setup is pending
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/configs", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for API route during setup, got %d", rec.Code)
+	}
+
+	// Setup endpoints themselves remain reachable
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/setup/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for setup route during setup, got %d", rec.Code)
+	}
+
+	// Once setup completes, everything passes through
+	s.setupLock.Lock()
+	s.setupRequired = false
+	s.setupLock.Unlock()
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/configs", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for API route after setup, got %d", rec.Code)
+	}
+}
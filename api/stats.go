@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bounds for the stats aggregation window. Requests outside this range are
+// rejected rather than clamped, so a typo'd window is visible to the caller.
+const (
+	minStatsWindow = time.Hour
+	maxStatsWindow = 90 * 24 * time.Hour
+)
+
+// defaultStatsWindow is used when the request omits the window parameter.
+const defaultStatsWindow = 7 * 24 * time.Hour
+
+// topConfigLimit caps how many most-deployed configs the stats endpoint lists.
+const topConfigLimit = 5
+
+// ConfigDeployCount is one entry in the most-deployed-configs ranking.
+type ConfigDeployCount struct {
+	ConfigPath string `json:"config_path"`
+	Deploys    int    `json:"deploys"`
+}
+
+// DeployStats aggregates a user's deployment history over a time window for
+// the dashboard.
+type DeployStats struct {
+	Window        string              `json:"window"`          // The effective window, echoed back
+	Total         int                 `json:"total"`           // Deployments started in the window
+	Succeeded     int                 `json:"succeeded"`       // Deployments that finished successfully
+	Failed        int                 `json:"failed"`          // Deployments that finished with an error
+	SuccessRate   float64             `json:"success_rate"`    // Succeeded / finished, 0 when nothing finished
+	AvgDurationMs int64               `json:"avg_duration_ms"` // Mean run time of finished deployments
+	TopConfigs    []ConfigDeployCount `json:"top_configs"`     // Most-deployed configs, busiest first
+}
+
+// parseStatsWindow parses the window query parameter. Plain durations
+// ("24h", "90m") are accepted, plus a day suffix ("7d", "30d") since days are
+// the natural dashboard unit. The result must fall within the allowed bounds.
+//
+// Parameters:
+//   - raw: the window query parameter; empty selects the default
+//
+// Returns:
+//   - time.Duration: the parsed window
+//   - error: if the value is unparseable or out of bounds
+func parseStatsWindow(raw string) (time.Duration, error) {
+	if raw == "" {
+		return defaultStatsWindow, nil
+	}
+
+	var window time.Duration
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q; expected e.g. 7d or 24h", raw)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q; expected e.g. 7d or 24h", raw)
+		}
+		window = parsed
+	}
+
+	if window < minStatsWindow || window > maxStatsWindow {
+		return 0, fmt.Errorf("window %q out of range; expected between %s and %s", raw, minStatsWindow, maxStatsWindow)
+	}
+	return window, nil
+}
+
+// deployStats computes aggregate deployment statistics for one user over the
+// given window.
+//
+// Parameters:
+//   - userID: the user whose deployments are aggregated
+//   - window: how far back from now to look
+//
+// Returns:
+//   - DeployStats: the aggregated metrics
+//   - error: if a database query fails
+func (s *Server) deployStats(userID int, window time.Duration) (DeployStats, error) {
+	stats := DeployStats{Window: window.String(), TopConfigs: []ConfigDeployCount{}}
+
+	// SQLite stores CURRENT_TIMESTAMP as a UTC text timestamp, so the cutoff
+	// has to be formatted the same way to compare correctly
+	cutoff := time.Now().UTC().Add(-window).Format("2006-01-02 15:04:05")
+
+	err := s.db.QueryRow(
+		`SELECT COUNT(*),
+			COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END), 0),
+			COALESCE(AVG(CASE WHEN status != 'pending' THEN duration_ms END), 0)
+		FROM deployments WHERE user_id = ? AND created_at >= ?`,
+		userID, cutoff,
+	).Scan(&stats.Total, &stats.Succeeded, &stats.Failed, &stats.AvgDurationMs)
+	if err != nil {
+		return DeployStats{}, fmt.Errorf("failed to aggregate deployments: %v", err)
+	}
+
+	if finished := stats.Succeeded + stats.Failed; finished > 0 {
+		stats.SuccessRate = float64(stats.Succeeded) / float64(finished)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT config_path, COUNT(*) AS deploys
+		FROM deployments WHERE user_id = ? AND created_at >= ?
+		GROUP BY config_path ORDER BY deploys DESC, config_path LIMIT ?`,
+		userID, cutoff, topConfigLimit,
+	)
+	if err != nil {
+		return DeployStats{}, fmt.Errorf("failed to rank configs: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry ConfigDeployCount
+		if err := rows.Scan(&entry.ConfigPath, &entry.Deploys); err != nil {
+			return DeployStats{}, fmt.Errorf("failed to scan config ranking: %v", err)
+		}
+		stats.TopConfigs = append(stats.TopConfigs, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return DeployStats{}, fmt.Errorf("error during config ranking iteration: %v", err)
+	}
+
+	return stats, nil
+}
+
+// handleStats returns aggregate deployment statistics for the requesting
+// user, selected by GET /api/stats?window=7d.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	window, err := parseStatsWindow(r.URL.Query().Get("window"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.deployStats(claims.UserID, window)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseStatsWindow(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", defaultStatsWindow, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"24h", 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"soon", 0, true},
+		{"5m", 0, true},   // below the minimum
+		{"365d", 0, true}, // above the maximum
+	}
+	for _, c := range cases {
+		got, err := parseStatsWindow(c.raw)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseStatsWindow(%q) should fail", c.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseStatsWindow(%q) failed: %v", c.raw, err)
+		} else if got != c.want {
+			t.Errorf("parseStatsWindow(%q) = %s, want %s", c.raw, got, c.want)
+		}
+	}
+}
+
+// seedDeployment inserts one deployment row with a controlled outcome and age.
+func seedDeployment(t *testing.T, s *Server, userID int, configPath, status string, durationMs int, age time.Duration) {
+	t.Helper()
+
+	createdAt := time.Now().UTC().Add(-age).Format("2006-01-02 15:04:05")
+	if _, err := s.db.Exec(
+		`INSERT INTO deployments (config_path, host, user_id, config_hash, status, duration_ms, created_at)
+		VALUES (?, 'server1', ?, 'hash', ?, ?, ?)`,
+		configPath, userID, status, durationMs, createdAt,
+	); err != nil {
+		t.Fatalf("failed to seed deployment: %v", err)
+	}
+}
+
+func TestDeployStatsAggregatesSeededRows(t *testing.T) {
+	s := newDeploymentTestServer(t)
+
+	// In-window rows for user 1: three successes, one failure, one still
+	// running; /tmp/a.yaml is deployed most often
+	seedDeployment(t, s, 1, "/tmp/a.yaml", "success", 100, time.Hour)
+	seedDeployment(t, s, 1, "/tmp/a.yaml", "success", 200, 2*time.Hour)
+	seedDeployment(t, s, 1, "/tmp/a.yaml", "success", 300, 3*time.Hour)
+	seedDeployment(t, s, 1, "/tmp/b.yaml", "error", 400, 4*time.Hour)
+	seedDeployment(t, s, 1, "/tmp/b.yaml", "pending", 0, time.Minute)
+
+	// Outside the window and other users must not count
+	seedDeployment(t, s, 1, "/tmp/a.yaml", "success", 999, 8*24*time.Hour)
+	seedDeployment(t, s, 2, "/tmp/c.yaml", "success", 999, time.Hour)
+
+	stats, err := s.deployStats(1, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("deployStats failed: %v", err)
+	}
+
+	if stats.Total != 5 {
+		t.Errorf("total = %d, want 5", stats.Total)
+	}
+	if stats.Succeeded != 3 || stats.Failed != 1 {
+		t.Errorf("succeeded/failed = %d/%d, want 3/1", stats.Succeeded, stats.Failed)
+	}
+	if stats.SuccessRate != 0.75 {
+		t.Errorf("success rate = %v, want 0.75 (pending runs excluded)", stats.SuccessRate)
+	}
+	if stats.AvgDurationMs != 250 {
+		t.Errorf("avg duration = %dms, want 250 (mean of finished runs)", stats.AvgDurationMs)
+	}
+
+	if len(stats.TopConfigs) != 2 {
+		t.Fatalf("top configs = %d entries, want 2", len(stats.TopConfigs))
+	}
+	if stats.TopConfigs[0].ConfigPath != "/tmp/a.yaml" || stats.TopConfigs[0].Deploys != 3 {
+		t.Errorf("top config = %+v, want /tmp/a.yaml with 3 deploys", stats.TopConfigs[0])
+	}
+	if stats.TopConfigs[1].ConfigPath != "/tmp/b.yaml" || stats.TopConfigs[1].Deploys != 2 {
+		t.Errorf("second config = %+v, want /tmp/b.yaml with 2 deploys", stats.TopConfigs[1])
+	}
+}
+
+func TestDeployStatsEmptyWindow(t *testing.T) {
+	s := newDeploymentTestServer(t)
+
+	stats, err := s.deployStats(1, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("deployStats on empty history failed: %v", err)
+	}
+	if stats.Total != 0 || stats.SuccessRate != 0 || stats.AvgDurationMs != 0 {
+		t.Errorf("empty history stats = %+v, want zeros", stats)
+	}
+	if stats.TopConfigs == nil || len(stats.TopConfigs) != 0 {
+		t.Errorf("top configs = %v, want an empty (non-null) list", stats.TopConfigs)
+	}
+}
+
+func TestHandleStatsRejectsInvalidWindow(t *testing.T) {
+	s := newDeploymentTestServer(t)
+
+	req := httptest.NewRequest("GET", "/api/stats?window=soon", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 1}))
+
+	rec := httptest.NewRecorder()
+	s.handleStats(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid window status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestFinishDeploymentRecordsOutcome(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+
+	id := s.recordDeployment(1, path, "server1", "", 0)
+	if id == 0 {
+		t.Fatal("recordDeployment returned no ID")
+	}
+	s.finishDeployment(id, false, 1500*time.Millisecond)
+
+	var status string
+	var durationMs int64
+	if err := s.db.QueryRow("SELECT status, duration_ms FROM deployments WHERE id = ?", id).Scan(&status, &durationMs); err != nil {
+		t.Fatalf("failed to read deployment outcome: %v", err)
+	}
+	if status != "error" || durationMs != 1500 {
+		t.Errorf("outcome = (%s, %dms), want (error, 1500ms)", status, durationMs)
+	}
+}
+
+func TestRecordDeploymentStoresReleaseVersion(t *testing.T) {
+	s := newDeploymentTestServer(t)
+	path := writeTestConfig(t, "appname: demo\n")
+
+	id := s.recordDeployment(1, path, "server1", "", 1234567890)
+
+	var releaseVersion int64
+	if err := s.db.QueryRow("SELECT release_version FROM deployments WHERE id = ?", id).Scan(&releaseVersion); err != nil {
+		t.Fatalf("failed to read release version: %v", err)
+	}
+	if releaseVersion != 1234567890 {
+		t.Errorf("release_version = %d, want 1234567890", releaseVersion)
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// jsonArrayStreamer writes a JSON array to a response one element at a time,
+// so large list exports don't require building the full payload (or even the
+// full slice) in memory. Callers Write each item as it is produced and Close
+// once to terminate the array.
+type jsonArrayStreamer struct {
+	w     io.Writer
+	enc   *json.Encoder
+	count int
+}
+
+// newJSONArrayStreamer starts a JSON array on w.
+//
+// Parameters:
+//   - w: destination writer; callers set Content-Type before the first write
+//
+// Returns:
+//   - *jsonArrayStreamer: streamer positioned after the opening bracket
+//   - error: if the opening bracket cannot be written
+func newJSONArrayStreamer(w io.Writer) (*jsonArrayStreamer, error) {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nil, err
+	}
+	return &jsonArrayStreamer{w: w, enc: json.NewEncoder(w)}, nil
+}
+
+// Write encodes a single array element.
+//
+// Parameters:
+//   - item: the element to encode
+//
+// Returns:
+//   - error: if encoding or writing fails
+func (s *jsonArrayStreamer) Write(item any) error {
+	if s.count > 0 {
+		if _, err := io.WriteString(s.w, ","); err != nil {
+			return err
+		}
+	}
+	s.count++
+	return s.enc.Encode(item)
+}
+
+// Close terminates the array. The streamer must not be written to afterwards.
+//
+// Returns:
+//   - error: if the closing bracket cannot be written
+func (s *jsonArrayStreamer) Close() error {
+	_, err := io.WriteString(s.w, "]")
+	return err
+}
+
+// streamList writes a bare JSON array to w, with elements produced by
+// iterate calling write once per item. Used by list endpoints behind
+// ?stream=true for full exports; the response carries no envelope. A failure
+// mid-stream leaves the array unterminated (headers are already sent), which
+// clients detect as invalid JSON.
+//
+// Parameters:
+//   - w: the response writer
+//   - iterate: produces items by invoking write; a returned error aborts
+func streamList(w http.ResponseWriter, iterate func(write func(any) error) error) {
+	w.Header().Set("Content-Type", "application/json")
+	streamer, err := newJSONArrayStreamer(w)
+	if err != nil {
+		return
+	}
+	if err := iterate(streamer.Write); err != nil {
+		logger.Log(fmt.Sprintf("Failed to stream list: %v", err))
+		return
+	}
+	streamer.Close()
+}
+
+// queryInt parses an integer query parameter, returning 0 when it is absent
+// or not a number.
+func queryInt(r *http.Request, name string) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// paginate applies optional limit/offset values to a slice, returning the
+// requested page. Non-positive limit means no limit; offsets past the end
+// yield an empty page.
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return items[:0]
+		}
+		items = items[offset:]
+	}
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
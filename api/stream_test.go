@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestJSONArrayStreamerProducesValidJSON(t *testing.T) {
+	var buf bytes.Buffer
+	streamer, err := newJSONArrayStreamer(&buf)
+	if err != nil {
+		t.Fatalf("failed to start streamer: %v", err)
+	}
+	items := []map[string]string{{"a": "1"}, {"b": "2"}, {"c": "3"}}
+	for _, item := range items {
+		if err := streamer.Write(item); err != nil {
+			t.Fatalf("failed to write item: %v", err)
+		}
+	}
+	if err := streamer.Close(); err != nil {
+		t.Fatalf("failed to close streamer: %v", err)
+	}
+
+	var decoded []map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("streamed output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(items, decoded) {
+		t.Errorf("streamed output = %v, want %v", decoded, items)
+	}
+}
+
+func TestJSONArrayStreamerEmptyArray(t *testing.T) {
+	var buf bytes.Buffer
+	streamer, err := newJSONArrayStreamer(&buf)
+	if err != nil {
+		t.Fatalf("failed to start streamer: %v", err)
+	}
+	if err := streamer.Close(); err != nil {
+		t.Fatalf("failed to close streamer: %v", err)
+	}
+
+	var decoded []any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("empty stream is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(decoded) != 0 {
+		t.Errorf("empty stream decoded to %v, want empty array", decoded)
+	}
+}
+
+func TestStreamedConfigsMatchBuffered(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/nyati.yaml", 1)
+	for _, path := range []string{"/tmp/a.yaml", "/tmp/b.yaml"} {
+		if _, err := s.db.Exec(
+			"INSERT INTO configs (name, description, path, status, user_id) VALUES (?, '', ?, '', 1)",
+			path, path,
+		); err != nil {
+			t.Fatalf("failed to insert config: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleGetConfigs(rec, auditRequest(t, "GET", "/api/configs", nil, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("buffered configs status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var buffered PagedResponse[ConfigEntry]
+	if err := json.NewDecoder(rec.Body).Decode(&buffered); err != nil {
+		t.Fatalf("buffered output is not valid JSON: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleGetConfigs(rec, auditRequest(t, "GET", "/api/configs?stream=true", nil, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("streamed configs status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var streamed []ConfigEntry
+	if err := json.NewDecoder(rec.Body).Decode(&streamed); err != nil {
+		t.Fatalf("streamed output is not valid JSON: %v\n%s", err, rec.Body.String())
+	}
+
+	if !reflect.DeepEqual(buffered.Items, streamed) {
+		t.Errorf("streamed configs differ from buffered:\nbuffered: %+v\nstreamed: %+v", buffered.Items, streamed)
+	}
+	if len(streamed) != 3 {
+		t.Errorf("streamed %d configs, want 3", len(streamed))
+	}
+}
+
+func TestConfigsPagination(t *testing.T) {
+	s := newDeployTestServer(t, "/tmp/nyati.yaml", 1)
+	for _, path := range []string{"/tmp/a.yaml", "/tmp/b.yaml"} {
+		if _, err := s.db.Exec(
+			"INSERT INTO configs (name, description, path, status, user_id) VALUES (?, '', ?, '', 1)",
+			path, path,
+		); err != nil {
+			t.Fatalf("failed to insert config: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleGetConfigs(rec, auditRequest(t, "GET", "/api/configs?limit=1&page=2", nil, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("paginated configs status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var page PagedResponse[ConfigEntry]
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("paginated output is not valid JSON: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Path != "/tmp/a.yaml" {
+		t.Errorf("page = %+v, want the single second entry", page.Items)
+	}
+	if page.Total != 3 || page.Page != 2 || !page.HasNext {
+		t.Errorf("envelope = total %d page %d has_next %v, want 3/2/true", page.Total, page.Page, page.HasNext)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	if got := paginate(items, 0, 0); !reflect.DeepEqual(got, items) {
+		t.Errorf("no limit/offset = %v, want all items", got)
+	}
+	if got := paginate(items, 2, 0); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("limit 2 = %v, want [1 2]", got)
+	}
+	if got := paginate(items, 2, 3); !reflect.DeepEqual(got, []int{4, 5}) {
+		t.Errorf("limit 2 offset 3 = %v, want [4 5]", got)
+	}
+	if got := paginate(items, 0, 10); len(got) != 0 {
+		t.Errorf("offset past end = %v, want empty", got)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"sync"
+)
+
+const (
+	// lockModeReject answers a deploy to an already-busy target with
+	// 409 Conflict.
+	lockModeReject = "reject"
+
+	// lockModeQueue makes a deploy to an already-busy target wait for the
+	// running deploy to finish instead of failing.
+	lockModeQueue = "queue"
+)
+
+// targetLockRegistry serializes deploys per config+host target. Two deploys
+// writing the same release directory at once corrupt it, so a deploy claims
+// its target's advisory lock before running and releases it when done. The
+// mode decides what a second deploy to a held target gets: an immediate
+// rejection or a place in line. A nil registry locks nothing, mirroring the
+// nil-safe notifier and health checker.
+type targetLockRegistry struct {
+	mode string
+	lock sync.Mutex
+	held map[string]chan struct{} // Busy targets; the channel closes on release to wake queued waiters
+}
+
+// newTargetLockRegistry builds a registry for the configured mode. Anything
+// other than "queue" falls back to rejecting, the safer default.
+func newTargetLockRegistry(mode string) *targetLockRegistry {
+	if mode != lockModeQueue {
+		mode = lockModeReject
+	}
+	return &targetLockRegistry{
+		mode: mode,
+		held: make(map[string]chan struct{}),
+	}
+}
+
+// targetKey identifies one config+host pair. The NUL separator cannot appear
+// in either part, so distinct pairs never collide.
+func targetKey(configPath, host string) string {
+	return configPath + "\x00" + host
+}
+
+// queues reports whether deploys to a busy target should wait rather than
+// be rejected.
+func (r *targetLockRegistry) queues() bool {
+	return r != nil && r.mode == lockModeQueue
+}
+
+// tryAcquire claims the lock for one target without blocking. Every
+// successful acquire must be paired with a release when the deploy finishes.
+//
+// Parameters:
+//   - configPath: the config being deployed
+//   - host: the deploy target host (or "all")
+//
+// Returns:
+//   - bool: whether the lock was claimed
+func (r *targetLockRegistry) tryAcquire(configPath, host string) bool {
+	if r == nil {
+		return true
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := targetKey(configPath, host)
+	if _, busy := r.held[key]; busy {
+		return false
+	}
+	r.held[key] = make(chan struct{})
+	return true
+}
+
+// acquire claims the lock for one target, waiting for the current holder
+// (and any earlier waiters that win the race) to release it first. Used in
+// queue mode and by background retries, which have no request to answer
+// with a rejection.
+func (r *targetLockRegistry) acquire(configPath, host string) {
+	if r == nil {
+		return
+	}
+	for {
+		r.lock.Lock()
+		key := targetKey(configPath, host)
+		done, busy := r.held[key]
+		if !busy {
+			r.held[key] = make(chan struct{})
+			r.lock.Unlock()
+			return
+		}
+		r.lock.Unlock()
+		<-done
+	}
+}
+
+// release frees one target's lock and wakes any queued waiters. Only the
+// goroutine that acquired the lock may release it, the same discipline the
+// deploy limiter relies on.
+func (r *targetLockRegistry) release(configPath, host string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := targetKey(configPath, host)
+	if done, busy := r.held[key]; busy {
+		delete(r.held, key)
+		close(done)
+	}
+}
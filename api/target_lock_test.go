@@ -0,0 +1,116 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTargetLockRejectsSecondAcquire(t *testing.T) {
+	locks := newTargetLockRegistry(lockModeReject)
+
+	if !locks.tryAcquire("/tmp/nyati.yaml", "server1") {
+		t.Fatal("first acquire for an idle target failed")
+	}
+	if locks.tryAcquire("/tmp/nyati.yaml", "server1") {
+		t.Error("second acquire for a busy target succeeded")
+	}
+
+	locks.release("/tmp/nyati.yaml", "server1")
+	if !locks.tryAcquire("/tmp/nyati.yaml", "server1") {
+		t.Error("acquire after release failed")
+	}
+}
+
+func TestTargetLockDistinctTargetsIndependent(t *testing.T) {
+	locks := newTargetLockRegistry(lockModeReject)
+
+	if !locks.tryAcquire("/tmp/nyati.yaml", "server1") {
+		t.Fatal("acquire for server1 failed")
+	}
+	if !locks.tryAcquire("/tmp/nyati.yaml", "server2") {
+		t.Error("a lock on server1 should not block server2")
+	}
+	if !locks.tryAcquire("/tmp/other.yaml", "server1") {
+		t.Error("a lock on one config should not block another config")
+	}
+}
+
+func TestTargetLockQueueWaitsForRelease(t *testing.T) {
+	locks := newTargetLockRegistry(lockModeQueue)
+
+	if !locks.queues() {
+		t.Fatal("queue-mode registry should report that it queues")
+	}
+	if !locks.tryAcquire("/tmp/nyati.yaml", "server1") {
+		t.Fatal("first acquire failed")
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		locks.acquire("/tmp/nyati.yaml", "server1")
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("queued acquire completed while the lock was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	locks.release("/tmp/nyati.yaml", "server1")
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("queued acquire did not complete after release")
+	}
+	locks.release("/tmp/nyati.yaml", "server1")
+}
+
+func TestTargetLockNilRegistryLocksNothing(t *testing.T) {
+	var locks *targetLockRegistry
+
+	if !locks.tryAcquire("/tmp/nyati.yaml", "server1") {
+		t.Error("nil registry should grant every acquire")
+	}
+	if locks.queues() {
+		t.Error("nil registry should not report queueing")
+	}
+	locks.acquire("/tmp/nyati.yaml", "server1")
+	locks.release("/tmp/nyati.yaml", "server1")
+}
+
+func TestDeployConflictsWhenTargetLocked(t *testing.T) {
+	configPath := writeUnreachableHostConfig(t)
+	s := newDeployTestServer(t, configPath, 1)
+	defer s.writeQueue.Close()
+	s.targetLocks = newTargetLockRegistry(lockModeReject)
+
+	// Simulate an in-flight deploy holding the target
+	if !s.targetLocks.tryAcquire(configPath, "server1") {
+		t.Fatal("failed to claim the target for the in-flight deploy")
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleDeploy(rec, deployRequest(t, "/api/deploy", configPath, "server1", 1))
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("deploy to a locked target status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "already running") {
+		t.Errorf("conflict body should explain the running deploy, got %q", body)
+	}
+
+	// The rejected deploy must give its concurrency slot back
+	if s.limiter.running() != 0 {
+		t.Errorf("limiter has %d slot(s) in use after a rejected deploy, want 0", s.limiter.running())
+	}
+
+	// Once the in-flight deploy releases the target it can be claimed again
+	s.targetLocks.release(configPath, "server1")
+	if !s.targetLocks.tryAcquire(configPath, "server1") {
+		t.Error("target still locked after release")
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zechtz/nyatictl/logger"
+	"github.com/zechtz/nyatictl/web"
+)
+
+// uiDirEnv names an external directory to serve the frontend from instead of
+// the embedded build, useful during frontend development.
+const uiDirEnv = "NYATI_UI_DIR"
+
+// resolveUIFS returns the filesystem to serve the frontend from: the
+// NYATI_UI_DIR override when set, otherwise the embedded dist build. It
+// returns nil when neither contains a built UI, in which case the server
+// falls back to a built-in status page while keeping the API fully
+// functional.
+func resolveUIFS() fs.FS {
+	if dir := os.Getenv(uiDirEnv); dir != "" {
+		if _, err := os.Stat(filepath.Join(dir, "index.html")); err != nil {
+			logger.Warn(fmt.Sprintf("%s=%s has no index.html; falling back to the embedded UI", uiDirEnv, dir))
+		} else {
+			logger.Info(fmt.Sprintf("Serving UI from %s (%s override)", dir, uiDirEnv))
+			// os.DirFS only accepts slash-separated paths validated by
+			// fs.ValidPath, so requests cannot traverse out of the directory
+			return os.DirFS(dir)
+		}
+	}
+
+	uiFS, err := fs.Sub(web.EmbeddedUI, "dist")
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Embedded UI is not available: %v", err))
+		return nil
+	}
+	if f, err := uiFS.Open("index.html"); err != nil {
+		logger.Warn("Embedded UI was not bundled into this binary; the API stays available but / serves a status page. Build the frontend (web/dist) and rebuild, or set " + uiDirEnv + ".")
+		return nil
+	} else {
+		f.Close()
+	}
+	return uiFS
+}
+
+// computeUIETags hashes every file in uiFS once at startup, producing the
+// strong ETag served for each path. The frontend bundle cannot change while
+// the process runs, so precomputing avoids hashing on every request.
+//
+// Parameters:
+//   - uiFS: the frontend filesystem to hash
+//
+// Returns:
+//   - map[string]string: quoted ETag per slash-separated file path
+func computeUIETags(uiFS fs.FS) map[string]string {
+	etags := make(map[string]string)
+	fs.WalkDir(uiFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		data, err := fs.ReadFile(uiFS, path)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Failed to hash UI file %s for its ETag: %v", path, err))
+			return nil
+		}
+		etags[path] = fmt.Sprintf("%q", fmt.Sprintf("%x", sha256.Sum256(data)))
+		return nil
+	})
+	return etags
+}
+
+// uiNotModified reports whether the client's cached copy is still current.
+// If-None-Match takes precedence over If-Modified-Since, per RFC 9110.
+func uiNotModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		return match == "*" || strings.Contains(match, etag)
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" {
+		if t, err := http.ParseTime(since); err == nil {
+			return !modified.Truncate(time.Second).After(t)
+		}
+	}
+	return false
+}
+
+// uiHandler serves the frontend from uiFS, falling back to index.html for
+// unknown paths so client-side routing keeps working. Request paths that are
+// not valid fs paths (e.g. containing "..") are rejected outright. Files are
+// served with a precomputed strong ETag and a Last-Modified of process start
+// (embedded files carry no timestamps), and conditional requests get a 304
+// so a reloading browser does not refetch the whole bundle. The dev override
+// directory serves live files, so its startup ETags would mask edits and are
+// skipped.
+func uiHandler(uiFS fs.FS) http.HandlerFunc {
+	fileServer := http.FileServer(http.FS(uiFS))
+
+	var etags map[string]string
+	if os.Getenv(uiDirEnv) == "" {
+		etags = computeUIETags(uiFS)
+	}
+	startedAt := time.Now().UTC()
+	lastModified := startedAt.Format(http.TimeFormat)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/")
+		if path != "" && !fs.ValidPath(path) {
+			http.Error(w, "Invalid path", http.StatusBadRequest)
+			return
+		}
+		// Check if the path exists in our file system
+		if _, err := uiFS.Open(path); path != "" && err != nil && os.IsNotExist(err) {
+			// If the file doesn't exist, serve the index.html file
+			// This enables client-side routing with React Router
+			r.URL.Path = "/"
+			path = ""
+		}
+
+		name := path
+		if name == "" {
+			name = "index.html"
+		}
+		if etag, ok := etags[name]; ok {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", lastModified)
+			if uiNotModified(r, etag, startedAt) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		fileServer.ServeHTTP(w, r)
+	}
+}
+
+// uiMissingPage is served at / when no frontend build is available.
+const uiMissingPage = `<!DOCTYPE html>
+<html>
+<head><title>Nyatictl</title></head>
+<body>
+<h1>Nyatictl API is running</h1>
+<p>The web UI was not bundled into this binary. The REST API under /api and
+the WebSocket endpoints under /ws remain fully functional.</p>
+<p>To get the UI, build the frontend into web/dist and rebuild the binary,
+or point NYATI_UI_DIR at a built frontend directory.</p>
+</body>
+</html>
+`
+
+// handleUIMissing explains why there is no frontend instead of failing the
+// whole server when the dist directory was never built.
+func handleUIMissing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, uiMissingPage)
+}
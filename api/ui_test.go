@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolveUIFSUsesExternalDirOverride(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>dev build</html>"), 0644); err != nil {
+		t.Fatalf("failed to write index.html: %v", err)
+	}
+	t.Setenv(uiDirEnv, dir)
+
+	uiFS := resolveUIFS()
+	if uiFS == nil {
+		t.Fatal("resolveUIFS returned nil with a valid override directory")
+	}
+
+	rec := httptest.NewRecorder()
+	uiHandler(uiFS)(rec, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rec.Body.String(), "dev build") {
+		t.Errorf("override dir not served, got %q", rec.Body.String())
+	}
+}
+
+func TestResolveUIFSIgnoresOverrideWithoutIndex(t *testing.T) {
+	t.Setenv(uiDirEnv, t.TempDir())
+
+	// An override without a built UI falls back to the embedded dist
+	if uiFS := resolveUIFS(); uiFS == nil {
+		t.Error("resolveUIFS should fall back to the embedded UI")
+	}
+}
+
+func TestUIHandlerRejectsInvalidPaths(t *testing.T) {
+	uiFS := fstest.MapFS{"index.html": {Data: []byte("home")}}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.URL.Path = "/../secrets.txt"
+	uiHandler(uiFS)(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("traversal path status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestUIHandlerFallsBackToIndexForClientRoutes(t *testing.T) {
+	uiFS := fstest.MapFS{"index.html": {Data: []byte("home")}}
+
+	rec := httptest.NewRecorder()
+	uiHandler(uiFS)(rec, httptest.NewRequest("GET", "/settings/profile", nil))
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "home") {
+		t.Errorf("client route got status %d body %q, want index.html", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleUIMissingKeepsAPIDocumented(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleUIMissing(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status page status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"not bundled", "/api", "NYATI_UI_DIR"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("status page missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestUIHandlerReturns304ForCachedIndex(t *testing.T) {
+	uiFS := fstest.MapFS{"index.html": {Data: []byte("home")}}
+	handler := uiHandler(uiFS)
+
+	// First fetch carries the validators
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first fetch status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	etag := rec.Header().Get("ETag")
+	lastModified := rec.Header().Get("Last-Modified")
+	if etag == "" || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("ETag = %q, want a strong quoted ETag", etag)
+	}
+	if lastModified == "" {
+		t.Fatal("Last-Modified header missing")
+	}
+
+	// A second fetch with If-None-Match is answered with 304 and no body
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("conditional fetch status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("304 response carried a %d-byte body", rec.Body.Len())
+	}
+
+	// If-Modified-Since alone also revalidates
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since fetch status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+
+	// A stale ETag still gets the full file
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "home" {
+		t.Errorf("stale ETag fetch = %d %q, want the full index.html", rec.Code, rec.Body.String())
+	}
+}
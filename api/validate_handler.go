@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/zechtz/nyatictl/api/response"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/lint"
+)
+
+// ValidateConfigRequest represents a request to validate a config without
+// saving it. Either a path to an existing file or inline YAML content must be
+// provided; inline content takes precedence.
+type ValidateConfigRequest struct {
+	Path    string `json:"path,omitempty"`    // Path to a config file on disk
+	Content string `json:"content,omitempty"` // Inline YAML content from the editor
+}
+
+// ValidateConfigResult contains the structured outcome of a validation run.
+type ValidateConfigResult struct {
+	Valid    bool     `json:"valid"`    // Whether the config passed validation
+	Errors   []string `json:"errors"`   // Fatal validation errors
+	Warnings []string `json:"warnings"` // Non-fatal issues worth surfacing in the editor
+}
+
+// handleValidateConfig validates a config (by path or inline content) and
+// returns structured errors/warnings without persisting anything. This powers
+// the "Validate" button in the config editor.
+func (s *Server) handleValidateConfig(w http.ResponseWriter, r *http.Request) {
+	rw := response.NewWriter(w)
+
+	var req ValidateConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.BadRequest("Invalid request body")
+		return
+	}
+
+	if req.Path == "" && req.Content == "" {
+		rw.BadRequest("Either 'path' or 'content' is required")
+		return
+	}
+
+	result := ValidateConfigResult{
+		Valid:    true,
+		Errors:   []string{},
+		Warnings: []string{},
+	}
+
+	var cfg *config.Config
+	var err error
+	if req.Content != "" {
+		cfg, err = config.LoadBytes([]byte(req.Content), "0.1.2")
+	} else {
+		cfg, err = config.Load(req.Path, "0.1.2")
+	}
+
+	if err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	if cfg != nil {
+		result.Warnings = append(result.Warnings, configWarnings(cfg)...)
+	}
+
+	// A successful revalidation of an on-disk file means the user has seen
+	// its current state, so refresh the stored hash and stop the deploy-time
+	// staleness warning from firing for this version of the file
+	if result.Valid && req.Content == "" {
+		s.refreshConfigHash(req.Path)
+	}
+
+	rw.Success(result)
+}
+
+// configWarnings collects non-fatal issues in a config that validation does
+// not reject but that are likely to cause problems at deploy time.
+func configWarnings(cfg *config.Config) []string {
+	var warnings []string
+
+	for name, host := range cfg.Hosts {
+		if host.Password == "" && host.PrivateKey == "" {
+			warnings = append(warnings, fmt.Sprintf("host '%s': no password or private_key configured; connections will fail", name))
+		}
+	}
+
+	// Dangerous command patterns share the linter's ruleset so the editor
+	// and `nyatictl lint` warn about the same things
+	for _, task := range cfg.Tasks {
+		for _, msg := range lint.CheckCommand(task.Cmd) {
+			warnings = append(warnings, fmt.Sprintf("task '%s': %s", task.Name, msg))
+		}
+	}
+
+	for _, task := range cfg.Tasks {
+		if task.Lib && len(task.DependsOn) == 0 && !isDependedOn(cfg.Tasks, task.Name) {
+			warnings = append(warnings, fmt.Sprintf("task '%s': marked as lib but nothing depends on it", task.Name))
+		}
+	}
+
+	return warnings
+}
+
+// isDependedOn reports whether any task lists name in its depends_on list.
+func isDependedOn(tasks []config.Task, name string) bool {
+	for _, task := range tasks {
+		for _, dep := range task.DependsOn {
+			if dep == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const validInlineConfig = `version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 192.168.1.10
+    username: deploy
+    password: secret
+tasks:
+  - name: build
+    cmd: make build
+`
+
+func validateRequest(t *testing.T, req ValidateConfigRequest) (*httptest.ResponseRecorder, ValidateConfigResult) {
+	t.Helper()
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+
+	s := &Server{}
+	rec := httptest.NewRecorder()
+	s.handleValidateConfig(rec, httptest.NewRequest("POST", "/api/config/validate", bytes.NewBuffer(body)))
+
+	var envelope struct {
+		Success bool                 `json:"success"`
+		Data    ValidateConfigResult `json:"data"`
+	}
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&envelope); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return rec, envelope.Data
+}
+
+func TestValidateConfigInlineValid(t *testing.T) {
+	rec, result := validateRequest(t, ValidateConfigRequest{Content: validInlineConfig})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("handleValidateConfig() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !result.Valid {
+		t.Errorf("expected config to be valid, got errors: %v", result.Errors)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("expected no errors, got %v", result.Errors)
+	}
+}
+
+func TestValidateConfigInlineInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "malformed yaml",
+			content: "appname: [unclosed",
+		},
+		{
+			name: "missing appname",
+			content: `version: "0.1.2"
+hosts:
+  server1:
+    host: 192.168.1.10
+    username: deploy
+tasks:
+  - name: build
+    cmd: make build
+`,
+		},
+		{
+			name: "duplicate task names",
+			content: `version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 192.168.1.10
+    username: deploy
+tasks:
+  - name: build
+    cmd: make build
+  - name: build
+    cmd: make build
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, result := validateRequest(t, ValidateConfigRequest{Content: tt.content})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("handleValidateConfig() status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if result.Valid {
+				t.Error("expected config to be invalid")
+			}
+			if len(result.Errors) == 0 {
+				t.Error("expected validation errors, got none")
+			}
+		})
+	}
+}
+
+func TestValidateConfigWarnings(t *testing.T) {
+	content := `version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 192.168.1.10
+    username: deploy
+tasks:
+  - name: build
+    cmd: make build
+`
+	_, result := validateRequest(t, ValidateConfigRequest{Content: content})
+	if !result.Valid {
+		t.Fatalf("expected config to be valid, got errors: %v", result.Errors)
+	}
+	if len(result.Warnings) == 0 {
+		t.Error("expected a warning for host without credentials")
+	}
+}
+
+func TestValidateConfigMissingInput(t *testing.T) {
+	rec, _ := validateRequest(t, ValidateConfigRequest{})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("handleValidateConfig() status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/tls"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/zechtz/nyatictl/logger"
@@ -37,7 +40,19 @@ type Webhook struct {
 	Description string    `json:"description"`
 	URL         string    `json:"url"`
 	Secret      string    `json:"secret,omitempty"` // Secret for HMAC signature validation
-	Event       string    `json:"event"`            // Event type (e.g., "deployment", "task-execution")
+	AutoSecret  bool      `json:"auto_secret,omitempty"` // Request flag: generate a secret on create; never persisted
+	WeakSecret  bool      `json:"weak_secret,omitempty"` // Computed at read time: secret is set but below the minimum length
+	Event       string    `json:"event"`            // Event subscription: a canonical name or wildcard (e.g. "task.failed", "deployment.*")
+	TagFilter   string    `json:"tag_filter"`       // Comma-separated task tags to scope delivery to; empty fires for all tasks
+	Algorithm   string    `json:"algorithm,omitempty"` // HMAC algorithm for signatures: sha256 (default) or sha512
+
+	// Overrides for endpoints behind proxies whose TLS certificate or virtual
+	// host doesn't match the URL's hostname. TLSServerName sets the SNI name
+	// (and certificate verification name) for https URLs; HostHeader replaces
+	// the Host header on the outbound request.
+	TLSServerName string `json:"tls_server_name,omitempty"`
+	HostHeader    string `json:"host_header,omitempty"`
+
 	UserID      int       `json:"user_id"`
 	Active      bool      `json:"active"`
 	CreatedAt   time.Time `json:"created_at"`
@@ -52,6 +67,7 @@ type WebhookPayload struct {
 	Timestamp  time.Time      `json:"timestamp"`
 	ConfigPath string         `json:"config_path,omitempty"`
 	TaskName   string         `json:"task_name,omitempty"`
+	Tags       []string       `json:"tags,omitempty"` // Tags of the executed task, matched against each webhook's tag filter
 	Host       string         `json:"host,omitempty"`
 	UserID     int            `json:"user_id,omitempty"`
 	Data       map[string]any `json:"data,omitempty"`
@@ -61,8 +77,8 @@ type WebhookPayload struct {
 func CreateWebhook(db *sql.DB, webhook Webhook) (int, error) {
 	query := `
 		INSERT INTO webhooks (
-			name, description, url, secret, event, user_id, active, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			name, description, url, secret, event, tag_filter, algorithm, tls_server_name, host_header, user_id, active, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	now := time.Now()
 	result, err := db.Exec(
@@ -72,6 +88,10 @@ func CreateWebhook(db *sql.DB, webhook Webhook) (int, error) {
 		webhook.URL,
 		webhook.Secret,
 		webhook.Event,
+		webhook.TagFilter,
+		webhook.Algorithm,
+		webhook.TLSServerName,
+		webhook.HostHeader,
 		webhook.UserID,
 		webhook.Active,
 		now,
@@ -92,7 +112,7 @@ func CreateWebhook(db *sql.DB, webhook Webhook) (int, error) {
 // GetWebhooks retrieves all webhooks for a user
 func GetWebhooks(db *sql.DB, userID int) ([]Webhook, error) {
 	query := `
-		SELECT id, name, description, url, event, user_id, active, created_at, updated_at
+		SELECT id, name, description, url, secret, event, tag_filter, algorithm, tls_server_name, host_header, user_id, active, created_at, updated_at
 		FROM webhooks
 		WHERE user_id = ?
 	`
@@ -105,13 +125,19 @@ func GetWebhooks(db *sql.DB, userID int) ([]Webhook, error) {
 	var webhooks []Webhook
 	for rows.Next() {
 		var webhook Webhook
+		var secret string
 		var createdAt, updatedAt string
 		err := rows.Scan(
 			&webhook.ID,
 			&webhook.Name,
 			&webhook.Description,
 			&webhook.URL,
+			&secret,
 			&webhook.Event,
+			&webhook.TagFilter,
+			&webhook.Algorithm,
+			&webhook.TLSServerName,
+			&webhook.HostHeader,
 			&webhook.UserID,
 			&webhook.Active,
 			&createdAt,
@@ -123,6 +149,10 @@ func GetWebhooks(db *sql.DB, userID int) ([]Webhook, error) {
 
 		webhook.CreatedAt = parseTimeWithLogging(createdAt, "created_at")
 		webhook.UpdatedAt = parseTimeWithLogging(updatedAt, "updated_at")
+
+		// Flag (but never return) secrets below the minimum length so the
+		// UI can nudge owners to rotate them
+		webhook.WeakSecret = isWeakWebhookSecret(secret)
 		webhooks = append(webhooks, webhook)
 	}
 
@@ -134,14 +164,17 @@ func GetWebhooks(db *sql.DB, userID int) ([]Webhook, error) {
 	return webhooks, nil
 }
 
-// GetWebhooksByEvent retrieves all active webhooks for a specific event
+// GetWebhooksByEvent retrieves all active webhooks whose subscription covers
+// the given event, including wildcard subscriptions ("deployment.*", "*").
+// Matching happens here rather than in SQL so the wildcard rules live in one
+// place (subscriptionMatches).
 func GetWebhooksByEvent(db *sql.DB, event string) ([]Webhook, error) {
 	query := `
-		SELECT id, name, description, url, secret, event, user_id, active, created_at, updated_at
+		SELECT id, name, description, url, secret, event, tag_filter, algorithm, tls_server_name, host_header, user_id, active, created_at, updated_at
 		FROM webhooks
-		WHERE event = ? AND active = 1
+		WHERE active = 1
 	`
-	rows, err := db.Query(query, event)
+	rows, err := db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query webhooks: %v", err)
 	}
@@ -158,6 +191,10 @@ func GetWebhooksByEvent(db *sql.DB, event string) ([]Webhook, error) {
 			&webhook.URL,
 			&webhook.Secret,
 			&webhook.Event,
+			&webhook.TagFilter,
+			&webhook.Algorithm,
+			&webhook.TLSServerName,
+			&webhook.HostHeader,
 			&webhook.UserID,
 			&webhook.Active,
 			&createdAt,
@@ -166,6 +203,9 @@ func GetWebhooksByEvent(db *sql.DB, event string) ([]Webhook, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan webhook: %v", err)
 		}
+		if !subscriptionMatches(webhook.Event, event) {
+			continue
+		}
 
 		webhook.CreatedAt = parseTimeWithLogging(createdAt, "created_at")
 		webhook.UpdatedAt = parseTimeWithLogging(updatedAt, "updated_at")
@@ -183,7 +223,7 @@ func GetWebhooksByEvent(db *sql.DB, event string) ([]Webhook, error) {
 // GetWebhook retrieves a webhook by ID
 func GetWebhook(db *sql.DB, id int, userID int) (Webhook, error) {
 	query := `
-		SELECT id, name, description, url, secret, event, user_id, active, created_at, updated_at
+		SELECT id, name, description, url, secret, event, tag_filter, algorithm, tls_server_name, host_header, user_id, active, created_at, updated_at
 		FROM webhooks
 		WHERE id = ? AND user_id = ?
 	`
@@ -196,6 +236,10 @@ func GetWebhook(db *sql.DB, id int, userID int) (Webhook, error) {
 		&webhook.URL,
 		&webhook.Secret,
 		&webhook.Event,
+		&webhook.TagFilter,
+		&webhook.Algorithm,
+		&webhook.TLSServerName,
+		&webhook.HostHeader,
 		&webhook.UserID,
 		&webhook.Active,
 		&createdAt,
@@ -214,7 +258,7 @@ func GetWebhook(db *sql.DB, id int, userID int) (Webhook, error) {
 func UpdateWebhook(db *sql.DB, webhook Webhook) error {
 	query := `
 		UPDATE webhooks
-		SET name = ?, description = ?, url = ?, secret = ?, event = ?, active = ?, updated_at = ?
+		SET name = ?, description = ?, url = ?, secret = ?, event = ?, tag_filter = ?, algorithm = ?, tls_server_name = ?, host_header = ?, active = ?, updated_at = ?
 		WHERE id = ? AND user_id = ?
 	`
 	_, err := db.Exec(
@@ -224,6 +268,10 @@ func UpdateWebhook(db *sql.DB, webhook Webhook) error {
 		webhook.URL,
 		webhook.Secret,
 		webhook.Event,
+		webhook.TagFilter,
+		webhook.Algorithm,
+		webhook.TLSServerName,
+		webhook.HostHeader,
 		webhook.Active,
 		time.Now(),
 		webhook.ID,
@@ -245,7 +293,11 @@ func DeleteWebhook(db *sql.DB, id int, userID int) error {
 	return nil
 }
 
-// TriggerWebhooks sends the payload to all webhooks for a specific event
+// TriggerWebhooks sends the payload to all webhooks for a specific event.
+// Webhooks with a tag filter are skipped unless the payload's task tags
+// match, so users can scope notifications to e.g. tasks tagged "critical".
+// While the global mute is active nothing is sent; each suppressed delivery
+// is recorded in the delivery history with status "muted" instead.
 func TriggerWebhooks(db *sql.DB, event string, payload WebhookPayload) {
 	webhooks, err := GetWebhooksByEvent(db, event)
 	if err != nil {
@@ -253,13 +305,46 @@ func TriggerWebhooks(db *sql.DB, event string, payload WebhookPayload) {
 		return
 	}
 
+	_, muted := webhooksMutedUntil(db)
 	for _, webhook := range webhooks {
-		go sendWebhook(webhook, payload)
+		if !matchesTagFilter(webhook.TagFilter, payload.Tags) {
+			continue
+		}
+		if muted {
+			logger.Log(fmt.Sprintf("Webhook %s (%d) muted, suppressing %s delivery", webhook.Name, webhook.ID, event))
+			recordMutedWebhookDelivery(db, webhook.ID, payload.Event)
+			continue
+		}
+		go sendWebhook(db, webhook, payload)
 	}
 }
 
-// sendWebhook sends a webhook payload to the configured URL
-func sendWebhook(webhook Webhook, payload WebhookPayload) {
+// matchesTagFilter reports whether a webhook's comma-separated tag filter
+// matches any of the executed task's tags. An empty filter matches every
+// task; a non-empty filter never matches an untagged task.
+func matchesTagFilter(filter string, tags []string) bool {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return true
+	}
+	for _, want := range strings.Split(filter, ",") {
+		want = strings.TrimSpace(want)
+		if want == "" {
+			continue
+		}
+		for _, tag := range tags {
+			if tag == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sendWebhook sends a webhook payload to the configured URL. Each attempt is
+// recorded in webhook_deliveries (best-effort, skipped when db is nil) so the
+// dashboard can report delivery success rates.
+func sendWebhook(db *sql.DB, webhook Webhook, payload WebhookPayload) {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		logger.Log(fmt.Sprintf("Failed to marshal webhook payload: %v", err))
@@ -275,16 +360,25 @@ func sendWebhook(webhook Webhook, payload WebhookPayload) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "NyatiCtl-Webhook")
 
-	// Add signature if webhook has a secret
+	// A proxy in front of the endpoint may route on a Host distinct from the
+	// URL's hostname
+	if webhook.HostHeader != "" {
+		req.Host = webhook.HostHeader
+	}
+
+	// Add a timestamped signature if webhook has a secret, so receivers can
+	// reject replayed deliveries (scheme documented in webhook_signature.go)
 	if webhook.Secret != "" {
-		signature := calculateSignature(payloadBytes, webhook.Secret)
-		req.Header.Set("X-NyatiCtl-Signature", signature)
+		signature, timestamp := signWebhookPayload(payloadBytes, webhook.Secret, webhook.Algorithm, time.Now())
+		req.Header.Set(signatureHeader, signature)
+		req.Header.Set(timestampHeader, timestamp)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := webhookHTTPClient(webhook)
 	resp, err := client.Do(req)
 	if err != nil {
 		logger.Log(fmt.Sprintf("Failed to send webhook: %v", err))
+		recordWebhookDelivery(db, webhook.ID, payload.Event, 0, false)
 		return
 	}
 	defer func() {
@@ -297,6 +391,56 @@ func sendWebhook(webhook Webhook, payload WebhookPayload) {
 
 	// Record webhook response code
 	logger.Log(fmt.Sprintf("Webhook %s (%d) delivered: Status %d", webhook.Name, webhook.ID, resp.StatusCode))
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	recordWebhookDelivery(db, webhook.ID, payload.Event, resp.StatusCode, success)
+}
+
+// webhookHTTPClient builds the HTTP client for one delivery. A webhook with a
+// TLS server name override gets its own transport so the SNI name (and the
+// name the endpoint's certificate is verified against) can differ from the
+// URL's hostname.
+func webhookHTTPClient(webhook Webhook) *http.Client {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if webhook.TLSServerName != "" {
+		client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{ServerName: webhook.TLSServerName},
+		}
+	}
+	return client
+}
+
+// validateWebhookProxyOverrides checks the proxy override fields: the TLS
+// server name only makes sense for https URLs, and neither override may be a
+// URL itself — both are bare hostnames (with an optional port for the Host
+// header).
+func validateWebhookProxyOverrides(webhook Webhook) error {
+	if webhook.TLSServerName != "" {
+		parsed, err := url.Parse(webhook.URL)
+		if err != nil || parsed.Scheme != "https" {
+			return fmt.Errorf("tls_server_name is only valid for https webhook URLs")
+		}
+		if strings.Contains(webhook.TLSServerName, "/") || strings.Contains(webhook.TLSServerName, ":") {
+			return fmt.Errorf("tls_server_name must be a bare hostname")
+		}
+	}
+	if strings.ContainsAny(webhook.HostHeader, "/ ") {
+		return fmt.Errorf("host_header must be a hostname, optionally with a port")
+	}
+	return nil
+}
+
+// recordWebhookDelivery stores the outcome of one delivery attempt. Recording
+// is best-effort: a nil db or insert failure never affects the delivery.
+func recordWebhookDelivery(db *sql.DB, webhookID int, event string, statusCode int, success bool) {
+	if db == nil {
+		return
+	}
+	if _, err := db.Exec(
+		"INSERT INTO webhook_deliveries (webhook_id, event, status_code, success) VALUES (?, ?, ?, ?)",
+		webhookID, event, statusCode, success,
+	); err != nil {
+		logger.Log(fmt.Sprintf("Failed to record webhook delivery for webhook %d: %v", webhookID, err))
+	}
 }
 
 // calculateSignature generates an HMAC signature for webhook payloads
@@ -346,24 +490,34 @@ func ProcessIncomingWebhook(db *sql.DB, w http.ResponseWriter, r *http.Request,
 
 	// Verify signature if secret is provided
 	if secret != "" {
-		signature := r.Header.Get("X-Hub-Signature-256")
-		if signature == "" {
-			signature = r.Header.Get("X-GitHub-Signature-256") // GitHub specific
-		}
-		if signature == "" {
-			signature = r.Header.Get("X-GitLab-Token") // GitLab specific
-		}
+		// Senders using our own timestamped scheme are checked against the
+		// replay tolerance window; other senders fall through to the legacy
+		// body-only verification below
+		if signature := r.Header.Get(signatureHeader); signature != "" {
+			if err := verifyTimestampedSignature(body, secret, signature, r.Header.Get(timestampHeader), time.Now()); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid signature: %v", err), http.StatusUnauthorized)
+				return
+			}
+		} else {
+			signature := r.Header.Get("X-Hub-Signature-256")
+			if signature == "" {
+				signature = r.Header.Get("X-GitHub-Signature-256") // GitHub specific
+			}
+			if signature == "" {
+				signature = r.Header.Get("X-GitLab-Token") // GitLab specific
+			}
 
-		// If no signature found but secret required
-		if signature == "" {
-			http.Error(w, "Missing signature header", http.StatusUnauthorized)
-			return
-		}
+			// If no signature found but secret required
+			if signature == "" {
+				http.Error(w, "Missing signature header", http.StatusUnauthorized)
+				return
+			}
 
-		// Verify the signature
-		if !verifySignature(body, secret, signature) {
-			http.Error(w, "Invalid signature", http.StatusUnauthorized)
-			return
+			// Verify the signature
+			if !verifySignature(body, secret, signature) {
+				http.Error(w, "Invalid signature", http.StatusUnauthorized)
+				return
+			}
 		}
 	}
 
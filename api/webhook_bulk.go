@@ -0,0 +1,112 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// BulkWebhookRequest is the body of POST /api/webhooks/bulk. The selection is
+// either an explicit ID list or all of the caller's webhooks; the update is
+// the active flag, a new event subscription, or both.
+type BulkWebhookRequest struct {
+	IDs    []int  `json:"ids,omitempty"`    // Webhooks to update; mutually exclusive with All
+	All    bool   `json:"all,omitempty"`    // Update every webhook the caller owns
+	Active *bool  `json:"active,omitempty"` // New active state; nil leaves it unchanged
+	Event  string `json:"event,omitempty"`  // New event subscription; empty leaves it unchanged
+}
+
+// BulkWebhookResponse reports how many webhooks a bulk update touched.
+type BulkWebhookResponse struct {
+	Updated int `json:"updated"`
+}
+
+// bulkUpdateWebhooks applies the active and/or event changes to the selected
+// webhooks of one user in a single statement. Selection never crosses user
+// boundaries: IDs belonging to other users are silently skipped, mirroring
+// the per-webhook handlers.
+//
+// Parameters:
+//   - db: database handle
+//   - userID: owner whose webhooks may be updated
+//   - req: the validated bulk request
+//
+// Returns:
+//   - int: how many webhooks were updated
+//   - error: if the update fails
+func bulkUpdateWebhooks(db *sql.DB, userID int, req BulkWebhookRequest) (int, error) {
+	set := []string{"updated_at = ?"}
+	args := []any{time.Now()}
+	if req.Active != nil {
+		set = append(set, "active = ?")
+		args = append(args, *req.Active)
+	}
+	if req.Event != "" {
+		set = append(set, "event = ?")
+		args = append(args, req.Event)
+	}
+
+	query := "UPDATE webhooks SET " + strings.Join(set, ", ") + " WHERE user_id = ?"
+	args = append(args, userID)
+	if !req.All {
+		placeholders := make([]string, len(req.IDs))
+		for i, id := range req.IDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query += " AND id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to bulk update webhooks: %v", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count updated webhooks: %v", err)
+	}
+	return int(updated), nil
+}
+
+// HandleBulkUpdateWebhooks enables, disables, or re-subscribes several
+// webhooks at once, e.g. to silence all of them during an incident without
+// deleting their configuration.
+func (s *Server) HandleBulkUpdateWebhooks(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	var req BulkWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.All == (len(req.IDs) > 0) {
+		http.Error(w, "Provide either a non-empty ids list or all: true", http.StatusBadRequest)
+		return
+	}
+	if req.Active == nil && req.Event == "" {
+		http.Error(w, "Nothing to update; provide active and/or event", http.StatusBadRequest)
+		return
+	}
+	if req.Event != "" {
+		if err := validateWebhookEvent(req.Event); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	updated, err := bulkUpdateWebhooks(s.db.DB, claims.UserID, req)
+	if err != nil {
+		logger.Log(fmt.Sprintf("Failed to bulk update webhooks: %v", err))
+		http.Error(w, "Failed to update webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	s.recordAudit(claims.UserID, "bulk-update", "webhook", fmt.Sprintf("%d updated", updated))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BulkWebhookResponse{Updated: updated})
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// seedWebhook inserts one webhook and returns its ID.
+func seedWebhook(t *testing.T, s *Server, userID int, name, event string, active bool) int {
+	t.Helper()
+
+	id, err := CreateWebhook(s.db.DB, Webhook{
+		Name:   name,
+		URL:    "https://example.com/hook",
+		Event:  event,
+		UserID: userID,
+		Active: active,
+	})
+	if err != nil {
+		t.Fatalf("failed to seed webhook: %v", err)
+	}
+	return id
+}
+
+// webhookState reads the active flag and event of one webhook.
+func webhookState(t *testing.T, s *Server, id int) (bool, string) {
+	t.Helper()
+
+	var active bool
+	var event string
+	if err := s.db.QueryRow("SELECT active, event FROM webhooks WHERE id = ?", id).Scan(&active, &event); err != nil {
+		t.Fatalf("failed to read webhook %d: %v", id, err)
+	}
+	return active, event
+}
+
+func bulkRequest(t *testing.T, s *Server, userID int, body any) (*httptest.ResponseRecorder, BulkWebhookResponse) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleBulkUpdateWebhooks)(rec, auditRequest(t, "POST", "/api/webhooks/bulk", body, userID, nil))
+
+	var resp BulkWebhookResponse
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("bulk update did not return JSON: %v", err)
+		}
+	}
+	return rec, resp
+}
+
+func TestBulkUpdateWebhooksByIDs(t *testing.T) {
+	s := newAuditTestServer(t)
+	first := seedWebhook(t, s, 1, "one", "deployment", true)
+	second := seedWebhook(t, s, 1, "two", "deployment", true)
+	third := seedWebhook(t, s, 1, "three", "deployment", true)
+
+	inactive := false
+	rec, resp := bulkRequest(t, s, 1, BulkWebhookRequest{IDs: []int{first, second}, Active: &inactive})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("bulk update status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if resp.Updated != 2 {
+		t.Errorf("updated = %d, want 2", resp.Updated)
+	}
+
+	for _, id := range []int{first, second} {
+		if active, _ := webhookState(t, s, id); active {
+			t.Errorf("webhook %d still active after bulk disable", id)
+		}
+	}
+	if active, _ := webhookState(t, s, third); !active {
+		t.Error("webhook outside the id list was deactivated")
+	}
+}
+
+func TestBulkUpdateWebhooksAllResubscribes(t *testing.T) {
+	s := newAuditTestServer(t)
+	first := seedWebhook(t, s, 1, "one", "deployment", true)
+	second := seedWebhook(t, s, 1, "two", "task-execution", false)
+
+	rec, resp := bulkRequest(t, s, 1, BulkWebhookRequest{All: true, Event: "deployment.succeeded"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("bulk update status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if resp.Updated != 2 {
+		t.Errorf("updated = %d, want 2", resp.Updated)
+	}
+
+	for _, id := range []int{first, second} {
+		if _, event := webhookState(t, s, id); event != "deployment.succeeded" {
+			t.Errorf("webhook %d event = %q, want deployment.succeeded", id, event)
+		}
+	}
+	// Re-subscription alone must not touch the active flag
+	if active, _ := webhookState(t, s, second); active {
+		t.Error("inactive webhook was activated by an event-only update")
+	}
+}
+
+func TestBulkUpdateWebhooksScopedToOwner(t *testing.T) {
+	s := newAuditTestServer(t)
+	mine := seedWebhook(t, s, 1, "mine", "deployment", true)
+	theirs := seedWebhook(t, s, 2, "theirs", "deployment", true)
+
+	inactive := false
+	rec, resp := bulkRequest(t, s, 1, BulkWebhookRequest{IDs: []int{mine, theirs}, Active: &inactive})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("bulk update status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if resp.Updated != 1 {
+		t.Errorf("updated = %d, want only the caller's webhook", resp.Updated)
+	}
+	if active, _ := webhookState(t, s, theirs); !active {
+		t.Error("another user's webhook was deactivated")
+	}
+}
+
+func TestBulkUpdateWebhooksRejectsInvalidSelections(t *testing.T) {
+	s := newAuditTestServer(t)
+	active := true
+
+	cases := []struct {
+		name string
+		body BulkWebhookRequest
+	}{
+		{"no selection", BulkWebhookRequest{Active: &active}},
+		{"both selections", BulkWebhookRequest{IDs: []int{1}, All: true, Active: &active}},
+		{"nothing to update", BulkWebhookRequest{All: true}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec, _ := bulkRequest(t, s, 1, c.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestBulkUpdateWebhooksTouchesUpdatedAt(t *testing.T) {
+	s := newAuditTestServer(t)
+	id := seedWebhook(t, s, 1, "one", "deployment", true)
+	if _, err := s.db.Exec("UPDATE webhooks SET updated_at = 'backdated' WHERE id = ?", id); err != nil {
+		t.Fatalf("failed to backdate webhook: %v", err)
+	}
+
+	inactive := false
+	if rec, _ := bulkRequest(t, s, 1, BulkWebhookRequest{IDs: []int{id}, Active: &inactive}); rec.Code != http.StatusOK {
+		t.Fatalf("bulk update status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var updatedAt string
+	if err := s.db.QueryRow("SELECT updated_at FROM webhooks WHERE id = ?", id).Scan(&updatedAt); err != nil {
+		t.Fatalf("failed to read updated_at: %v", err)
+	}
+	if updatedAt == "backdated" {
+		t.Error("updated_at was not refreshed by the bulk update")
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Canonical webhook event names. The event column used to be free text, so
+// "deployment", "task" and "task-execution" all floated around and an event
+// query missed rows created under another spelling; triggers now emit only
+// these names and subscriptions are validated against them.
+const (
+	EventDeploymentStarted   = "deployment.started"
+	EventDeploymentSucceeded = "deployment.succeeded"
+	EventDeploymentFailed    = "deployment.failed"
+	EventTaskSucceeded       = "task.succeeded"
+	EventTaskFailed          = "task.failed"
+	EventTest                = "test"
+)
+
+// webhookEventNames lists every concrete event a trigger site may emit.
+var webhookEventNames = []string{
+	EventDeploymentStarted,
+	EventDeploymentSucceeded,
+	EventDeploymentFailed,
+	EventTaskSucceeded,
+	EventTaskFailed,
+	EventTest,
+}
+
+// webhookSubscriptionNames is everything a webhook may subscribe to: each
+// concrete event plus the wildcard forms covering an event family or all
+// events.
+var webhookSubscriptionNames = append([]string{
+	"deployment.*",
+	"task.*",
+	"*",
+}, webhookEventNames...)
+
+// validateWebhookEvent checks a webhook's event subscription against the
+// canonical names and wildcard forms. The error lists the allowed values, so
+// a 422 response tells the caller exactly what to send.
+func validateWebhookEvent(event string) error {
+	for _, allowed := range webhookSubscriptionNames {
+		if event == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown webhook event %q; allowed values: %s", event, strings.Join(webhookSubscriptionNames, ", "))
+}
+
+// subscriptionMatches reports whether a webhook's event subscription covers a
+// concrete event: an exact name matches itself, "family.*" matches every
+// event in that family, and "*" matches everything.
+func subscriptionMatches(subscription, event string) bool {
+	switch {
+	case subscription == "*":
+		return true
+	case strings.HasSuffix(subscription, ".*"):
+		return strings.HasPrefix(event, strings.TrimSuffix(subscription, "*"))
+	default:
+		return subscription == event
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateWebhookEvent(t *testing.T) {
+	for _, event := range webhookSubscriptionNames {
+		if err := validateWebhookEvent(event); err != nil {
+			t.Errorf("validateWebhookEvent(%q) = %v, want allowed", event, err)
+		}
+	}
+
+	for _, event := range []string{"", "deployment", "task", "task-execution", "deploy.completed", "task.started"} {
+		err := validateWebhookEvent(event)
+		if err == nil {
+			t.Errorf("validateWebhookEvent(%q) = nil, want rejected", event)
+			continue
+		}
+		// The error doubles as the 422 body, so it must list the allowed values
+		if !strings.Contains(err.Error(), EventDeploymentStarted) || !strings.Contains(err.Error(), "*") {
+			t.Errorf("validateWebhookEvent(%q) error %q does not list the allowed values", event, err)
+		}
+	}
+}
+
+func TestSubscriptionMatches(t *testing.T) {
+	tests := []struct {
+		subscription string
+		event        string
+		want         bool
+	}{
+		{EventTaskFailed, EventTaskFailed, true},
+		{EventTaskFailed, EventTaskSucceeded, false},
+		{"task.*", EventTaskFailed, true},
+		{"task.*", EventTaskSucceeded, true},
+		{"task.*", EventDeploymentFailed, false},
+		{"deployment.*", EventDeploymentStarted, true},
+		{"deployment.*", EventTest, false},
+		{"*", EventTest, true},
+		{"*", EventDeploymentFailed, true},
+		// Legacy spellings still match only themselves, never canonical events
+		{"task", EventTaskFailed, false},
+		{"deployment", EventDeploymentFailed, false},
+	}
+
+	for _, tt := range tests {
+		if got := subscriptionMatches(tt.subscription, tt.event); got != tt.want {
+			t.Errorf("subscriptionMatches(%q, %q) = %v, want %v", tt.subscription, tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestGetWebhooksByEventHonorsWildcards(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	exact := seedWebhook(t, s, 1, "exact", EventTaskFailed, true)
+	family := seedWebhook(t, s, 1, "family", "task.*", true)
+	all := seedWebhook(t, s, 1, "all", "*", true)
+	seedWebhook(t, s, 1, "other-family", "deployment.*", true)
+	seedWebhook(t, s, 1, "inactive", EventTaskFailed, false)
+
+	webhooks, err := GetWebhooksByEvent(s.db.DB, EventTaskFailed)
+	if err != nil {
+		t.Fatalf("GetWebhooksByEvent() error = %v", err)
+	}
+
+	got := make(map[int]bool, len(webhooks))
+	for _, webhook := range webhooks {
+		got[webhook.ID] = true
+	}
+	for _, id := range []int{exact, family, all} {
+		if !got[id] {
+			t.Errorf("webhook %d missing from matches %v", id, got)
+		}
+	}
+	if len(webhooks) != 3 {
+		t.Errorf("matched %d webhooks, want exactly the exact, family and catch-all subscriptions", len(webhooks))
+	}
+}
+
+func TestCreateWebhookRejectsUnknownEvent(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := createWebhookRequest(t, s, Webhook{Name: "w", URL: "https://example.com", Event: "task-execution"})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("unknown event status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if !strings.Contains(rec.Body.String(), "allowed values") {
+		t.Errorf("error = %q, want the allowed values listed", rec.Body.String())
+	}
+}
+
+func TestBulkUpdateWebhooksRejectsUnknownEvent(t *testing.T) {
+	s := newAuditTestServer(t)
+	id := seedWebhook(t, s, 1, "one", "deployment.*", true)
+
+	rec, _ := bulkRequest(t, s, 1, BulkWebhookRequest{IDs: []int{id}, Event: "deploy.completed"})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("unknown event status = %d, want %d: %s", rec.Code, http.StatusUnprocessableEntity, rec.Body)
+	}
+	if _, event := webhookState(t, s, id); event != "deployment.*" {
+		t.Errorf("webhook event = %q, want the subscription untouched", event)
+	}
+}
+
+// TestTriggerSiteEventsAreCanonical enumerates the event constant each
+// TriggerWebhooks (and direct sendWebhook) site emits, so adding a site with
+// a free-text event name fails review here before it fragments the event
+// column again.
+func TestTriggerSiteEventsAreCanonical(t *testing.T) {
+	triggerSiteEvents := map[string]string{
+		"handleExecuteTask failure":   EventTaskFailed,
+		"handleExecuteTask success":   EventTaskSucceeded,
+		"notifyDeployTargets success": EventDeploymentSucceeded,
+		"notifyDeployTargets failure": EventDeploymentFailed,
+	}
+
+	canonical := make(map[string]bool, len(webhookEventNames))
+	for _, name := range webhookEventNames {
+		canonical[name] = true
+	}
+
+	for site, event := range triggerSiteEvents {
+		if !canonical[event] {
+			t.Errorf("%s emits %q, which is not a canonical concrete event", site, event)
+		}
+		if strings.Contains(event, "*") {
+			t.Errorf("%s emits the wildcard %q; wildcards are for subscriptions only", site, event)
+		}
+	}
+}
@@ -12,14 +12,7 @@ import (
 )
 
 // HandleCreateWebhook creates a new webhook
-func (s *Server) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
-	// Get user from context
-	claims, ok := GetUserFromContext(r)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
+func (s *Server) HandleCreateWebhook(w http.ResponseWriter, r *http.Request, claims *Claims) {
 	// Parse webhook data from request
 	var webhook Webhook
 	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
@@ -36,6 +29,40 @@ func (s *Server) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateWebhookEvent(webhook.Event); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	algorithm, err := normalizeSignatureAlgorithm(webhook.Algorithm)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	webhook.Algorithm = algorithm
+
+	if err := validateWebhookProxyOverrides(webhook); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Generate a strong secret on request instead of making the caller
+	// invent one
+	if webhook.AutoSecret && webhook.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			logger.Log(fmt.Sprintf("Failed to generate webhook secret: %v", err))
+			http.Error(w, "Failed to generate secret", http.StatusInternalServerError)
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	if isWeakWebhookSecret(webhook.Secret) {
+		http.Error(w, fmt.Sprintf("Secret must be at least %d characters; a short secret makes the HMAC signature trivial to brute-force", minWebhookSecretLength), http.StatusUnprocessableEntity)
+		return
+	}
+
 	// Create the webhook
 	id, err := CreateWebhook(s.db.DB, webhook)
 	if err != nil {
@@ -44,13 +71,18 @@ func (s *Server) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordAudit(claims.UserID, "create", "webhook", strconv.Itoa(id))
+
 	// Return the created webhook
 	webhook.ID = id
 	webhook.CreatedAt = time.Now()
 	webhook.UpdatedAt = time.Now()
 
-	// Don't return the secret in the response
-	webhook.Secret = ""
+	// Don't return the secret in the response, except when it was generated
+	// here: the caller gets exactly one chance to copy it
+	if !webhook.AutoSecret {
+		webhook.Secret = ""
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -58,14 +90,7 @@ func (s *Server) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleGetWebhooks returns all webhooks for the authenticated user
-func (s *Server) HandleGetWebhooks(w http.ResponseWriter, r *http.Request) {
-	// Get user from context
-	claims, ok := GetUserFromContext(r)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
+func (s *Server) HandleGetWebhooks(w http.ResponseWriter, r *http.Request, claims *Claims) {
 	// Get webhooks for the user
 	webhooks, err := GetWebhooks(s.db.DB, claims.UserID)
 	if err != nil {
@@ -74,20 +99,13 @@ func (s *Server) HandleGetWebhooks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return the webhooks
+	// Return the webhooks, one page at a time
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(webhooks)
+	json.NewEncoder(w).Encode(Paginate(r, webhooks))
 }
 
 // HandleGetWebhook returns a specific webhook by ID
-func (s *Server) HandleGetWebhook(w http.ResponseWriter, r *http.Request) {
-	// Get user from context
-	claims, ok := GetUserFromContext(r)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
+func (s *Server) HandleGetWebhook(w http.ResponseWriter, r *http.Request, claims *Claims) {
 	// Parse webhook ID from URL
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -112,14 +130,7 @@ func (s *Server) HandleGetWebhook(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleUpdateWebhook updates an existing webhook
-func (s *Server) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
-	// Get user from context
-	claims, ok := GetUserFromContext(r)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
+func (s *Server) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request, claims *Claims) {
 	// Parse webhook ID from URL
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -146,6 +157,11 @@ func (s *Server) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
 	webhookUpdate.ID = existingWebhook.ID
 	webhookUpdate.UserID = claims.UserID
 
+	if isWeakWebhookSecret(webhookUpdate.Secret) {
+		http.Error(w, fmt.Sprintf("Secret must be at least %d characters; a short secret makes the HMAC signature trivial to brute-force", minWebhookSecretLength), http.StatusUnprocessableEntity)
+		return
+	}
+
 	// If no new secret is provided, keep the existing one
 	if webhookUpdate.Secret == "" {
 		webhookUpdate.Secret = existingWebhook.Secret
@@ -157,6 +173,23 @@ func (s *Server) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateWebhookEvent(webhookUpdate.Event); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	algorithm, err := normalizeSignatureAlgorithm(webhookUpdate.Algorithm)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	webhookUpdate.Algorithm = algorithm
+
+	if err := validateWebhookProxyOverrides(webhookUpdate); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
 	// Update the webhook
 	err = UpdateWebhook(s.db.DB, webhookUpdate)
 	if err != nil {
@@ -165,6 +198,8 @@ func (s *Server) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordAudit(claims.UserID, "update", "webhook", strconv.Itoa(id))
+
 	// Don't return the secret in the response
 	webhookUpdate.Secret = ""
 	webhookUpdate.UpdatedAt = time.Now()
@@ -175,14 +210,7 @@ func (s *Server) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleDeleteWebhook deletes a webhook
-func (s *Server) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
-	// Get user from context
-	claims, ok := GetUserFromContext(r)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
+func (s *Server) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request, claims *Claims) {
 	// Parse webhook ID from URL
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -199,6 +227,8 @@ func (s *Server) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordAudit(claims.UserID, "delete", "webhook", strconv.Itoa(id))
+
 	// Return success
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -211,25 +241,27 @@ func (s *Server) HandleIncomingWebhook(w http.ResponseWriter, r *http.Request) {
 	ProcessIncomingWebhook(s.db.DB, w, r, webhookID)
 }
 
-// getConfigName retrieves the name of a config from its path
-func getConfigName(configs []ConfigEntry, path string) string {
-	for _, cfg := range configs {
-		if cfg.Path == path {
-			if cfg.Name != "" {
-				return cfg.Name
-			}
-			return path // Return path if name is empty
-		}
+// configNameFor retrieves the display name of a config from its path,
+// falling back to the path itself when the config is unknown or unnamed.
+func (s *Server) configNameFor(path string) string {
+	var name string
+	if err := s.db.QueryRow("SELECT name FROM configs WHERE path = ?", path).Scan(&name); err != nil || name == "" {
+		return path
 	}
-	return path // Return path if config not found
+	return name
 }
 
 func (s *Server) RegisterWebhookRoutes(r *mux.Router) {
-	r.HandleFunc("/webhooks", s.HandleGetWebhooks).Methods("GET")
-	r.HandleFunc("/webhooks", s.HandleCreateWebhook).Methods("POST")
-	r.HandleFunc("/webhooks/{id:[0-9]+}", s.HandleGetWebhook).Methods("GET")
-	r.HandleFunc("/webhooks/{id:[0-9]+}", s.HandleUpdateWebhook).Methods("PUT")
-	r.HandleFunc("/webhooks/{id:[0-9]+}", s.HandleDeleteWebhook).Methods("DELETE")
+	r.HandleFunc("/webhooks", s.withUser(s.HandleGetWebhooks)).Methods("GET")
+	r.HandleFunc("/webhooks", s.withUser(s.HandleCreateWebhook)).Methods("POST")
+	r.HandleFunc("/webhooks/bulk", s.withUser(s.HandleBulkUpdateWebhooks)).Methods("POST")
+	r.HandleFunc("/webhooks/mute", s.withUser(s.HandleWebhookMuteStatus)).Methods("GET")
+	r.HandleFunc("/webhooks/mute", s.withUser(s.HandleMuteWebhooks)).Methods("POST")
+	r.HandleFunc("/webhooks/mute", s.withUser(s.HandleUnmuteWebhooks)).Methods("DELETE")
+	r.HandleFunc("/webhooks/generate-secret", s.withUser(s.HandleGenerateWebhookSecret)).Methods("POST")
+	r.HandleFunc("/webhooks/{id:[0-9]+}", s.withUser(s.HandleGetWebhook)).Methods("GET")
+	r.HandleFunc("/webhooks/{id:[0-9]+}", s.withUser(s.HandleUpdateWebhook)).Methods("PUT")
+	r.HandleFunc("/webhooks/{id:[0-9]+}", s.withUser(s.HandleDeleteWebhook)).Methods("DELETE")
 
 	r.HandleFunc("/webhooks/incoming/{webhookID}", s.HandleIncomingWebhook).Methods("POST")
 }
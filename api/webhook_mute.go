@@ -0,0 +1,139 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// webhookMuteSetting is the settings-table key holding the RFC3339 expiry of
+// the global webhook mute. An absent, empty, or past value means webhooks are
+// not muted; unmuting at expiry needs no write, only the comparison here.
+const webhookMuteSetting = "webhooks_muted_until"
+
+// maxWebhookMuteDuration caps one mute window. A mute is an incident tool,
+// not a permanent off switch — deactivating webhooks is what bulk updates
+// are for.
+const maxWebhookMuteDuration = 24 * time.Hour
+
+// WebhookMuteStatus reports the global mute state as returned by the mute
+// endpoints and embedded in the admin stats.
+type WebhookMuteStatus struct {
+	Muted bool   `json:"muted"`
+	Until string `json:"until,omitempty"` // RFC3339 expiry, set while muted
+}
+
+// MuteWebhooksRequest is the body of POST /api/webhooks/mute.
+type MuteWebhooksRequest struct {
+	Duration string `json:"duration"` // How long to mute, e.g. "30m" or "2h"
+}
+
+// webhooksMutedUntil reports whether the global webhook mute is active and,
+// if so, when it expires. An unparseable stored value counts as unmuted, so
+// a corrupted setting can never silence deliveries indefinitely.
+func webhooksMutedUntil(db *sql.DB) (time.Time, bool) {
+	raw := GetSetting(db, webhookMuteSetting, "")
+	if raw == "" {
+		return time.Time{}, false
+	}
+	until, err := time.Parse(time.RFC3339, raw)
+	if err != nil || !time.Now().Before(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// webhookMuteStatus builds the externally visible mute state.
+func webhookMuteStatus(db *sql.DB) WebhookMuteStatus {
+	until, muted := webhooksMutedUntil(db)
+	status := WebhookMuteStatus{Muted: muted}
+	if muted {
+		status.Until = until.Format(time.RFC3339)
+	}
+	return status
+}
+
+// HandleWebhookMuteStatus reports whether the global mute is active. Any
+// authenticated user may check; only admins can change it.
+func (s *Server) HandleWebhookMuteStatus(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhookMuteStatus(s.db.DB))
+}
+
+// HandleMuteWebhooks activates the global webhook mute for a bounded
+// duration. Admin only. While the mute is active TriggerWebhooks suppresses
+// every outgoing delivery, recording each one with status "muted"; the mute
+// lifts automatically at the expiry.
+func (s *Server) HandleMuteWebhooks(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	var req MuteWebhooksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		http.Error(w, fmt.Sprintf("Invalid mute duration %q; expected e.g. 30m or 2h", req.Duration), http.StatusBadRequest)
+		return
+	}
+	if duration > maxWebhookMuteDuration {
+		http.Error(w, fmt.Sprintf("Mute duration exceeds the %s maximum; deactivate webhooks via POST /api/webhooks/bulk for longer silences", maxWebhookMuteDuration), http.StatusBadRequest)
+		return
+	}
+
+	until := time.Now().Add(duration)
+	if err := SetSetting(s.db.DB, webhookMuteSetting, until.Format(time.RFC3339)); err != nil {
+		logger.Error(fmt.Sprintf("Failed to store webhook mute: %v", err))
+		http.Error(w, "Failed to mute webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	s.recordAudit(claims.UserID, "mute", "webhooks", "global")
+	logger.Log(fmt.Sprintf("Webhook deliveries muted until %s by user %d", until.Format(time.RFC3339), claims.UserID))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhookMuteStatus(s.db.DB))
+}
+
+// HandleUnmuteWebhooks lifts the global webhook mute before its expiry.
+// Admin only; unmuting when no mute is active is a harmless no-op.
+func (s *Server) HandleUnmuteWebhooks(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	if !s.isAdmin(claims.UserID) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return
+	}
+
+	if err := SetSetting(s.db.DB, webhookMuteSetting, ""); err != nil {
+		logger.Error(fmt.Sprintf("Failed to clear webhook mute: %v", err))
+		http.Error(w, "Failed to unmute webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	s.recordAudit(claims.UserID, "unmute", "webhooks", "global")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// recordMutedWebhookDelivery stores one delivery suppressed by the global
+// mute. Like recordWebhookDelivery it is best-effort: a nil db or insert
+// failure never affects anything else.
+func recordMutedWebhookDelivery(db *sql.DB, webhookID int, event string) {
+	if db == nil {
+		return
+	}
+	if _, err := db.Exec(
+		"INSERT INTO webhook_deliveries (webhook_id, event, status_code, success, status) VALUES (?, ?, 0, 0, 'muted')",
+		webhookID, event,
+	); err != nil {
+		logger.Log(fmt.Sprintf("Failed to record muted webhook delivery for webhook %d: %v", webhookID, err))
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// createDeliveriesTable adds the webhook_deliveries table to a mute test
+// server so suppressed deliveries have somewhere to be recorded.
+func createDeliveriesTable(t *testing.T, s *Server) {
+	t.Helper()
+
+	if _, err := s.db.Exec(`CREATE TABLE webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL,
+		event TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		success INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`); err != nil {
+		t.Fatalf("failed to create webhook_deliveries table: %v", err)
+	}
+}
+
+func muteStatus(t *testing.T, s *Server, userID int) WebhookMuteStatus {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleWebhookMuteStatus)(rec, auditRequest(t, "GET", "/api/webhooks/mute", nil, userID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("mute status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var status WebhookMuteStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("mute status did not return JSON: %v", err)
+	}
+	return status
+}
+
+func TestMuteWebhooksIsAdminOnly(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleMuteWebhooks)(rec, auditRequest(t, "POST", "/api/webhooks/mute",
+		MuteWebhooksRequest{Duration: "30m"}, 2, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("mute for non-admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec = httptest.NewRecorder()
+	s.withUser(s.HandleUnmuteWebhooks)(rec, auditRequest(t, "DELETE", "/api/webhooks/mute", nil, 2, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("unmute for non-admin status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestMuteWebhooksRejectsBadDurations(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	for _, duration := range []string{"", "soon", "-5m", "48h"} {
+		rec := httptest.NewRecorder()
+		s.withUser(s.HandleMuteWebhooks)(rec, auditRequest(t, "POST", "/api/webhooks/mute",
+			MuteWebhooksRequest{Duration: duration}, 1, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("mute with duration %q status = %d, want %d", duration, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestMuteAndUnmuteWebhooks(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.withUser(s.HandleMuteWebhooks)(rec, auditRequest(t, "POST", "/api/webhooks/mute",
+		MuteWebhooksRequest{Duration: "1h"}, 1, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("mute status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	status := muteStatus(t, s, 2)
+	if !status.Muted || status.Until == "" {
+		t.Errorf("mute status = %+v, want muted with an expiry", status)
+	}
+
+	rec = httptest.NewRecorder()
+	s.withUser(s.HandleUnmuteWebhooks)(rec, auditRequest(t, "DELETE", "/api/webhooks/mute", nil, 1, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("unmute status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	if status := muteStatus(t, s, 2); status.Muted {
+		t.Errorf("mute status after unmute = %+v, want unmuted", status)
+	}
+}
+
+func TestMuteExpiresAutomatically(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	// A mute whose expiry has passed needs no write to lift; the comparison
+	// in webhooksMutedUntil is the unmute
+	expired := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	if err := SetSetting(s.db.DB, webhookMuteSetting, expired); err != nil {
+		t.Fatalf("failed to store expired mute: %v", err)
+	}
+	if _, muted := webhooksMutedUntil(s.db.DB); muted {
+		t.Error("an expired mute still reports as active")
+	}
+
+	// A corrupted stored value must fail open, not silence deliveries forever
+	if err := SetSetting(s.db.DB, webhookMuteSetting, "not-a-timestamp"); err != nil {
+		t.Fatalf("failed to store garbage mute: %v", err)
+	}
+	if _, muted := webhooksMutedUntil(s.db.DB); muted {
+		t.Error("an unparseable mute value reports as active")
+	}
+}
+
+func TestTriggerWebhooksSuppressedWhileMuted(t *testing.T) {
+	s := newAuditTestServer(t)
+	createDeliveriesTable(t, s)
+	id := seedWebhook(t, s, 1, "one", "deployment", true)
+
+	until := time.Now().Add(time.Hour).Format(time.RFC3339)
+	if err := SetSetting(s.db.DB, webhookMuteSetting, until); err != nil {
+		t.Fatalf("failed to store mute: %v", err)
+	}
+
+	TriggerWebhooks(s.db.DB, "deployment", WebhookPayload{Event: "deployment"})
+
+	var status string
+	var statusCode int
+	if err := s.db.QueryRow(
+		"SELECT status, status_code FROM webhook_deliveries WHERE webhook_id = ?", id,
+	).Scan(&status, &statusCode); err != nil {
+		t.Fatalf("failed to read suppressed delivery: %v", err)
+	}
+	if status != "muted" || statusCode != 0 {
+		t.Errorf("suppressed delivery = (%q, %d), want (muted, 0)", status, statusCode)
+	}
+}
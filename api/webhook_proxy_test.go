@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendWebhookSetsConfiguredHostHeader(t *testing.T) {
+	hosts := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts <- r.Host
+	}))
+	defer ts.Close()
+
+	webhook := Webhook{Name: "proxied", URL: ts.URL, Event: "deployment", HostHeader: "internal.example.com"}
+	sendWebhook(nil, webhook, WebhookPayload{Event: "deployment"})
+
+	select {
+	case host := <-hosts:
+		if host != "internal.example.com" {
+			t.Errorf("request Host = %q, want the configured host_header", host)
+		}
+	default:
+		t.Fatal("webhook was never delivered")
+	}
+}
+
+func TestWebhookHTTPClientSetsSNI(t *testing.T) {
+	client := webhookHTTPClient(Webhook{URL: "https://edge.example.com/hook", TLSServerName: "internal.example.com"})
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatal("client with a TLS server name should carry its own transport")
+	}
+	if transport.TLSClientConfig == nil || transport.TLSClientConfig.ServerName != "internal.example.com" {
+		t.Errorf("transport TLS config = %+v, want ServerName internal.example.com", transport.TLSClientConfig)
+	}
+
+	// Without the override the default transport (and its connection pool)
+	// is used
+	if webhookHTTPClient(Webhook{URL: "https://edge.example.com/hook"}).Transport != nil {
+		t.Error("client without a TLS server name should use the default transport")
+	}
+}
+
+func TestValidateWebhookProxyOverrides(t *testing.T) {
+	cases := []struct {
+		name    string
+		webhook Webhook
+		wantErr bool
+	}{
+		{"no overrides", Webhook{URL: "http://example.com/hook"}, false},
+		{"sni with https", Webhook{URL: "https://edge.example.com/hook", TLSServerName: "internal.example.com"}, false},
+		{"sni with http", Webhook{URL: "http://edge.example.com/hook", TLSServerName: "internal.example.com"}, true},
+		{"sni with a path", Webhook{URL: "https://edge.example.com/hook", TLSServerName: "internal.example.com/x"}, true},
+		{"host header with port", Webhook{URL: "http://example.com/hook", HostHeader: "internal.example.com:8443"}, false},
+		{"host header with path", Webhook{URL: "http://example.com/hook", HostHeader: "internal.example.com/x"}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateWebhookProxyOverrides(c.webhook)
+			if (err != nil) != c.wantErr {
+				t.Errorf("validateWebhookProxyOverrides(%+v) error = %v, wantErr %v", c.webhook, err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateWebhookRejectsSNIOnHTTPURL(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.HandleCreateWebhook(rec, auditRequest(t, "POST", "/api/webhooks",
+		Webhook{Name: "bad", URL: "http://example.com/hook", Event: "deployment", TLSServerName: "internal.example.com"},
+		1, nil), &Claims{UserID: 1})
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("create status = %d, want %d: %s", rec.Code, http.StatusUnprocessableEntity, rec.Body.String())
+	}
+}
+
+func TestWebhookProxyOverridesRoundTrip(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	webhook := Webhook{
+		Name:          "proxied",
+		URL:           "https://edge.example.com/hook",
+		Event:         "deployment",
+		TLSServerName: "internal.example.com",
+		HostHeader:    "internal.example.com",
+		UserID:        1,
+		Active:        true,
+	}
+	id, err := CreateWebhook(s.db.DB, webhook)
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+
+	stored, err := GetWebhook(s.db.DB, id, 1)
+	if err != nil {
+		t.Fatalf("failed to load webhook: %v", err)
+	}
+	if stored.TLSServerName != webhook.TLSServerName || stored.HostHeader != webhook.HostHeader {
+		t.Errorf("stored overrides = %q/%q, want %q/%q",
+			stored.TLSServerName, stored.HostHeader, webhook.TLSServerName, webhook.HostHeader)
+	}
+}
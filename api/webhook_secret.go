@@ -0,0 +1,43 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// minWebhookSecretLength is the shortest secret the HMAC signature is worth
+// computing for; anything shorter is trivially brute-forced offline.
+const minWebhookSecretLength = 16
+
+// generateWebhookSecret returns a cryptographically random 32-byte secret,
+// base64-encoded so it pastes cleanly into external services.
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// isWeakWebhookSecret reports whether a stored secret is set but too short to
+// provide meaningful HMAC protection. An empty secret means signing is off,
+// which is a choice rather than a weakness.
+func isWeakWebhookSecret(secret string) bool {
+	return secret != "" && len(secret) < minWebhookSecretLength
+}
+
+// HandleGenerateWebhookSecret returns a fresh random secret for the webhook
+// form. Nothing is stored; the caller submits it with create or update.
+func (s *Server) HandleGenerateWebhookSecret(w http.ResponseWriter, r *http.Request, claims *Claims) {
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		http.Error(w, "Failed to generate secret", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"secret": secret})
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// createWebhookRequest posts a webhook payload through the create handler.
+func createWebhookRequest(t *testing.T, s *Server, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.HandleCreateWebhook(rec, auditRequest(t, "POST", "/api/webhooks", body, 1, nil), &Claims{UserID: 1})
+	return rec
+}
+
+func TestGenerateWebhookSecret(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.HandleGenerateWebhookSecret(rec, auditRequest(t, "POST", "/api/webhooks/generate-secret", nil, 1, nil), &Claims{UserID: 1})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("generate-secret status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var resp map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	secret := resp["secret"]
+	if len(secret) < 40 {
+		t.Errorf("secret = %q, want base64 of 32 random bytes", secret)
+	}
+
+	// Two calls must not repeat
+	rec = httptest.NewRecorder()
+	s.HandleGenerateWebhookSecret(rec, auditRequest(t, "POST", "/api/webhooks/generate-secret", nil, 1, nil), &Claims{UserID: 1})
+	var second map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&second); err != nil {
+		t.Fatalf("second response is not valid JSON: %v", err)
+	}
+	if second["secret"] == secret {
+		t.Error("consecutive secrets are identical")
+	}
+}
+
+func TestCreateWebhookRejectsShortSecret(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := createWebhookRequest(t, s, Webhook{Name: "w", URL: "https://example.com", Event: EventDeploymentSucceeded, Secret: "123"})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("short secret status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if !strings.Contains(rec.Body.String(), "at least 16 characters") {
+		t.Errorf("error = %q, want the minimum length explained", rec.Body.String())
+	}
+
+	// No secret at all means signing is off, which stays allowed
+	rec = createWebhookRequest(t, s, Webhook{Name: "w", URL: "https://example.com", Event: EventDeploymentSucceeded})
+	if rec.Code != http.StatusCreated {
+		t.Errorf("unsigned webhook status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+}
+
+func TestCreateWebhookAutoSecretReturnsSecretOnce(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	rec := createWebhookRequest(t, s, Webhook{Name: "w", URL: "https://example.com", Event: EventDeploymentSucceeded, AutoSecret: true})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("auto-secret create status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var created Webhook
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if len(created.Secret) < minWebhookSecretLength {
+		t.Fatalf("created.Secret = %q, want the generated secret returned once", created.Secret)
+	}
+
+	// The stored secret matches what was handed back, and later reads
+	// never reveal it again
+	stored, err := GetWebhook(s.db.DB, created.ID, 1)
+	if err != nil {
+		t.Fatalf("failed to load webhook: %v", err)
+	}
+	if stored.Secret != created.Secret {
+		t.Error("stored secret differs from the one returned on create")
+	}
+
+	rec = httptest.NewRecorder()
+	s.HandleGetWebhook(rec, auditRequest(t, "GET", "/api/webhooks/1", nil, 1, map[string]string{"id": "1"}), &Claims{UserID: 1})
+	if strings.Contains(rec.Body.String(), created.Secret) {
+		t.Error("secret leaked from the read endpoint")
+	}
+}
+
+func TestUpdateWebhookRejectsShortSecret(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	id, err := CreateWebhook(s.db.DB, Webhook{Name: "w", URL: "https://example.com", Event: EventDeploymentSucceeded, UserID: 1, Secret: "a-long-enough-secret"})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	update := Webhook{Name: "w", URL: "https://example.com", Event: EventDeploymentSucceeded, Secret: "123"}
+	s.HandleUpdateWebhook(rec, auditRequest(t, "PUT", "/api/webhooks/1", update, 1, map[string]string{"id": "1"}), &Claims{UserID: 1})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("short secret update status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+
+	// The stored secret is untouched
+	stored, err := GetWebhook(s.db.DB, id, 1)
+	if err != nil {
+		t.Fatalf("failed to load webhook: %v", err)
+	}
+	if stored.Secret != "a-long-enough-secret" {
+		t.Errorf("stored secret = %q, want it unchanged after the rejected update", stored.Secret)
+	}
+}
+
+func TestGetWebhooksFlagsWeakSecrets(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	for _, webhook := range []Webhook{
+		{Name: "weak", URL: "https://example.com/a", Event: EventDeploymentSucceeded, UserID: 1, Secret: "123"},
+		{Name: "strong", URL: "https://example.com/b", Event: EventDeploymentSucceeded, UserID: 1, Secret: "a-long-enough-secret"},
+		{Name: "unsigned", URL: "https://example.com/c", Event: EventDeploymentSucceeded, UserID: 1},
+	} {
+		if _, err := CreateWebhook(s.db.DB, webhook); err != nil {
+			t.Fatalf("failed to create webhook: %v", err)
+		}
+	}
+
+	webhooks, err := GetWebhooks(s.db.DB, 1)
+	if err != nil {
+		t.Fatalf("failed to load webhooks: %v", err)
+	}
+	flagged := map[string]bool{}
+	for _, webhook := range webhooks {
+		flagged[webhook.Name] = webhook.WeakSecret
+		if webhook.Secret != "" {
+			t.Errorf("webhook %q leaks its secret in the list", webhook.Name)
+		}
+	}
+	if !flagged["weak"] || flagged["strong"] || flagged["unsigned"] {
+		t.Errorf("weak_secret flags = %v, want only the short secret flagged", flagged)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayloadRoundTrip(t *testing.T) {
+	payload := []byte(`{"event":"deployment"}`)
+	now := time.Now()
+
+	for _, algorithm := range []string{"", "sha256", "sha512"} {
+		signature, timestamp := signWebhookPayload(payload, "topsecret", algorithm, now)
+		if err := verifyTimestampedSignature(payload, "topsecret", signature, timestamp, now); err != nil {
+			t.Errorf("fresh %q signature rejected: %v", algorithm, err)
+		}
+	}
+
+	signature, timestamp := signWebhookPayload(payload, "topsecret", "sha512", now)
+	if !strings.HasPrefix(signature, "sha512=") {
+		t.Errorf("signature = %q, want the algorithm named in the prefix", signature)
+	}
+	if err := verifyTimestampedSignature([]byte(`{"event":"tampered"}`), "topsecret", signature, timestamp, now); err == nil {
+		t.Error("tampered payload passed verification")
+	}
+	if err := verifyTimestampedSignature(payload, "wrong", signature, timestamp, now); err == nil {
+		t.Error("wrong secret passed verification")
+	}
+}
+
+func TestVerifyTimestampedSignatureRejectsReplays(t *testing.T) {
+	payload := []byte(`{"event":"deployment"}`)
+	signedAt := time.Now()
+	signature, timestamp := signWebhookPayload(payload, "topsecret", "sha256", signedAt)
+
+	// Within the window, in both directions
+	if err := verifyTimestampedSignature(payload, "topsecret", signature, timestamp, signedAt.Add(signatureTolerance/2)); err != nil {
+		t.Errorf("delivery inside the window rejected: %v", err)
+	}
+
+	// A replay after the window fails even though the HMAC is valid
+	err := verifyTimestampedSignature(payload, "topsecret", signature, timestamp, signedAt.Add(signatureTolerance+time.Minute))
+	if err == nil || !strings.Contains(err.Error(), "tolerance window") {
+		t.Errorf("stale delivery error = %v, want the tolerance window named", err)
+	}
+
+	// A timestamp from the future beyond the window is just as suspect
+	if err := verifyTimestampedSignature(payload, "topsecret", signature, timestamp, signedAt.Add(-signatureTolerance-time.Minute)); err == nil {
+		t.Error("future-dated delivery passed verification")
+	}
+
+	// The timestamp header cannot be omitted or garbled
+	if err := verifyTimestampedSignature(payload, "topsecret", signature, "", signedAt); err == nil {
+		t.Error("missing timestamp passed verification")
+	}
+	if err := verifyTimestampedSignature(payload, "topsecret", signature, "soon", signedAt); err == nil {
+		t.Error("malformed timestamp passed verification")
+	}
+}
+
+func TestNormalizeSignatureAlgorithm(t *testing.T) {
+	if got, err := normalizeSignatureAlgorithm(""); err != nil || got != "sha256" {
+		t.Errorf("normalizeSignatureAlgorithm(\"\") = (%q, %v), want the sha256 default", got, err)
+	}
+	if got, err := normalizeSignatureAlgorithm("sha512"); err != nil || got != "sha512" {
+		t.Errorf("normalizeSignatureAlgorithm(\"sha512\") = (%q, %v), want it accepted", got, err)
+	}
+	if _, err := normalizeSignatureAlgorithm("md5"); err == nil {
+		t.Error("normalizeSignatureAlgorithm(\"md5\") accepted an unsupported algorithm")
+	}
+}
+
+// incomingWebhookRequest posts body to the incoming endpoint for webhook 1
+// with the timestamped signature headers.
+func incomingWebhookRequest(t *testing.T, s *Server, body []byte, signature, timestamp string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", "/api/webhooks/incoming/1", bytes.NewReader(body))
+	if signature != "" {
+		req.Header.Set(signatureHeader, signature)
+	}
+	if timestamp != "" {
+		req.Header.Set(timestampHeader, timestamp)
+	}
+	rec := httptest.NewRecorder()
+	ProcessIncomingWebhook(s.db.DB, rec, req, "1")
+	return rec
+}
+
+func TestProcessIncomingWebhookTimestampedScheme(t *testing.T) {
+	s := newAuditTestServer(t)
+	if _, err := CreateWebhook(s.db.DB, Webhook{
+		Name: "ci", URL: "https://example.com/hook", Event: "deployment",
+		Secret: "incoming-webhook-secret", UserID: 2, Active: true,
+	}); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+
+	body := []byte(`{"event":"deployment"}`)
+
+	// A fresh signature is accepted
+	signature, timestamp := signWebhookPayload(body, "incoming-webhook-secret", "sha256", time.Now())
+	if rec := incomingWebhookRequest(t, s, body, signature, timestamp); rec.Code != http.StatusOK {
+		t.Errorf("fresh delivery status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	// The same request replayed outside the window is rejected
+	signature, timestamp = signWebhookPayload(body, "incoming-webhook-secret", "sha256", time.Now().Add(-signatureTolerance-time.Minute))
+	rec := incomingWebhookRequest(t, s, body, signature, timestamp)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("stale delivery status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if !strings.Contains(rec.Body.String(), "tolerance window") {
+		t.Errorf("stale delivery response %q should name the tolerance window", rec.Body.String())
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMatchesTagFilter(t *testing.T) {
+	cases := []struct {
+		filter string
+		tags   []string
+		want   bool
+	}{
+		{"", nil, true},
+		{"", []string{"critical"}, true},
+		{"critical", []string{"critical"}, true},
+		{"critical", []string{"routine"}, false},
+		{"critical", nil, false},
+		{"critical, deploy", []string{"deploy"}, true},
+		{" critical ", []string{"critical"}, true},
+	}
+	for _, c := range cases {
+		if got := matchesTagFilter(c.filter, c.tags); got != c.want {
+			t.Errorf("matchesTagFilter(%q, %v) = %v, want %v", c.filter, c.tags, got, c.want)
+		}
+	}
+}
+
+func TestTriggerWebhooksSkipsNonMatchingTagFilter(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	delivered := make(chan string, 4)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- r.URL.Path
+	}))
+	defer ts.Close()
+
+	for _, webhook := range []Webhook{
+		{Name: "all-tasks", URL: ts.URL + "/all", Event: "task", UserID: 1, Active: true},
+		{Name: "critical-only", URL: ts.URL + "/critical", Event: "task", TagFilter: "critical", UserID: 1, Active: true},
+	} {
+		if _, err := CreateWebhook(s.db.DB, webhook); err != nil {
+			t.Fatalf("failed to create webhook: %v", err)
+		}
+	}
+
+	// A routine task must reach the unfiltered webhook but not the one
+	// scoped to critical tasks
+	TriggerWebhooks(s.db.DB, "task", WebhookPayload{
+		Event:    "task",
+		TaskName: "migrate",
+		Tags:     []string{"routine"},
+	})
+
+	select {
+	case path := <-delivered:
+		if path != "/all" {
+			t.Errorf("delivery hit %s, want /all", path)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("unfiltered webhook was never delivered")
+	}
+	select {
+	case path := <-delivered:
+		t.Errorf("unexpected second delivery to %s", path)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestTriggerWebhooksDeliversOnMatchingTag(t *testing.T) {
+	s := newAuditTestServer(t)
+
+	delivered := make(chan string, 4)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- r.URL.Path
+	}))
+	defer ts.Close()
+
+	webhook := Webhook{Name: "critical-only", URL: ts.URL + "/critical", Event: "task", TagFilter: "critical", UserID: 1, Active: true}
+	if _, err := CreateWebhook(s.db.DB, webhook); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+
+	TriggerWebhooks(s.db.DB, "task", WebhookPayload{
+		Event:    "task",
+		TaskName: "release",
+		Tags:     []string{"critical", "deploy"},
+	})
+
+	select {
+	case <-delivered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("matching webhook was never delivered")
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithUserRejectsUnauthenticatedRequests(t *testing.T) {
+	s := &Server{}
+	called := false
+	handler := s.withUser(func(w http.ResponseWriter, r *http.Request, claims *Claims) {
+		called = true
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/api/webhooks", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated request status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if called {
+		t.Error("wrapped handler must not run without claims")
+	}
+}
+
+func TestWithUserForwardsClaims(t *testing.T) {
+	s := &Server{}
+	var got *Claims
+	handler := s.withUser(func(w http.ResponseWriter, r *http.Request, claims *Claims) {
+		got = claims
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("GET", "/api/webhooks", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userClaimsKey, &Claims{UserID: 42}))
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("authenticated request status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if got == nil || got.UserID != 42 {
+		t.Errorf("wrapped handler received claims %+v, want UserID 42", got)
+	}
+}
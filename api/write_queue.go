@@ -0,0 +1,189 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// writeJob is a single persistence operation applied by the write queue.
+// Jobs receive a transaction so the worker can batch several of them together.
+type writeJob struct {
+	name  string              // Short label used in error logs
+	apply func(*sql.Tx) error // The actual write
+}
+
+// WriteQueue serializes database writes through a single worker goroutine.
+//
+// Under heavy parallel deploys, many goroutines (task completions, status
+// updates, webhook bookkeeping) would otherwise hit SQLite concurrently and
+// stall or fail on write contention. The queue applies jobs sequentially in
+// batched transactions instead. When the queue is nil or already closed,
+// Enqueue falls back to a synchronous write, which keeps CLI-mode code paths
+// working without a running server.
+type WriteQueue struct {
+	db     *sql.DB
+	jobs   chan writeJob
+	done   chan struct{} // Closed when the worker has drained all jobs
+	closed bool
+	lock   sync.Mutex
+}
+
+// maxWriteBatch bounds how many queued jobs are applied in one transaction.
+const maxWriteBatch = 64
+
+// NewWriteQueue creates a write queue with the given buffer size and starts
+// its worker goroutine.
+//
+// Parameters:
+//   - db: the database all jobs are applied to
+//   - buffer: channel capacity; producers block once the buffer is full
+//
+// Returns:
+//   - *WriteQueue: a running queue; call Close() to flush and stop it
+func NewWriteQueue(db *sql.DB, buffer int) *WriteQueue {
+	q := &WriteQueue{
+		db:   db,
+		jobs: make(chan writeJob, buffer),
+		done: make(chan struct{}),
+	}
+	go q.worker()
+	return q
+}
+
+// Enqueue submits a write for asynchronous, serialized application.
+//
+// If the queue is nil (CLI mode) or has been closed, the job is applied
+// synchronously in its own transaction so no write is ever dropped.
+func (q *WriteQueue) Enqueue(name string, apply func(*sql.Tx) error) {
+	job := writeJob{name: name, apply: apply}
+
+	if q == nil {
+		// No queue available (CLI mode): apply synchronously
+		applySingle(nil, job)
+		return
+	}
+
+	// The lock is held across the send so Close() cannot close the channel
+	// underneath an in-flight Enqueue. The worker never takes this lock, so a
+	// blocking send (full buffer) still drains and cannot deadlock.
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.closed {
+		applySingle(q.db, job)
+		return
+	}
+	q.jobs <- job
+}
+
+// Depth returns the number of jobs currently waiting in the queue.
+// It is exposed through the database metrics endpoint.
+func (q *WriteQueue) Depth() int {
+	if q == nil {
+		return 0
+	}
+	return len(q.jobs)
+}
+
+// Close flushes all pending jobs and stops the worker. Producers enqueueing
+// after Close fall back to synchronous writes. Safe to call more than once.
+func (q *WriteQueue) Close() {
+	if q == nil {
+		return
+	}
+
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		<-q.done
+		return
+	}
+	q.closed = true
+	q.lock.Unlock()
+
+	close(q.jobs)
+	<-q.done
+}
+
+// worker drains the job channel, applying jobs in batched transactions.
+func (q *WriteQueue) worker() {
+	defer close(q.done)
+
+	for job := range q.jobs {
+		batch := []writeJob{job}
+
+		// Opportunistically batch whatever else is already queued
+	drain:
+		for len(batch) < maxWriteBatch {
+			select {
+			case next, ok := <-q.jobs:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+
+		q.applyBatch(batch)
+	}
+}
+
+// applyBatch applies a batch of jobs in one transaction. If the batch fails,
+// each job is retried individually so a single bad write cannot take down the
+// rest of the batch.
+func (q *WriteQueue) applyBatch(batch []writeJob) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		logger.Error(fmt.Sprintf("write queue: failed to begin batch transaction: %v", err))
+		for _, job := range batch {
+			applySingle(q.db, job)
+		}
+		return
+	}
+
+	for _, job := range batch {
+		if err := job.apply(tx); err != nil {
+			logger.Error(fmt.Sprintf("write queue: job '%s' failed, retrying batch individually: %v", job.name, err))
+			tx.Rollback()
+			for _, j := range batch {
+				applySingle(q.db, j)
+			}
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error(fmt.Sprintf("write queue: failed to commit batch: %v", err))
+		for _, job := range batch {
+			applySingle(q.db, job)
+		}
+	}
+}
+
+// applySingle applies one job in its own transaction. When db is nil the job
+// is skipped with an error log, since there is nothing to write to.
+func applySingle(db *sql.DB, job writeJob) {
+	if db == nil {
+		logger.Error(fmt.Sprintf("write queue: dropping job '%s': no database available", job.name))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error(fmt.Sprintf("write queue: job '%s' failed to begin transaction: %v", job.name, err))
+		return
+	}
+	if err := job.apply(tx); err != nil {
+		tx.Rollback()
+		logger.Error(fmt.Sprintf("write queue: job '%s' failed: %v", job.name, err))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		logger.Error(fmt.Sprintf("write queue: job '%s' failed to commit: %v", job.name, err))
+	}
+}
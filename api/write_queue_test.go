@@ -0,0 +1,102 @@
+package api
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newWriteQueueTestDB creates an in-memory SQLite database with a minimal
+// events table for exercising the write queue.
+func newWriteQueueTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task TEXT NOT NULL
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create events table: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestWriteQueueStress verifies that no events are lost when 1000 task
+// completions are recorded from many goroutines at once.
+func TestWriteQueueStress(t *testing.T) {
+	db := newWriteQueueTestDB(t)
+	q := NewWriteQueue(db, 256)
+
+	const total = 1000
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.Enqueue("task-completion", func(tx *sql.Tx) error {
+				_, err := tx.Exec("INSERT INTO events (task) VALUES (?)", "deploy")
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+	q.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM events").Scan(&count); err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if count != total {
+		t.Errorf("expected %d events, got %d", total, count)
+	}
+}
+
+// TestWriteQueueSynchronousFallback verifies that jobs enqueued after Close,
+// or against a nil queue with no database, do not panic and that post-Close
+// jobs are still written synchronously.
+func TestWriteQueueSynchronousFallback(t *testing.T) {
+	db := newWriteQueueTestDB(t)
+	q := NewWriteQueue(db, 4)
+	q.Close()
+
+	q.Enqueue("late-write", func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO events (task) VALUES (?)", "late")
+		return err
+	})
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM events").Scan(&count); err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 event after synchronous fallback, got %d", count)
+	}
+
+	// A nil queue (CLI mode) must not panic
+	var nilQueue *WriteQueue
+	nilQueue.Enqueue("cli-write", func(tx *sql.Tx) error { return nil })
+	if nilQueue.Depth() != 0 {
+		t.Errorf("nil queue Depth() = %d, want 0", nilQueue.Depth())
+	}
+}
+
+// TestWriteQueueCloseIdempotent verifies Close can be called repeatedly.
+func TestWriteQueueCloseIdempotent(t *testing.T) {
+	q := NewWriteQueue(newWriteQueueTestDB(t), 4)
+	q.Close()
+	q.Close()
+
+	if q.Depth() != 0 {
+		t.Errorf("Depth() after Close = %d, want 0", q.Depth())
+	}
+}
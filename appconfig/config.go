@@ -27,17 +27,55 @@ type Config struct {
 	LogPath           string       `env:"NYATI_LOG_PATH" default:"nyatictl.log"`
 	LogLevel          string       `env:"NYATI_LOG_LEVEL" default:"INFO"`
 	StructuredLogging bool         `env:"NYATI_STRUCTURED_LOGGING" default:"false"`
+	LogBufferSize     int          `env:"NYATI_LOG_BUFFER_SIZE" default:"100"`
 	
 	// File paths
-	ConfigsPath string `env:"NYATI_CONFIGS_PATH" default:"configs.json"`
+	ConfigsPath  string `env:"NYATI_CONFIGS_PATH" default:"configs.json"`
+	DefaultsFile string `env:"NYATI_DEFAULTS_FILE" default:""`
+	
+	// Env secret encryption algorithm: aes256-gcm (default) or chacha20-poly1305
+	EnvAlgorithm string `env:"NYATI_ENV_ALGORITHM" default:"aes256-gcm"`
 	
 	// Security settings
 	JWTSecret        string        `env:"NYATI_JWT_SECRET" default:""`
 	SessionTimeout   time.Duration `env:"NYATI_SESSION_TIMEOUT" default:"24h"`
+
+	// MasterKey wraps per-environment data keys at rest. When set, the web
+	// server reads and writes environment secrets for authenticated users
+	// without a per-request passphrase; when empty, passphrase mode applies.
+	MasterKey string `env:"NYATI_MASTER_KEY" default:""`
 	
 	// Performance settings
 	RequestTimeout   time.Duration `env:"NYATI_REQUEST_TIMEOUT" default:"30s"`
 	ShutdownTimeout  time.Duration `env:"NYATI_SHUTDOWN_TIMEOUT" default:"10s"`
+	MaxConcurrentDeploys int       `env:"NYATI_MAX_CONCURRENT_DEPLOYS" default:"5"`
+
+	// Automatic deploy retries: a deploy that failed with a connection error
+	// is retried up to DeployRetryAttempts times, waiting DeployRetryDelay
+	// before each retry. 0 attempts (the default) disables retries.
+	DeployRetryAttempts int           `env:"NYATI_DEPLOY_RETRY_ATTEMPTS" default:"0"`
+	DeployRetryDelay    time.Duration `env:"NYATI_DEPLOY_RETRY_DELAY" default:"30s"`
+
+	// DeployLockMode decides what a deploy gets when another deploy of the
+	// same config to the same host is already running: "reject" (the
+	// default) answers it with a conflict, "queue" makes it wait its turn.
+	DeployLockMode string `env:"NYATI_DEPLOY_LOCK_MODE" default:"reject"`
+
+	// Background config health checks: when enabled, every saved config's
+	// hosts are probed with a short SSH connect on this interval and the
+	// result is shown as a badge in the configs list. Off by default for
+	// security-sensitive installs that do not want unattended connections.
+	HealthCheckEnabled  bool          `env:"NYATI_HEALTH_CHECK_ENABLED" default:"false"`
+	HealthCheckInterval time.Duration `env:"NYATI_HEALTH_CHECK_INTERVAL" default:"5m"`
+
+	// Deploy notification settings
+	SMTPHost         string `env:"NYATI_SMTP_HOST" default:""`
+	SMTPPort         int    `env:"NYATI_SMTP_PORT" default:"587"`
+	SMTPUsername     string `env:"NYATI_SMTP_USERNAME" default:""`
+	SMTPPassword     string `env:"NYATI_SMTP_PASSWORD" default:""`
+	SMTPFrom         string `env:"NYATI_SMTP_FROM" default:""`
+	NotifyEmail      string `env:"NYATI_NOTIFY_EMAIL" default:""`
+	NotifyWebhookURL string `env:"NYATI_NOTIFY_WEBHOOK_URL" default:""`
 }
 
 // Load loads configuration from environment variables with defaults
@@ -75,12 +113,24 @@ func Load() (*Config, error) {
 	if err := loadField(cfg, "StructuredLogging", "NYATI_STRUCTURED_LOGGING", "false"); err != nil {
 		return nil, err
 	}
+	if err := loadField(cfg, "LogBufferSize", "NYATI_LOG_BUFFER_SIZE", "100"); err != nil {
+		return nil, err
+	}
 	if err := loadField(cfg, "ConfigsPath", "NYATI_CONFIGS_PATH", "configs.json"); err != nil {
 		return nil, err
 	}
+	if err := loadField(cfg, "DefaultsFile", "NYATI_DEFAULTS_FILE", ""); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "EnvAlgorithm", "NYATI_ENV_ALGORITHM", "aes256-gcm"); err != nil {
+		return nil, err
+	}
 	if err := loadField(cfg, "JWTSecret", "NYATI_JWT_SECRET", ""); err != nil {
 		return nil, err
 	}
+	if err := loadField(cfg, "MasterKey", "NYATI_MASTER_KEY", ""); err != nil {
+		return nil, err
+	}
 	if err := loadField(cfg, "SessionTimeout", "NYATI_SESSION_TIMEOUT", "24h"); err != nil {
 		return nil, err
 	}
@@ -90,7 +140,46 @@ func Load() (*Config, error) {
 	if err := loadField(cfg, "ShutdownTimeout", "NYATI_SHUTDOWN_TIMEOUT", "10s"); err != nil {
 		return nil, err
 	}
-	
+	if err := loadField(cfg, "MaxConcurrentDeploys", "NYATI_MAX_CONCURRENT_DEPLOYS", "5"); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "DeployRetryAttempts", "NYATI_DEPLOY_RETRY_ATTEMPTS", "0"); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "DeployRetryDelay", "NYATI_DEPLOY_RETRY_DELAY", "30s"); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "DeployLockMode", "NYATI_DEPLOY_LOCK_MODE", "reject"); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "HealthCheckEnabled", "NYATI_HEALTH_CHECK_ENABLED", "false"); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "HealthCheckInterval", "NYATI_HEALTH_CHECK_INTERVAL", "5m"); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "SMTPHost", "NYATI_SMTP_HOST", ""); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "SMTPPort", "NYATI_SMTP_PORT", "587"); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "SMTPUsername", "NYATI_SMTP_USERNAME", ""); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "SMTPPassword", "NYATI_SMTP_PASSWORD", ""); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "SMTPFrom", "NYATI_SMTP_FROM", ""); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "NotifyEmail", "NYATI_NOTIFY_EMAIL", ""); err != nil {
+		return nil, err
+	}
+	if err := loadField(cfg, "NotifyWebhookURL", "NYATI_NOTIFY_WEBHOOK_URL", ""); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
@@ -143,10 +232,22 @@ func loadField(cfg *Config, fieldName, envName, defaultValue string) error {
 			return fmt.Errorf("invalid boolean value for %s: %v", envName, err)
 		}
 		cfg.StructuredLogging = parsed
+	case "LogBufferSize":
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer value for %s: %v", envName, err)
+		}
+		cfg.LogBufferSize = parsed
 	case "ConfigsPath":
 		cfg.ConfigsPath = value
+	case "DefaultsFile":
+		cfg.DefaultsFile = value
+	case "EnvAlgorithm":
+		cfg.EnvAlgorithm = value
 	case "JWTSecret":
 		cfg.JWTSecret = value
+	case "MasterKey":
+		cfg.MasterKey = value
 	case "SessionTimeout":
 		parsed, err := time.ParseDuration(value)
 		if err != nil {
@@ -165,6 +266,56 @@ func loadField(cfg *Config, fieldName, envName, defaultValue string) error {
 			return fmt.Errorf("invalid duration value for %s: %v", envName, err)
 		}
 		cfg.ShutdownTimeout = parsed
+	case "MaxConcurrentDeploys":
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer value for %s: %v", envName, err)
+		}
+		cfg.MaxConcurrentDeploys = parsed
+	case "DeployRetryAttempts":
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer value for %s: %v", envName, err)
+		}
+		cfg.DeployRetryAttempts = parsed
+	case "DeployRetryDelay":
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration value for %s: %v", envName, err)
+		}
+		cfg.DeployRetryDelay = parsed
+	case "DeployLockMode":
+		cfg.DeployLockMode = strings.ToLower(value)
+	case "HealthCheckEnabled":
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean value for %s: %v", envName, err)
+		}
+		cfg.HealthCheckEnabled = parsed
+	case "HealthCheckInterval":
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration value for %s: %v", envName, err)
+		}
+		cfg.HealthCheckInterval = parsed
+	case "SMTPHost":
+		cfg.SMTPHost = value
+	case "SMTPPort":
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer value for %s: %v", envName, err)
+		}
+		cfg.SMTPPort = parsed
+	case "SMTPUsername":
+		cfg.SMTPUsername = value
+	case "SMTPPassword":
+		cfg.SMTPPassword = value
+	case "SMTPFrom":
+		cfg.SMTPFrom = value
+	case "NotifyEmail":
+		cfg.NotifyEmail = value
+	case "NotifyWebhookURL":
+		cfg.NotifyWebhookURL = value
 	default:
 		return fmt.Errorf("unknown field: %s", fieldName)
 	}
@@ -227,7 +378,17 @@ func (cfg *Config) Validate() error {
 	if !validLogLevels[cfg.LogLevel] {
 		return fmt.Errorf("invalid log level: %s (must be one of: DEBUG, INFO, WARN, ERROR, FATAL)", cfg.LogLevel)
 	}
+	// Zero means "use the default"; only reject nonsensical values
+	if cfg.LogBufferSize < 0 {
+		return fmt.Errorf("log buffer size cannot be negative, got %d", cfg.LogBufferSize)
+	}
 	
+	// Validate deploy lock mode; empty means "use the default" for configs
+	// built by hand
+	if cfg.DeployLockMode != "" && cfg.DeployLockMode != "reject" && cfg.DeployLockMode != "queue" {
+		return fmt.Errorf("invalid deploy lock mode: %s (must be reject or queue)", cfg.DeployLockMode)
+	}
+
 	// Validate paths are not empty
 	if cfg.LogPath == "" {
 		return fmt.Errorf("log path cannot be empty")
@@ -239,6 +400,19 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("database path cannot be empty")
 	}
 	
+	// Validate notification settings: email notifications need a usable SMTP setup
+	if cfg.NotifyEmail != "" {
+		if cfg.SMTPHost == "" {
+			return fmt.Errorf("NYATI_SMTP_HOST is required when NYATI_NOTIFY_EMAIL is set")
+		}
+		if cfg.SMTPFrom == "" {
+			return fmt.Errorf("NYATI_SMTP_FROM is required when NYATI_NOTIFY_EMAIL is set")
+		}
+		if cfg.SMTPPort < 1 || cfg.SMTPPort > 65535 {
+			return fmt.Errorf("invalid SMTP port: %d (must be between 1 and 65535)", cfg.SMTPPort)
+		}
+	}
+
 	// Warn if JWT secret is not set (but don't fail validation)
 	if cfg.JWTSecret == "" {
 		logger.Warn("JWT secret not configured - using default (SECURITY RISK in production)")
@@ -267,8 +441,14 @@ func (cfg *Config) GetLogLevel() logger.LogLevel {
 
 // GetDatabaseURL constructs the SQLite database connection URL with parameters
 func (cfg *Config) GetDatabaseURL() string {
+	return DatabaseURL(cfg.DatabasePath)
+}
+
+// DatabaseURL constructs the SQLite connection URL for a database path with
+// the standard connection parameters shared by the CLI and the web server
+func DatabaseURL(path string) string {
 	return fmt.Sprintf("%s?_busy_timeout=10000&_journal_mode=WAL&_synchronous=NORMAL&_cache_size=1000&_foreign_keys=1",
-		cfg.DatabasePath)
+		path)
 }
 
 // LogConfiguration logs the current configuration (excluding sensitive values)
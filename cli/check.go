@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/ssh"
+	"github.com/zechtz/nyatictl/term"
+)
+
+// setupCheckCommand registers the `check` subcommand, which probes whether
+// each configured host's SSH port is reachable without running any tasks.
+//
+// Parameters:
+//   - rootCmd: The root command to attach the check command to
+//   - version: Application version used when loading the config
+func setupCheckCommand(rootCmd *cobra.Command, version string) {
+	var cfgFile string
+	var noCache bool
+
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check that configured hosts are reachable",
+		Long: `Check dials the SSH port of every host in the config and reports which
+are reachable. Results are cached briefly so repeated checks across a fleet
+do not re-dial every host; use --no-cache to force fresh probes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cfgFile == "" {
+				cfgFile = "nyati.yaml"
+			}
+
+			cfg, err := config.LoadWithOptions(cfgFile, version, config.LoadOptions{AllowInventory: true})
+			if err != nil {
+				return err
+			}
+
+			unreachable := 0
+			for name, server := range cfg.Hosts {
+				result := ssh.CheckHost(server, noCache)
+				note := ""
+				if result.Cached {
+					note = " (cached)"
+				}
+				if result.Reachable {
+					fmt.Printf("%s %s (%s)%s\n", term.Green("✔"), name, result.Host, note)
+				} else {
+					unreachable++
+					fmt.Printf("%s %s (%s)%s: %s\n", term.Red("✘"), name, result.Host, note, result.Error)
+				}
+			}
+
+			if unreachable > 0 {
+				return fmt.Errorf("%d host(s) unreachable", unreachable)
+			}
+			return nil
+		},
+	}
+
+	checkCmd.Flags().StringVarP(&cfgFile, "config", "c", "", "Path to config file (default: nyati.yaml)")
+	checkCmd.Flags().BoolVar(&noCache, "no-cache", false, "Bypass cached reachability results")
+
+	rootCmd.AddCommand(checkCmd)
+}
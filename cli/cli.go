@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/zechtz/nyatictl/config"
 	"github.com/zechtz/nyatictl/env"
+	"github.com/zechtz/nyatictl/logger"
 	"github.com/zechtz/nyatictl/ssh"
 	"github.com/zechtz/nyatictl/tasks"
+	"github.com/zechtz/nyatictl/term"
 )
 
 // Execute initializes and executes the root Cobra command for nyatictl.
@@ -24,13 +27,29 @@ import (
 // Returns:
 //   - error: If any error occurs during execution, it will be returned.
 func Execute(version string) error {
-	var cfgFile string    // Path to configuration file
-	var deployHost string // Host to deploy tasks to (e.g., "all", "server1")
-	var taskName string   // Optional task name to execute
-	var includeLib bool   // Whether to include "lib" tasks
-	var debug bool        // Enable debug output
-	var envName string    // Environment to use for deployment
-	var envFile string    // Path to environment file
+	var cfgFile string       // Path to configuration file
+	var hostsFile string     // Path to an ops-managed hosts file merged over the config's hosts
+	var deployHost string    // Host to deploy tasks to (e.g., "all", "server1")
+	var taskName string      // Optional task name to execute
+	var only bool            // Run the named task without its dependencies
+	var includeLib bool      // Whether to include "lib" tasks
+	var debug bool           // Enable debug output
+	var debugSSH bool        // Log SSH transport milestones
+	var transportRetries int // Re-runs of idempotent tasks after mid-task connection drops
+	var timestamps bool      // Prefix task output lines with elapsed time
+	var envName string       // Environment to use for deployment
+	var envFile string       // Path to environment file
+	var envKey string        // Encryption key for environment secrets
+	var noColor bool         // Disable colored output
+	var noStrict bool        // Accept unknown config keys during migration
+	var output string        // Summary output format: text or json
+	var quiet bool           // Suppress info chatter; failures and summary only
+	var dryRun bool          // Show the execution plan without running anything
+	var explain bool         // Annotate the plan with inclusion/exclusion reasons
+	var defaultsFile string  // Path to a global defaults file merged under the config
+	var releaseVersion int64 // Explicit release version reused across phases/retries
+	var sudoPassEnv string   // Env var (process or --env store) holding the sudo password
+	var assumeYes bool       // Pass task confirmation gates without prompting
 
 	rootCmd := &cobra.Command{
 		Use:   "nyatictl",
@@ -43,6 +62,19 @@ Usage examples:
   nyatictl [-c nyati.yaml] deploy all --include-lib  # Include lib tasks
   nyatictl [-c nyati.yaml] deploy server1 --task clean  # Run the 'clean' task on server1
   nyatictl [-c nyati.yaml] server1       # Shorthand for deploy server1`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// Disable colors for CI logs; NO_COLOR and non-TTY output are
+			// handled automatically by the term package.
+			if noColor {
+				term.SetColorMode(term.ColorNever)
+			}
+
+			// Temporary escape hatch while configs are cleaned up for
+			// strict unknown-key validation
+			if noStrict {
+				config.SetStrictUnknownKeys(false)
+			}
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Display help if explicitly requested
 			if cmd.Flag("help").Changed {
@@ -60,33 +92,154 @@ Usage examples:
 				}
 			}
 
-			// Load the configuration file
-			cfg, err := config.Load(cfgFile, version)
+			// Point config loading at the global defaults file, if given
+			if defaultsFile != "" {
+				config.DefaultsFile = defaultsFile
+			}
+
+			// Load the configuration file, merging in the external hosts
+			// file when one is given (tasks in the repo, targets outside it)
+			cfg, err := config.LoadWithOptions(cfgFile, version, config.LoadOptions{HostsFile: hostsFile, AllowInventory: true})
 			if err != nil {
 				return err
 			}
 
+			// Reuse an explicit release version (e.g. from an earlier phase
+			// or a rollback) instead of the freshly generated one
+			if releaseVersion > 0 {
+				cfg.ReleaseVersion = releaseVersion
+			}
+
 			// Override args if deploy flag is set
 			if deployHost != "" {
 				args = []string{"deploy", deployHost}
 			}
 
-			// Execute main logic
-			return Run(cfg, args, taskName, includeLib, debug)
+			// Resolve environment-specific host overrides (e.g.
+			// ${env.PROD_HOST}) so --env targets the right servers with the
+			// right credentials
+			var envVars map[string]string
+			if envName != "" {
+				envVars, err = env.FileResolver(envFile, envKey)(envName)
+				if err != nil {
+					return err
+				}
+				config.ApplyHostEnv(cfg, envVars)
+			}
+
+			// Show the plan without connecting anywhere
+			if dryRun || explain {
+				return printPlan(os.Stdout, cfg, taskName, includeLib, explain)
+			}
+
+			if output != outputText && output != outputJSON {
+				return fmt.Errorf("invalid --output format %q; expected %q or %q", output, outputText, outputJSON)
+			}
+
+			// Transport milestone logging for diagnosing SSH-level failures
+			if debugSSH {
+				ssh.SetTransportDebug(true)
+			}
+
+			// How persistently idempotent tasks are re-run when their
+			// connection drops mid-task
+			ssh.SetTransportRetries(transportRetries)
+
+			// Phase timing on all task output, as if every task had
+			// timestamps: true
+			if timestamps {
+				ssh.SetOutputTimestamps(true)
+			}
+
+			// Quiet mode keeps informational chatter (connection banners,
+			// per-task progress) off the console; the log file still
+			// captures everything
+			if quiet {
+				logger.SetConsoleLevel(logger.WARN)
+			}
+
+			// Debugging a single step should not force its whole chain to
+			// rerun; --only trades safety for iteration speed explicitly
+			if only && taskName == "" {
+				return fmt.Errorf("--only requires a task selection (--task)")
+			}
+
+			// Wire the sudo password for askpass tasks: from the named
+			// variable, or an interactive prompt on a terminal. It is fed to
+			// the remote command over stdin, never the command line.
+			sudoPassword, err := resolveSudoPassword(sudoPassEnv, envVars, cfg)
+			if err != nil {
+				return err
+			}
+			if sudoPassword != "" {
+				ssh.SetSudoPassword(sudoPassword)
+			}
+
+			// Execute main logic, resolving env_name references against the
+			// file-backed environment store in CLI mode, then report how the
+			// run went so failures don't require scrolling back
+			start := time.Now()
+			results, err := RunWithOptions(cfg, args, RunOptions{
+				TaskName:    taskName,
+				Only:        only,
+				IncludeLib:  includeLib,
+				Debug:       debug,
+				AutoConfirm: assumeYes,
+				EnvResolver: env.FileResolver(envFile, envKey),
+			})
+			printRunSummary(os.Stdout, results, time.Since(start), output, quiet)
+			return err
 		},
 	}
 
 	// Add database migration commands
 	setupMigrationCommands(rootCmd)
 
+	// Add config lint command
+	setupLintCommand(rootCmd, version)
+
+	// Add environment management commands
+	setupEnvCommands(rootCmd)
+
+	// Add host reachability check command
+	setupCheckCommand(rootCmd, version)
+
+	// Register the history export command
+	setupHistoryCommand(rootCmd)
+
+	// Register the partial-deploy resume command
+	setupResumeCommand(rootCmd, version)
+
+	// Register the Git config import command
+	setupImportCommand(rootCmd, version)
+
+	// Register the local environment doctor command
+	setupDoctorCommand(rootCmd, version)
+
 	// Define supported flags
 	rootCmd.Flags().StringVarP(&cfgFile, "config", "c", "", "Path to config file (default: nyati.yaml or nyati.yml in current directory)")
+	rootCmd.Flags().StringVar(&hostsFile, "hosts-file", "", "Path to a hosts file whose entries override the config's hosts (also honored via the hosts_file config key)")
 	rootCmd.Flags().StringVar(&deployHost, "deploy", "", "Host to deploy tasks on (e.g., 'all' or 'server1')")
 	rootCmd.Flags().StringVar(&taskName, "task", "", "Specific task to run (e.g., 'clean')")
+	rootCmd.Flags().BoolVar(&only, "only", false, "Run the --task exactly as named, skipping its dependencies (prerequisites are not run)")
 	rootCmd.Flags().BoolVar(&includeLib, "include-lib", false, "Include tasks marked as lib")
 	rootCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable debug output")
+	rootCmd.Flags().BoolVar(&debugSSH, "debug-ssh", false, "Log SSH transport milestones (handshake, auth attempts, session events)")
+	rootCmd.Flags().IntVar(&transportRetries, "transport-retries", 1, "Times an idempotent task is re-run after its connection drops mid-task")
+	rootCmd.Flags().BoolVar(&timestamps, "timestamps", false, "Prefix task output lines with elapsed time since the task started")
 	rootCmd.Flags().StringVarP(&envName, "env", "e", "", "Environment to use for deployment")
 	rootCmd.Flags().StringVar(&envFile, "env-file", env.DefaultEnvFile, "Path to environment file")
+	rootCmd.Flags().StringVar(&envKey, "env-key", "", "Encryption key for decrypting environment secrets")
+	rootCmd.Flags().StringVar(&output, "output", outputText, "Execution summary format: 'text' or 'json'")
+	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Suppress informational task/connection output; print only warnings, errors and the summary")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the execution plan without connecting to any host")
+	rootCmd.Flags().BoolVar(&explain, "explain", false, "Annotate every task with why it is included or excluded (implies --dry-run)")
+	rootCmd.Flags().StringVar(&defaultsFile, "defaults", "", "Path to a global defaults file whose params/hosts are merged under the config (also honored via NYATI_DEFAULTS_FILE)")
+	rootCmd.Flags().Int64Var(&releaseVersion, "release-version", 0, "Explicit ${release_version} value, to target a release created by an earlier run")
+	rootCmd.Flags().StringVar(&sudoPassEnv, "sudo-password-from-env", "", "Name of a variable (process environment or --env store) holding the sudo password for askpass tasks")
+	rootCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Proceed through task confirmation gates without prompting (required for confirm tasks in non-interactive runs)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also honored via NO_COLOR env variable)")
+	rootCmd.PersistentFlags().BoolVar(&noStrict, "no-strict", false, "Accept unknown config keys instead of rejecting them (temporary migration aid)")
 	rootCmd.Flags().BoolP("help", "h", false, "Show help")
 
 	// Start CLI
@@ -104,65 +257,110 @@ Usage examples:
 //   - taskName: Optional specific task to run
 //   - includeLib: Whether to include tasks marked as lib
 //   - debug: Enable debug output
+//   - envResolver: Optional resolver for hosts that reference a named
+//     environment via env_name
 //
 // Returns:
 //   - error: Any encountered error
-func Run(cfg *config.Config, args []string, taskName string, includeLib bool, debug bool) error {
+func Run(cfg *config.Config, args []string, taskName string, includeLib bool, debug bool, envResolver ...env.Resolver) error {
+	_, err := RunResults(cfg, args, taskName, includeLib, debug, envResolver...)
+	return err
+}
+
+// RunOptions bundles the task selection and execution switches for a run,
+// replacing the growing list of positional arguments. The zero value runs
+// every non-lib task.
+type RunOptions struct {
+	TaskName     string       // Run only this task and its dependencies (CLI --task)
+	Tasks        []string     // Run only these tasks plus their dependencies
+	ExcludeTasks []string     // Drop these tasks from the final plan
+	Only         bool         // Run the requested tasks without resolving their dependencies (CLI --only)
+	IncludeLib   bool         // Include tasks marked as lib in the default selection
+	Debug        bool         // Enable debug output
+	AutoConfirm  bool         // Pass confirmation gates without prompting (CLI --yes, web deploys)
+	EnvResolver  env.Resolver // Optional resolver for hosts that reference env_name
+
+	// Env holds extra variables injected into every host's environment, for
+	// callers that resolve an environment up front (such as the deploy API
+	// with a config-bound environment).
+	Env map[string]string
+
+	// Completed marks task/host pairs that succeeded in an earlier run; a
+	// resumed deploy skips them and re-runs only what failed or never ran.
+	Completed tasks.CompletedSet
+}
+
+// RunResults behaves like Run but also returns the structured per-task,
+// per-host results, for callers (such as the synchronous deploy API) that
+// need a machine-readable outcome rather than just pass/fail.
+//
+// Parameters: identical to Run.
+//
+// Returns:
+//   - []tasks.Result: structured execution results (nil when nothing ran)
+//   - error: Any encountered error
+func RunResults(cfg *config.Config, args []string, taskName string, includeLib bool, debug bool, envResolver ...env.Resolver) ([]tasks.Result, error) {
+	opts := RunOptions{TaskName: taskName, IncludeLib: includeLib, Debug: debug}
+	if len(envResolver) > 0 {
+		opts.EnvResolver = envResolver[0]
+	}
+	return RunWithOptions(cfg, args, opts)
+}
+
+// RunWithOptions is the options-based execution entry point behind Run and
+// RunResults.
+//
+// Parameters:
+//   - cfg: The loaded configuration object
+//   - args: CLI arguments determining what to run
+//   - opts: Task selection and execution switches
+//
+// Returns:
+//   - []tasks.Result: structured execution results (nil when nothing ran)
+//   - error: Any encountered error
+func RunWithOptions(cfg *config.Config, args []string, opts RunOptions) ([]tasks.Result, error) {
 	// Display help if nothing to do
 	if len(args) == 0 && !hasDeployFlag(args) {
 		PrintHelp(cfg)
-		return nil
+		return nil, nil
 	}
 
 	// Initialize SSH clients
-	clients, err := ssh.NewManager(cfg, args, debug)
+	clients, err := ssh.NewManager(cfg, args, opts.Debug)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer clients.Close()
 
+	if opts.EnvResolver != nil {
+		clients.SetEnvResolver(opts.EnvResolver)
+	}
+
 	// Establish SSH connections
 	if err := clients.Open(); err != nil {
-		return err
+		return nil, err
 	}
 
-	// Determine which tasks to run
-	var tasksToRun []config.Task
-	if taskName != "" {
-		// Run only the specified task and its dependencies
-		for _, task := range cfg.Tasks {
-			if task.Name == taskName {
-				deps, err := getTaskWithDependencies(cfg.Tasks, taskName)
-				if err != nil {
-					return err
-				}
-				tasksToRun = deps
-				break
-			}
-		}
-		if len(tasksToRun) == 0 {
-			return fmt.Errorf("task '%s' not found", taskName)
-		}
-	} else {
-		// Run all tasks, optionally excluding lib tasks
-		var filteredTasks []config.Task
-		for _, task := range cfg.Tasks {
-			if task.Lib && !includeLib {
-				continue
-			}
-			filteredTasks = append(filteredTasks, task)
-		}
+	// Inject caller-resolved environment variables into every connected host
+	if len(opts.Env) > 0 {
+		clients.InjectEnv(opts.Env)
+	}
 
-		// Sort tasks by dependency order
-		sortedTasks, err := topologicalSort(filteredTasks)
-		if err != nil {
-			return err
-		}
-		tasksToRun = sortedTasks
+	// Confirmation gates cannot prompt without a terminal, so unattended
+	// callers pass them only by opting in explicitly
+	if opts.AutoConfirm {
+		tasks.SetConfirmFunc(tasks.AutoProceed)
+		defer tasks.SetConfirmFunc(nil)
+	}
+
+	// Determine which tasks to run
+	tasksToRun, err := selectTasks(cfg, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	// Run the tasks over SSH
-	return tasks.Run(clients, tasksToRun, debug)
+	return tasks.RunSkipping(clients, tasksToRun, opts.Debug, opts.Completed)
 }
 
 // getTaskWithDependencies builds a dependency-aware list of tasks,
@@ -191,7 +389,7 @@ func getTaskWithDependencies(tasks []config.Task, taskName string) ([]config.Tas
 		}
 		task, ok := taskMap[name]
 		if !ok {
-			return fmt.Errorf("task '%s' not found", name)
+			return config.ErrTaskNotFound{Name: name}
 		}
 		for _, dep := range task.DependsOn {
 			if err := collectDeps(dep); err != nil {
@@ -280,7 +478,7 @@ func hasDeployFlag(args []string) bool {
 // Parameters:
 //   - cfg: Optional loaded config to display host/version info
 func PrintHelp(cfg *config.Config) {
-	fmt.Println("   -^- Nyatictl -^-    ")
+	fmt.Println(term.Bold("   -^- Nyatictl -^-    "))
 	fmt.Println("Usage:")
 	fmt.Println("\tnyatictl [-c config.yaml] [-d] [deploy hostname] [--task taskname] [--include-lib] [hostname]")
 	fmt.Println("\tnyatictl [-c config.yaml] [deploy all] [--task taskname] [--include-lib]")
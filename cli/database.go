@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/zechtz/nyatictl/appconfig"
+)
+
+// ResolveDatabasePath returns the database path a command should use: the
+// explicit override when one was given (usually a --db flag), otherwise the
+// configured NYATI_DB_PATH with its ./nyatictl.db default. This is the same
+// resolution the web server applies, so CLI commands and the server always
+// agree on which file they are talking about.
+//
+// Parameters:
+//   - override: an explicit path that wins over the configured one; may be empty
+//
+// Returns:
+//   - string: the resolved database path
+//   - error: if the application configuration cannot be loaded
+func ResolveDatabasePath(override string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	cfg, err := appconfig.Load()
+	if err != nil {
+		return "", err
+	}
+	return cfg.DatabasePath, nil
+}
+
+// OpenDatabase opens the SQLite database at path with the same connection
+// parameters the web server uses (WAL journal, busy timeout, foreign keys).
+//
+// Unless createIfMissing is set, a missing file is an error: the sqlite
+// driver would otherwise silently create an empty database in whatever
+// directory the command happened to run from. Only `db migrate` and the
+// server startup path (which migrates first) pass createIfMissing.
+//
+// Parameters:
+//   - path: the database file to open
+//   - createIfMissing: allow creating a fresh database file
+//
+// Returns:
+//   - *sql.DB: open handle on the database
+//   - error: if the file is missing (and may not be created) or cannot be opened
+func OpenDatabase(path string, createIfMissing bool) (*sql.DB, error) {
+	if !createIfMissing {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("database %s not found; run `nyatictl db migrate` or the web server once to create it", path)
+		}
+	}
+
+	database, err := sql.Open("sqlite3", appconfig.DatabaseURL(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	return database, nil
+}
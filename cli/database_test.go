@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDatabasePath(t *testing.T) {
+	t.Setenv("NYATI_DB_PATH", "/var/lib/nyati/env.db")
+
+	// An explicit override always wins
+	path, err := ResolveDatabasePath("/tmp/override.db")
+	if err != nil || path != "/tmp/override.db" {
+		t.Errorf("ResolveDatabasePath(override) = %q, %v; want the override", path, err)
+	}
+
+	// Without an override the configured NYATI_DB_PATH is used
+	path, err = ResolveDatabasePath("")
+	if err != nil || path != "/var/lib/nyati/env.db" {
+		t.Errorf("ResolveDatabasePath(\"\") = %q, %v; want the configured path", path, err)
+	}
+}
+
+func TestOpenDatabaseRefusesToCreateMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "absent.db")
+
+	if _, err := OpenDatabase(path, false); err == nil {
+		t.Fatal("OpenDatabase created a missing database without createIfMissing")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("database file was created as a side effect: stat err = %v", err)
+	}
+}
+
+func TestOpenDatabaseCreatesWhenAsked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fresh.db")
+
+	database, err := OpenDatabase(path, true)
+	if err != nil {
+		t.Fatalf("OpenDatabase failed: %v", err)
+	}
+	defer database.Close()
+
+	if _, err := database.Exec("CREATE TABLE t (id INTEGER)"); err != nil {
+		t.Fatalf("new database is not usable: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("database file missing after create: %v", err)
+	}
+
+	// Re-opening an existing file without createIfMissing succeeds
+	reopened, err := OpenDatabase(path, false)
+	if err != nil {
+		t.Fatalf("OpenDatabase on an existing file failed: %v", err)
+	}
+	reopened.Close()
+}
@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/ssh"
+	"github.com/zechtz/nyatictl/term"
+)
+
+// Doctor check outcomes. Warnings are printed but do not fail the command;
+// any failure makes `nyatictl doctor` exit non-zero.
+const (
+	doctorPass = iota
+	doctorWarn
+	doctorFail
+)
+
+// doctorCheck is one line of the doctor checklist: what was checked, how it
+// went, and (for warnings and failures) how to fix it.
+type doctorCheck struct {
+	Status int
+	Name   string
+	Detail string
+	Hint   string
+}
+
+// setupDoctorCommand registers the `doctor` subcommand, which inspects the
+// local environment for the common setup problems that otherwise surface as
+// obscure failures mid-deploy (missing known_hosts, unreadable keys, no
+// ssh-agent, a database behind on migrations).
+//
+// Parameters:
+//   - rootCmd: The root command to attach the doctor command to
+//   - version: Application version used when loading the config
+func setupDoctorCommand(rootCmd *cobra.Command, version string) {
+	var cfgFile string
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the local environment for common setup problems",
+		Long: `Doctor runs a series of local checks before you deploy: the config file
+loads, SSH keys referenced by hosts exist and are readable, known_hosts
+covers hosts with strict key checking, an ssh-agent is available, and the
+local database is accessible and up to date on migrations.
+
+Each check prints pass (✔), warn (⚠) or fail (✘) with a remediation hint.
+The command exits non-zero if any check fails.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cfgFile == "" {
+				cfgFile = "nyati.yaml"
+			}
+
+			checks := runDoctorChecks(cfgFile, version)
+
+			failed := 0
+			for _, check := range checks {
+				switch check.Status {
+				case doctorPass:
+					fmt.Printf("%s %s: %s\n", term.Green("✔"), check.Name, check.Detail)
+				case doctorWarn:
+					fmt.Printf("%s %s: %s\n", term.Yellow("⚠"), check.Name, check.Detail)
+				default:
+					failed++
+					fmt.Printf("%s %s: %s\n", term.Red("✘"), check.Name, check.Detail)
+				}
+				if check.Hint != "" {
+					fmt.Printf("    hint: %s\n", check.Hint)
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d check(s) failed", failed)
+			}
+			return nil
+		},
+	}
+
+	doctorCmd.Flags().StringVarP(&cfgFile, "config", "c", "", "Path to config file (default: nyati.yaml)")
+
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// runDoctorChecks runs every doctor check in display order. Host-level checks
+// are skipped when the config itself does not load, since there is nothing
+// trustworthy to inspect.
+func runDoctorChecks(cfgFile, version string) []doctorCheck {
+	var checks []doctorCheck
+
+	cfgCheck, cfg := checkConfigFile(cfgFile, version)
+	checks = append(checks, cfgCheck)
+	if cfg != nil {
+		checks = append(checks, checkHostKeys(cfg)...)
+		checks = append(checks, checkKnownHosts(cfg))
+	}
+	databasePath, err := ResolveDatabasePath("")
+	if err != nil {
+		databasePath = dbPath
+	}
+	checks = append(checks,
+		checkSSHAgent(os.Getenv("SSH_AUTH_SOCK")),
+		checkDatabase(databasePath),
+		checkMigrations(databasePath, migrationsDir),
+	)
+	return checks
+}
+
+// checkConfigFile verifies the config file exists and loads. The parsed
+// config is returned so host-level checks can reuse it; it is nil whenever
+// the check fails.
+func checkConfigFile(path, version string) (doctorCheck, *config.Config) {
+	check := doctorCheck{Name: "config file"}
+
+	if _, err := os.Stat(path); err != nil {
+		check.Status = doctorFail
+		check.Detail = fmt.Sprintf("%s not found", path)
+		check.Hint = "create a nyati.yaml in the project root, or point at one with --config"
+		return check, nil
+	}
+
+	cfg, err := config.LoadWithOptions(path, version, config.LoadOptions{AllowInventory: true})
+	if err != nil {
+		check.Status = doctorFail
+		check.Detail = fmt.Sprintf("%s does not load: %v", path, err)
+		check.Hint = "run `nyatictl lint` for a full validation report"
+		return check, nil
+	}
+
+	check.Status = doctorPass
+	check.Detail = fmt.Sprintf("%s loads (%d host(s), %d task(s))", path, len(cfg.Hosts), len(cfg.Tasks))
+	return check, cfg
+}
+
+// checkHostKeys verifies every private key referenced by a host exists and
+// is readable, and warns when a key is readable by group or others (OpenSSH
+// itself refuses such keys). Hosts that authenticate by password or agent
+// only are skipped. One check is returned per host with a key, sorted by
+// host name for stable output.
+func checkHostKeys(cfg *config.Config) []doctorCheck {
+	var names []string
+	for name, server := range cfg.Hosts {
+		if server.PrivateKey != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var checks []doctorCheck
+	for _, name := range names {
+		keyPath := expandUserPath(cfg.Hosts[name].PrivateKey)
+		check := doctorCheck{Name: fmt.Sprintf("SSH key (%s)", name)}
+
+		info, err := os.Stat(keyPath)
+		if err != nil {
+			check.Status = doctorFail
+			check.Detail = fmt.Sprintf("%s not found", keyPath)
+			check.Hint = "fix the private_key path in the config, or generate the key with ssh-keygen"
+			checks = append(checks, check)
+			continue
+		}
+		if f, err := os.Open(keyPath); err != nil {
+			check.Status = doctorFail
+			check.Detail = fmt.Sprintf("%s is not readable: %v", keyPath, err)
+			check.Hint = fmt.Sprintf("chown the key to the current user or chmod 600 %s", keyPath)
+			checks = append(checks, check)
+			continue
+		} else {
+			f.Close()
+		}
+		if info.Mode().Perm()&0o077 != 0 {
+			check.Status = doctorWarn
+			check.Detail = fmt.Sprintf("%s is readable by group or others (%04o)", keyPath, info.Mode().Perm())
+			check.Hint = fmt.Sprintf("chmod 600 %s", keyPath)
+			checks = append(checks, check)
+			continue
+		}
+
+		check.Status = doctorPass
+		check.Detail = fmt.Sprintf("%s is readable", keyPath)
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// checkKnownHosts verifies that every host with strict host key checking
+// (the default policy) has at least one existing known_hosts file to verify
+// against. Hosts using accept-new create the file on first connect, and
+// insecure-ignore skips verification entirely, so neither needs one.
+func checkKnownHosts(cfg *config.Config) doctorCheck {
+	check := doctorCheck{Name: "known_hosts"}
+
+	var uncovered []string
+	strictHosts := 0
+	for name, server := range cfg.Hosts {
+		if server.HostKeyPolicy != "" && server.HostKeyPolicy != "strict" {
+			continue
+		}
+		strictHosts++
+		found := false
+		for _, path := range ssh.KnownHostsFiles(server) {
+			if _, err := os.Stat(path); err == nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			uncovered = append(uncovered, name)
+		}
+	}
+	sort.Strings(uncovered)
+
+	if len(uncovered) > 0 {
+		check.Status = doctorFail
+		check.Detail = fmt.Sprintf("no known_hosts file exists for host(s) with strict checking: %s", strings.Join(uncovered, ", "))
+		check.Hint = "pre-seed it with `ssh-keyscan <host> >> ~/.ssh/known_hosts`, or set host_key_policy: accept-new"
+		return check
+	}
+
+	check.Status = doctorPass
+	if strictHosts == 0 {
+		check.Detail = "no hosts use strict host key checking"
+	} else {
+		check.Detail = fmt.Sprintf("known_hosts present for all %d strict host(s)", strictHosts)
+	}
+	return check
+}
+
+// checkSSHAgent reports whether an ssh-agent is reachable through the given
+// SSH_AUTH_SOCK value. A missing agent is a warning rather than a failure:
+// password and unencrypted-key auth work without one, but keys with a
+// passphrase will not.
+func checkSSHAgent(sock string) doctorCheck {
+	check := doctorCheck{Name: "ssh-agent"}
+
+	if sock == "" {
+		check.Status = doctorWarn
+		check.Detail = "SSH_AUTH_SOCK is not set"
+		check.Hint = "start one with `eval $(ssh-agent)` and `ssh-add` if your keys have passphrases"
+		return check
+	}
+	if _, err := os.Stat(sock); err != nil {
+		check.Status = doctorWarn
+		check.Detail = fmt.Sprintf("SSH_AUTH_SOCK points at %s, which does not exist", sock)
+		check.Hint = "the agent has gone away; restart it with `eval $(ssh-agent)`"
+		return check
+	}
+
+	check.Status = doctorPass
+	check.Detail = fmt.Sprintf("agent socket at %s", sock)
+	return check
+}
+
+// checkDatabase verifies the local database file is accessible. A database
+// that does not exist yet is a warning (it is created on first use); one
+// that exists but cannot be opened is a failure.
+func checkDatabase(path string) doctorCheck {
+	check := doctorCheck{Name: "database"}
+
+	if _, err := os.Stat(path); err != nil {
+		check.Status = doctorWarn
+		check.Detail = fmt.Sprintf("%s does not exist yet", path)
+		check.Hint = "it is created by `nyatictl serve` or `nyatictl db migrate`"
+		return check
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err == nil {
+		defer db.Close()
+		var version int
+		err = db.QueryRow("PRAGMA schema_version").Scan(&version)
+	}
+	if err != nil {
+		check.Status = doctorFail
+		check.Detail = fmt.Sprintf("%s cannot be opened: %v", path, err)
+		check.Hint = "check file permissions, or move the corrupt file aside and re-run `nyatictl db migrate`"
+		return check
+	}
+
+	check.Status = doctorPass
+	check.Detail = fmt.Sprintf("%s is accessible", path)
+	return check
+}
+
+// checkMigrations compares the migration files on disk against the
+// migrations table and warns when any are pending. A missing database or
+// missing migrations table counts every file as pending.
+func checkMigrations(databasePath, dir string) doctorCheck {
+	check := doctorCheck{Name: "migrations"}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		check.Status = doctorWarn
+		check.Detail = fmt.Sprintf("migrations directory %s not found", dir)
+		check.Hint = "run doctor from the project root so db/migrations is visible"
+		return check
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+			files = append(files, entry.Name())
+		}
+	}
+	if len(files) == 0 {
+		check.Status = doctorPass
+		check.Detail = fmt.Sprintf("no migration files in %s", dir)
+		return check
+	}
+
+	applied := make(map[string]bool)
+	if _, err := os.Stat(databasePath); err == nil {
+		db, err := sql.Open("sqlite3", databasePath)
+		if err == nil {
+			defer db.Close()
+			rows, err := db.Query("SELECT name FROM migrations")
+			if err == nil {
+				defer rows.Close()
+				for rows.Next() {
+					var name string
+					if rows.Scan(&name) == nil {
+						applied[name] = true
+					}
+				}
+			}
+		}
+	}
+
+	pending := 0
+	for _, name := range files {
+		if !applied[name] {
+			pending++
+		}
+	}
+	if pending > 0 {
+		check.Status = doctorWarn
+		check.Detail = fmt.Sprintf("%d of %d migration(s) pending", pending, len(files))
+		check.Hint = "apply them with `nyatictl db migrate`"
+		return check
+	}
+
+	check.Status = doctorPass
+	check.Detail = fmt.Sprintf("all %d migration(s) applied", len(files))
+	return check
+}
+
+// expandUserPath expands a leading ~/ in a key path to the user's home
+// directory, mirroring what the SSH layer does when it loads the key.
+func expandUserPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, path[2:])
+		}
+	}
+	return path
+}
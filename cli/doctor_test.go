@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+// doctorConfigYAML is a minimal loadable config for doctor tests.
+const doctorConfigYAML = `version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 127.0.0.1
+    username: deploy
+    password: secret
+    host_key_policy: insecure-ignore
+tasks:
+  - name: build
+    cmd: "true"
+`
+
+func TestCheckConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	check, cfg := checkConfigFile(filepath.Join(dir, "missing.yaml"), "0.1.2")
+	if check.Status != doctorFail || cfg != nil {
+		t.Errorf("missing file: status = %d, cfg = %v; want fail with nil config", check.Status, cfg)
+	}
+
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("not: a: valid: config\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	check, cfg = checkConfigFile(bad, "0.1.2")
+	if check.Status != doctorFail || cfg != nil {
+		t.Errorf("invalid file: status = %d, cfg = %v; want fail with nil config", check.Status, cfg)
+	}
+
+	good := filepath.Join(dir, "nyati.yaml")
+	if err := os.WriteFile(good, []byte(doctorConfigYAML), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	check, cfg = checkConfigFile(good, "0.1.2")
+	if check.Status != doctorPass || cfg == nil {
+		t.Errorf("valid file: status = %d (%s), cfg = %v; want pass with a config", check.Status, check.Detail, cfg)
+	}
+}
+
+func TestCheckHostKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	missing := filepath.Join(dir, "missing_key")
+	loose := filepath.Join(dir, "loose_key")
+	tight := filepath.Join(dir, "tight_key")
+	if err := os.WriteFile(loose, []byte("key"), 0644); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if err := os.WriteFile(tight, []byte("key"), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	cfg := &config.Config{Hosts: map[string]config.Host{
+		"absent":   {PrivateKey: missing},
+		"loose":    {PrivateKey: loose},
+		"tight":    {PrivateKey: tight},
+		"password": {Password: "secret"},
+	}}
+
+	checks := checkHostKeys(cfg)
+	if len(checks) != 3 {
+		t.Fatalf("got %d checks, want 3 (password-only host skipped): %+v", len(checks), checks)
+	}
+	// Sorted by host name: absent, loose, tight
+	if checks[0].Status != doctorFail {
+		t.Errorf("missing key: status = %d (%s), want fail", checks[0].Status, checks[0].Detail)
+	}
+	if checks[1].Status != doctorWarn || !strings.Contains(checks[1].Hint, "chmod 600") {
+		t.Errorf("loose key: status = %d, hint = %q; want a chmod warning", checks[1].Status, checks[1].Hint)
+	}
+	if checks[2].Status != doctorPass {
+		t.Errorf("tight key: status = %d (%s), want pass", checks[2].Status, checks[2].Detail)
+	}
+}
+
+func TestCheckKnownHosts(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "known_hosts")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatalf("failed to write known_hosts: %v", err)
+	}
+
+	covered := &config.Config{Hosts: map[string]config.Host{
+		"server1": {KnownHosts: existing},
+	}}
+	if check := checkKnownHosts(covered); check.Status != doctorPass {
+		t.Errorf("covered strict host: status = %d (%s), want pass", check.Status, check.Detail)
+	}
+
+	uncovered := &config.Config{Hosts: map[string]config.Host{
+		"server1": {KnownHosts: filepath.Join(dir, "absent")},
+	}}
+	check := checkKnownHosts(uncovered)
+	if check.Status != doctorFail || !strings.Contains(check.Detail, "server1") {
+		t.Errorf("uncovered strict host: status = %d (%s), want fail naming the host", check.Status, check.Detail)
+	}
+
+	relaxed := &config.Config{Hosts: map[string]config.Host{
+		"server1": {HostKeyPolicy: "insecure-ignore"},
+		"server2": {HostKeyPolicy: "accept-new", KnownHosts: filepath.Join(dir, "absent")},
+	}}
+	if check := checkKnownHosts(relaxed); check.Status != doctorPass {
+		t.Errorf("relaxed policies: status = %d (%s), want pass", check.Status, check.Detail)
+	}
+}
+
+func TestCheckSSHAgent(t *testing.T) {
+	if check := checkSSHAgent(""); check.Status != doctorWarn {
+		t.Errorf("unset SSH_AUTH_SOCK: status = %d, want warn", check.Status)
+	}
+
+	dir := t.TempDir()
+	if check := checkSSHAgent(filepath.Join(dir, "absent.sock")); check.Status != doctorWarn {
+		t.Errorf("dangling SSH_AUTH_SOCK: status = %d, want warn", check.Status)
+	}
+
+	sock := filepath.Join(dir, "agent.sock")
+	if err := os.WriteFile(sock, nil, 0600); err != nil {
+		t.Fatalf("failed to create socket stand-in: %v", err)
+	}
+	if check := checkSSHAgent(sock); check.Status != doctorPass {
+		t.Errorf("present SSH_AUTH_SOCK: status = %d (%s), want pass", check.Status, check.Detail)
+	}
+}
+
+func TestCheckDatabase(t *testing.T) {
+	dir := t.TempDir()
+
+	if check := checkDatabase(filepath.Join(dir, "absent.db")); check.Status != doctorWarn {
+		t.Errorf("missing database: status = %d, want warn", check.Status)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.db")
+	if err := os.WriteFile(corrupt, []byte("this is not sqlite"), 0644); err != nil {
+		t.Fatalf("failed to write corrupt file: %v", err)
+	}
+	if check := checkDatabase(corrupt); check.Status != doctorFail {
+		t.Errorf("corrupt database: status = %d (%s), want fail", check.Status, check.Detail)
+	}
+
+	valid := filepath.Join(dir, "valid.db")
+	db, err := sql.Open("sqlite3", valid)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if _, err := db.Exec("CREATE TABLE t (id INTEGER)"); err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+	db.Close()
+	if check := checkDatabase(valid); check.Status != doctorPass {
+		t.Errorf("valid database: status = %d (%s), want pass", check.Status, check.Detail)
+	}
+}
+
+func TestCheckMigrations(t *testing.T) {
+	dir := t.TempDir()
+	dbFile := filepath.Join(dir, "nyatictl.db")
+
+	if check := checkMigrations(dbFile, filepath.Join(dir, "absent")); check.Status != doctorWarn {
+		t.Errorf("missing migrations dir: status = %d, want warn", check.Status)
+	}
+
+	migDir := filepath.Join(dir, "migrations")
+	if err := os.MkdirAll(migDir, 0755); err != nil {
+		t.Fatalf("failed to create migrations dir: %v", err)
+	}
+	if check := checkMigrations(dbFile, migDir); check.Status != doctorPass {
+		t.Errorf("empty migrations dir: status = %d (%s), want pass", check.Status, check.Detail)
+	}
+
+	migName := "20260101000000_init.sql"
+	if err := os.WriteFile(filepath.Join(migDir, migName), []byte("-- UP\nCREATE TABLE t (id INTEGER);\n-- DOWN\nDROP TABLE t;\n"), 0644); err != nil {
+		t.Fatalf("failed to write migration: %v", err)
+	}
+
+	// No database yet: the migration counts as pending
+	check := checkMigrations(dbFile, migDir)
+	if check.Status != doctorWarn || !strings.Contains(check.Detail, "1 of 1") {
+		t.Errorf("pending migration: status = %d (%s), want a pending warning", check.Status, check.Detail)
+	}
+
+	// Record it as applied and the check passes
+	db, err := sql.Open("sqlite3", dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if _, err := db.Exec("CREATE TABLE migrations (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL UNIQUE, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)"); err != nil {
+		t.Fatalf("failed to create migrations table: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO migrations (name) VALUES (?)", migName); err != nil {
+		t.Fatalf("failed to record migration: %v", err)
+	}
+	db.Close()
+	if check := checkMigrations(dbFile, migDir); check.Status != doctorPass {
+		t.Errorf("applied migration: status = %d (%s), want pass", check.Status, check.Detail)
+	}
+}
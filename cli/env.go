@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/zechtz/nyatictl/env"
+	"gopkg.in/yaml.v3"
+)
+
+// setupEnvCommands registers the `env` command group for managing the
+// file-backed environment store from the CLI.
+//
+// Parameters:
+//   - rootCmd: The root command to attach the env commands to
+func setupEnvCommands(rootCmd *cobra.Command) {
+	envCmd := &cobra.Command{
+		Use:   "env",
+		Short: "Environment management commands",
+	}
+
+	var fromFile string
+	var envName string
+	var envFile string
+	var envKey string
+	var keyStdin bool
+
+	setManyCmd := &cobra.Command{
+		Use:   "set-many",
+		Short: "Set multiple variables from a file in one atomic write",
+		Long: `Set-many reads a YAML file containing a list of {key, value, is_secret}
+entries and applies them to an environment atomically: all validation errors
+are reported together, and nothing is written unless every entry is valid.
+Secrets require --env-key.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(fromFile)
+			if err != nil {
+				return fmt.Errorf("failed to read variables file: %v", err)
+			}
+
+			var items []env.BulkItem
+			if err := yaml.Unmarshal(data, &items); err != nil {
+				return fmt.Errorf("failed to parse variables file: %v", err)
+			}
+			if len(items) == 0 {
+				return fmt.Errorf("no variables found in %s", fromFile)
+			}
+
+			envFileData, err := env.LoadEnvironmentFile(envFile)
+			if err != nil {
+				return err
+			}
+
+			// Default to the store's current environment
+			targetName := envName
+			if targetName == "" {
+				targetName = envFileData.CurrentEnv
+			}
+
+			var target *env.Environment
+			for _, e := range envFileData.Environments {
+				if e.Name == targetName {
+					target = e
+					break
+				}
+			}
+			if target == nil {
+				return fmt.Errorf("environment '%s' not found", targetName)
+			}
+
+			// Secrets in the batch need the encryption key; plain batches
+			// must not prompt for one
+			for _, item := range items {
+				if item.IsSecret {
+					key, err := resolveEnvKey(envKey, keyStdin)
+					if err != nil {
+						return err
+					}
+					target.SetEncryptionKey(key)
+					break
+				}
+			}
+
+			if bulkErrs := target.BulkSet(items); len(bulkErrs) > 0 {
+				for _, e := range bulkErrs {
+					if e.Index >= 0 {
+						fmt.Printf("item %d (%s): %s\n", e.Index, e.Key, e.Message)
+					} else {
+						fmt.Printf("batch: %s\n", e.Message)
+					}
+				}
+				return fmt.Errorf("%d invalid item(s); nothing was applied", len(bulkErrs))
+			}
+
+			// Single file save for the whole batch
+			if err := env.SaveEnvironmentFile(envFileData, target.FilePath); err != nil {
+				return err
+			}
+
+			fmt.Printf("Set %d variable(s) in environment '%s'\n", len(items), targetName)
+			return nil
+		},
+	}
+
+	setManyCmd.Flags().StringVar(&fromFile, "from-file", "", "YAML file containing a list of {key, value, is_secret} entries (required)")
+	setManyCmd.Flags().StringVarP(&envName, "env", "e", "", "Environment to update (default: the current environment)")
+	setManyCmd.Flags().StringVar(&envFile, "env-file", env.DefaultEnvFile, "Path to environment file")
+	setManyCmd.Flags().StringVar(&envKey, "env-key", "", "Encryption key, required when the batch contains secrets")
+	setManyCmd.Flags().BoolVar(&keyStdin, "key-stdin", false, "Read the encryption key from the first line of stdin")
+	setManyCmd.MarkFlagRequired("from-file")
+
+	var encryptKey string
+	var encryptKeyStdin bool
+	var encryptAlgorithm string
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt <value>",
+		Short: "Encrypt a value with an environment encryption key",
+		Long: `Encrypt produces a ciphertext compatible with the environment store's
+secret handling, for scripting and debugging. The output can be verified
+with 'env decrypt' or stored alongside other secrets.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := env.SetEncryptionAlgorithm(encryptAlgorithm); err != nil {
+				return err
+			}
+			key, err := resolveEnvKey(encryptKey, encryptKeyStdin)
+			if err != nil {
+				return err
+			}
+			ciphertext, err := env.EncryptValue(args[0], key)
+			if err != nil {
+				return err
+			}
+			fmt.Println(ciphertext)
+			return nil
+		},
+	}
+	encryptCmd.Flags().StringVar(&encryptKey, "env-key", "", "Encryption key (falls back to --key-stdin, NYATI_ENV_KEY or a prompt)")
+	encryptCmd.Flags().BoolVar(&encryptKeyStdin, "key-stdin", false, "Read the encryption key from the first line of stdin")
+	encryptCmd.Flags().StringVar(&encryptAlgorithm, "algorithm", env.AlgorithmAESGCM,
+		"Encryption algorithm: aes256-gcm or chacha20-poly1305")
+
+	var decryptKey string
+	var decryptKeyStdin bool
+	decryptCmd := &cobra.Command{
+		Use:   "decrypt <ciphertext>",
+		Short: "Decrypt a ciphertext produced by the environment store",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := resolveEnvKey(decryptKey, decryptKeyStdin)
+			if err != nil {
+				return err
+			}
+			plaintext, err := env.DecryptValue(args[0], key)
+			if err != nil {
+				return err
+			}
+			fmt.Println(plaintext)
+			return nil
+		},
+	}
+	decryptCmd.Flags().StringVar(&decryptKey, "env-key", "", "Encryption key (falls back to --key-stdin, NYATI_ENV_KEY or a prompt)")
+	decryptCmd.Flags().BoolVar(&decryptKeyStdin, "key-stdin", false, "Read the encryption key from the first line of stdin")
+
+	var varsEnvName string
+	var varsEnvFile string
+	var varsEnvKey string
+	var varsKeyStdin bool
+	var varsReveal string
+	var varsSecrets bool
+	varsCmd := &cobra.Command{
+		Use:   "vars",
+		Short: "List variables in an environment",
+		Long: `Vars lists the variables of an environment from the environment store.
+Secret values stay hidden unless --secrets is given, which requires
+--env-key. With --reveal partial only the first and last two characters
+of each secret are shown, keeping the output safe to copy into chats
+and terminals with logging.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if varsReveal != "partial" && varsReveal != "full" {
+				return fmt.Errorf("--reveal must be 'partial' or 'full', got %q", varsReveal)
+			}
+
+			envFileData, err := env.LoadEnvironmentFile(varsEnvFile)
+			if err != nil {
+				return err
+			}
+
+			// Default to the store's current environment
+			targetName := varsEnvName
+			if targetName == "" {
+				targetName = envFileData.CurrentEnv
+			}
+
+			var target *env.Environment
+			for _, e := range envFileData.Environments {
+				if e.Name == targetName {
+					target = e
+					break
+				}
+			}
+			if target == nil {
+				return fmt.Errorf("environment '%s' not found", targetName)
+			}
+
+			if varsSecrets && len(target.Secrets) > 0 {
+				key, err := resolveEnvKey(varsEnvKey, varsKeyStdin)
+				if err != nil {
+					return err
+				}
+				target.SetEncryptionKey(key)
+			}
+
+			for _, k := range sortedKeys(target.Variables) {
+				fmt.Printf("%s=%s\n", k, target.Variables[k])
+			}
+			for _, k := range sortedKeys(target.Secrets) {
+				value := "<encrypted>"
+				if varsSecrets {
+					plain, _, err := target.Get(k)
+					switch {
+					case err != nil:
+						value = fmt.Sprintf("<error: %v>", err)
+					case varsReveal == "partial":
+						value = env.MaskValue(plain)
+					default:
+						value = plain
+					}
+				}
+				fmt.Printf("%s=%s (secret)\n", k, value)
+			}
+			return nil
+		},
+	}
+	varsCmd.Flags().StringVarP(&varsEnvName, "env", "e", "", "Environment to list (default: the current environment)")
+	varsCmd.Flags().StringVar(&varsEnvFile, "env-file", env.DefaultEnvFile, "Path to environment file")
+	varsCmd.Flags().StringVar(&varsEnvKey, "env-key", "", "Encryption key, required with --secrets")
+	varsCmd.Flags().BoolVar(&varsKeyStdin, "key-stdin", false, "Read the encryption key from the first line of stdin")
+	varsCmd.Flags().BoolVar(&varsSecrets, "secrets", false, "Decrypt and display secret values")
+	varsCmd.Flags().StringVar(&varsReveal, "reveal", "partial", "How much of each secret to show: partial or full")
+
+	envCmd.AddCommand(setManyCmd)
+	envCmd.AddCommand(encryptCmd)
+	envCmd.AddCommand(decryptCmd)
+	envCmd.AddCommand(varsCmd)
+	rootCmd.AddCommand(envCmd)
+}
+
+// sortedKeys returns the map's keys in sorted order for stable listing output.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
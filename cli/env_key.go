@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/mattn/go-isatty"
+)
+
+// envKeyVar is the environment variable the env subcommands read the
+// encryption key from when no flag supplies it.
+const envKeyVar = "NYATI_ENV_KEY"
+
+// envKeyStdin is os.Stdin unless a test substitutes it.
+var envKeyStdin io.Reader = os.Stdin
+
+// envKeyInteractive reports whether prompting for a key is possible.
+// Overridable so tests can exercise the non-interactive failure path.
+var envKeyInteractive = func() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// resolveEnvKey returns the encryption key for an env subcommand. All env
+// commands share this resolution order so scripts behave the same across
+// set-many, encrypt, decrypt and vars:
+//
+//  1. the command's --env-key flag value
+//  2. the first line of stdin, when --key-stdin is set
+//  3. the NYATI_ENV_KEY environment variable
+//  4. an interactive masked prompt, only when stdin is a terminal
+//
+// Parameters:
+//   - flagKey: the value of the command's --env-key flag, possibly empty
+//   - keyStdin: whether --key-stdin was set
+//
+// Returns:
+//   - string: the resolved key
+//   - error: if no source yields a key, with the available options named
+func resolveEnvKey(flagKey string, keyStdin bool) (string, error) {
+	if flagKey != "" {
+		return flagKey, nil
+	}
+
+	if keyStdin {
+		line, err := bufio.NewReader(envKeyStdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read key from stdin: %v", err)
+		}
+		key := strings.TrimRight(line, "\r\n")
+		if key == "" {
+			return "", fmt.Errorf("--key-stdin was set but stdin provided no key")
+		}
+		return key, nil
+	}
+
+	if key := os.Getenv(envKeyVar); key != "" {
+		return key, nil
+	}
+
+	if envKeyInteractive() {
+		prompt := promptui.Prompt{Label: "Encryption key", Mask: '*'}
+		key, err := prompt.Run()
+		if err != nil {
+			return "", fmt.Errorf("failed to read key: %v", err)
+		}
+		return key, nil
+	}
+
+	return "", fmt.Errorf("no encryption key provided; use --env-key, --key-stdin or %s", envKeyVar)
+}
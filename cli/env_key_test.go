@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+// withNonInteractiveKeyResolution routes stdin to the given content and
+// disables the interactive prompt for the duration of a test.
+func withNonInteractiveKeyResolution(t *testing.T, stdin string) {
+	t.Helper()
+
+	origStdin := envKeyStdin
+	origInteractive := envKeyInteractive
+	envKeyStdin = strings.NewReader(stdin)
+	envKeyInteractive = func() bool { return false }
+	t.Cleanup(func() {
+		envKeyStdin = origStdin
+		envKeyInteractive = origInteractive
+	})
+}
+
+func TestResolveEnvKeyFlagWinsOverEverything(t *testing.T) {
+	withNonInteractiveKeyResolution(t, "stdin-key\n")
+	t.Setenv(envKeyVar, "env-key")
+
+	key, err := resolveEnvKey("flag-key", true)
+	if err != nil {
+		t.Fatalf("resolveEnvKey failed: %v", err)
+	}
+	if key != "flag-key" {
+		t.Errorf("key = %q, want the flag value to win", key)
+	}
+}
+
+func TestResolveEnvKeyFromStdin(t *testing.T) {
+	withNonInteractiveKeyResolution(t, "stdin-key\nleftover input\n")
+	t.Setenv(envKeyVar, "env-key")
+
+	key, err := resolveEnvKey("", true)
+	if err != nil {
+		t.Fatalf("resolveEnvKey failed: %v", err)
+	}
+	if key != "stdin-key" {
+		t.Errorf("key = %q, want only the first stdin line", key)
+	}
+
+	// A key without a trailing newline still works
+	withNonInteractiveKeyResolution(t, "bare-key")
+	if key, err = resolveEnvKey("", true); err != nil || key != "bare-key" {
+		t.Errorf("resolveEnvKey without newline = (%q, %v), want bare-key", key, err)
+	}
+
+	// Empty stdin is an explicit error, not an empty key
+	withNonInteractiveKeyResolution(t, "")
+	if _, err := resolveEnvKey("", true); err == nil {
+		t.Error("an empty stdin must fail rather than yield an empty key")
+	}
+}
+
+func TestResolveEnvKeyFromEnvironment(t *testing.T) {
+	withNonInteractiveKeyResolution(t, "")
+	t.Setenv(envKeyVar, "env-key")
+
+	key, err := resolveEnvKey("", false)
+	if err != nil {
+		t.Fatalf("resolveEnvKey failed: %v", err)
+	}
+	if key != "env-key" {
+		t.Errorf("key = %q, want the %s value", key, envKeyVar)
+	}
+}
+
+func TestResolveEnvKeyNonInteractiveWithoutSource(t *testing.T) {
+	withNonInteractiveKeyResolution(t, "")
+	t.Setenv(envKeyVar, "")
+
+	_, err := resolveEnvKey("", false)
+	if err == nil || !strings.Contains(err.Error(), "--key-stdin") {
+		t.Errorf("err = %v, want a message naming the non-interactive options", err)
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zechtz/nyatictl/history"
+)
+
+// setupHistoryCommand registers the `history` command group for working with
+// the deployment history in the local database.
+//
+// Parameters:
+//   - rootCmd: The root command to attach the history commands to
+func setupHistoryCommand(rootCmd *cobra.Command) {
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Deployment history commands",
+	}
+
+	var dbPath string
+	var fromDate string
+	var toDate string
+	var format string
+	var outFile string
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export deployment history as CSV or JSON",
+		Long: `Export streams the recorded deployment history from the local database
+in CSV or JSON, with RFC3339 UTC timestamps. Use --from/--to to limit the
+range (inclusive days) and --output to write to a file instead of stdout.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := history.Options{Format: format}
+			var err error
+			if fromDate != "" {
+				if opts.From, err = history.ParseDay(fromDate); err != nil {
+					return err
+				}
+			}
+			if toDate != "" {
+				if opts.To, err = history.ParseDay(toDate); err != nil {
+					return err
+				}
+			}
+
+			// Default to the same database the web server uses; a missing
+			// file is an error, never silently created
+			path, err := ResolveDatabasePath(dbPath)
+			if err != nil {
+				return err
+			}
+			database, err := OpenDatabase(path, false)
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			out := os.Stdout
+			if outFile != "" {
+				f, err := os.Create(outFile)
+				if err != nil {
+					return fmt.Errorf("failed to create output file: %v", err)
+				}
+				defer f.Close()
+				out = f
+			}
+
+			return history.Export(database, opts, out)
+		},
+	}
+
+	exportCmd.Flags().StringVar(&dbPath, "db", "", "Path to the deployments database (default: the configured NYATI_DB_PATH)")
+	exportCmd.Flags().StringVar(&fromDate, "from", "", "Inclusive start day, YYYY-MM-DD")
+	exportCmd.Flags().StringVar(&toDate, "to", "", "Inclusive end day, YYYY-MM-DD")
+	exportCmd.Flags().StringVar(&format, "format", history.FormatCSV, "Export format: csv or json")
+	exportCmd.Flags().StringVar(&outFile, "output", "", "Write to this file instead of stdout")
+
+	historyCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/gitsource"
+)
+
+// setupImportCommand registers the `import` command group. Its only member
+// today is `import git`, which fetches a config file from a repository,
+// validates it and writes it locally — the same fetch-and-validate path the
+// web server's /api/configs/import-git endpoint uses.
+//
+// Parameters:
+//   - rootCmd: The root command to attach the import command to
+//   - version: The application version, used for config compatibility checks
+func setupImportCommand(rootCmd *cobra.Command, version string) {
+	var ref string
+	var path string
+	var output string
+	var tokenEnv string
+	var sshKeyFile string
+
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import configuration from external sources",
+	}
+
+	gitCmd := &cobra.Command{
+		Use:   "git <clone-url>",
+		Short: "Fetch and validate a nyati.yaml from a Git repository",
+		Long: `Import git shallow-clones a repository, reads the config file at the given
+in-repo path, validates it, and writes it to the output path. Private
+repositories are supported with a token (--token-from-env) for HTTPS
+remotes or a private key file (--ssh-key-file) for SSH remotes.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoURL := args[0]
+
+			var cred gitsource.Credential
+			if tokenEnv != "" {
+				cred.Token = os.Getenv(tokenEnv)
+				if cred.Token == "" {
+					return fmt.Errorf("--token-from-env: %q is not set in the environment", tokenEnv)
+				}
+			}
+			if sshKeyFile != "" {
+				key, err := os.ReadFile(sshKeyFile)
+				if err != nil {
+					return fmt.Errorf("failed to read SSH key: %v", err)
+				}
+				cred.SSHKey = string(key)
+			}
+
+			file, err := gitsource.Fetch(repoURL, ref, path, cred)
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				output = filepath.Base(path)
+			}
+			if _, err := os.Stat(output); err == nil {
+				return fmt.Errorf("%s already exists; pass --output to write elsewhere", output)
+			}
+			if err := os.WriteFile(output, file.Content, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %v", output, err)
+			}
+
+			// Validate the written file exactly the way deploy would load it;
+			// an invalid config is removed again
+			if _, err := config.LoadWithOptions(output, version, config.LoadOptions{AllowInventory: true}); err != nil {
+				os.Remove(output)
+				return fmt.Errorf("fetched config is invalid: %v", err)
+			}
+
+			refLabel := ref
+			if refLabel == "" {
+				refLabel = "default branch"
+			}
+			fmt.Printf("✅ Imported %s from %s (%s, commit %s)\n",
+				output, repoURL, refLabel, shortImportCommit(file.Commit))
+			return nil
+		},
+	}
+
+	gitCmd.Flags().StringVar(&ref, "ref", "", "Branch or tag to fetch (default: the remote's default branch)")
+	gitCmd.Flags().StringVar(&path, "path", "nyati.yaml", "Path of the config file inside the repository")
+	gitCmd.Flags().StringVar(&output, "output", "", "Where to write the fetched config (default: the file's base name)")
+	gitCmd.Flags().StringVar(&tokenEnv, "token-from-env", "", "Environment variable holding an access token for HTTPS remotes")
+	gitCmd.Flags().StringVar(&sshKeyFile, "ssh-key-file", "", "Private key file for SSH remotes")
+
+	importCmd.AddCommand(gitCmd)
+	rootCmd.AddCommand(importCmd)
+}
+
+// shortImportCommit abbreviates a commit hash for the success message.
+func shortImportCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return strings.TrimSpace(commit)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/lint"
+	"github.com/zechtz/nyatictl/term"
+)
+
+// setupLintCommand registers the `lint` subcommand, which checks a config for
+// best-practice smells beyond hard validation.
+//
+// Parameters:
+//   - rootCmd: The root command to attach the lint command to
+//   - version: Application version used when loading the config
+func setupLintCommand(rootCmd *cobra.Command, version string) {
+	var cfgFile string
+	var failOn string
+	var applyFixes bool
+	var strict bool
+
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check a config for best-practice issues",
+		Long: `Lint checks a config file for smells that validation does not reject:
+missing task messages, plaintext passwords, unquoted variables, and more.
+Each finding has a rule ID and severity; --fail-on controls which severity
+fails the command for CI use, and --fix applies safe automatic repairs.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if failOn != "warning" && failOn != "error" {
+				return fmt.Errorf("invalid --fail-on value '%s'; expected 'warning' or 'error'", failOn)
+			}
+			if strict {
+				failOn = "warning"
+			}
+
+			if cfgFile == "" {
+				if _, err := os.Stat("nyati.yaml"); err == nil {
+					cfgFile = "nyati.yaml"
+				} else if _, err := os.Stat("nyati.yml"); err == nil {
+					cfgFile = "nyati.yml"
+				} else {
+					return fmt.Errorf("no config file found; expected nyati.yaml or nyati.yml in current directory")
+				}
+			}
+
+			cfg, err := config.LoadWithOptions(cfgFile, version, config.LoadOptions{AllowInventory: true})
+			if err != nil {
+				return err
+			}
+
+			if applyFixes {
+				content, err := os.ReadFile(cfgFile)
+				if err != nil {
+					return fmt.Errorf("failed to read config for fixing: %v", err)
+				}
+				fixed, n := lint.FixContent(string(content))
+				if n > 0 {
+					if err := os.WriteFile(cfgFile, []byte(fixed), 0644); err != nil {
+						return fmt.Errorf("failed to write fixed config: %v", err)
+					}
+					fmt.Printf("Applied %d automatic fix(es) to %s\n", n, cfgFile)
+
+					// Re-load so findings reflect the fixed file
+					cfg, err = config.LoadWithOptions(cfgFile, version, config.LoadOptions{AllowInventory: true})
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			findings := lint.Run(cfg)
+			if len(findings) == 0 {
+				fmt.Println(term.Green("✔ No issues found"))
+				return nil
+			}
+
+			warnings, errors := 0, 0
+			for _, f := range findings {
+				label := term.Yellow(string(f.Severity))
+				if f.Severity == lint.SeverityError {
+					label = term.Red(string(f.Severity))
+					errors++
+				} else {
+					warnings++
+				}
+				suffix := ""
+				if f.Fixable {
+					suffix = " (fixable with --fix)"
+				}
+				fmt.Printf("%s %s %s: %s%s\n", f.RuleID, label, f.Location, f.Message, suffix)
+			}
+			fmt.Printf("\n%d issue(s): %d error(s), %d warning(s)\n", len(findings), errors, warnings)
+
+			if errors > 0 || (failOn == "warning" && warnings > 0) {
+				return fmt.Errorf("lint failed with --fail-on=%s", failOn)
+			}
+			return nil
+		},
+	}
+
+	lintCmd.Flags().StringVarP(&cfgFile, "config", "c", "", "Path to config file (default: nyati.yaml or nyati.yml in current directory)")
+	lintCmd.Flags().StringVar(&failOn, "fail-on", "error", "Minimum severity that fails the command ('warning' or 'error')")
+	lintCmd.Flags().BoolVar(&applyFixes, "fix", false, "Apply safe automatic fixes before linting")
+	lintCmd.Flags().BoolVar(&strict, "strict", false, "Fail on warnings too (shorthand for --fail-on=warning)")
+
+	rootCmd.AddCommand(lintCmd)
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -15,11 +16,27 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Defaults for the db commands; both can be overridden per invocation with
+// the --db and --migrations-dir flags, and the database path additionally
+// honors the configured NYATI_DB_PATH.
 const (
 	dbPath        = "./nyatictl.db"
 	migrationsDir = "./db/migrations"
 )
 
+// openMigrationDB resolves the database path (explicit override first, then
+// the configured NYATI_DB_PATH) and opens it. Only `db migrate` (and
+// commands run with --create-db) may create a missing database file;
+// everything else fails instead of leaving an empty nyatictl.db in whatever
+// directory it happened to run from.
+func openMigrationDB(dbOverride string, createIfMissing bool) (*sql.DB, error) {
+	path, err := ResolveDatabasePath(dbOverride)
+	if err != nil {
+		return nil, err
+	}
+	return OpenDatabase(path, createIfMissing)
+}
+
 // Migration represents a database migration file.
 type Migration struct {
 	Name string
@@ -36,13 +53,27 @@ func setupMigrationCommands(rootCmd *cobra.Command) {
 		Long:  "Commands for managing the NyatiCtl database schema",
 	}
 
+	// Creating a fresh database file is something only `db migrate` does by
+	// default; the other db commands require explicit confirmation so they
+	// never leave an empty nyatictl.db behind in the wrong directory
+	var createDB bool
+	dbCmd.PersistentFlags().BoolVar(&createDB, "create-db", false, "Create the database file if it does not exist")
+
+	// Every db command operates on the same database and migration directory;
+	// without --db the configured NYATI_DB_PATH applies, so the CLI works
+	// against the same file as a server started with a custom path
+	var dbOverride string
+	var dir string
+	dbCmd.PersistentFlags().StringVar(&dbOverride, "db", "", "Path to the database file (default: the configured NYATI_DB_PATH, else "+dbPath+")")
+	dbCmd.PersistentFlags().StringVar(&dir, "migrations-dir", migrationsDir, "Directory containing the SQL migration files")
+
 	// Add the migrate command
 	migrateCmd := &cobra.Command{
 		Use:   "migrate",
 		Short: "Run database migrations",
 		Long:  "Apply all pending database migrations in sequential order",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runMigrations()
+			return runMigrations(dbOverride, dir)
 		},
 	}
 
@@ -50,10 +81,10 @@ func setupMigrationCommands(rootCmd *cobra.Command) {
 	generateCmd := &cobra.Command{
 		Use:   "generate [name]",
 		Short: "Generate a new migration file",
-		Long:  "Create a new timestamped SQL migration file in the db/migrations directory",
+		Long:  "Create a new timestamped SQL migration file in the migrations directory",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return generateMigration(args[0])
+			return generateMigration(args[0], dir)
 		},
 	}
 
@@ -65,10 +96,10 @@ func setupMigrationCommands(rootCmd *cobra.Command) {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// If migration name is provided, roll back that specific migration
 			if len(args) > 0 {
-				return rollbackMigration(args[0])
+				return rollbackMigration(args[0], dbOverride, dir, createDB)
 			}
 			// Otherwise, roll back the most recent migration
-			return rollbackLastMigration()
+			return rollbackLastMigration(dbOverride, dir, createDB)
 		},
 	}
 
@@ -84,7 +115,7 @@ Examples:
   nyatictl db status            # Show basic migration status
   nyatictl db status --verbose  # Show status with SQL snippets`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return showMigrationStatus()
+			return showMigrationStatus(dbOverride, dir, createDB)
 		},
 	}
 
@@ -104,22 +135,21 @@ Examples:
 // tracks applied migrations in a migrations table,
 // and executes pending migrations in order.
 //
+// Parameters:
+//   - dbOverride: explicit database file; empty resolves the configured path
+//   - dir: directory containing the migration files
+//
 // Returns:
 //   - error: If any migration fails
-func runMigrations() error {
-	// Open database connection
-	db, err := sql.Open("sqlite3", dbPath)
+func runMigrations(dbOverride, dir string) error {
+	// Open database connection; migrate is the one command that may create a
+	// fresh database file (foreign keys are enabled through the connection URL)
+	db, err := openMigrationDB(dbOverride, true)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return err
 	}
 	defer db.Close()
 
-	// Enable foreign keys
-	_, err = db.Exec("PRAGMA foreign_keys = ON")
-	if err != nil {
-		return fmt.Errorf("failed to enable foreign keys: %v", err)
-	}
-
 	// Ensure migrations table exists
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS migrations (
@@ -154,7 +184,7 @@ func runMigrations() error {
 	}
 
 	// Read migration files
-	migrations, err := readMigrations()
+	migrations, err := readMigrations(dir)
 	if err != nil {
 		return fmt.Errorf("failed to read migrations: %v", err)
 	}
@@ -233,10 +263,11 @@ func runMigrations() error {
 //
 // Parameters:
 //   - name: The descriptive name for the migration (will be prefixed with timestamp)
+//   - dir: directory the migration file is written to
 //
 // Returns:
 //   - error: If file creation fails
-func generateMigration(name string) error {
+func generateMigration(name, dir string) error {
 	// Sanitize the name (replace spaces with underscores)
 	sanitizedName := strings.ReplaceAll(name, " ", "_")
 	sanitizedName = strings.ToLower(sanitizedName)
@@ -244,7 +275,7 @@ func generateMigration(name string) error {
 	// Create timestamp
 	timestamp := time.Now().Format("20060102150405")
 	filename := fmt.Sprintf("%s_%s.sql", timestamp, sanitizedName)
-	path := filepath.Join(migrationsDir, filename)
+	path := filepath.Join(dir, filename)
 
 	// Create migration content template with clear sections
 	content := `-- UP
@@ -263,7 +294,7 @@ func generateMigration(name string) error {
 `
 
 	// Ensure migrations directory exists
-	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create migrations directory: %v", err)
 	}
 
@@ -277,24 +308,27 @@ func generateMigration(name string) error {
 	return nil
 }
 
-// readMigrations reads all SQL migration files from the migrations directory.
+// readMigrations reads all SQL migration files from the given directory.
+//
+// Parameters:
+//   - dir: directory containing the migration files
 //
 // Returns:
 //   - []Migration: List of migrations
 //   - error: If directory reading fails
-func readMigrations() ([]Migration, error) {
+func readMigrations(dir string) ([]Migration, error) {
 	var migrations []Migration
 
 	// Check if migrations directory exists
-	if _, err := os.Stat(migrationsDir); os.IsNotExist(err) {
-		fmt.Printf("Migrations directory '%s' does not exist. Creating it...\n", migrationsDir)
-		if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		fmt.Printf("Migrations directory '%s' does not exist. Creating it...\n", dir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
 			return nil, fmt.Errorf("failed to create migrations directory: %v", err)
 		}
 		return migrations, nil // Return empty list (no migrations yet)
 	}
 
-	err := filepath.WalkDir(migrationsDir, func(path string, d fs.DirEntry, err error) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -484,24 +518,99 @@ func validateMigration(sql string) (bool, string) {
 	return true, ""
 }
 
-// RunMigrationsAPI provides a programmatic way to run migrations
+// migrationMutex is the advisory lock serializing the programmatic migration
+// entry points, so a remotely triggered run cannot collide with the startup
+// migration (or another remote trigger) on the same database.
+var migrationMutex sync.Mutex
+
+// RunMigrationsAPI provides a programmatic way to run migrations against the
+// configured database path and the default migrations directory.
 // This can be called from other parts of the application (like server startup)
 func RunMigrationsAPI() error {
-	return runMigrations()
+	migrationMutex.Lock()
+	defer migrationMutex.Unlock()
+	return runMigrations("", migrationsDir)
+}
+
+// MigrationState describes one migration file's standing against the database,
+// in the JSON shape the admin endpoints return.
+type MigrationState struct {
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt string `json:"applied_at,omitempty"` // From the migrations table, applied rows only
+}
+
+// MigrationStatusAPI reports every migration file's applied/pending standing
+// against the configured database path and the default migrations directory,
+// sorted by name. It takes the same advisory lock as RunMigrationsAPI so the
+// answer is never read mid-run.
+//
+// Returns:
+//   - []MigrationState: one entry per migration file
+//   - error: if the database or the migration files cannot be read
+func MigrationStatusAPI() ([]MigrationState, error) {
+	migrationMutex.Lock()
+	defer migrationMutex.Unlock()
+
+	db, err := openMigrationDB("", false)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// The migrations table may not exist on a database that predates the
+	// migration system; treat that the same as nothing applied
+	applied := make(map[string]string) // name -> applied_at
+	rows, err := db.Query("SELECT name, applied_at FROM migrations")
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var name, appliedAt string
+			if err := rows.Scan(&name, &appliedAt); err != nil {
+				return nil, fmt.Errorf("failed to scan migration: %v", err)
+			}
+			applied[name] = appliedAt
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error during migration row iteration: %v", err)
+		}
+	}
+
+	migrations, err := readMigrations(migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Name < migrations[j].Name
+	})
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, migration := range migrations {
+		appliedAt, ok := applied[migration.Name]
+		states = append(states, MigrationState{
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return states, nil
 }
 
 // rollbackMigration rolls back a specific migration.
 //
 // Parameters:
 //   - migrationName: The name of the migration to roll back
+//   - dbOverride: explicit database file; empty resolves the configured path
+//   - dir: directory containing the migration files
+//   - createDB: Allow creating the database file if it does not exist
 //
 // Returns:
 //   - error: If rollback fails
-func rollbackMigration(migrationName string) error {
+func rollbackMigration(migrationName, dbOverride, dir string, createDB bool) error {
 	// Open database connection
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := openMigrationDB(dbOverride, createDB)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return err
 	}
 	defer db.Close()
 
@@ -516,7 +625,7 @@ func rollbackMigration(migrationName string) error {
 	}
 
 	// Read the migration file to get the DOWN section
-	migrations, err := readMigrations()
+	migrations, err := readMigrations(dir)
 	if err != nil {
 		return fmt.Errorf("failed to read migrations: %v", err)
 	}
@@ -578,13 +687,18 @@ func rollbackMigration(migrationName string) error {
 
 // rollbackLastMigration rolls back the most recently applied migration.
 //
+// Parameters:
+//   - dbOverride: explicit database file; empty resolves the configured path
+//   - dir: directory containing the migration files
+//   - createDB: Allow creating the database file if it does not exist
+//
 // Returns:
 //   - error: If rollback fails
-func rollbackLastMigration() error {
+func rollbackLastMigration(dbOverride, dir string, createDB bool) error {
 	// Open database connection
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := openMigrationDB(dbOverride, createDB)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return err
 	}
 	defer db.Close()
 
@@ -599,18 +713,23 @@ func rollbackLastMigration() error {
 	}
 
 	// Roll back the migration
-	return rollbackMigration(migrationName)
+	return rollbackMigration(migrationName, dbOverride, dir, createDB)
 }
 
 // showMigrationStatus displays the status of all migrations with SQL snippets.
 //
+// Parameters:
+//   - dbOverride: explicit database file; empty resolves the configured path
+//   - dir: directory containing the migration files
+//   - createDB: Allow creating the database file if it does not exist
+//
 // Returns:
 //   - error: If checking status fails
-func showMigrationStatus() error {
+func showMigrationStatus(dbOverride, dir string, createDB bool) error {
 	// Open database connection
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := openMigrationDB(dbOverride, createDB)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return err
 	}
 	defer db.Close()
 
@@ -648,7 +767,7 @@ func showMigrationStatus() error {
 	}
 
 	// Read migration files
-	migrations, err := readMigrations()
+	migrations, err := readMigrations(dir)
 	if err != nil {
 		return fmt.Errorf("failed to read migrations: %v", err)
 	}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestMigration drops a migration file with UP and DOWN sections into dir.
+func writeTestMigration(t *testing.T, dir, name, up, down string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create migrations dir: %v", err)
+	}
+	content := "-- UP\n" + up + "\n\n-- DOWN\n" + down + "\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write migration file: %v", err)
+	}
+}
+
+// appliedMigrations returns the names recorded in the migrations table of the
+// database at path.
+func appliedMigrations(t *testing.T, path string) []string {
+	t.Helper()
+
+	db, err := OpenDatabase(path, false)
+	if err != nil {
+		t.Fatalf("failed to open database %s: %v", path, err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT name FROM migrations ORDER BY id")
+	if err != nil {
+		t.Fatalf("failed to query migrations table: %v", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("failed to scan migration name: %v", err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestRunMigrationsHonorsOverriddenPaths(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schema")
+	writeTestMigration(t, dir, "20240101000000_create_widgets.sql",
+		"CREATE TABLE widgets (id INTEGER PRIMARY KEY);",
+		"DROP TABLE widgets;")
+
+	dbFile := filepath.Join(t.TempDir(), "custom.db")
+	if err := runMigrations(dbFile, dir); err != nil {
+		t.Fatalf("runMigrations against overridden paths failed: %v", err)
+	}
+
+	// The migration landed in the overridden database file
+	names := appliedMigrations(t, dbFile)
+	if len(names) != 1 || names[0] != "20240101000000_create_widgets.sql" {
+		t.Errorf("applied migrations = %v, want the single test migration", names)
+	}
+
+	db, err := OpenDatabase(dbFile, false)
+	if err != nil {
+		t.Fatalf("failed to reopen overridden database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO widgets (id) VALUES (1)"); err != nil {
+		t.Errorf("widgets table missing from overridden database: %v", err)
+	}
+
+	// The default location was left untouched
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("default database %s was touched: stat err = %v", dbPath, err)
+	}
+}
+
+func TestRollbackLastMigrationHonorsOverriddenPaths(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schema")
+	writeTestMigration(t, dir, "20240101000000_create_widgets.sql",
+		"CREATE TABLE widgets (id INTEGER PRIMARY KEY);",
+		"DROP TABLE widgets;")
+
+	dbFile := filepath.Join(t.TempDir(), "custom.db")
+	if err := runMigrations(dbFile, dir); err != nil {
+		t.Fatalf("runMigrations failed: %v", err)
+	}
+
+	if err := rollbackLastMigration(dbFile, dir, false); err != nil {
+		t.Fatalf("rollbackLastMigration against overridden paths failed: %v", err)
+	}
+
+	if names := appliedMigrations(t, dbFile); len(names) != 0 {
+		t.Errorf("applied migrations after rollback = %v, want none", names)
+	}
+
+	db, err := OpenDatabase(dbFile, false)
+	if err != nil {
+		t.Fatalf("failed to reopen overridden database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO widgets (id) VALUES (1)"); err == nil {
+		t.Error("widgets table still present after rollback")
+	}
+}
+
+func TestRollbackRefusesToCreateMissingDatabase(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schema")
+	writeTestMigration(t, dir, "20240101000000_create_widgets.sql",
+		"CREATE TABLE widgets (id INTEGER PRIMARY KEY);",
+		"DROP TABLE widgets;")
+
+	dbFile := filepath.Join(t.TempDir(), "absent.db")
+	if err := rollbackLastMigration(dbFile, dir, false); err == nil {
+		t.Fatal("rollbackLastMigration created a missing database")
+	}
+	if _, err := os.Stat(dbFile); !os.IsNotExist(err) {
+		t.Errorf("database file was created as a side effect: stat err = %v", err)
+	}
+}
+
+func TestGenerateMigrationHonorsOverriddenDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schema")
+
+	if err := generateMigration("add widget color", dir); err != nil {
+		t.Fatalf("generateMigration into overridden dir failed: %v", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*_add_widget_color.sql"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("generated files = %v (err %v), want exactly one in the overridden dir", matches, err)
+	}
+
+	content, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatalf("failed to read generated migration: %v", err)
+	}
+	for _, marker := range []string{"-- UP", "-- DOWN"} {
+		if !strings.Contains(string(content), marker) {
+			t.Errorf("generated template is missing the %q marker", marker)
+		}
+	}
+}
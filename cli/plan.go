@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+	"github.com/zechtz/nyatictl/term"
+)
+
+// taskDecision records whether a task is part of the execution plan and the
+// human-readable reason, for the --dry-run/--explain output.
+type taskDecision struct {
+	Task     config.Task
+	Included bool
+	Reason   string
+}
+
+// selectTasks applies the task selection rules and returns the tasks that
+// would run, in execution order. With requested task names only those tasks
+// and their dependency closures are selected; otherwise every task runs
+// except lib tasks, which need IncludeLib. With Only set, the requested
+// tasks run exactly as named, without their dependency closures, which is
+// useful when iterating on a single task's command. Excluded tasks are
+// dropped from the final plan.
+//
+// Parameters:
+//   - cfg: the loaded configuration
+//   - opts: task selection switches
+//
+// Returns:
+//   - []config.Task: ordered execution plan
+//   - error: if a named task is unknown or dependencies are cyclic
+func selectTasks(cfg *config.Config, opts RunOptions) ([]config.Task, error) {
+	requested := opts.Tasks
+	if opts.TaskName != "" {
+		requested = append([]string{opts.TaskName}, requested...)
+	}
+
+	var plan []config.Task
+	var err error
+	if opts.Only {
+		// Run exactly the named tasks, skipping dependency resolution;
+		// warn so a failing run is traceable to missing prerequisites
+		if len(requested) == 0 {
+			return nil, fmt.Errorf("--only requires a task selection (--task)")
+		}
+		if err := ValidateTaskNames(cfg, requested); err != nil {
+			return nil, err
+		}
+		byName := make(map[string]config.Task, len(cfg.Tasks))
+		for _, task := range cfg.Tasks {
+			byName[task.Name] = task
+		}
+		seen := make(map[string]bool)
+		for _, name := range requested {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			task := byName[name]
+			if len(task.DependsOn) > 0 {
+				logger.Warn(fmt.Sprintf("Task '%s' runs without its prerequisites (%s); --only skips dependencies", task.Name, strings.Join(task.DependsOn, ", ")))
+			}
+			plan = append(plan, task)
+		}
+	} else if len(requested) > 0 {
+		// Run only the requested tasks and their dependency closures
+		seen := make(map[string]bool)
+		var union []config.Task
+		for _, name := range requested {
+			deps, err := getTaskWithDependencies(cfg.Tasks, name)
+			if err != nil {
+				return nil, err
+			}
+			for _, task := range deps {
+				if !seen[task.Name] {
+					seen[task.Name] = true
+					union = append(union, task)
+				}
+			}
+		}
+		plan, err = topologicalSort(union)
+	} else {
+		// Run all tasks, optionally excluding lib tasks
+		var filteredTasks []config.Task
+		for _, task := range cfg.Tasks {
+			if task.Lib && !opts.IncludeLib {
+				continue
+			}
+			filteredTasks = append(filteredTasks, task)
+		}
+
+		// Sort tasks by dependency order
+		plan, err = topologicalSort(filteredTasks)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop excluded tasks from the plan; unknown names are an error so typos
+	// don't silently run everything
+	if len(opts.ExcludeTasks) > 0 {
+		if err := ValidateTaskNames(cfg, opts.ExcludeTasks); err != nil {
+			return nil, err
+		}
+		excluded := make(map[string]bool, len(opts.ExcludeTasks))
+		for _, name := range opts.ExcludeTasks {
+			excluded[name] = true
+		}
+		kept := plan[:0]
+		for _, task := range plan {
+			if !excluded[task.Name] {
+				kept = append(kept, task)
+			}
+		}
+		plan = kept
+	}
+	return plan, nil
+}
+
+// ValidateTaskNames checks that every name refers to a task in the config,
+// so callers (such as the deploy API) can reject bad selections up front
+// instead of failing later inside a goroutine.
+//
+// Parameters:
+//   - cfg: the loaded configuration
+//   - names: task names to check
+//
+// Returns:
+//   - error: ErrTaskNotFound for the first unknown name, nil otherwise
+func ValidateTaskNames(cfg *config.Config, names []string) error {
+	known := make(map[string]bool, len(cfg.Tasks))
+	for _, task := range cfg.Tasks {
+		known[task.Name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return config.ErrTaskNotFound{Name: name}
+		}
+	}
+	return nil
+}
+
+// explainPlan annotates every task in the config with whether it is part of
+// the execution plan and why, in config order. The reasons mirror the rules
+// in selectTasks: a task runs because it was requested, because a selected
+// task depends on it, or by default; it is excluded because it is a lib task
+// without --include-lib or because a different task was requested.
+//
+// Parameters:
+//   - cfg: the loaded configuration
+//   - taskName: optional specific task to run
+//   - includeLib: whether tasks marked as lib are included
+//
+// Returns:
+//   - []taskDecision: one decision per configured task, in config order
+//   - error: if the task is unknown or dependencies are cyclic
+func explainPlan(cfg *config.Config, taskName string, includeLib bool) ([]taskDecision, error) {
+	selected, err := selectTasks(cfg, RunOptions{TaskName: taskName, IncludeLib: includeLib})
+	if err != nil {
+		return nil, err
+	}
+
+	reasons := make(map[string]string, len(selected))
+	for _, task := range selected {
+		switch {
+		case taskName == "" && task.Lib:
+			reasons[task.Name] = "lib task included via --include-lib"
+		case taskName == "":
+			reasons[task.Name] = "runs by default"
+		case task.Name == taskName:
+			reasons[task.Name] = "requested via --task"
+		default:
+			reasons[task.Name] = fmt.Sprintf("dependency of '%s'", dependentOf(selected, task.Name))
+		}
+	}
+
+	decisions := make([]taskDecision, 0, len(cfg.Tasks))
+	for _, task := range cfg.Tasks {
+		if reason, ok := reasons[task.Name]; ok {
+			decisions = append(decisions, taskDecision{Task: task, Included: true, Reason: reason})
+			continue
+		}
+		reason := fmt.Sprintf("not requested; only '%s' and its dependencies run", taskName)
+		if taskName == "" {
+			reason = "lib task; excluded without --include-lib"
+		}
+		decisions = append(decisions, taskDecision{Task: task, Included: false, Reason: reason})
+	}
+	return decisions, nil
+}
+
+// dependentOf returns the name of a selected task that directly depends on
+// name. Every selected task other than the requested one was pulled in
+// through some dependency edge, so a dependent always exists.
+func dependentOf(selected []config.Task, name string) string {
+	for _, task := range selected {
+		for _, dep := range task.DependsOn {
+			if dep == name {
+				return task.Name
+			}
+		}
+	}
+	return ""
+}
+
+// printPreludes lists the config- and host-level preludes that will be
+// prepended to every task command, so a dry run shows exactly what each
+// host executes. Tasks marked raw skip these.
+func printPreludes(w io.Writer, cfg *config.Config) {
+	writeScript := func(label, script string) {
+		fmt.Fprintf(w, "Prelude (%s):\n", label)
+		for _, line := range strings.Split(strings.TrimSpace(script), "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+	if cfg.Prelude != "" {
+		writeScript("all hosts", cfg.Prelude)
+	}
+	names := make([]string, 0, len(cfg.Hosts))
+	for name, host := range cfg.Hosts {
+		if host.Prelude != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeScript("host "+name, cfg.Hosts[name].Prelude)
+	}
+}
+
+// printPlan renders the execution plan to w without connecting to any host.
+// The plain listing shows the tasks that would run in order; with explain,
+// every configured task is listed with why it is included or excluded.
+//
+// Parameters:
+//   - w: destination writer (os.Stdout in CLI runs)
+//   - cfg: the loaded configuration
+//   - taskName: optional specific task to run
+//   - includeLib: whether tasks marked as lib are included
+//   - explain: annotate each task with its inclusion reason
+//
+// Returns:
+//   - error: if the task is unknown or dependencies are cyclic
+func printPlan(w io.Writer, cfg *config.Config, taskName string, includeLib bool, explain bool) error {
+	if !explain {
+		plan, err := selectTasks(cfg, RunOptions{TaskName: taskName, IncludeLib: includeLib})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Execution plan for %s (%d tasks):\n", cfg.AppName, len(plan))
+		printPreludes(w, cfg)
+		var total time.Duration
+		estimated := 0
+		for i, task := range plan {
+			if d := task.EstimateDuration(); d > 0 {
+				fmt.Fprintf(w, "  %d. %s (~%s)\n", i+1, task.Name, task.Estimate)
+				total += d
+				estimated++
+				continue
+			}
+			fmt.Fprintf(w, "  %d. %s\n", i+1, task.Name)
+		}
+		if estimated > 0 {
+			fmt.Fprintf(w, "Estimated total: %s (%d of %d tasks estimated)\n", total, estimated, len(plan))
+		}
+		return nil
+	}
+
+	decisions, err := explainPlan(cfg, taskName, includeLib)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Task selection for %s:\n", cfg.AppName)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, d := range decisions {
+		glyph := term.Green("✔")
+		if !d.Included {
+			glyph = term.Red("✘")
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", glyph, d.Task.Name, d.Reason)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/term"
+)
+
+// planTestConfig returns a config with a default task chain, a lib helper
+// and an independent task, covering each selection rule.
+func planTestConfig() *config.Config {
+	return &config.Config{
+		Version: "0.1.2",
+		AppName: "demo",
+		Tasks: []config.Task{
+			{Name: "build", Cmd: "make build"},
+			{Name: "deploy", Cmd: "make deploy", DependsOn: []string{"build"}},
+			{Name: "cleanup", Cmd: "make clean", Lib: true},
+			{Name: "notify", Cmd: "make notify"},
+		},
+	}
+}
+
+// decisionReasons maps task names to their explanation strings.
+func decisionReasons(t *testing.T, cfg *config.Config, taskName string, includeLib bool) map[string]taskDecision {
+	t.Helper()
+
+	decisions, err := explainPlan(cfg, taskName, includeLib)
+	if err != nil {
+		t.Fatalf("explainPlan failed: %v", err)
+	}
+	byName := make(map[string]taskDecision, len(decisions))
+	for _, d := range decisions {
+		byName[d.Task.Name] = d
+	}
+	return byName
+}
+
+func TestExplainPlanDefaultRun(t *testing.T) {
+	byName := decisionReasons(t, planTestConfig(), "", false)
+
+	for _, name := range []string{"build", "deploy", "notify"} {
+		if d := byName[name]; !d.Included || d.Reason != "runs by default" {
+			t.Errorf("%s = %+v, want included by default", name, d)
+		}
+	}
+	if d := byName["cleanup"]; d.Included || d.Reason != "lib task; excluded without --include-lib" {
+		t.Errorf("cleanup = %+v, want excluded as lib task", d)
+	}
+}
+
+func TestExplainPlanIncludeLib(t *testing.T) {
+	byName := decisionReasons(t, planTestConfig(), "", true)
+
+	if d := byName["cleanup"]; !d.Included || d.Reason != "lib task included via --include-lib" {
+		t.Errorf("cleanup = %+v, want included via --include-lib", d)
+	}
+}
+
+func TestExplainPlanRequestedTask(t *testing.T) {
+	byName := decisionReasons(t, planTestConfig(), "deploy", false)
+
+	if d := byName["deploy"]; !d.Included || d.Reason != "requested via --task" {
+		t.Errorf("deploy = %+v, want requested via --task", d)
+	}
+	if d := byName["build"]; !d.Included || d.Reason != "dependency of 'deploy'" {
+		t.Errorf("build = %+v, want dependency of 'deploy'", d)
+	}
+	for _, name := range []string{"cleanup", "notify"} {
+		d := byName[name]
+		if d.Included || d.Reason != "not requested; only 'deploy' and its dependencies run" {
+			t.Errorf("%s = %+v, want excluded as not requested", name, d)
+		}
+	}
+}
+
+func TestExplainPlanUnknownTask(t *testing.T) {
+	if _, err := explainPlan(planTestConfig(), "missing", false); err == nil {
+		t.Error("explainPlan should fail for an unknown task")
+	}
+}
+
+func TestSelectTasksUnionOfRequestedTasks(t *testing.T) {
+	plan, err := selectTasks(planTestConfig(), RunOptions{Tasks: []string{"deploy", "notify"}})
+	if err != nil {
+		t.Fatalf("selectTasks failed: %v", err)
+	}
+
+	var names []string
+	for _, task := range plan {
+		names = append(names, task.Name)
+	}
+	if len(names) != 3 || names[len(names)-1] == "build" {
+		t.Errorf("plan = %v, want build, deploy and notify with build first", names)
+	}
+	for _, want := range []string{"build", "deploy", "notify"} {
+		found := false
+		for _, name := range names {
+			if name == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("plan %v missing %s", names, want)
+		}
+	}
+}
+
+func TestSelectTasksExcludesNamedTasks(t *testing.T) {
+	plan, err := selectTasks(planTestConfig(), RunOptions{ExcludeTasks: []string{"notify"}})
+	if err != nil {
+		t.Fatalf("selectTasks failed: %v", err)
+	}
+	for _, task := range plan {
+		if task.Name == "notify" {
+			t.Errorf("excluded task still in plan: %v", plan)
+		}
+	}
+
+	if _, err := selectTasks(planTestConfig(), RunOptions{ExcludeTasks: []string{"missing"}}); err == nil {
+		t.Error("excluding an unknown task should fail")
+	}
+}
+
+func TestValidateTaskNames(t *testing.T) {
+	cfg := planTestConfig()
+	if err := ValidateTaskNames(cfg, []string{"build", "deploy"}); err != nil {
+		t.Errorf("known names rejected: %v", err)
+	}
+	if err := ValidateTaskNames(cfg, []string{"build", "missing"}); err == nil {
+		t.Error("unknown name should be rejected")
+	}
+}
+
+func TestPrintPlanListsTasksInOrder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printPlan(&buf, planTestConfig(), "", false, false); err != nil {
+		t.Fatalf("printPlan failed: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, "Execution plan for demo (3 tasks):") {
+		t.Errorf("plan header missing:\n%s", out)
+	}
+	// build must run before deploy, and cleanup (lib) must not appear
+	if strings.Index(out, "build") > strings.Index(out, "deploy") {
+		t.Errorf("plan order wrong:\n%s", out)
+	}
+	if strings.Contains(out, "cleanup") {
+		t.Errorf("lib task should not be in the plan:\n%s", out)
+	}
+}
+
+func TestPrintPlanExplainAnnotatesTasks(t *testing.T) {
+	term.SetColorMode(term.ColorNever)
+	defer term.SetColorMode(term.ColorAuto)
+
+	var buf bytes.Buffer
+	if err := printPlan(&buf, planTestConfig(), "", false, true); err != nil {
+		t.Fatalf("printPlan failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"runs by default",
+		"lib task; excluded without --include-lib",
+		"✔", "✘",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("explain output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintPlanShowsEstimatesAndTotal(t *testing.T) {
+	cfg := planTestConfig()
+	cfg.Tasks[0].Estimate = "2m"
+	cfg.Tasks[1].Estimate = "90s"
+
+	var buf bytes.Buffer
+	if err := printPlan(&buf, cfg, "", false, false); err != nil {
+		t.Fatalf("printPlan failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"build (~2m)",
+		"deploy (~90s)",
+		"Estimated total: 3m30s (2 of 3 tasks estimated)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plan output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintPlanOmitsTotalWithoutEstimates(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printPlan(&buf, planTestConfig(), "", false, false); err != nil {
+		t.Fatalf("printPlan failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "Estimated total") {
+		t.Errorf("total shown although no task has an estimate:\n%s", buf.String())
+	}
+}
+
+func TestPrintPlanShowsPreludes(t *testing.T) {
+	cfg := planTestConfig()
+	cfg.Prelude = "source /etc/profile.d/node.sh"
+	cfg.Hosts = map[string]config.Host{
+		"web": {Host: "example.com", Prelude: "export PATH=$PATH:/opt/node/bin\nexport NODE_ENV=production"},
+		"db":  {Host: "db.example.com"},
+	}
+
+	var buf bytes.Buffer
+	if err := printPlan(&buf, cfg, "", false, false); err != nil {
+		t.Fatalf("printPlan failed: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "Prelude (all hosts):\n    source /etc/profile.d/node.sh") {
+		t.Errorf("output missing the config prelude:\n%s", out)
+	}
+	if !strings.Contains(out, "Prelude (host web):\n    export PATH=$PATH:/opt/node/bin\n    export NODE_ENV=production") {
+		t.Errorf("output missing the host prelude lines:\n%s", out)
+	}
+	if strings.Contains(out, "host db") {
+		t.Errorf("host without a prelude was listed:\n%s", out)
+	}
+}
+
+func TestSelectTasksOnlySkipsDependencies(t *testing.T) {
+	// deploy depends on build, but --only runs it alone
+	plan, err := selectTasks(planTestConfig(), RunOptions{TaskName: "deploy", Only: true})
+	if err != nil {
+		t.Fatalf("selectTasks failed: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Name != "deploy" {
+		t.Errorf("plan = %v, want only the deploy task", plan)
+	}
+
+	// Only mode is meaningless without a task selection
+	if _, err := selectTasks(planTestConfig(), RunOptions{Only: true}); err == nil {
+		t.Error("only mode without a task selection should fail")
+	}
+
+	// Unknown names are still rejected up front
+	if _, err := selectTasks(planTestConfig(), RunOptions{TaskName: "missing", Only: true}); err == nil {
+		t.Error("only mode with an unknown task should fail")
+	}
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/tasks"
+)
+
+// setupResumeCommand registers the `resume` command, which re-runs a recorded
+// deployment from where it stopped.
+//
+// Parameters:
+//   - rootCmd: The root command to attach the resume command to
+//   - version: The application version, used for config compatibility checks
+func setupResumeCommand(rootCmd *cobra.Command, version string) {
+	var dbPath string
+	var force bool
+	var debug bool
+
+	resumeCmd := &cobra.Command{
+		Use:   "resume <deployment-id>",
+		Short: "Re-run a failed deployment from its first failed task",
+		Long: `Resume rebuilds the plan of a recorded deployment and re-runs it, skipping
+task/host pairs that already succeeded. The original release_version is
+reused so release paths line up, and the resumed run is recorded as its own
+history entry linked to the original.
+
+Resume refuses to run if the config file changed since the original run;
+pass --force to override (the override is noted in the history entry).`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid deployment ID %q", args[0])
+			}
+
+			// Default to the same database the web server uses; a missing
+			// file is an error, never silently created
+			path, err := ResolveDatabasePath(dbPath)
+			if err != nil {
+				return err
+			}
+			database, err := OpenDatabase(path, false)
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			return resumeDeployment(database, id, version, force, debug)
+		},
+	}
+
+	resumeCmd.Flags().StringVar(&dbPath, "db", "", "Path to the deployments database (default: the configured NYATI_DB_PATH)")
+	resumeCmd.Flags().BoolVar(&force, "force", false, "Resume even if the config file changed since the original run")
+	resumeCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable debug output")
+
+	rootCmd.AddCommand(resumeCmd)
+}
+
+// resumeDeployment loads a recorded deployment, rebuilds the plan minus the
+// task/host pairs that already succeeded, and re-runs it with the original
+// release version. The resumed run gets its own history entry linked to the
+// original through resumed_from.
+//
+// Parameters:
+//   - database: open handle on the deployments database
+//   - id: the deployment to resume
+//   - version: the application version, for config compatibility checks
+//   - force: resume even if the config file changed since the original run
+//   - debug: enable debug output
+//
+// Returns:
+//   - error: if the deployment cannot be resumed or the run fails
+func resumeDeployment(database *sql.DB, id int64, version string, force, debug bool) error {
+	var (
+		configPath     string
+		host           string
+		userID         int
+		originalHash   string
+		releaseVersion int64
+		paramsJSON     string
+		resultsJSON    string
+	)
+	err := database.QueryRow(
+		`SELECT config_path, host, user_id, config_hash, release_version, params, results
+		 FROM deployments WHERE id = ?`, id,
+	).Scan(&configPath, &host, &userID, &originalHash, &releaseVersion, &paramsJSON, &resultsJSON)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("deployment %d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load deployment %d: %v", id, err)
+	}
+
+	if resultsJSON == "" {
+		return fmt.Errorf("deployment %d has no recorded task results to resume from; run a full deploy instead", id)
+	}
+	var outcomes []tasks.TaskOutcome
+	if err := json.Unmarshal([]byte(resultsJSON), &outcomes); err != nil {
+		return fmt.Errorf("failed to decode recorded task results: %v", err)
+	}
+	completed := tasks.CompletedFromOutcomes(outcomes)
+
+	var params map[string]string
+	if paramsJSON != "" {
+		if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+			return fmt.Errorf("failed to decode recorded params: %v", err)
+		}
+	}
+
+	// A changed file invalidates the recorded outcomes: what "succeeded"
+	// refers to may no longer be the same task
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config %s: %v", configPath, err)
+	}
+	currentHash := fmt.Sprintf("%x", sha256.Sum256(content))
+	note := fmt.Sprintf("resumed from deployment %d", id)
+	if currentHash != originalHash {
+		if !force {
+			return fmt.Errorf("config %s changed since deployment %d ran; re-deploy from scratch or pass --force", configPath, id)
+		}
+		note += "; forced: config file changed since the original run"
+		fmt.Printf("⚠️  Config changed since deployment %d; resuming anyway (--force)\n", id)
+	}
+
+	cfg, err := config.LoadWithOptions(configPath, version, config.LoadOptions{Params: params, AllowInventory: true})
+	if err != nil {
+		return err
+	}
+	// Reuse the original release directory so partially created paths line up
+	if releaseVersion > 0 {
+		cfg.ReleaseVersion = releaseVersion
+	}
+
+	skipped := 0
+	for _, hosts := range completed {
+		skipped += len(hosts)
+	}
+	fmt.Printf("Resuming deployment %d on %s (%d task/host pairs already succeeded)\n", id, host, skipped)
+
+	newID := recordResumeEntry(database, userID, configPath, host, note, cfg.ReleaseVersion, id, content, currentHash)
+
+	start := time.Now()
+	results, err := RunWithOptions(cfg, []string{"deploy", host}, RunOptions{
+		Debug:     debug,
+		Completed: completed,
+	})
+	finishResumeEntry(database, newID, err == nil, time.Since(start), results)
+	printRunSummary(os.Stdout, results, time.Since(start), outputText, false)
+	return err
+}
+
+// recordResumeEntry stores the resumed run's own history entry, linked to the
+// original deployment. Best-effort: a failure is reported but never blocks
+// the run.
+//
+// Returns:
+//   - int64: ID of the recorded entry, 0 when recording failed
+func recordResumeEntry(database *sql.DB, userID int, configPath, host, note string, releaseVersion, resumedFrom int64, content []byte, hash string) int64 {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write(content)
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to compress config snapshot: %v\n", err)
+		return 0
+	}
+	if _, err := database.Exec(
+		"INSERT OR IGNORE INTO config_snapshots (hash, content) VALUES (?, ?)",
+		hash, compressed.Bytes(),
+	); err != nil {
+		fmt.Printf("Warning: failed to store config snapshot: %v\n", err)
+		return 0
+	}
+
+	result, err := database.Exec(
+		"INSERT INTO deployments (config_path, host, user_id, config_hash, note, release_version, attempt, resumed_from) VALUES (?, ?, ?, ?, ?, ?, 1, ?)",
+		configPath, host, userID, hash, note, releaseVersion, resumedFrom,
+	)
+	if err != nil {
+		fmt.Printf("Warning: failed to record resumed deployment: %v\n", err)
+		return 0
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return 0
+	}
+	return newID
+}
+
+// finishResumeEntry fills in the outcome of a resumed run's history entry.
+// Best-effort like recording.
+func finishResumeEntry(database *sql.DB, id int64, success bool, duration time.Duration, results []tasks.Result) {
+	if id == 0 {
+		return
+	}
+	status := "success"
+	if !success {
+		status = "error"
+	}
+	encoded, err := json.Marshal(tasks.Outcomes(results))
+	if err != nil {
+		encoded = []byte("")
+	}
+	if _, err := database.Exec(
+		"UPDATE deployments SET status = ?, duration_ms = ?, results = ? WHERE id = ?",
+		status, duration.Milliseconds(), string(encoded), id,
+	); err != nil {
+		fmt.Printf("Warning: failed to record resumed deployment outcome: %v\n", err)
+	}
+}
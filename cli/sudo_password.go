@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/manifoldco/promptui"
+	"github.com/mattn/go-isatty"
+	"github.com/zechtz/nyatictl/config"
+)
+
+// sudoPasswordInteractive reports whether prompting for the sudo password is
+// possible. Overridable so tests can exercise the non-interactive path.
+var sudoPasswordInteractive = func() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// configNeedsAskPass reports whether any task in the config requests a sudo
+// password prompt, so the CLI only asks for one when it will be used.
+func configNeedsAskPass(cfg *config.Config) bool {
+	for _, task := range cfg.Tasks {
+		if task.AskPass {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSudoPassword returns the sudo/become password for askpass tasks.
+// Resolution order:
+//
+//  1. the variable named by --sudo-password-from-env, read from the process
+//     environment first and the resolved environment store second
+//  2. an interactive masked prompt, only when a task needs it and stdin is a
+//     terminal
+//
+// With neither source available the password stays empty, which preserves
+// the historic PTY-only behavior. The password is fed to the remote command
+// over stdin and never becomes part of the command line.
+//
+// Parameters:
+//   - fromEnv: the value of --sudo-password-from-env, possibly empty
+//   - envVars: variables from the resolved --env environment, possibly nil
+//   - cfg: the loaded configuration, to check whether any task needs it
+//
+// Returns:
+//   - string: the resolved password, possibly empty
+//   - error: if --sudo-password-from-env names a variable that is unset
+func resolveSudoPassword(fromEnv string, envVars map[string]string, cfg *config.Config) (string, error) {
+	if fromEnv != "" {
+		if password := os.Getenv(fromEnv); password != "" {
+			return password, nil
+		}
+		if password := envVars[fromEnv]; password != "" {
+			return password, nil
+		}
+		return "", fmt.Errorf("--sudo-password-from-env: %q is not set in the process environment or the environment store", fromEnv)
+	}
+
+	if !configNeedsAskPass(cfg) {
+		return "", nil
+	}
+
+	if sudoPasswordInteractive() {
+		prompt := promptui.Prompt{Label: "Sudo password", Mask: '*'}
+		password, err := prompt.Run()
+		if err != nil {
+			return "", fmt.Errorf("failed to read sudo password: %v", err)
+		}
+		return password, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+func TestResolveSudoPasswordFromProcessEnv(t *testing.T) {
+	t.Setenv("DEPLOY_SUDO_PASS", "s3cret")
+
+	password, err := resolveSudoPassword("DEPLOY_SUDO_PASS", nil, &config.Config{})
+	if err != nil {
+		t.Fatalf("resolveSudoPassword failed: %v", err)
+	}
+	if password != "s3cret" {
+		t.Errorf("password = %q, want the process environment value", password)
+	}
+}
+
+func TestResolveSudoPasswordFromEnvStore(t *testing.T) {
+	// Not in the process environment, but present in the resolved --env store
+	envVars := map[string]string{"DEPLOY_SUDO_PASS": "store-secret"}
+
+	password, err := resolveSudoPassword("DEPLOY_SUDO_PASS", envVars, &config.Config{})
+	if err != nil {
+		t.Fatalf("resolveSudoPassword failed: %v", err)
+	}
+	if password != "store-secret" {
+		t.Errorf("password = %q, want the environment store value", password)
+	}
+}
+
+func TestResolveSudoPasswordUnsetVariableFails(t *testing.T) {
+	_, err := resolveSudoPassword("DEPLOY_SUDO_PASS", nil, &config.Config{})
+	if err == nil {
+		t.Fatal("expected an error for an unset variable")
+	}
+	if !strings.Contains(err.Error(), "DEPLOY_SUDO_PASS") {
+		t.Errorf("error %q should name the missing variable", err)
+	}
+}
+
+func TestResolveSudoPasswordNonInteractiveStaysEmpty(t *testing.T) {
+	restore := sudoPasswordInteractive
+	sudoPasswordInteractive = func() bool { return false }
+	defer func() { sudoPasswordInteractive = restore }()
+
+	// An askpass task without a configured source keeps the historic
+	// PTY-only behavior instead of blocking on a prompt
+	cfg := &config.Config{Tasks: []config.Task{{Name: "restart", Cmd: "sudo x", AskPass: true}}}
+	password, err := resolveSudoPassword("", nil, cfg)
+	if err != nil {
+		t.Fatalf("resolveSudoPassword failed: %v", err)
+	}
+	if password != "" {
+		t.Errorf("password = %q, want empty without a source or terminal", password)
+	}
+}
+
+func TestConfigNeedsAskPass(t *testing.T) {
+	if configNeedsAskPass(&config.Config{Tasks: []config.Task{{Name: "build", Cmd: "make"}}}) {
+		t.Error("config without askpass tasks should not need a password")
+	}
+	if !configNeedsAskPass(&config.Config{Tasks: []config.Task{{Name: "restart", Cmd: "sudo x", AskPass: true}}}) {
+		t.Error("config with an askpass task should need a password")
+	}
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zechtz/nyatictl/tasks"
+	"github.com/zechtz/nyatictl/term"
+)
+
+// failureOutputLines is how many trailing output lines are repeated for each
+// failed task below the summary table, so the relevant error is visible
+// without scrolling back through the full deploy log.
+const failureOutputLines = 5
+
+// Summary output formats accepted by the --output flag.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// runSummary aggregates the structured results of a run for end-of-run
+// reporting. It is also the document emitted verbatim with --output json.
+type runSummary struct {
+	Results   []tasks.Result `json:"results"`
+	Total     int            `json:"total"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	// AllowedFailures counts failures on allow_failure tasks; they are not
+	// included in Failed, matching the run outcome they don't affect.
+	AllowedFailures int                    `json:"allowedFailures"`
+	Skipped         int                    `json:"skipped"`
+	Hosts           map[string]hostSummary `json:"hosts"`
+	Duration        string                 `json:"duration"`
+}
+
+// hostSummary is the per-host slice of the run totals.
+type hostSummary struct {
+	Succeeded       int `json:"succeeded"`
+	Failed          int `json:"failed"`
+	AllowedFailures int `json:"allowedFailures,omitempty"`
+	Skipped         int `json:"skipped,omitempty"`
+}
+
+// summarize tallies per-execution results into a runSummary.
+//
+// Parameters:
+//   - results: structured results as returned by RunResults
+//   - wall: overall wall time of the run
+//
+// Returns:
+//   - runSummary: totals plus the original results
+func summarize(results []tasks.Result, wall time.Duration) runSummary {
+	s := runSummary{
+		Results:  results,
+		Total:    len(results),
+		Hosts:    make(map[string]hostSummary),
+		Duration: wall.Round(time.Millisecond).String(),
+	}
+	for _, r := range results {
+		host := s.Hosts[r.Host]
+		switch {
+		case r.Skipped:
+			s.Skipped++
+			host.Skipped++
+		case r.Success:
+			s.Succeeded++
+			host.Succeeded++
+		case r.AllowedFailure:
+			s.AllowedFailures++
+			host.AllowedFailures++
+		default:
+			s.Failed++
+			host.Failed++
+		}
+		s.Hosts[r.Host] = host
+	}
+	return s
+}
+
+// printRunSummary renders the end-of-run report to w.
+//
+// In text format it prints a task×host table with a status glyph, duration
+// and exit code per row, followed by totals and a section repeating each
+// failure with the last few lines of its output. With format "json" the
+// table is suppressed in favor of the summary document; with quiet only the
+// failure section prints. Nothing is printed when no task executed.
+//
+// Parameters:
+//   - w: destination writer (os.Stdout in CLI runs)
+//   - results: structured results as returned by RunResults
+//   - wall: overall wall time of the run
+//   - format: "text" or "json"
+//   - quiet: suppress everything except failures (text format only)
+func printRunSummary(w io.Writer, results []tasks.Result, wall time.Duration, format string, quiet bool) {
+	if len(results) == 0 {
+		return
+	}
+
+	summary := summarize(results, wall)
+	if format == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(summary)
+		return
+	}
+
+	if !quiet {
+		printSummaryTable(w, summary)
+	}
+	printFailures(w, results)
+}
+
+// printSummaryTable writes the aligned task×host table and the totals line.
+func printSummaryTable(w io.Writer, summary runSummary) {
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  \tTASK\tHOST\tDURATION\tCODE")
+	for _, r := range summary.Results {
+		glyph := term.Green("✔")
+		switch {
+		case r.Skipped:
+			glyph = "⏭"
+		case r.AllowedFailure:
+			glyph = term.Yellow("⚠")
+		case !r.Success:
+			glyph = term.Red("✘")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n",
+			glyph, r.Task, r.Host, r.Duration.Round(time.Millisecond), r.Code)
+	}
+	tw.Flush()
+
+	totals := fmt.Sprintf("%d succeeded, %d failed, %d skipped in %s",
+		summary.Succeeded, summary.Failed, summary.Skipped, summary.Duration)
+	if summary.AllowedFailures > 0 {
+		totals = fmt.Sprintf("%d succeeded, %d failed, %d allowed failure(s), %d skipped in %s",
+			summary.Succeeded, summary.Failed, summary.AllowedFailures, summary.Skipped, summary.Duration)
+	}
+	fmt.Fprintf(w, "\n%s\n", term.Bold(totals))
+
+	// With several hosts in the run, break the totals down per host so an
+	// all-green host is distinguishable from the one that failed
+	if len(summary.Hosts) > 1 {
+		hosts := make([]string, 0, len(summary.Hosts))
+		for host := range summary.Hosts {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+		for _, host := range hosts {
+			h := summary.Hosts[host]
+			line := fmt.Sprintf("  %s: %d succeeded, %d failed, %d skipped", host, h.Succeeded, h.Failed, h.Skipped)
+			if h.AllowedFailures > 0 {
+				line += fmt.Sprintf(", %d allowed failure(s)", h.AllowedFailures)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// printFailures repeats each failed execution with the tail of its output,
+// so the cause is visible without scrolling back through the deploy log.
+func printFailures(w io.Writer, results []tasks.Result) {
+	for _, r := range results {
+		if r.Success {
+			continue
+		}
+		header := term.Red(fmt.Sprintf("✘ %s@%s (code %d)", r.Task, r.Host, r.Code))
+		if r.AllowedFailure {
+			header = term.Yellow(fmt.Sprintf("⚠ %s@%s (code %d, failure allowed)", r.Task, r.Host, r.Code))
+		}
+		fmt.Fprintf(w, "\n%s\n", header)
+		for _, line := range lastLines(r.Output, failureOutputLines) {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+// lastLines returns up to n trailing lines of s, with trailing whitespace
+// stripped. An empty or whitespace-only string yields no lines.
+func lastLines(s string, n int) []string {
+	s = strings.TrimRight(s, "\n\r \t")
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
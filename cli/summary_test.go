@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zechtz/nyatictl/tasks"
+	"github.com/zechtz/nyatictl/term"
+)
+
+// summaryTestResults returns a mixed run: two successes and one failure whose
+// output is longer than the failure tail.
+func summaryTestResults() []tasks.Result {
+	return []tasks.Result{
+		{Task: "build", Host: "web1", Code: 0, Success: true, Duration: 2 * time.Second},
+		{Task: "build", Host: "web2", Code: 0, Success: true, Duration: 3 * time.Second},
+		{
+			Task:     "migrate",
+			Host:     "web2",
+			Code:     1,
+			Success:  false,
+			Output:   "line 1\nline 2\nline 3\nline 4\nline 5\nline 6\nline 7\n",
+			Duration: 500 * time.Millisecond,
+		},
+	}
+}
+
+func TestPrintRunSummaryTable(t *testing.T) {
+	term.SetColorMode(term.ColorNever)
+	defer term.SetColorMode(term.ColorAuto)
+
+	var buf bytes.Buffer
+	printRunSummary(&buf, summaryTestResults(), 6*time.Second, outputText, false)
+	out := buf.String()
+
+	for _, want := range []string{
+		"TASK", "HOST", "DURATION", "CODE",
+		"✔", "✘",
+		"2 succeeded, 1 failed, 0 skipped in 6s",
+		"✘ migrate@web2 (code 1)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintRunSummaryFailureTail(t *testing.T) {
+	term.SetColorMode(term.ColorNever)
+	defer term.SetColorMode(term.ColorAuto)
+
+	var buf bytes.Buffer
+	printRunSummary(&buf, summaryTestResults(), 6*time.Second, outputText, false)
+	out := buf.String()
+
+	// Only the last failureOutputLines lines of the failed task repeat
+	if strings.Contains(out, "line 2") {
+		t.Errorf("failure tail should drop lines beyond the last %d:\n%s", failureOutputLines, out)
+	}
+	for _, want := range []string{"line 3", "line 7"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("failure tail missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintRunSummaryQuietOnlyFailures(t *testing.T) {
+	term.SetColorMode(term.ColorNever)
+	defer term.SetColorMode(term.ColorAuto)
+
+	var buf bytes.Buffer
+	printRunSummary(&buf, summaryTestResults(), 6*time.Second, outputText, true)
+	out := buf.String()
+
+	if strings.Contains(out, "TASK") || strings.Contains(out, "succeeded") {
+		t.Errorf("quiet summary should omit the table and totals:\n%s", out)
+	}
+	if !strings.Contains(out, "✘ migrate@web2 (code 1)") {
+		t.Errorf("quiet summary should still list failures:\n%s", out)
+	}
+}
+
+func TestPrintRunSummaryJSON(t *testing.T) {
+	var buf bytes.Buffer
+	printRunSummary(&buf, summaryTestResults(), 6*time.Second, outputJSON, false)
+
+	var got runSummary
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("--output json did not produce valid JSON: %v\n%s", err, buf.String())
+	}
+	if got.Succeeded != 2 || got.Failed != 1 || got.Skipped != 0 {
+		t.Errorf("totals = %d/%d/%d, want 2/1/0", got.Succeeded, got.Failed, got.Skipped)
+	}
+	if len(got.Results) != 3 {
+		t.Errorf("results length = %d, want 3", len(got.Results))
+	}
+	if got.Duration != "6s" {
+		t.Errorf("duration = %q, want \"6s\"", got.Duration)
+	}
+}
+
+func TestPrintRunSummaryNothingRan(t *testing.T) {
+	var buf bytes.Buffer
+	printRunSummary(&buf, nil, time.Second, outputText, false)
+	if buf.Len() != 0 {
+		t.Errorf("summary for an empty run should print nothing, got:\n%s", buf.String())
+	}
+}
+
+func TestPrintRunSummaryAllowedFailures(t *testing.T) {
+	term.SetColorMode(term.ColorNever)
+	defer term.SetColorMode(term.ColorAuto)
+
+	results := []tasks.Result{
+		{Task: "build", Host: "web1", Code: 0, Success: true, Duration: time.Second},
+		{Task: "warm_cache", Host: "web1", Code: 1, Success: false, AllowedFailure: true, Output: "cache backend down\n"},
+	}
+
+	var buf bytes.Buffer
+	printRunSummary(&buf, results, 2*time.Second, outputText, false)
+	out := buf.String()
+
+	for _, want := range []string{
+		"⚠",
+		"1 succeeded, 0 failed, 1 allowed failure(s), 0 skipped in 2s",
+		"⚠ warm_cache@web1 (code 1, failure allowed)",
+		"cache backend down",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+
+	summary := summarize(results, 2*time.Second)
+	if summary.Failed != 0 || summary.AllowedFailures != 1 {
+		t.Errorf("totals = %d failed / %d allowed, want 0 failed, 1 allowed",
+			summary.Failed, summary.AllowedFailures)
+	}
+}
+
+func TestSummarizeCountsAndPerHostBreakdown(t *testing.T) {
+	results := []tasks.Result{
+		{Task: "build", Host: "web1", Success: true},
+		{Task: "build", Host: "web2", Success: true, Skipped: true},
+		{Task: "migrate", Host: "web1", Success: false, Code: 1},
+		{Task: "warm_cache", Host: "web2", Success: false, AllowedFailure: true},
+	}
+
+	got := summarize(results, 4*time.Second)
+
+	if got.Total != 4 || got.Succeeded != 1 || got.Failed != 1 || got.Skipped != 1 || got.AllowedFailures != 1 {
+		t.Errorf("totals = %+v, want 4 total, 1 succeeded, 1 failed, 1 skipped, 1 allowed", got)
+	}
+	if h := got.Hosts["web1"]; h.Succeeded != 1 || h.Failed != 1 || h.Skipped != 0 {
+		t.Errorf("web1 = %+v, want 1 succeeded, 1 failed", h)
+	}
+	if h := got.Hosts["web2"]; h.Skipped != 1 || h.AllowedFailures != 1 || h.Failed != 0 {
+		t.Errorf("web2 = %+v, want 1 skipped, 1 allowed failure", h)
+	}
+}
+
+func TestPrintRunSummaryPerHostBreakdown(t *testing.T) {
+	term.SetColorMode(term.ColorNever)
+	defer term.SetColorMode(term.ColorAuto)
+
+	results := []tasks.Result{
+		{Task: "build", Host: "web1", Success: true, Duration: time.Second},
+		{Task: "build", Host: "web2", Success: true, Skipped: true},
+		{Task: "migrate", Host: "web2", Success: false, Code: 1, Duration: time.Second},
+	}
+
+	var buf bytes.Buffer
+	printRunSummary(&buf, results, 2*time.Second, outputText, false)
+	out := buf.String()
+
+	for _, want := range []string{
+		"⏭",
+		"1 succeeded, 1 failed, 1 skipped in 2s",
+		"web1: 1 succeeded, 0 failed, 0 skipped",
+		"web2: 0 succeeded, 1 failed, 1 skipped",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintRunSummaryJSONIncludesHosts(t *testing.T) {
+	results := []tasks.Result{
+		{Task: "build", Host: "web1", Success: true},
+		{Task: "build", Host: "web2", Success: true, Skipped: true},
+	}
+
+	var buf bytes.Buffer
+	printRunSummary(&buf, results, time.Second, outputJSON, false)
+
+	var got runSummary
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("--output json did not produce valid JSON: %v\n%s", err, buf.String())
+	}
+	if got.Total != 2 || got.Skipped != 1 {
+		t.Errorf("total/skipped = %d/%d, want 2/1", got.Total, got.Skipped)
+	}
+	if len(got.Hosts) != 2 || got.Hosts["web2"].Skipped != 1 {
+		t.Errorf("hosts = %+v, want web2 with one skip", got.Hosts)
+	}
+}
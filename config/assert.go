@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// validateOutputAssertions checks that a task's assert_output and
+// forbid_output patterns compile, so a typo'd regex fails the load instead of
+// a deploy.
+func validateOutputAssertions(task Task) error {
+	if task.AssertOutput != "" {
+		if _, err := regexp.Compile(task.AssertOutput); err != nil {
+			return fmt.Errorf("task '%s': invalid assert_output pattern '%s': %v", task.Name, task.AssertOutput, err)
+		}
+	}
+	if task.ForbidOutput != "" {
+		if _, err := regexp.Compile(task.ForbidOutput); err != nil {
+			return fmt.Errorf("task '%s': invalid forbid_output pattern '%s': %v", task.Name, task.ForbidOutput, err)
+		}
+	}
+	return nil
+}
+
+// CheckOutput applies the task's output assertions to the captured command
+// output. A task can exit 0 while its output reports a problem (e.g.
+// "WARNING: degraded"), so assertions run after the exit-code check and fail
+// the task on a mismatch.
+//
+// Load validates the patterns, so compile errors cannot occur on a loaded
+// config; defensively, an assert_output that fails to compile fails the
+// assertion.
+//
+// Parameters:
+//   - output: the captured output of the task's command
+//
+// Returns:
+//   - error: describing the failed assertion, or nil when all assertions hold
+func (t Task) CheckOutput(output string) error {
+	if t.AssertOutput != "" {
+		re, err := regexp.Compile(t.AssertOutput)
+		if err != nil || !re.MatchString(output) {
+			return fmt.Errorf("output does not match required pattern '%s'", t.AssertOutput)
+		}
+	}
+	if t.ForbidOutput != "" {
+		re, err := regexp.Compile(t.ForbidOutput)
+		if err == nil && re.MatchString(output) {
+			return fmt.Errorf("output matches forbidden pattern '%s' (found %q)", t.ForbidOutput, re.FindString(output))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckOutputAssertions(t *testing.T) {
+	cases := []struct {
+		name    string
+		task    Task
+		output  string
+		wantErr string // Empty when the assertions should pass
+	}{
+		{"no assertions", Task{}, "anything at all", ""},
+		{"substring matches", Task{AssertOutput: "migrations applied"}, "42 migrations applied\n", ""},
+		{"substring missing", Task{AssertOutput: "migrations applied"}, "nothing to do\n", "does not match required pattern"},
+		{"regex matches", Task{AssertOutput: `status: (ok|healthy)`}, "status: healthy", ""},
+		{"regex missing", Task{AssertOutput: `status: (ok|healthy)`}, "status: degraded", "does not match required pattern"},
+		{"forbidden absent", Task{ForbidOutput: "WARNING"}, "all good\n", ""},
+		{"forbidden present", Task{ForbidOutput: `WARNING: \w+`}, "done\nWARNING: degraded\n", "forbidden pattern"},
+		{"both hold", Task{AssertOutput: "deployed", ForbidOutput: "WARNING"}, "deployed cleanly", ""},
+		{"both set, forbidden wins", Task{AssertOutput: "deployed", ForbidOutput: "WARNING"}, "deployed with WARNING", "forbidden pattern"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.task.CheckOutput(c.output)
+			if c.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckOutput(%q) = %v, want nil", c.output, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("CheckOutput(%q) = %v, want an error containing %q", c.output, err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckOutputNamesTheForbiddenMatch(t *testing.T) {
+	task := Task{ForbidOutput: `WARNING: \w+`}
+	err := task.CheckOutput("ok\nWARNING: degraded\n")
+	if err == nil || !strings.Contains(err.Error(), "WARNING: degraded") {
+		t.Errorf("CheckOutput error = %v, want the matched text quoted", err)
+	}
+}
+
+func TestLoadRejectsInvalidOutputAssertionPatterns(t *testing.T) {
+	for _, field := range []string{"assert_output", "forbid_output"} {
+		content := []byte(`
+version: "0.1.2"
+appname: testapp
+hosts:
+  server1:
+    host: example.com
+    username: deploy
+    password: secret
+tasks:
+  - name: build
+    cmd: make
+    ` + field + `: "[unclosed"
+`)
+		_, err := LoadBytes(content, "0.1.2")
+		if err == nil {
+			t.Fatalf("a config with an invalid %s pattern must be rejected", field)
+		}
+		if !strings.Contains(err.Error(), field) || !strings.Contains(err.Error(), "build") {
+			t.Errorf("error %v does not name the field %s and the task", err, field)
+		}
+	}
+}
+
+func TestLoadAcceptsOutputAssertions(t *testing.T) {
+	content := []byte(`
+version: "0.1.2"
+appname: testapp
+hosts:
+  server1:
+    host: example.com
+    username: deploy
+    password: secret
+tasks:
+  - name: health
+    cmd: ./healthcheck.sh
+    assert_output: "status: ok"
+    forbid_output: "WARNING"
+`)
+	cfg, err := LoadBytes(content, "0.1.2")
+	if err != nil {
+		t.Fatalf("LoadBytes failed: %v", err)
+	}
+	task := cfg.Tasks[0]
+	if task.AssertOutput != "status: ok" || task.ForbidOutput != "WARNING" {
+		t.Errorf("assertions = (%q, %q), want the configured patterns", task.AssertOutput, task.ForbidOutput)
+	}
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,36 +16,121 @@ import (
 // It includes metadata (like version and app name), the set of target hosts,
 // the list of tasks to run, and key-value parameters used in templates.
 type Config struct {
-	Version        string            `mapstructure:"version"` // Version of the config file
-	AppName        string            `mapstructure:"appname"` // Name of the application being deployed
-	Hosts          map[string]Host   `mapstructure:"hosts"`   // Map of host identifiers to Host structs
-	Tasks          []Task            `mapstructure:"tasks"`   // List of defined deployment tasks
-	Params         map[string]string `mapstructure:"params"`  // Key-value parameters for template substitution
-	ReleaseVersion int64             // Populated at runtime to indicate the current release timestamp
+	Version        string               `mapstructure:"version" yaml:"version"`                       // Version of the config file
+	AppName        string               `mapstructure:"appname" yaml:"appname"`                       // Name of the application being deployed
+	Hosts          map[string]Host      `mapstructure:"hosts" yaml:"hosts"`                           // Map of host identifiers to Host structs
+	HostDefaults   HostDefaults         `mapstructure:"host_defaults" yaml:"host_defaults,omitempty"` // Connection details inherited by hosts that do not override them
+	Inventory      string               `mapstructure:"inventory" yaml:"inventory,omitempty"`         // Path to a JSON/YAML inventory file whose hosts augment hosts
+	InventoryCmd   string               `mapstructure:"inventory_cmd" yaml:"inventory_cmd,omitempty"` // Command whose stdout is an inventory document, for dynamic fleets
+	HostsFile      string               `mapstructure:"hosts_file" yaml:"hosts_file,omitempty"`       // Path to an ops-managed hosts file whose entries override hosts
+	Tasks          []Task               `mapstructure:"tasks" yaml:"tasks"`                           // List of defined deployment tasks
+	Params         map[string]string    `mapstructure:"params" yaml:"params,omitempty"`               // Key-value parameters for template substitution
+	ParamSchema    map[string]ParamSpec `mapstructure:"param_schema" yaml:"param_schema,omitempty"`   // Optional constraints on deploy-request param overrides
+	PluginsDir     string               `mapstructure:"plugins_dir" yaml:"plugins_dir,omitempty"`     // Directory plugin task executables are resolved from
+	Metrics        bool                 `mapstructure:"metrics" yaml:"metrics,omitempty"`             // Whether to capture per-task resource usage on remote hosts
+	GatherFacts    bool                 `mapstructure:"gather_facts" yaml:"gather_facts,omitempty"`   // Whether to collect host facts before running tasks
+	SessionShell   bool                 `mapstructure:"session_shell" yaml:"session_shell,omitempty"` // Run each host's tasks in one persistent shell, keeping exports and cwd across tasks
+	Prelude        string               `mapstructure:"prelude" yaml:"prelude,omitempty"`             // Script prepended to every task command on every host (e.g. sourcing profiles)
+	ReleaseVersion int64                `yaml:"-"`                                                    // Populated at runtime to indicate the current release timestamp
 }
 
+// Task type values accepted in a task's type field.
+const (
+	// TaskTypeShell runs the task's cmd over SSH on each host. This is the
+	// default when type is omitted.
+	TaskTypeShell = "shell"
+	// TaskTypePlugin invokes an external executable from the plugins
+	// directory, passing the task as JSON on stdin and reading a structured
+	// result from stdout.
+	TaskTypePlugin = "plugin"
+	// TaskTypeUpload transfers a local file to each host in resumable chunks
+	// with checksum verification, instead of running a command.
+	TaskTypeUpload = "upload"
+)
+
 // Host defines connection details for a target server.
 type Host struct {
-	Host       string `mapstructure:"host"`                  // IP or hostname of the server
-	Username   string `mapstructure:"username"`              // SSH username
-	Password   string `mapstructure:"password,omitempty"`    // Optional password (used if no key is provided)
-	PrivateKey string `mapstructure:"private_key,omitempty"` // Optional private key path for SSH authentication
-	EnvFile    string `mapstructure:"envfile,omitempty"`     // Path to environment file to load before tasks
+	Host       string   `mapstructure:"host" yaml:"host"`                                   // IP or hostname of the server
+	Username   string   `mapstructure:"username" yaml:"username"`                           // SSH username
+	Password   string   `mapstructure:"password,omitempty" yaml:"password,omitempty"`       // Optional password (used if no key is provided)
+	PrivateKey string   `mapstructure:"private_key,omitempty" yaml:"private_key,omitempty"` // Optional private key path for SSH authentication
+	EnvFile    string   `mapstructure:"envfile,omitempty" yaml:"envfile,omitempty"`         // Path to environment file to load before tasks
+	EnvName    string   `mapstructure:"env_name,omitempty" yaml:"env_name,omitempty"`       // Named environment from the env store, merged over EnvFile
+	Labels     []string `mapstructure:"labels,omitempty" yaml:"labels,omitempty"`           // Informational labels carried by inventory hosts (e.g. role, region)
+
+	Prelude string `mapstructure:"prelude,omitempty" yaml:"prelude,omitempty"` // Host-specific script prepended to every command, after the config-level prelude
+	Shell   string `mapstructure:"shell,omitempty" yaml:"shell,omitempty"`     // Remote shell family: sh (default); powershell is reserved and rejects preludes
+
+	ConnectTimeout string `mapstructure:"connect_timeout,omitempty" yaml:"connect_timeout,omitempty"` // SSH dial/handshake timeout for this host (e.g. "30s"); default 10s
+	ExecTimeout    string `mapstructure:"exec_timeout,omitempty" yaml:"exec_timeout,omitempty"`       // Per-command execution timeout for this host (e.g. "10m"); default 5m
+
+	KnownHosts         string   `mapstructure:"known_hosts,omitempty" yaml:"known_hosts,omitempty"`                   // Optional known_hosts file path (default: ~/.ssh/known_hosts)
+	HostKeyPolicy      string   `mapstructure:"host_key_policy,omitempty" yaml:"host_key_policy,omitempty"`           // Host key policy: strict (default), accept-new or insecure-ignore
+	HostKeyAlgorithms  []string `mapstructure:"host_key_algorithms,omitempty" yaml:"host_key_algorithms,omitempty"`   // Preferred host key algorithms, most preferred first (default: modern set)
+	HostKeyFingerprint string   `mapstructure:"host_key_fingerprint,omitempty" yaml:"host_key_fingerprint,omitempty"` // Pinned SHA256 host key fingerprint; when set, only the exact key is accepted and known_hosts is ignored
 }
 
 // Task defines a command to run on a host, along with its metadata and dependencies.
 type Task struct {
-	ID        string   `mapstructure:"id,omitempty" json:"id"`                           // Unique identifier for the task
-	Name      string   `mapstructure:"name" json:"name"`                                 // Unique identifier for the task
-	Cmd       string   `mapstructure:"cmd" json:"cmd"`                                   // Shell command to run
-	Dir       string   `mapstructure:"dir,omitempty" json:"dir,omitempty"`               // Optional working directory for the command
-	Expect    int      `mapstructure:"expect" json:"expect"`                             // Expected exit code (0 = success)
-	Message   string   `mapstructure:"message,omitempty" json:"message,omitempty"`       // Optional message to display before execution
-	Retry     bool     `mapstructure:"retry,omitempty" json:"retry,omitempty"`           // Whether to retry on failure
-	AskPass   bool     `mapstructure:"askpass,omitempty" json:"askpass,omitempty"`       // Whether to prompt for password
-	Lib       bool     `mapstructure:"lib,omitempty" json:"lib,omitempty"`               // Whether this is a library task (not run by default)
-	Output    bool     `mapstructure:"output,omitempty" json:"output,omitempty"`         // Whether to display command output
-	DependsOn []string `mapstructure:"depends_on,omitempty" json:"depends_on,omitempty"` // List of task names that must run before this one
+	ID           string   `mapstructure:"id,omitempty" json:"id,omitempty" yaml:"id,omitempty"`                                  // Unique identifier for the task
+	Name         string   `mapstructure:"name" json:"name" yaml:"name"`                                                          // Unique identifier for the task
+	Type         string   `mapstructure:"type,omitempty" json:"type,omitempty" yaml:"type,omitempty"`                            // Task type: shell (default) or plugin
+	Plugin       string   `mapstructure:"plugin,omitempty" json:"plugin,omitempty" yaml:"plugin,omitempty"`                      // Plugin executable name, required for plugin tasks
+	Cmd          string   `mapstructure:"cmd" json:"cmd" yaml:"cmd,omitempty"`                                                   // Shell command to run (unused by plugin tasks)
+	Dir          string   `mapstructure:"dir,omitempty" json:"dir,omitempty" yaml:"dir,omitempty"`                               // Optional working directory for the command
+	EnsureDir    bool     `mapstructure:"ensure_dir,omitempty" json:"ensure_dir,omitempty" yaml:"ensure_dir,omitempty"`          // Create the working directory with mkdir -p before running
+	Expect       int      `mapstructure:"expect" json:"expect" yaml:"expect,omitempty"`                                          // Expected exit code (0 = success)
+	Message      string   `mapstructure:"message,omitempty" json:"message,omitempty" yaml:"message,omitempty"`                   // Optional message to display before execution
+	Retry        bool     `mapstructure:"retry,omitempty" json:"retry,omitempty" yaml:"retry,omitempty"`                         // Whether to retry on failure
+	AllowFailure bool     `mapstructure:"allow_failure,omitempty" json:"allow_failure,omitempty" yaml:"allow_failure,omitempty"` // Failure warns instead of aborting the run
+	Idempotent   bool     `mapstructure:"idempotent,omitempty" json:"idempotent,omitempty" yaml:"idempotent,omitempty"`          // Safe to re-run automatically if the connection drops mid-task
+	Timestamps   bool     `mapstructure:"timestamps,omitempty" json:"timestamps,omitempty" yaml:"timestamps,omitempty"`          // Prefix output lines with time elapsed since the task started
+	AskPass      bool     `mapstructure:"askpass,omitempty" json:"askpass,omitempty" yaml:"askpass,omitempty"`                   // Whether to prompt for password
+	Lib          bool     `mapstructure:"lib,omitempty" json:"lib,omitempty" yaml:"lib,omitempty"`                               // Whether this is a library task (not run by default)
+	Output       bool     `mapstructure:"output,omitempty" json:"output,omitempty" yaml:"output,omitempty"`                      // Whether to display command output
+	DependsOn    []string `mapstructure:"depends_on,omitempty" json:"depends_on,omitempty" yaml:"depends_on,omitempty"`          // List of task names that must run before this one
+	Environment  string   `mapstructure:"environment,omitempty" json:"environment,omitempty" yaml:"environment,omitempty"`       // Named environment whose variables are injected for this task only
+	Tags         []string `mapstructure:"tags,omitempty" json:"tags,omitempty" yaml:"tags,omitempty"`                            // Labels used to scope webhook notifications (e.g. "critical")
+
+	// Raw opts a task out of the config- and host-level preludes, for
+	// commands that must run exactly as written.
+	Raw bool `mapstructure:"raw,omitempty" json:"raw,omitempty" yaml:"raw,omitempty"`
+
+	// Source and destination for upload tasks; unused by other task types.
+	Src  string `mapstructure:"src,omitempty" json:"src,omitempty" yaml:"src,omitempty"`    // Local file to upload
+	Dest string `mapstructure:"dest,omitempty" json:"dest,omitempty" yaml:"dest,omitempty"` // Remote path the file lands at
+
+	// Manual confirmation gate for sensitive steps. An interactive run pauses
+	// and asks the operator to proceed, skip or abort before the task runs;
+	// unattended runs must opt in explicitly (--yes, or the web deploy path).
+	Confirm        bool   `mapstructure:"confirm,omitempty" json:"confirm,omitempty" yaml:"confirm,omitempty"`                         // Pause for manual confirmation before running
+	ConfirmMessage string `mapstructure:"confirm_message,omitempty" json:"confirm_message,omitempty" yaml:"confirm_message,omitempty"` // Prompt text shown at the gate (default names the task)
+
+	// Output assertions, checked after the task exits with its expected code.
+	// Both are Go regular expressions (a plain substring works as-is) and are
+	// validated at load time.
+	AssertOutput string `mapstructure:"assert_output,omitempty" json:"assert_output,omitempty" yaml:"assert_output,omitempty"` // Output must match this pattern or the task fails
+	ForbidOutput string `mapstructure:"forbid_output,omitempty" json:"forbid_output,omitempty" yaml:"forbid_output,omitempty"` // Output matching this pattern fails the task
+
+	// Informational annotations surfaced in the UI and plan output; execution
+	// logic ignores them entirely.
+	Estimate string `mapstructure:"estimate,omitempty" json:"estimate,omitempty" yaml:"estimate,omitempty"` // Expected duration (e.g. "3m"), validated at load time
+	Doc      string `mapstructure:"doc,omitempty" json:"doc,omitempty" yaml:"doc,omitempty"`                // Runbook or documentation URL for the task
+	Owner    string `mapstructure:"owner,omitempty" json:"owner,omitempty" yaml:"owner,omitempty"`          // Team or person responsible for the task
+}
+
+// EstimateDuration returns the task's estimate as a duration, or zero when no
+// estimate is set. Load validates the field, so parse errors cannot occur on
+// a loaded config.
+func (t Task) EstimateDuration() time.Duration {
+	if t.Estimate == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(t.Estimate)
+	if err != nil {
+		return 0
+	}
+	return d
 }
 
 // Load reads, parses, and validates a YAML configuration file into a Config object.
@@ -58,45 +145,282 @@ type Task struct {
 //   - *Config: populated config object
 //   - error: if validation or parsing fails
 func Load(file, appVersion string) (*Config, error) {
+	return LoadWithOptions(file, appVersion, LoadOptions{})
+}
+
+// LoadOptions bundles the per-load overrides a caller may supply on top of the
+// config file itself. The zero value loads the file as written, with local
+// inventory sources disabled.
+type LoadOptions struct {
+	// Params are request-supplied parameter overrides merged over the
+	// config's own params before placeholder substitution; precedence is
+	// override > config > global defaults.
+	Params map[string]string
+
+	// HostsFile names an ops-managed hosts file whose entries are merged
+	// over the config's hosts map. It takes precedence over the config's
+	// own hosts_file key, so a flag can retarget a deploy without editing
+	// the YAML.
+	HostsFile string
+
+	// AllowInventory permits the config's inventory and inventory_cmd
+	// sources, which read files and run commands on the loading machine.
+	// Only CLI entry points set it: a config submitted over the API must
+	// not be able to reach into the server's filesystem or shell.
+	AllowInventory bool
+}
+
+// LoadWithOptions is the options-based loader behind Load and LoadWithParams,
+// for callers that combine several per-load overrides (e.g. the web deploy
+// flow supplying both params and a hosts file).
+//
+// Parameters:
+//   - file: path to the YAML config file
+//   - appVersion: expected minimum version (usually matches CLI version)
+//   - opts: per-load overrides, zero value for none
+//
+// Returns:
+//   - *Config: populated config object with the overrides applied
+//   - error: if validation, parsing or an override fails
+func LoadWithOptions(file, appVersion string, opts LoadOptions) (*Config, error) {
 	v := viper.New()
 	v.SetConfigFile(file)
 
 	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	return parse(v, appVersion, opts)
+}
+
+// LoadWithParams is Load with request-supplied parameter overrides merged over
+// the config's own params before placeholder substitution, so a single deploy
+// can change ${branch} or similar without editing the YAML. Precedence is
+// override > config > global defaults. Overrides are validated against the
+// param_schema when the config declares one.
+//
+// Parameters:
+//   - file: path to the YAML config file
+//   - appVersion: expected minimum version (usually matches CLI version)
+//   - params: parameter overrides for this load, possibly nil
+//
+// Returns:
+//   - *Config: populated config object with the overrides applied
+//   - error: if validation, parsing or an override fails
+func LoadWithParams(file, appVersion string, params map[string]string) (*Config, error) {
+	return LoadWithOptions(file, appVersion, LoadOptions{Params: params})
+}
+
+// LoadBytes parses and validates raw YAML config content without touching the
+// filesystem. It applies the same validation rules as Load and is used to
+// validate inline content (e.g. from the web UI editor) before it is saved.
+//
+// Parameters:
+//   - content: raw YAML config content
+//   - appVersion: expected minimum version (usually matches CLI version)
+//
+// Returns:
+//   - *Config: populated config object
+//   - error: if validation or parsing fails
+func LoadBytes(content []byte, appVersion string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
 		return nil, fmt.Errorf("failed to read config: %v", err)
 	}
 
+	return parse(v, appVersion, LoadOptions{})
+}
+
+// parse unmarshals and validates a config already read into viper.
+// It is shared by Load, LoadWithOptions, LoadWithParams and LoadBytes.
+func parse(v *viper.Viper, appVersion string, opts LoadOptions) (*Config, error) {
+	// Reject typos and misindented keys before they are silently dropped;
+	// every offender is reported in one pass
+	if strictUnknownKeys {
+		if unknown := UnknownKeys(v.AllSettings()); len(unknown) > 0 {
+			return nil, fmt.Errorf("unknown key(s) in config: %s (prefix custom keys with '%s' or pass --no-strict while migrating)",
+				strings.Join(unknown, ", "), userExtensionPrefix)
+		}
+	}
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("invalid config format: %v", err)
 	}
 
+	// Merge global defaults under the config's own values before validation,
+	// so a config may rely entirely on shared hosts or params
+	defaults, err := loadDefaults(resolveDefaultsFile())
+	if err != nil {
+		return nil, err
+	}
+	applyDefaults(&cfg, defaults)
+
+	// Augment hosts from the external inventory source, if configured; the
+	// config's own host entries win on name conflicts. Inventory sources are
+	// gated to CLI loads so server-side parsing of user-submitted content
+	// cannot be steered into local file reads or command execution
+	if cfg.Inventory != "" || cfg.InventoryCmd != "" {
+		if !opts.AllowInventory {
+			return nil, fmt.Errorf("inventory and inventory_cmd are only available to CLI loads; define hosts directly or use a registered hosts file")
+		}
+		inventoryHosts, err := loadInventory(&cfg)
+		if err != nil {
+			return nil, err
+		}
+		applyInventory(&cfg, inventoryHosts)
+	}
+
+	// Merge the ops-managed hosts file over the config's hosts, so targets
+	// and credentials can live outside the repo; an explicit override names
+	// a different file than the config's own hosts_file key
+	hostsFile := opts.HostsFile
+	if hostsFile == "" {
+		hostsFile = cfg.HostsFile
+	}
+	if hostsFile != "" {
+		fileHosts, err := loadHostsFile(hostsFile)
+		if err != nil {
+			return nil, err
+		}
+		applyHostsFile(&cfg, fileHosts)
+	}
+
+	// Fill unset host fields from the host_defaults block; a key a host
+	// spells out, even as an empty string, is never overridden
+	applyHostDefaults(&cfg, explicitHostKeys(v))
+
+	// Merge deploy-request parameter overrides over the config's own params,
+	// after schema validation; the substitution below then sees the final values
+	if len(opts.Params) > 0 {
+		if err := cfg.ValidateParamOverrides(opts.Params); err != nil {
+			return nil, err
+		}
+		if cfg.Params == nil {
+			cfg.Params = make(map[string]string, len(opts.Params))
+		}
+		for key, value := range opts.Params {
+			cfg.Params[key] = value
+		}
+	}
+
 	// Basic field validation
 	if cfg.AppName == "" {
 		return nil, fmt.Errorf("appname is required")
 	}
 	if len(cfg.Hosts) == 0 {
-		return nil, fmt.Errorf("at least one host is required")
+		return nil, fmt.Errorf("at least one host is required (define hosts in the config or supply a hosts file)")
 	}
 	if len(cfg.Tasks) == 0 {
 		return nil, fmt.Errorf("at least one task is required")
 	}
+
+	// Validate per-host host key policies
+	for name, host := range cfg.Hosts {
+		switch host.HostKeyPolicy {
+		case "", "strict", "accept-new", "insecure-ignore":
+		default:
+			return nil, fmt.Errorf("host '%s': invalid host_key_policy '%s'; expected strict, accept-new or insecure-ignore", name, host.HostKeyPolicy)
+		}
+		switch host.Shell {
+		case "", "sh":
+		case "powershell":
+			// Preludes are composed with POSIX grouping and cd chains, which
+			// powershell does not speak; fail at load rather than on the host
+			if cfg.Prelude != "" || host.Prelude != "" {
+				return nil, fmt.Errorf("host '%s': preludes are not supported with the powershell shell", name)
+			}
+		default:
+			return nil, fmt.Errorf("host '%s': invalid shell '%s'; expected sh or powershell", name, host.Shell)
+		}
+		if host.ConnectTimeout != "" {
+			if d, err := time.ParseDuration(host.ConnectTimeout); err != nil || d <= 0 {
+				return nil, fmt.Errorf("host '%s': invalid connect_timeout '%s'; expected a positive duration like 30s", name, host.ConnectTimeout)
+			}
+		}
+		if host.ExecTimeout != "" {
+			if d, err := time.ParseDuration(host.ExecTimeout); err != nil || d <= 0 {
+				return nil, fmt.Errorf("host '%s': invalid exec_timeout '%s'; expected a positive duration like 10m", name, host.ExecTimeout)
+			}
+		}
+	}
 	if !strings.HasPrefix(cfg.Version, "0.") || cfg.Version < appVersion {
 		return nil, fmt.Errorf("config version %s is outdated; update to %s+", cfg.Version, appVersion)
 	}
 
 	// Validate task definitions
 	taskNames := make(map[string]bool)
+	taskIDs := make(map[string]int)
 	for i, task := range cfg.Tasks {
 		if task.Name == "" {
 			return nil, fmt.Errorf("task at index %d: name is required", i)
 		}
-		if task.Cmd == "" {
-			return nil, fmt.Errorf("task '%s': cmd is required", task.Name)
+		switch task.Type {
+		case "", TaskTypeShell:
+			if task.Cmd == "" {
+				return nil, fmt.Errorf("task '%s': cmd is required", task.Name)
+			}
+			if task.Plugin != "" {
+				return nil, fmt.Errorf("task '%s': plugin is only valid with type: plugin", task.Name)
+			}
+			if task.Src != "" || task.Dest != "" {
+				return nil, fmt.Errorf("task '%s': src/dest are only valid with type: upload", task.Name)
+			}
+		case TaskTypePlugin:
+			if task.Plugin == "" {
+				return nil, fmt.Errorf("task '%s': plugin is required for plugin tasks", task.Name)
+			}
+			// Plugin names are resolved inside the plugins directory only
+			if task.Plugin != filepath.Base(task.Plugin) {
+				return nil, fmt.Errorf("task '%s': plugin name '%s' must not contain path separators", task.Name, task.Plugin)
+			}
+		case TaskTypeUpload:
+			if task.Src == "" || task.Dest == "" {
+				return nil, fmt.Errorf("task '%s': src and dest are required for upload tasks", task.Name)
+			}
+			if task.Cmd != "" {
+				return nil, fmt.Errorf("task '%s': cmd is not used by upload tasks", task.Name)
+			}
+			if task.Plugin != "" {
+				return nil, fmt.Errorf("task '%s': plugin is only valid with type: plugin", task.Name)
+			}
+		default:
+			return nil, fmt.Errorf("task '%s': invalid type '%s'; expected shell, plugin or upload", task.Name, task.Type)
+		}
+		if task.EnsureDir && task.Dir == "" {
+			return nil, fmt.Errorf("task '%s': ensure_dir requires dir to be set", task.Name)
+		}
+		if task.ConfirmMessage != "" && !task.Confirm {
+			return nil, fmt.Errorf("task '%s': confirm_message requires confirm to be set", task.Name)
+		}
+		if task.Estimate != "" {
+			if _, err := time.ParseDuration(task.Estimate); err != nil {
+				return nil, fmt.Errorf("task '%s': invalid estimate '%s'; expected a duration like 90s or 3m", task.Name, task.Estimate)
+			}
+		}
+		if task.Doc != "" {
+			u, err := url.Parse(task.Doc)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("task '%s': invalid doc '%s'; expected an http(s) URL", task.Name, task.Doc)
+			}
+		}
+		if err := validateOutputAssertions(task); err != nil {
+			return nil, err
 		}
 		if taskNames[task.Name] {
 			return nil, fmt.Errorf("duplicate task name '%s' at index %d", task.Name, i)
 		}
 		taskNames[task.Name] = true
+		// IDs key the deployment timeline and task stats, so two tasks
+		// sharing one would silently mix their history
+		if task.ID != "" {
+			if first, seen := taskIDs[task.ID]; seen {
+				return nil, fmt.Errorf("duplicate task id '%s' at index %d (already used by task at index %d)", task.ID, i, first)
+			}
+			taskIDs[task.ID] = i
+		}
 	}
 
 	// Check that all dependencies exist
@@ -113,7 +437,8 @@ func Load(file, appVersion string) (*Config, error) {
 		return nil, err
 	}
 
-	// Set runtime timestamp for use in task substitution
+	// Default release version for this session; callers may override it with
+	// an explicit value before running (deploy request, --release-version)
 	cfg.ReleaseVersion = time.Now().UnixMilli()
 
 	// Perform placeholder substitution on command fields
@@ -158,7 +483,7 @@ func checkCircularDependencies(tasks []Task) error {
 			} else if recStack[dep] {
 				// Cycle found: format path and return error
 				cycle := append([]string{dep}, path...)
-				return fmt.Errorf("circular dependency detected: %s", strings.Join(cycle, " -> "))
+				return ErrCircularDependency{Cycle: cycle}
 			}
 		}
 
@@ -180,6 +505,9 @@ func checkCircularDependencies(tasks []Task) error {
 
 // parseLiteral replaces parameter placeholders (e.g. ${param}) in a string
 // with actual values from the config.Params map, as well as built-in values.
+// ${release_version} is deliberately left untouched here: it is resolved at
+// run time by ApplyReleaseVersion so every Load of the same deployment
+// session agrees on one value.
 //
 // Parameters:
 //   - cfg: the loaded Config object
@@ -196,10 +524,28 @@ func parseLiteral(cfg *Config, input string) string {
 		output = strings.ReplaceAll(output, fmt.Sprintf("${%s}", key), value)
 	}
 	output = strings.ReplaceAll(output, "${appname}", cfg.AppName)
-	output = strings.ReplaceAll(output, "${release_version}", fmt.Sprintf("%d", cfg.ReleaseVersion))
 	return output
 }
 
+// ApplyReleaseVersion resolves ${release_version} placeholders in the tasks'
+// command fields against a single release version. It runs at execution time
+// rather than at load time, so retries and multi-phase runs that reload the
+// config still point at the release directory created earlier, and an
+// explicit version (deploy request field, --release-version flag) can be
+// installed after loading.
+//
+// Parameters:
+//   - tasks: the tasks about to run; modified in place
+//   - version: the release version for this deployment session
+func ApplyReleaseVersion(tasks []Task, version int64) {
+	value := fmt.Sprintf("%d", version)
+	for i, task := range tasks {
+		tasks[i].Cmd = strings.ReplaceAll(task.Cmd, "${release_version}", value)
+		tasks[i].Dir = strings.ReplaceAll(task.Dir, "${release_version}", value)
+		tasks[i].Message = strings.ReplaceAll(task.Message, "${release_version}", value)
+	}
+}
+
 // LoadEnv reads key=value pairs from a file and loads them into a map,
 // skipping empty lines and comments. Used for injecting environment variables.
 //
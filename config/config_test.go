@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 	"testing"
 )
 
@@ -122,9 +124,9 @@ func TestParseLiteral(t *testing.T) {
 			expected: "myapp version 1.0.0 in production",
 		},
 		{
-			name:     "release_version contains timestamp",
+			name:     "release_version preserved for runtime substitution",
 			input:    "Release: ${release_version}",
-			expected: "Release: ", // We can't predict the exact timestamp, just check it's not empty
+			expected: "Release: ${release_version}", // Resolved later by ApplyReleaseVersion
 		},
 		{
 			name:     "no substitutions",
@@ -142,15 +144,8 @@ func TestParseLiteral(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			result := parseLiteral(config, tt.input)
 			
-			if tt.name == "release_version contains timestamp" {
-				// Special case: check that release_version was replaced with something
-				if result == tt.input || len(result) <= len("Release: ") {
-					t.Errorf("parseLiteral() failed to replace release_version")
-				}
-			} else {
-				if result != tt.expected {
-					t.Errorf("parseLiteral() = %v, want %v", result, tt.expected)
-				}
+			if result != tt.expected {
+				t.Errorf("parseLiteral() = %v, want %v", result, tt.expected)
 			}
 		})
 	}
@@ -288,4 +283,475 @@ KEY3=value with spaces`,
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestLoadValidatesPluginTasks(t *testing.T) {
+	base := `
+version: "0.1.2"
+appname: "testapp"
+hosts:
+  testhost:
+    host: "example.com"
+    username: "user"
+    password: "pass"
+tasks:
+`
+	tests := []struct {
+		name    string
+		task    string
+		wantErr bool
+	}{
+		{
+			name: "valid plugin task",
+			task: `  - name: "notify"
+    type: "plugin"
+    plugin: "slack-notify"
+`,
+			wantErr: false,
+		},
+		{
+			name: "plugin task without plugin name",
+			task: `  - name: "notify"
+    type: "plugin"
+`,
+			wantErr: true,
+		},
+		{
+			name: "plugin name with path separator",
+			task: `  - name: "notify"
+    type: "plugin"
+    plugin: "../outside"
+`,
+			wantErr: true,
+		},
+		{
+			name: "unknown task type",
+			task: `  - name: "notify"
+    type: "docker"
+    cmd: "echo hi"
+`,
+			wantErr: true,
+		},
+		{
+			name: "shell task with plugin field",
+			task: `  - name: "notify"
+    cmd: "echo hi"
+    plugin: "slack-notify"
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadBytes([]byte(base+tt.task), "0.1.2")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadValidatesEnsureDir(t *testing.T) {
+	_, err := LoadBytes([]byte(`
+version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 192.168.1.1
+    username: admin
+tasks:
+  - name: build
+    cmd: make
+    ensure_dir: true
+`), "0.1.2")
+	if err == nil || !strings.Contains(err.Error(), "ensure_dir requires dir") {
+		t.Errorf("ensure_dir without dir error = %v, want it rejected", err)
+	}
+}
+
+func TestLoadParsesAllowFailure(t *testing.T) {
+	cfg, err := LoadBytes([]byte(`
+version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 192.168.1.1
+    username: admin
+tasks:
+  - name: warm_cache
+    cmd: ./warm-cache.sh
+    allow_failure: true
+`), "0.1.2")
+	if err != nil {
+		t.Fatalf("LoadBytes() error = %v", err)
+	}
+	if !cfg.Tasks[0].AllowFailure {
+		t.Error("allow_failure: true should be parsed onto the task")
+	}
+}
+
+func TestApplyReleaseVersion(t *testing.T) {
+	tasks := []Task{
+		{Name: "setup", Cmd: "mkdir -p /var/www/app/releases/${release_version}"},
+		{Name: "link", Cmd: "ln -sfn /var/www/app/releases/${release_version} current", Dir: "/var/www/app/releases/${release_version}", Message: "released ${release_version}"},
+	}
+	ApplyReleaseVersion(tasks, 1234567890)
+
+	if tasks[0].Cmd != "mkdir -p /var/www/app/releases/1234567890" {
+		t.Errorf("cmd = %q, want the release version substituted", tasks[0].Cmd)
+	}
+	if tasks[1].Dir != "/var/www/app/releases/1234567890" || tasks[1].Message != "released 1234567890" {
+		t.Errorf("dir/message = %q/%q, want the release version substituted", tasks[1].Dir, tasks[1].Message)
+	}
+
+	// Every field sees the same value: multi-phase runs must agree
+	if tasks[0].Cmd[len(tasks[0].Cmd)-10:] != tasks[1].Message[len(tasks[1].Message)-10:] {
+		t.Error("tasks resolved to different release versions")
+	}
+}
+
+func TestLoadParsesTaskAnnotations(t *testing.T) {
+	configContent := `
+version: "0.1.2"
+appname: "testapp"
+hosts:
+  testhost:
+    host: "example.com"
+    username: "user"
+    password: "pass"
+tasks:
+  - name: "migrate"
+    cmd: "php artisan migrate"
+    estimate: "3m"
+    doc: "https://wiki.example.com/runbooks/migrate"
+    owner: "platform-team"
+`
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath, "0.1.2")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	task := cfg.Tasks[0]
+	if task.Estimate != "3m" || task.Doc != "https://wiki.example.com/runbooks/migrate" || task.Owner != "platform-team" {
+		t.Errorf("annotations = (%q, %q, %q), want them carried through", task.Estimate, task.Doc, task.Owner)
+	}
+	if task.EstimateDuration() != 3*time.Minute {
+		t.Errorf("EstimateDuration() = %v, want 3m", task.EstimateDuration())
+	}
+}
+
+func TestLoadRejectsInvalidTaskAnnotations(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{"bad estimate", `    estimate: "soonish"`, "invalid estimate"},
+		{"bad doc scheme", `    doc: "ftp://wiki/runbook"`, "invalid doc"},
+		{"doc without host", `    doc: "https://"`, "invalid doc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := `
+version: "0.1.2"
+appname: "testapp"
+hosts:
+  testhost:
+    host: "example.com"
+    username: "user"
+    password: "pass"
+tasks:
+  - name: "migrate"
+    cmd: "php artisan migrate"
+` + tt.extra + "\n"
+			configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+			if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			_, err := Load(configPath, "0.1.2")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadParsesPreludes(t *testing.T) {
+	configContent := `
+version: "0.1.2"
+appname: "testapp"
+prelude: "source /etc/profile.d/node.sh"
+hosts:
+  testhost:
+    host: "example.com"
+    username: "user"
+    password: "pass"
+    prelude: "export PATH=$PATH:/opt/node/bin"
+tasks:
+  - name: "build"
+    cmd: "npm run build"
+    raw: true
+`
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath, "0.1.2")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Prelude != "source /etc/profile.d/node.sh" {
+		t.Errorf("config prelude = %q, want it carried through", cfg.Prelude)
+	}
+	if got := cfg.Hosts["testhost"].Prelude; got != "export PATH=$PATH:/opt/node/bin" {
+		t.Errorf("host prelude = %q, want it carried through", got)
+	}
+	if !cfg.Tasks[0].Raw {
+		t.Error("raw flag was not carried through")
+	}
+}
+
+func TestLoadRejectsPreludeWithPowershell(t *testing.T) {
+	configContent := `
+version: "0.1.2"
+appname: "testapp"
+prelude: "source /etc/profile.d/node.sh"
+hosts:
+  winhost:
+    host: "example.com"
+    username: "user"
+    password: "pass"
+    shell: "powershell"
+tasks:
+  - name: "build"
+    cmd: "npm run build"
+`
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	_, err := Load(configPath, "0.1.2")
+	if err == nil || !strings.Contains(err.Error(), "preludes are not supported with the powershell shell") {
+		t.Errorf("Load() error = %v, want the prelude rejected for powershell", err)
+	}
+
+	// An unknown shell value is rejected outright
+	badShell := strings.Replace(configContent, `shell: "powershell"`, `shell: "fish"`, 1)
+	if err := os.WriteFile(configPath, []byte(badShell), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	_, err = Load(configPath, "0.1.2")
+	if err == nil || !strings.Contains(err.Error(), "invalid shell") {
+		t.Errorf("Load() error = %v, want the unknown shell rejected", err)
+	}
+}
+
+func TestLoadRejectsDuplicateTaskIDs(t *testing.T) {
+	configContent := `
+version: "0.1.2"
+appname: "testapp"
+hosts:
+  testhost:
+    host: "example.com"
+    username: "user"
+    password: "pass"
+tasks:
+  - name: "build"
+    id: "6e7f7a1a-8a41-4e58-9c07-1d6a2a3b4c5d"
+    cmd: "npm run build"
+  - name: "deploy"
+    id: "6e7f7a1a-8a41-4e58-9c07-1d6a2a3b4c5d"
+    cmd: "npm run deploy"
+`
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	_, err := Load(configPath, "0.1.2")
+	if err == nil || !strings.Contains(err.Error(), "duplicate task id") {
+		t.Errorf("Load() error = %v, want the duplicate task id rejected", err)
+	}
+
+	// Tasks without IDs are still fine; uniqueness only applies to set IDs
+	noIDs := strings.ReplaceAll(configContent, `    id: "6e7f7a1a-8a41-4e58-9c07-1d6a2a3b4c5d"`+"\n", "")
+	if err := os.WriteFile(configPath, []byte(noIDs), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if _, err := Load(configPath, "0.1.2"); err != nil {
+		t.Errorf("Load() error = %v, want ID-less tasks accepted", err)
+	}
+}
+
+func TestLoadValidatesHostTimeouts(t *testing.T) {
+	configContent := `
+version: "0.1.2"
+appname: "testapp"
+hosts:
+  testhost:
+    host: "example.com"
+    username: "user"
+    password: "pass"
+    connect_timeout: "30s"
+    exec_timeout: "10m"
+tasks:
+  - name: "build"
+    cmd: "make build"
+`
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath, "0.1.2")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	host := cfg.Hosts["testhost"]
+	if host.ConnectTimeout != "30s" || host.ExecTimeout != "10m" {
+		t.Errorf("host timeouts = %q/%q, want them carried through", host.ConnectTimeout, host.ExecTimeout)
+	}
+
+	// Unparsable and non-positive durations are rejected at load
+	for _, bad := range []string{
+		`connect_timeout: "soon"`,
+		`exec_timeout: "-1m"`,
+	} {
+		content := strings.Replace(configContent, `connect_timeout: "30s"`, bad, 1)
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test config: %v", err)
+		}
+		if _, err := Load(configPath, "0.1.2"); err == nil {
+			t.Errorf("Load() accepted %s", bad)
+		}
+	}
+}
+
+func TestLoadValidatesUploadTasks(t *testing.T) {
+	base := `
+version: "0.1.2"
+appname: "testapp"
+hosts:
+  testhost:
+    host: "example.com"
+    username: "user"
+    password: "pass"
+tasks:
+`
+	tests := []struct {
+		name    string
+		task    string
+		wantErr bool
+	}{
+		{
+			name: "valid upload task",
+			task: `  - name: "ship artifact"
+    type: "upload"
+    src: "./build/app.tar.gz"
+    dest: "/var/www/releases/app.tar.gz"
+`,
+			wantErr: false,
+		},
+		{
+			name: "upload task without src",
+			task: `  - name: "ship artifact"
+    type: "upload"
+    dest: "/var/www/releases/app.tar.gz"
+`,
+			wantErr: true,
+		},
+		{
+			name: "upload task without dest",
+			task: `  - name: "ship artifact"
+    type: "upload"
+    src: "./build/app.tar.gz"
+`,
+			wantErr: true,
+		},
+		{
+			name: "upload task with cmd",
+			task: `  - name: "ship artifact"
+    type: "upload"
+    src: "./build/app.tar.gz"
+    dest: "/var/www/releases/app.tar.gz"
+    cmd: "echo hi"
+`,
+			wantErr: true,
+		},
+		{
+			name: "shell task with src and dest",
+			task: `  - name: "ship artifact"
+    cmd: "echo hi"
+    src: "./build/app.tar.gz"
+    dest: "/var/www/releases/app.tar.gz"
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadBytes([]byte(base+tt.task), "0.1.2")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadParsesConfirmGates(t *testing.T) {
+	content := []byte(`
+version: "0.1.2"
+appname: testapp
+hosts:
+  server1:
+    host: example.com
+    username: deploy
+    password: secret
+tasks:
+  - name: migrate
+    cmd: ./migrate.sh
+    confirm: true
+    confirm_message: "Run database migrations on production?"
+`)
+
+	cfg, err := LoadBytes(content, "0.1.2")
+	if err != nil {
+		t.Fatalf("LoadBytes failed: %v", err)
+	}
+	task := cfg.Tasks[0]
+	if !task.Confirm || task.ConfirmMessage != "Run database migrations on production?" {
+		t.Errorf("confirm gate = (%v, %q), want the configured gate", task.Confirm, task.ConfirmMessage)
+	}
+}
+
+func TestLoadRejectsConfirmMessageWithoutConfirm(t *testing.T) {
+	content := []byte(`
+version: "0.1.2"
+appname: testapp
+hosts:
+  server1:
+    host: example.com
+    username: deploy
+    password: secret
+tasks:
+  - name: migrate
+    cmd: ./migrate.sh
+    confirm_message: "orphaned prompt text"
+`)
+
+	if _, err := LoadBytes(content, "0.1.2"); err == nil {
+		t.Fatal("confirm_message without confirm must be rejected")
+	}
+}
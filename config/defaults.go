@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultsFileEnv is the environment variable naming the global defaults
+// file, used when no explicit path is set through DefaultsFile.
+const DefaultsFileEnv = "NYATI_DEFAULTS_FILE"
+
+// DefaultsFile is the path to an optional global defaults file whose params
+// and hosts are merged under every loaded config. It is set from the
+// --defaults CLI flag or the server configuration; when empty, the
+// NYATI_DEFAULTS_FILE environment variable is consulted instead.
+var DefaultsFile string
+
+// Defaults holds organization-wide values shared across configs, so standard
+// params (company, region, registry) and common hosts live in one file
+// instead of being repeated in every nyati.yaml. Config-local values always
+// take precedence over defaults.
+type Defaults struct {
+	Hosts  map[string]Host   `mapstructure:"hosts"`  // Hosts added unless the config defines the same name
+	Params map[string]string `mapstructure:"params"` // Params added unless the config defines the same key
+}
+
+// resolveDefaultsFile returns the effective defaults file path: the
+// explicitly configured one, or the environment variable fallback.
+func resolveDefaultsFile() string {
+	if DefaultsFile != "" {
+		return DefaultsFile
+	}
+	return os.Getenv(DefaultsFileEnv)
+}
+
+// loadDefaults reads and parses the global defaults file. A missing file is
+// not an error — defaults are optional — but a present file that cannot be
+// parsed is, so typos don't silently disable shared values.
+//
+// Parameters:
+//   - path: path to the defaults file; empty disables defaults
+//
+// Returns:
+//   - *Defaults: the parsed defaults, nil when none are configured
+//   - error: if an existing defaults file cannot be read or parsed
+func loadDefaults(path string) (*Defaults, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read defaults file %s: %v", path, err)
+	}
+
+	var defaults Defaults
+	if err := v.Unmarshal(&defaults); err != nil {
+		return nil, fmt.Errorf("invalid defaults file %s: %v", path, err)
+	}
+	return &defaults, nil
+}
+
+// applyDefaults merges defaults under a config: params and hosts the config
+// does not define itself are filled in, while config-local values are never
+// overwritten.
+//
+// Parameters:
+//   - cfg: the config being loaded
+//   - defaults: the global defaults, possibly nil
+func applyDefaults(cfg *Config, defaults *Defaults) {
+	if defaults == nil {
+		return
+	}
+
+	if len(defaults.Params) > 0 && cfg.Params == nil {
+		cfg.Params = make(map[string]string, len(defaults.Params))
+	}
+	for key, value := range defaults.Params {
+		if _, ok := cfg.Params[key]; !ok {
+			cfg.Params[key] = value
+		}
+	}
+
+	if len(defaults.Hosts) > 0 && cfg.Hosts == nil {
+		cfg.Hosts = make(map[string]Host, len(defaults.Hosts))
+	}
+	for name, host := range defaults.Hosts {
+		if _, ok := cfg.Hosts[name]; !ok {
+			cfg.Hosts[name] = host
+		}
+	}
+}
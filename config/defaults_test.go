@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withDefaultsFile points DefaultsFile at a temp file with the given content
+// for the duration of the test.
+func withDefaultsFile(t *testing.T, content string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "defaults.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write defaults file: %v", err)
+	}
+	DefaultsFile = path
+	t.Cleanup(func() { DefaultsFile = "" })
+}
+
+func TestDefaultsMergeUnderConfig(t *testing.T) {
+	withDefaultsFile(t, `
+params:
+  company: acme
+  region: eu-west-1
+hosts:
+  shared:
+    host: shared.example.com
+    username: deploy
+`)
+
+	cfg, err := LoadBytes([]byte(`
+version: "0.1.2"
+appname: demo
+params:
+  region: us-east-1
+hosts:
+  server1:
+    host: 192.168.1.1
+    username: admin
+tasks:
+  - name: build
+    cmd: echo ${company} ${region}
+`), "0.1.2")
+	if err != nil {
+		t.Fatalf("Load with defaults failed: %v", err)
+	}
+
+	// Default-only values are filled in
+	if cfg.Params["company"] != "acme" {
+		t.Errorf("company = %q, want the default %q", cfg.Params["company"], "acme")
+	}
+	if _, ok := cfg.Hosts["shared"]; !ok {
+		t.Error("default host 'shared' was not merged in")
+	}
+
+	// Config-local values always win
+	if cfg.Params["region"] != "us-east-1" {
+		t.Errorf("region = %q, want the config-local %q", cfg.Params["region"], "us-east-1")
+	}
+	if cfg.Hosts["server1"].Username != "admin" {
+		t.Errorf("server1 username = %q, want %q", cfg.Hosts["server1"].Username, "admin")
+	}
+
+	// Default params participate in placeholder substitution
+	if cfg.Tasks[0].Cmd != "echo acme us-east-1" {
+		t.Errorf("cmd = %q, want substituted default and local params", cfg.Tasks[0].Cmd)
+	}
+}
+
+func TestDefaultsCanSatisfyRequiredHosts(t *testing.T) {
+	withDefaultsFile(t, `
+hosts:
+  shared:
+    host: shared.example.com
+    username: deploy
+`)
+
+	cfg, err := LoadBytes([]byte(`
+version: "0.1.2"
+appname: demo
+tasks:
+  - name: build
+    cmd: make
+`), "0.1.2")
+	if err != nil {
+		t.Fatalf("config with only default hosts should load: %v", err)
+	}
+	if len(cfg.Hosts) != 1 {
+		t.Errorf("hosts = %d, want 1 from defaults", len(cfg.Hosts))
+	}
+}
+
+func TestMissingDefaultsFileIsNonFatal(t *testing.T) {
+	DefaultsFile = filepath.Join(t.TempDir(), "does-not-exist.yaml")
+	t.Cleanup(func() { DefaultsFile = "" })
+
+	_, err := LoadBytes([]byte(`
+version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 192.168.1.1
+    username: admin
+tasks:
+  - name: build
+    cmd: make
+`), "0.1.2")
+	if err != nil {
+		t.Errorf("absent defaults file must not fail loading: %v", err)
+	}
+}
+
+func TestInvalidDefaultsFileFailsLoading(t *testing.T) {
+	withDefaultsFile(t, "params: [not, a, map]\n")
+
+	_, err := LoadBytes([]byte(`
+version: "0.1.2"
+appname: demo
+hosts:
+  server1:
+    host: 192.168.1.1
+    username: admin
+tasks:
+  - name: build
+    cmd: make
+`), "0.1.2")
+	if err == nil {
+		t.Error("a present but invalid defaults file should fail loading")
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrTaskNotFound reports a reference to a task name that does not exist in
+// the config. Callers can match it with errors.As instead of string matching.
+type ErrTaskNotFound struct {
+	Name string // The task name that was looked up
+}
+
+// Error implements the error interface with the historical message format.
+func (e ErrTaskNotFound) Error() string {
+	return fmt.Sprintf("task '%s' not found", e.Name)
+}
+
+// ErrInvalidParam reports a deploy-request parameter override rejected by the
+// config's param_schema.
+type ErrInvalidParam struct {
+	Key    string // The overridden parameter
+	Reason string // Why the override was rejected
+}
+
+// Error implements the error interface.
+func (e ErrInvalidParam) Error() string {
+	return fmt.Sprintf("param '%s': %s", e.Key, e.Reason)
+}
+
+// ErrCircularDependency reports a dependency cycle between tasks.
+type ErrCircularDependency struct {
+	Cycle []string // The task names forming the cycle, in traversal order
+}
+
+// Error implements the error interface with the historical message format.
+func (e ErrCircularDependency) Error() string {
+	return fmt.Sprintf("circular dependency detected: %s", strings.Join(e.Cycle, " -> "))
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrCircularDependencyFromParse(t *testing.T) {
+	err := checkCircularDependencies([]Task{
+		{Name: "a", Cmd: "true", DependsOn: []string{"b"}},
+		{Name: "b", Cmd: "true", DependsOn: []string{"a"}},
+	})
+
+	var circular ErrCircularDependency
+	if !errors.As(err, &circular) {
+		t.Fatalf("checkCircularDependencies() error = %v, want ErrCircularDependency", err)
+	}
+	if len(circular.Cycle) < 2 {
+		t.Errorf("ErrCircularDependency.Cycle = %v, want the tasks forming the cycle", circular.Cycle)
+	}
+}
+
+func TestErrTaskNotFoundMessage(t *testing.T) {
+	err := ErrTaskNotFound{Name: "clean"}
+	if err.Error() != "task 'clean' not found" {
+		t.Errorf("Error() = %q, message format changed", err.Error())
+	}
+
+	wrapped := errors.Join(errors.New("context"), err)
+	var notFound ErrTaskNotFound
+	if !errors.As(wrapped, &notFound) || notFound.Name != "clean" {
+		t.Errorf("errors.As on wrapped error failed: %+v", notFound)
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// HostDefaults holds connection details shared by every host, so a fleet
+// using one deploy user and key does not repeat them per host. A host
+// inherits each default it does not mention; a key the host spells out —
+// even as an empty string — always wins, so an explicit empty value can
+// disable a default. The host address itself is deliberately not part of
+// the defaults: every host must name its own.
+type HostDefaults struct {
+	Username      string   `mapstructure:"username" yaml:"username,omitempty"`               // Default SSH username
+	Password      string   `mapstructure:"password" yaml:"password,omitempty"`               // Default password
+	PrivateKey    string   `mapstructure:"private_key" yaml:"private_key,omitempty"`         // Default private key path
+	EnvFile       string   `mapstructure:"envfile" yaml:"envfile,omitempty"`                 // Default environment file
+	EnvName       string   `mapstructure:"env_name" yaml:"env_name,omitempty"`               // Default named environment
+	Labels        []string `mapstructure:"labels" yaml:"labels,omitempty"`                   // Default labels
+	Shell         string   `mapstructure:"shell" yaml:"shell,omitempty"`                     // Default remote shell family
+	KnownHosts    string   `mapstructure:"known_hosts" yaml:"known_hosts,omitempty"`         // Default known_hosts file path
+	HostKeyPolicy string   `mapstructure:"host_key_policy" yaml:"host_key_policy,omitempty"` // Default host key policy
+}
+
+// explicitHostKeys records which keys each host spells out in the raw
+// document, so an explicit empty value can be told apart from an absent key
+// when defaults are applied. Viper lowercases keys, matching the
+// mapstructure tags.
+//
+// Parameters:
+//   - v: the viper instance the config was read into
+//
+// Returns:
+//   - map[string]map[string]bool: per host name, the set of present keys
+func explicitHostKeys(v *viper.Viper) map[string]map[string]bool {
+	explicit := make(map[string]map[string]bool)
+	for name, raw := range v.GetStringMap("hosts") {
+		fields, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		keys := make(map[string]bool, len(fields))
+		for key := range fields {
+			keys[key] = true
+		}
+		explicit[name] = keys
+	}
+	return explicit
+}
+
+// applyHostDefaults fills each host's unset fields from the host_defaults
+// block. Resolution happens at load time, before validation, so downstream
+// code — the runner, dry-run plans, host listings — always sees fully
+// populated Host structs. Hosts without an entry in explicit (e.g. from an
+// inventory source) inherit every default their fields leave empty.
+//
+// Parameters:
+//   - cfg: the config being loaded
+//   - explicit: per-host keys present in the raw document
+func applyHostDefaults(cfg *Config, explicit map[string]map[string]bool) {
+	d := cfg.HostDefaults
+	for name, host := range cfg.Hosts {
+		keys := explicit[name]
+		inherit := func(field *string, key, def string) {
+			if def != "" && *field == "" && !keys[key] {
+				*field = def
+			}
+		}
+		inherit(&host.Username, "username", d.Username)
+		inherit(&host.Password, "password", d.Password)
+		inherit(&host.PrivateKey, "private_key", d.PrivateKey)
+		inherit(&host.EnvFile, "envfile", d.EnvFile)
+		inherit(&host.EnvName, "env_name", d.EnvName)
+		inherit(&host.Shell, "shell", d.Shell)
+		inherit(&host.KnownHosts, "known_hosts", d.KnownHosts)
+		inherit(&host.HostKeyPolicy, "host_key_policy", d.HostKeyPolicy)
+		if len(d.Labels) > 0 && len(host.Labels) == 0 && !keys["labels"] {
+			host.Labels = append([]string(nil), d.Labels...)
+		}
+		cfg.Hosts[name] = host
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAppliesHostDefaults(t *testing.T) {
+	configContent := `
+version: "0.1.2"
+appname: "testapp"
+host_defaults:
+  username: "deploy"
+  private_key: "~/.ssh/fleet_key"
+  shell: "sh"
+  labels: ["fleet"]
+hosts:
+  web1:
+    host: "web1.example.com"
+  web2:
+    host: "web2.example.com"
+    username: "admin"
+    labels: ["db"]
+  web3:
+    host: "web3.example.com"
+    password: "pass"
+    private_key: ""
+tasks:
+  - name: "build"
+    cmd: "make build"
+`
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath, "0.1.2")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// A host that says nothing inherits every default
+	web1 := cfg.Hosts["web1"]
+	if web1.Username != "deploy" || web1.PrivateKey != "~/.ssh/fleet_key" || web1.Shell != "sh" {
+		t.Errorf("web1 = %+v, want the defaults inherited", web1)
+	}
+	if len(web1.Labels) != 1 || web1.Labels[0] != "fleet" {
+		t.Errorf("web1 labels = %v, want the default labels", web1.Labels)
+	}
+
+	// Host-level values win over defaults
+	web2 := cfg.Hosts["web2"]
+	if web2.Username != "admin" {
+		t.Errorf("web2 username = %q, want the host's own value kept", web2.Username)
+	}
+	if len(web2.Labels) != 1 || web2.Labels[0] != "db" {
+		t.Errorf("web2 labels = %v, want the host's own labels kept", web2.Labels)
+	}
+	if web2.PrivateKey != "~/.ssh/fleet_key" {
+		t.Errorf("web2 private_key = %q, want the unmentioned field inherited", web2.PrivateKey)
+	}
+
+	// An explicit empty value disables the default rather than inheriting it
+	if web3 := cfg.Hosts["web3"]; web3.PrivateKey != "" {
+		t.Errorf("web3 private_key = %q, want the explicit empty value respected", web3.PrivateKey)
+	}
+}
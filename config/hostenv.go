@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApplyHostEnv substitutes ${env.KEY} placeholders in host connection fields
+// with variables from the selected environment, so one config can target
+// different servers with different credentials per environment (e.g.
+// `host: ${env.PROD_HOST}` resolved with --env production).
+//
+// Only connection-related fields are rewritten: host, username, password and
+// private_key. Placeholders without a matching variable are left untouched so
+// connection errors point at the unresolved name instead of an empty value.
+//
+// Parameters:
+//   - cfg: the config whose hosts are rewritten in place
+//   - vars: variables of the selected environment
+func ApplyHostEnv(cfg *Config, vars map[string]string) {
+	for name, host := range cfg.Hosts {
+		host.Host = applyEnvVars(host.Host, vars)
+		host.Username = applyEnvVars(host.Username, vars)
+		host.Password = applyEnvVars(host.Password, vars)
+		host.PrivateKey = applyEnvVars(host.PrivateKey, vars)
+		cfg.Hosts[name] = host
+	}
+}
+
+// applyEnvVars substitutes ${env.KEY} placeholders in a single value.
+func applyEnvVars(value string, vars map[string]string) string {
+	if !strings.Contains(value, "${env.") {
+		return value
+	}
+	for k, v := range vars {
+		value = strings.ReplaceAll(value, fmt.Sprintf("${env.%s}", k), v)
+	}
+	return value
+}
@@ -0,0 +1,65 @@
+package config
+
+import "testing"
+
+// hostEnvConfig returns a config whose host fields reference environment
+// variables instead of literal values.
+func hostEnvConfig() *Config {
+	return &Config{
+		Version: "0.1.2",
+		AppName: "demo",
+		Hosts: map[string]Host{
+			"app": {
+				Host:       "${env.APP_HOST}",
+				Username:   "${env.APP_USER}",
+				PrivateKey: "${env.APP_KEY}",
+			},
+		},
+		Tasks: []Task{{Name: "noop", Cmd: "true"}},
+	}
+}
+
+func TestApplyHostEnvResolvesCredentials(t *testing.T) {
+	production := map[string]string{
+		"APP_HOST": "prod.example.com",
+		"APP_USER": "deploy",
+		"APP_KEY":  "/keys/prod_id_rsa",
+	}
+	staging := map[string]string{
+		"APP_HOST": "staging.example.com",
+		"APP_USER": "staging-deploy",
+		"APP_KEY":  "/keys/staging_id_rsa",
+	}
+
+	prodCfg := hostEnvConfig()
+	ApplyHostEnv(prodCfg, production)
+	if host := prodCfg.Hosts["app"]; host.Host != "prod.example.com" ||
+		host.Username != "deploy" || host.PrivateKey != "/keys/prod_id_rsa" {
+		t.Errorf("production host not resolved: %+v", host)
+	}
+
+	stagingCfg := hostEnvConfig()
+	ApplyHostEnv(stagingCfg, staging)
+	if host := stagingCfg.Hosts["app"]; host.Host != "staging.example.com" ||
+		host.Username != "staging-deploy" || host.PrivateKey != "/keys/staging_id_rsa" {
+		t.Errorf("staging host not resolved: %+v", host)
+	}
+}
+
+func TestApplyHostEnvLeavesLiteralsAndUnknownsAlone(t *testing.T) {
+	cfg := hostEnvConfig()
+	cfg.Hosts["db"] = Host{Host: "10.0.0.5", Username: "root", Password: "${env.DB_PASS}"}
+
+	ApplyHostEnv(cfg, map[string]string{"APP_HOST": "prod.example.com"})
+
+	if db := cfg.Hosts["db"]; db.Host != "10.0.0.5" || db.Username != "root" {
+		t.Errorf("literal host fields changed: %+v", db)
+	}
+	// Unknown variables stay as placeholders so failures name the variable
+	if db := cfg.Hosts["db"]; db.Password != "${env.DB_PASS}" {
+		t.Errorf("unresolved placeholder rewritten: %q", db.Password)
+	}
+	if app := cfg.Hosts["app"]; app.Username != "${env.APP_USER}" {
+		t.Errorf("unresolved placeholder rewritten: %q", app.Username)
+	}
+}
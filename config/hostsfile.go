@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+// hostsFileDocument is the shape of a hosts file: the same hosts map a
+// nyati.yaml carries, so entries move between the repo config and the
+// ops-managed file without translation.
+type hostsFileDocument struct {
+	Hosts map[string]Host `mapstructure:"hosts"` // Hosts merged over the config's own, file entries winning
+}
+
+// loadHostsFile reads a hosts file and returns its hosts map. Unlike an
+// inventory, a hosts file is the authoritative target list, so a file that
+// defines no hosts is an error rather than a silent no-op.
+//
+// Parameters:
+//   - path: path to the YAML/JSON hosts file
+//
+// Returns:
+//   - map[string]Host: the hosts defined by the file
+//   - error: if the file cannot be read, parsed, or defines no hosts
+func loadHostsFile(path string) (map[string]Host, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("hosts file %s is not readable: %v", path, err)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read hosts file %s: %v", path, err)
+	}
+
+	var doc hostsFileDocument
+	if err := v.Unmarshal(&doc); err != nil {
+		return nil, fmt.Errorf("invalid hosts file format in %s: %v", path, err)
+	}
+	if len(doc.Hosts) == 0 {
+		return nil, fmt.Errorf("hosts file %s defines no hosts", path)
+	}
+
+	return doc.Hosts, nil
+}
+
+// ValidateHostsFile checks that a hosts file is readable and defines at least
+// one host, without loading a config around it. The web API uses it to vet a
+// hosts file at registration time rather than mid-deploy.
+//
+// Parameters:
+//   - path: path to the YAML/JSON hosts file
+//
+// Returns:
+//   - error: if the file cannot be read, parsed, or defines no hosts
+func ValidateHostsFile(path string) error {
+	_, err := loadHostsFile(path)
+	return err
+}
+
+// applyHostsFile merges hosts-file entries over the config's own hosts: on a
+// name conflict the file wins, so ops can repoint a target the repo config
+// also defines. The merged hosts pass through the same validation as hosts
+// written in the config itself.
+//
+// Parameters:
+//   - cfg: the config being loaded
+//   - hosts: the hosts-file hosts, possibly nil
+func applyHostsFile(cfg *Config, hosts map[string]Host) {
+	if len(hosts) > 0 && cfg.Hosts == nil {
+		cfg.Hosts = make(map[string]Host, len(hosts))
+	}
+	for name, host := range hosts {
+		cfg.Hosts[name] = host
+	}
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeHostsFile writes a hosts document into dir and returns its path.
+func writeHostsFile(t *testing.T, dir, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "hosts.prod.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write hosts file: %v", err)
+	}
+	return path
+}
+
+// writeHostsTestConfig writes a minimal config with the given hosts section
+// and extra top-level lines, and returns its path.
+func writeHostsTestConfig(t *testing.T, dir, hostsSection, extraLines string) string {
+	t.Helper()
+
+	configContent := fmt.Sprintf(`
+version: "0.1.2"
+appname: "testapp"
+%s
+%s
+tasks:
+  - name: "build"
+    cmd: "make build"
+`, extraLines, hostsSection)
+
+	configPath := filepath.Join(dir, "nyati.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	return configPath
+}
+
+func TestLoadMergesHostsFileOverConfigHosts(t *testing.T) {
+	dir := t.TempDir()
+	hostsPath := writeHostsFile(t, dir, `
+hosts:
+  web1:
+    host: "web1.prod.example.com"
+    username: "ops"
+    password: "pass"
+  db1:
+    host: "db1.prod.example.com"
+    username: "ops"
+    password: "pass"
+`)
+
+	hostsSection := `hosts:
+  web1:
+    host: "web1.staging.example.com"
+    username: "dev"
+    password: "pass"`
+	configPath := writeHostsTestConfig(t, dir, hostsSection, "")
+
+	cfg, err := LoadWithOptions(configPath, "0.1.2", LoadOptions{HostsFile: hostsPath})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+
+	// The hosts file wins on a name conflict, unlike an inventory
+	if web1 := cfg.Hosts["web1"]; web1.Host != "web1.prod.example.com" || web1.Username != "ops" {
+		t.Errorf("web1 = %+v, want the hosts-file entry to override the config's", web1)
+	}
+	if _, ok := cfg.Hosts["db1"]; !ok {
+		t.Errorf("hosts = %v, want the hosts-file-only entry present", cfg.Hosts)
+	}
+}
+
+func TestLoadHonorsHostsFileKey(t *testing.T) {
+	dir := t.TempDir()
+	hostsPath := writeHostsFile(t, dir, `
+hosts:
+  web1:
+    host: "web1.prod.example.com"
+    username: "ops"
+    password: "pass"
+`)
+
+	// No hosts in the config at all; the hosts_file key supplies them
+	configPath := writeHostsTestConfig(t, dir, "", "hosts_file: "+hostsPath)
+
+	cfg, err := Load(configPath, "0.1.2")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if web1 := cfg.Hosts["web1"]; web1.Host != "web1.prod.example.com" {
+		t.Errorf("web1 = %+v, want the hosts_file entry loaded", web1)
+	}
+}
+
+func TestLoadHostsFileOverrideBeatsConfigKey(t *testing.T) {
+	dir := t.TempDir()
+	stagingPath := writeHostsFile(t, dir, `
+hosts:
+  web1:
+    host: "web1.staging.example.com"
+    username: "ops"
+    password: "pass"
+`)
+	prodPath := filepath.Join(dir, "hosts.override.yaml")
+	if err := os.WriteFile(prodPath, []byte(`
+hosts:
+  web1:
+    host: "web1.prod.example.com"
+    username: "ops"
+    password: "pass"
+`), 0644); err != nil {
+		t.Fatalf("Failed to write override hosts file: %v", err)
+	}
+
+	configPath := writeHostsTestConfig(t, dir, "", "hosts_file: "+stagingPath)
+
+	cfg, err := LoadWithOptions(configPath, "0.1.2", LoadOptions{HostsFile: prodPath})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+	if web1 := cfg.Hosts["web1"]; web1.Host != "web1.prod.example.com" {
+		t.Errorf("web1 = %+v, want the explicit override to beat the hosts_file key", web1)
+	}
+}
+
+func TestLoadFailsWithoutHostsOrHostsFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := writeHostsTestConfig(t, dir, "", "")
+
+	_, err := Load(configPath, "0.1.2")
+	if err == nil || !strings.Contains(err.Error(), "at least one host is required") {
+		t.Errorf("Load() error = %v, want the zero-hosts config rejected", err)
+	}
+}
+
+func TestLoadValidatesHostsFileEntries(t *testing.T) {
+	dir := t.TempDir()
+	hostsPath := writeHostsFile(t, dir, `
+hosts:
+  web1:
+    host: "web1.prod.example.com"
+    username: "ops"
+    password: "pass"
+    host_key_policy: "yolo"
+`)
+	configPath := writeHostsTestConfig(t, dir, "", "")
+
+	_, err := LoadWithOptions(configPath, "0.1.2", LoadOptions{HostsFile: hostsPath})
+	if err == nil || !strings.Contains(err.Error(), "invalid host_key_policy") {
+		t.Errorf("LoadWithOptions() error = %v, want hosts-file hosts validated like config hosts", err)
+	}
+}
+
+func TestLoadRejectsEmptyHostsFile(t *testing.T) {
+	dir := t.TempDir()
+	hostsPath := writeHostsFile(t, dir, "hosts: {}\n")
+	configPath := writeHostsTestConfig(t, dir, "", "")
+
+	_, err := LoadWithOptions(configPath, "0.1.2", LoadOptions{HostsFile: hostsPath})
+	if err == nil || !strings.Contains(err.Error(), "defines no hosts") {
+		t.Errorf("LoadWithOptions() error = %v, want the empty hosts file rejected", err)
+	}
+}
+
+func TestValidateHostsFile(t *testing.T) {
+	dir := t.TempDir()
+	hostsPath := writeHostsFile(t, dir, `
+hosts:
+  web1:
+    host: "web1.prod.example.com"
+    username: "ops"
+    password: "pass"
+`)
+
+	if err := ValidateHostsFile(hostsPath); err != nil {
+		t.Errorf("ValidateHostsFile() error = %v, want a valid file accepted", err)
+	}
+	if err := ValidateHostsFile(filepath.Join(dir, "absent.yaml")); err == nil {
+		t.Error("ValidateHostsFile() accepted a missing file")
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zechtz/nyatictl/cache"
+)
+
+// inventoryTTL is how long a loaded inventory is reused before the source is
+// read (or executed) again. A short window keeps dynamic fleets fresh while
+// sparing a command-backed inventory from running for every load in quick
+// succession (e.g. a dry-run plan followed by the deploy).
+const inventoryTTL = 30 * time.Second
+
+// inventoryCache holds recently loaded inventories, keyed by source.
+var inventoryCache = cache.NewCache(inventoryTTL)
+
+// inventory is the shape of an inventory document: the same hosts map a
+// nyati.yaml carries, so entries move between the two without translation.
+type inventory struct {
+	Hosts map[string]Host `mapstructure:"hosts"` // Hosts added unless the config defines the same name
+}
+
+// loadInventory resolves the config's inventory source, if any: a JSON/YAML
+// file named by inventory, or a command named by inventory_cmd whose stdout
+// is an inventory document. Results are cached briefly so repeated loads do
+// not hammer the source.
+//
+// Parameters:
+//   - cfg: the config being loaded, carrying the inventory source fields
+//
+// Returns:
+//   - map[string]Host: the inventory hosts, nil when no source is configured
+//   - error: if both sources are set or the configured one cannot be read
+func loadInventory(cfg *Config) (map[string]Host, error) {
+	if cfg.Inventory == "" && cfg.InventoryCmd == "" {
+		return nil, nil
+	}
+	if cfg.Inventory != "" && cfg.InventoryCmd != "" {
+		return nil, fmt.Errorf("inventory and inventory_cmd are mutually exclusive")
+	}
+
+	key := "file:" + cfg.Inventory
+	if cfg.InventoryCmd != "" {
+		key = "cmd:" + cfg.InventoryCmd
+	}
+	if cached, ok := inventoryCache.Get(key); ok {
+		return cached.(map[string]Host), nil
+	}
+
+	v := viper.New()
+	if cfg.InventoryCmd != "" {
+		out, err := exec.Command("sh", "-c", cfg.InventoryCmd).Output()
+		if err != nil {
+			return nil, fmt.Errorf("inventory command '%s' failed: %v", cfg.InventoryCmd, err)
+		}
+		// YAML is a superset of the JSON these commands typically emit, so
+		// one config type covers both
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(bytes.NewReader(out)); err != nil {
+			return nil, fmt.Errorf("invalid inventory from command '%s': %v", cfg.InventoryCmd, err)
+		}
+	} else {
+		if _, err := os.Stat(cfg.Inventory); err != nil {
+			return nil, fmt.Errorf("inventory file %s is not readable: %v", cfg.Inventory, err)
+		}
+		v.SetConfigFile(cfg.Inventory)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read inventory file %s: %v", cfg.Inventory, err)
+		}
+	}
+
+	var inv inventory
+	if err := v.Unmarshal(&inv); err != nil {
+		return nil, fmt.Errorf("invalid inventory format: %v", err)
+	}
+
+	inventoryCache.Set(key, inv.Hosts)
+	return inv.Hosts, nil
+}
+
+// applyInventory merges inventory hosts under the config's own hosts: names
+// the config already defines are never overwritten, so a static entry can
+// pin credentials for a host the inventory also lists.
+//
+// Parameters:
+//   - cfg: the config being loaded
+//   - hosts: the inventory hosts, possibly nil
+func applyInventory(cfg *Config, hosts map[string]Host) {
+	if len(hosts) > 0 && cfg.Hosts == nil {
+		cfg.Hosts = make(map[string]Host, len(hosts))
+	}
+	for name, host := range hosts {
+		if _, ok := cfg.Hosts[name]; !ok {
+			cfg.Hosts[name] = host
+		}
+	}
+}
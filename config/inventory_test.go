@@ -0,0 +1,182 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeInventoryTestConfig writes a minimal config whose hosts section is the
+// given YAML fragment plus the given inventory lines, and returns its path.
+func writeInventoryTestConfig(t *testing.T, dir, hostsSection, inventoryLines string) string {
+	t.Helper()
+
+	configContent := fmt.Sprintf(`
+version: "0.1.2"
+appname: "testapp"
+%s
+%s
+tasks:
+  - name: "build"
+    cmd: "make build"
+`, inventoryLines, hostsSection)
+
+	configPath := filepath.Join(dir, "nyati.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	return configPath
+}
+
+func TestLoadMergesFileInventory(t *testing.T) {
+	dir := t.TempDir()
+	inventoryPath := filepath.Join(dir, "inventory.yaml")
+	inventoryContent := `
+hosts:
+  web1:
+    host: "web1.example.com"
+    username: "deploy"
+    password: "pass"
+    labels: ["web", "eu-west"]
+  shared:
+    host: "inventory.example.com"
+    username: "inventory-user"
+    password: "pass"
+`
+	if err := os.WriteFile(inventoryPath, []byte(inventoryContent), 0644); err != nil {
+		t.Fatalf("Failed to write inventory: %v", err)
+	}
+
+	hostsSection := `hosts:
+  shared:
+    host: "static.example.com"
+    username: "static-user"
+    password: "pass"`
+	configPath := writeInventoryTestConfig(t, dir, hostsSection, "inventory: "+inventoryPath)
+
+	cfg, err := LoadWithOptions(configPath, "0.1.2", LoadOptions{AllowInventory: true})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	web1, ok := cfg.Hosts["web1"]
+	if !ok {
+		t.Fatalf("inventory host missing from merged hosts: %v", cfg.Hosts)
+	}
+	if web1.Host != "web1.example.com" || web1.Username != "deploy" {
+		t.Errorf("inventory host = %+v, want its connection details carried through", web1)
+	}
+	if len(web1.Labels) != 2 || web1.Labels[0] != "web" || web1.Labels[1] != "eu-west" {
+		t.Errorf("inventory host labels = %v, want [web eu-west]", web1.Labels)
+	}
+
+	// The config's own entry wins on a name conflict
+	if shared := cfg.Hosts["shared"]; shared.Host != "static.example.com" || shared.Username != "static-user" {
+		t.Errorf("shared host = %+v, want the static entry kept", shared)
+	}
+}
+
+func TestLoadInventoryFromCommand(t *testing.T) {
+	dir := t.TempDir()
+	// JSON on stdout, as a cloud CLI would emit it
+	inventoryCmd := `echo '{"hosts":{"db1":{"host":"db1.example.com","username":"deploy","password":"pass","labels":["db"]}}}'`
+	configPath := writeInventoryTestConfig(t, dir, "", "inventory_cmd: |\n  "+inventoryCmd)
+
+	cfg, err := LoadWithOptions(configPath, "0.1.2", LoadOptions{AllowInventory: true})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	db1, ok := cfg.Hosts["db1"]
+	if !ok {
+		t.Fatalf("command inventory host missing: %v", cfg.Hosts)
+	}
+	if db1.Host != "db1.example.com" || len(db1.Labels) != 1 || db1.Labels[0] != "db" {
+		t.Errorf("command inventory host = %+v, want details and labels parsed", db1)
+	}
+}
+
+func TestLoadInventoryIsCachedBriefly(t *testing.T) {
+	dir := t.TempDir()
+	inventoryPath := filepath.Join(dir, "inventory.yaml")
+	first := `
+hosts:
+  web1:
+    host: "web1.example.com"
+    username: "deploy"
+    password: "pass"
+`
+	if err := os.WriteFile(inventoryPath, []byte(first), 0644); err != nil {
+		t.Fatalf("Failed to write inventory: %v", err)
+	}
+	configPath := writeInventoryTestConfig(t, dir, "", "inventory: "+inventoryPath)
+
+	if _, err := LoadWithOptions(configPath, "0.1.2", LoadOptions{AllowInventory: true}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// A rewritten inventory is not picked up inside the cache window
+	second := strings.ReplaceAll(first, "web1", "web2")
+	if err := os.WriteFile(inventoryPath, []byte(second), 0644); err != nil {
+		t.Fatalf("Failed to rewrite inventory: %v", err)
+	}
+	cfg, err := LoadWithOptions(configPath, "0.1.2", LoadOptions{AllowInventory: true})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, ok := cfg.Hosts["web1"]; !ok {
+		t.Errorf("hosts = %v, want the cached inventory reused within the TTL", cfg.Hosts)
+	}
+
+	// After the cache entry is dropped, the new inventory is read
+	inventoryCache.Delete("file:" + inventoryPath)
+	cfg, err = LoadWithOptions(configPath, "0.1.2", LoadOptions{AllowInventory: true})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, ok := cfg.Hosts["web2"]; !ok {
+		t.Errorf("hosts = %v, want the rewritten inventory after expiry", cfg.Hosts)
+	}
+}
+
+func TestLoadRejectsInventoryWithoutOptIn(t *testing.T) {
+	dir := t.TempDir()
+	configPath := writeInventoryTestConfig(t, dir, "", "inventory_cmd: echo hosts")
+
+	_, err := Load(configPath, "0.1.2")
+	if err == nil || !strings.Contains(err.Error(), "CLI loads") {
+		t.Errorf("Load() error = %v, want inventory sources rejected without AllowInventory", err)
+	}
+}
+
+func TestLoadBytesRejectsInventorySources(t *testing.T) {
+	// Server-side validation of user-submitted content must never reach the
+	// inventory sources: the file form reads the server's filesystem and the
+	// command form executes in the server process
+	for _, line := range []string{"inventory: /etc/hosts.yaml", "inventory_cmd: touch pwned"} {
+		content := fmt.Sprintf(`
+version: "0.1.2"
+appname: "testapp"
+%s
+tasks:
+  - name: "build"
+    cmd: "make build"
+`, line)
+		_, err := LoadBytes([]byte(content), "0.1.2")
+		if err == nil || !strings.Contains(err.Error(), "CLI loads") {
+			t.Errorf("LoadBytes() with %q error = %v, want the source rejected", line, err)
+		}
+	}
+}
+
+func TestLoadRejectsAmbiguousInventorySources(t *testing.T) {
+	dir := t.TempDir()
+	configPath := writeInventoryTestConfig(t, dir, "", "inventory: inv.yaml\ninventory_cmd: echo hosts")
+
+	_, err := LoadWithOptions(configPath, "0.1.2", LoadOptions{AllowInventory: true})
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("Load() error = %v, want the ambiguous sources rejected", err)
+	}
+}
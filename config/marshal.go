@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Marshal renders a Config back to nyati.yaml-style YAML.
+//
+// Struct fields are emitted in declaration order and map keys (hosts, params)
+// are sorted, so the same config always produces the same document. Runtime
+// fields such as ReleaseVersion are excluded, which makes the output suitable
+// for loading again with Load or LoadBytes.
+//
+// Parameters:
+//   - cfg: the config to render
+//
+// Returns:
+//   - []byte: YAML document representing the config
+//   - error: if the config cannot be marshaled
+func Marshal(cfg *Config) ([]byte, error) {
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %v", err)
+	}
+	return out, nil
+}
+
+// Save writes the config to path as YAML, so features that generate configs
+// (blueprints, scaffolding, imports) don't hand-assemble documents.
+//
+// Parameters:
+//   - path: destination file path
+//
+// Returns:
+//   - error: if the config cannot be marshaled or the file cannot be written
+func (cfg *Config) Save(path string) error {
+	out, err := Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %v", err)
+	}
+	return nil
+}
+
+// NewConfig returns a config skeleton for programmatic construction, with the
+// current config version and initialized hosts and params maps. Callers add
+// hosts and tasks before saving; validation still happens on load.
+//
+// Parameters:
+//   - appName: name of the application being deployed
+//
+// Returns:
+//   - *Config: empty config ready for AddTask and host entries
+func NewConfig(appName string) *Config {
+	return &Config{
+		Version: "0.1.2",
+		AppName: appName,
+		Hosts:   make(map[string]Host),
+		Params:  make(map[string]string),
+	}
+}
+
+// AddTask appends a task to the config, enforcing the same unique-name rule
+// that Load applies, so a generated config cannot fail validation later for
+// a duplicate introduced here.
+//
+// Parameters:
+//   - task: the task to append; name and cmd are required
+//
+// Returns:
+//   - error: if the task is missing required fields or the name is taken
+func (cfg *Config) AddTask(task Task) error {
+	if task.Name == "" {
+		return fmt.Errorf("task name is required")
+	}
+	if task.Cmd == "" {
+		return fmt.Errorf("task '%s': cmd is required", task.Name)
+	}
+	for _, existing := range cfg.Tasks {
+		if existing.Name == task.Name {
+			return fmt.Errorf("duplicate task name '%s'", task.Name)
+		}
+	}
+	cfg.Tasks = append(cfg.Tasks, task)
+	return nil
+}
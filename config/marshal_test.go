@@ -0,0 +1,182 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const roundTripYAML = `version: "0.1.2"
+appname: demo
+hosts:
+  web1:
+    host: 192.168.1.10
+    username: deploy
+    private_key: ~/.ssh/id_rsa
+  web2:
+    host: 192.168.1.11
+    username: deploy
+    password: secret
+    host_key_policy: accept-new
+tasks:
+  - name: build
+    cmd: make build
+    dir: /srv/demo
+    output: true
+  - name: migrate
+    cmd: make migrate
+    depends_on:
+      - build
+    expect: 2
+    retry: true
+params:
+  branch: main
+  port: "8080"
+metrics: true
+gather_facts: true
+`
+
+func TestMarshalRoundTrip(t *testing.T) {
+	cfg, err := LoadBytes([]byte(roundTripYAML), "0.1.2")
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+
+	out, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+
+	reloaded, err := LoadBytes(out, "0.1.2")
+	if err != nil {
+		t.Fatalf("marshaled config does not load: %v\n%s", err, out)
+	}
+
+	// ReleaseVersion is runtime state and intentionally not serialized
+	reloaded.ReleaseVersion = cfg.ReleaseVersion
+	if !reflect.DeepEqual(cfg, reloaded) {
+		t.Errorf("config changed across load → marshal → load:\nbefore: %+v\nafter:  %+v", cfg, reloaded)
+	}
+}
+
+func TestMarshalIsDeterministic(t *testing.T) {
+	cfg, err := LoadBytes([]byte(roundTripYAML), "0.1.2")
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+
+	first, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	second, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("marshal output is not stable:\nfirst:\n%s\nsecond:\n%s", first, second)
+	}
+}
+
+func TestSaveRoundTripsConstructedConfig(t *testing.T) {
+	cfg := NewConfig("demo")
+	cfg.Hosts["web1"] = Host{
+		Host:       "192.168.1.10",
+		Username:   "deploy",
+		PrivateKey: "~/.ssh/id_rsa",
+	}
+	cfg.Params["branch"] = "main"
+	for _, task := range []Task{
+		{Name: "build", Cmd: "make build"},
+		{Name: "deploy", Cmd: "make deploy", DependsOn: []string{"build"}, Expect: 2},
+	} {
+		if err := cfg.AddTask(task); err != nil {
+			t.Fatalf("failed to add task %s: %v", task.Name, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "nyati.yaml")
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	loaded, err := Load(path, "0.1.2")
+	if err != nil {
+		t.Fatalf("saved config does not load: %v", err)
+	}
+	loaded.ReleaseVersion = cfg.ReleaseVersion
+	if !reflect.DeepEqual(cfg, loaded) {
+		t.Errorf("config changed across save → load:\nbefore: %+v\nafter:  %+v", cfg, loaded)
+	}
+	if got := loaded.Tasks[1]; len(got.DependsOn) != 1 || got.DependsOn[0] != "build" || got.Expect != 2 {
+		t.Errorf("depends_on/expect not preserved: %+v", got)
+	}
+}
+
+func TestAddTaskRejectsDuplicatesAndMissingFields(t *testing.T) {
+	cfg := NewConfig("demo")
+	if err := cfg.AddTask(Task{Name: "build", Cmd: "make build"}); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	if err := cfg.AddTask(Task{Name: "build", Cmd: "make rebuild"}); err == nil {
+		t.Error("AddTask should reject a duplicate task name")
+	}
+	if err := cfg.AddTask(Task{Cmd: "make build"}); err == nil {
+		t.Error("AddTask should reject a task without a name")
+	}
+	if err := cfg.AddTask(Task{Name: "lint"}); err == nil {
+		t.Error("AddTask should reject a task without a cmd")
+	}
+	if len(cfg.Tasks) != 1 {
+		t.Errorf("rejected tasks must not be appended, got %d tasks", len(cfg.Tasks))
+	}
+}
+
+func TestMarshalOmitsRuntimeAndEmptyFields(t *testing.T) {
+	cfg, err := LoadBytes([]byte(roundTripYAML), "0.1.2")
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+	cfg.ReleaseVersion = 1234567890
+
+	out, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	doc := string(out)
+
+	for _, unwanted := range []string{"releaseversion", "1234567890", "envfile", "known_hosts"} {
+		if strings.Contains(doc, unwanted) {
+			t.Errorf("marshaled config should not contain %q:\n%s", unwanted, doc)
+		}
+	}
+}
+
+func TestMarshalPreservesTaskIDs(t *testing.T) {
+	const id = "6e7f7a1a-8a41-4e58-9c07-1d6a2a3b4c5d"
+	cfg := NewConfig("demo")
+	cfg.Hosts["web"] = Host{Host: "example.com", Username: "deploy", Password: "secret"}
+	if err := cfg.AddTask(Task{ID: id, Name: "build", Cmd: "make build"}); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	out, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	if !strings.Contains(string(out), "id: "+id) {
+		t.Fatalf("marshaled config should carry the task id:\n%s", out)
+	}
+
+	reloaded, err := LoadBytes(out, "0.1.2")
+	if err != nil {
+		t.Fatalf("marshaled config does not load: %v\n%s", err, out)
+	}
+	if reloaded.Tasks[0].ID != id {
+		t.Errorf("task id = %q after round trip, want %q", reloaded.Tasks[0].ID, id)
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ParamSpec describes one entry of the optional param_schema block, which
+// constrains the parameter overrides a deploy request may supply.
+type ParamSpec struct {
+	Description string   `mapstructure:"description,omitempty" yaml:"description,omitempty"` // What the parameter controls, surfaced in the UI dialog
+	Secret      bool     `mapstructure:"secret,omitempty" yaml:"secret,omitempty"`           // Secret values must come from the env store, never deploy params
+	Allowed     []string `mapstructure:"allowed,omitempty" yaml:"allowed,omitempty"`         // When non-empty, the only values an override may take
+}
+
+// ValidateParamOverrides checks request-supplied parameter overrides against
+// the config's param_schema. Without a schema any override is accepted; with
+// one, every override must name a declared parameter, must not be flagged
+// secret, and must use an allowed value when the spec lists them.
+//
+// Parameters:
+//   - params: the overrides from the deploy request
+//
+// Returns:
+//   - error: an ErrInvalidParam for the first offending override, nil otherwise
+func (cfg *Config) ValidateParamOverrides(params map[string]string) error {
+	if len(params) == 0 || len(cfg.ParamSchema) == 0 {
+		return nil
+	}
+
+	// Check overrides in sorted order so the reported offender is deterministic
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		spec, ok := cfg.ParamSchema[key]
+		if !ok {
+			return ErrInvalidParam{Key: key, Reason: "not declared in param_schema"}
+		}
+		if spec.Secret {
+			return ErrInvalidParam{Key: key, Reason: "flagged secret; set it through the environment store instead of deploy params"}
+		}
+		if len(spec.Allowed) > 0 && !slices.Contains(spec.Allowed, params[key]) {
+			return ErrInvalidParam{
+				Key:    key,
+				Reason: fmt.Sprintf("value '%s' is not allowed; expected one of: %s", params[key], strings.Join(spec.Allowed, ", ")),
+			}
+		}
+	}
+	return nil
+}
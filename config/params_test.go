@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// schemaTestConfig returns a config whose param_schema covers a plain, a
+// value-constrained and a secret parameter.
+func schemaTestConfig() *Config {
+	return &Config{
+		Params: map[string]string{"branch": "main", "php_version": "8.2"},
+		ParamSchema: map[string]ParamSpec{
+			"branch":      {Description: "Git branch to deploy"},
+			"php_version": {Allowed: []string{"8.2", "8.3"}},
+			"db_password": {Secret: true},
+		},
+	}
+}
+
+func TestValidateParamOverridesWithoutSchema(t *testing.T) {
+	cfg := &Config{Params: map[string]string{"branch": "main"}}
+	if err := cfg.ValidateParamOverrides(map[string]string{"anything": "goes"}); err != nil {
+		t.Errorf("override without a schema rejected: %v", err)
+	}
+}
+
+func TestValidateParamOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{"declared param", map[string]string{"branch": "feature/login"}, ""},
+		{"allowed value", map[string]string{"php_version": "8.3"}, ""},
+		{"unknown param", map[string]string{"region": "eu-west-1"}, "not declared in param_schema"},
+		{"secret param", map[string]string{"db_password": "hunter2"}, "environment store"},
+		{"disallowed value", map[string]string{"php_version": "7.4"}, "not allowed"},
+	}
+
+	cfg := schemaTestConfig()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cfg.ValidateParamOverrides(tt.params)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateParamOverrides() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateParamOverrides() = %v, want it to contain %q", err, tt.wantErr)
+			}
+			var invalid ErrInvalidParam
+			if !errors.As(err, &invalid) {
+				t.Errorf("error is %T, want ErrInvalidParam", err)
+			}
+		})
+	}
+}
+
+func TestLoadWithParamsSubstitutesOverrides(t *testing.T) {
+	configContent := `
+version: "0.1.2"
+appname: "testapp"
+params:
+  branch: "main"
+param_schema:
+  branch:
+    description: "Git branch to deploy"
+  db_password:
+    secret: true
+hosts:
+  testhost:
+    host: "example.com"
+    username: "user"
+    password: "pass"
+tasks:
+  - name: "checkout"
+    cmd: "git checkout ${branch}"
+`
+	configPath := filepath.Join(t.TempDir(), "nyati.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadWithParams(configPath, "0.1.2", map[string]string{"branch": "feature/login"})
+	if err != nil {
+		t.Fatalf("LoadWithParams() error = %v", err)
+	}
+	if cfg.Params["branch"] != "feature/login" {
+		t.Errorf("branch = %q, want the override to win over the config value", cfg.Params["branch"])
+	}
+	if cfg.Tasks[0].Cmd != "git checkout feature/login" {
+		t.Errorf("cmd = %q, want the override substituted", cfg.Tasks[0].Cmd)
+	}
+
+	// Without overrides the config's own value still applies
+	cfg, err = Load(configPath, "0.1.2")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Tasks[0].Cmd != "git checkout main" {
+		t.Errorf("cmd = %q, want the config param substituted", cfg.Tasks[0].Cmd)
+	}
+
+	// A secret override fails the load before anything is substituted
+	_, err = LoadWithParams(configPath, "0.1.2", map[string]string{"db_password": "hunter2"})
+	var invalid ErrInvalidParam
+	if !errors.As(err, &invalid) {
+		t.Fatalf("LoadWithParams() error = %v, want ErrInvalidParam for the secret override", err)
+	}
+}
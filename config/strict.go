@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// userExtensionPrefix marks keys that are deliberately outside the schema.
+// Anything starting with "x-" is ignored by strict validation, so users can
+// annotate configs (anchors, tooling metadata) without tripping it.
+const userExtensionPrefix = "x-"
+
+// strictUnknownKeys controls whether unknown keys reject a config. On by
+// default; --no-strict turns it off as a temporary migration escape hatch.
+var strictUnknownKeys = true
+
+// SetStrictUnknownKeys toggles unknown-key rejection. Used by the CLI
+// --no-strict flag.
+func SetStrictUnknownKeys(enabled bool) {
+	strictUnknownKeys = enabled
+}
+
+// UnknownKeys walks raw config settings against the Config schema and
+// returns the YAML path of every key that no struct field accepts, e.g.
+// "tasks[0].comand" or "hosts.server1.usernme". All offenders are collected
+// in one pass so a misindented config is fixed in one round trip. Keys with
+// the x- prefix are skipped at every level.
+//
+// Parameters:
+//   - settings: the raw parsed config, as returned by viper's AllSettings
+//
+// Returns:
+//   - []string: sorted YAML paths of unknown keys; empty when clean
+func UnknownKeys(settings map[string]interface{}) []string {
+	var found []string
+	walkStruct("", reflect.TypeOf(Config{}), settings, &found)
+	sort.Strings(found)
+	return found
+}
+
+// structFields maps a struct's mapstructure key names to field types.
+// Fields without a mapstructure tag (runtime-only fields) accept no input.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = t.Field(i).Type
+	}
+	return fields
+}
+
+// walkStruct checks one map of settings against one struct type, recursing
+// into nested structs, maps of structs and slices of structs.
+func walkStruct(path string, t reflect.Type, settings map[string]interface{}, found *[]string) {
+	fields := structFields(t)
+	for key, value := range settings {
+		if strings.HasPrefix(key, userExtensionPrefix) {
+			continue
+		}
+		fieldType, ok := fields[key]
+		if !ok {
+			*found = append(*found, joinKeyPath(path, key))
+			continue
+		}
+		walkValue(joinKeyPath(path, key), fieldType, value, found)
+	}
+}
+
+// walkValue recurses into a field's value when its type has inner structure
+// worth checking; scalar fields need no further validation here.
+func walkValue(path string, t reflect.Type, value interface{}, found *[]string) {
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := value.(map[string]interface{}); ok {
+			walkStruct(path, t, m, found)
+		}
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok || t.Elem().Kind() != reflect.Struct {
+			return
+		}
+		for name, entry := range m {
+			walkValue(joinKeyPath(path, name), t.Elem(), entry, found)
+		}
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok || t.Elem().Kind() != reflect.Struct {
+			return
+		}
+		for i, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				walkStruct(fmt.Sprintf("%s[%d]", path, i), t.Elem(), m, found)
+			}
+		}
+	}
+}
+
+// joinKeyPath appends a key to a dotted YAML path.
+func joinKeyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnknownKeysReportsAllOffendersWithPaths(t *testing.T) {
+	content := []byte(`
+version: "0.1.2"
+appname: testapp
+environmnt: prod
+hosts:
+  server1:
+    host: example.com
+    username: deploy
+    password: secret
+    usernme: typo
+tasks:
+  - name: build
+    cmd: make
+    comand: make again
+  - name: release
+    cmd: deploy.sh
+    depends_onn: [build]
+`)
+
+	_, err := LoadBytes(content, "0.1.2")
+	if err == nil {
+		t.Fatal("a config with unknown keys must be rejected")
+	}
+	for _, path := range []string{"environmnt", "hosts.server1.usernme", "tasks[0].comand", "tasks[1].depends_onn"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("error %v does not name offender %s", err, path)
+		}
+	}
+}
+
+func TestUnknownKeysAllowsUserExtensions(t *testing.T) {
+	content := []byte(`
+version: "0.1.2"
+appname: testapp
+x-team: platform
+hosts:
+  server1:
+    host: example.com
+    username: deploy
+    password: secret
+    x-rack: eu-3
+tasks:
+  - name: build
+    cmd: make
+    x-owner: backend
+`)
+
+	if _, err := LoadBytes(content, "0.1.2"); err != nil {
+		t.Fatalf("x- prefixed keys must be accepted, got %v", err)
+	}
+}
+
+func TestUnknownKeysEscapeHatch(t *testing.T) {
+	content := []byte(`
+version: "0.1.2"
+appname: testapp
+sometypo: value
+hosts:
+  server1:
+    host: example.com
+    username: deploy
+    password: secret
+tasks:
+  - name: build
+    cmd: make
+`)
+
+	SetStrictUnknownKeys(false)
+	defer SetStrictUnknownKeys(true)
+
+	if _, err := LoadBytes(content, "0.1.2"); err != nil {
+		t.Fatalf("--no-strict must accept unknown keys, got %v", err)
+	}
+}
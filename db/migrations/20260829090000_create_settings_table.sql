@@ -0,0 +1,8 @@
+-- UP
+CREATE TABLE IF NOT EXISTS settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+
+-- DOWN
+DROP TABLE IF EXISTS settings;
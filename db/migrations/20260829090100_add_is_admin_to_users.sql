@@ -0,0 +1,5 @@
+-- UP
+ALTER TABLE users ADD COLUMN is_admin INTEGER NOT NULL DEFAULT 0;
+
+-- DOWN
+ALTER TABLE users DROP COLUMN is_admin;
@@ -0,0 +1,18 @@
+-- UP
+-- Audit trail for admin actions on webhooks and environments
+CREATE TABLE IF NOT EXISTS admin_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Create an index for time-ordered queries by the audit endpoint
+CREATE INDEX idx_admin_audit_created_at ON admin_audit(created_at);
+
+-- DOWN
+DROP INDEX IF EXISTS idx_admin_audit_created_at;
+DROP TABLE IF EXISTS admin_audit;
@@ -0,0 +1,28 @@
+-- UP
+-- Raw config content snapshots, deduplicated by content hash so repeated
+-- deploys of an unchanged file share one row
+CREATE TABLE IF NOT EXISTS config_snapshots (
+    hash TEXT PRIMARY KEY,
+    content BLOB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Deployment history: which config version was deployed where, by whom
+CREATE TABLE IF NOT EXISTS deployments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    config_path TEXT NOT NULL,
+    host TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    config_hash TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (config_hash) REFERENCES config_snapshots(hash)
+);
+
+-- Create an index for per-config history queries
+CREATE INDEX idx_deployments_config_path ON deployments(config_path);
+
+-- DOWN
+DROP INDEX IF EXISTS idx_deployments_config_path;
+DROP TABLE IF EXISTS deployments;
+DROP TABLE IF EXISTS config_snapshots;
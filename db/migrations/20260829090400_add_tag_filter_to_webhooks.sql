@@ -0,0 +1,6 @@
+-- UP
+-- Comma-separated task tags this webhook is scoped to; empty fires for all tasks
+ALTER TABLE webhooks ADD COLUMN tag_filter TEXT NOT NULL DEFAULT '';
+
+-- DOWN
+ALTER TABLE webhooks DROP COLUMN tag_filter;
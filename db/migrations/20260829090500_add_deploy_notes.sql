@@ -0,0 +1,11 @@
+-- UP
+-- Free-text note attached to a deployment, shown in history and notifications
+ALTER TABLE deployments ADD COLUMN note TEXT NOT NULL DEFAULT '';
+
+-- Per-config default notification targets as JSON:
+-- {"webhook_ids": [1, 2], "emails": ["ops@example.com"]}
+ALTER TABLE configs ADD COLUMN notification_defaults TEXT NOT NULL DEFAULT '';
+
+-- DOWN
+ALTER TABLE configs DROP COLUMN notification_defaults;
+ALTER TABLE deployments DROP COLUMN note;
@@ -0,0 +1,11 @@
+-- UP
+-- Outcome of a deployment, filled in when the run finishes: 'pending' while
+-- running, then 'success' or 'error'
+ALTER TABLE deployments ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+
+-- Wall-clock run time in milliseconds, 0 until the run finishes
+ALTER TABLE deployments ADD COLUMN duration_ms INTEGER NOT NULL DEFAULT 0;
+
+-- DOWN
+ALTER TABLE deployments DROP COLUMN duration_ms;
+ALTER TABLE deployments DROP COLUMN status;
@@ -0,0 +1,7 @@
+-- UP
+-- The ${release_version} value used by the deployment, recorded so a later
+-- run (e.g. a rollback) can target the same release directory
+ALTER TABLE deployments ADD COLUMN release_version INTEGER NOT NULL DEFAULT 0;
+
+-- DOWN
+ALTER TABLE deployments DROP COLUMN release_version;
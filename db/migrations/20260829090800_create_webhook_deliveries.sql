@@ -0,0 +1,18 @@
+-- UP
+-- One row per webhook delivery attempt, recorded best-effort by the sender.
+-- success mirrors whether the endpoint answered with a 2xx status;
+-- status_code is 0 when the request never got a response.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    success INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
+
+-- DOWN
+DROP INDEX IF EXISTS idx_webhook_deliveries_created_at;
+DROP TABLE IF EXISTS webhook_deliveries;
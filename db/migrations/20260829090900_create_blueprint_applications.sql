@@ -0,0 +1,20 @@
+-- UP
+-- Provenance of generated configs: which blueprint (and version) produced
+-- which config, and with what parameters, so an updated blueprint can be
+-- re-applied later
+CREATE TABLE IF NOT EXISTS blueprint_applications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    blueprint_id TEXT NOT NULL,
+    blueprint_name TEXT NOT NULL,
+    blueprint_version TEXT NOT NULL,
+    config_name TEXT NOT NULL,
+    parameters TEXT NOT NULL DEFAULT '{}',
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_blueprint_applications_config_name ON blueprint_applications(config_name);
+
+-- DOWN
+DROP INDEX IF EXISTS idx_blueprint_applications_config_name;
+DROP TABLE IF EXISTS blueprint_applications;
@@ -0,0 +1,7 @@
+-- UP
+-- Free-form labels for grouping configs in the UI, stored as a JSON array of
+-- lowercase strings (e.g. ["laravel", "production"])
+ALTER TABLE configs ADD COLUMN labels TEXT NOT NULL DEFAULT '[]';
+
+-- DOWN
+ALTER TABLE configs DROP COLUMN labels;
@@ -0,0 +1,7 @@
+-- UP
+-- Attempt number of this deployment run: 1 for the initial deploy, counting
+-- up for automatic retries of connection failures
+ALTER TABLE deployments ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1;
+
+-- DOWN
+ALTER TABLE deployments DROP COLUMN attempt;
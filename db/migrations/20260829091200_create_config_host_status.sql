@@ -0,0 +1,16 @@
+-- UP
+-- Per-host reachability of a config, maintained by the background health
+-- checker so the UI can badge configs before anyone hits deploy
+CREATE TABLE IF NOT EXISTS config_host_status (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    config_id INTEGER NOT NULL,
+    host TEXT NOT NULL,
+    status TEXT NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (config_id, host),
+    FOREIGN KEY (config_id) REFERENCES configs (id) ON DELETE CASCADE
+);
+
+-- DOWN
+DROP TABLE IF EXISTS config_host_status;
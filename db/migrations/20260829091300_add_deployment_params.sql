@@ -0,0 +1,7 @@
+-- UP
+-- JSON object of the request-supplied parameter overrides a deployment ran
+-- with, empty when the deploy used the config's params unchanged
+ALTER TABLE deployments ADD COLUMN params TEXT NOT NULL DEFAULT '';
+
+-- DOWN
+ALTER TABLE deployments DROP COLUMN params;
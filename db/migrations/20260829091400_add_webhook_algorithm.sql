@@ -0,0 +1,6 @@
+-- UP
+-- HMAC algorithm used to sign this webhook's deliveries (sha256 or sha512)
+ALTER TABLE webhooks ADD COLUMN algorithm TEXT NOT NULL DEFAULT 'sha256';
+
+-- DOWN
+ALTER TABLE webhooks DROP COLUMN algorithm;
@@ -0,0 +1,7 @@
+-- UP
+-- SHA-256 of the config file at save/validation time, compared at deploy
+-- time to detect edits made after the entry was last reviewed
+ALTER TABLE configs ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+
+-- DOWN
+ALTER TABLE configs DROP COLUMN content_hash;
@@ -0,0 +1,11 @@
+-- UP
+-- Compact per-task, per-host outcomes of the run, stored as JSON; resume
+-- reads them to skip tasks that already succeeded
+ALTER TABLE deployments ADD COLUMN results TEXT NOT NULL DEFAULT '';
+
+-- The deployment this run resumed, linking partial re-runs to their original
+ALTER TABLE deployments ADD COLUMN resumed_from INTEGER;
+
+-- DOWN
+ALTER TABLE deployments DROP COLUMN resumed_from;
+ALTER TABLE deployments DROP COLUMN results;
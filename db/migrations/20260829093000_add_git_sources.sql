@@ -0,0 +1,30 @@
+-- UP
+-- Provenance for configs imported from a Git repository: where the file came
+-- from and which commit the managed copy currently matches. One row per
+-- imported config; hand-registered configs have no row.
+CREATE TABLE IF NOT EXISTS config_git_sources (
+    config_id INTEGER PRIMARY KEY,
+    repo TEXT NOT NULL,
+    ref TEXT NOT NULL DEFAULT '',
+    path TEXT NOT NULL,
+    last_commit TEXT NOT NULL DEFAULT '',
+    synced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (config_id) REFERENCES configs (id) ON DELETE CASCADE
+);
+
+-- Per-user credentials for cloning private repositories, keyed by remote
+-- host. Token and SSH key are stored encrypted with a user-supplied key that
+-- is never persisted, like env secrets.
+CREATE TABLE IF NOT EXISTS git_credentials (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    host TEXT NOT NULL,
+    token TEXT NOT NULL DEFAULT '',
+    ssh_key TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, host)
+);
+
+-- DOWN
+DROP TABLE IF EXISTS git_credentials;
+DROP TABLE IF EXISTS config_git_sources;
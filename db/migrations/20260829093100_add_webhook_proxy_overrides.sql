@@ -0,0 +1,9 @@
+-- UP
+-- Overrides for webhook endpoints behind proxies: the TLS SNI name and the
+-- Host header to send when they differ from the URL's hostname
+ALTER TABLE webhooks ADD COLUMN tls_server_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhooks ADD COLUMN host_header TEXT NOT NULL DEFAULT '';
+
+-- DOWN
+ALTER TABLE webhooks DROP COLUMN tls_server_name;
+ALTER TABLE webhooks DROP COLUMN host_header;
@@ -0,0 +1,7 @@
+-- UP
+-- Environment whose variables are injected into web deploys of this config;
+-- NULL means no environment is bound
+ALTER TABLE configs ADD COLUMN environment_id INTEGER;
+
+-- DOWN
+ALTER TABLE configs DROP COLUMN environment_id;
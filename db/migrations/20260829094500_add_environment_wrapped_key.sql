@@ -0,0 +1,7 @@
+-- UP
+-- Each environment may carry its own data key, wrapped (encrypted) by the
+-- server master key. Empty means the environment uses passphrase mode.
+ALTER TABLE environments ADD COLUMN wrapped_key TEXT NOT NULL DEFAULT '';
+
+-- DOWN
+ALTER TABLE environments DROP COLUMN wrapped_key;
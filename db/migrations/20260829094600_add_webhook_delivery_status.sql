@@ -0,0 +1,8 @@
+-- UP
+-- Mark deliveries suppressed by the global webhook mute. Regular attempts
+-- keep the empty default; suppressed ones are recorded with status 'muted'
+-- so delivery history shows what a mute window swallowed.
+ALTER TABLE webhook_deliveries ADD COLUMN status TEXT NOT NULL DEFAULT '';
+
+-- DOWN
+ALTER TABLE webhook_deliveries DROP COLUMN status;
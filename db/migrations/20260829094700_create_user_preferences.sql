@@ -0,0 +1,13 @@
+-- UP
+-- One JSON blob of UI preferences per user (default environment, log level
+-- filter, page size, dark mode, ...). The server treats the blob as opaque
+-- apart from requiring a JSON object under the size limit; writes are
+-- last-write-wins with updated_at returned for concurrent-change detection.
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id INTEGER PRIMARY KEY,
+    preferences TEXT NOT NULL DEFAULT '{}',
+    updated_at TEXT NOT NULL
+);
+
+-- DOWN
+DROP TABLE IF EXISTS user_preferences;
@@ -0,0 +1,11 @@
+-- UP
+-- Canonicalize the free-text webhook event names. Legacy rows subscribed to
+-- a whole family ("deployment", "task", "task-execution"), so they map to
+-- the matching wildcard subscription and keep receiving every event they
+-- received before.
+UPDATE webhooks SET event = 'deployment.*' WHERE event = 'deployment';
+UPDATE webhooks SET event = 'task.*' WHERE event IN ('task', 'task-execution');
+
+-- DOWN
+UPDATE webhooks SET event = 'deployment' WHERE event = 'deployment.*';
+UPDATE webhooks SET event = 'task' WHERE event = 'task.*';
@@ -0,0 +1,84 @@
+package env
+
+import (
+	"fmt"
+	"maps"
+)
+
+// BulkItem is a single entry in a bulk variable update, shared by the API
+// bulk endpoint and the `env set-many` CLI command.
+type BulkItem struct {
+	Key      string `json:"key" yaml:"key"`
+	Value    string `json:"value" yaml:"value"`
+	IsSecret bool   `json:"is_secret" yaml:"is_secret"`
+}
+
+// BulkError describes why a single item in a bulk update was rejected.
+// Index -1 marks a batch-level problem (e.g. missing encryption key).
+type BulkError struct {
+	Index   int    `json:"index"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+// BulkSet validates and applies a batch of variables atomically: either every
+// item is applied to the environment, or none is. All validation errors are
+// collected and returned together so callers can fix the whole batch at once.
+// Secrets are encrypted during staging, so a batch containing secrets
+// requires the encryption key to be set up front.
+//
+// Parameters:
+//   - items: the variables to set
+//
+// Returns:
+//   - []BulkError: all rejections; nil when the batch was applied
+func (e *Environment) BulkSet(items []BulkItem) []BulkError {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var errs []BulkError
+
+	// Validate everything before touching the environment
+	seen := make(map[string]int, len(items))
+	needsKey := false
+	for i, item := range items {
+		if item.Key == "" {
+			errs = append(errs, BulkError{Index: i, Message: "key is required"})
+			continue
+		}
+		if first, dup := seen[item.Key]; dup {
+			errs = append(errs, BulkError{Index: i, Key: item.Key, Message: fmt.Sprintf("duplicate of item %d", first)})
+			continue
+		}
+		seen[item.Key] = i
+		if item.IsSecret {
+			needsKey = true
+		}
+	}
+	if needsKey && len(e.encryptKey) == 0 {
+		errs = append(errs, BulkError{Index: -1, Message: ErrNoEncryptionKey.Error()})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// Stage all values (including encryption) before merging, so an
+	// encryption failure cannot leave the batch half applied
+	stagedVars := make(map[string]string)
+	stagedSecrets := make(map[string]string)
+	for i, item := range items {
+		if item.IsSecret {
+			encrypted, err := encrypt(item.Value, e.encryptKey)
+			if err != nil {
+				return []BulkError{{Index: i, Key: item.Key, Message: fmt.Sprintf("failed to encrypt value: %v", err)}}
+			}
+			stagedSecrets[item.Key] = encrypted
+		} else {
+			stagedVars[item.Key] = item.Value
+		}
+	}
+
+	maps.Copy(e.Variables, stagedVars)
+	maps.Copy(e.Secrets, stagedSecrets)
+	return nil
+}
@@ -0,0 +1,65 @@
+package env
+
+import (
+	"testing"
+)
+
+func TestBulkSetAppliesAllItems(t *testing.T) {
+	e := NewEnvironment("staging", "")
+	e.SetEncryptionKey("test-key")
+
+	errs := e.BulkSet([]BulkItem{
+		{Key: "APP_ENV", Value: "staging"},
+		{Key: "DB_HOST", Value: "db.internal"},
+		{Key: "DB_PASSWORD", Value: "hunter2", IsSecret: true},
+	})
+	if len(errs) != 0 {
+		t.Fatalf("BulkSet() errors = %v, want none", errs)
+	}
+
+	if e.Variables["APP_ENV"] != "staging" || e.Variables["DB_HOST"] != "db.internal" {
+		t.Errorf("plain variables not applied: %v", e.Variables)
+	}
+	value, isSecret, err := e.Get("DB_PASSWORD")
+	if err != nil || !isSecret || value != "hunter2" {
+		t.Errorf("Get(DB_PASSWORD) = (%q, %v, %v), want decrypted secret", value, isSecret, err)
+	}
+}
+
+func TestBulkSetCollectsAllValidationErrors(t *testing.T) {
+	e := NewEnvironment("staging", "")
+
+	errs := e.BulkSet([]BulkItem{
+		{Key: "", Value: "x"},
+		{Key: "GOOD", Value: "y"},
+		{Key: "GOOD", Value: "z"},
+	})
+	if len(errs) != 2 {
+		t.Fatalf("BulkSet() returned %d errors, want 2: %v", len(errs), errs)
+	}
+
+	// Nothing may be applied when any item fails validation
+	if len(e.Variables) != 0 {
+		t.Errorf("BulkSet() partially applied a failing batch: %v", e.Variables)
+	}
+}
+
+func TestBulkSetSecretsRequireEncryptionKey(t *testing.T) {
+	e := NewEnvironment("staging", "")
+
+	errs := e.BulkSet([]BulkItem{
+		{Key: "PLAIN", Value: "x"},
+		{Key: "SECRET", Value: "y", IsSecret: true},
+	})
+	if len(errs) != 1 {
+		t.Fatalf("BulkSet() returned %d errors, want 1: %v", len(errs), errs)
+	}
+	if errs[0].Index != -1 {
+		t.Errorf("missing-key error should be batch-level (index -1), got %d", errs[0].Index)
+	}
+
+	// The mixed batch must not partially apply
+	if len(e.Variables) != 0 || len(e.Secrets) != 0 {
+		t.Errorf("BulkSet() partially applied: vars=%v secrets=%v", e.Variables, e.Secrets)
+	}
+}
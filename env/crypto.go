@@ -0,0 +1,114 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Algorithm identifiers accepted by SetEncryptionAlgorithm and stored as a
+// prefix on ciphertexts so decryption can dispatch.
+const (
+	// AlgorithmAESGCM is AES-256-GCM, the default and the legacy format's
+	// implicit algorithm.
+	AlgorithmAESGCM = "aes256-gcm"
+	// AlgorithmChaCha20Poly1305 is ChaCha20-Poly1305 with a 256-bit key.
+	AlgorithmChaCha20Poly1305 = "chacha20-poly1305"
+)
+
+// encryptionAlgorithm is the algorithm new ciphertexts are produced with.
+// Decryption always dispatches on the ciphertext's own prefix, so changing
+// this never breaks existing secrets.
+var encryptionAlgorithm = AlgorithmAESGCM
+
+// SetEncryptionAlgorithm selects the algorithm used for new encryptions.
+//
+// Parameters:
+//   - alg: one of AlgorithmAESGCM or AlgorithmChaCha20Poly1305
+//
+// Returns:
+//   - error: if the algorithm is not supported
+func SetEncryptionAlgorithm(alg string) error {
+	switch alg {
+	case AlgorithmAESGCM, AlgorithmChaCha20Poly1305:
+		encryptionAlgorithm = alg
+		return nil
+	default:
+		return fmt.Errorf("unsupported encryption algorithm %q; expected %q or %q",
+			alg, AlgorithmAESGCM, AlgorithmChaCha20Poly1305)
+	}
+}
+
+// splitAlgorithmPrefix separates a ciphertext into its algorithm identifier
+// and base64 payload. Legacy ciphertexts carry no prefix (base64 never
+// contains ':') and are treated as AES-256-GCM.
+func splitAlgorithmPrefix(ciphertext string) (string, string) {
+	if idx := strings.IndexByte(ciphertext, ':'); idx >= 0 {
+		return ciphertext[:idx], ciphertext[idx+1:]
+	}
+	return AlgorithmAESGCM, ciphertext
+}
+
+// deriveKey turns a user-supplied key into the fixed-size AES-256 key the
+// store uses: hashed or padded to 32 bytes.
+func deriveKey(key string) []byte {
+	hashedKey := make([]byte, 32)
+	copy(hashedKey, []byte(key))
+	return hashedKey
+}
+
+// EncryptValue encrypts a value with the same AES-GCM routine and key
+// derivation the environment store uses, so the resulting ciphertext is
+// interchangeable with stored secrets. Exposed for tooling such as the
+// `env encrypt` CLI command.
+//
+// Parameters:
+//   - value: the plaintext to encrypt
+//   - key: the encryption key; must not be empty
+//
+// Returns:
+//   - string: base64-encoded ciphertext
+//   - error: if the key is empty or encryption fails
+func EncryptValue(value, key string) (string, error) {
+	if key == "" {
+		return "", ErrNoEncryptionKey
+	}
+	return encrypt(value, deriveKey(key))
+}
+
+// DecryptValue decrypts a ciphertext produced by EncryptValue or by the
+// environment store's secret handling.
+//
+// Parameters:
+//   - ciphertext: base64-encoded ciphertext
+//   - key: the encryption key; must not be empty
+//
+// Returns:
+//   - string: the decrypted plaintext
+//   - error: if the key is empty, wrong, or the ciphertext is malformed
+func DecryptValue(ciphertext, key string) (string, error) {
+	if key == "" {
+		return "", ErrNoEncryptionKey
+	}
+	plaintext, err := decrypt(ciphertext, deriveKey(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %v", err)
+	}
+	return plaintext, nil
+}
+
+// MaskValue renders a secret for partial display: the first and last two
+// characters stay visible with the middle replaced by asterisks. Values too
+// short to safely keep any context are masked entirely.
+//
+// Parameters:
+//   - value: the decrypted secret value
+//
+// Returns:
+//   - string: the masked representation, never the full value
+func MaskValue(value string) string {
+	runes := []rune(value)
+	if len(runes) < 8 {
+		return "****"
+	}
+	return string(runes[:2]) + "****" + string(runes[len(runes)-2:])
+}
@@ -0,0 +1,164 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncryptValueRoundTrip(t *testing.T) {
+	ciphertext, err := EncryptValue("s3cret-value", "my-key")
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	if ciphertext == "s3cret-value" {
+		t.Fatal("ciphertext equals plaintext")
+	}
+
+	plaintext, err := DecryptValue(ciphertext, "my-key")
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if plaintext != "s3cret-value" {
+		t.Errorf("round trip = %q, want %q", plaintext, "s3cret-value")
+	}
+}
+
+func TestDecryptValueFailsOnWrongKey(t *testing.T) {
+	ciphertext, err := EncryptValue("s3cret-value", "right-key")
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+
+	if _, err := DecryptValue(ciphertext, "wrong-key"); err == nil {
+		t.Error("decrypt with the wrong key should fail")
+	}
+	if _, err := DecryptValue("not-base64!!!", "right-key"); err == nil {
+		t.Error("decrypt of malformed ciphertext should fail")
+	}
+}
+
+func TestEncryptValueRequiresKey(t *testing.T) {
+	if _, err := EncryptValue("value", ""); err != ErrNoEncryptionKey {
+		t.Errorf("encrypt without key = %v, want ErrNoEncryptionKey", err)
+	}
+	if _, err := DecryptValue("value", ""); err != ErrNoEncryptionKey {
+		t.Errorf("decrypt without key = %v, want ErrNoEncryptionKey", err)
+	}
+}
+
+func TestEncryptValueMatchesStoreSecrets(t *testing.T) {
+	// A ciphertext produced by EncryptValue must be readable as a stored
+	// secret by an Environment using the same key
+	environment := NewEnvironment("production", "")
+	environment.SetEncryptionKey("my-key")
+
+	ciphertext, err := EncryptValue("db-password", "my-key")
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	environment.Secrets["DB_PASS"] = ciphertext
+
+	value, isSecret, err := environment.Get("DB_PASS")
+	if err != nil {
+		t.Fatalf("failed to read secret: %v", err)
+	}
+	if !isSecret || value != "db-password" {
+		t.Errorf("stored secret = (%q, %v), want (\"db-password\", true)", value, isSecret)
+	}
+}
+
+func TestEncryptionAlgorithmsRoundTrip(t *testing.T) {
+	t.Cleanup(func() {
+		if err := SetEncryptionAlgorithm(AlgorithmAESGCM); err != nil {
+			t.Fatalf("failed to restore default algorithm: %v", err)
+		}
+	})
+
+	for _, alg := range []string{AlgorithmAESGCM, AlgorithmChaCha20Poly1305} {
+		if err := SetEncryptionAlgorithm(alg); err != nil {
+			t.Fatalf("SetEncryptionAlgorithm(%q) failed: %v", alg, err)
+		}
+
+		ciphertext, err := EncryptValue("s3cr3t-value", "test-key")
+		if err != nil {
+			t.Fatalf("%s: encrypt failed: %v", alg, err)
+		}
+		if !strings.HasPrefix(ciphertext, alg+":") {
+			t.Errorf("%s: ciphertext %q missing algorithm prefix", alg, ciphertext)
+		}
+
+		plaintext, err := DecryptValue(ciphertext, "test-key")
+		if err != nil {
+			t.Fatalf("%s: decrypt failed: %v", alg, err)
+		}
+		if plaintext != "s3cr3t-value" {
+			t.Errorf("%s: round trip = %q, want s3cr3t-value", alg, plaintext)
+		}
+	}
+}
+
+func TestDecryptDispatchesOnPrefixNotCurrentAlgorithm(t *testing.T) {
+	t.Cleanup(func() {
+		if err := SetEncryptionAlgorithm(AlgorithmAESGCM); err != nil {
+			t.Fatalf("failed to restore default algorithm: %v", err)
+		}
+	})
+
+	if err := SetEncryptionAlgorithm(AlgorithmChaCha20Poly1305); err != nil {
+		t.Fatalf("SetEncryptionAlgorithm failed: %v", err)
+	}
+	ciphertext, err := EncryptValue("dispatch", "test-key")
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+
+	// Switching back must not break decryption of the ChaCha ciphertext
+	if err := SetEncryptionAlgorithm(AlgorithmAESGCM); err != nil {
+		t.Fatalf("SetEncryptionAlgorithm failed: %v", err)
+	}
+	plaintext, err := DecryptValue(ciphertext, "test-key")
+	if err != nil {
+		t.Fatalf("decrypt after switching algorithm failed: %v", err)
+	}
+	if plaintext != "dispatch" {
+		t.Errorf("round trip = %q, want dispatch", plaintext)
+	}
+}
+
+func TestDecryptLegacyUnprefixedCiphertext(t *testing.T) {
+	// Produce a legacy ciphertext by stripping the prefix from an AES-GCM one;
+	// the payload bytes are identical to what the old code emitted
+	ciphertext, err := EncryptValue("legacy-secret", "test-key")
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	legacy := strings.TrimPrefix(ciphertext, AlgorithmAESGCM+":")
+	if legacy == ciphertext {
+		t.Fatalf("ciphertext %q missing expected prefix", ciphertext)
+	}
+
+	plaintext, err := DecryptValue(legacy, "test-key")
+	if err != nil {
+		t.Fatalf("legacy decrypt failed: %v", err)
+	}
+	if plaintext != "legacy-secret" {
+		t.Errorf("legacy round trip = %q, want legacy-secret", plaintext)
+	}
+}
+
+func TestSetEncryptionAlgorithmRejectsUnknown(t *testing.T) {
+	if err := SetEncryptionAlgorithm("rot13"); err == nil {
+		t.Error("unknown algorithm should be rejected")
+	}
+}
+
+func TestMaskValueKeepsOnlyEdges(t *testing.T) {
+	if got := MaskValue("supersecretvalue"); got != "su****ue" {
+		t.Errorf("MaskValue() = %q, want \"su****ue\"", got)
+	}
+	for _, short := range []string{"", "abc", "abcdefg"} {
+		if got := MaskValue(short); got != "****" {
+			t.Errorf("MaskValue(%q) = %q, want a full mask for short values", short, got)
+		}
+	}
+}
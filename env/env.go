@@ -13,10 +13,10 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 var (
@@ -37,6 +37,8 @@ type Environment struct {
 	Description string            `json:"description"`  // Description of the environment
 	Variables   map[string]string `json:"variables"`    // Plain text variables
 	Secrets     map[string]string `json:"secrets"`      // Encrypted sensitive values
+	WrappedKey  string            `json:"-"`            // Data key wrapped by the server master key; empty in passphrase mode
+	deleted     map[string]bool   // Keys removed via Delete, pending a targeted delete on save
 	mu          sync.RWMutex      // For concurrent access safety
 	encryptKey  []byte            // Encryption key (not serialized)
 	FilePath    string            // Path to the environment file
@@ -74,9 +76,7 @@ func (e *Environment) SetEncryptionKey(key string) {
 	defer e.mu.Unlock()
 
 	// Use a fixed size key by hashing or padding
-	hashedKey := make([]byte, 32) // AES-256 requires 32-byte key
-	copy(hashedKey, []byte(key))
-	e.encryptKey = hashedKey
+	e.encryptKey = deriveKey(key)
 }
 
 // Set adds or updates an environment variable
@@ -99,6 +99,7 @@ func (e *Environment) Set(name, value string, isSecret bool) error {
 	} else {
 		e.Variables[name] = value
 	}
+	delete(e.deleted, name)
 
 	return nil
 }
@@ -131,13 +132,27 @@ func (e *Environment) Get(name string) (string, bool, error) {
 	return "", false, nil
 }
 
-// Delete removes an environment variable
+// Delete removes an environment variable. The removal is remembered so the
+// next save deletes exactly this key from the database rather than relying on
+// the key's absence from the in-memory maps.
 func (e *Environment) Delete(name string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	delete(e.Variables, name)
 	delete(e.Secrets, name)
+	if e.deleted == nil {
+		e.deleted = make(map[string]bool)
+	}
+	e.deleted[name] = true
+}
+
+// clearDeleted forgets pending deletions once they have been persisted.
+func (e *Environment) clearDeleted() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.deleted = nil
 }
 
 // AsMap returns all environment variables (including decrypted secrets) as a map
@@ -240,8 +255,8 @@ func GetEnvironment(db *sql.DB, id int) (*Environment, error) {
 	}
 
 	// Get environment info
-	err := db.QueryRow("SELECT id, name, description, is_current, user_id FROM environments WHERE id = ?", id).
-		Scan(&env.ID, &env.Name, &env.Description, &env.IsCurrent, &env.UserID)
+	err := db.QueryRow("SELECT id, name, description, is_current, user_id, wrapped_key FROM environments WHERE id = ?", id).
+		Scan(&env.ID, &env.Name, &env.Description, &env.IsCurrent, &env.UserID, &env.WrappedKey)
 	if err != nil {
 		return nil, err
 	}
@@ -279,7 +294,7 @@ func GetEnvironment(db *sql.DB, id int) (*Environment, error) {
 
 func GetEnvironments(db *sql.DB, userID int) ([]*Environment, error) {
 	// Query for all environments for this user
-	rows, err := db.Query("SELECT id, name, description, is_current, user_id FROM environments WHERE user_id = ?", userID)
+	rows, err := db.Query("SELECT id, name, description, is_current, user_id, wrapped_key FROM environments WHERE user_id = ?", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -293,7 +308,7 @@ func GetEnvironments(db *sql.DB, userID int) ([]*Environment, error) {
 			Secrets:   make(map[string]string),
 		}
 
-		if err := rows.Scan(&env.ID, &env.Name, &env.Description, &env.IsCurrent, &env.UserID); err != nil {
+		if err := rows.Scan(&env.ID, &env.Name, &env.Description, &env.IsCurrent, &env.UserID, &env.WrappedKey); err != nil {
 			return nil, err
 		}
 
@@ -348,11 +363,11 @@ func GetActiveEnvironment(db *sql.DB, userID int) (*Environment, error) {
 
 	// Get the active environment for this user
 	err := db.QueryRow(`
-        SELECT id, name, description, is_current, user_id 
-        FROM environments 
-        WHERE user_id = ? AND is_current = 1 
+        SELECT id, name, description, is_current, user_id, wrapped_key
+        FROM environments
+        WHERE user_id = ? AND is_current = 1
         LIMIT 1`, userID).
-		Scan(&env.ID, &env.Name, &env.Description, &env.IsCurrent, &env.UserID)
+		Scan(&env.ID, &env.Name, &env.Description, &env.IsCurrent, &env.UserID, &env.WrappedKey)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no active environment found for user %d", userID)
@@ -481,24 +496,40 @@ func RemoveEnvironment(envFile *EnvironmentFile, name string) error {
 	return SaveEnvironmentFile(envFile, envFile.Environments[0].FilePath)
 }
 
+// DotenvContent renders the environment's variables and decrypted secrets in
+// .env format, sorted by key and quoted so values containing spaces, quotes
+// or newlines survive a round trip through godotenv.
+//
+// Parameters:
+//   - env: the environment to render; its encryption key must be set when it
+//     holds secrets
+//
+// Returns:
+//   - string: the .env file content, newline-terminated
+//   - error: ErrNoEncryptionKey or a decryption failure
+func DotenvContent(env *Environment) (string, error) {
+	vars, err := env.AsMap()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := godotenv.Marshal(vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to render .env content: %v", err)
+	}
+	return content + "\n", nil
+}
+
 // ExportDotenv exports the current environment to a .env file
 func ExportDotenv(env *Environment, outputPath string) error {
 	if outputPath == "" {
 		outputPath = ".env"
 	}
 
-	vars, err := env.AsMap()
+	content, err := DotenvContent(env)
 	if err != nil {
 		return err
 	}
-
-	// Convert to .env format
-	var lines []string
-	for k, v := range vars {
-		lines = append(lines, fmt.Sprintf("%s=%s", k, v))
-	}
-
-	content := strings.Join(lines, "\n")
 	return os.WriteFile(outputPath, []byte(content), 0600)
 }
 
@@ -532,48 +563,42 @@ func ImportDotenv(env *Environment, inputPath string, isSecret bool) error {
 
 // encrypt encrypts a string using AES-GCM
 func encrypt(plaintext string, key []byte) (string, error) {
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
+	aead, err := aeadFor(encryptionAlgorithm, key)
 	if err != nil {
 		return "", err
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
+	nonce := make([]byte, aead.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", err
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	ciphertext := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptionAlgorithm + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// decrypt decrypts a string using AES-GCM
+// decrypt decrypts a string produced by encrypt, dispatching on the
+// ciphertext's algorithm prefix. Unprefixed legacy ciphertexts decrypt as
+// AES-GCM, so secrets stored before the prefix existed keep working.
 func decrypt(encryptedText string, key []byte) (string, error) {
-	ciphertext, err := base64.StdEncoding.DecodeString(encryptedText)
-	if err != nil {
-		return "", err
-	}
+	alg, payload := splitAlgorithmPrefix(encryptedText)
 
-	block, err := aes.NewCipher(key)
+	ciphertext, err := base64.StdEncoding.DecodeString(payload)
 	if err != nil {
 		return "", err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	aead, err := aeadFor(alg, key)
 	if err != nil {
 		return "", err
 	}
 
-	if len(ciphertext) < gcm.NonceSize() {
+	if len(ciphertext) < aead.NonceSize() {
 		return "", errors.New("ciphertext too short")
 	}
 
-	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
 		return "", err
 	}
@@ -581,6 +606,22 @@ func decrypt(encryptedText string, key []byte) (string, error) {
 	return string(plaintext), nil
 }
 
+// aeadFor constructs the AEAD for an algorithm identifier over a 32-byte key.
+func aeadFor(alg string, key []byte) (cipher.AEAD, error) {
+	switch alg {
+	case AlgorithmAESGCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case AlgorithmChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	default:
+		return nil, fmt.Errorf("unsupported encryption algorithm %q", alg)
+	}
+}
+
 // SaveEnvironment persists an environment to the database
 func SaveEnvironment(db *sql.DB, env *Environment) error {
 	// Begin a transaction for atomicity
@@ -596,15 +637,15 @@ func SaveEnvironment(db *sql.DB, env *Environment) error {
 	// If env has an ID, update it; otherwise insert a new one
 	if env.ID > 0 {
 		_, err = tx.Exec(`
-            UPDATE environments 
-            SET name = ?, description = ?, is_current = ?, user_id = ? 
+            UPDATE environments
+            SET name = ?, description = ?, is_current = ?, user_id = ?, wrapped_key = ?
             WHERE id = ?`,
-			env.Name, env.Description, env.IsCurrent, env.UserID, env.ID)
+			env.Name, env.Description, env.IsCurrent, env.UserID, env.WrappedKey, env.ID)
 	} else {
 		result, err = tx.Exec(`
-            INSERT INTO environments (name, description, is_current, user_id) 
-            VALUES (?, ?, ?, ?)`,
-			env.Name, env.Description, env.IsCurrent, env.UserID)
+            INSERT INTO environments (name, description, is_current, user_id, wrapped_key)
+            VALUES (?, ?, ?, ?, ?)`,
+			env.Name, env.Description, env.IsCurrent, env.UserID, env.WrappedKey)
 
 		if err == nil {
 			id, _ := result.LastInsertId()
@@ -626,40 +667,95 @@ func SaveEnvironment(db *sql.DB, env *Environment) error {
 		return fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
+	// Pending deletions are persisted; forget them
+	env.clearDeleted()
+
 	return nil
 }
 
-// saveEnvironmentVariables is a helper function to save environment variables
+// storedVariable is the persisted shape of one environment_variables row,
+// used to diff stored state against in-memory state.
+type storedVariable struct {
+	value     string
+	isSecret  bool
+	encrypted string
+}
+
+// saveEnvironmentVariables reconciles the stored variables with the
+// environment's in-memory state using targeted statements. Keys are inserted
+// or updated one by one, and only keys explicitly removed via Delete are
+// deleted. Rows this Environment never saw — because a concurrent handler
+// wrote them after our load, or because a load missed them — survive the
+// save instead of being wiped by a blanket delete-and-reinsert.
 func saveEnvironmentVariables(tx *sql.Tx, env *Environment) error {
-	// First, delete existing variables for this environment
-	if env.ID > 0 {
-		_, err := tx.Exec("DELETE FROM environment_variables WHERE environment_id = ?", env.ID)
-		if err != nil {
-			return fmt.Errorf("failed to clear existing variables: %v", err)
+	// Read what is currently stored, inside the same transaction
+	rows, err := tx.Query(
+		"SELECT key, value, is_secret, encrypted_value FROM environment_variables WHERE environment_id = ?",
+		env.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load existing variables: %v", err)
+	}
+
+	stored := make(map[string]storedVariable)
+	for rows.Next() {
+		var key string
+		var row storedVariable
+		if err := rows.Scan(&key, &row.value, &row.isSecret, &row.encrypted); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan existing variable: %v", err)
 		}
+		stored[key] = row
 	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error during variable row iteration: %v", err)
+	}
+	rows.Close()
 
-	// Insert regular variables
+	// Desired state: plain variables and encrypted secrets
+	desired := make(map[string]storedVariable, len(env.Variables)+len(env.Secrets))
 	for key, value := range env.Variables {
-		_, err := tx.Exec(`
-            INSERT INTO environment_variables 
-            (environment_id, key, value, is_secret, encrypted_value) 
-            VALUES (?, ?, ?, ?, ?)`,
-			env.ID, key, value, false, "")
+		desired[key] = storedVariable{value: value}
+	}
+	for key, encValue := range env.Secrets {
+		desired[key] = storedVariable{isSecret: true, encrypted: encValue}
+	}
+
+	// Upsert new and changed keys
+	for key, want := range desired {
+		have, exists := stored[key]
+		if exists && have == want {
+			continue
+		}
+		if exists {
+			_, err = tx.Exec(`
+                UPDATE environment_variables
+                SET value = ?, is_secret = ?, encrypted_value = ?
+                WHERE environment_id = ? AND key = ?`,
+				want.value, want.isSecret, want.encrypted, env.ID, key)
+		} else {
+			_, err = tx.Exec(`
+                INSERT INTO environment_variables
+                (environment_id, key, value, is_secret, encrypted_value)
+                VALUES (?, ?, ?, ?, ?)`,
+				env.ID, key, want.value, want.isSecret, want.encrypted)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to insert variable %s: %v", key, err)
+			return fmt.Errorf("failed to save variable %s: %v", key, err)
 		}
 	}
 
-	// Insert secrets
-	for key, encValue := range env.Secrets {
-		_, err := tx.Exec(`
-            INSERT INTO environment_variables 
-            (environment_id, key, value, is_secret, encrypted_value) 
-            VALUES (?, ?, ?, ?, ?)`,
-			env.ID, key, "", true, encValue)
-		if err != nil {
-			return fmt.Errorf("failed to insert secret %s: %v", key, err)
+	// Remove only keys explicitly deleted and not re-set since
+	for key := range env.deleted {
+		if _, keep := desired[key]; keep {
+			continue
+		}
+		if _, err := tx.Exec(
+			"DELETE FROM environment_variables WHERE environment_id = ? AND key = ?",
+			env.ID, key,
+		); err != nil {
+			return fmt.Errorf("failed to delete variable %s: %v", key, err)
 		}
 	}
 
@@ -0,0 +1,84 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrapUnwrapRoundTrip(t *testing.T) {
+	dataKey, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("GenerateDataKey failed: %v", err)
+	}
+	if dataKey == "" {
+		t.Fatal("GenerateDataKey returned an empty key")
+	}
+
+	wrapped, err := WrapDataKey(dataKey, "master-key")
+	if err != nil {
+		t.Fatalf("WrapDataKey failed: %v", err)
+	}
+	if strings.Contains(wrapped, dataKey) {
+		t.Error("wrapped blob contains the plaintext data key")
+	}
+
+	unwrapped, err := UnwrapDataKey(wrapped, "master-key")
+	if err != nil {
+		t.Fatalf("UnwrapDataKey failed: %v", err)
+	}
+	if unwrapped != dataKey {
+		t.Errorf("unwrapped key = %q, want the original data key", unwrapped)
+	}
+}
+
+func TestUnwrapWithWrongMasterKeyFails(t *testing.T) {
+	dataKey, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("GenerateDataKey failed: %v", err)
+	}
+	wrapped, err := WrapDataKey(dataKey, "master-key")
+	if err != nil {
+		t.Fatalf("WrapDataKey failed: %v", err)
+	}
+
+	if _, err := UnwrapDataKey(wrapped, "not-the-master-key"); err == nil {
+		t.Error("UnwrapDataKey succeeded with the wrong master key")
+	}
+	if _, err := UnwrapDataKey("garbage", "master-key"); err == nil {
+		t.Error("UnwrapDataKey succeeded on a malformed blob")
+	}
+}
+
+func TestWrapRequiresMasterKey(t *testing.T) {
+	if _, err := WrapDataKey("data", ""); err != ErrNoEncryptionKey {
+		t.Errorf("WrapDataKey(\"\") err = %v, want ErrNoEncryptionKey", err)
+	}
+	if _, err := UnwrapDataKey("blob", ""); err != ErrNoEncryptionKey {
+		t.Errorf("UnwrapDataKey(\"\") err = %v, want ErrNoEncryptionKey", err)
+	}
+}
+
+func TestGenerateDataKeyIsRandom(t *testing.T) {
+	a, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("GenerateDataKey failed: %v", err)
+	}
+	b, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("GenerateDataKey failed: %v", err)
+	}
+	if a == b {
+		t.Error("two generated data keys are identical")
+	}
+
+	// A generated key works with the environment's key derivation
+	environment := NewEnvironment("test", "")
+	environment.SetEncryptionKey(a)
+	if err := environment.Set("TOKEN", "s3cret", true); err != nil {
+		t.Fatalf("Set with a generated key failed: %v", err)
+	}
+	value, _, err := environment.Get("TOKEN")
+	if err != nil || value != "s3cret" {
+		t.Errorf("Get = %q, %v; want the decrypted secret", value, err)
+	}
+}
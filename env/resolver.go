@@ -0,0 +1,81 @@
+package env
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Resolver resolves a named environment into its variables, decrypting
+// secrets when an encryption key is available. Hosts reference environments
+// by name via the env_name field; a Resolver hides whether the store behind
+// it is the environment file (CLI mode) or the database (server mode).
+type Resolver func(name string) (map[string]string, error)
+
+// FileResolver returns a Resolver backed by the JSON environment file.
+//
+// Parameters:
+//   - filePath: path to the environment file (empty uses DefaultEnvFile)
+//   - encryptionKey: key used to decrypt secrets; may be empty when the
+//     environment holds no secrets
+//
+// Returns:
+//   - Resolver: resolves environment names against the file store
+func FileResolver(filePath, encryptionKey string) Resolver {
+	return func(name string) (map[string]string, error) {
+		envFile, err := LoadEnvironmentFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range envFile.Environments {
+			if e.Name != name {
+				continue
+			}
+			if encryptionKey != "" {
+				e.SetEncryptionKey(encryptionKey)
+			}
+			vars, err := e.AsMap()
+			if err != nil {
+				return nil, fmt.Errorf("environment '%s': %v", name, err)
+			}
+			return vars, nil
+		}
+
+		return nil, fmt.Errorf("environment '%s' not found", name)
+	}
+}
+
+// DBResolver returns a Resolver backed by the database environment store.
+//
+// Parameters:
+//   - db: database connection holding the environments tables
+//   - userID: owner whose environments are searched
+//   - encryptionKey: key used to decrypt secrets; may be empty when the
+//     environment holds no secrets
+//
+// Returns:
+//   - Resolver: resolves environment names against the database store
+func DBResolver(db *sql.DB, userID int, encryptionKey string) Resolver {
+	return func(name string) (map[string]string, error) {
+		environments, err := GetEnvironments(db, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range environments {
+			if e.Name != name {
+				continue
+			}
+			if encryptionKey != "" {
+				e.SetEncryptionKey(encryptionKey)
+			}
+			vars, err := e.AsMap()
+			if err != nil {
+				return nil, fmt.Errorf("environment '%s': %v", name, err)
+			}
+			return vars, nil
+		}
+
+		return nil, fmt.Errorf("environment '%s' not found", name)
+	}
+}
@@ -0,0 +1,182 @@
+package env
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newEnvTestDB creates an in-memory database with the environments schema.
+func newEnvTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	schema := []string{
+		`CREATE TABLE environments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT,
+			is_current BOOLEAN DEFAULT 0,
+			user_id INTEGER NOT NULL,
+			wrapped_key TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE environment_variables (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			environment_id INTEGER NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT,
+			is_secret BOOLEAN DEFAULT 0,
+			encrypted_value TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (environment_id, key)
+		);`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+	return db
+}
+
+// savedEnvironment creates and persists an environment with the given
+// plain variables, returning its database ID.
+func savedEnvironment(t *testing.T, db *sql.DB, vars map[string]string) int {
+	t.Helper()
+
+	env := NewEnvironment("production", "")
+	env.UserID = 1
+	for key, value := range vars {
+		if err := env.Set(key, value, false); err != nil {
+			t.Fatalf("failed to set %s: %v", key, err)
+		}
+	}
+	if err := SaveEnvironment(db, env); err != nil {
+		t.Fatalf("failed to save environment: %v", err)
+	}
+	return env.ID
+}
+
+func TestConcurrentSavesPreserveEachOthersKeys(t *testing.T) {
+	db := newEnvTestDB(t)
+	id := savedEnvironment(t, db, map[string]string{"SHARED": "1"})
+
+	// Two handlers load the same environment before either saves
+	first, err := GetEnvironment(db, id)
+	if err != nil {
+		t.Fatalf("failed to load environment: %v", err)
+	}
+	second, err := GetEnvironment(db, id)
+	if err != nil {
+		t.Fatalf("failed to load environment: %v", err)
+	}
+
+	// Each sets a different key and saves
+	if err := first.Set("FIRST", "a", false); err != nil {
+		t.Fatalf("failed to set FIRST: %v", err)
+	}
+	if err := SaveEnvironment(db, first); err != nil {
+		t.Fatalf("failed to save first copy: %v", err)
+	}
+	if err := second.Set("SECOND", "b", false); err != nil {
+		t.Fatalf("failed to set SECOND: %v", err)
+	}
+	if err := SaveEnvironment(db, second); err != nil {
+		t.Fatalf("failed to save second copy: %v", err)
+	}
+
+	final, err := GetEnvironment(db, id)
+	if err != nil {
+		t.Fatalf("failed to reload environment: %v", err)
+	}
+	for key, want := range map[string]string{"SHARED": "1", "FIRST": "a", "SECOND": "b"} {
+		if got := final.Variables[key]; got != want {
+			t.Errorf("variable %s = %q, want %q; the second save must not wipe keys it never saw", key, got, want)
+		}
+	}
+}
+
+func TestDeleteRemovesOnlyThatKey(t *testing.T) {
+	db := newEnvTestDB(t)
+	id := savedEnvironment(t, db, map[string]string{"KEEP": "1", "DROP": "2"})
+
+	env, err := GetEnvironment(db, id)
+	if err != nil {
+		t.Fatalf("failed to load environment: %v", err)
+	}
+	env.Delete("DROP")
+	if err := SaveEnvironment(db, env); err != nil {
+		t.Fatalf("failed to save environment: %v", err)
+	}
+
+	final, err := GetEnvironment(db, id)
+	if err != nil {
+		t.Fatalf("failed to reload environment: %v", err)
+	}
+	if _, exists := final.Variables["DROP"]; exists {
+		t.Error("deleted key still present after save")
+	}
+	if final.Variables["KEEP"] != "1" {
+		t.Errorf("unrelated key lost: %+v", final.Variables)
+	}
+
+	// The deletion is consumed by the save; saving again must not delete a
+	// value someone else wrote for the same key in the meantime
+	restored, err := GetEnvironment(db, id)
+	if err != nil {
+		t.Fatalf("failed to reload environment: %v", err)
+	}
+	if err := restored.Set("DROP", "3", false); err != nil {
+		t.Fatalf("failed to restore DROP: %v", err)
+	}
+	if err := SaveEnvironment(db, restored); err != nil {
+		t.Fatalf("failed to save restored copy: %v", err)
+	}
+	if err := SaveEnvironment(db, env); err != nil {
+		t.Fatalf("failed to re-save original copy: %v", err)
+	}
+	final, err = GetEnvironment(db, id)
+	if err != nil {
+		t.Fatalf("failed to reload environment: %v", err)
+	}
+	if final.Variables["DROP"] != "3" {
+		t.Errorf("consumed deletion was re-applied: %+v", final.Variables)
+	}
+}
+
+func TestSaveUpdatesChangedValuesInPlace(t *testing.T) {
+	db := newEnvTestDB(t)
+	id := savedEnvironment(t, db, map[string]string{"PORT": "8080"})
+
+	env, err := GetEnvironment(db, id)
+	if err != nil {
+		t.Fatalf("failed to load environment: %v", err)
+	}
+	if err := env.Set("PORT", "9090", false); err != nil {
+		t.Fatalf("failed to update PORT: %v", err)
+	}
+	if err := SaveEnvironment(db, env); err != nil {
+		t.Fatalf("failed to save environment: %v", err)
+	}
+
+	var value string
+	var count int
+	if err := db.QueryRow(
+		"SELECT value, COUNT(*) FROM environment_variables WHERE environment_id = ? AND key = 'PORT'", id,
+	).Scan(&value, &count); err != nil {
+		t.Fatalf("failed to query PORT row: %v", err)
+	}
+	if value != "9090" || count != 1 {
+		t.Errorf("PORT row = (%q, %d), want a single updated row", value, count)
+	}
+}
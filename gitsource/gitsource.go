@@ -0,0 +1,204 @@
+// Package gitsource fetches a single configuration file from a Git
+// repository, so configs can be imported from and synced with the repo that
+// owns them instead of being uploaded by hand. It shells out to the git
+// binary with a shallow clone, the same way inventory commands already rely
+// on the host toolchain, and supports token auth for HTTPS remotes and key
+// auth for SSH remotes.
+package gitsource
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Credential carries the secrets used to clone a private repository. Both
+// fields are optional; the zero value clones anonymously.
+type Credential struct {
+	Token  string // Access token for HTTPS remotes, injected into the clone URL
+	SSHKey string // PEM private key for SSH remotes, written to a temp file for the clone
+}
+
+// File is one file fetched from a repository, together with the commit it
+// was read from so the import can be recorded with full provenance.
+type File struct {
+	Content []byte // The file's content at the fetched commit
+	Commit  string // Full hash of the fetched commit
+}
+
+// RepoHost extracts the host part of a clone URL, used to look up stored
+// credentials. It understands both URL-style remotes
+// (https://github.com/org/repo.git) and scp-style SSH remotes
+// (git@github.com:org/repo.git).
+//
+// Parameters:
+//   - repoURL: the clone URL as the user supplied it
+//
+// Returns:
+//   - string: the bare host name, or "" when none can be found
+func RepoHost(repoURL string) string {
+	if parsed, err := url.Parse(repoURL); err == nil && parsed.Host != "" {
+		return parsed.Hostname()
+	}
+	// scp-style: [user@]host:path
+	if at := strings.Index(repoURL, "@"); at >= 0 {
+		rest := repoURL[at+1:]
+		if colon := strings.Index(rest, ":"); colon > 0 {
+			return rest[:colon]
+		}
+	}
+	return ""
+}
+
+// ValidateRemote checks that a clone URL and ref are plain remote references
+// before they reach git on the command line. Git accepts far more than
+// remotes there — transport helpers like ext:: run arbitrary commands, and
+// values beginning with "-" parse as options — so the API validates every
+// user-supplied or stored remote with this before calling Fetch. The CLI
+// import does not: cloning a local path on one's own machine is legitimate.
+//
+// Parameters:
+//   - repoURL: the clone URL as the caller supplied it
+//   - ref: branch or tag to fetch, possibly empty
+//
+// Returns:
+//   - error: if either value could be read as anything other than an
+//     http(s), ssh or scp-style remote plus a plain ref
+func ValidateRemote(repoURL, ref string) error {
+	if strings.HasPrefix(repoURL, "-") {
+		return fmt.Errorf("repository URL must not begin with '-'")
+	}
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("ref must not begin with '-'")
+	}
+	if scheme, _, ok := strings.Cut(repoURL, "://"); ok {
+		switch strings.ToLower(scheme) {
+		case "http", "https", "ssh":
+			return nil
+		}
+		return fmt.Errorf("unsupported remote scheme %q; expected an http(s), ssh or scp-style remote", scheme)
+	}
+	// Without a scheme, only the scp-style [user@]host:path form is accepted.
+	// A path beginning with another ":" is git's transport-helper syntax
+	// (ext:: runs a shell command), never a remote.
+	userHost, path, ok := strings.Cut(repoURL, ":")
+	if !ok || path == "" || strings.HasPrefix(path, ":") || strings.Contains(userHost, "/") ||
+		userHost[strings.LastIndex(userHost, "@")+1:] == "" {
+		return fmt.Errorf("unrecognized remote %q; expected an http(s), ssh or scp-style remote", repoURL)
+	}
+	return nil
+}
+
+// Fetch shallow-clones one ref of a repository into a temporary directory,
+// reads a single file out of it and returns the file with the commit it came
+// from. The clone is removed before returning.
+//
+// Parameters:
+//   - repoURL: the clone URL (HTTPS, SSH URL or scp-style)
+//   - ref: branch or tag to fetch; empty means the remote's default branch
+//   - path: file path inside the repository, relative to its root
+//   - cred: secrets for private repositories; the zero value is anonymous
+//
+// Returns:
+//   - *File: the file content and source commit
+//   - error: if the clone fails or the file does not exist at that ref
+func Fetch(repoURL, ref, path string, cred Credential) (*File, error) {
+	cleaned, err := repoRelativePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "nyatictl-gitsource-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clone directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cloneURL := repoURL
+	if cred.Token != "" {
+		cloneURL, err = injectToken(repoURL, cred.Token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cloneDir := filepath.Join(dir, "repo")
+	args := []string{"clone", "--depth", "1", "--quiet"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	// "--" keeps an option-like URL or directory from being parsed as a flag
+	args = append(args, "--", cloneURL, cloneDir)
+
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if cred.SSHKey != "" {
+		keyPath := filepath.Join(dir, "id_key")
+		if err := os.WriteFile(keyPath, []byte(cred.SSHKey), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write SSH key: %v", err)
+		}
+		env = append(env, fmt.Sprintf(
+			"GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new", keyPath))
+	}
+
+	clone := exec.Command("git", args...)
+	clone.Env = env
+	if out, err := clone.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %s", repoURL, redactToken(string(out), cred.Token))
+	}
+
+	revParse := exec.Command("git", "-C", cloneDir, "rev-parse", "HEAD")
+	commitOut, err := revParse.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the fetched commit: %v", err)
+	}
+	commit := strings.TrimSpace(string(commitOut))
+
+	content, err := os.ReadFile(filepath.Join(cloneDir, cleaned))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s does not exist in the repository at that ref", cleaned)
+		}
+		return nil, fmt.Errorf("failed to read %s from the clone: %v", cleaned, err)
+	}
+
+	return &File{Content: content, Commit: commit}, nil
+}
+
+// repoRelativePath validates that path stays inside the repository root and
+// returns its cleaned form.
+func repoRelativePath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("a file path inside the repository is required")
+	}
+	cleaned := filepath.Clean(path)
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file path %q must stay inside the repository", path)
+	}
+	return cleaned, nil
+}
+
+// injectToken rewrites an HTTPS clone URL to carry the access token as
+// userinfo, the form every major Git host accepts for token auth.
+func injectToken(repoURL, token string) (string, error) {
+	parsed, err := url.Parse(repoURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("token auth requires a URL-style remote, got %q", repoURL)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return "", fmt.Errorf("token auth only applies to HTTP(S) remotes, got a %s remote", parsed.Scheme)
+	}
+	parsed.User = url.UserPassword("x-access-token", token)
+	return parsed.String(), nil
+}
+
+// redactToken masks the token wherever it appears in git's output, so a
+// failed clone cannot leak the secret into logs or error responses.
+func redactToken(output, token string) string {
+	if token == "" {
+		return output
+	}
+	return strings.ReplaceAll(output, token, "****")
+}
@@ -0,0 +1,165 @@
+// Package history exports recorded deployment history in machine-readable
+// formats. It is shared by the web API's export endpoint and the
+// `nyatictl history export` command, which runs against the local database.
+package history
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Export formats accepted by Export.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Row is one exported deployment. Timestamps are RFC3339 in UTC so exports
+// from differently-configured machines line up in a spreadsheet.
+type Row struct {
+	ConfigPath string `json:"config_path"` // Config file the deploy ran from
+	Host       string `json:"host"`        // Target host alias
+	User       string `json:"user"`        // Email of the deploying user, empty if unknown
+	Status     string `json:"status"`      // pending, success or error
+	StartedAt  string `json:"started_at"`  // RFC3339 UTC start time
+	DurationMS int64  `json:"duration_ms"` // Wall-clock run time in milliseconds
+	Note       string `json:"note"`        // Free-form deploy note
+	ConfigHash string `json:"config_hash"` // Hash of the deployed config snapshot
+}
+
+// Options narrows and formats an export.
+type Options struct {
+	From   time.Time // Include deploys started on or after this day; zero means unbounded
+	To     time.Time // Include deploys started up to and including this day; zero means unbounded
+	UserID int       // Restrict to one user's deploys; 0 exports everyone's
+	Format string    // FormatCSV or FormatJSON
+}
+
+// ParseDay parses a YYYY-MM-DD date as UTC midnight, the granularity the
+// export range filters work at.
+//
+// Parameters:
+//   - value: the date string, e.g. "2024-03-31"
+//
+// Returns:
+//   - time.Time: UTC midnight of that day
+//   - error: if the value is not a valid date
+func ParseDay(value string) (time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", value, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s'; expected YYYY-MM-DD", value)
+	}
+	return day, nil
+}
+
+// Export streams matching deployment rows to w, one row at a time, so
+// arbitrarily large ranges never hold the full result set in memory. CSV
+// output starts with a header row; JSON output is a single array.
+//
+// Parameters:
+//   - database: the deployments database to read from
+//   - opts: range, visibility and format options
+//   - w: destination for the formatted export
+//
+// Returns:
+//   - error: if the options are invalid or the query or a write fails
+func Export(database *sql.DB, opts Options, w io.Writer) error {
+	if opts.Format != FormatCSV && opts.Format != FormatJSON {
+		return fmt.Errorf("invalid format '%s'; expected %s or %s", opts.Format, FormatCSV, FormatJSON)
+	}
+
+	query := `SELECT d.config_path, d.host, COALESCE(u.email, ''), d.status, d.created_at, d.duration_ms, d.note, d.config_hash
+		FROM deployments d
+		LEFT JOIN users u ON u.id = d.user_id
+		WHERE 1=1`
+	var args []interface{}
+	if !opts.From.IsZero() {
+		query += " AND d.created_at >= ?"
+		args = append(args, opts.From.UTC())
+	}
+	if !opts.To.IsZero() {
+		// To names an inclusive day, so the cut-off is the next midnight
+		query += " AND d.created_at < ?"
+		args = append(args, opts.To.UTC().AddDate(0, 0, 1))
+	}
+	if opts.UserID != 0 {
+		query += " AND d.user_id = ?"
+		args = append(args, opts.UserID)
+	}
+	query += " ORDER BY d.created_at, d.id"
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query deployments: %v", err)
+	}
+	defer rows.Close()
+
+	write, finish, err := newRowWriter(w, opts.Format)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var row Row
+		var started time.Time
+		if err := rows.Scan(&row.ConfigPath, &row.Host, &row.User, &row.Status,
+			&started, &row.DurationMS, &row.Note, &row.ConfigHash); err != nil {
+			return fmt.Errorf("failed to scan deployment: %v", err)
+		}
+		row.StartedAt = started.UTC().Format(time.RFC3339)
+		if err := write(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read deployments: %v", err)
+	}
+	return finish()
+}
+
+// newRowWriter returns per-row write and final flush functions for the
+// chosen format, emitting any header up front.
+func newRowWriter(w io.Writer, format string) (func(Row) error, func() error, error) {
+	if format == FormatCSV {
+		cw := csv.NewWriter(w)
+		header := []string{"config_path", "host", "user", "status", "started_at", "duration_ms", "note", "config_hash"}
+		if err := cw.Write(header); err != nil {
+			return nil, nil, err
+		}
+		write := func(r Row) error {
+			return cw.Write([]string{
+				r.ConfigPath, r.Host, r.User, r.Status, r.StartedAt,
+				fmt.Sprintf("%d", r.DurationMS), r.Note, r.ConfigHash,
+			})
+		}
+		finish := func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+		return write, finish, nil
+	}
+
+	// JSON: a streamed array, one element encoded per row
+	enc := json.NewEncoder(w)
+	count := 0
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nil, nil, err
+	}
+	write := func(r Row) error {
+		if count > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		count++
+		return enc.Encode(r)
+	}
+	finish := func() error {
+		_, err := io.WriteString(w, "]")
+		return err
+	}
+	return write, finish, nil
+}
@@ -0,0 +1,155 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newExportTestDB creates an in-memory database with deployment history and
+// two users, returning the open handle.
+func newExportTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	database, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	database.SetMaxOpenConns(1)
+	t.Cleanup(func() { database.Close() })
+
+	schema := []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);`,
+		`CREATE TABLE deployments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			config_path TEXT NOT NULL,
+			host TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			config_hash TEXT NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'pending',
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`INSERT INTO users (id, email) VALUES (1, 'admin@example.com'), (2, 'dev@example.com');`,
+	}
+	for _, stmt := range schema {
+		if _, err := database.Exec(stmt); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+	return database
+}
+
+// insertDeployment adds one history row with an explicit start time.
+func insertDeployment(t *testing.T, database *sql.DB, userID int, host, note, status string, started time.Time) {
+	t.Helper()
+
+	if _, err := database.Exec(
+		`INSERT INTO deployments (config_path, host, user_id, config_hash, note, status, duration_ms, created_at)
+		 VALUES ('/tmp/nyati.yaml', ?, ?, 'abc123', ?, ?, 1500, ?)`,
+		host, userID, note, status, started,
+	); err != nil {
+		t.Fatalf("failed to insert deployment: %v", err)
+	}
+}
+
+func TestExportCSVEscapesAndFormatsUTC(t *testing.T) {
+	database := newExportTestDB(t)
+	started := time.Date(2024, 2, 10, 9, 30, 0, 0, time.UTC)
+	insertDeployment(t, database, 2, "server1", "rollout, with \"quotes\"\nand a newline", "success", started)
+
+	var out strings.Builder
+	if err := Export(database, Options{Format: FormatCSV}, &out); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v\n%s", err, out.String())
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d CSV records, want header plus one row", len(records))
+	}
+	if records[0][0] != "config_path" || records[0][4] != "started_at" {
+		t.Errorf("header = %v, want the documented column order", records[0])
+	}
+	row := records[1]
+	if row[6] != "rollout, with \"quotes\"\nand a newline" {
+		t.Errorf("note = %q, want the raw note round-tripped through CSV escaping", row[6])
+	}
+	if row[4] != "2024-02-10T09:30:00Z" {
+		t.Errorf("started_at = %q, want RFC3339 UTC", row[4])
+	}
+	if row[3] != "success" || row[5] != "1500" || row[2] != "dev@example.com" {
+		t.Errorf("row = %v, want status/duration/user filled in", row)
+	}
+}
+
+func TestExportJSONStreamsArray(t *testing.T) {
+	database := newExportTestDB(t)
+	started := time.Date(2024, 2, 10, 9, 30, 0, 0, time.UTC)
+	insertDeployment(t, database, 1, "server1", "", "success", started)
+	insertDeployment(t, database, 1, "server2", "", "error", started.Add(time.Hour))
+
+	var out strings.Builder
+	if err := Export(database, Options{Format: FormatJSON}, &out); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	var rows []Row
+	if err := json.Unmarshal([]byte(out.String()), &rows); err != nil {
+		t.Fatalf("export is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0].Host != "server1" || rows[1].Host != "server2" {
+		t.Errorf("rows out of chronological order: %+v", rows)
+	}
+	if rows[0].StartedAt != "2024-02-10T09:30:00Z" {
+		t.Errorf("started_at = %q, want RFC3339 UTC", rows[0].StartedAt)
+	}
+}
+
+func TestExportFiltersByRangeAndUser(t *testing.T) {
+	database := newExportTestDB(t)
+	insertDeployment(t, database, 1, "january", "", "success", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	insertDeployment(t, database, 1, "march", "", "success", time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC))
+	insertDeployment(t, database, 1, "april", "", "success", time.Date(2024, 4, 1, 0, 30, 0, 0, time.UTC))
+	insertDeployment(t, database, 2, "other-user", "", "success", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	from, _ := ParseDay("2024-01-01")
+	to, _ := ParseDay("2024-03-31")
+
+	var out strings.Builder
+	if err := Export(database, Options{From: from, To: to, UserID: 1, Format: FormatJSON}, &out); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	var rows []Row
+	if err := json.Unmarshal([]byte(out.String()), &rows); err != nil {
+		t.Fatalf("export is not valid JSON: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Host != "january" || rows[1].Host != "march" {
+		t.Errorf("rows = %+v, want january and march only (to-day inclusive, other users excluded)", rows)
+	}
+}
+
+func TestExportRejectsUnknownFormat(t *testing.T) {
+	database := newExportTestDB(t)
+
+	var out strings.Builder
+	err := Export(database, Options{Format: "xml"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("Export with unknown format = %v, want a format error", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("an invalid format must not write anything, got %q", out.String())
+	}
+}
@@ -0,0 +1,106 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+// DangerousPattern pairs a regular expression with the warning emitted when a
+// task command matches it. The default set below can be replaced wholesale
+// with SetDangerousPatterns for operators who maintain their own list.
+type DangerousPattern struct {
+	Pattern *regexp.Regexp // Matched against the raw task command
+	Message string         // Explanation shown in the finding
+}
+
+// dangerousPatterns is the active ruleset CheckCommand evaluates.
+var dangerousPatterns = []DangerousPattern{
+	{
+		Pattern: regexp.MustCompile(`rm\s+-(?:[a-z]*r[a-z]*f|[a-z]*f[a-z]*r)[a-z]*\s+["']?/(?:\*|["'\s]|$)`),
+		Message: "recursive delete of the filesystem root",
+	},
+	{
+		Pattern: regexp.MustCompile(`rm\s+-(?:[a-z]*r[a-z]*f|[a-z]*f[a-z]*r)[a-z]*\s+["']?\$`),
+		Message: "recursive delete rooted at a variable; an empty value deletes from /",
+	},
+	{
+		Pattern: regexp.MustCompile(`\bmkfs(?:\.\w+)?\s`),
+		Message: "formats a filesystem",
+	},
+	{
+		Pattern: regexp.MustCompile(`\bdd\s+[^|;&]*of=/dev/`),
+		Message: "writes directly to a block device",
+	},
+	{
+		Pattern: regexp.MustCompile(`\bchmod\s+(?:-R\s+)?0?777\b`),
+		Message: "grants world-writable permissions",
+	},
+	{
+		Pattern: regexp.MustCompile(`\b(?:curl|wget)\b[^|;&]*\|\s*(?:sudo\s+)?(?:ba|z|da)?sh\b`),
+		Message: "pipes a download straight into a shell",
+	},
+	{
+		Pattern: regexp.MustCompile(`>\s*/dev/sd`),
+		Message: "redirects output onto a raw disk",
+	},
+}
+
+// SetDangerousPatterns replaces the active dangerous-command ruleset. Passing
+// nil restores nothing; callers that need the defaults back should save the
+// previous value, the same way tests do.
+func SetDangerousPatterns(patterns []DangerousPattern) []DangerousPattern {
+	previous := dangerousPatterns
+	dangerousPatterns = patterns
+	return previous
+}
+
+// CheckCommand evaluates a single command against the active ruleset and
+// returns one message per matching pattern. It is the unit the danger rules
+// are built on, kept separate so the ruleset is testable without a config.
+//
+// Parameters:
+//   - cmd: the raw task command
+//
+// Returns:
+//   - []string: one explanation per matched pattern, possibly empty
+func CheckCommand(cmd string) []string {
+	var out []string
+	for _, p := range dangerousPatterns {
+		if p.Pattern.MatchString(cmd) {
+			out = append(out, p.Message)
+		}
+	}
+	return out
+}
+
+// checkDangerousCommands flags task commands matching the dangerous-command
+// ruleset: destructive deletes, raw disk writes, curl-pipe-sh, and friends.
+func checkDangerousCommands(cfg *config.Config) []string {
+	var out []string
+	for _, task := range cfg.Tasks {
+		for _, msg := range CheckCommand(task.Cmd) {
+			out = append(out, fmt.Sprintf("task '%s': %s", task.Name, msg))
+		}
+	}
+	return out
+}
+
+// checkMissingSetE flags multi-statement scripts that do not start with
+// set -e; without it the script keeps running after an earlier step fails.
+// Commands chained with && already stop on failure and are left alone.
+func checkMissingSetE(cfg *config.Config) []string {
+	var out []string
+	for _, task := range cfg.Tasks {
+		if !strings.ContainsAny(task.Cmd, "\n;") {
+			continue
+		}
+		if strings.Contains(task.Cmd, "set -e") {
+			continue
+		}
+		out = append(out, fmt.Sprintf("task '%s': multi-statement script without set -e; later steps run even after a failure", task.Name))
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+func TestCheckCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		want int
+	}{
+		{"root delete", "rm -rf /", 1},
+		{"root glob delete", "rm -rf /*", 1},
+		{"variable-rooted delete", "rm -rf $RELEASE_DIR/old", 1},
+		{"filesystem format", "mkfs.ext4 /dev/sdb1", 1},
+		{"raw disk write", "dd if=image.img of=/dev/sda bs=4M", 1},
+		{"world-writable chmod", "chmod -R 777 /var/www", 1},
+		{"curl piped to shell", "curl -fsSL https://example.com/install.sh | sh", 1},
+		{"wget piped to sudo bash", "wget -qO- https://example.com/setup | sudo bash", 1},
+		{"scoped delete", "rm -rf /var/www/releases/old", 0},
+		{"quoted variable delete", `rm -rf "$RELEASE_DIR/old"`, 1},
+		{"ordinary build", "make build && make test", 0},
+		{"download to file", "curl -o installer.sh https://example.com/install.sh", 0},
+		{"sane chmod", "chmod 755 deploy.sh", 0},
+	}
+	for _, tc := range tests {
+		if got := CheckCommand(tc.cmd); len(got) != tc.want {
+			t.Errorf("%s: CheckCommand(%q) = %v, want %d finding(s)", tc.name, tc.cmd, got, tc.want)
+		}
+	}
+}
+
+func TestSetDangerousPatternsReplacesRuleset(t *testing.T) {
+	previous := SetDangerousPatterns([]DangerousPattern{{
+		Pattern: regexp.MustCompile(`\bdrop\s+database\b`),
+		Message: "drops a database",
+	}})
+	defer SetDangerousPatterns(previous)
+
+	if got := CheckCommand("rm -rf /"); got != nil {
+		t.Errorf("default rules still active after replacement: %v", got)
+	}
+	if got := CheckCommand("mysql -e 'drop database prod'"); len(got) != 1 {
+		t.Errorf("custom rule did not fire: %v", got)
+	}
+}
+
+func TestDangerousCommandFindings(t *testing.T) {
+	cfg := &config.Config{Tasks: []config.Task{
+		{Name: "cleanup", Cmd: "rm -rf /", Message: "Cleaning"},
+		{Name: "build", Cmd: "make build", Message: "Building"},
+	}}
+
+	findings := findingsFor(cfg, "NYT007")
+	if len(findings) != 1 {
+		t.Fatalf("got %d NYT007 findings, want 1: %v", len(findings), findings)
+	}
+	if findings[0].Location != "task 'cleanup'" {
+		t.Errorf("finding location = %q, want the offending task named", findings[0].Location)
+	}
+	if findings[0].Severity != SeverityWarning {
+		t.Errorf("severity = %s, want a warning that does not block by default", findings[0].Severity)
+	}
+}
+
+func TestMissingSetEFindings(t *testing.T) {
+	cfg := &config.Config{Tasks: []config.Task{
+		{Name: "migrate", Cmd: "cd /var/www\nphp artisan migrate\nphp artisan cache:clear", Message: "Migrating"},
+		{Name: "safe-migrate", Cmd: "set -e\ncd /var/www\nphp artisan migrate", Message: "Migrating"},
+		{Name: "chained", Cmd: "make build && make test", Message: "Building"},
+	}}
+
+	findings := findingsFor(cfg, "NYT008")
+	if len(findings) != 1 {
+		t.Fatalf("got %d NYT008 findings, want 1: %v", len(findings), findings)
+	}
+	if !strings.Contains(findings[0].Location, "migrate") {
+		t.Errorf("finding location = %q, want the script without set -e", findings[0].Location)
+	}
+}
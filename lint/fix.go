@@ -0,0 +1,68 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+)
+
+// cmdLinePattern matches the value portion of a `cmd:` line in a config file.
+var cmdLinePattern = regexp.MustCompile(`^(\s*(?:-\s+)?cmd:\s*)(.*)$`)
+
+// FixContent applies the safe automatic fixes to raw config file content and
+// returns the rewritten content plus the number of fixes applied.
+//
+// Only text-level repairs that cannot change command semantics are attempted;
+// currently that is quoting bare ${...} interpolations on cmd lines (NYT003).
+// The file is edited line by line so comments and formatting are preserved.
+//
+// Parameters:
+//   - content: the raw YAML config file content
+//
+// Returns:
+//   - string: the fixed content (identical to the input when nothing applied)
+//   - int: how many individual fixes were made
+func FixContent(content string) (string, int) {
+	lines := strings.Split(content, "\n")
+	fixes := 0
+
+	for i, line := range lines {
+		m := cmdLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		// Leave YAML-quoted scalars alone: inserting quotes inside them would
+		// produce invalid YAML rather than a safe fix.
+		if strings.HasPrefix(m[2], `"`) || strings.HasPrefix(m[2], `'`) {
+			continue
+		}
+		fixed, n := quoteBareVars(m[2])
+		if n > 0 {
+			lines[i] = m[1] + fixed
+			fixes += n
+		}
+	}
+
+	return strings.Join(lines, "\n"), fixes
+}
+
+// quoteBareVars wraps every unquoted ${...} in a command string in double
+// quotes and returns the result with the number of substitutions made.
+func quoteBareVars(cmd string) (string, int) {
+	fixes := 0
+	var b strings.Builder
+	last := 0
+
+	for _, loc := range varPattern.FindAllStringIndex(cmd, -1) {
+		b.WriteString(cmd[last:loc[0]])
+		if isQuoted(cmd, loc[0], loc[1]) {
+			b.WriteString(cmd[loc[0]:loc[1]])
+		} else {
+			b.WriteString(`"` + cmd[loc[0]:loc[1]] + `"`)
+			fixes++
+		}
+		last = loc[1]
+	}
+	b.WriteString(cmd[last:])
+
+	return b.String(), fixes
+}
@@ -0,0 +1,244 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+// Severity classifies how serious a lint finding is.
+type Severity string
+
+const (
+	// SeverityWarning marks a smell that is worth fixing but does not block a deploy.
+	SeverityWarning Severity = "warning"
+	// SeverityError marks a problem that is very likely to break a deploy.
+	SeverityError Severity = "error"
+)
+
+// Finding is a single lint result tied to a rule and a location in the config.
+type Finding struct {
+	RuleID   string   `json:"rule_id"`  // Stable rule identifier (e.g., "NYT003")
+	Severity Severity `json:"severity"` // warning or error
+	Location string   `json:"location"` // Where the problem is (e.g., "task 'build'")
+	Message  string   `json:"message"`  // Human-readable explanation
+	Fixable  bool     `json:"fixable"`  // Whether `nyatictl lint --fix` can repair it
+}
+
+// Rule is a single lint check. Rules are table-driven: adding a new one means
+// appending to the rules slice below with its own Check function and tests.
+type Rule struct {
+	ID          string                        // Stable identifier used in findings and docs
+	Severity    Severity                      // Severity attached to this rule's findings
+	Description string                        // One-line summary shown in documentation
+	Check       func(*config.Config) []string // Returns a location+message per finding
+}
+
+// rules is the registry of all lint checks, evaluated in order by Run.
+var rules = []Rule{
+	{
+		ID:          "NYT001",
+		Severity:    SeverityWarning,
+		Description: "task has no message describing what it does",
+		Check:       checkMissingMessage,
+	},
+	{
+		ID:          "NYT002",
+		Severity:    SeverityWarning,
+		Description: "host uses a plaintext password instead of a private key",
+		Check:       checkPlaintextPassword,
+	},
+	{
+		ID:          "NYT003",
+		Severity:    SeverityWarning,
+		Description: "command interpolates ${...} without surrounding double quotes",
+		Check:       checkUnquotedVariables,
+	},
+	{
+		ID:          "NYT004",
+		Severity:    SeverityError,
+		Description: "task name contains spaces",
+		Check:       checkTaskNameSpaces,
+	},
+	{
+		ID:          "NYT005",
+		Severity:    SeverityWarning,
+		Description: "task references a directory created by another task without depends_on",
+		Check:       checkMissingDependsOn,
+	},
+	{
+		ID:          "NYT006",
+		Severity:    SeverityWarning,
+		Description: "lib task that no other task depends on",
+		Check:       checkOrphanLibTasks,
+	},
+	{
+		ID:          "NYT007",
+		Severity:    SeverityWarning,
+		Description: "command matches a dangerous pattern (destructive delete, raw disk write, curl-pipe-sh)",
+		Check:       checkDangerousCommands,
+	},
+	{
+		ID:          "NYT008",
+		Severity:    SeverityWarning,
+		Description: "multi-statement script does not set -e",
+		Check:       checkMissingSetE,
+	},
+}
+
+// fixableRules lists the rule IDs --fix knows how to repair safely.
+var fixableRules = map[string]bool{
+	"NYT003": true,
+}
+
+// Run evaluates every registered rule against the config and returns the
+// combined findings in rule order.
+//
+// Parameters:
+//   - cfg: a loaded (and therefore structurally valid) configuration
+//
+// Returns:
+//   - []Finding: all findings, possibly empty; never nil
+func Run(cfg *config.Config) []Finding {
+	findings := []Finding{}
+	for _, rule := range rules {
+		for _, msg := range rule.Check(cfg) {
+			location, message, _ := strings.Cut(msg, ": ")
+			findings = append(findings, Finding{
+				RuleID:   rule.ID,
+				Severity: rule.Severity,
+				Location: location,
+				Message:  message,
+				Fixable:  fixableRules[rule.ID],
+			})
+		}
+	}
+	return findings
+}
+
+// Rules returns the registered rule table, primarily for help output and docs.
+func Rules() []Rule {
+	return rules
+}
+
+// checkMissingMessage flags tasks without a message, since the message is the
+// only progress line operators see during a deploy.
+func checkMissingMessage(cfg *config.Config) []string {
+	var out []string
+	for _, task := range cfg.Tasks {
+		if strings.TrimSpace(task.Message) == "" {
+			out = append(out, fmt.Sprintf("task '%s': no message set; deploy output will only show the task name", task.Name))
+		}
+	}
+	return out
+}
+
+// checkPlaintextPassword flags hosts that authenticate with a plaintext
+// password committed to the config file.
+func checkPlaintextPassword(cfg *config.Config) []string {
+	var out []string
+	for name, host := range cfg.Hosts {
+		if host.Password != "" {
+			out = append(out, fmt.Sprintf("host '%s': plaintext password in config; prefer private_key or askpass", name))
+		}
+	}
+	return out
+}
+
+// varPattern matches a ${...} interpolation in a command string.
+var varPattern = regexp.MustCompile(`\$\{[^}]+\}`)
+
+// checkUnquotedVariables flags ${...} interpolations that are not wrapped in
+// double quotes, where word splitting can corrupt paths containing spaces.
+func checkUnquotedVariables(cfg *config.Config) []string {
+	var out []string
+	for _, task := range cfg.Tasks {
+		for _, loc := range varPattern.FindAllStringIndex(task.Cmd, -1) {
+			if !isQuoted(task.Cmd, loc[0], loc[1]) {
+				out = append(out, fmt.Sprintf("task '%s': unquoted %s; wrap it in double quotes to survive word splitting", task.Name, task.Cmd[loc[0]:loc[1]]))
+			}
+		}
+	}
+	return out
+}
+
+// isQuoted reports whether cmd[start:end] is immediately surrounded by double
+// quotes.
+func isQuoted(cmd string, start, end int) bool {
+	return start > 0 && cmd[start-1] == '"' && end < len(cmd) && cmd[end] == '"'
+}
+
+// checkTaskNameSpaces flags task names containing spaces, which break --task
+// selection and depends_on references.
+func checkTaskNameSpaces(cfg *config.Config) []string {
+	var out []string
+	for _, task := range cfg.Tasks {
+		if strings.Contains(task.Name, " ") {
+			out = append(out, fmt.Sprintf("task '%s': task names must not contain spaces", task.Name))
+		}
+	}
+	return out
+}
+
+// mkdirPattern extracts the directory argument of a mkdir invocation.
+var mkdirPattern = regexp.MustCompile(`mkdir\s+(?:-p\s+)?([^\s;&|]+)`)
+
+// checkMissingDependsOn applies a path heuristic: when one task creates a
+// directory via mkdir and another task's command or dir references that path
+// without depending on the creator, the deploy order is only correct by luck.
+func checkMissingDependsOn(cfg *config.Config) []string {
+	// Map created directory -> creating task name
+	created := make(map[string]string)
+	for _, task := range cfg.Tasks {
+		for _, m := range mkdirPattern.FindAllStringSubmatch(task.Cmd, -1) {
+			dir := strings.Trim(m[1], `"'`)
+			if dir != "" {
+				created[dir] = task.Name
+			}
+		}
+	}
+
+	var out []string
+	for _, task := range cfg.Tasks {
+		deps := make(map[string]bool, len(task.DependsOn))
+		for _, dep := range task.DependsOn {
+			deps[dep] = true
+		}
+		for dir, creator := range created {
+			if creator == task.Name || deps[creator] {
+				continue
+			}
+			if strings.Contains(task.Dir, dir) || referencesPath(task.Cmd, dir) {
+				out = append(out, fmt.Sprintf("task '%s': uses '%s' created by task '%s' but does not depend on it", task.Name, dir, creator))
+			}
+		}
+	}
+	return out
+}
+
+// referencesPath reports whether cmd uses dir outside of creating it itself.
+func referencesPath(cmd, dir string) bool {
+	stripped := mkdirPattern.ReplaceAllString(cmd, "")
+	return strings.Contains(stripped, dir)
+}
+
+// checkOrphanLibTasks flags lib tasks that nothing depends on; they can never
+// run as part of a normal deploy.
+func checkOrphanLibTasks(cfg *config.Config) []string {
+	dependedOn := make(map[string]bool)
+	for _, task := range cfg.Tasks {
+		for _, dep := range task.DependsOn {
+			dependedOn[dep] = true
+		}
+	}
+
+	var out []string
+	for _, task := range cfg.Tasks {
+		if task.Lib && !dependedOn[task.Name] {
+			out = append(out, fmt.Sprintf("task '%s': marked as lib but nothing depends on it", task.Name))
+		}
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+// findingsFor runs the linter and returns only findings for the given rule.
+func findingsFor(cfg *config.Config, ruleID string) []Finding {
+	var out []Finding
+	for _, f := range Run(cfg) {
+		if f.RuleID == ruleID {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       *config.Config
+		ruleID    string
+		wantCount int
+	}{
+		{
+			name: "missing message",
+			cfg: &config.Config{Tasks: []config.Task{
+				{Name: "build", Cmd: "make build"},
+				{Name: "deploy", Cmd: "make deploy", Message: "Deploying"},
+			}},
+			ruleID:    "NYT001",
+			wantCount: 1,
+		},
+		{
+			name: "plaintext password",
+			cfg: &config.Config{Hosts: map[string]config.Host{
+				"server1": {Host: "10.0.0.1", Username: "deploy", Password: "secret"},
+				"server2": {Host: "10.0.0.2", Username: "deploy", PrivateKey: "~/.ssh/id_rsa"},
+			}},
+			ruleID:    "NYT002",
+			wantCount: 1,
+		},
+		{
+			name: "unquoted variable",
+			cfg: &config.Config{Tasks: []config.Task{
+				{Name: "copy", Cmd: `cp ${source} "${target}"`, Message: "Copying"},
+			}},
+			ruleID:    "NYT003",
+			wantCount: 1,
+		},
+		{
+			name: "task name with spaces",
+			cfg: &config.Config{Tasks: []config.Task{
+				{Name: "build app", Cmd: "make build", Message: "Building"},
+			}},
+			ruleID:    "NYT004",
+			wantCount: 1,
+		},
+		{
+			name: "missing depends_on for created directory",
+			cfg: &config.Config{Tasks: []config.Task{
+				{Name: "prepare", Cmd: "mkdir -p /var/www/releases", Message: "Preparing"},
+				{Name: "upload", Cmd: "cp app.tar.gz /var/www/releases", Message: "Uploading"},
+			}},
+			ruleID:    "NYT005",
+			wantCount: 1,
+		},
+		{
+			name: "declared depends_on suppresses path heuristic",
+			cfg: &config.Config{Tasks: []config.Task{
+				{Name: "prepare", Cmd: "mkdir -p /var/www/releases", Message: "Preparing"},
+				{Name: "upload", Cmd: "cp app.tar.gz /var/www/releases", Message: "Uploading", DependsOn: []string{"prepare"}},
+			}},
+			ruleID:    "NYT005",
+			wantCount: 0,
+		},
+		{
+			name: "orphan lib task",
+			cfg: &config.Config{Tasks: []config.Task{
+				{Name: "helper", Cmd: "true", Message: "Helping", Lib: true},
+				{Name: "used", Cmd: "true", Message: "Used", Lib: true},
+				{Name: "main", Cmd: "true", Message: "Main", DependsOn: []string{"used"}},
+			}},
+			ruleID:    "NYT006",
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findingsFor(tt.cfg, tt.ruleID)
+			if len(got) != tt.wantCount {
+				t.Errorf("Run() returned %d findings for %s, want %d: %v", len(got), tt.ruleID, tt.wantCount, got)
+			}
+		})
+	}
+}
+
+func TestRunCleanConfig(t *testing.T) {
+	cfg := &config.Config{
+		Hosts: map[string]config.Host{
+			"server1": {Host: "10.0.0.1", Username: "deploy", PrivateKey: "~/.ssh/id_rsa"},
+		},
+		Tasks: []config.Task{
+			{Name: "build", Cmd: `make build "${target}"`, Message: "Building"},
+		},
+	}
+	if findings := Run(cfg); len(findings) != 0 {
+		t.Errorf("Run() on clean config returned %v, want none", findings)
+	}
+}
+
+func TestFindingMetadata(t *testing.T) {
+	cfg := &config.Config{Tasks: []config.Task{
+		{Name: "copy", Cmd: "cp ${source} /tmp", Message: "Copying"},
+	}}
+
+	findings := findingsFor(cfg, "NYT003")
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 NYT003 finding, got %d", len(findings))
+	}
+	f := findings[0]
+	if f.Severity != SeverityWarning {
+		t.Errorf("Severity = %s, want %s", f.Severity, SeverityWarning)
+	}
+	if f.Location != "task 'copy'" {
+		t.Errorf("Location = %q, want %q", f.Location, "task 'copy'")
+	}
+	if !f.Fixable {
+		t.Error("NYT003 finding should be marked fixable")
+	}
+}
+
+func TestFixContent(t *testing.T) {
+	content := `version: "0.1.2"
+appname: demo
+tasks:
+  - name: copy
+    cmd: cp ${source} ${target}
+  - name: quoted
+    cmd: echo "${already}"
+  - name: literal
+    cmd: "echo ${inside_yaml_quotes}"
+`
+	fixed, n := FixContent(content)
+	if n != 2 {
+		t.Errorf("FixContent() applied %d fixes, want 2", n)
+	}
+	if !strings.Contains(fixed, `cp "${source}" "${target}"`) {
+		t.Errorf("FixContent() did not quote bare variables:\n%s", fixed)
+	}
+	if !strings.Contains(fixed, `echo "${already}"`) {
+		t.Errorf("FixContent() altered an already-quoted variable:\n%s", fixed)
+	}
+	if !strings.Contains(fixed, `"echo ${inside_yaml_quotes}"`) {
+		t.Errorf("FixContent() must not edit YAML-quoted scalars:\n%s", fixed)
+	}
+}
+
+func TestFixContentNoChanges(t *testing.T) {
+	content := "tasks:\n  - name: build\n    cmd: make build\n"
+	fixed, n := FixContent(content)
+	if n != 0 {
+		t.Errorf("FixContent() applied %d fixes, want 0", n)
+	}
+	if fixed != content {
+		t.Error("FixContent() modified content with nothing to fix")
+	}
+}
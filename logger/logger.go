@@ -55,6 +55,7 @@ var (
 	logFile     *os.File         // File handle for writing logs to disk
 	logFilePath = "nyatictl.log" // Default log file path; override using SetLogFilePath()
 	currentLevel LogLevel = INFO  // Current minimum log level
+	consoleLevel LogLevel = DEBUG // Minimum level echoed to the console; file logs are unaffected
 	structuredLogging bool = false // Whether to use structured JSON logging
 )
 
@@ -75,6 +76,34 @@ func SetLogLevel(level LogLevel) {
 	currentLevel = level
 }
 
+// SetConsoleLevel sets the minimum level echoed to the console by Console().
+// File logs and the stream channel keep capturing everything regardless, so
+// quiet runs still produce a complete log file.
+func SetConsoleLevel(level LogLevel) {
+	logLock.Lock()
+	defer logLock.Unlock()
+	consoleLevel = level
+}
+
+// ConsoleAllowed reports whether a message at the given level should be
+// printed to the console.
+func ConsoleAllowed(level LogLevel) bool {
+	logLock.Lock()
+	defer logLock.Unlock()
+	return level >= consoleLevel
+}
+
+// Console logs a message at the given level and echoes it to stdout unless
+// the console level suppresses it. Informational chatter routed through here
+// disappears from the console in quiet mode while still reaching the log
+// file and the stream channel.
+func Console(level LogLevel, msg string) {
+	LogWithLevel(level, msg, nil)
+	if ConsoleAllowed(level) {
+		fmt.Println(msg)
+	}
+}
+
 // EnableStructuredLogging enables JSON-formatted structured logging
 func EnableStructuredLogging(enabled bool) {
 	logLock.Lock()
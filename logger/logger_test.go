@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -376,4 +377,42 @@ func TestStructuredLogging(t *testing.T) {
 
 	// Reset log level for other tests
 	SetLogLevel(INFO)
-}
\ No newline at end of file
+}
+func TestConsoleOmitsInfoButPrintsErrorsWhenQuiet(t *testing.T) {
+	SetConsoleLevel(WARN)
+	t.Cleanup(func() { SetConsoleLevel(DEBUG) })
+
+	// Capture stdout while Console emits one line per level
+	old := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	os.Stdout = w
+
+	Console(INFO, "connected to server1")
+	Console(WARN, "metrics unavailable")
+	Console(ERROR, "task failed")
+
+	w.Close()
+	os.Stdout = old
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read captured stdout: %v", err)
+	}
+
+	if strings.Contains(string(out), "connected to server1") {
+		t.Error("quiet console printed an info line")
+	}
+	for _, want := range []string{"metrics unavailable", "task failed"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("quiet console missing %q", want)
+		}
+	}
+}
+
+func TestConsoleAllowedDefaultsToEverything(t *testing.T) {
+	if !ConsoleAllowed(DEBUG) || !ConsoleAllowed(INFO) || !ConsoleAllowed(ERROR) {
+		t.Error("default console level should allow all levels")
+	}
+}
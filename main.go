@@ -11,6 +11,8 @@ import (
 	"github.com/zechtz/nyatictl/api"
 	"github.com/zechtz/nyatictl/appconfig"
 	"github.com/zechtz/nyatictl/cli"
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/env"
 	"github.com/zechtz/nyatictl/logger"
 )
 
@@ -65,6 +67,7 @@ func main() {
 	port := flag.String("port", cfg.Port, "Port for the web server (used in web mode)")
 	configsPath := flag.String("configs-path", cfg.ConfigsPath, "Path to the configs.json file")
 	logPath := flag.String("log-path", cfg.LogPath, "Path to the persistent log file")
+	defaultsFile := flag.String("defaults", cfg.DefaultsFile, "Path to a global defaults file merged under every loaded config")
 
 	// Parse all defined flags
 	flag.Parse()
@@ -74,6 +77,7 @@ func main() {
 	cfg.Port = *port
 	cfg.ConfigsPath = *configsPath
 	cfg.LogPath = *logPath
+	cfg.DefaultsFile = *defaultsFile
 
 	// Validate final configuration
 	if err := cfg.Validate(); err != nil {
@@ -106,12 +110,21 @@ func main() {
 	// Set the config path for the web layer (used globally in web package)
 	api.ConfigFilePath = cfg.ConfigsPath
 
+	// Point config loading at the global defaults file, if one is configured
+	config.DefaultsFile = cfg.DefaultsFile
+
+	// Select the env secret encryption algorithm before any secret is written
+	if err := env.SetEncryptionAlgorithm(cfg.EnvAlgorithm); err != nil {
+		log.Printf("Invalid NYATI_ENV_ALGORITHM: %v", err)
+		return
+	}
+
 	// Ensure that the config file exists at the specified path.
 	// If it does not exist, it will be created with an empty JSON array ([]).
 	// This prevents "file not found" errors during web UI interactions.
 	if err := api.EnsureConfigsFile(); err != nil {
 		logger.Error("Failed to create config file", map[string]interface{}{
-			"path": cfg.ConfigsPath,
+			"path":  cfg.ConfigsPath,
 			"error": err.Error(),
 		})
 		return
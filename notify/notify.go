@@ -0,0 +1,126 @@
+// Package notify sends a single deploy-level notification with the overall
+// result of a deployment, independent of webhooks and per-task notify hooks.
+// The delivery channel (SMTP email or a summary webhook) is configured
+// through appconfig.
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zechtz/nyatictl/logger"
+	"github.com/zechtz/nyatictl/tasks"
+)
+
+// Sender delivers a rendered notification. Implementations exist for SMTP
+// email and summary webhooks; tests inject fakes.
+type Sender interface {
+	Send(subject, body string) error
+}
+
+// DeploySummary aggregates the outcome of a whole deployment.
+type DeploySummary struct {
+	ConfigPath string        `json:"configPath"` // Config that was deployed
+	Host       string        `json:"host"`       // Target host (or "all")
+	Succeeded  int           `json:"succeeded"`  // Number of successful task executions
+	Failed     int           `json:"failed"`     // Number of hard task failures
+	Duration   time.Duration `json:"duration"`   // Total wall-clock time
+
+	// AllowedFailures counts failures on tasks marked allow_failure. They are
+	// excluded from Failed so a run with only allowed failures still counts
+	// as a success, just one with warnings.
+	AllowedFailures int `json:"allowedFailures,omitempty"`
+
+	// Note is the sanitized free-text note attached to the deploy request,
+	// empty when the deployer did not leave one.
+	Note string `json:"note,omitempty"`
+
+	// Params are the request-supplied parameter overrides this deployment ran
+	// with, empty when none were given.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Summarize builds a DeploySummary from per-task results.
+//
+// Parameters:
+//   - configPath: config that was deployed
+//   - host: target host (or "all")
+//   - results: per-task, per-host outcomes from tasks.Run
+//   - duration: total wall-clock time of the deploy
+//
+// Returns:
+//   - DeploySummary: the aggregated outcome
+func Summarize(configPath, host string, results []tasks.Result, duration time.Duration) DeploySummary {
+	summary := DeploySummary{
+		ConfigPath: configPath,
+		Host:       host,
+		Duration:   duration,
+	}
+	for _, r := range results {
+		switch {
+		case r.Success:
+			summary.Succeeded++
+		case r.AllowedFailure:
+			summary.AllowedFailures++
+		default:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// Success reports whether every task execution succeeded.
+func (s DeploySummary) Success() bool {
+	return s.Failed == 0
+}
+
+// Subject renders the notification subject line.
+func (s DeploySummary) Subject() string {
+	status := "succeeded"
+	switch {
+	case !s.Success():
+		status = "failed"
+	case s.AllowedFailures > 0:
+		status = "succeeded with warnings"
+	}
+	return fmt.Sprintf("Deploy %s: %s (%s)", status, s.ConfigPath, s.Host)
+}
+
+// Body renders the notification body with success/failure counts, the total
+// duration, and the deploy note when one was attached.
+func (s DeploySummary) Body() string {
+	body := fmt.Sprintf(
+		"Deployment of %s to %s finished.\n\nTasks succeeded: %d\nTasks failed: %d\nDuration: %s\n",
+		s.ConfigPath, s.Host, s.Succeeded, s.Failed, s.Duration,
+	)
+	if s.AllowedFailures > 0 {
+		body += fmt.Sprintf("Allowed failures: %d\n", s.AllowedFailures)
+	}
+	if s.Note != "" {
+		body += fmt.Sprintf("Note: %s\n", s.Note)
+	}
+	return body
+}
+
+// Notifier sends deploy-completion notifications through a configured Sender.
+// A nil Notifier is valid and sends nothing, so callers don't need to guard
+// the unconfigured case.
+type Notifier struct {
+	sender Sender
+}
+
+// New creates a Notifier that delivers through the given sender.
+func New(sender Sender) *Notifier {
+	return &Notifier{sender: sender}
+}
+
+// DeployCompleted sends one notification for a finished deployment.
+// Delivery failures are logged but never propagate to the deploy itself.
+func (n *Notifier) DeployCompleted(summary DeploySummary) {
+	if n == nil || n.sender == nil {
+		return
+	}
+	if err := n.sender.Send(summary.Subject(), summary.Body()); err != nil {
+		logger.Error(fmt.Sprintf("Failed to send deploy notification: %v", err))
+	}
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zechtz/nyatictl/tasks"
+)
+
+// fakeSender records the notifications it receives.
+type fakeSender struct {
+	subjects []string
+	bodies   []string
+	err      error
+}
+
+func (f *fakeSender) Send(subject, body string) error {
+	f.subjects = append(f.subjects, subject)
+	f.bodies = append(f.bodies, body)
+	return f.err
+}
+
+func mixedResults() []tasks.Result {
+	return []tasks.Result{
+		{Task: "build", Host: "server1", Success: true},
+		{Task: "migrate", Host: "server1", Success: true},
+		{Task: "restart", Host: "server1", Success: false},
+	}
+}
+
+func TestSummarizeCountsMixedResults(t *testing.T) {
+	s := Summarize("/deploy/nyati.yaml", "server1", mixedResults(), 90*time.Second)
+
+	if s.Succeeded != 2 || s.Failed != 1 {
+		t.Errorf("Summarize() counts = %d/%d, want 2 succeeded, 1 failed", s.Succeeded, s.Failed)
+	}
+	if s.Success() {
+		t.Error("a deploy with a failed task must not count as successful")
+	}
+}
+
+func TestDeployCompletedMessageContent(t *testing.T) {
+	sender := &fakeSender{}
+	n := New(sender)
+
+	n.DeployCompleted(Summarize("/deploy/nyati.yaml", "server1", mixedResults(), 90*time.Second))
+
+	if len(sender.subjects) != 1 {
+		t.Fatalf("sender received %d notifications, want exactly 1", len(sender.subjects))
+	}
+
+	subject := sender.subjects[0]
+	if !strings.Contains(subject, "failed") || !strings.Contains(subject, "/deploy/nyati.yaml") {
+		t.Errorf("subject = %q, want failure status and config path", subject)
+	}
+
+	body := sender.bodies[0]
+	for _, want := range []string{
+		"Tasks succeeded: 2",
+		"Tasks failed: 1",
+		"Duration: 1m30s",
+		"server1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestDeployCompletedSuccessSubject(t *testing.T) {
+	sender := &fakeSender{}
+	n := New(sender)
+
+	results := []tasks.Result{{Task: "build", Host: "server1", Success: true}}
+	n.DeployCompleted(Summarize("/deploy/nyati.yaml", "all", results, time.Second))
+
+	if len(sender.subjects) != 1 || !strings.Contains(sender.subjects[0], "succeeded") {
+		t.Errorf("subjects = %v, want one success subject", sender.subjects)
+	}
+}
+
+func TestNilNotifierIsSafe(t *testing.T) {
+	var n *Notifier
+	// Must not panic; unconfigured servers carry a nil notifier
+	n.DeployCompleted(Summarize("/deploy/nyati.yaml", "server1", nil, time.Second))
+}
+
+func TestDeployCompletedSenderErrorDoesNotPropagate(t *testing.T) {
+	sender := &fakeSender{err: errors.New("smtp down")}
+	n := New(sender)
+
+	// Delivery failures are logged, never raised
+	n.DeployCompleted(Summarize("/deploy/nyati.yaml", "server1", mixedResults(), time.Second))
+
+	if len(sender.subjects) != 1 {
+		t.Errorf("sender should still have been invoked once, got %d", len(sender.subjects))
+	}
+}
+
+func TestSummarizeAllowedFailuresKeepSuccess(t *testing.T) {
+	results := []tasks.Result{
+		{Task: "build", Host: "server1", Success: true},
+		{Task: "warm_cache", Host: "server1", Success: false, AllowedFailure: true},
+	}
+	s := Summarize("/deploy/nyati.yaml", "server1", results, time.Minute)
+
+	if s.Failed != 0 || s.AllowedFailures != 1 {
+		t.Errorf("counts = %d failed / %d allowed, want 0 failed, 1 allowed", s.Failed, s.AllowedFailures)
+	}
+	if !s.Success() {
+		t.Error("a deploy with only allowed failures should still count as successful")
+	}
+	if !strings.Contains(s.Subject(), "succeeded with warnings") {
+		t.Errorf("subject = %q, want it marked as succeeded with warnings", s.Subject())
+	}
+	if !strings.Contains(s.Body(), "Allowed failures: 1") {
+		t.Errorf("body = %q, want the allowed-failure count listed", s.Body())
+	}
+}
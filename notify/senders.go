@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"time"
+
+	"github.com/zechtz/nyatictl/appconfig"
+)
+
+// SMTPSender delivers notifications as plain-text email via SMTP.
+type SMTPSender struct {
+	Host     string // SMTP server hostname
+	Port     int    // SMTP server port
+	Username string // Optional auth username; auth is skipped when empty
+	Password string // Auth password, used with Username
+	From     string // Sender address
+	To       string // Recipient address
+}
+
+// Send delivers one email with the given subject and body.
+func (s *SMTPSender) Send(subject, body string) error {
+	addr := fmt.Sprintf("%s:%d", s.Host, s.Port)
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s", s.From, s.To, subject, body)
+	if err := smtp.SendMail(addr, auth, s.From, []string{s.To}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email via %s: %v", addr, err)
+	}
+	return nil
+}
+
+// WebhookSender delivers notifications as a JSON POST to a single summary
+// webhook URL.
+type WebhookSender struct {
+	URL    string       // Webhook endpoint
+	Client *http.Client // Optional; a default client with a timeout is used when nil
+}
+
+// Send posts the subject and body as JSON to the configured URL.
+func (w *WebhookSender) Send(subject, body string) error {
+	payload, err := json.Marshal(map[string]string{
+		"subject": subject,
+		"body":    body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %v", err)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Post(w.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to post to %s: %v", w.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", w.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// FromConfig builds a Notifier from the application configuration.
+//
+// Email takes precedence when both channels are configured. Returns nil when
+// no notification channel is configured, which disables notifications.
+func FromConfig(cfg *appconfig.Config) *Notifier {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.NotifyEmail != "" {
+		return New(&SMTPSender{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			To:       cfg.NotifyEmail,
+		})
+	}
+	if cfg.NotifyWebhookURL != "" {
+		return New(&WebhookSender{URL: cfg.NotifyWebhookURL})
+	}
+	return nil
+}
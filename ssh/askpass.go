@@ -0,0 +1,40 @@
+package ssh
+
+import (
+	"io"
+	"strings"
+)
+
+// sudoPassword is the sudo/become password fed to AskPass tasks. It is set
+// once per run from the CLI or API layer; the zero value leaves the historic
+// behavior of only requesting a PTY.
+var sudoPassword string
+
+// SetSudoPassword installs the password AskPass tasks deliver to the remote
+// command's stdin, exactly as if it had been typed at the prompt. The
+// password never becomes part of the command line, and any echo of it in
+// task output is redacted. An empty string clears it.
+func SetSudoPassword(password string) {
+	sudoPassword = password
+}
+
+// askpassStdin returns a reader delivering the configured sudo password,
+// newline-terminated, for an AskPass session's stdin. Nil when no password
+// is configured, so the session keeps its default (empty) stdin.
+func askpassStdin() io.Reader {
+	if sudoPassword == "" {
+		return nil
+	}
+	return strings.NewReader(sudoPassword + "\n")
+}
+
+// redactSudoPassword masks the configured sudo password in task output.
+// Password prompts do not echo, but a careless remote command might print
+// what it read; this keeps the password out of the console and log file
+// either way.
+func redactSudoPassword(output string) string {
+	if sudoPassword == "" {
+		return output
+	}
+	return strings.ReplaceAll(output, sudoPassword, "****")
+}
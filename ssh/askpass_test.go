@@ -0,0 +1,67 @@
+package ssh
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+func TestAskpassStdinDeliversPassword(t *testing.T) {
+	SetSudoPassword("s3cret")
+	defer SetSudoPassword("")
+
+	stdin := askpassStdin()
+	if stdin == nil {
+		t.Fatal("askpassStdin() = nil with a password configured")
+	}
+	delivered, err := io.ReadAll(stdin)
+	if err != nil {
+		t.Fatalf("failed to read askpass stdin: %v", err)
+	}
+	// Newline-terminated, exactly as if typed at the prompt
+	if string(delivered) != "s3cret\n" {
+		t.Errorf("askpass stdin = %q, want the password followed by a newline", delivered)
+	}
+}
+
+func TestAskpassStdinEmptyWithoutPassword(t *testing.T) {
+	SetSudoPassword("")
+	if stdin := askpassStdin(); stdin != nil {
+		t.Error("askpassStdin() should be nil when no password is configured")
+	}
+}
+
+func TestAskpassPasswordNotEmbeddedInCommand(t *testing.T) {
+	SetSudoPassword("s3cret")
+	defer SetSudoPassword("")
+
+	// The command sent over SSH is built from the task alone; the password
+	// travels only on stdin
+	task := config.Task{Name: "restart", Cmd: "sudo systemctl restart app", AskPass: true}
+	client := &Client{Name: "server1"}
+	cmd := client.applyEnvPlaceholders(task.Cmd)
+	cmd = wrapWithDir(cmd, task)
+	if strings.Contains(cmd, "s3cret") {
+		t.Errorf("command %q must not contain the sudo password", cmd)
+	}
+}
+
+func TestRedactSudoPasswordMasksOutput(t *testing.T) {
+	SetSudoPassword("s3cret")
+	defer SetSudoPassword("")
+
+	output := redactSudoPassword("password is: s3cret\ndone")
+	if strings.Contains(output, "s3cret") {
+		t.Errorf("output %q still contains the password", output)
+	}
+	if !strings.Contains(output, "****") {
+		t.Errorf("output %q should carry the redaction marker", output)
+	}
+
+	SetSudoPassword("")
+	if got := redactSudoPassword("untouched"); got != "untouched" {
+		t.Errorf("redaction without a password changed output to %q", got)
+	}
+}
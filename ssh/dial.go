@@ -0,0 +1,81 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// dnsTimeout bounds the resolution step on its own, so an unresponsive
+// resolver is reported as a DNS failure instead of silently eating the whole
+// dial timeout.
+const dnsTimeout = 5 * time.Second
+
+// dialSSH resolves and dials addr, classifying failures so the user can tell
+// a hostname that does not resolve from a firewall dropping packets or a
+// closed port. Resolution runs first with its own short timeout; the
+// resolved addresses are then dialed in order until one succeeds. Every
+// error names the config host alias, so failures in multi-host deploys stay
+// attributable.
+//
+// Parameters:
+//   - ctx: context for cancellation
+//   - name: the host alias from the config, included in every error
+//   - addr: the host:port to connect to
+//   - timeout: the per-dial timeout
+//
+// Returns:
+//   - net.Conn: the established TCP connection
+//   - error: an ErrDNSLookup or a classified dial failure
+func dialSSH(ctx context.Context, name, addr string, timeout time.Duration) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("host %s: invalid address %s: %v", name, addr, err)
+	}
+
+	// Literal IPs skip resolution entirely
+	dialAddrs := []string{addr}
+	if net.ParseIP(host) == nil {
+		lookupCtx, cancel := context.WithTimeout(ctx, dnsTimeout)
+		ips, lookupErr := net.DefaultResolver.LookupHost(lookupCtx, host)
+		cancel()
+		if lookupErr != nil {
+			return nil, ErrDNSLookup{Host: name, Hostname: host, Cause: lookupErr}
+		}
+		dialAddrs = dialAddrs[:0]
+		for _, ip := range ips {
+			dialAddrs = append(dialAddrs, net.JoinHostPort(ip, port))
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var lastErr error
+	for _, dialAddr := range dialAddrs {
+		transportLog(fmt.Sprintf("%s: dialing %s", name, dialAddr))
+		conn, dialErr := dialer.DialContext(ctx, "tcp", dialAddr)
+		if dialErr == nil {
+			return conn, nil
+		}
+		lastErr = classifyDialError(name, dialAddr, dialErr, timeout)
+	}
+	return nil, lastErr
+}
+
+// classifyDialError turns a raw dial error into one that says what actually
+// happened: an active refusal (the port is closed), a silent drop (timeout),
+// or something else. The resolved address appears in the message so the user
+// sees which IP was tried, not just the hostname.
+func classifyDialError(name, addr string, err error, timeout time.Duration) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return fmt.Errorf("host %s: connection to %s refused", name, addr)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("host %s: connection to %s timed out after %s", name, addr, timeout)
+	default:
+		return fmt.Errorf("host %s: failed to dial %s: %v", name, addr, err)
+	}
+}
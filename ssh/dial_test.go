@@ -0,0 +1,99 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDialSSHReportsDNSFailure(t *testing.T) {
+	_, err := dialSSH(context.Background(), "server1", "definitely-not-a-real-host.invalid:22", time.Second)
+	if err == nil {
+		t.Fatal("dialSSH succeeded against an unresolvable hostname")
+	}
+
+	var dnsErr ErrDNSLookup
+	if !errors.As(err, &dnsErr) {
+		t.Fatalf("err = %v (%T), want ErrDNSLookup", err, err)
+	}
+	if dnsErr.Host != "server1" || dnsErr.Hostname != "definitely-not-a-real-host.invalid" {
+		t.Errorf("ErrDNSLookup = %+v, want alias and hostname recorded", dnsErr)
+	}
+	if !strings.Contains(err.Error(), "DNS lookup failed") || !strings.Contains(err.Error(), "server1") {
+		t.Errorf("error %q should name the failure kind and the host alias", err)
+	}
+}
+
+func TestDialSSHReportsConnectionRefused(t *testing.T) {
+	// Reserve a port, then close the listener so the dial is refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, err = dialSSH(context.Background(), "server1", addr, time.Second)
+	if err == nil {
+		t.Fatal("dialSSH succeeded against a closed port")
+	}
+	if !strings.Contains(err.Error(), "refused") || !strings.Contains(err.Error(), "server1") {
+		t.Errorf("error %q should report the refusal and the host alias", err)
+	}
+
+	var dnsErr ErrDNSLookup
+	if errors.As(err, &dnsErr) {
+		t.Error("a refused connection must not classify as a DNS failure")
+	}
+}
+
+func TestDialSSHConnectsToLiteralIP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go listener.Accept()
+
+	conn, err := dialSSH(context.Background(), "server1", listener.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("dialSSH failed: %v", err)
+	}
+	conn.Close()
+}
+
+// timeoutError fakes a net.Error timeout for classification tests.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyDialError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, "connection to 1.2.3.4:22 refused"},
+		{"timeout", timeoutError{}, "connection to 1.2.3.4:22 timed out after 10s"},
+		{"other", fmt.Errorf("network is unreachable"), "failed to dial 1.2.3.4:22"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := classifyDialError("server1", "1.2.3.4:22", c.err, 10*time.Second)
+			if !strings.Contains(got.Error(), c.want) {
+				t.Errorf("classified error = %q, want it to contain %q", got, c.want)
+			}
+			if !strings.Contains(got.Error(), "host server1") {
+				t.Errorf("classified error = %q, want the host alias included", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAuthConfig is returned when a host has neither a password nor a private
+// key configured. It is wrapped with the host name, so match it with
+// errors.Is rather than comparing messages.
+var ErrAuthConfig = errors.New("password or private_key required")
+
+// ErrHostNotFound reports a deploy target that does not exist in the config.
+type ErrHostNotFound struct {
+	Host string // The host alias that was requested
+}
+
+// Error implements the error interface with the historical message format.
+func (e ErrHostNotFound) Error() string {
+	return fmt.Sprintf("host %s not found", e.Host)
+}
+
+// ErrConnectFailed reports a failure to establish an SSH connection to a
+// host. The underlying cause is available via errors.Unwrap.
+type ErrConnectFailed struct {
+	Host  string // The host alias that could not be reached
+	Cause error  // The underlying dial/handshake error
+}
+
+// Error implements the error interface with the historical message format.
+func (e ErrConnectFailed) Error() string {
+	return fmt.Sprintf("failed to connect to %s: %v", e.Host, e.Cause)
+}
+
+// Unwrap exposes the underlying cause for errors.Is/As chains.
+func (e ErrConnectFailed) Unwrap() error {
+	return e.Cause
+}
+
+// ErrDNSLookup reports a hostname that failed to resolve before any dial was
+// attempted. Resolution failures are effectively permanent for the run —
+// retrying cannot fix an NXDOMAIN — so automatic deploy retries skip them.
+type ErrDNSLookup struct {
+	Host     string // The host alias from the config
+	Hostname string // The hostname that failed to resolve
+	Cause    error  // The underlying resolver error
+}
+
+// Error implements the error interface, naming both the alias and the
+// hostname so multi-host failures stay attributable.
+func (e ErrDNSLookup) Error() string {
+	return fmt.Sprintf("host %s: DNS lookup failed for %s: %v", e.Host, e.Hostname, e.Cause)
+}
+
+// Unwrap exposes the underlying cause for errors.Is/As chains.
+func (e ErrDNSLookup) Unwrap() error {
+	return e.Cause
+}
+
+// ErrTaskInterrupted reports a task whose SSH connection dropped mid-run
+// before the remote command reported an exit status. The command may have
+// partially executed on the host; only tasks marked idempotent are re-run
+// automatically.
+type ErrTaskInterrupted struct {
+	Task  string // Name of the interrupted task
+	Host  string // Host alias the task was running on
+	Cause error  // The underlying transport error
+}
+
+// Error implements the error interface with a message that makes the
+// partial-execution risk explicit.
+func (e ErrTaskInterrupted) Error() string {
+	return fmt.Sprintf("task %s on %s was interrupted by a connection failure and may have partially run: %v (mark the task 'idempotent: true' to allow automatic re-runs)", e.Task, e.Host, e.Cause)
+}
+
+// Unwrap exposes the underlying cause for errors.Is/As chains.
+func (e ErrTaskInterrupted) Unwrap() error {
+	return e.Cause
+}
@@ -0,0 +1,59 @@
+package ssh
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+func TestErrHostNotFoundFromOpen(t *testing.T) {
+	cfg := &config.Config{
+		Hosts: map[string]config.Host{
+			"server1": {Host: "10.0.0.1", Username: "deploy", Password: "x"},
+		},
+	}
+	manager, err := NewManager(cfg, []string{"deploy", "missing"}, false)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+
+	err = manager.Open()
+	var notFound ErrHostNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("Open() error = %v, want ErrHostNotFound", err)
+	}
+	if notFound.Host != "missing" {
+		t.Errorf("ErrHostNotFound.Host = %q, want %q", notFound.Host, "missing")
+	}
+	if err.Error() != "host missing not found" {
+		t.Errorf("Error() = %q, message format changed", err.Error())
+	}
+}
+
+func TestErrAuthConfigIsMatchable(t *testing.T) {
+	_, err := NewClient("server1", config.Host{Host: "10.0.0.1", Username: "deploy"}, false)
+	if !errors.Is(err, ErrAuthConfig) {
+		t.Fatalf("NewClient() error = %v, want errors.Is(err, ErrAuthConfig)", err)
+	}
+	if err.Error() != "host server1: password or private_key required" {
+		t.Errorf("Error() = %q, message format changed", err.Error())
+	}
+}
+
+func TestErrConnectFailedUnwrap(t *testing.T) {
+	cause := errors.New("dial tcp: connection refused")
+	err := ErrConnectFailed{Host: "server1", Cause: cause}
+
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is should match the wrapped cause")
+	}
+	var connectFailed ErrConnectFailed
+	if !errors.As(error(err), &connectFailed) || connectFailed.Host != "server1" {
+		t.Errorf("errors.As failed or lost the host: %+v", connectFailed)
+	}
+	want := "failed to connect to server1: dial tcp: connection refused"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
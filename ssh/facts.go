@@ -0,0 +1,78 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// factsCommand gathers host facts in a single round trip. Each line of output
+// is a key=value pair parsed by parseFacts; commands that fail on a given
+// platform simply produce an empty value instead of failing the whole run.
+const factsCommand = `echo "os=$(uname -s 2>/dev/null)"; ` +
+	`echo "arch=$(uname -m 2>/dev/null)"; ` +
+	`echo "kernel=$(uname -r 2>/dev/null)"; ` +
+	`echo "hostname=$(hostname 2>/dev/null)"; ` +
+	`echo "cpus=$(nproc 2>/dev/null)"; ` +
+	`echo "disk_free_kb=$(df -Pk / 2>/dev/null | awk 'NR==2 {print $4}')"`
+
+// GatherFacts collects basic facts about the remote host (OS, architecture,
+// kernel, hostname, CPU count, free disk) and stores them on the client so
+// later commands can reference them as ${fact.*} placeholders.
+//
+// Returns:
+//   - map[string]string: the gathered facts, also retained on the client
+//   - error: if the fact-gathering command could not be executed
+func (c *Client) GatherFacts() (map[string]string, error) {
+	task := config.Task{
+		Name: "gather_facts",
+		Cmd:  factsCommand,
+	}
+
+	code, output, err := c.Exec(task, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather facts from %s: %v", c.Name, err)
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("failed to gather facts from %s: exit code %d", c.Name, code)
+	}
+
+	c.facts = parseFacts(output)
+	logger.Log(fmt.Sprintf("Gathered %d fact(s) from %s", len(c.facts), c.Name))
+	return c.facts, nil
+}
+
+// Facts returns the facts gathered from the host, or nil when GatherFacts has
+// not run.
+func (c *Client) Facts() map[string]string {
+	return c.facts
+}
+
+// parseFacts parses key=value lines (as produced by factsCommand) into a fact
+// map. Empty values and malformed lines are dropped.
+func parseFacts(output string) map[string]string {
+	facts := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || key == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		facts[key] = value
+	}
+	return facts
+}
+
+// applyFactPlaceholders substitutes ${fact.KEY} placeholders in a command
+// with the facts gathered from this host.
+func (c *Client) applyFactPlaceholders(cmd string) string {
+	for k, v := range c.facts {
+		cmd = strings.ReplaceAll(cmd, fmt.Sprintf("${fact.%s}", k), v)
+	}
+	return cmd
+}
@@ -0,0 +1,91 @@
+package ssh
+
+import (
+	"testing"
+)
+
+func TestParseFacts(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[string]string
+	}{
+		{
+			name:   "typical linux output",
+			output: "os=Linux\narch=x86_64\nkernel=6.1.0-18-amd64\nhostname=web01\ncpus=8\ndisk_free_kb=52428800\n",
+			want: map[string]string{
+				"os":           "Linux",
+				"arch":         "x86_64",
+				"kernel":       "6.1.0-18-amd64",
+				"hostname":     "web01",
+				"cpus":         "8",
+				"disk_free_kb": "52428800",
+			},
+		},
+		{
+			name:   "missing commands produce empty values that are dropped",
+			output: "os=Darwin\narch=arm64\ncpus=\ndisk_free_kb=\n",
+			want: map[string]string{
+				"os":   "Darwin",
+				"arch": "arm64",
+			},
+		},
+		{
+			name:   "malformed lines are skipped",
+			output: "os=Linux\nnot a fact line\n=orphan\n\n",
+			want: map[string]string{
+				"os": "Linux",
+			},
+		},
+		{
+			name:   "values containing equals signs survive",
+			output: "os=Linux\nflags=a=b\n",
+			want: map[string]string{
+				"os":    "Linux",
+				"flags": "a=b",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseFacts(tt.output)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseFacts() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("parseFacts()[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyFactPlaceholders(t *testing.T) {
+	client := &Client{facts: map[string]string{"os": "Linux", "arch": "x86_64"}}
+
+	got := client.applyFactPlaceholders("echo ${fact.os}/${fact.arch} ${fact.unknown}")
+	want := "echo Linux/x86_64 ${fact.unknown}"
+	if got != want {
+		t.Errorf("applyFactPlaceholders() = %q, want %q", got, want)
+	}
+
+	// A client without facts leaves commands untouched
+	bare := &Client{}
+	if got := bare.applyFactPlaceholders("uptime"); got != "uptime" {
+		t.Errorf("applyFactPlaceholders() without facts = %q, want unchanged", got)
+	}
+}
+
+func TestFactsAccessor(t *testing.T) {
+	client := &Client{}
+	if client.Facts() != nil {
+		t.Error("Facts() should be nil before GatherFacts runs")
+	}
+
+	client.facts = map[string]string{"os": "Linux"}
+	if client.Facts()["os"] != "Linux" {
+		t.Errorf("Facts() = %v, want gathered facts", client.Facts())
+	}
+}
@@ -279,9 +279,13 @@ func (pc *PooledConnection) ExecWithContext(ctx context.Context, task config.Tas
 	session.Stdout = &stdout
 	session.Stderr = &stderr
 
-	// Enable pseudo-terminal if AskPass is set
+	// Enable pseudo-terminal if AskPass is set, and deliver the configured
+	// sudo password over stdin so it never appears on the command line
 	if task.AskPass {
 		session.RequestPty("xterm", 80, 24, ssh.TerminalModes{})
+		if stdin := askpassStdin(); stdin != nil {
+			session.Stdin = stdin
+		}
 	}
 
 	// Prepend directory change if specified
@@ -312,7 +316,7 @@ func (pc *PooledConnection) ExecWithContext(ctx context.Context, task config.Tas
 	// Wait for either command completion or context cancellation
 	select {
 	case res := <-resultChan:
-		output := stdout.String() + stderr.String()
+		output := redactSudoPassword(stdout.String() + stderr.String())
 
 		if res.err != nil {
 			// Gracefully handle remote command exit codes
@@ -0,0 +1,34 @@
+package ssh
+
+import "strings"
+
+// SetConfigPrelude installs the config-level prelude script. Together with
+// the host's own prelude it is prepended to every task command, except tasks
+// marked raw. Preludes are applied at execution time, so task summaries and
+// stored commands stay free of the boilerplate.
+func (c *Client) SetConfigPrelude(script string) {
+	c.configPrelude = script
+}
+
+// preludeScript combines the config-level and host-level preludes, config
+// first, with surrounding whitespace trimmed from each. Empty when neither
+// is configured.
+func (c *Client) preludeScript() string {
+	var parts []string
+	for _, script := range []string{c.configPrelude, c.Server.Prelude} {
+		if s := strings.TrimSpace(script); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// applyPrelude prepends the prelude to cmd inside a brace group, so the
+// env-exports chain and the cd guard compose with the possibly multi-line
+// result as a single compound command.
+func applyPrelude(cmd, prelude string) string {
+	if prelude == "" {
+		return cmd
+	}
+	return "{\n" + prelude + "\n" + cmd + "\n}"
+}
@@ -0,0 +1,64 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+func TestPreludeScriptOrdersConfigBeforeHost(t *testing.T) {
+	client := &Client{Server: config.Host{Prelude: "export FROM_HOST=1\n"}}
+	client.SetConfigPrelude("\nexport FROM_CONFIG=1")
+
+	got := client.preludeScript()
+	want := "export FROM_CONFIG=1\nexport FROM_HOST=1"
+	if got != want {
+		t.Errorf("preludeScript() = %q, want config before host with whitespace trimmed:\n%q", got, want)
+	}
+
+	if got := (&Client{}).preludeScript(); got != "" {
+		t.Errorf("preludeScript() without preludes = %q, want empty", got)
+	}
+}
+
+func TestApplyPreludeGroupsMultilineCommand(t *testing.T) {
+	got := applyPrelude("npm run build", "source /etc/profile.d/node.sh\nexport PATH=$PATH:/opt/node/bin")
+	if !strings.HasPrefix(got, "{\n") || !strings.HasSuffix(got, "\n}") {
+		t.Errorf("applyPrelude() = %q, want the result confined to a brace group", got)
+	}
+
+	// The group must compose as one compound command behind an && chain and
+	// after a cd guard, even with the multi-line prelude inside
+	if chained := "export A=1 && " + got; !strings.Contains(chained, "&& {\n") {
+		t.Errorf("chained command %q does not keep the group intact", chained)
+	}
+
+	if got := applyPrelude("npm run build", ""); got != "npm run build" {
+		t.Errorf("command without a prelude was rewritten to %q", got)
+	}
+}
+
+func TestShellModeAppliesPrelude(t *testing.T) {
+	client := shellClient(t)
+	client.SetConfigPrelude("export FROM_CONFIG=cfg")
+	client.Server.Prelude = "export FROM_HOST=host"
+
+	// A raw task runs before any prelude has executed, so neither variable
+	// may be visible to it
+	code, output, err := client.Exec(config.Task{Name: "raw", Cmd: `echo "${FROM_CONFIG:-unset}"`, Raw: true}, false)
+	if err != nil || code != 0 {
+		t.Fatalf("raw task = (%d, %v), want clean success", code, err)
+	}
+	if !strings.Contains(output, "unset") {
+		t.Errorf("raw task output = %q, want the prelude skipped", output)
+	}
+
+	code, output, err = client.Exec(config.Task{Name: "use", Cmd: `echo "$FROM_CONFIG $FROM_HOST"`}, false)
+	if err != nil || code != 0 {
+		t.Fatalf("task = (%d, %v), want clean success", code, err)
+	}
+	if !strings.Contains(output, "cfg host") {
+		t.Errorf("output = %q, want both preludes applied", output)
+	}
+}
@@ -0,0 +1,79 @@
+package ssh
+
+import (
+	"net"
+	"time"
+
+	"github.com/zechtz/nyatictl/cache"
+	"github.com/zechtz/nyatictl/config"
+)
+
+// reachabilityTTL is how long a reachability verdict stays fresh. Back-to-back
+// checks across a fleet reuse recent results instead of re-dialing every host.
+const reachabilityTTL = 30 * time.Second
+
+// reachabilityDialTimeout bounds a single reachability probe.
+const reachabilityDialTimeout = 5 * time.Second
+
+// reachabilityCache holds one Reachability per host address.
+var reachabilityCache = cache.NewCache(reachabilityTTL)
+
+// reachabilityDial performs the TCP probe. It is a variable so tests can
+// substitute a dialer and count how often hosts are actually contacted.
+var reachabilityDial = func(addr string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+// Reachability is the outcome of a host reachability probe.
+type Reachability struct {
+	Host      string    // Host address that was probed
+	Reachable bool      // Whether the SSH port accepted a TCP connection
+	Error     string    // Failure reason, when unreachable
+	Cached    bool      // Whether this result came from the cache
+	CheckedAt time.Time // When the probe actually ran
+}
+
+// CheckHost probes whether a host's SSH port is reachable. Results are cached
+// per host address for a short TTL so repeated preflights do not re-dial the
+// whole fleet; pass force to bypass the cache and probe again.
+//
+// Parameters:
+//   - server: host configuration from nyati.yaml
+//   - force: skip the cache and dial the host regardless
+//
+// Returns:
+//   - Reachability: the probe outcome, with Cached set when reused
+func CheckHost(server config.Host, force bool) Reachability {
+	if !force {
+		if cached, ok := reachabilityCache.Get(server.Host); ok {
+			result := cached.(Reachability)
+			result.Cached = true
+			return result
+		}
+	}
+
+	result := Reachability{Host: server.Host, CheckedAt: time.Now()}
+	if err := reachabilityDial(server.Host+":22", reachabilityDialTimeout); err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Reachable = true
+	}
+	reachabilityCache.Set(server.Host, result)
+	return result
+}
+
+// recordReachability refreshes the cache with the outcome of a real connection
+// attempt, so a deploy that just succeeded or failed overrides any stale
+// preflight verdict.
+func recordReachability(host string, err error) {
+	result := Reachability{Host: host, Reachable: err == nil, CheckedAt: time.Now()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	reachabilityCache.Set(host, result)
+}
@@ -0,0 +1,77 @@
+package ssh
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+// countingDial replaces the reachability prober with one that records how
+// often it runs, restoring the original when the test finishes.
+func countingDial(t *testing.T, err error) *int {
+	t.Helper()
+
+	calls := 0
+	original := reachabilityDial
+	reachabilityDial = func(addr string, timeout time.Duration) error {
+		calls++
+		return err
+	}
+	t.Cleanup(func() {
+		reachabilityDial = original
+		reachabilityCache.Clear()
+	})
+	return &calls
+}
+
+func TestCheckHostReusesCachedResult(t *testing.T) {
+	calls := countingDial(t, nil)
+	server := config.Host{Host: "cached.example.com"}
+
+	first := CheckHost(server, false)
+	if !first.Reachable || first.Cached {
+		t.Errorf("first check = %+v, want fresh reachable result", first)
+	}
+
+	second := CheckHost(server, false)
+	if !second.Cached || !second.Reachable {
+		t.Errorf("second check = %+v, want cached reachable result", second)
+	}
+	if *calls != 1 {
+		t.Errorf("host dialed %d times, want 1", *calls)
+	}
+}
+
+func TestCheckHostForceBypassesCache(t *testing.T) {
+	calls := countingDial(t, fmt.Errorf("connection refused"))
+	server := config.Host{Host: "forced.example.com"}
+
+	CheckHost(server, false)
+	result := CheckHost(server, true)
+
+	if result.Cached || result.Reachable {
+		t.Errorf("forced check = %+v, want fresh unreachable result", result)
+	}
+	if *calls != 2 {
+		t.Errorf("host dialed %d times, want 2", *calls)
+	}
+}
+
+func TestRecordReachabilityRefreshesCache(t *testing.T) {
+	calls := countingDial(t, nil)
+	server := config.Host{Host: "deployed.example.com"}
+
+	CheckHost(server, false)
+	// A real connection attempt during a deploy overrides the cached verdict
+	recordReachability(server.Host, fmt.Errorf("dial tcp: connection refused"))
+
+	result := CheckHost(server, false)
+	if !result.Cached || result.Reachable {
+		t.Errorf("check after failed deploy = %+v, want cached unreachable result", result)
+	}
+	if *calls != 1 {
+		t.Errorf("host dialed %d times, want 1", *calls)
+	}
+}
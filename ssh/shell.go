@@ -0,0 +1,179 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+	"golang.org/x/crypto/ssh"
+)
+
+// shellSession keeps a single remote /bin/sh alive across tasks, so exported
+// variables and the working directory from one task remain visible to the
+// next. Commands are written to the shell's stdin and their output read back
+// until a per-command sentinel line carrying the exit code appears.
+type shellSession struct {
+	session *ssh.Session
+	stdin   io.WriteCloser
+	lines   chan string // Output lines pumped from the shell; closed when it exits
+	seq     int         // Per-command counter making each sentinel unique
+}
+
+// StartShell opens the persistent shell session on an already-connected
+// client, switching it into session-shell mode: ExecWithContext runs every
+// subsequent task through this one shell instead of a fresh session each.
+//
+// Returns:
+//   - error: if the client is not connected or the shell cannot be started
+func (c *Client) StartShell() error {
+	if c.client == nil {
+		return fmt.Errorf("SSH client not connected")
+	}
+
+	session, err := c.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to open shell session: %v", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return fmt.Errorf("failed to open shell stdin: %v", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return fmt.Errorf("failed to open shell stdout: %v", err)
+	}
+	// /bin/sh rather than the login shell keeps output parsing predictable
+	if err := session.Start("/bin/sh"); err != nil {
+		session.Close()
+		return fmt.Errorf("failed to start remote shell: %v", err)
+	}
+
+	sh := &shellSession{
+		session: session,
+		stdin:   stdin,
+		lines:   make(chan string, 64),
+	}
+	// Fold stderr into stdout inside the shell so one ordered stream covers
+	// both, mirroring the combined output normal exec mode returns
+	if _, err := io.WriteString(stdin, "exec 2>&1\n"); err != nil {
+		session.Close()
+		return fmt.Errorf("failed to initialize remote shell: %v", err)
+	}
+	go sh.pump(stdout)
+
+	c.shell = sh
+	transportLog(fmt.Sprintf("%s: persistent shell started", c.Name))
+	return nil
+}
+
+// CloseShell tears down the persistent shell, returning the client to
+// one-session-per-task mode. Safe to call when no shell is active.
+func (c *Client) CloseShell() {
+	if c.shell == nil {
+		return
+	}
+	c.shell.stdin.Close()
+	c.shell.session.Close()
+	c.shell = nil
+	transportLog(fmt.Sprintf("%s: persistent shell closed", c.Name))
+}
+
+// pump reads the shell's combined output line by line into the lines channel
+// and closes it when the shell exits.
+func (sh *shellSession) pump(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		sh.lines <- scanner.Text()
+	}
+	close(sh.lines)
+}
+
+// execShell runs one task through the persistent shell. The command is
+// followed by a printf of a unique sentinel plus $?, and output is collected
+// until that sentinel line arrives. Unlike one-shot sessions there is no
+// transport retry here: if the shell dies, its accumulated state is gone and
+// silently re-running against a fresh shell would change semantics.
+func (c *Client) execShell(ctx context.Context, task config.Task, debug bool) (int, string, error) {
+	cmd := c.applyEnvPlaceholders(task.Cmd)
+	cmd = c.applyFactPlaceholders(cmd)
+	if !task.Raw {
+		cmd = applyPrelude(cmd, c.preludeScript())
+	}
+	if exports := c.envExports(); exports != "" {
+		cmd = exports + " && " + cmd
+	}
+	cmd = wrapWithDirShell(cmd, task)
+
+	if debug {
+		logger.Console(logger.INFO, fmt.Sprintf("🎲 %s@%s (shell): %s", c.Name, c.Server.Host, c.redactSecrets(cmd)))
+	}
+
+	sh := c.shell
+	sh.seq++
+	sentinel := fmt.Sprintf("__nyati_rc_%d__", sh.seq)
+	if _, err := fmt.Fprintf(sh.stdin, "%s\nprintf '\\n%s %%d\\n' \"$?\"\n", cmd, sentinel); err != nil {
+		return -1, "", fmt.Errorf("task %s on %s: persistent shell is gone: %v", task.Name, c.Name, err)
+	}
+
+	var collected []string
+	for {
+		select {
+		case line, ok := <-sh.lines:
+			if !ok {
+				return -1, joinOutput(collected), fmt.Errorf("task %s on %s: persistent shell exited before the task finished", task.Name, c.Name)
+			}
+			if rest, found := strings.CutPrefix(line, sentinel+" "); found {
+				code, err := strconv.Atoi(strings.TrimSpace(rest))
+				if err != nil {
+					return -1, joinOutput(collected), fmt.Errorf("task %s on %s: malformed exit marker %q", task.Name, c.Name, line)
+				}
+				// Drop the blank line the sentinel printf injects when the
+				// command's own output already ended with a newline
+				if n := len(collected); n > 0 && collected[n-1] == "" {
+					collected = collected[:n-1]
+				}
+				output := joinOutput(collected)
+				if code == dirMissingExitCode && task.Dir != "" {
+					return code, output, dirMissingError(task, c.Name)
+				}
+				return code, output, nil
+			}
+			collected = append(collected, line)
+
+		case <-ctx.Done():
+			return -1, joinOutput(collected), fmt.Errorf("command execution cancelled: %v", ctx.Err())
+		}
+	}
+}
+
+// joinOutput reassembles collected lines into the newline-terminated form the
+// one-shot exec path produces.
+func joinOutput(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// wrapWithDirShell is the session-shell variant of wrapWithDir. The guard
+// runs in a subshell so a failed cd (or the reserved exit code) cannot kill
+// the persistent shell; the trade-off is that an explicit dir does not change
+// the shared working directory, while a cd inside the task's own cmd does.
+func wrapWithDirShell(cmd string, task config.Task) string {
+	if task.Dir == "" {
+		return cmd
+	}
+	guard := fmt.Sprintf("cd %s || exit %d", task.Dir, dirMissingExitCode)
+	if task.EnsureDir {
+		guard = fmt.Sprintf("mkdir -p %s && %s", task.Dir, guard)
+	}
+	return "( " + guard + "; " + cmd + " )"
+}
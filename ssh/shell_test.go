@@ -0,0 +1,198 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// startShellServer runs an in-process SSH server whose sessions answer a
+// "shell" request by wiring the channel to a real local /bin/sh, so the
+// persistent-shell protocol is exercised against genuine shell semantics.
+func startShellServer(t *testing.T) string {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == "testpass" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password")
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					if newChannel.ChannelType() != "session" {
+						newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					ch, chReqs, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go func(ch ssh.Channel, chReqs <-chan *ssh.Request) {
+						for req := range chReqs {
+							if req.Type != "shell" && req.Type != "exec" {
+								req.Reply(req.Type == "pty-req" || req.Type == "env", nil)
+								continue
+							}
+							req.Reply(true, nil)
+							cmd := exec.Command("/bin/sh")
+							cmd.Stdout = ch
+							cmd.Stderr = ch.Stderr()
+							// Feed stdin through an explicit pipe: Wait must
+							// not block on the channel staying open
+							stdin, err := cmd.StdinPipe()
+							if err != nil {
+								ch.Close()
+								return
+							}
+							go func() {
+								io.Copy(stdin, ch)
+								stdin.Close()
+							}()
+							if err := cmd.Start(); err != nil {
+								ch.Close()
+								return
+							}
+							go func() {
+								cmd.Wait()
+								ch.SendRequest("exit-status", false, ssh.Marshal(&struct{ Status uint32 }{0}))
+								ch.Close()
+							}()
+						}
+					}(ch, chReqs)
+				}
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// shellClient connects a client to the shell server and switches it into
+// session-shell mode.
+func shellClient(t *testing.T) *Client {
+	t.Helper()
+
+	client := dropServerClient(t, startShellServer(t))
+	if err := client.StartShell(); err != nil {
+		t.Fatalf("failed to start persistent shell: %v", err)
+	}
+	return client
+}
+
+func TestShellModePreservesStateAcrossTasks(t *testing.T) {
+	client := shellClient(t)
+
+	code, _, err := client.Exec(config.Task{Name: "setup", Cmd: "export RELEASE=v42 && cd /tmp"}, false)
+	if err != nil || code != 0 {
+		t.Fatalf("setup task = (%d, %v), want clean success", code, err)
+	}
+
+	// The export and the cd from the first task must both survive into the
+	// second
+	code, output, err := client.Exec(config.Task{Name: "use", Cmd: `echo "$RELEASE $(pwd)"`}, false)
+	if err != nil || code != 0 {
+		t.Fatalf("use task = (%d, %v), want clean success", code, err)
+	}
+	if !strings.Contains(output, "v42 /tmp") {
+		t.Errorf("output = %q, want the exported variable and working directory preserved", output)
+	}
+}
+
+func TestShellModeDetectsExitCodes(t *testing.T) {
+	client := shellClient(t)
+
+	// A subshell exit keeps the persistent shell alive while still
+	// surfacing the code; a bare `exit` would terminate the shell itself
+	code, _, err := client.Exec(config.Task{Name: "fail", Cmd: "(exit 3)"}, false)
+	if err != nil {
+		t.Fatalf("failing task returned error: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("exit code = %d, want 3", code)
+	}
+
+	// A failure must not poison the shell for the next task
+	code, output, err := client.Exec(config.Task{Name: "next", Cmd: "echo still-alive"}, false)
+	if err != nil || code != 0 {
+		t.Fatalf("follow-up task = (%d, %v), want clean success", code, err)
+	}
+	if !strings.Contains(output, "still-alive") {
+		t.Errorf("output = %q, want the follow-up command's output", output)
+	}
+}
+
+func TestShellModeCombinesStderr(t *testing.T) {
+	client := shellClient(t)
+
+	code, output, err := client.Exec(config.Task{Name: "warn", Cmd: "echo oops >&2"}, false)
+	if err != nil || code != 0 {
+		t.Fatalf("task = (%d, %v), want clean success", code, err)
+	}
+	if !strings.Contains(output, "oops") {
+		t.Errorf("output = %q, want stderr folded into the captured output", output)
+	}
+}
+
+func TestShellModeDirGuardDoesNotKillShell(t *testing.T) {
+	client := shellClient(t)
+
+	_, _, err := client.Exec(config.Task{Name: "bad-dir", Cmd: "echo never", Dir: "/does/not/exist"}, false)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("err = %v, want the missing directory named", err)
+	}
+
+	code, _, err := client.Exec(config.Task{Name: "after", Cmd: "true"}, false)
+	if err != nil || code != 0 {
+		t.Errorf("shell did not survive the dir guard: (%d, %v)", code, err)
+	}
+}
+
+func TestWrapWithDirShellUsesSubshell(t *testing.T) {
+	got := wrapWithDirShell("make build", config.Task{Name: "build", Dir: "/srv/app"})
+	if !strings.HasPrefix(got, "( ") || !strings.HasSuffix(got, " )") {
+		t.Errorf("wrapped command %q is not confined to a subshell", got)
+	}
+
+	if got := wrapWithDirShell("make build", config.Task{Name: "build"}); got != "make build" {
+		t.Errorf("command without dir was rewritten to %q", got)
+	}
+}
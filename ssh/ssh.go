@@ -4,14 +4,18 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/env"
 	"github.com/zechtz/nyatictl/logger"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/knownhosts"
@@ -28,6 +32,7 @@ type Manager struct {
 	pool           *ConnectionPool  // Optional connection pool for reuse
 	usePooling     bool             // Whether to use connection pooling
 	pooledConns    []*PooledConnection // Active pooled connections
+	envResolver    env.Resolver     // Resolves env_name references from the environment store
 }
 
 // Client represents a single SSH session to a remote host.
@@ -39,49 +44,221 @@ type Client struct {
 	config *ssh.ClientConfig // SSH configuration used to establish connection
 	client *ssh.Client       // Active SSH connection
 	env    map[string]string // Environment variables loaded from optional env file
+	facts  map[string]string // Host facts gathered by GatherFacts
+
+	taskEnv map[string]string // Per-task environment override, set for one task's duration
+
+	configPrelude string // Config-level prelude, prepended (before the host's own) to non-raw commands
+
+	shell *shellSession // Persistent shell when session-shell mode is on; nil otherwise
+}
+
+// Host key policy values accepted in a host's host_key_policy field.
+const (
+	// HostKeyPolicyStrict rejects any host not present in known_hosts.
+	HostKeyPolicyStrict = "strict"
+	// HostKeyPolicyAcceptNew records unknown hosts on first contact but still
+	// rejects hosts whose recorded key has changed.
+	HostKeyPolicyAcceptNew = "accept-new"
+	// HostKeyPolicyInsecureIgnore disables host key checking entirely and must
+	// be opted into explicitly.
+	HostKeyPolicyInsecureIgnore = "insecure-ignore"
+)
+
+// defaultHostKeyAlgorithms is the host key algorithm preference offered during
+// negotiation when a host doesn't configure its own list. Modern key types come
+// first so servers with an ed25519 or ECDSA key in known_hosts don't fail
+// verification just because an older algorithm was negotiated.
+var defaultHostKeyAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512,
+	ssh.KeyAlgoRSASHA256,
+	ssh.KeyAlgoRSA,
+}
+
+// hostKeyAlgorithms returns the host key algorithm preference for a host: its
+// own host_key_algorithms list when set, otherwise defaultHostKeyAlgorithms.
+// The known_hosts callback matches entries of any recorded key type, so the
+// list only influences which key the server presents, never which recorded
+// keys are trusted.
+func hostKeyAlgorithms(server config.Host) []string {
+	if len(server.HostKeyAlgorithms) > 0 {
+		return server.HostKeyAlgorithms
+	}
+	return defaultHostKeyAlgorithms
+}
+
+// transportDebug controls whether SSH transport milestones (handshake, auth
+// attempts, session events) are logged. Off by default; enabled via the
+// --debug-ssh flag. The logged lines never contain key material or passwords,
+// only key types, fingerprints and event names.
+var transportDebug bool
+
+// transportRetries is how many times a task marked idempotent is re-run
+// after its connection drops mid-task. Non-idempotent tasks are never re-run.
+var transportRetries = 1
+
+// SetTransportRetries sets the transport-retry count for idempotent tasks.
+// Negative values are clamped to 0 (interruptions always fail immediately).
+func SetTransportRetries(n int) {
+	if n < 0 {
+		n = 0
+	}
+	transportRetries = n
+}
+
+// SetTransportDebug enables or disables SSH transport milestone logging.
+func SetTransportDebug(enabled bool) {
+	transportDebug = enabled
+}
+
+// transportLog emits one transport milestone when --debug-ssh is active.
+func transportLog(msg string) {
+	if transportDebug {
+		logger.Console(logger.INFO, "🔐 "+msg)
+	}
 }
 
-// getKnownHostsFile returns the path to the known_hosts file
-func getKnownHostsFile() string {
+// knownHostsEnv lists known_hosts files to consult, colon-separated, for
+// hosts without an explicit known_hosts setting.
+const knownHostsEnv = "NYATI_KNOWN_HOSTS"
+
+// defaultKnownHostsFiles returns the standard OpenSSH known_hosts paths
+// (known_hosts and known_hosts2).
+func defaultKnownHostsFiles() []string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return ""
+		return nil
+	}
+	return []string{
+		filepath.Join(homeDir, ".ssh", "known_hosts"),
+		filepath.Join(homeDir, ".ssh", "known_hosts2"),
 	}
-	return filepath.Join(homeDir, ".ssh", "known_hosts")
 }
 
-// createHostKeyCallback creates a secure host key callback that validates
-// against known_hosts file and prompts user for unknown hosts
-func createHostKeyCallback() ssh.HostKeyCallback {
-	knownHostsFile := getKnownHostsFile()
-	
-	// Try to load known hosts file if it exists
+// expandHomePath expands a leading ~/ to the user's home directory.
+func expandHomePath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, path[2:])
+		}
+	}
+	return path
+}
+
+// resolveKnownHostsFiles returns the known_hosts paths for a host. The host's
+// own known_hosts setting wins; otherwise NYATI_KNOWN_HOSTS (colon-separated)
+// is consulted, falling back to both standard OpenSSH paths. The first path
+// is also where newly accepted keys are recorded.
+func resolveKnownHostsFiles(server config.Host) []string {
+	if server.KnownHosts != "" {
+		return []string{expandHomePath(server.KnownHosts)}
+	}
+	if env := os.Getenv(knownHostsEnv); env != "" {
+		var paths []string
+		for _, p := range strings.Split(env, ":") {
+			if p != "" {
+				paths = append(paths, expandHomePath(p))
+			}
+		}
+		return paths
+	}
+	return defaultKnownHostsFiles()
+}
+
+// KnownHostsFiles returns the known_hosts paths consulted for a host, in
+// resolution order. Exposed for preflight tooling (nyatictl doctor) that
+// reports on missing files without opening a connection.
+func KnownHostsFiles(server config.Host) []string {
+	return resolveKnownHostsFiles(server)
+}
+
+// createHostKeyCallback creates a host key callback honoring the host's
+// host_key_policy and known_hosts settings.
+//
+// Policies:
+//   - strict (default): validate against known_hosts and reject unknown hosts
+//   - accept-new: record unknown hosts in known_hosts, reject changed keys
+//   - insecure-ignore: skip verification entirely (logs a prominent warning)
+//
+// A host_key_fingerprint pin takes precedence over all of the above.
+func createHostKeyCallback(server config.Host) ssh.HostKeyCallback {
+	// A pinned fingerprint overrides every policy: only the exact key is
+	// accepted and known_hosts is not consulted at all
+	if server.HostKeyFingerprint != "" {
+		return pinnedHostKeyCallback(server.HostKeyFingerprint)
+	}
+
+	policy := server.HostKeyPolicy
+	if policy == "" {
+		policy = HostKeyPolicyStrict
+	}
+
+	if policy == HostKeyPolicyInsecureIgnore {
+		logger.Log("WARNING: host key verification is DISABLED (host_key_policy: insecure-ignore); connections are vulnerable to man-in-the-middle attacks")
+		return ssh.InsecureIgnoreHostKey()
+	}
+
+	knownHostsFiles := resolveKnownHostsFiles(server)
+
+	// New keys are recorded in the first configured path
+	appendFile := ""
+	if len(knownHostsFiles) > 0 {
+		appendFile = knownHostsFiles[0]
+	}
+
+	// Load every known_hosts file that exists; the knownhosts package matches
+	// both plain and hashed (HashKnownHosts yes) entries
+	var existingFiles []string
+	for _, f := range knownHostsFiles {
+		if f != "" && fileExists(f) {
+			existingFiles = append(existingFiles, f)
+		}
+	}
 	var knownHostsCallback ssh.HostKeyCallback
-	if knownHostsFile != "" && fileExists(knownHostsFile) {
+	if len(existingFiles) > 0 {
 		var err error
-		knownHostsCallback, err = knownhosts.New(knownHostsFile)
+		knownHostsCallback, err = knownhosts.New(existingFiles...)
 		if err != nil {
-			logger.Log(fmt.Sprintf("Warning: Could not load known_hosts file (%s): %v", knownHostsFile, err))
+			logger.Log(fmt.Sprintf("Warning: Could not load known_hosts files (%s): %v", strings.Join(existingFiles, ", "), err))
 		}
 	}
 
 	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
 		// If we have a known_hosts callback, try it first
+		var keyErr *knownhosts.KeyError
 		if knownHostsCallback != nil {
 			err := knownHostsCallback(hostname, remote, key)
 			if err == nil {
 				return nil // Host key is already known and valid
 			}
+			// A KeyError with recorded keys means the host's key CHANGED,
+			// which no policy short of insecure-ignore accepts
+			if errors.As(err, &keyErr) && len(keyErr.Want) > 0 {
+				return fmt.Errorf("host key verification failed: key for %s has changed (recorded in %s)", hostname, strings.Join(existingFiles, ", "))
+			}
 		}
 
-		// For unknown hosts, show the key fingerprint and require explicit approval
 		keyHash := sha256.Sum256(key.Marshal())
 		fingerprint := hex.EncodeToString(keyHash[:])
-		
+
+		// accept-new trusts the first key seen and records it for next time
+		if policy == HostKeyPolicyAcceptNew {
+			if err := appendKnownHost(appendFile, hostname, key); err != nil {
+				return fmt.Errorf("failed to record host key for %s: %v", hostname, err)
+			}
+			logger.Log(fmt.Sprintf("Recorded new host key for %s (SHA256: %s) in %s", hostname, fingerprint, appendFile))
+			return nil
+		}
+
+		// For unknown hosts, show the key fingerprint and require explicit approval
 		logger.Log(fmt.Sprintf("WARNING: Unknown host key for %s", hostname))
 		logger.Log(fmt.Sprintf("Host key fingerprint (SHA256): %s", fingerprint))
 		logger.Log(fmt.Sprintf("Key type: %s", key.Type()))
-		
+
 		// In automated mode, we should reject unknown hosts for security
 		// In interactive mode, we could prompt the user
 		// For now, we'll log the details and reject for security
@@ -89,6 +266,79 @@ func createHostKeyCallback() ssh.HostKeyCallback {
 	}
 }
 
+// pinnedHostKeyCallback builds the callback for a host with a pinned SHA256
+// fingerprint: the connection is accepted only when the presented key's
+// fingerprint matches the pin exactly. Nothing is read from or written to
+// known_hosts.
+func pinnedHostKeyCallback(pin string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if fingerprintMatchesPin(key, pin) {
+			return nil
+		}
+		keyHash := sha256.Sum256(key.Marshal())
+		return fmt.Errorf("SECURITY: host key verification failed for %s: presented %s key %s (SHA256 hex %s) does not match the pinned fingerprint %s; the host may have been re-provisioned or the connection intercepted",
+			hostname, key.Type(), ssh.FingerprintSHA256(key), hex.EncodeToString(keyHash[:]), pin)
+	}
+}
+
+// fingerprintMatchesPin compares a presented key against a pinned SHA256
+// fingerprint. Both the OpenSSH form ("SHA256:" plus unpadded base64, as
+// printed by ssh-keygen -lf) and the plain hex digest are accepted; hex is
+// compared case-insensitively.
+func fingerprintMatchesPin(key ssh.PublicKey, pin string) bool {
+	trimmed := strings.TrimPrefix(pin, "SHA256:")
+	keyHash := sha256.Sum256(key.Marshal())
+	if strings.EqualFold(hex.EncodeToString(keyHash[:]), trimmed) {
+		return true
+	}
+	return strings.TrimPrefix(ssh.FingerprintSHA256(key), "SHA256:") == trimmed
+}
+
+// appendKnownHost appends a host key entry to the known_hosts file, creating
+// the file (and its directory) if needed. When the existing file uses hashed
+// hostnames (HashKnownHosts yes), the new entry is written hashed as well.
+func appendKnownHost(path, hostname string, key ssh.PublicKey) error {
+	if path == "" {
+		return fmt.Errorf("no known_hosts path configured")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	address := knownhosts.Normalize(hostname)
+	var line string
+	if fileUsesHashedHosts(path) {
+		serialized := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(key)), "\n")
+		line = knownhosts.HashHostname(address) + " " + serialized
+	} else {
+		line = knownhosts.Line([]string{address}, key)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(line + "\n")
+	return err
+}
+
+// fileUsesHashedHosts reports whether a known_hosts file contains hashed
+// hostname entries.
+func fileUsesHashedHosts(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "|1|") {
+			return true
+		}
+	}
+	return false
+}
+
 // fileExists checks if a file exists
 func fileExists(filename string) bool {
 	_, err := os.Stat(filename)
@@ -114,6 +364,76 @@ func NewManager(cfg *config.Config, args []string, debug bool) (*Manager, error)
 	}, nil
 }
 
+// SetEnvResolver sets the resolver used for hosts that reference a named
+// environment via env_name. When unset, the default file-backed store is used.
+func (m *Manager) SetEnvResolver(resolver env.Resolver) {
+	m.envResolver = resolver
+}
+
+// resolveNamedEnvs resolves the env_name reference of every selected host up
+// front, so a missing environment fails before any connection is opened or
+// task runs. The returned map is keyed by environment name.
+func (m *Manager) resolveNamedEnvs(selectedHosts []string) (map[string]map[string]string, error) {
+	// Collect which hosts reference which environment names
+	referencedBy := make(map[string][]string)
+	for _, name := range selectedHosts {
+		host := m.Config.Hosts[name]
+		if host.EnvName != "" {
+			referencedBy[host.EnvName] = append(referencedBy[host.EnvName], name)
+		}
+	}
+	if len(referencedBy) == 0 {
+		return nil, nil
+	}
+
+	resolver := m.envResolver
+	if resolver == nil {
+		resolver = env.FileResolver(env.DefaultEnvFile, "")
+	}
+
+	resolved := make(map[string]map[string]string, len(referencedBy))
+	var failures []string
+	for envName, hosts := range referencedBy {
+		vars, err := resolver(envName)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%v (referenced by hosts: %s)", err, strings.Join(hosts, ", ")))
+			continue
+		}
+		resolved[envName] = vars
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("failed to resolve environments: %s", strings.Join(failures, "; "))
+	}
+
+	return resolved, nil
+}
+
+// ResolveEnv resolves a named environment through the manager's resolver,
+// falling back to the default file-backed store when none is set.
+//
+// Parameters:
+//   - name: the environment name to resolve
+//
+// Returns:
+//   - map[string]string: the environment's variables
+//   - error: if the environment cannot be resolved
+func (m *Manager) ResolveEnv(name string) (map[string]string, error) {
+	resolver := m.envResolver
+	if resolver == nil {
+		resolver = env.FileResolver(env.DefaultEnvFile, "")
+	}
+	return resolver(name)
+}
+
+// InjectEnv merges vars into the environment of every connected client, for
+// callers that resolve an environment up front rather than per host. On key
+// conflicts vars win over the host's own env file values. Call after Open.
+func (m *Manager) InjectEnv(vars map[string]string) {
+	for _, client := range m.Clients {
+		client.mergeEnv(vars)
+	}
+}
+
 // EnableConnectionPooling enables SSH connection pooling with the specified configuration
 func (m *Manager) EnableConnectionPooling(poolConfig *ConnectionPoolConfig) {
 	if poolConfig == nil {
@@ -174,7 +494,7 @@ func (m *Manager) Open() error {
 			} else if _, ok := m.Config.Hosts[m.args[1]]; ok {
 				selectedHosts = append(selectedHosts, m.args[1])
 			} else {
-				return fmt.Errorf("host %s not found", m.args[1])
+				return ErrHostNotFound{Host: m.args[1]}
 			}
 		} else if _, ok := m.Config.Hosts[m.args[0]]; ok {
 			selectedHosts = append(selectedHosts, m.args[0])
@@ -185,6 +505,13 @@ func (m *Manager) Open() error {
 		return fmt.Errorf("no hosts selected; use deploy <host> or <host>")
 	}
 
+	// Resolve named environments first so missing names fail before any
+	// connection is opened
+	namedEnvs, err := m.resolveNamedEnvs(selectedHosts)
+	if err != nil {
+		return err
+	}
+
 	// Create SSH clients for selected hosts
 	for _, name := range selectedHosts {
 		host := m.Config.Hosts[name]
@@ -192,15 +519,24 @@ func (m *Manager) Open() error {
 		if err != nil {
 			return err
 		}
+		if host.EnvName != "" {
+			client.mergeEnv(namedEnvs[host.EnvName])
+		}
+		client.SetConfigPrelude(m.Config.Prelude)
 		if err := client.Connect(); err != nil {
-			return fmt.Errorf("failed to connect to %s: %v", name, err)
+			return ErrConnectFailed{Host: name, Cause: err}
+		}
+		if m.Config.SessionShell {
+			if err := client.StartShell(); err != nil {
+				client.Disconnect()
+				return ErrConnectFailed{Host: name, Cause: err}
+			}
 		}
 		m.Clients = append(m.Clients, client)
 
-		// Log connection status
-		msg := fmt.Sprintf("📡 Connected: %s (%s@%s)", name, host.Username, host.Host)
-		logger.Log(msg)
-		fmt.Println(msg)
+		// Log connection status; the banner is informational chatter and is
+		// suppressed on the console in quiet mode
+		logger.Console(logger.INFO, fmt.Sprintf("📡 Connected: %s (%s@%s)", name, host.Username, host.Host))
 	}
 
 	return nil
@@ -241,21 +577,31 @@ func (m *Manager) Close() {
 func NewClient(name string, server config.Host, debug bool) (*Client, error) {
 	authMethods := []ssh.AuthMethod{}
 
-	// Determine authentication method
+	// Determine authentication method. The callback variants fire when the
+	// transport actually attempts the method, which is what --debug-ssh
+	// reports; only the method name and key fingerprint are ever logged.
 	if server.Password != "" {
-		authMethods = append(authMethods, ssh.Password(server.Password))
+		password := server.Password
+		authMethods = append(authMethods, ssh.PasswordCallback(func() (string, error) {
+			transportLog(fmt.Sprintf("%s: attempting password auth for user %s", name, server.Username))
+			return password, nil
+		}))
 	} else if server.PrivateKey != "" {
 		key, err := os.ReadFile(server.PrivateKey)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read private key: %v", err)
+			return nil, fmt.Errorf("failed to read private key: %w", err)
 		}
 		signer, err := ssh.ParsePrivateKey(key)
 		if err != nil {
 			return nil, fmt.Errorf("invalid private key: %v", err)
 		}
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
+		authMethods = append(authMethods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			transportLog(fmt.Sprintf("%s: attempting public key auth for user %s (%s %s)",
+				name, server.Username, signer.PublicKey().Type(), ssh.FingerprintSHA256(signer.PublicKey())))
+			return []ssh.Signer{signer}, nil
+		}))
 	} else {
-		return nil, fmt.Errorf("host %s: password or private_key required", name)
+		return nil, fmt.Errorf("host %s: %w", name, ErrAuthConfig)
 	}
 
 	// Load env file if specified
@@ -264,57 +610,99 @@ func NewClient(name string, server config.Host, debug bool) (*Client, error) {
 		return nil, err
 	}
 
+	// The host key callback doubles as the handshake observation point: the
+	// server's chosen host key algorithm is known exactly when it runs
+	hostKeyCallback := createHostKeyCallback(server)
+	observedCallback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		transportLog(fmt.Sprintf("%s: negotiated host key %s (%s)", name, key.Type(), ssh.FingerprintSHA256(key)))
+		err := hostKeyCallback(hostname, remote, key)
+		if err != nil {
+			transportLog(fmt.Sprintf("%s: host key rejected: %v", name, err))
+		}
+		return err
+	}
+
 	return &Client{
 		Name:   name,
 		Server: server,
 		config: &ssh.ClientConfig{
-			User:            server.Username,
-			Auth:            authMethods,
-			HostKeyCallback: createHostKeyCallback(),
-			Timeout:         10 * time.Second,
+			User:              server.Username,
+			Auth:              authMethods,
+			HostKeyCallback:   observedCallback,
+			HostKeyAlgorithms: hostKeyAlgorithms(server),
+			BannerCallback: func(message string) error {
+				transportLog(fmt.Sprintf("%s: server banner: %s", name, strings.TrimSpace(message)))
+				return nil
+			},
+			Timeout: connectTimeout(server),
 		},
 		env: env,
 	}, nil
 }
 
+// hostAddress returns the dial address for a host, appending the default
+// SSH port when the config does not name one explicitly.
+func hostAddress(server config.Host) string {
+	if _, _, err := net.SplitHostPort(server.Host); err == nil {
+		return server.Host
+	}
+	return server.Host + ":22"
+}
+
+// isTransportError reports whether a command failure came from the SSH
+// transport rather than the remote command: the connection dropped before an
+// exit status arrived. These are candidates for reconnect-and-re-run.
+func isTransportError(err error) bool {
+	var exitMissing *ssh.ExitMissingError
+	return errors.As(err, &exitMissing) || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)
+}
+
 // Connect dials the remote host and establishes an SSH connection.
 //
+// DNS resolution and dialing run as separate steps (see dialSSH), so a
+// hostname that does not resolve is reported as a DNS failure rather than
+// being indistinguishable from a dropped connection.
+//
 // Parameters:
 //   - ctx: context for cancellation and timeout control
 //
 // Returns:
-//   - error: if dialing the host fails or context is cancelled
+//   - error: if resolution or dialing fails, or the context is cancelled
 func (c *Client) ConnectWithContext(ctx context.Context) error {
-	// Create a dialer that respects context cancellation
-	dialer := &net.Dialer{
-		Timeout: 10 * time.Second,
-	}
-	
-	conn, err := dialer.DialContext(ctx, "tcp", c.Server.Host+":22")
+	addr := hostAddress(c.Server)
+	conn, err := dialSSH(ctx, c.Name, addr, connectTimeout(c.Server))
+	recordReachability(c.Server.Host, err)
 	if err != nil {
-		return fmt.Errorf("failed to dial SSH host: %v", err)
+		return err
 	}
 	
-	clientConn, chans, reqs, err := ssh.NewClientConn(conn, c.Server.Host+":22", c.config)
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, c.config)
 	if err != nil {
+		transportLog(fmt.Sprintf("%s: handshake failed: %v", c.Name, err))
 		conn.Close()
 		return fmt.Errorf("failed to create SSH client connection: %v", err)
 	}
+	transportLog(fmt.Sprintf("%s: handshake complete, authenticated (server version %s)",
+		c.Name, string(clientConn.ServerVersion())))
 	
 	c.client = ssh.NewClient(clientConn, chans, reqs)
 	return nil
 }
 
-// Connect provides backward compatibility - uses context with default timeout
+// Connect provides backward compatibility - uses context with default timeout.
+// The window is three connect timeouts so dial plus handshake fit with margin,
+// matching the historical 30s for the 10s default.
 func (c *Client) Connect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*connectTimeout(c.Server))
 	defer cancel()
 	return c.ConnectWithContext(ctx)
 }
 
 // Disconnect cleanly closes the SSH session.
 func (c *Client) Disconnect() {
+	c.CloseShell()
 	if c.client != nil {
+		transportLog(fmt.Sprintf("%s: closing connection", c.Name))
 		c.client.Close()
 	}
 }
@@ -324,6 +712,12 @@ func (c *Client) Disconnect() {
 // It optionally changes the working directory, handles password prompt (if AskPass is set),
 // captures both stdout and stderr, and returns output + status.
 //
+// Transport failures (the connection dropping before the remote command
+// reports an exit status) are classified separately from command failures:
+// tasks marked idempotent are re-run after reconnecting, up to the configured
+// transport-retry count, while other tasks fail with ErrTaskInterrupted
+// because the command may have partially executed.
+//
 // Parameters:
 //   - ctx: context for cancellation and timeout control
 //   - task: Task to be executed on the remote host
@@ -334,35 +728,90 @@ func (c *Client) Disconnect() {
 //   - string: Combined stdout and stderr output
 //   - error: If the session setup or command execution fails
 func (c *Client) ExecWithContext(ctx context.Context, task config.Task, debug bool) (int, string, error) {
+	// In session-shell mode every task goes through the one persistent
+	// shell; see execShell for why transport retries do not apply there
+	if c.shell != nil {
+		return c.execShell(ctx, task, debug)
+	}
+
+	code, output, err := c.execAttempt(ctx, task, debug)
+	for attempt := 1; err != nil && isTransportError(err); attempt++ {
+		if !task.Idempotent {
+			return -1, output, ErrTaskInterrupted{Task: task.Name, Host: c.Name, Cause: err}
+		}
+		if attempt > transportRetries {
+			return -1, output, fmt.Errorf("task %s on %s: connection kept dropping after %d re-run(s): %v", task.Name, c.Name, transportRetries, err)
+		}
+
+		warnMsg := fmt.Sprintf("⚠️  %s@%s: connection dropped mid-task, reconnecting for re-run %d of %d", task.Name, c.Name, attempt, transportRetries)
+		logger.Console(logger.WARN, warnMsg)
+		c.Disconnect()
+		if cerr := c.ConnectWithContext(ctx); cerr != nil {
+			return -1, output, fmt.Errorf("task %s on %s: reconnect after connection drop failed: %v", task.Name, c.Name, cerr)
+		}
+		code, output, err = c.execAttempt(ctx, task, debug)
+	}
+	return code, output, err
+}
+
+// execAttempt runs one execution of the task over the current connection.
+func (c *Client) execAttempt(ctx context.Context, task config.Task, debug bool) (int, string, error) {
 	if c.client == nil {
 		return -1, "", fmt.Errorf("SSH client not connected")
 	}
 	
 	session, err := c.client.NewSession()
 	if err != nil {
+		transportLog(fmt.Sprintf("%s: failed to open session: %v", c.Name, err))
 		return -1, "", err
 	}
-	defer session.Close()
+	transportLog(fmt.Sprintf("%s: session opened for task %s", c.Name, task.Name))
+	defer func() {
+		session.Close()
+		transportLog(fmt.Sprintf("%s: session closed for task %s", c.Name, task.Name))
+	}()
 
 	var stdout, stderr strings.Builder
 	session.Stdout = &stdout
 	session.Stderr = &stderr
 
-	// Enable pseudo-terminal if AskPass is set
+	// Stamp output lines with elapsed time when the task (or the global
+	// --timestamps flag) asks for phase timing
+	if task.Timestamps || outputTimestamps {
+		taskStart := time.Now()
+		session.Stdout = newTimestampWriter(&stdout, taskStart)
+		session.Stderr = newTimestampWriter(&stderr, taskStart)
+	}
+
+	// Enable pseudo-terminal if AskPass is set, and deliver the configured
+	// sudo password over stdin so it never appears on the command line
 	if task.AskPass {
 		session.RequestPty("xterm", 80, 24, ssh.TerminalModes{})
+		if stdin := askpassStdin(); stdin != nil {
+			session.Stdin = stdin
+		}
+	}
+
+	// Substitute per-host ${env.*} and ${fact.*} placeholders
+	cmd := c.applyEnvPlaceholders(task.Cmd)
+	cmd = c.applyFactPlaceholders(cmd)
+
+	// Prepend the config- and host-level preludes unless the task opted out
+	if !task.Raw {
+		cmd = applyPrelude(cmd, c.preludeScript())
 	}
 
-	// Prepend directory change if specified
-	cmd := task.Cmd
-	if task.Dir != "" {
-		cmd = fmt.Sprintf("cd %s && %s", task.Dir, task.Cmd)
+	// Inject the host's environment variables into the shell
+	if exports := c.envExports(); exports != "" {
+		cmd = exports + " && " + cmd
 	}
 
+	// Guard the directory change with a reserved exit code so a missing
+	// directory is distinguishable from the command itself failing
+	cmd = wrapWithDir(cmd, task)
+
 	if debug {
-		msg := fmt.Sprintf("🎲 %s@%s: %s", c.Name, c.Server.Host, cmd)
-		logger.Log(msg)
-		fmt.Println(msg)
+		logger.Console(logger.INFO, fmt.Sprintf("🎲 %s@%s: %s", c.Name, c.Server.Host, c.redactSecrets(cmd)))
 	}
 
 	// Create a channel to receive the result
@@ -380,11 +829,14 @@ func (c *Client) ExecWithContext(ctx context.Context, task config.Task, debug bo
 	// Wait for either command completion or context cancellation
 	select {
 	case res := <-resultChan:
-		output := stdout.String() + stderr.String()
-		
+		output := redactSudoPassword(stdout.String() + stderr.String())
+
 		if res.err != nil {
 			// Gracefully handle remote command exit codes
 			if exitErr, ok := res.err.(*ssh.ExitError); ok {
+				if exitErr.ExitStatus() == dirMissingExitCode && task.Dir != "" {
+					return exitErr.ExitStatus(), output, dirMissingError(task, c.Name)
+				}
 				return exitErr.ExitStatus(), output, nil
 			}
 			return -1, output, res.err
@@ -397,10 +849,133 @@ func (c *Client) ExecWithContext(ctx context.Context, task config.Task, debug bo
 	}
 }
 
+// SetTaskEnv installs a per-task environment override whose values take
+// precedence over the client's own environment until cleared with nil.
+// Clients execute one task at a time, so this does not race across tasks.
+func (c *Client) SetTaskEnv(vars map[string]string) {
+	c.taskEnv = vars
+}
+
+// effectiveEnv returns the environment visible to the current task: the
+// client's environment with any per-task override merged on top.
+func (c *Client) effectiveEnv() map[string]string {
+	if len(c.taskEnv) == 0 {
+		return c.env
+	}
+	merged := make(map[string]string, len(c.env)+len(c.taskEnv))
+	for k, v := range c.env {
+		merged[k] = v
+	}
+	for k, v := range c.taskEnv {
+		merged[k] = v
+	}
+	return merged
+}
+
+// mergeEnv merges vars over the client's existing environment; on key
+// conflicts the named environment (vars) wins over the EnvFile values.
+func (c *Client) mergeEnv(vars map[string]string) {
+	if len(vars) == 0 {
+		return
+	}
+	if c.env == nil {
+		c.env = make(map[string]string, len(vars))
+	}
+	for k, v := range vars {
+		c.env[k] = v
+	}
+}
+
+// applyEnvPlaceholders substitutes ${env.KEY} placeholders in a command with
+// the environment visible to the current task.
+func (c *Client) applyEnvPlaceholders(cmd string) string {
+	for k, v := range c.effectiveEnv() {
+		cmd = strings.ReplaceAll(cmd, fmt.Sprintf("${env.%s}", k), v)
+	}
+	return cmd
+}
+
+// envExports builds the export prefix that injects the task's effective
+// environment into the remote shell, or "" when there is nothing to inject.
+func (c *Client) envExports() string {
+	environ := c.effectiveEnv()
+	if len(environ) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(environ))
+	for k := range environ {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, quoteEnvValue(environ[k])))
+	}
+	return "export " + strings.Join(parts, " ")
+}
+
+// redactSecrets masks the values of the task's effective environment and the
+// host's password in a command string, so --debug output can show the command
+// shape without leaking credentials into the console or log file.
+func (c *Client) redactSecrets(cmd string) string {
+	for _, v := range c.effectiveEnv() {
+		if v != "" {
+			cmd = strings.ReplaceAll(cmd, v, "****")
+		}
+	}
+	if c.Server.Password != "" {
+		cmd = strings.ReplaceAll(cmd, c.Server.Password, "****")
+	}
+	return cmd
+}
+
+// quoteEnvValue single-quotes a value for safe use in a shell export.
+func quoteEnvValue(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// dirMissingExitCode is the reserved exit code signalling that the cd into a
+// task's working directory failed. Commands exiting with this code themselves
+// would be misreported, so tasks should avoid it.
+const dirMissingExitCode = 97
+
+// wrapWithDir prefixes a command with the task's working directory change.
+// The cd is guarded with the reserved exit code so its failure can be told
+// apart from the command failing, and ensure_dir tasks get a mkdir -p first.
+//
+// Parameters:
+//   - cmd: the fully assembled command to run
+//   - task: the task being executed
+//
+// Returns:
+//   - string: the command, prefixed with the directory guard when dir is set
+func wrapWithDir(cmd string, task config.Task) string {
+	if task.Dir == "" {
+		return cmd
+	}
+	guard := fmt.Sprintf("cd %s || exit %d", task.Dir, dirMissingExitCode)
+	if task.EnsureDir {
+		guard = fmt.Sprintf("mkdir -p %s && %s", task.Dir, guard)
+	}
+	return guard + "; " + cmd
+}
+
+// dirMissingError renders the failure of a task's directory guard as an error
+// that names the task, the directory and the host, instead of the shell's
+// bare "No such file or directory".
+func dirMissingError(task config.Task, hostName string) error {
+	if task.EnsureDir {
+		return fmt.Errorf("task %s: working directory %s does not exist on host %s and could not be created", task.Name, task.Dir, hostName)
+	}
+	return fmt.Errorf("task %s: working directory %s does not exist on host %s", task.Name, task.Dir, hostName)
+}
+
 // Exec provides backward compatibility - uses context with default timeout
 func (c *Client) Exec(task config.Task, debug bool) (int, string, error) {
-	// Use a reasonable default timeout for SSH commands
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	// Bound the command by the host's exec_timeout (default 5 minutes)
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout(c.Server))
 	defer cancel()
 	return c.ExecWithContext(ctx, task, debug)
 }
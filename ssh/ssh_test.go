@@ -2,26 +2,39 @@ package ssh
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
-func TestGetKnownHostsFile(t *testing.T) {
-	knownHostsPath := getKnownHostsFile()
-	
-	// Should return a path ending with .ssh/known_hosts
-	if knownHostsPath == "" {
-		t.Error("getKnownHostsFile() returned empty path")
+func TestDefaultKnownHostsFiles(t *testing.T) {
+	paths := defaultKnownHostsFiles()
+
+	// Should return both standard OpenSSH paths
+	if len(paths) != 2 {
+		t.Fatalf("defaultKnownHostsFiles() returned %d paths, want 2", len(paths))
 	}
-	
-	expectedSuffix := filepath.Join(".ssh", "known_hosts")
-	if !strings.HasSuffix(knownHostsPath, expectedSuffix) {
-		t.Errorf("getKnownHostsFile() = %v, should end with %v", knownHostsPath, expectedSuffix)
+	if !strings.HasSuffix(paths[0], filepath.Join(".ssh", "known_hosts")) {
+		t.Errorf("defaultKnownHostsFiles()[0] = %v, should end with .ssh/known_hosts", paths[0])
+	}
+	if !strings.HasSuffix(paths[1], filepath.Join(".ssh", "known_hosts2")) {
+		t.Errorf("defaultKnownHostsFiles()[1] = %v, should end with .ssh/known_hosts2", paths[1])
 	}
 }
 
@@ -177,13 +190,188 @@ NhAAAAAwEAAQAAAQEA1234567890abcdefghijklmnop
 }
 
 func TestCreateHostKeyCallback(t *testing.T) {
-	callback := createHostKeyCallback()
+	callback := createHostKeyCallback(config.Host{})
 	if callback == nil {
 		t.Error("createHostKeyCallback() should return non-nil callback")
 	}
-	
-	// We can't easily test the actual callback functionality without setting up
-	// a real SSH connection, but we can at least verify it returns a function
+}
+
+// generateTestHostKey creates an ed25519 host key for callback tests.
+func generateTestHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	sshKey, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to convert key: %v", err)
+	}
+	return sshKey
+}
+
+// writeKnownHosts writes a known_hosts file containing the given host/key pair.
+func writeKnownHosts(t *testing.T, hostname string, key ssh.PublicKey) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if err := os.WriteFile(path, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write known_hosts: %v", err)
+	}
+	return path
+}
+
+func TestHostKeyPolicyStrict(t *testing.T) {
+	knownKey := generateTestHostKey(t)
+	unknownKey := generateTestHostKey(t)
+	knownHostsPath := writeKnownHosts(t, "example.com", knownKey)
+
+	callback := createHostKeyCallback(config.Host{
+		KnownHosts:    knownHostsPath,
+		HostKeyPolicy: HostKeyPolicyStrict,
+	})
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	if err := callback("example.com:22", addr, knownKey); err != nil {
+		t.Errorf("strict policy rejected a known host: %v", err)
+	}
+	if err := callback("unknown.example.com:22", addr, unknownKey); err == nil {
+		t.Error("strict policy should reject an unknown host")
+	}
+	if err := callback("example.com:22", addr, unknownKey); err == nil {
+		t.Error("strict policy should reject a changed host key")
+	}
+}
+
+func TestHostKeyPolicyAcceptNew(t *testing.T) {
+	firstKey := generateTestHostKey(t)
+	changedKey := generateTestHostKey(t)
+	knownHostsPath := filepath.Join(t.TempDir(), "known_hosts")
+
+	server := config.Host{
+		KnownHosts:    knownHostsPath,
+		HostKeyPolicy: HostKeyPolicyAcceptNew,
+	}
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+
+	// First contact: accept and record the key
+	callback := createHostKeyCallback(server)
+	if err := callback("example.com:22", addr, firstKey); err != nil {
+		t.Fatalf("accept-new rejected first contact: %v", err)
+	}
+	if !fileExists(knownHostsPath) {
+		t.Fatal("accept-new should have created the known_hosts file")
+	}
+
+	// Second contact with the recorded key succeeds
+	callback = createHostKeyCallback(server)
+	if err := callback("example.com:22", addr, firstKey); err != nil {
+		t.Errorf("accept-new rejected a recorded host: %v", err)
+	}
+
+	// A changed key is still rejected
+	if err := callback("example.com:22", addr, changedKey); err == nil {
+		t.Error("accept-new should reject a changed host key")
+	}
+}
+
+// writeHashedKnownHosts writes a known_hosts file with a hashed hostname
+// entry (HashKnownHosts yes style).
+func writeHashedKnownHosts(t *testing.T, hostname string, key ssh.PublicKey) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	serialized := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(key)), "\n")
+	line := knownhosts.HashHostname(knownhosts.Normalize(hostname)) + " " + serialized
+	if err := os.WriteFile(path, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write hashed known_hosts: %v", err)
+	}
+	return path
+}
+
+func TestHostKeyCallbackMatchesHashedEntries(t *testing.T) {
+	knownKey := generateTestHostKey(t)
+	changedKey := generateTestHostKey(t)
+	knownHostsPath := writeHashedKnownHosts(t, "example.com:22", knownKey)
+
+	callback := createHostKeyCallback(config.Host{
+		KnownHosts:    knownHostsPath,
+		HostKeyPolicy: HostKeyPolicyStrict,
+	})
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	if err := callback("example.com:22", addr, knownKey); err != nil {
+		t.Errorf("strict policy rejected a host recorded with a hashed entry: %v", err)
+	}
+	if err := callback("example.com:22", addr, changedKey); err == nil {
+		t.Error("strict policy should reject a changed key for a hashed entry")
+	}
+}
+
+func TestKnownHostsEnvListsMultipleFiles(t *testing.T) {
+	keyA := generateTestHostKey(t)
+	keyB := generateTestHostKey(t)
+	fileA := writeKnownHosts(t, "first.example.com", keyA)
+	fileB := writeKnownHosts(t, "second.example.com", keyB)
+	t.Setenv(knownHostsEnv, fileA+":"+fileB)
+
+	// No per-host known_hosts: the env var supplies both files
+	callback := createHostKeyCallback(config.Host{HostKeyPolicy: HostKeyPolicyStrict})
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	if err := callback("first.example.com:22", addr, keyA); err != nil {
+		t.Errorf("host from first known_hosts file rejected: %v", err)
+	}
+	if err := callback("second.example.com:22", addr, keyB); err != nil {
+		t.Errorf("host from second known_hosts file rejected: %v", err)
+	}
+	if err := callback("second.example.com:22", addr, keyA); err == nil {
+		t.Error("mismatched key should still be rejected")
+	}
+}
+
+func TestAppendKnownHostHonorsHashing(t *testing.T) {
+	existingKey := generateTestHostKey(t)
+	newKey := generateTestHostKey(t)
+	knownHostsPath := writeHashedKnownHosts(t, "existing.example.com:22", existingKey)
+
+	server := config.Host{
+		KnownHosts:    knownHostsPath,
+		HostKeyPolicy: HostKeyPolicyAcceptNew,
+	}
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+
+	callback := createHostKeyCallback(server)
+	if err := callback("new.example.com:22", addr, newKey); err != nil {
+		t.Fatalf("accept-new rejected first contact: %v", err)
+	}
+
+	// The appended entry must be hashed like the rest of the file
+	data, err := os.ReadFile(knownHostsPath)
+	if err != nil {
+		t.Fatalf("failed to read known_hosts: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if !strings.HasPrefix(line, "|1|") {
+			t.Errorf("entry is not hashed: %q", line)
+		}
+	}
+
+	// A fresh callback must match the hashed entry it just wrote
+	callback = createHostKeyCallback(server)
+	if err := callback("new.example.com:22", addr, newKey); err != nil {
+		t.Errorf("recorded hashed entry not matched on second contact: %v", err)
+	}
+}
+
+func TestHostKeyPolicyInsecureIgnore(t *testing.T) {
+	callback := createHostKeyCallback(config.Host{
+		HostKeyPolicy: HostKeyPolicyInsecureIgnore,
+	})
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	if err := callback("anything.example.com:22", addr, generateTestHostKey(t)); err != nil {
+		t.Errorf("insecure-ignore should accept any host key, got: %v", err)
+	}
 }
 
 func TestExecWithContextTimeout(t *testing.T) {
@@ -287,4 +475,493 @@ func TestManagerOpen(t *testing.T) {
 			manager.Close()
 		})
 	}
-}
\ No newline at end of file
+}
+func TestMergeEnvNamedEnvironmentWins(t *testing.T) {
+	client := &Client{env: map[string]string{"SHARED": "from_file", "FILE_ONLY": "yes"}}
+	client.mergeEnv(map[string]string{"SHARED": "from_store", "STORE_ONLY": "yes"})
+
+	if client.env["SHARED"] != "from_store" {
+		t.Errorf("mergeEnv() SHARED = %v, want from_store (env_name wins)", client.env["SHARED"])
+	}
+	if client.env["FILE_ONLY"] != "yes" || client.env["STORE_ONLY"] != "yes" {
+		t.Errorf("mergeEnv() lost keys: %v", client.env)
+	}
+}
+
+func TestApplyEnvPlaceholders(t *testing.T) {
+	client := &Client{env: map[string]string{"RELEASE_DIR": "/var/www/app"}}
+
+	got := client.applyEnvPlaceholders("ls ${env.RELEASE_DIR} && echo ${env.MISSING}")
+	want := "ls /var/www/app && echo ${env.MISSING}"
+	if got != want {
+		t.Errorf("applyEnvPlaceholders() = %q, want %q", got, want)
+	}
+}
+
+func TestEnvExports(t *testing.T) {
+	client := &Client{}
+	if got := client.envExports(); got != "" {
+		t.Errorf("envExports() with no env = %q, want empty", got)
+	}
+
+	client.env = map[string]string{"B": "two words", "A": "it's"}
+	want := `export A='it'\''s' B='two words'`
+	if got := client.envExports(); got != want {
+		t.Errorf("envExports() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveNamedEnvsMissingEnvironment(t *testing.T) {
+	cfg := &config.Config{
+		Hosts: map[string]config.Host{
+			"server1": {Host: "10.0.0.1", Username: "deploy", Password: "x", EnvName: "production-eu"},
+			"server2": {Host: "10.0.0.2", Username: "deploy", Password: "x", EnvName: "production-eu"},
+			"server3": {Host: "10.0.0.3", Username: "deploy", Password: "x"},
+		},
+	}
+	manager, err := NewManager(cfg, []string{"deploy", "all"}, false)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	manager.SetEnvResolver(func(name string) (map[string]string, error) {
+		return nil, fmt.Errorf("environment '%s' not found", name)
+	})
+
+	_, err = manager.resolveNamedEnvs([]string{"server1", "server2", "server3"})
+	if err == nil {
+		t.Fatal("resolveNamedEnvs() should fail for a missing environment")
+	}
+	for _, want := range []string{"production-eu", "server1", "server2"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("resolveNamedEnvs() error %q should mention %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "server3") {
+		t.Errorf("resolveNamedEnvs() error %q should not mention server3", err)
+	}
+}
+
+func TestResolveNamedEnvsSuccess(t *testing.T) {
+	cfg := &config.Config{
+		Hosts: map[string]config.Host{
+			"server1": {Host: "10.0.0.1", Username: "deploy", Password: "x", EnvName: "staging"},
+		},
+	}
+	manager, err := NewManager(cfg, []string{"deploy", "server1"}, false)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	manager.SetEnvResolver(func(name string) (map[string]string, error) {
+		if name != "staging" {
+			return nil, fmt.Errorf("environment '%s' not found", name)
+		}
+		return map[string]string{"APP_ENV": "staging"}, nil
+	})
+
+	resolved, err := manager.resolveNamedEnvs([]string{"server1"})
+	if err != nil {
+		t.Fatalf("resolveNamedEnvs() error = %v", err)
+	}
+	if resolved["staging"]["APP_ENV"] != "staging" {
+		t.Errorf("resolveNamedEnvs() = %v, want staging vars", resolved)
+	}
+}
+
+func TestPerTaskEnvironmentOverride(t *testing.T) {
+	client := &Client{env: map[string]string{"DB_HOST": "staging-db", "APP": "demo"}}
+
+	// Task using the deploy-wide environment
+	if got := client.applyEnvPlaceholders("psql -h ${env.DB_HOST}"); got != "psql -h staging-db" {
+		t.Errorf("applyEnvPlaceholders() = %q, want staging-db", got)
+	}
+
+	// Task selecting a different environment resolves different values
+	client.SetTaskEnv(map[string]string{"DB_HOST": "production-db"})
+	if got := client.applyEnvPlaceholders("psql -h ${env.DB_HOST}"); got != "psql -h production-db" {
+		t.Errorf("applyEnvPlaceholders() with override = %q, want production-db", got)
+	}
+	// Keys not overridden still come from the client environment
+	if got := client.applyEnvPlaceholders("echo ${env.APP}"); got != "echo demo" {
+		t.Errorf("applyEnvPlaceholders() = %q, want demo", got)
+	}
+
+	// Clearing the override restores the deploy-wide values
+	client.SetTaskEnv(nil)
+	if got := client.applyEnvPlaceholders("psql -h ${env.DB_HOST}"); got != "psql -h staging-db" {
+		t.Errorf("applyEnvPlaceholders() after clear = %q, want staging-db", got)
+	}
+}
+
+func TestResolveEnvUsesManagerResolver(t *testing.T) {
+	cfg := &config.Config{Hosts: map[string]config.Host{}}
+	manager, err := NewManager(cfg, nil, false)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	manager.SetEnvResolver(func(name string) (map[string]string, error) {
+		if name == "production" {
+			return map[string]string{"DB_HOST": "production-db"}, nil
+		}
+		return nil, fmt.Errorf("environment '%s' not found", name)
+	})
+
+	vars, err := manager.ResolveEnv("production")
+	if err != nil || vars["DB_HOST"] != "production-db" {
+		t.Errorf("ResolveEnv() = (%v, %v), want production vars", vars, err)
+	}
+	if _, err := manager.ResolveEnv("missing"); err == nil {
+		t.Error("ResolveEnv() should fail for an unknown environment")
+	}
+}
+
+func TestWrapWithDir(t *testing.T) {
+	// No dir: command passes through untouched
+	if got := wrapWithDir("make build", config.Task{Name: "build"}); got != "make build" {
+		t.Errorf("wrapWithDir without dir = %q, want the command unchanged", got)
+	}
+
+	// Dir set: the cd is guarded with the reserved exit code
+	got := wrapWithDir("make build", config.Task{Name: "build", Dir: "/var/www/app"})
+	want := "cd /var/www/app || exit 97; make build"
+	if got != want {
+		t.Errorf("wrapWithDir = %q, want %q", got, want)
+	}
+
+	// ensure_dir: the directory is created first
+	got = wrapWithDir("make build", config.Task{Name: "build", Dir: "/var/www/app", EnsureDir: true})
+	want = "mkdir -p /var/www/app && cd /var/www/app || exit 97; make build"
+	if got != want {
+		t.Errorf("wrapWithDir with ensure_dir = %q, want %q", got, want)
+	}
+}
+
+func TestDirMissingErrorNamesTaskDirAndHost(t *testing.T) {
+	task := config.Task{Name: "release", Dir: "/var/www/app"}
+	err := dirMissingError(task, "server1")
+	want := "task release: working directory /var/www/app does not exist on host server1"
+	if err == nil || err.Error() != want {
+		t.Errorf("dirMissingError = %v, want %q", err, want)
+	}
+
+	task.EnsureDir = true
+	err = dirMissingError(task, "server1")
+	if err == nil || !strings.Contains(err.Error(), "could not be created") {
+		t.Errorf("ensure_dir error = %v, want creation failure mentioned", err)
+	}
+}
+
+func TestHostKeyAlgorithmsDefaultsToModernSet(t *testing.T) {
+	client, err := NewClient("server1", config.Host{
+		Host:     "192.168.1.1",
+		Username: "admin",
+		Password: "secret",
+	}, false)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	algos := client.config.HostKeyAlgorithms
+	if len(algos) == 0 {
+		t.Fatal("HostKeyAlgorithms not set on the client config")
+	}
+	if algos[0] != ssh.KeyAlgoED25519 {
+		t.Errorf("first preference = %q, want %q", algos[0], ssh.KeyAlgoED25519)
+	}
+	for _, want := range []string{ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521, ssh.KeyAlgoRSASHA256} {
+		if !slices.Contains(algos, want) {
+			t.Errorf("default preference missing %q", want)
+		}
+	}
+}
+
+func TestHostKeyAlgorithmsHonorsHostOverride(t *testing.T) {
+	override := []string{ssh.KeyAlgoECDSA521, ssh.KeyAlgoED25519}
+	client, err := NewClient("server1", config.Host{
+		Host:              "192.168.1.1",
+		Username:          "admin",
+		Password:          "secret",
+		HostKeyAlgorithms: override,
+	}, false)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	if !slices.Equal(client.config.HostKeyAlgorithms, override) {
+		t.Errorf("HostKeyAlgorithms = %v, want the host's own list %v", client.config.HostKeyAlgorithms, override)
+	}
+}
+
+func TestRedactSecretsMasksEnvValuesAndPassword(t *testing.T) {
+	client := &Client{
+		Server: config.Host{Password: "hunter2"},
+		env:    map[string]string{"API_KEY": "s3cr3t", "EMPTY": ""},
+	}
+
+	cmd := "export API_KEY='s3cr3t' && curl -u admin:hunter2 https://example.com"
+	redacted := client.redactSecrets(cmd)
+
+	for _, leaked := range []string{"s3cr3t", "hunter2"} {
+		if strings.Contains(redacted, leaked) {
+			t.Errorf("redacted command still contains %q: %s", leaked, redacted)
+		}
+	}
+	if !strings.Contains(redacted, "export API_KEY='****'") {
+		t.Errorf("redacted command lost its shape: %s", redacted)
+	}
+}
+
+func TestTransportLogRespectsFlag(t *testing.T) {
+	// Drain any stale messages so the assertion below sees only ours
+	if logger.LogChan == nil {
+		logger.LogChan = make(chan string, 100)
+	}
+	for len(logger.LogChan) > 0 {
+		<-logger.LogChan
+	}
+
+	transportLog("should not appear")
+	if len(logger.LogChan) != 0 {
+		t.Error("transportLog emitted while --debug-ssh is off")
+	}
+
+	SetTransportDebug(true)
+	t.Cleanup(func() { SetTransportDebug(false) })
+	transportLog("handshake complete")
+	select {
+	case msg := <-logger.LogChan:
+		if !strings.Contains(msg, "handshake complete") {
+			t.Errorf("transport log = %q, want the milestone message", msg)
+		}
+	default:
+		t.Error("transportLog emitted nothing with --debug-ssh on")
+	}
+}
+
+// testHostPublicKey generates a fresh ed25519 host key for pinning tests.
+func testHostPublicKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to convert key: %v", err)
+	}
+	return key
+}
+
+func TestPinnedHostKeyCallbackAcceptsMatchingFingerprint(t *testing.T) {
+	key := testHostPublicKey(t)
+	keyHash := sha256.Sum256(key.Marshal())
+
+	// Both accepted pin formats: OpenSSH base64 and plain hex (any case)
+	for _, pin := range []string{
+		ssh.FingerprintSHA256(key),
+		hex.EncodeToString(keyHash[:]),
+		strings.ToUpper(hex.EncodeToString(keyHash[:])),
+	} {
+		callback := createHostKeyCallback(config.Host{HostKeyFingerprint: pin})
+		if err := callback("example.com:22", nil, key); err != nil {
+			t.Errorf("pin %q rejected the matching key: %v", pin, err)
+		}
+	}
+}
+
+func TestPinnedHostKeyCallbackRejectsMismatch(t *testing.T) {
+	key := testHostPublicKey(t)
+	other := testHostPublicKey(t)
+
+	callback := createHostKeyCallback(config.Host{HostKeyFingerprint: ssh.FingerprintSHA256(other)})
+	err := callback("example.com:22", nil, key)
+	if err == nil {
+		t.Fatal("a mismatching host key must be rejected")
+	}
+	if !strings.Contains(err.Error(), "SECURITY") || !strings.Contains(err.Error(), "pinned fingerprint") {
+		t.Errorf("mismatch error = %v, want a prominent pinning failure", err)
+	}
+}
+
+func TestPinnedFingerprintIgnoresKnownHosts(t *testing.T) {
+	key := testHostPublicKey(t)
+
+	// A known_hosts file recording a DIFFERENT key must not rescue the
+	// connection when the pin does not match
+	other := testHostPublicKey(t)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize("example.com:22")}, key)
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write known_hosts: %v", err)
+	}
+
+	callback := createHostKeyCallback(config.Host{
+		KnownHosts:         knownHosts,
+		HostKeyFingerprint: ssh.FingerprintSHA256(other),
+	})
+	if err := callback("example.com:22", nil, key); err == nil {
+		t.Error("a pin mismatch must reject even a host present in known_hosts")
+	}
+}
+
+func TestHostAddressAppendsDefaultPort(t *testing.T) {
+	if got := hostAddress(config.Host{Host: "example.com"}); got != "example.com:22" {
+		t.Errorf("hostAddress without port = %q, want example.com:22", got)
+	}
+	if got := hostAddress(config.Host{Host: "example.com:2222"}); got != "example.com:2222" {
+		t.Errorf("hostAddress with explicit port = %q, want it preserved", got)
+	}
+}
+
+// startDropServer runs an in-process SSH server on a random local port. The
+// first dropFirst exec requests are answered by closing the connection
+// without an exit status, simulating a transport failure mid-task; later
+// execs succeed with exit code 0. The returned counter tracks how many exec
+// requests arrived in total.
+func startDropServer(t *testing.T, dropFirst int) (string, *atomic.Int32) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == "testpass" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password")
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	execCount := &atomic.Int32{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				sconn, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					if newChannel.ChannelType() != "session" {
+						newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					ch, chReqs, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go func(ch ssh.Channel, chReqs <-chan *ssh.Request) {
+						for req := range chReqs {
+							if req.Type != "exec" {
+								req.Reply(req.Type == "pty-req" || req.Type == "env", nil)
+								continue
+							}
+							req.Reply(true, nil)
+							if int(execCount.Add(1)) <= dropFirst {
+								// Drop the whole connection before any
+								// exit status reaches the client
+								sconn.Close()
+								return
+							}
+							ch.Write([]byte("done\n"))
+							ch.SendRequest("exit-status", false, ssh.Marshal(&struct{ Status uint32 }{0}))
+							ch.Close()
+						}
+					}(ch, chReqs)
+				}
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String(), execCount
+}
+
+// dropServerClient connects a Client to the in-process server at addr.
+func dropServerClient(t *testing.T, addr string) *Client {
+	t.Helper()
+
+	client, err := NewClient("web", config.Host{
+		Host:          addr,
+		Username:      "tester",
+		Password:      "testpass",
+		HostKeyPolicy: HostKeyPolicyInsecureIgnore,
+	}, false)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect to test server: %v", err)
+	}
+	t.Cleanup(client.Disconnect)
+	return client
+}
+
+func TestExecWithContextRetriesIdempotentTasks(t *testing.T) {
+	addr, execCount := startDropServer(t, 1)
+	client := dropServerClient(t, addr)
+
+	task := config.Task{Name: "migrate", Cmd: "run-migrations", Idempotent: true}
+	code, _, err := client.ExecWithContext(context.Background(), task, false)
+	if err != nil {
+		t.Fatalf("idempotent task should survive one connection drop, got %v", err)
+	}
+	if code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	if got := execCount.Load(); got != 2 {
+		t.Errorf("exec count = %d, want 2 (the dropped run plus one re-run)", got)
+	}
+}
+
+func TestExecWithContextInterruptsNonIdempotentTasks(t *testing.T) {
+	addr, execCount := startDropServer(t, 1)
+	client := dropServerClient(t, addr)
+
+	task := config.Task{Name: "charge-cards", Cmd: "bill-customers"}
+	_, _, err := client.ExecWithContext(context.Background(), task, false)
+
+	var interrupted ErrTaskInterrupted
+	if !errors.As(err, &interrupted) {
+		t.Fatalf("non-idempotent drop error = %v, want ErrTaskInterrupted", err)
+	}
+	if !strings.Contains(err.Error(), "may have partially run") {
+		t.Errorf("error = %v, want a partial-execution warning", err)
+	}
+	if got := execCount.Load(); got != 1 {
+		t.Errorf("exec count = %d, want 1 (no automatic re-run)", got)
+	}
+}
+
+func TestExecWithContextGivesUpAfterConfiguredRetries(t *testing.T) {
+	addr, execCount := startDropServer(t, 100)
+	client := dropServerClient(t, addr)
+	SetTransportRetries(1)
+	defer SetTransportRetries(1)
+
+	task := config.Task{Name: "migrate", Cmd: "run-migrations", Idempotent: true}
+	_, _, err := client.ExecWithContext(context.Background(), task, false)
+	if err == nil || !strings.Contains(err.Error(), "connection kept dropping") {
+		t.Fatalf("persistent drops error = %v, want the give-up message", err)
+	}
+	if got := execCount.Load(); got != 2 {
+		t.Errorf("exec count = %d, want 2 (the initial run plus one re-run)", got)
+	}
+}
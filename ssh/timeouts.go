@@ -0,0 +1,34 @@
+package ssh
+
+import (
+	"time"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+// Default timeouts applied when a host does not configure its own. The
+// connect timeout bounds the TCP dial and handshake; the exec timeout bounds
+// a single task command.
+const (
+	defaultConnectTimeout = 10 * time.Second
+	defaultExecTimeout    = 5 * time.Minute
+)
+
+// connectTimeout returns the host's connect_timeout, or the default when the
+// host does not set one. The value was validated at config load, so a parse
+// failure here only ever means "unset".
+func connectTimeout(server config.Host) time.Duration {
+	if d, err := time.ParseDuration(server.ConnectTimeout); err == nil && d > 0 {
+		return d
+	}
+	return defaultConnectTimeout
+}
+
+// execTimeout returns the host's exec_timeout, or the default when the host
+// does not set one.
+func execTimeout(server config.Host) time.Duration {
+	if d, err := time.ParseDuration(server.ExecTimeout); err == nil && d > 0 {
+		return d
+	}
+	return defaultExecTimeout
+}
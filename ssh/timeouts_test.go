@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+func TestNewClientAppliesConnectTimeout(t *testing.T) {
+	host := config.Host{Host: "example.com", Username: "deploy", Password: "pass"}
+
+	client, err := NewClient("default", host, false)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.config.Timeout != defaultConnectTimeout {
+		t.Errorf("default client timeout = %v, want %v", client.config.Timeout, defaultConnectTimeout)
+	}
+
+	host.ConnectTimeout = "45s"
+	client, err = NewClient("slow-link", host, false)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.config.Timeout != 45*time.Second {
+		t.Errorf("client timeout = %v, want the host's 45s", client.config.Timeout)
+	}
+}
+
+func TestExecTimeoutPerHost(t *testing.T) {
+	if got := execTimeout(config.Host{}); got != defaultExecTimeout {
+		t.Errorf("execTimeout for an unset host = %v, want %v", got, defaultExecTimeout)
+	}
+	if got := execTimeout(config.Host{ExecTimeout: "10m"}); got != 10*time.Minute {
+		t.Errorf("execTimeout = %v, want the host's 10m", got)
+	}
+	// Load validates the field, so anything unparsable here means unset
+	if got := execTimeout(config.Host{ExecTimeout: "soon"}); got != defaultExecTimeout {
+		t.Errorf("execTimeout for a bad value = %v, want the default", got)
+	}
+}
+
+func TestExecUsesHostExecTimeout(t *testing.T) {
+	client := &Client{
+		Name:   "slow",
+		Server: config.Host{Host: "example.com", ExecTimeout: "1ms"},
+	}
+
+	// With no established connection Exec tries to reconnect; the 1ms
+	// budget from exec_timeout expires the context almost immediately
+	start := time.Now()
+	_, _, err := client.Exec(config.Task{Name: "noop", Cmd: "true"}, false)
+	if err == nil {
+		t.Fatal("Exec against an unreachable host should fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Exec took %v, want it bounded by the host's exec_timeout", elapsed)
+	}
+}
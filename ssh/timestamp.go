@@ -0,0 +1,76 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// outputTimestamps globally enables elapsed-time prefixes on task output,
+// equivalent to setting timestamps: true on every task.
+var outputTimestamps bool
+
+// SetOutputTimestamps toggles elapsed-time prefixes on all task output.
+// Used by the CLI --timestamps flag; individual tasks can opt in with
+// timestamps: true instead.
+func SetOutputTimestamps(enabled bool) {
+	outputTimestamps = enabled
+}
+
+// timestampWriter prefixes every line written through it with the time
+// elapsed since the task started, e.g. "[+0.412s] Compiling assets", so a
+// task's output doubles as a phase-timing profile. stdout and stderr share
+// one task start but use separate writers, hence the internal lock is per
+// writer only; interleaving across streams matches the unprefixed behavior.
+type timestampWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	start   time.Time
+	midLine bool // True while the current output line is still open
+}
+
+// newTimestampWriter wraps w, stamping lines relative to start.
+//
+// Parameters:
+//   - w: destination for the prefixed output
+//   - start: the task start time prefixes are measured from
+//
+// Returns:
+//   - *timestampWriter: the wrapping writer
+func newTimestampWriter(w io.Writer, start time.Time) *timestampWriter {
+	return &timestampWriter{w: w, start: start}
+}
+
+// Write stamps each new line with its elapsed offset and forwards the data.
+// Chunks that end mid-line leave the line open, so a line split across
+// several writes is stamped exactly once.
+func (t *timestampWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	written := 0
+	for len(p) > 0 {
+		if !t.midLine {
+			if _, err := fmt.Fprintf(t.w, "[+%.3fs] ", time.Since(t.start).Seconds()); err != nil {
+				return written, err
+			}
+			t.midLine = true
+		}
+
+		// Forward up to and including the next newline, then stamp again
+		chunk := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			chunk = p[:i+1]
+			t.midLine = false
+		}
+		n, err := t.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
@@ -0,0 +1,50 @@
+package ssh
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestampWriterPrefixesLines(t *testing.T) {
+	var buf strings.Builder
+	w := newTimestampWriter(&buf, time.Now())
+
+	for _, chunk := range []string{"first line\nsecond ", "half\nthird line\n"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), buf.String())
+	}
+
+	prefix := regexp.MustCompile(`^\[\+\d+\.\d{3}s\] `)
+	for i, line := range lines {
+		if !prefix.MatchString(line) {
+			t.Errorf("line %d = %q, want an elapsed-time prefix", i, line)
+		}
+	}
+
+	// The split line must carry exactly one stamp, placed where it started
+	if !strings.Contains(lines[1], "second half") {
+		t.Errorf("line 1 = %q, want the two chunks joined under one stamp", lines[1])
+	}
+}
+
+func TestTimestampWriterReportsPayloadLength(t *testing.T) {
+	var buf strings.Builder
+	w := newTimestampWriter(&buf, time.Now())
+
+	payload := []byte("hello\nworld\n")
+	n, err := w.Write(payload)
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if n != len(payload) {
+		t.Errorf("Write returned %d, want the payload length %d", n, len(payload))
+	}
+}
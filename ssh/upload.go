@@ -0,0 +1,275 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+)
+
+// uploadChunkSize is how much of the local file is transferred per remote
+// session. Each chunk is appended to the remote partial file, so a dropped
+// connection loses at most one chunk of progress.
+const uploadChunkSize = 4 << 20
+
+// uploadProgressInterval throttles progress reporting to the session log so a
+// large transfer doesn't flood it with one line per chunk.
+const uploadProgressInterval = 2 * time.Second
+
+// uploadPartialSuffix marks the temporary remote file a transfer streams into.
+// The file is renamed onto the real destination only after its checksum has
+// been verified, so the destination is never observed half-written.
+const uploadPartialSuffix = ".nyati-partial"
+
+// Upload transfers the task's src file to dest on the remote host in resumable
+// chunks, verifies the result against a local SHA-256 checksum, and only then
+// renames it into place.
+//
+// The file streams into dest plus uploadPartialSuffix. When a partial file
+// from an interrupted transfer is already present, the upload resumes after
+// its last byte instead of starting over; a partial larger than the local
+// file (the source changed) is discarded. After the last chunk the remote
+// checksum (sha256sum) must match the local one — on mismatch the partial is
+// deleted and the task fails with both checksums in the error.
+//
+// Parameters:
+//   - task: upload task carrying Src and Dest
+//   - debug: enables logging of the remote commands being run
+//
+// Returns:
+//   - int: exit status (0 on success, 1 on failure), mirroring Exec
+//   - string: transfer summary, used as the task's output
+//   - error: if the transfer, verification or rename fails
+func (c *Client) Upload(task config.Task, debug bool) (int, string, error) {
+	if c.client == nil {
+		return -1, "", fmt.Errorf("SSH client not connected")
+	}
+
+	src := c.applyFactPlaceholders(c.applyEnvPlaceholders(task.Src))
+	dest := c.applyFactPlaceholders(c.applyEnvPlaceholders(task.Dest))
+
+	local, err := os.Open(src)
+	if err != nil {
+		return 1, "", fmt.Errorf("task %s: failed to open %s: %v", task.Name, src, err)
+	}
+	defer local.Close()
+
+	info, err := local.Stat()
+	if err != nil {
+		return 1, "", fmt.Errorf("task %s: failed to stat %s: %v", task.Name, src, err)
+	}
+	size := info.Size()
+
+	// Checksum the whole local file up front; the remote side is verified
+	// against this after the last chunk lands
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, local); err != nil {
+		return 1, "", fmt.Errorf("task %s: failed to checksum %s: %v", task.Name, src, err)
+	}
+	localSum := hex.EncodeToString(hasher.Sum(nil))
+
+	tmp := dest + uploadPartialSuffix
+
+	// A partial file left by an interrupted transfer lets us resume after its
+	// last byte; anything larger than the source means the source changed and
+	// the partial is stale
+	offset, err := c.remoteFileSize(task.Name, tmp, debug)
+	if err != nil {
+		return 1, "", err
+	}
+	if offset > size {
+		logger.Log(fmt.Sprintf("⤴️  %s@%s: partial file is larger than %s, restarting transfer", task.Name, c.Name, src))
+		offset = 0
+	}
+	if offset > 0 {
+		logger.Log(fmt.Sprintf("⤴️  %s@%s: resuming upload at %s of %s", task.Name, c.Name, humanBytes(offset), humanBytes(size)))
+	}
+
+	if _, err := local.Seek(offset, io.SeekStart); err != nil {
+		return 1, "", fmt.Errorf("task %s: failed to seek %s: %v", task.Name, src, err)
+	}
+
+	// Make sure the destination directory exists before the first byte
+	if dir := path.Dir(dest); dir != "" && dir != "." {
+		if out, err := c.runUploadCommand("mkdir -p "+shellQuote(dir), nil, debug); err != nil {
+			return 1, out, fmt.Errorf("task %s: failed to create %s on %s: %v", task.Name, dir, c.Name, err)
+		}
+	}
+
+	sent := offset
+	start := time.Now()
+	lastProgress := start
+	buf := make([]byte, uploadChunkSize)
+	for sent < size {
+		n, err := io.ReadFull(local, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return 1, "", fmt.Errorf("task %s: failed to read %s: %v", task.Name, src, err)
+		}
+
+		// The first chunk of a fresh transfer truncates; everything after
+		// (including every resumed chunk) appends
+		redirect := ">>"
+		if sent == 0 {
+			redirect = ">"
+		}
+		cmd := fmt.Sprintf("cat %s %s", redirect, shellQuote(tmp))
+		if out, err := c.runUploadCommand(cmd, bytes.NewReader(buf[:n]), debug); err != nil {
+			return 1, out, fmt.Errorf("task %s: upload to %s failed at %s of %s: %v",
+				task.Name, c.Name, humanBytes(sent), humanBytes(size), err)
+		}
+		sent += int64(n)
+
+		if time.Since(lastProgress) >= uploadProgressInterval && sent < size {
+			lastProgress = time.Now()
+			logger.Log(fmt.Sprintf("📦 %s@%s: %s of %s (%d%%) at %s/s", task.Name, c.Name,
+				humanBytes(sent), humanBytes(size), percentOf(sent, size), humanBytes(transferRate(sent-offset, start))))
+		}
+	}
+
+	// An empty source never entered the loop; create the remote file so the
+	// rename below has something to move
+	if size == 0 {
+		if out, err := c.runUploadCommand(": > "+shellQuote(tmp), nil, debug); err != nil {
+			return 1, out, fmt.Errorf("task %s: failed to create %s on %s: %v", task.Name, tmp, c.Name, err)
+		}
+	}
+
+	// Verify before the rename: the destination must never receive a file
+	// whose checksum hasn't matched
+	out, err := c.runUploadCommand("sha256sum "+shellQuote(tmp), nil, debug)
+	if err != nil {
+		return 1, out, fmt.Errorf("task %s: failed to checksum %s on %s: %v", task.Name, tmp, c.Name, err)
+	}
+	remoteSum, err := parseRemoteChecksum(out)
+	if err != nil {
+		return 1, out, fmt.Errorf("task %s: %v", task.Name, err)
+	}
+	if remoteSum != localSum {
+		c.runUploadCommand("rm -f "+shellQuote(tmp), nil, debug)
+		return 1, out, fmt.Errorf("task %s: checksum mismatch after upload to %s: local %s, remote %s; partial file removed",
+			task.Name, c.Name, localSum, remoteSum)
+	}
+
+	if out, err := c.runUploadCommand(fmt.Sprintf("mv %s %s", shellQuote(tmp), shellQuote(dest)), nil, debug); err != nil {
+		return 1, out, fmt.Errorf("task %s: failed to move %s into place on %s: %v", task.Name, tmp, c.Name, err)
+	}
+
+	summary := fmt.Sprintf("uploaded %s (%s) to %s in %s, sha256 %s",
+		src, humanBytes(size), dest, time.Since(start).Round(time.Millisecond), localSum)
+	if offset > 0 {
+		summary += fmt.Sprintf(" (resumed at %s)", humanBytes(offset))
+	}
+	return 0, summary, nil
+}
+
+// remoteFileSize returns the size in bytes of a remote file, or 0 when it
+// does not exist.
+func (c *Client) remoteFileSize(taskName, remotePath string, debug bool) (int64, error) {
+	out, err := c.runUploadCommand(
+		fmt.Sprintf("stat -c %%s %s 2>/dev/null || echo 0", shellQuote(remotePath)), nil, debug)
+	if err != nil {
+		return 0, fmt.Errorf("task %s: failed to probe %s on %s: %v", taskName, remotePath, c.Name, err)
+	}
+	return parseRemoteSize(out)
+}
+
+// runUploadCommand runs one short command in its own session, optionally
+// feeding stdin (the chunk being transferred), and returns the combined
+// output. Upload commands are built from quoted paths, not user commands, so
+// they bypass preludes and directory guards.
+func (c *Client) runUploadCommand(cmd string, stdin io.Reader, debug bool) (string, error) {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	var stdout, stderr strings.Builder
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if stdin != nil {
+		session.Stdin = stdin
+	}
+
+	if debug {
+		logger.Console(logger.INFO, fmt.Sprintf("🎲 %s@%s: %s", c.Name, c.Server.Host, cmd))
+	}
+
+	err = session.Run(cmd)
+	output := stdout.String() + stderr.String()
+	if err != nil {
+		return output, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// shellQuote single-quotes a path for safe use in a remote command.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// parseRemoteSize parses the output of the remote size probe into bytes.
+func parseRemoteSize(out string) (int64, error) {
+	trimmed := strings.TrimSpace(out)
+	size, err := strconv.ParseInt(trimmed, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("unexpected remote size %q", trimmed)
+	}
+	return size, nil
+}
+
+// parseRemoteChecksum extracts the digest from sha256sum output
+// ("<digest>  <path>").
+func parseRemoteChecksum(out string) (string, error) {
+	fields := strings.Fields(out)
+	if len(fields) == 0 || len(fields[0]) != 64 {
+		return "", fmt.Errorf("unexpected sha256sum output %q", strings.TrimSpace(out))
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// percentOf returns sent as a whole percentage of total, with an empty file
+// reported as complete.
+func percentOf(sent, total int64) int {
+	if total == 0 {
+		return 100
+	}
+	return int(sent * 100 / total)
+}
+
+// transferRate returns the average bytes per second since start, guarding
+// against a zero elapsed time on very fast transfers.
+func transferRate(transferred int64, start time.Time) int64 {
+	elapsed := time.Since(start).Seconds()
+	if elapsed <= 0 {
+		return transferred
+	}
+	return int64(float64(transferred) / elapsed)
+}
+
+// humanBytes renders a byte count with a binary unit suffix for progress and
+// summary lines.
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
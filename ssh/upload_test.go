@@ -0,0 +1,92 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShellQuote(t *testing.T) {
+	cases := map[string]string{
+		"/var/www/app.tar.gz": "'/var/www/app.tar.gz'",
+		"file with spaces":    "'file with spaces'",
+		"it's":                `'it'\''s'`,
+	}
+	for in, want := range cases {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseRemoteSize(t *testing.T) {
+	size, err := parseRemoteSize("4194304\n")
+	if err != nil || size != 4194304 {
+		t.Errorf("parseRemoteSize = %d, %v; want 4194304", size, err)
+	}
+
+	// The probe prints 0 when the partial file does not exist
+	size, err = parseRemoteSize("0\n")
+	if err != nil || size != 0 {
+		t.Errorf("parseRemoteSize for a missing file = %d, %v; want 0", size, err)
+	}
+
+	for _, out := range []string{"", "not a number", "-5"} {
+		if _, err := parseRemoteSize(out); err == nil {
+			t.Errorf("parseRemoteSize(%q) should fail", out)
+		}
+	}
+}
+
+func TestParseRemoteChecksum(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	sum, err := parseRemoteChecksum(digest + "  /tmp/app.tar.gz.nyati-partial\n")
+	if err != nil || sum != digest {
+		t.Errorf("parseRemoteChecksum = %q, %v; want %q", sum, err, digest)
+	}
+
+	// Uppercase digests normalize so comparison against the local hex works
+	sum, err = parseRemoteChecksum(strings.ToUpper(digest) + "  f\n")
+	if err != nil || sum != digest {
+		t.Errorf("parseRemoteChecksum uppercase = %q, %v; want %q", sum, err, digest)
+	}
+
+	for _, out := range []string{"", "sha256sum: missing file", "tooshort  f"} {
+		if _, err := parseRemoteChecksum(out); err == nil {
+			t.Errorf("parseRemoteChecksum(%q) should fail", out)
+		}
+	}
+}
+
+func TestPercentOf(t *testing.T) {
+	if got := percentOf(50, 200); got != 25 {
+		t.Errorf("percentOf(50, 200) = %d, want 25", got)
+	}
+	if got := percentOf(0, 0); got != 100 {
+		t.Errorf("percentOf for an empty file = %d, want 100", got)
+	}
+}
+
+func TestTransferRate(t *testing.T) {
+	rate := transferRate(10*1024*1024, time.Now().Add(-2*time.Second))
+	// Allow slack for the time elapsed while the test runs
+	if rate < 4*1024*1024 || rate > 6*1024*1024 {
+		t.Errorf("transferRate = %d, want roughly 5 MiB/s", rate)
+	}
+}
+
+func TestHumanBytes(t *testing.T) {
+	cases := map[int64]string{
+		512:           "512 B",
+		2048:          "2.0 KiB",
+		5 << 20:       "5.0 MiB",
+		3 << 30:       "3.0 GiB",
+		1536 * 1024:   "1.5 MiB",
+		1<<40 + 1<<39: "1.5 TiB",
+	}
+	for in, want := range cases {
+		if got := humanBytes(in); got != want {
+			t.Errorf("humanBytes(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
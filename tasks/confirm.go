@@ -0,0 +1,73 @@
+package tasks
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/manifoldco/promptui"
+	"github.com/zechtz/nyatictl/config"
+)
+
+// ConfirmDecision is the operator's choice at a task's confirmation gate.
+type ConfirmDecision int
+
+const (
+	ConfirmProceed ConfirmDecision = iota // Run the task
+	ConfirmSkip                           // Leave the task out and continue the run
+	ConfirmAbort                          // Stop the run before the task executes
+)
+
+// ConfirmFunc decides what happens at the confirmation gate of one task with
+// confirm set.
+type ConfirmFunc func(task config.Task) ConfirmDecision
+
+var (
+	confirmLock sync.Mutex
+	confirmFunc ConfirmFunc // nil selects the interactive prompt
+)
+
+// SetConfirmFunc installs the decider used for confirm-gated tasks. Passing
+// nil restores the default interactive prompt. Callers that legitimately run
+// unattended (the web deploy path, --yes) install AutoProceed; tests install
+// scripted deciders.
+func SetConfirmFunc(f ConfirmFunc) {
+	confirmLock.Lock()
+	defer confirmLock.Unlock()
+	confirmFunc = f
+}
+
+// AutoProceed is the decider for runs explicitly allowed to pass every
+// confirmation gate without a human.
+func AutoProceed(config.Task) ConfirmDecision { return ConfirmProceed }
+
+// confirmTask runs the installed decider for one gated task.
+func confirmTask(task config.Task) ConfirmDecision {
+	confirmLock.Lock()
+	f := confirmFunc
+	confirmLock.Unlock()
+	if f == nil {
+		f = promptConfirm
+	}
+	return f(task)
+}
+
+// promptConfirm asks the operator on the terminal. A failed prompt — EOF,
+// interrupt, or no terminal at all — aborts: an unattended run must not slip
+// through a gate that exists to require a human, so non-interactive callers
+// have to opt in via --yes instead.
+func promptConfirm(task config.Task) ConfirmDecision {
+	label := task.ConfirmMessage
+	if label == "" {
+		label = fmt.Sprintf("Task '%s' requires confirmation", task.Name)
+	}
+
+	sel := promptui.Select{
+		Label: label,
+		Items: []string{"Proceed", "Skip", "Abort"},
+	}
+	choice, _, err := sel.Run()
+	if err != nil {
+		return ConfirmAbort
+	}
+	return []ConfirmDecision{ConfirmProceed, ConfirmSkip, ConfirmAbort}[choice]
+}
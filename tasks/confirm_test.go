@@ -0,0 +1,133 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/ssh"
+)
+
+// confirmTestManager builds a manager whose tasks run through the local
+// plugin runner, so confirmation gates are exercised without SSH.
+func confirmTestManager(t *testing.T) *ssh.Manager {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeFakePlugin(t, dir, "step", `cat > /dev/null
+echo '{"code":0,"output":"ok"}'`)
+
+	return &ssh.Manager{
+		Clients: []*ssh.Client{{Name: "server1"}},
+		Config:  &config.Config{PluginsDir: dir},
+	}
+}
+
+// scriptConfirm installs a decider that returns the scripted decisions in
+// order and records which tasks reached the gate.
+func scriptConfirm(t *testing.T, decisions ...ConfirmDecision) *[]string {
+	t.Helper()
+
+	var asked []string
+	next := 0
+	SetConfirmFunc(func(task config.Task) ConfirmDecision {
+		asked = append(asked, task.Name)
+		if next >= len(decisions) {
+			t.Fatalf("unexpected confirmation prompt for task %s", task.Name)
+		}
+		d := decisions[next]
+		next++
+		return d
+	})
+	t.Cleanup(func() { SetConfirmFunc(nil) })
+	return &asked
+}
+
+func gatedTask(name string) config.Task {
+	return config.Task{Name: name, Type: config.TaskTypePlugin, Plugin: "step", Confirm: true}
+}
+
+func TestConfirmGateProceeds(t *testing.T) {
+	m := confirmTestManager(t)
+	asked := scriptConfirm(t, ConfirmProceed)
+
+	results, err := Run(m, []config.Task{gatedTask("migrate")}, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(*asked) != 1 || (*asked)[0] != "migrate" {
+		t.Errorf("gates asked = %v, want [migrate]", *asked)
+	}
+	if len(results) != 1 || !results[0].Success || results[0].Skipped {
+		t.Errorf("results = %+v, want one executed success", results)
+	}
+}
+
+func TestConfirmGateSkipContinuesTheRun(t *testing.T) {
+	m := confirmTestManager(t)
+	scriptConfirm(t, ConfirmSkip)
+
+	taskList := []config.Task{
+		gatedTask("migrate"),
+		{Name: "restart", Type: config.TaskTypePlugin, Plugin: "step"},
+	}
+	results, err := Run(m, taskList, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if !results[0].Skipped || results[0].Task != "migrate" {
+		t.Errorf("first result = %+v, want the gated task recorded as skipped", results[0])
+	}
+	if results[1].Task != "restart" || !results[1].Success || results[1].Skipped {
+		t.Errorf("second result = %+v, want the ungated task executed", results[1])
+	}
+}
+
+func TestConfirmGateAbortStopsBeforeTheTask(t *testing.T) {
+	m := confirmTestManager(t)
+	asked := scriptConfirm(t, ConfirmAbort)
+
+	taskList := []config.Task{
+		gatedTask("migrate"),
+		{Name: "restart", Type: config.TaskTypePlugin, Plugin: "step"},
+	}
+	results, err := Run(m, taskList, false)
+	if err == nil || !strings.Contains(err.Error(), "migrate") {
+		t.Fatalf("err = %v, want an abort naming the gated task", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("results = %+v, want nothing executed after the abort", results)
+	}
+	if len(*asked) != 1 {
+		t.Errorf("gates asked = %v, want only the aborting gate", *asked)
+	}
+}
+
+func TestUngatedTasksNeverPrompt(t *testing.T) {
+	m := confirmTestManager(t)
+	asked := scriptConfirm(t)
+
+	if _, err := Run(m, []config.Task{{Name: "restart", Type: config.TaskTypePlugin, Plugin: "step"}}, false); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(*asked) != 0 {
+		t.Errorf("gates asked = %v, want none for an ungated task", *asked)
+	}
+}
+
+func TestAutoProceedPassesEveryGate(t *testing.T) {
+	m := confirmTestManager(t)
+	SetConfirmFunc(AutoProceed)
+	t.Cleanup(func() { SetConfirmFunc(nil) })
+
+	results, err := Run(m, []config.Task{gatedTask("migrate")}, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 1 || !results[0].Success || results[0].Skipped {
+		t.Errorf("results = %+v, want the gated task executed", results)
+	}
+}
@@ -0,0 +1,155 @@
+package tasks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/logger"
+	"github.com/zechtz/nyatictl/ssh"
+)
+
+// ResourceUsage captures per-task resource consumption measured on the remote
+// host via GNU time (/usr/bin/time -v).
+type ResourceUsage struct {
+	UserTimeSec   float64 `json:"user_time_sec"`   // CPU time spent in user mode
+	SystemTimeSec float64 `json:"system_time_sec"` // CPU time spent in kernel mode
+	MaxRSSKB      int64   `json:"max_rss_kb"`      // Maximum resident set size in kilobytes
+	FSInputs      int64   `json:"fs_inputs"`       // File system input operations
+	FSOutputs     int64   `json:"fs_outputs"`      // File system output operations
+}
+
+// timePath is the location of GNU time on the remote host. The shell builtin
+// `time` does not support -v, so the binary is required.
+const timePath = "/usr/bin/time"
+
+// probeTimeV checks whether GNU time is available on the remote host.
+// Hosts without it degrade gracefully: metrics are skipped and a single
+// warning is logged for the host.
+//
+// Parameters:
+//   - c: connected SSH client to probe
+//   - debug: whether debug output is enabled
+//
+// Returns:
+//   - bool: true if /usr/bin/time supports -v on the host
+func probeTimeV(c *ssh.Client, debug bool) bool {
+	probe := config.Task{
+		Name: "metrics-probe",
+		Cmd:  fmt.Sprintf("%s -v true", timePath),
+	}
+	code, _, err := c.Exec(probe, debug)
+	return err == nil && code == 0
+}
+
+// wrapWithTimeV wraps a task command so it runs under /usr/bin/time -v.
+// The original command is passed to a subshell so pipelines and compound
+// commands are measured as a whole.
+func wrapWithTimeV(cmd string) string {
+	return fmt.Sprintf("%s -v sh -c %s", timePath, shellQuote(cmd))
+}
+
+// shellQuote single-quotes a string for safe embedding in a shell command.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// parseTimeV extracts resource usage from combined command output produced by
+// /usr/bin/time -v and returns the output with the measurement lines removed,
+// so task output shown to the user stays clean.
+//
+// Parameters:
+//   - output: combined stdout/stderr from the remote command
+//
+// Returns:
+//   - *ResourceUsage: parsed metrics, or nil if no measurement lines were found
+//   - string: the output stripped of /usr/bin/time lines
+func parseTimeV(output string) (*ResourceUsage, string) {
+	var usage ResourceUsage
+	found := false
+
+	var kept []string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		key, value, ok := strings.Cut(trimmed, ": ")
+		if !ok {
+			kept = append(kept, line)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(key, "User time"):
+			usage.UserTimeSec, _ = strconv.ParseFloat(value, 64)
+			found = true
+		case strings.HasPrefix(key, "System time"):
+			usage.SystemTimeSec, _ = strconv.ParseFloat(value, 64)
+			found = true
+		case strings.HasPrefix(key, "Maximum resident set size"):
+			usage.MaxRSSKB, _ = strconv.ParseInt(value, 10, 64)
+			found = true
+		case strings.HasPrefix(key, "File system inputs"):
+			usage.FSInputs, _ = strconv.ParseInt(value, 10, 64)
+			found = true
+		case strings.HasPrefix(key, "File system outputs"):
+			usage.FSOutputs, _ = strconv.ParseInt(value, 10, 64)
+			found = true
+		case isTimeVLine(key):
+			// Other /usr/bin/time -v lines we don't record; drop them
+		default:
+			kept = append(kept, line)
+		}
+	}
+
+	if !found {
+		return nil, output
+	}
+	return &usage, strings.Join(kept, "\n")
+}
+
+// isTimeVLine reports whether a "key" from a `key: value` line belongs to the
+// /usr/bin/time -v report rather than to the task's own output.
+func isTimeVLine(key string) bool {
+	timeVKeys := []string{
+		"Command being timed",
+		"Percent of CPU this job got",
+		"Elapsed (wall clock) time",
+		"Average shared text size",
+		"Average unshared data size",
+		"Average stack size",
+		"Average total size",
+		"Average resident set size",
+		"Major (requiring I/O) page faults",
+		"Minor (reclaiming a frame) page faults",
+		"Voluntary context switches",
+		"Involuntary context switches",
+		"Swaps",
+		"Socket messages sent",
+		"Socket messages received",
+		"Signals delivered",
+		"Page size (bytes)",
+		"Exit status",
+	}
+	for _, k := range timeVKeys {
+		if strings.HasPrefix(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// logUsage emits a single structured log line summarizing a task's resource
+// consumption on a host.
+func logUsage(taskName, hostName string, usage *ResourceUsage) {
+	logger.Info(fmt.Sprintf("📊 %s@%s: user %.2fs, sys %.2fs, max RSS %d KB, io %d/%d",
+		taskName, hostName, usage.UserTimeSec, usage.SystemTimeSec, usage.MaxRSSKB, usage.FSInputs, usage.FSOutputs),
+		map[string]interface{}{
+			"task":            taskName,
+			"host":            hostName,
+			"user_time_sec":   usage.UserTimeSec,
+			"system_time_sec": usage.SystemTimeSec,
+			"max_rss_kb":      usage.MaxRSSKB,
+			"fs_inputs":       usage.FSInputs,
+			"fs_outputs":      usage.FSOutputs,
+		})
+}
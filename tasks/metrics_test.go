@@ -0,0 +1,75 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTimeV(t *testing.T) {
+	output := `build output line 1
+build output line 2
+	Command being timed: "sh -c make build"
+	User time (seconds): 1.52
+	System time (seconds): 0.34
+	Percent of CPU this job got: 98%
+	Elapsed (wall clock) time (h:mm:ss or m:ss): 0:01.89
+	Maximum resident set size (kbytes): 204800
+	File system inputs: 1024
+	File system outputs: 2048
+	Exit status: 0`
+
+	usage, cleaned := parseTimeV(output)
+	if usage == nil {
+		t.Fatal("parseTimeV() returned nil usage for valid /usr/bin/time output")
+	}
+
+	if usage.UserTimeSec != 1.52 {
+		t.Errorf("UserTimeSec = %v, want 1.52", usage.UserTimeSec)
+	}
+	if usage.SystemTimeSec != 0.34 {
+		t.Errorf("SystemTimeSec = %v, want 0.34", usage.SystemTimeSec)
+	}
+	if usage.MaxRSSKB != 204800 {
+		t.Errorf("MaxRSSKB = %v, want 204800", usage.MaxRSSKB)
+	}
+	if usage.FSInputs != 1024 {
+		t.Errorf("FSInputs = %v, want 1024", usage.FSInputs)
+	}
+	if usage.FSOutputs != 2048 {
+		t.Errorf("FSOutputs = %v, want 2048", usage.FSOutputs)
+	}
+
+	if !strings.Contains(cleaned, "build output line 1") || !strings.Contains(cleaned, "build output line 2") {
+		t.Errorf("cleaned output lost task output: %q", cleaned)
+	}
+	if strings.Contains(cleaned, "Maximum resident set size") || strings.Contains(cleaned, "Command being timed") {
+		t.Errorf("cleaned output still contains /usr/bin/time lines: %q", cleaned)
+	}
+}
+
+func TestParseTimeVWithoutMetrics(t *testing.T) {
+	output := "plain command output\nno measurements here"
+	usage, cleaned := parseTimeV(output)
+	if usage != nil {
+		t.Errorf("parseTimeV() = %+v, want nil for output without measurements", usage)
+	}
+	if cleaned != output {
+		t.Errorf("parseTimeV() modified output without measurements: %q", cleaned)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"echo hello", "'echo hello'"},
+		{"echo 'quoted'", `'echo '\''quoted'\'''`},
+		{"", "''"},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.input); got != tt.want {
+			t.Errorf("shellQuote(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package tasks
+
+// TaskOutcome is the compact per-task, per-host outcome stored in deployment
+// history. It carries just enough of a Result to reconstruct which task/host
+// pairs succeeded, without the output and timing payload of the full Result.
+type TaskOutcome struct {
+	Task    string `json:"task"`    // Task name
+	Host    string `json:"host"`    // Host alias the task ran on
+	Success bool   `json:"success"` // Whether the task succeeded on that host
+}
+
+// CompletedSet marks task/host pairs that already succeeded in an earlier
+// run. RunSkipping consults it to leave those executions out of a resumed
+// deploy.
+type CompletedSet map[string]map[string]bool
+
+// Completed reports whether the named task already succeeded on the named
+// host. A nil set never skips anything.
+func (s CompletedSet) Completed(task, host string) bool {
+	return s != nil && s[task][host]
+}
+
+// Outcomes reduces full execution results to the compact form stored in
+// deployment history.
+//
+// Parameters:
+//   - results: per-task, per-host results from a run
+//
+// Returns:
+//   - []TaskOutcome: one entry per result, in the same order
+func Outcomes(results []Result) []TaskOutcome {
+	outcomes := make([]TaskOutcome, 0, len(results))
+	for _, r := range results {
+		outcomes = append(outcomes, TaskOutcome{Task: r.Task, Host: r.Host, Success: r.Success})
+	}
+	return outcomes
+}
+
+// CompletedFromOutcomes builds the set of task/host pairs a resumed run can
+// skip: exactly those that succeeded. Failed and allowed-failure tasks are
+// left out, so they run again.
+//
+// Parameters:
+//   - outcomes: stored outcomes of the original run
+//
+// Returns:
+//   - CompletedSet: the succeeded task/host pairs
+func CompletedFromOutcomes(outcomes []TaskOutcome) CompletedSet {
+	completed := make(CompletedSet)
+	for _, o := range outcomes {
+		if !o.Success {
+			continue
+		}
+		if completed[o.Task] == nil {
+			completed[o.Task] = make(map[string]bool)
+		}
+		completed[o.Task][o.Host] = true
+	}
+	return completed
+}
@@ -0,0 +1,51 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOutcomesCompactResults(t *testing.T) {
+	results := []Result{
+		{Task: "build", Host: "server1", Success: true, Output: "long output"},
+		{Task: "deploy", Host: "server1", Success: false, Code: 1},
+	}
+
+	want := []TaskOutcome{
+		{Task: "build", Host: "server1", Success: true},
+		{Task: "deploy", Host: "server1", Success: false},
+	}
+	if got := Outcomes(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("Outcomes() = %v, want %v", got, want)
+	}
+}
+
+func TestCompletedFromOutcomesKeepsOnlySuccesses(t *testing.T) {
+	outcomes := []TaskOutcome{
+		{Task: "build", Host: "server1", Success: true},
+		{Task: "build", Host: "server2", Success: true},
+		{Task: "deploy", Host: "server1", Success: true},
+		{Task: "deploy", Host: "server2", Success: false},
+	}
+
+	completed := CompletedFromOutcomes(outcomes)
+	if !completed.Completed("build", "server1") || !completed.Completed("build", "server2") {
+		t.Error("build succeeded on both hosts and should be skippable")
+	}
+	if !completed.Completed("deploy", "server1") {
+		t.Error("deploy succeeded on server1 and should be skippable there")
+	}
+	if completed.Completed("deploy", "server2") {
+		t.Error("deploy failed on server2 and must run again")
+	}
+	if completed.Completed("migrate", "server1") {
+		t.Error("a task that never ran must not be marked completed")
+	}
+}
+
+func TestCompletedSetNilNeverSkips(t *testing.T) {
+	var completed CompletedSet
+	if completed.Completed("build", "server1") {
+		t.Error("a nil set must not skip anything")
+	}
+}
@@ -0,0 +1,113 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+// defaultPluginsDir is where plugin executables are looked up when the config
+// does not set plugins_dir.
+const defaultPluginsDir = "./plugins"
+
+// PluginRequest is the JSON document written to a plugin's stdin. It carries
+// the full task definition plus the host the task is being run for, so a
+// plugin can make its own connection or act locally.
+type PluginRequest struct {
+	Task config.Task `json:"task"`
+	Host string      `json:"host"`
+}
+
+// PluginResult is the JSON document a plugin must print to stdout. The code
+// is compared against the task's expect value exactly like a shell exit code.
+type PluginResult struct {
+	Code   int    `json:"code"`
+	Output string `json:"output"`
+}
+
+// pluginsDir returns the configured plugins directory, or empty when no
+// config is attached (resolvePlugin then applies the default).
+func pluginsDir(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.PluginsDir
+}
+
+// resolvePlugin maps a plugin name to an executable inside the plugins
+// directory. Names containing path separators are rejected at config load
+// time, so this only has to check that the file exists and is executable.
+//
+// Parameters:
+//   - dir: plugins directory from the config (default when empty)
+//   - name: plugin executable name
+//
+// Returns:
+//   - string: path to the plugin executable
+//   - error: if the plugin is missing or not executable
+func resolvePlugin(dir, name string) (string, error) {
+	if dir == "" {
+		dir = defaultPluginsDir
+	}
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("plugin '%s' not found in %s: %v", name, dir, err)
+	}
+	if info.IsDir() || info.Mode().Perm()&0111 == 0 {
+		return "", fmt.Errorf("plugin '%s' in %s is not executable", name, dir)
+	}
+	return path, nil
+}
+
+// runPlugin executes a plugin task: the external executable receives the
+// PluginRequest on stdin and must reply with a PluginResult on stdout. A
+// plugin that exits non-zero or prints something other than a result document
+// is a protocol failure, distinct from the task itself failing (which the
+// plugin reports through the result code).
+//
+// Parameters:
+//   - dir: plugins directory from the config
+//   - task: the plugin task being executed
+//   - host: host alias the task is running for
+//
+// Returns:
+//   - int: task exit code reported by the plugin
+//   - string: task output reported by the plugin
+//   - error: if the plugin could not be run or broke the protocol
+func runPlugin(dir string, task config.Task, host string) (int, string, error) {
+	path, err := resolvePlugin(dir, task.Plugin)
+	if err != nil {
+		return -1, "", err
+	}
+
+	input, err := json.Marshal(PluginRequest{Task: task, Host: host})
+	if err != nil {
+		return -1, "", fmt.Errorf("failed to encode plugin request: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		detail := strings.TrimSpace(stderr.String())
+		if detail != "" {
+			return -1, "", fmt.Errorf("plugin '%s' failed: %v: %s", task.Plugin, err, detail)
+		}
+		return -1, "", fmt.Errorf("plugin '%s' failed: %v", task.Plugin, err)
+	}
+
+	var result PluginResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return -1, "", fmt.Errorf("plugin '%s' returned an invalid result: %v", task.Plugin, err)
+	}
+	return result.Code, result.Output, nil
+}
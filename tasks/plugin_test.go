@@ -0,0 +1,93 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+)
+
+// writeFakePlugin creates an executable plugin script in dir.
+func writeFakePlugin(t *testing.T, dir, name, script string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("failed to write fake plugin: %v", err)
+	}
+}
+
+func TestRunPluginPassesTaskAndReadsResult(t *testing.T) {
+	dir := t.TempDir()
+	// The plugin proves it received the task JSON on stdin by checking for
+	// the task name before replying
+	writeFakePlugin(t, dir, "notify", `IN=$(cat)
+case "$IN" in
+*release-db*) echo '{"code":0,"output":"saw the task"}' ;;
+*) echo '{"code":1,"output":"no task on stdin"}' ;;
+esac`)
+
+	task := config.Task{Name: "release-db", Type: config.TaskTypePlugin, Plugin: "notify"}
+	code, output, err := runPlugin(dir, task, "server1")
+	if err != nil {
+		t.Fatalf("runPlugin failed: %v", err)
+	}
+	if code != 0 || output != "saw the task" {
+		t.Errorf("runPlugin = (%d, %q), want (0, \"saw the task\")", code, output)
+	}
+}
+
+func TestRunPluginReportsTaskFailureCode(t *testing.T) {
+	dir := t.TempDir()
+	writeFakePlugin(t, dir, "failing", `cat > /dev/null
+echo '{"code":3,"output":"task blew up"}'`)
+
+	task := config.Task{Name: "deploy", Type: config.TaskTypePlugin, Plugin: "failing"}
+	code, output, err := runPlugin(dir, task, "server1")
+	if err != nil {
+		t.Fatalf("a failing task is not a protocol error: %v", err)
+	}
+	if code != 3 || output != "task blew up" {
+		t.Errorf("runPlugin = (%d, %q), want (3, \"task blew up\")", code, output)
+	}
+}
+
+func TestRunPluginRejectsInvalidResult(t *testing.T) {
+	dir := t.TempDir()
+	writeFakePlugin(t, dir, "chatty", `cat > /dev/null
+echo 'this is not json'`)
+
+	task := config.Task{Name: "deploy", Type: config.TaskTypePlugin, Plugin: "chatty"}
+	if _, _, err := runPlugin(dir, task, "server1"); err == nil {
+		t.Error("a plugin printing garbage should be a protocol error")
+	}
+}
+
+func TestRunPluginSurfacesPluginCrash(t *testing.T) {
+	dir := t.TempDir()
+	writeFakePlugin(t, dir, "crashing", `cat > /dev/null
+echo 'config missing' >&2
+exit 2`)
+
+	task := config.Task{Name: "deploy", Type: config.TaskTypePlugin, Plugin: "crashing"}
+	_, _, err := runPlugin(dir, task, "server1")
+	if err == nil || !strings.Contains(err.Error(), "config missing") {
+		t.Errorf("plugin crash error = %v, want stderr detail included", err)
+	}
+}
+
+func TestResolvePluginRequiresExecutable(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := resolvePlugin(dir, "absent"); err == nil {
+		t.Error("missing plugin should not resolve")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "plain"), []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := resolvePlugin(dir, "plain"); err == nil {
+		t.Error("non-executable file should not resolve as a plugin")
+	}
+}
@@ -10,29 +10,162 @@ import (
 	"github.com/zechtz/nyatictl/config"
 	"github.com/zechtz/nyatictl/logger"
 	"github.com/zechtz/nyatictl/ssh"
+	"github.com/zechtz/nyatictl/term"
 )
 
+// Result captures the outcome of a single task execution on a single host.
+// It is the structured counterpart to the human-readable status lines.
+type Result struct {
+	Task     string         `json:"task"`            // Task name
+	Host     string         `json:"host"`            // Host alias the task ran on
+	Code     int            `json:"code"`            // Exit code returned by the command
+	Success  bool           `json:"success"`         // Whether the exit code matched the expectation
+	Output   string         `json:"output"`          // Combined stdout/stderr from the command
+	Duration time.Duration  `json:"duration"`        // Wall-clock execution time
+	Usage    *ResourceUsage `json:"usage,omitempty"` // Remote resource usage (when metrics are enabled)
+
+	// Precise execution window for performance analysis; Duration is always
+	// EndedAt minus StartedAt.
+	StartedAt time.Time `json:"started_at"` // When the command started on the host
+	EndedAt   time.Time `json:"ended_at"`   // When the command finished
+
+	// AllowedFailure marks a failure on a task with allow_failure set: the
+	// task did fail (Success is false), but the run was allowed to continue.
+	AllowedFailure bool `json:"allowed_failure,omitempty"`
+
+	// Skipped marks a task/host pair left out of a resumed run because it
+	// already succeeded; Success is true but no command was executed.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
 // Run concurrently executes a list of deployment tasks across multiple SSH clients.
 //
 // For each task, it spins up one goroutine per client to execute the command in parallel.
 // Results are collected, and optional retry logic is supported for failed executions.
 // Debug output and task-specific output can be conditionally displayed based on task config.
 //
+// When the config enables metrics, each task is run under /usr/bin/time -v on
+// hosts that provide it, and per-task resource usage is attached to the
+// results. Hosts without GNU time get a single warning and run unmeasured.
+//
 // Parameters:
 //   - m: A reference to the SSH Manager, which contains all connected clients
 //   - tasks: List of config.Task objects to execute
 //   - debug: Enables debug logging if set to true
 //
 // Returns:
+//   - []Result: structured per-task, per-host execution results
 //   - error: Returns on the first encountered failure (aggregating errors could be future enhancement)
-func Run(m *ssh.Manager, tasks []config.Task, debug bool) error {
+func Run(m *ssh.Manager, tasks []config.Task, debug bool) ([]Result, error) {
+	return RunSkipping(m, tasks, debug, nil)
+}
+
+// RunSkipping behaves like Run but leaves out task/host pairs present in
+// completed, so a resumed deploy re-runs only what failed or never ran. A nil
+// set skips nothing, which is the plain Run behavior.
+//
+// Parameters:
+//   - m: A reference to the SSH Manager, which contains all connected clients
+//   - tasks: List of config.Task objects to execute
+//   - debug: Enables debug logging if set to true
+//   - completed: task/host pairs that already succeeded and must not re-run
+//
+// Returns:
+//   - []Result: structured per-task, per-host execution results
+//   - error: Returns on the first encountered failure
+func RunSkipping(m *ssh.Manager, tasks []config.Task, debug bool, completed CompletedSet) ([]Result, error) {
 	var wg sync.WaitGroup
 
 	// Buffered channel to capture possible errors
 	errChan := make(chan error, len(m.Clients)*len(tasks))
 
+	// Structured results, guarded by their own mutex
+	var resultsLock sync.Mutex
+	var results []Result
+
+	// Gather host facts up front when enabled, so ${fact.*} placeholders
+	// resolve in every task that follows
+	if m.Config != nil && m.Config.GatherFacts {
+		for _, client := range m.Clients {
+			if _, err := client.GatherFacts(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Probe each host once per run for GNU time when metrics are enabled
+	metricsEnabled := m.Config != nil && m.Config.Metrics
+	hostHasTimeV := make(map[string]bool, len(m.Clients))
+	if metricsEnabled {
+		for _, client := range m.Clients {
+			available := probeTimeV(client, debug)
+			hostHasTimeV[client.Name] = available
+			if !available {
+				warnMsg := fmt.Sprintf("⚠️  %s: %s not available, resource metrics disabled for this host", client.Name, timePath)
+				logger.Console(logger.WARN, warnMsg)
+			}
+		}
+	}
+
+	// Resolve ${release_version} now, against the session's single value, so
+	// every task in the run addresses the same release directory
+	if m.Config != nil {
+		config.ApplyReleaseVersion(tasks, m.Config.ReleaseVersion)
+	}
+
+	// Resolve per-task environment overrides once per distinct name, so a
+	// missing environment fails before anything runs
+	taskEnvs := make(map[string]map[string]string)
+	for _, task := range tasks {
+		if task.Environment == "" {
+			continue
+		}
+		if _, ok := taskEnvs[task.Environment]; ok {
+			continue
+		}
+		vars, err := m.ResolveEnv(task.Environment)
+		if err != nil {
+			return nil, fmt.Errorf("task '%s': %v", task.Name, err)
+		}
+		taskEnvs[task.Environment] = vars
+	}
+
+	// In quiet mode the spinner and per-task success chatter stay off the
+	// console; failures are still printed explicitly below
+	quiet := !logger.ConsoleAllowed(logger.INFO)
+
 	// Iterate over each task in the execution plan
 	for _, task := range tasks {
+		// Confirmation gates pause here, before anything is launched for the
+		// task. A skip is recorded per host so the summary shows it; an abort
+		// ends the run with the results gathered so far.
+		if task.Confirm {
+			switch confirmTask(task) {
+			case ConfirmSkip:
+				skipMsg := fmt.Sprintf("⏭  %s: Skipped at confirmation gate", task.Name)
+				logger.Log(skipMsg)
+				if !quiet {
+					fmt.Println(skipMsg)
+				}
+				for _, client := range m.Clients {
+					results = append(results, Result{
+						Task:    task.Name,
+						Host:    client.Name,
+						Success: true,
+						Skipped: true,
+					})
+				}
+				continue
+			case ConfirmAbort:
+				abortMsg := fmt.Sprintf("🛑 %s: Run aborted at confirmation gate", task.Name)
+				logger.Log(abortMsg)
+				if !quiet {
+					fmt.Println(term.Red(abortMsg))
+				}
+				return results, fmt.Errorf("run aborted at confirmation gate before task '%s'", task.Name)
+			}
+		}
+
 		wg.Add(len(m.Clients)) // Add to waitgroup: one for each client
 
 		// Create a spinner (animated loading indicator) for visual feedback
@@ -44,32 +177,156 @@ func Run(m *ssh.Manager, tasks []config.Task, debug bool) error {
 			go func(c *ssh.Client, t config.Task) {
 				defer wg.Done()
 
-				s.Start()
+				// Resumed runs leave out what already succeeded on this host
+				if completed.Completed(t.Name, c.Name) {
+					skipMsg := fmt.Sprintf("⏭  %s@%s: Skipped (succeeded in previous run)", t.Name, c.Name)
+					logger.Log(skipMsg)
+					if !quiet {
+						fmt.Println(skipMsg)
+					}
+					// Record the skip so the end-of-run summary can count it
+					// and a later resume still sees the pair as completed
+					resultsLock.Lock()
+					results = append(results, Result{
+						Task:    t.Name,
+						Host:    c.Name,
+						Success: true,
+						Skipped: true,
+					})
+					resultsLock.Unlock()
+					return
+				}
+
+				if !quiet {
+					s.Start()
+				}
 				logger.Log(s.Prefix)
 
-				// Execute the command over SSH
-				code, output, err := c.Exec(t, debug)
+				// Install the task's environment override for this execution
+				if t.Environment != "" {
+					c.SetTaskEnv(taskEnvs[t.Environment])
+					defer c.SetTaskEnv(nil)
+				}
+
+				// Wrap the command under GNU time when metrics are captured;
+				// plugin tasks run locally and upload tasks run no command,
+				// so neither is measured remotely
+				execTask := t
+				measured := metricsEnabled && hostHasTimeV[c.Name] &&
+					t.Type != config.TaskTypePlugin && t.Type != config.TaskTypeUpload
+				if measured {
+					execTask.Cmd = wrapWithTimeV(t.Cmd)
+				}
+
+				// Execute the command over SSH, hand plugin tasks to the
+				// external runner, or stream upload tasks to the host
+				start := time.Now()
+				var code int
+				var output string
+				var err error
+				switch t.Type {
+				case config.TaskTypePlugin:
+					code, output, err = runPlugin(pluginsDir(m.Config), execTask, c.Name)
+				case config.TaskTypeUpload:
+					code, output, err = c.Upload(execTask, debug)
+				default:
+					code, output, err = c.Exec(execTask, debug)
+				}
+				end := time.Now()
+				duration := end.Sub(start)
+
+				// Extract resource usage and strip measurement noise from output
+				var usage *ResourceUsage
+				if measured {
+					usage, output = parseTimeV(output)
+					if usage != nil {
+						logUsage(t.Name, c.Name, usage)
+					}
+				}
+
+				recordResult := func(success, allowed bool) {
+					resultsLock.Lock()
+					results = append(results, Result{
+						Task:           t.Name,
+						Host:           c.Name,
+						Code:           code,
+						Success:        success,
+						Output:         output,
+						Duration:       duration,
+						Usage:          usage,
+						AllowedFailure: allowed,
+						StartedAt:      start,
+						EndedAt:        end,
+					})
+					resultsLock.Unlock()
+
+					// Structured timing entry for the audit trail
+					logger.LogWithLevel(logger.INFO, fmt.Sprintf("task %s@%s finished", t.Name, c.Name), map[string]interface{}{
+						"started_at":  start.Format(time.RFC3339Nano),
+						"duration_ms": duration.Milliseconds(),
+						"success":     success,
+					})
+				}
+
 				if err != nil {
+					if t.AllowFailure {
+						warnMsg := fmt.Sprintf("⚠️  %s@%s: Failed (allowed)", t.Name, c.Name)
+						s.FinalMSG = term.Yellow(warnMsg) + "\n"
+						logger.LogWithLevel(logger.WARN, warnMsg, nil)
+						s.Stop()
+						if quiet {
+							fmt.Println(term.Yellow(warnMsg))
+						}
+
+						recordResult(false, true)
+						return
+					}
+
 					errMsg := fmt.Sprintf("❌ %s@%s: Failed", t.Name, c.Name)
-					s.FinalMSG = errMsg + "\n"
+					s.FinalMSG = term.Red(errMsg) + "\n"
 					logger.Log(errMsg)
 					s.Stop()
+					if quiet {
+						fmt.Println(term.Red(errMsg))
+					}
 
+					recordResult(false, false)
 					errChan <- fmt.Errorf("%s@%s: %v", c.Name, c.Server.Host, err)
 					return
 				}
 
 				// If exit code does not match expected, handle retry or log failure
 				if code != t.Expect {
+					// Non-critical tasks warn and let the run continue; the
+					// failure stays visible in the results and the summary
+					if t.AllowFailure {
+						warnMsg := fmt.Sprintf("⚠️  %s@%s: Failed (allowed, code %d)", t.Name, c.Name, code)
+						s.FinalMSG = term.Yellow(warnMsg) + "\n"
+						logger.LogWithLevel(logger.WARN, warnMsg, nil)
+						s.Stop()
+						if quiet {
+							fmt.Println(term.Yellow(warnMsg))
+						}
+						if debug || t.Output {
+							logger.Console(logger.WARN, output)
+						}
+
+						recordResult(false, true)
+						return
+					}
+
 					errMsg := fmt.Sprintf("❌ %s@%s: Failed (code %d)", t.Name, c.Name, code)
-					s.FinalMSG = errMsg + "\n"
+					s.FinalMSG = term.Red(errMsg) + "\n"
 					logger.Log(errMsg)
 					s.Stop()
+					if quiet {
+						fmt.Println(term.Red(errMsg))
+					}
 
-					// Display output if necessary
+					// Display output if necessary; failure output is error
+					// context, so it is printed even in quiet mode
 					if debug || t.Output || t.Retry {
-						logger.Log(output)
-						fmt.Println(output)
+						logger.Console(logger.ERROR, output)
 					}
 
 					// Prompt user for retry if the task allows it
@@ -83,33 +340,68 @@ func Run(m *ssh.Manager, tasks []config.Task, debug bool) error {
 							_, _, err = c.Exec(t, debug)
 							if err == nil && code == t.Expect {
 								successMsg := fmt.Sprintf("🎉 %s@%s: Succeeded after retry", t.Name, c.Name)
-								s.FinalMSG = successMsg + "\n"
+								s.FinalMSG = term.Green(successMsg) + "\n"
 								logger.Log(successMsg)
 							}
 						}
 					}
 
+					recordResult(false, false)
 					errChan <- fmt.Errorf("task %s failed on %s", t.Name, c.Name)
 					return
 				}
 
+				// The exit code alone can lie: a command may exit 0 while its
+				// output reports a problem. Output assertions catch that case.
+				if assertErr := t.CheckOutput(output); assertErr != nil {
+					if t.AllowFailure {
+						warnMsg := fmt.Sprintf("⚠️  %s@%s: Failed (allowed, %v)", t.Name, c.Name, assertErr)
+						s.FinalMSG = term.Yellow(warnMsg) + "\n"
+						logger.LogWithLevel(logger.WARN, warnMsg, nil)
+						s.Stop()
+						if quiet {
+							fmt.Println(term.Yellow(warnMsg))
+						}
+						if debug || t.Output {
+							logger.Console(logger.WARN, output)
+						}
+
+						recordResult(false, true)
+						return
+					}
+
+					errMsg := fmt.Sprintf("❌ %s@%s: Failed (%v)", t.Name, c.Name, assertErr)
+					s.FinalMSG = term.Red(errMsg) + "\n"
+					logger.Log(errMsg)
+					s.Stop()
+					if quiet {
+						fmt.Println(term.Red(errMsg))
+					}
+
+					// The output is what failed the assertion, so it is error
+					// context and printed even in quiet mode
+					logger.Console(logger.ERROR, output)
+
+					recordResult(false, false)
+					errChan <- fmt.Errorf("task %s failed on %s: %v", t.Name, c.Name, assertErr)
+					return
+				}
+
 				// Task completed successfully
+				recordResult(true, false)
 				successMsg := fmt.Sprintf("🎉 %s@%s: Succeeded", t.Name, c.Name)
-				s.FinalMSG = successMsg + "\n"
+				s.FinalMSG = term.Green(successMsg) + "\n"
 				logger.Log(successMsg)
 				s.Stop()
 
 				// Output command logs based on flags
 				if debug || t.Output || t.Message != "" {
-					logger.Log(output)
-					fmt.Println(output)
+					logger.Console(logger.INFO, output)
 				}
 
 				// Display task message, if present
 				if t.Message != "" {
-					msg := fmt.Sprintf("📗 %s", t.Message)
-					logger.Log(msg)
-					fmt.Printf("%s\n", msg)
+					logger.Console(logger.INFO, fmt.Sprintf("📗 %s", t.Message))
 				}
 			}(client, task)
 		}
@@ -121,8 +413,8 @@ func Run(m *ssh.Manager, tasks []config.Task, debug bool) error {
 	// After all tasks are processed, check for errors
 	close(errChan)
 	for err := range errChan {
-		return err // Return first error found
+		return results, err // Return first error found
 	}
 
-	return nil
+	return results, nil
 }
@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/zechtz/nyatictl/config"
+	"github.com/zechtz/nyatictl/ssh"
+)
+
+func TestRunRecordsMonotonicTaskTimings(t *testing.T) {
+	// Plugin tasks run locally, so the whole pipeline is exercised without
+	// a live SSH connection
+	dir := t.TempDir()
+	writeFakePlugin(t, dir, "step", `cat > /dev/null
+sleep 0.02
+echo '{"code":0,"output":"ok"}'`)
+
+	m := &ssh.Manager{
+		Clients: []*ssh.Client{{Name: "server1"}},
+		Config:  &config.Config{PluginsDir: dir},
+	}
+	taskList := []config.Task{
+		{Name: "first", Type: config.TaskTypePlugin, Plugin: "step"},
+		{Name: "second", Type: config.TaskTypePlugin, Plugin: "step"},
+	}
+
+	results, err := Run(m, taskList, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	for _, r := range results {
+		if r.StartedAt.IsZero() || r.EndedAt.IsZero() {
+			t.Fatalf("%s: execution window not recorded: %+v", r.Task, r)
+		}
+		if !r.EndedAt.After(r.StartedAt) {
+			t.Errorf("%s: EndedAt %v is not after StartedAt %v", r.Task, r.EndedAt, r.StartedAt)
+		}
+		if r.Duration != r.EndedAt.Sub(r.StartedAt) {
+			t.Errorf("%s: Duration %v != EndedAt-StartedAt %v", r.Task, r.Duration, r.EndedAt.Sub(r.StartedAt))
+		}
+	}
+
+	// Tasks run in plan order, so the second window must not begin before
+	// the first one ends
+	if results[1].StartedAt.Before(results[0].EndedAt) {
+		t.Errorf("second task started %v before the first ended %v", results[1].StartedAt, results[0].EndedAt)
+	}
+}
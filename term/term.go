@@ -0,0 +1,100 @@
+// Package term centralizes terminal color handling for CLI output.
+//
+// Colors are enabled only when stdout is a TTY, and can be explicitly forced
+// on or off via SetColorMode (driven by the --no-color flag and the NO_COLOR
+// environment variable). All status-line coloring in the CLI should go through
+// this package so behavior stays consistent.
+package term
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+)
+
+// ColorMode controls whether ANSI color codes are emitted.
+type ColorMode int
+
+const (
+	// ColorAuto enables colors only when stdout is a TTY and NO_COLOR is unset.
+	ColorAuto ColorMode = iota
+	// ColorAlways forces colors on regardless of the output destination.
+	ColorAlways
+	// ColorNever disables colors entirely (e.g. --no-color, CI logs).
+	ColorNever
+)
+
+// ANSI escape sequences for the colors used in CLI status lines.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiBold   = "\x1b[1m"
+)
+
+var (
+	modeLock sync.Mutex
+	mode     = ColorAuto
+)
+
+// SetColorMode overrides the automatic color detection.
+//
+// Parameters:
+//   - m: the mode to use (ColorAuto, ColorAlways, or ColorNever)
+func SetColorMode(m ColorMode) {
+	modeLock.Lock()
+	defer modeLock.Unlock()
+	mode = m
+}
+
+// ColorsEnabled reports whether ANSI color codes will be emitted.
+//
+// In ColorAuto mode, colors are enabled only when the NO_COLOR environment
+// variable is unset and stdout is a terminal.
+func ColorsEnabled() bool {
+	modeLock.Lock()
+	defer modeLock.Unlock()
+
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	default:
+		if os.Getenv("NO_COLOR") != "" {
+			return false
+		}
+		return isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+	}
+}
+
+// colorize wraps s in the given ANSI code when colors are enabled.
+func colorize(code, s string) string {
+	if !ColorsEnabled() {
+		return s
+	}
+	return fmt.Sprintf("%s%s%s", code, s, ansiReset)
+}
+
+// Green formats s in green, used for success status lines.
+func Green(s string) string {
+	return colorize(ansiGreen, s)
+}
+
+// Red formats s in red, used for failure status lines.
+func Red(s string) string {
+	return colorize(ansiRed, s)
+}
+
+// Yellow formats s in yellow, used for warnings.
+func Yellow(s string) string {
+	return colorize(ansiYellow, s)
+}
+
+// Bold formats s in bold, used for headers.
+func Bold(s string) string {
+	return colorize(ansiBold, s)
+}
@@ -0,0 +1,65 @@
+package term
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestColorsForced(t *testing.T) {
+	SetColorMode(ColorAlways)
+	defer SetColorMode(ColorAuto)
+
+	tests := []struct {
+		name string
+		fn   func(string) string
+		code string
+	}{
+		{"green", Green, ansiGreen},
+		{"red", Red, ansiRed},
+		{"yellow", Yellow, ansiYellow},
+		{"bold", Bold, ansiBold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.fn("hello")
+			if !strings.Contains(got, tt.code) {
+				t.Errorf("%s(%q) = %q, want escape code %q present", tt.name, "hello", got, tt.code)
+			}
+			if !strings.Contains(got, ansiReset) {
+				t.Errorf("%s(%q) = %q, want reset code present", tt.name, "hello", got)
+			}
+			if !strings.Contains(got, "hello") {
+				t.Errorf("%s(%q) = %q, want original text present", tt.name, "hello", got)
+			}
+		})
+	}
+}
+
+func TestColorsDisabled(t *testing.T) {
+	SetColorMode(ColorNever)
+	defer SetColorMode(ColorAuto)
+
+	for name, fn := range map[string]func(string) string{
+		"green":  Green,
+		"red":    Red,
+		"yellow": Yellow,
+		"bold":   Bold,
+	} {
+		if got := fn("hello"); got != "hello" {
+			t.Errorf("%s(%q) = %q, want plain text when colors disabled", name, "hello", got)
+		}
+	}
+}
+
+func TestNoColorEnvDisablesAutoMode(t *testing.T) {
+	SetColorMode(ColorAuto)
+	t.Setenv("NO_COLOR", "1")
+
+	if ColorsEnabled() {
+		t.Error("ColorsEnabled() = true, want false when NO_COLOR is set")
+	}
+	if got := Green("hello"); got != "hello" {
+		t.Errorf("Green(%q) = %q, want plain text when NO_COLOR is set", "hello", got)
+	}
+}
@@ -41,6 +41,12 @@ export interface ConfigEntry {
   path: string;
   status?: "DEPLOYED" | "DRAFT" | "TEMPLATE";
   user_id?: number;
+  environment_id?: number;
+}
+
+export interface EnvironmentOption {
+  id: number;
+  name: string;
 }
 
 export type User = {
@@ -63,6 +69,7 @@ export interface ConfigDetails {
 
 const App: React.FC = () => {
   const [configs, setConfigs] = useState<ConfigEntry[]>([]);
+  const [environments, setEnvironments] = useState<EnvironmentOption[]>([]);
   const [newConfigPath, setNewConfigPath] = useState("");
   const [logs, setLogs] = useState<string[]>([]);
   const [, setUser] = useState<User>({} as User);
@@ -107,6 +114,16 @@ const App: React.FC = () => {
     }
   };
 
+  // Fetch the user's environments for the per-config environment dropdown
+  const fetchEnvironments = async () => {
+    try {
+      const response = await axios.get("/api/env/list");
+      setEnvironments(response.data.data || []);
+    } catch (error) {
+      console.error("Failed to fetch environments:", error);
+    }
+  };
+
   // Fetch tasks and hosts for a config
   const fetchTasksAndHosts = async (configPath: string) => {
     try {
@@ -134,7 +151,7 @@ const App: React.FC = () => {
   const updateConfig = (
     index: number,
     field: keyof ConfigEntry,
-    value: string,
+    value: string | number,
   ) => {
     setConfigs((prev) => {
       const newConfigs = [...prev];
@@ -294,6 +311,7 @@ const App: React.FC = () => {
   useEffect(() => {
     fetchUser();
     fetchConfigs();
+    fetchEnvironments();
   }, []);
 
   const fetchUser = () => {
@@ -333,6 +351,7 @@ const App: React.FC = () => {
             <TableHead>Owner</TableHead>
             <TableHead>Status</TableHead>
             <TableHead>Parent</TableHead>
+            <TableHead>Environment</TableHead>
             <TableHead>Hosts</TableHead>
             <TableHead>Tasks</TableHead>
             <TableHead>Actions</TableHead>
@@ -379,6 +398,37 @@ const App: React.FC = () => {
               </TableCell>
               <TableCell>None</TableCell>
 
+              <TableCell>
+                <div className="min-w-[120px]">
+                  <Select
+                    value={
+                      config.environment_id
+                        ? String(config.environment_id)
+                        : "none"
+                    }
+                    onValueChange={(value) =>
+                      updateConfig(
+                        index,
+                        "environment_id",
+                        value === "none" ? 0 : Number(value),
+                      )
+                    }
+                  >
+                    <SelectTrigger className="w-full">
+                      <SelectValue placeholder="Select environment" />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="none">None</SelectItem>
+                      {environments.map((env) => (
+                        <SelectItem key={env.id} value={String(env.id)}>
+                          {env.name}
+                        </SelectItem>
+                      ))}
+                    </SelectContent>
+                  </Select>
+                </div>
+              </TableCell>
+
               <TableCell>
                 <div className="min-w-[120px]">
                   <Select